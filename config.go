@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// reloadableSettings are the serve flags a configuration reload applies to the running process;
+// the others only take effect on the next start
+var reloadableSettings = map[string]bool{
+	"log-level":                true,
+	"stats-interval":           true,
+	"cert-check-interval":      true,
+	"cert-expiry-days":         true,
+	"alert-webhook-url":        true,
+	"defectdojo-url":           true,
+	"defectdojo-api-key":       true,
+	"breaker-threshold":        true,
+	"breaker-cooldown":         true,
+	"dockerhub-pull-reserve":   true,
+	"scan-cpus":                true,
+	"scan-memory-mb":           true,
+	"scan-pids-limit":          true,
+	"scan-timeout":             true,
+	"scan-max-containers":      true,
+	"scan-history-keep":        true,
+	"scan-report-max-age-days": true,
+}
+
+// configFile is a JSON object of serve flag values, e.g. {"cert-check-interval": "1h",
+// "scan-max-containers": 4}. Flags given on the command line take precedence over it.
+type configFile struct {
+	path     string
+	flags    *flag.FlagSet
+	explicit map[string]bool // Given on the command line
+	apply    func()          // Applies the reloadable settings to the running process
+
+	mu       sync.Mutex
+	fromFile map[string]bool // Set by the file, so removing them restores the default
+	modTime  time.Time
+}
+
+func newConfigFile(path string, flags *flag.FlagSet) *configFile {
+	c := &configFile{path: path, flags: flags, explicit: map[string]bool{}, fromFile: map[string]bool{}}
+	flags.Visit(func(f *flag.Flag) { c.explicit[f.Name] = true })
+	return c
+}
+
+// read parses the file into flag values in their canonical form, validating each of them
+func (c *configFile) read() (map[string]string, time.Time, error) {
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, time.Time{}, fmt.Errorf("%s: %w", c.path, err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for name, v := range raw {
+		f := c.flags.Lookup(name)
+		if f == nil || name == "config" {
+			return nil, time.Time{}, fmt.Errorf("unknown setting %q", name)
+		}
+		var s string
+		if err := json.Unmarshal(v, &s); err != nil {
+			s = string(bytes.TrimSpace(v)) // Numbers and booleans
+		}
+		if values[name], err = canonicalValue(f, s); err != nil {
+			return nil, time.Time{}, fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	return values, info.ModTime(), nil
+}
+
+// canonicalValue parses a value with the flag's type, without changing the flag, and formats it
+// the way the flag does
+func canonicalValue(f *flag.Flag, s string) (string, error) {
+	scratch, ok := reflect.New(reflect.TypeOf(f.Value).Elem()).Interface().(flag.Value)
+	if !ok {
+		return s, nil
+	}
+	if err := scratch.Set(s); err != nil {
+		return "", err
+	}
+	return scratch.String(), nil
+}
+
+// Load applies the file before the dashboard starts; every setting is taken
+func (c *configFile) Load() error {
+	_, err := c.update(true)
+	return err
+}
+
+// Reload rereads the file and applies the changed reloadable settings to the running process.
+// Nothing changes when the file is invalid.
+func (c *configFile) Reload() (*models.ConfigReload, error) {
+	return c.update(false)
+}
+
+func (c *configFile) update(initial bool) (*models.ConfigReload, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	values, modTime, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+	c.modTime = modTime
+	result := &models.ConfigReload{File: c.path, Changed: []string{}, RestartRequired: []string{}, Ignored: []string{}, ReloadedAt: time.Now()}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	for name := range c.fromFile {
+		if _, ok := values[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := c.flags.Lookup(name)
+		if c.explicit[name] {
+			result.Ignored = append(result.Ignored, name)
+			continue
+		}
+		value, inFile := values[name]
+		if !inFile {
+			value, _ = canonicalValue(f, f.DefValue)
+		}
+		if value == f.Value.String() {
+			continue
+		}
+		if !initial && !reloadableSettings[name] {
+			result.RestartRequired = append(result.RestartRequired, name)
+			continue
+		}
+		if err := f.Value.Set(value); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		if inFile {
+			c.fromFile[name] = true
+		} else {
+			delete(c.fromFile, name)
+		}
+		result.Changed = append(result.Changed, name)
+	}
+
+	if !initial {
+		if len(result.Changed) > 0 {
+			c.apply()
+			log.Printf("🔄 Reloaded %s: %s changed", c.path, strings.Join(result.Changed, ", "))
+		}
+		if len(result.RestartRequired) > 0 {
+			log.Printf("⚠️  %s changed in %s; restart the dashboard to apply", strings.Join(result.RestartRequired, ", "), c.path)
+		}
+	}
+	return result, nil
+}
+
+// Watch reloads the file whenever its modification time changes, checking every interval
+func (c *configFile) Watch(interval time.Duration, quit <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			info, err := os.Stat(c.path)
+			if err != nil {
+				continue
+			}
+			c.mu.Lock()
+			changed := !info.ModTime().Equal(c.modTime)
+			c.mu.Unlock()
+			if !changed {
+				continue
+			}
+			if _, err := c.Reload(); err != nil {
+				log.Printf("⚠️  Configuration not reloaded: %v", err)
+				c.mu.Lock()
+				c.modTime = info.ModTime() // Wait for the next edit instead of failing every interval
+				c.mu.Unlock()
+			}
+		case <-quit:
+			return
+		}
+	}
+}
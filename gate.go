@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/registry"
+	"docker-registry-dashboard/internal/scanner"
+)
+
+// gateVerdict is the machine-readable result printed by the gate command
+type gateVerdict struct {
+	Image      string         `json:"image"`
+	Digest     string         `json:"digest,omitempty"`
+	Passed     bool           `json:"passed"`
+	Source     string         `json:"source"` // "dashboard" (stored scan) or "local" (scanned by this command)
+	Counts     map[string]int `json:"counts"`
+	Signed     *bool          `json:"signed,omitempty"`
+	Violations []string       `json:"violations"`
+}
+
+// runGate checks an image against vulnerability limits and a signature requirement, e.g.
+// docker-registry-dashboard gate --image registry.example.com/app:1.0 --max-critical 0 --max-high 5 --require-signature
+func runGate(args []string) int {
+	flags := flag.NewFlagSet("gate", flag.ContinueOnError)
+	reg := registryFlags(flags)
+	image := flags.String("image", "", "Image reference host[:port]/repository[:tag]")
+	plainHTTP := flags.Bool("plain-http", false, "Talk to the registry of --image over http instead of https")
+	maxCritical := flags.Int("max-critical", 0, "Maximum CRITICAL findings (-1: no limit)")
+	maxHigh := flags.Int("max-high", -1, "Maximum HIGH findings (-1: no limit)")
+	requireSignature := flags.Bool("require-signature", false, "Fail unless the image has a cosign or notation signature")
+	scannerType := flags.String("scanner", "trivy", "Scanner used when the image has to be scanned: trivy or osv")
+	platform := flags.String("platform", "", "Platform of a multi-arch image (e.g. linux/arm64)")
+	server := flags.String("server", "", "Dashboard URL to read (or trigger) the scan from instead of scanning locally")
+	registryID := flags.Int64("registry-id", 0, "ID of the image's registry in the dashboard (with --server)")
+	token := flags.String("token", os.Getenv("DASHBOARD_TOKEN"), "Dashboard API token, when it requires authentication (default $DASHBOARD_TOKEN)")
+	wait := flags.Duration("wait", 10*time.Minute, "How long to wait for a scan triggered on the dashboard")
+	limits := scanLimitFlags(flags)
+	if !parseFlags(flags, args) {
+		return exitError
+	}
+	scanner.SetLimits(*limits)
+	if *image == "" {
+		return fail("--image is required")
+	}
+	if *server != "" && *registryID == 0 {
+		return fail("--registry-id is required with --server")
+	}
+
+	host, repo, tag, err := parseImageRef(*image)
+	if err != nil {
+		return fail("%v", err)
+	}
+	if reg.URL == "" {
+		scheme := "https://"
+		if *plainHTTP {
+			scheme = "http://"
+		}
+		reg.URL = scheme + host
+	}
+
+	client := registry.NewClientFromRegistry(reg)
+	verdict := gateVerdict{Image: *image, Counts: map[string]int{}, Violations: []string{}}
+	if digest, err := client.GetDigestForTag(context.Background(), repo, tag); err == nil {
+		verdict.Digest = digest
+	} else {
+		return fail("image not found: %v", err)
+	}
+
+	var findings []scanner.Finding
+	if *server != "" {
+		verdict.Source = "dashboard"
+		findings, err = dashboardFindings(*server, *token, *registryID, repo, tag, verdict.Digest, *scannerType, *platform, *wait)
+	} else {
+		verdict.Source = "local"
+		findings, err = localFindings(reg.URL, repo, tag, *scannerType, *platform)
+	}
+	if err != nil {
+		return fail("scan failed: %v", err)
+	}
+
+	for _, f := range findings {
+		verdict.Counts[strings.ToUpper(f.Severity)]++
+	}
+	if *maxCritical >= 0 && verdict.Counts["CRITICAL"] > *maxCritical {
+		verdict.Violations = append(verdict.Violations,
+			fmt.Sprintf("%d CRITICAL findings (max %d)", verdict.Counts["CRITICAL"], *maxCritical))
+	}
+	if *maxHigh >= 0 && verdict.Counts["HIGH"] > *maxHigh {
+		verdict.Violations = append(verdict.Violations,
+			fmt.Sprintf("%d HIGH findings (max %d)", verdict.Counts["HIGH"], *maxHigh))
+	}
+
+	if *requireSignature {
+		signed, err := client.HasSignature(context.Background(), repo, verdict.Digest)
+		if err != nil {
+			return fail("signature check failed: %v", err)
+		}
+		verdict.Signed = &signed
+		if !signed {
+			verdict.Violations = append(verdict.Violations, "image is not signed")
+		}
+	}
+
+	verdict.Passed = len(verdict.Violations) == 0
+	if err := writeJSON(os.Stdout, verdict); err != nil {
+		return fail("%v", err)
+	}
+	if !verdict.Passed {
+		return exitFailed
+	}
+	return exitOK
+}
+
+// parseImageRef splits host[:port]/repository[:tag] (tag defaults to latest)
+func parseImageRef(ref string) (host, repo, tag string, err error) {
+	ref = strings.TrimPrefix(strings.TrimPrefix(ref, "https://"), "http://")
+	if strings.Contains(ref, "@") {
+		return "", "", "", fmt.Errorf("digest references are not supported, use a tag: %s", ref)
+	}
+	slash := strings.Index(ref, "/")
+	if slash <= 0 {
+		return "", "", "", fmt.Errorf("image reference must include the registry host: %s", ref)
+	}
+	host, repo = ref[:slash], ref[slash+1:]
+
+	tag = "latest"
+	if colon := strings.LastIndex(repo, ":"); colon != -1 {
+		repo, tag = repo[:colon], repo[colon+1:]
+	}
+	if repo == "" || tag == "" {
+		return "", "", "", fmt.Errorf("invalid image reference: %s", ref)
+	}
+	return host, repo, tag, nil
+}
+
+func localFindings(registryURL, repo, tag, scannerType, platform string) ([]scanner.Finding, error) {
+	var report string
+	var err error
+	switch scannerType {
+	case "trivy":
+		report, _, err = scanner.ScanImage(context.Background(), registryURL, repo, tag, platform)
+	case "osv":
+		report, _, err = scanner.ScanImageOSV(context.Background(), registryURL, repo, tag, platform)
+	default:
+		return nil, fmt.Errorf("unknown scanner %q", scannerType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return scanner.ParseScannerFindings(scanner.MergeReport("", scannerType, report), scannerType), nil
+}
+
+// dashboardFindings reads the dashboard's stored scan of the image. When there is none, or it
+// belongs to another digest, a scan is triggered and polled until it finishes.
+func dashboardFindings(server, token string, registryID int64, repo, tag, digest, scannerType, platform string, wait time.Duration) ([]scanner.Finding, error) {
+	server = strings.TrimRight(server, "/")
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+	call := func(method, path string, body []byte) (*http.Response, error) {
+		req, err := http.NewRequest(method, server+path, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return httpClient.Do(req)
+	}
+
+	fetch := func() (*models.VulnerabilityScan, error) {
+		q := url.Values{}
+		q.Set("registry_id", fmt.Sprint(registryID))
+		q.Set("repository", repo)
+		q.Set("tag", tag)
+		resp, err := call("GET", "/api/v1/scan/result?"+q.Encode(), nil)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		return decodeScan(resp)
+	}
+
+	scan, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	fresh := false
+	// A scan without a digest may be of another image the tag pointed at before
+	if scan != nil && scan.Status == "completed" && scan.Digest == digest {
+		found, scanErr := scanner.ScannerOutcome(scan.Report, scannerType)
+		fresh = found && scanErr == ""
+	}
+	if !fresh && (scan == nil || scan.Status != "scanning") {
+		body, _ := json.Marshal(map[string]interface{}{
+			"registry_id": registryID,
+			"repository":  repo,
+			"tag":         tag,
+			"digest":      digest,
+			"scanner":     scannerType,
+			"platform":    platform,
+		})
+		resp, err := call("POST", "/api/v1/scan/trigger", body)
+		if err != nil {
+			return nil, err
+		}
+		scan, err = decodeScan(resp)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, "triggered scan of %s:%s on %s\n", repo, tag, server)
+	}
+
+	deadline := time.Now().Add(wait)
+	// An interrupted scan is queued again when the dashboard comes back
+	for scan.Status == "scanning" || scan.Status == "pending" || scan.Status == "interrupted" {
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("scan did not finish within %s", wait)
+		}
+		time.Sleep(5 * time.Second)
+		if scan, err = fetch(); err != nil {
+			return nil, err
+		}
+		if scan == nil {
+			return nil, fmt.Errorf("scan record disappeared")
+		}
+	}
+	if scan.Status != "completed" {
+		return nil, fmt.Errorf("scan %s", scan.Status)
+	}
+	if scan.Digest != digest {
+		return nil, fmt.Errorf("dashboard scanned %s instead of %s; the tag moved during the scan", scan.Digest, digest)
+	}
+	if found, scanErr := scanner.ScannerOutcome(scan.Report, scannerType); !found {
+		return nil, fmt.Errorf("dashboard has no %s result for the image", scannerType)
+	} else if scanErr != "" {
+		return nil, fmt.Errorf("%s", scanErr)
+	}
+	return scanner.ParseScannerFindings(scan.Report, scannerType), nil
+}
+
+func decodeScan(resp *http.Response) (*models.VulnerabilityScan, error) {
+	var body struct {
+		Success bool                      `json:"success"`
+		Data    *models.VulnerabilityScan `json:"data"`
+		Error   string                    `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("dashboard returned status %d", resp.StatusCode)
+	}
+	if !body.Success || body.Data == nil {
+		return nil, fmt.Errorf("dashboard returned status %d: %s", resp.StatusCode, body.Error)
+	}
+	return body.Data, nil
+}
@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// ErrInvalidCredentials is returned by a PasswordVerifier for a wrong username or password
+var ErrInvalidCredentials = errors.New("invalid username or password")
+
+// Authenticator identifies the caller of a request by one mechanism. The dashboard tries its
+// authenticators in the order they were registered and uses the first that recognizes the caller.
+type Authenticator interface {
+	// Name identifies the mechanism, e.g. "local", "token", "mtls"
+	Name() string
+	// Authenticate returns the caller, or nil when the request has no valid credentials for this mechanism
+	Authenticate(r *http.Request) *Identity
+}
+
+// PasswordVerifier is implemented by authenticators that accept a username and password at login
+// (local accounts, a directory); a successful login starts a local session.
+type PasswordVerifier interface {
+	Name() string
+	// VerifyPassword returns the account of valid credentials
+	VerifyPassword(username, password string) (*models.User, error)
+}
+
+// UserStore looks up the accounts, sessions and API tokens the built-in authenticators check
+type UserStore interface {
+	GetUserByName(username string) (*models.User, error)
+	GetSessionUser(tokenHash string) (*models.User, error)
+	GetAPITokenUser(tokenHash string) (*models.User, int64, error)
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header
+func bearerToken(r *http.Request) string {
+	if v, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(v)
+	}
+	return ""
+}
+
+// SessionAuthenticator accepts the session tokens of local accounts, sent as a cookie or Bearer token,
+// and checks local passwords at login
+type SessionAuthenticator struct {
+	Store  UserStore
+	Cookie string // Name of the session cookie
+}
+
+func (a *SessionAuthenticator) Name() string { return "local" }
+
+func (a *SessionAuthenticator) Authenticate(r *http.Request) *Identity {
+	token := bearerToken(r)
+	if token == "" {
+		if c, err := r.Cookie(a.Cookie); err == nil {
+			token = c.Value
+		}
+	}
+	if token == "" || strings.HasPrefix(token, APITokenPrefix) {
+		return nil
+	}
+	hash := HashToken(token)
+	user, err := a.Store.GetSessionUser(hash)
+	if err != nil {
+		return nil
+	}
+	return &Identity{User: user, SessionHash: hash, Mechanism: a.Name()}
+}
+
+func (a *SessionAuthenticator) VerifyPassword(username, password string) (*models.User, error) {
+	user, err := a.Store.GetUserByName(username)
+	if err != nil {
+		checkDummyPassword(password)
+		return nil, err
+	}
+	if !CheckPassword(user.PasswordHash, password) {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// TokenAuthenticator accepts API tokens sent as a Bearer token
+type TokenAuthenticator struct {
+	Store UserStore
+	// AllowQuery, when set, also accepts the token as ?token= on the requests it approves,
+	// for clients such as feed readers that cannot send headers
+	AllowQuery func(r *http.Request) bool
+}
+
+func (a *TokenAuthenticator) Name() string { return "token" }
+
+func (a *TokenAuthenticator) Authenticate(r *http.Request) *Identity {
+	token := bearerToken(r)
+	if token == "" && a.AllowQuery != nil && a.AllowQuery(r) {
+		token = r.URL.Query().Get("token")
+	}
+	if !strings.HasPrefix(token, APITokenPrefix) {
+		return nil
+	}
+	user, tokenID, err := a.Store.GetAPITokenUser(HashToken(token))
+	if err != nil {
+		return nil
+	}
+	return &Identity{User: user, TokenID: tokenID, Mechanism: a.Name()}
+}
+
+// ClientCertAuthenticator accepts TLS client certificates verified by the server; the certificate's
+// common name is the username of the account it signs in as
+type ClientCertAuthenticator struct {
+	Store UserStore
+}
+
+func (a *ClientCertAuthenticator) Name() string { return "mtls" }
+
+func (a *ClientCertAuthenticator) Authenticate(r *http.Request) *Identity {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	name := r.TLS.VerifiedChains[0][0].Subject.CommonName
+	if name == "" {
+		return nil
+	}
+	user, err := a.Store.GetUserByName(name)
+	if err != nil {
+		return nil
+	}
+	return &Identity{User: user, Mechanism: a.Name()}
+}
@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"context"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+type contextKey struct{}
+
+// Identity is the authenticated caller of a request
+type Identity struct {
+	User        *models.User
+	TokenID     int64  // ID of the API token used, 0 for session logins
+	SessionHash string // Hash of the session token used, "" for API tokens
+	Mechanism   string // Name of the Authenticator that identified the caller
+}
+
+// WithIdentity attaches the authenticated caller to a request context
+func WithIdentity(ctx context.Context, id *Identity) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the authenticated caller, or nil for anonymous requests
+func FromContext(ctx context.Context) *Identity {
+	id, _ := ctx.Value(contextKey{}).(*Identity)
+	return id
+}
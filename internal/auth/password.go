@@ -0,0 +1,90 @@
+// Package auth holds the credential primitives of the dashboard's local accounts:
+// password hashing, opaque session/API tokens and the authenticated user of a request,
+// and the pluggable authenticators that identify that user.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	passwordIterations = 210000
+	saltBytes          = 16
+
+	// MinPasswordLength is the shortest password accepted for local accounts
+	MinPasswordLength = 8
+
+	// APITokenPrefix marks API tokens so they can be told apart from session tokens (and found by secret scanners)
+	APITokenPrefix = "rdt_"
+)
+
+// HashPassword derives a PBKDF2-SHA256 hash encoded as "pbkdf2-sha256$iterations$salt$hash"
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, saltBytes)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := pbkdf2.Key([]byte(password), salt, passwordIterations, sha256.Size, sha256.New)
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", passwordIterations,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// CheckPassword reports whether password matches an encoded hash from HashPassword
+func CheckPassword(encoded, password string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+	got := pbkdf2.Key([]byte(password), salt, iterations, len(want), sha256.New)
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
+
+// dummyPasswordHash is checked for unknown accounts so that a failed login takes as long
+// whether or not the user exists
+var dummyPasswordHash = sync.OnceValue(func() string {
+	hash, _ := HashPassword("")
+	return hash
+})
+
+// checkDummyPassword spends the time of a CheckPassword call and discards the result
+func checkDummyPassword(password string) {
+	CheckPassword(dummyPasswordHash(), password)
+}
+
+// NewToken returns a random opaque token (with prefix) and the hash under which it is stored
+func NewToken(prefix string) (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token := prefix + base64.RawURLEncoding.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+// HashToken returns the stored form of a session or API token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
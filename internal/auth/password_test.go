@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHashPasswordRoundTrip(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "pbkdf2-sha256$210000$") {
+		t.Fatalf("hash = %q, want the pbkdf2-sha256 format", hash)
+	}
+	if !CheckPassword(hash, "correct horse") {
+		t.Error("the password does not match its own hash")
+	}
+	if CheckPassword(hash, "correct horse ") {
+		t.Error("a different password matches")
+	}
+
+	again, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again == hash {
+		t.Error("two hashes of the same password are equal; the salt is not random")
+	}
+}
+
+func TestCheckPasswordAcceptsStoredHashes(t *testing.T) {
+	// Computed independently (Python's hashlib.pbkdf2_hmac), as stored by earlier versions
+	const stored = "pbkdf2-sha256$1000$MDEyMzQ1Njc4OWFiY2RlZg$cBg8D2DungRB9k76szThf5ehfyBz991ay6PT8Srwk4M"
+	if !CheckPassword(stored, "correct horse") {
+		t.Error("a stored hash no longer matches its password")
+	}
+	if CheckPassword(stored, "wrong") {
+		t.Error("a wrong password matches a stored hash")
+	}
+}
+
+func TestCheckPasswordRejectsMalformedHashes(t *testing.T) {
+	for _, hash := range []string{
+		"",
+		"plain",
+		"bcrypt$10$salt$hash",
+		"pbkdf2-sha256$0$MDEyMzQ1Njc4OWFiY2RlZg$cBg8D2DungRB9k76szThf5ehfyBz991ay6PT8Srwk4M",
+		"pbkdf2-sha256$abc$MDEyMzQ1Njc4OWFiY2RlZg$cBg8D2DungRB9k76szThf5ehfyBz991ay6PT8Srwk4M",
+		"pbkdf2-sha256$1000$not base64!$cBg8D2DungRB9k76szThf5ehfyBz991ay6PT8Srwk4M",
+		"pbkdf2-sha256$1000$MDEyMzQ1Njc4OWFiY2RlZg",
+	} {
+		if CheckPassword(hash, "correct horse") {
+			t.Errorf("CheckPassword(%q) matched", hash)
+		}
+	}
+}
+
+func TestHashTokenIsStable(t *testing.T) {
+	token, hash, err := NewToken(APITokenPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(token, APITokenPrefix) {
+		t.Errorf("token = %q, want the %q prefix", token, APITokenPrefix)
+	}
+	if HashToken(token) != hash {
+		t.Error("HashToken does not return the hash NewToken stored")
+	}
+}
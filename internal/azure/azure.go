@@ -0,0 +1,249 @@
+// Package azure logs in to Azure Container Registry with Microsoft Entra ID (AAD): an AAD access
+// token of a service principal or managed identity is exchanged at the registry for an ACR refresh
+// token, which works as the password of the registry's token service. Refresh tokens last about
+// three hours; they are cached and replaced shortly before they expire.
+package azure
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Username is the registry username that goes with an ACR refresh token
+const Username = "00000000-0000-0000-0000-000000000000"
+
+// resource is the AAD audience ACR accepts in the token exchange
+const resource = "https://management.azure.com/"
+
+// refreshBefore is how long before they expire cached refresh tokens are replaced
+const refreshBefore = 15 * time.Minute
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// metadataClient reaches the instance metadata service, which answers quickly or not at all
+var metadataClient = &http.Client{Timeout: 5 * time.Second}
+
+type refreshToken struct {
+	token   string
+	expires time.Time
+}
+
+var (
+	tokensMu sync.Mutex
+	tokens   = map[string]refreshToken{} // By registry and identity
+)
+
+// Login returns the registry username and password (an ACR refresh token) of a registry. With a
+// client secret the service principal clientID of tenant signs in; otherwise the environment's
+// identity is used: the AZURE_CLIENT_SECRET variables, AKS workload identity, or the managed
+// identity of the VM or container (the user-assigned one clientID names, if set).
+func Login(ctx context.Context, registryURL, tenant, clientID, clientSecret string) (string, string, error) {
+	u, err := url.Parse(registryURL)
+	if err != nil || u.Host == "" {
+		return "", "", fmt.Errorf("invalid registry URL %q", registryURL)
+	}
+	key := strings.Join([]string{u.Host, tenant, clientID, clientSecret}, "\x00")
+	tokensMu.Lock()
+	cached, ok := tokens[key]
+	tokensMu.Unlock()
+	if ok && time.Until(cached.expires) > refreshBefore {
+		return Username, cached.token, nil
+	}
+
+	if tenant == "" {
+		tenant = os.Getenv("AZURE_TENANT_ID")
+	}
+	aadToken, err := entraToken(ctx, tenant, clientID, clientSecret)
+	if err != nil {
+		return "", "", err
+	}
+	token, err := exchange(ctx, u, tenant, aadToken)
+	if err != nil {
+		return "", "", err
+	}
+	tokensMu.Lock()
+	tokens[key] = *token
+	tokensMu.Unlock()
+	return Username, token.token, nil
+}
+
+// entraToken returns an AAD access token for the first identity available
+func entraToken(ctx context.Context, tenant, clientID, clientSecret string) (string, error) {
+	if clientSecret != "" {
+		return clientCredentialsToken(ctx, tenant, clientID, url.Values{"client_secret": {clientSecret}})
+	}
+	if clientID == "" {
+		if secret := os.Getenv("AZURE_CLIENT_SECRET"); secret != "" {
+			return clientCredentialsToken(ctx, tenant, os.Getenv("AZURE_CLIENT_ID"), url.Values{"client_secret": {secret}})
+		}
+	}
+	if file := os.Getenv("AZURE_FEDERATED_TOKEN_FILE"); file != "" {
+		assertion, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("workload identity: %w", err)
+		}
+		id := clientID
+		if id == "" {
+			id = os.Getenv("AZURE_CLIENT_ID")
+		}
+		return clientCredentialsToken(ctx, tenant, id, url.Values{
+			"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+			"client_assertion":      {strings.TrimSpace(string(assertion))},
+		})
+	}
+	token, err := managedIdentityToken(ctx, clientID)
+	if err != nil {
+		return "", fmt.Errorf("no Azure credentials: set a service principal on the registry, or run with a managed or workload identity (%v)", err)
+	}
+	return token, nil
+}
+
+// clientCredentialsToken signs in an application with a client secret or federated assertion.
+// AZURE_AUTHORITY_HOST selects a sovereign cloud, as with the Azure SDKs.
+func clientCredentialsToken(ctx context.Context, tenant, clientID string, secret url.Values) (string, error) {
+	if tenant == "" || clientID == "" {
+		return "", fmt.Errorf("a service principal needs a tenant and a client ID")
+	}
+	authority := os.Getenv("AZURE_AUTHORITY_HOST")
+	if authority == "" {
+		authority = "https://login.microsoftonline.com"
+	}
+	form := url.Values{
+		"grant_type": {"client_credentials"},
+		"client_id":  {clientID},
+		"scope":      {resource + ".default"},
+	}
+	for k, v := range secret {
+		form[k] = v
+	}
+	tokenURL := strings.TrimRight(authority, "/") + "/" + url.PathEscape(tenant) + "/oauth2/v2.0/token"
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var body struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := sendJSON(httpClient, req, &body); err != nil {
+		return "", fmt.Errorf("AAD sign-in failed: %w", err)
+	}
+	if body.AccessToken == "" {
+		return "", fmt.Errorf("AAD sign-in returned no access token")
+	}
+	return body.AccessToken, nil
+}
+
+// managedIdentityToken reads a token of the managed identity: from the App Service / Container
+// Apps endpoint when IDENTITY_ENDPOINT is set, otherwise from the VM's instance metadata service
+func managedIdentityToken(ctx context.Context, clientID string) (string, error) {
+	q := url.Values{"resource": {resource}}
+	if clientID != "" {
+		q.Set("client_id", clientID)
+	}
+	var req *http.Request
+	var err error
+	if endpoint := os.Getenv("IDENTITY_ENDPOINT"); endpoint != "" {
+		q.Set("api-version", "2019-08-01")
+		req, err = http.NewRequestWithContext(ctx, "GET", endpoint+"?"+q.Encode(), nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("X-IDENTITY-HEADER", os.Getenv("IDENTITY_HEADER"))
+	} else {
+		q.Set("api-version", "2018-02-01")
+		req, err = http.NewRequestWithContext(ctx, "GET", "http://169.254.169.254/metadata/identity/oauth2/token?"+q.Encode(), nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("Metadata", "true")
+	}
+	var body struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := sendJSON(metadataClient, req, &body); err != nil {
+		return "", fmt.Errorf("managed identity: %w", err)
+	}
+	if body.AccessToken == "" {
+		return "", fmt.Errorf("managed identity returned no access token")
+	}
+	return body.AccessToken, nil
+}
+
+// exchange trades an AAD access token for an ACR refresh token at the registry
+func exchange(ctx context.Context, registry *url.URL, tenant, aadToken string) (*refreshToken, error) {
+	form := url.Values{
+		"grant_type":   {"access_token"},
+		"service":      {registry.Host},
+		"access_token": {aadToken},
+	}
+	if tenant != "" {
+		form.Set("tenant", tenant)
+	}
+	exchangeURL := registry.Scheme + "://" + registry.Host + "/oauth2/exchange"
+	req, err := http.NewRequestWithContext(ctx, "POST", exchangeURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var body struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := sendJSON(httpClient, req, &body); err != nil {
+		return nil, fmt.Errorf("ACR token exchange failed: %w", err)
+	}
+	if body.RefreshToken == "" {
+		return nil, fmt.Errorf("ACR token exchange returned no refresh token")
+	}
+	return &refreshToken{token: body.RefreshToken, expires: tokenExpiry(body.RefreshToken)}, nil
+}
+
+// tokenExpiry reads the expiry of a JWT without verifying it, assuming three hours when it has none
+func tokenExpiry(token string) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) == 3 {
+		var claims struct {
+			Exp int64 `json:"exp"`
+		}
+		if data, err := base64.RawURLEncoding.DecodeString(parts[1]); err == nil && json.Unmarshal(data, &claims) == nil && claims.Exp > 0 {
+			return time.Unix(claims.Exp, 0)
+		}
+	}
+	return time.Now().Add(3 * time.Hour)
+}
+
+// sendJSON sends a request and decodes its JSON response into out
+func sendJSON(client *http.Client, req *http.Request, out interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr struct {
+			Error       interface{} `json:"error"`
+			Description string      `json:"error_description"`
+		}
+		if json.Unmarshal(data, &oauthErr) == nil && oauthErr.Description != "" {
+			return fmt.Errorf("status %d: %s", resp.StatusCode, oauthErr.Description)
+		}
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisCache is a Cache shared between dashboard replicas
+type RedisCache struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisCache creates a Redis-backed cache; keys are namespaced with prefix
+func NewRedisCache(client *redis.Client, prefix string) *RedisCache {
+	return &RedisCache{client: client, prefix: prefix}
+}
+
+// Get returns a value if present
+func (c *RedisCache) Get(key string) ([]byte, bool) {
+	value, err := c.client.Get(context.Background(), c.prefix+key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Printf("⚠️  Redis cache GET failed: %v", err)
+		}
+		return nil, false
+	}
+	return value, true
+}
+
+// Set stores a value for ttl
+func (c *RedisCache) Set(key string, value []byte, ttl time.Duration) {
+	if ttl < time.Millisecond {
+		ttl = time.Millisecond
+	}
+	if err := c.client.Set(context.Background(), c.prefix+key, value, ttl).Err(); err != nil {
+		log.Printf("⚠️  Redis cache SET failed: %v", err)
+	}
+}
+
+// Delete removes a value
+func (c *RedisCache) Delete(key string) {
+	if err := c.client.Del(context.Background(), c.prefix+key).Err(); err != nil {
+		log.Printf("⚠️  Redis cache DEL failed: %v", err)
+	}
+}
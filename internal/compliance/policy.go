@@ -0,0 +1,74 @@
+package compliance
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// BaseImageLabel is the OCI annotation/label that records which image an image was built from
+const BaseImageLabel = "org.opencontainers.image.base.name"
+
+// Violation rules
+const (
+	RuleDeniedNamespace = "denied_namespace"
+	RuleUnapprovedBase  = "unapproved_base"
+	RuleUnknownBase     = "unknown_base"
+)
+
+// SplitList parses a comma or newline separated policy list
+func SplitList(s string) []string {
+	var items []string
+	for _, item := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// ChecksBase reports whether evaluating the policy needs the image's base image name
+func ChecksBase(p *models.ImagePolicy) bool {
+	return len(SplitList(p.ApprovedBaseImages)) > 0
+}
+
+// CheckRepository returns the namespace violation of a repository, if any
+func CheckRepository(p *models.ImagePolicy, repo string) (rule, detail string, ok bool) {
+	for _, ns := range SplitList(p.DeniedNamespaces) {
+		ns = strings.Trim(ns, "/")
+		if repo == ns || strings.HasPrefix(repo, ns+"/") {
+			return RuleDeniedNamespace, fmt.Sprintf("namespace %q is denied", ns), true
+		}
+	}
+	return "", "", false
+}
+
+// CheckBase returns the base image violation for an image built from baseName ("" if unknown)
+func CheckBase(p *models.ImagePolicy, baseName string) (rule, detail string, ok bool) {
+	approved := SplitList(p.ApprovedBaseImages)
+	if len(approved) == 0 {
+		return "", "", false
+	}
+	if baseName == "" {
+		return RuleUnknownBase, fmt.Sprintf("image has no %s label", BaseImageLabel), true
+	}
+	for _, pattern := range approved {
+		if baseMatches(pattern, baseName) {
+			return "", "", false
+		}
+	}
+	return RuleUnapprovedBase, fmt.Sprintf("base image %q is not approved", baseName), true
+}
+
+// baseMatches matches a glob ("alpine:*"), an exact name, or a repository without tag/digest
+func baseMatches(pattern, baseName string) bool {
+	if pattern == baseName {
+		return true
+	}
+	if ok, err := path.Match(pattern, baseName); err == nil && ok {
+		return true
+	}
+	return strings.HasPrefix(baseName, pattern+":") || strings.HasPrefix(baseName, pattern+"@")
+}
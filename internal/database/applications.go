@@ -0,0 +1,90 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Applications ---
+
+func scanApplication(row rowScanner) (*models.Application, error) {
+	var a models.Application
+	var members string
+	var createdAt sql.NullTime
+	if err := row.Scan(&a.ID, &a.Name, &a.Description, &members, &createdAt); err != nil {
+		return nil, err
+	}
+	a.Members = []models.ApplicationMember{}
+	if members != "" {
+		json.Unmarshal([]byte(members), &a.Members)
+	}
+	if createdAt.Valid {
+		a.CreatedAt = createdAt.Time
+	}
+	return &a, nil
+}
+
+// ListApplications returns all applications by name
+func (db *DB) ListApplications() ([]models.Application, error) {
+	rows, err := db.conn.Query("SELECT id, name, description, members, created_at FROM applications ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	apps := []models.Application{}
+	for rows.Next() {
+		a, err := scanApplication(rows)
+		if err != nil {
+			continue
+		}
+		apps = append(apps, *a)
+	}
+	return apps, nil
+}
+
+// GetApplication returns a single application
+func (db *DB) GetApplication(id int64) (*models.Application, error) {
+	return scanApplication(db.conn.QueryRow("SELECT id, name, description, members, created_at FROM applications WHERE id=?", id))
+}
+
+// SaveApplication creates (ID 0) or updates an application
+func (db *DB) SaveApplication(a *models.Application) error {
+	if a.Members == nil {
+		a.Members = []models.ApplicationMember{}
+	}
+	members, err := json.Marshal(a.Members)
+	if err != nil {
+		return err
+	}
+
+	if a.ID == 0 {
+		a.CreatedAt = time.Now()
+		res, err := db.conn.Exec("INSERT INTO applications (name, description, members, created_at) VALUES (?, ?, ?, ?)",
+			a.Name, a.Description, string(members), a.CreatedAt)
+		if err != nil {
+			return err
+		}
+		a.ID, err = res.LastInsertId()
+		return err
+	}
+
+	res, err := db.conn.Exec("UPDATE applications SET name=?, description=?, members=? WHERE id=?",
+		a.Name, a.Description, string(members), a.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteApplication deletes an application; its repositories are not touched
+func (db *DB) DeleteApplication(id int64) error {
+	_, err := db.conn.Exec("DELETE FROM applications WHERE id=?", id)
+	return err
+}
@@ -0,0 +1,282 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Users ---
+
+const userColumns = `id, username, password_hash, role, password_changed_at, created_at`
+
+func scanUser(row rowScanner) (*models.User, error) {
+	var u models.User
+	var changed, created sql.NullTime
+	if err := row.Scan(&u.ID, &u.Username, &u.PasswordHash, &u.Role, &changed, &created); err != nil {
+		return nil, err
+	}
+	if changed.Valid {
+		u.PasswordChangedAt = changed.Time
+	}
+	if created.Valid {
+		u.CreatedAt = created.Time
+	}
+	return &u, nil
+}
+
+// CountUsers returns the number of local accounts
+func (db *DB) CountUsers() (int, error) {
+	var n int
+	err := db.conn.QueryRow("SELECT COUNT(*) FROM users").Scan(&n)
+	return n, err
+}
+
+// CreateUser adds an account; PasswordHash must already be set
+func (db *DB) CreateUser(u *models.User) error {
+	now := time.Now()
+	res, err := db.conn.Exec(`INSERT INTO users (username, password_hash, role, password_changed_at, created_at) VALUES (?, ?, ?, ?, ?)`,
+		u.Username, u.PasswordHash, u.Role, now, now)
+	if err != nil {
+		return err
+	}
+	u.ID, _ = res.LastInsertId()
+	u.PasswordChangedAt, u.CreatedAt = now, now
+	return nil
+}
+
+// GetUser returns an account by ID
+func (db *DB) GetUser(id int64) (*models.User, error) {
+	return scanUser(db.conn.QueryRow("SELECT "+userColumns+" FROM users WHERE id=?", id))
+}
+
+// GetUserByName returns an account by username
+func (db *DB) GetUserByName(username string) (*models.User, error) {
+	return scanUser(db.conn.QueryRow("SELECT "+userColumns+" FROM users WHERE username=?", username))
+}
+
+// ListUsers returns all accounts ordered by username
+func (db *DB) ListUsers() ([]models.User, error) {
+	rows, err := db.conn.Query("SELECT " + userColumns + " FROM users ORDER BY username")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			continue
+		}
+		users = append(users, *u)
+	}
+	return users, nil
+}
+
+// SetUserPassword replaces an account's password hash
+func (db *DB) SetUserPassword(userID int64, passwordHash string) error {
+	_, err := db.conn.Exec("UPDATE users SET password_hash=?, password_changed_at=? WHERE id=?", passwordHash, time.Now(), userID)
+	return err
+}
+
+// --- Sessions ---
+
+// CreateSession stores a login session under the hash of its token
+func (db *DB) CreateSession(tokenHash string, userID int64, expiresAt time.Time) error {
+	_, err := db.conn.Exec("INSERT INTO sessions (token_hash, user_id, expires_at, created_at) VALUES (?, ?, ?, ?)",
+		tokenHash, userID, expiresAt, time.Now())
+	return err
+}
+
+// GetSessionUser returns the user of an unexpired session
+func (db *DB) GetSessionUser(tokenHash string) (*models.User, error) {
+	return scanUser(db.conn.QueryRow(`
+		SELECT u.id, u.username, u.password_hash, u.role, u.password_changed_at, u.created_at
+		FROM sessions s JOIN users u ON u.id = s.user_id
+		WHERE s.token_hash=? AND s.expires_at > ?`, tokenHash, time.Now()))
+}
+
+// DeleteSession ends one session
+func (db *DB) DeleteSession(tokenHash string) error {
+	_, err := db.conn.Exec("DELETE FROM sessions WHERE token_hash=?", tokenHash)
+	return err
+}
+
+// DeleteUserSessions ends all sessions of a user except keepHash (empty: all) and returns how many were ended
+func (db *DB) DeleteUserSessions(userID int64, keepHash string) (int64, error) {
+	res, err := db.conn.Exec("DELETE FROM sessions WHERE user_id=? AND token_hash<>?", userID, keepHash)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// --- API Tokens ---
+
+const apiTokenColumns = `id, user_id, name, prefix, expires_at, last_used_at, created_at`
+
+func scanAPIToken(row rowScanner) (*models.APIToken, error) {
+	var t models.APIToken
+	var expires, lastUsed, created sql.NullTime
+	if err := row.Scan(&t.ID, &t.UserID, &t.Name, &t.Prefix, &expires, &lastUsed, &created); err != nil {
+		return nil, err
+	}
+	if expires.Valid {
+		t.ExpiresAt = expires.Time
+	}
+	if lastUsed.Valid {
+		t.LastUsedAt = lastUsed.Time
+	}
+	if created.Valid {
+		t.CreatedAt = created.Time
+	}
+	return &t, nil
+}
+
+// nullTime stores the zero time as NULL
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+// CreateAPIToken stores a token under the hash of its secret
+func (db *DB) CreateAPIToken(t *models.APIToken, tokenHash string) error {
+	t.CreatedAt = time.Now()
+	res, err := db.conn.Exec(`INSERT INTO api_tokens (user_id, name, token_hash, prefix, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		t.UserID, t.Name, tokenHash, t.Prefix, nullTime(t.ExpiresAt), t.CreatedAt)
+	if err != nil {
+		return err
+	}
+	t.ID, _ = res.LastInsertId()
+	return nil
+}
+
+// GetAPIToken returns a token of a user
+func (db *DB) GetAPIToken(userID, id int64) (*models.APIToken, error) {
+	return scanAPIToken(db.conn.QueryRow("SELECT "+apiTokenColumns+" FROM api_tokens WHERE id=? AND user_id=?", id, userID))
+}
+
+// ListAPITokens returns the tokens of a user, newest first
+func (db *DB) ListAPITokens(userID int64) ([]models.APIToken, error) {
+	rows, err := db.conn.Query("SELECT "+apiTokenColumns+" FROM api_tokens WHERE user_id=? ORDER BY created_at DESC, id DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []models.APIToken{}
+	for rows.Next() {
+		t, err := scanAPIToken(rows)
+		if err != nil {
+			continue
+		}
+		tokens = append(tokens, *t)
+	}
+	return tokens, nil
+}
+
+// RotateAPIToken replaces the secret of a token, invalidating the old one immediately
+func (db *DB) RotateAPIToken(userID, id int64, tokenHash, prefix string, expiresAt time.Time) error {
+	res, err := db.conn.Exec("UPDATE api_tokens SET token_hash=?, prefix=?, expires_at=?, last_used_at=NULL, created_at=? WHERE id=? AND user_id=?",
+		tokenHash, prefix, nullTime(expiresAt), time.Now(), id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteAPIToken revokes one token of a user
+func (db *DB) DeleteAPIToken(userID, id int64) error {
+	res, err := db.conn.Exec("DELETE FROM api_tokens WHERE id=? AND user_id=?", id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteUserAPITokens revokes all tokens of a user and returns how many were revoked
+func (db *DB) DeleteUserAPITokens(userID int64) (int64, error) {
+	res, err := db.conn.Exec("DELETE FROM api_tokens WHERE user_id=?", userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// GetAPITokenUser returns the user and token ID of an unexpired token and records its use
+func (db *DB) GetAPITokenUser(tokenHash string) (*models.User, int64, error) {
+	var tokenID int64
+	var expires sql.NullTime
+	var userID int64
+	err := db.conn.QueryRow("SELECT id, user_id, expires_at FROM api_tokens WHERE token_hash=?", tokenHash).Scan(&tokenID, &userID, &expires)
+	if err != nil {
+		return nil, 0, err
+	}
+	if expires.Valid && time.Now().After(expires.Time) {
+		return nil, 0, sql.ErrNoRows
+	}
+	user, err := db.GetUser(userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	db.conn.Exec("UPDATE api_tokens SET last_used_at=? WHERE id=?", time.Now(), tokenID)
+	return user, tokenID, nil
+}
+
+// --- Audit Log ---
+
+// AddAuditEntry records a security-relevant action
+func (db *DB) AddAuditEntry(e *models.AuditEntry) error {
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	res, err := db.conn.Exec("INSERT INTO audit_log (actor, action, target, detail, remote_addr, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		e.Actor, e.Action, e.Target, e.Detail, e.RemoteAddr, e.CreatedAt)
+	if err != nil {
+		return err
+	}
+	e.ID, _ = res.LastInsertId()
+	return nil
+}
+
+// ListAuditEntries returns the newest entries first; actor "" means all actors
+func (db *DB) ListAuditEntries(actor string, limit int) ([]models.AuditEntry, error) {
+	query := "SELECT id, actor, action, target, detail, remote_addr, created_at FROM audit_log"
+	var args []interface{}
+	if actor != "" {
+		query += " WHERE actor=?"
+		args = append(args, actor)
+	}
+	query += " ORDER BY created_at DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var e models.AuditEntry
+		var created sql.NullTime
+		if err := rows.Scan(&e.ID, &e.Actor, &e.Action, &e.Target, &e.Detail, &e.RemoteAddr, &created); err != nil {
+			continue
+		}
+		if created.Valid {
+			e.CreatedAt = created.Time
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
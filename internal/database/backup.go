@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"modernc.org/sqlite"
+)
+
+// errNoBackups is returned for a MySQL database, which its own tools back up
+var errNoBackups = errors.New("backups of a MySQL database are taken with mysqldump or mariadb-backup")
+
+// backuper is the part of the SQLite driver connection that copies databases page by page
+type backuper interface {
+	NewBackup(dstUri string) (*sqlite.Backup, error)
+	NewRestore(srcUri string) (*sqlite.Backup, error)
+}
+
+// Backup writes a consistent snapshot of the database to a new file with SQLite's online backup
+// API. Unlike copying the file, it includes what is still in the write-ahead log, and writes
+// meanwhile do not tear it.
+func (db *DB) Backup(dst string) error {
+	if db.conn.dialect != sqliteDialect {
+		return errNoBackups
+	}
+	return db.withBackuper(db.conn.reader(), func(b backuper) error {
+		backup, err := b.NewBackup(dst)
+		if err != nil {
+			return err
+		}
+		return runBackup(backup)
+	})
+}
+
+// Restore replaces the content of the database with a backup file, then upgrades its schema.
+// The file is checked first: a corrupt file or one that is not a dashboard database is refused.
+func (db *DB) Restore(src string) error {
+	if db.conn.dialect != sqliteDialect {
+		return errNoBackups
+	}
+	if err := CheckBackup(src); err != nil {
+		return err
+	}
+	err := db.withBackuper(db.conn.DB, func(b backuper) error {
+		restore, err := b.NewRestore(src)
+		if err != nil {
+			return err
+		}
+		return runBackup(restore)
+	})
+	if err != nil {
+		return err
+	}
+	if err := db.Migrate(); err != nil {
+		return fmt.Errorf("failed to upgrade the restored database: %w", err)
+	}
+	return nil
+}
+
+// CheckBackup verifies that a file is an intact dashboard database
+func CheckBackup(path string) error {
+	conn, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var result string
+	if err := conn.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return fmt.Errorf("not a SQLite database: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("database is corrupt: %s", result)
+	}
+	var tables int
+	conn.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name IN ('registries', 'vuln_scans')").Scan(&tables)
+	if tables != 2 {
+		return fmt.Errorf("not a registry dashboard database")
+	}
+	var version int
+	conn.QueryRow("SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	if version > LatestMigration() {
+		return fmt.Errorf("the backup has schema version %d, newer than this build (%d)", version, LatestMigration())
+	}
+	return nil
+}
+
+// withBackuper runs fn with a dedicated driver connection of a pool: the read pool for backups,
+// so writes go on meanwhile, and the write connection for restores
+func (db *DB) withBackuper(pool *sql.DB, fn func(backuper) error) error {
+	conn, err := pool.Conn(context.Background())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.Raw(func(driverConn interface{}) error {
+		b, ok := driverConn.(backuper)
+		if !ok {
+			return fmt.Errorf("the database driver does not support backups")
+		}
+		return fn(b)
+	})
+}
+
+// runBackup copies all pages and releases the backup
+func runBackup(b *sqlite.Backup) error {
+	if _, err := b.Step(-1); err != nil {
+		b.Finish()
+		return err
+	}
+	return b.Finish()
+}
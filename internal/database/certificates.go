@@ -0,0 +1,69 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Certificate Checks ---
+
+const certificateCheckColumns = `registry_id, status, reachable, error, verify_error, chain, fingerprint, expires_at, days_left, chain_changed, previous_fingerprint, checked_at`
+
+func scanCertificateCheck(row rowScanner) (*models.CertificateCheck, error) {
+	var c models.CertificateCheck
+	var chain string
+	var expires sql.NullTime
+	if err := row.Scan(&c.RegistryID, &c.Status, &c.Reachable, &c.Error, &c.VerifyError, &chain, &c.Fingerprint,
+		&expires, &c.DaysLeft, &c.ChainChanged, &c.PreviousFingerprint, &c.CheckedAt); err != nil {
+		return nil, err
+	}
+	if json.Unmarshal([]byte(chain), &c.Chain) != nil || c.Chain == nil {
+		c.Chain = []models.CertificateInfo{}
+	}
+	c.ExpiresAt = timePtr(expires)
+	return &c, nil
+}
+
+// GetCertificateCheck returns the latest certificate check of a registry, or sql.ErrNoRows if none ran yet
+func (db *DB) GetCertificateCheck(registryID int64) (*models.CertificateCheck, error) {
+	return scanCertificateCheck(db.conn.QueryRow("SELECT "+certificateCheckColumns+" FROM certificate_checks WHERE registry_id=?", registryID))
+}
+
+// ListCertificateChecks returns the latest certificate check of every registry, keyed by registry ID
+func (db *DB) ListCertificateChecks() (map[int64]models.CertificateCheck, error) {
+	rows, err := db.conn.Query("SELECT " + certificateCheckColumns + " FROM certificate_checks")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checks := make(map[int64]models.CertificateCheck)
+	for rows.Next() {
+		c, err := scanCertificateCheck(rows)
+		if err != nil {
+			continue
+		}
+		checks[c.RegistryID] = *c
+	}
+	return checks, nil
+}
+
+// SaveCertificateCheck replaces the stored certificate check of a registry
+func (db *DB) SaveCertificateCheck(c *models.CertificateCheck) error {
+	chain, err := json.Marshal(c.Chain)
+	if err != nil {
+		return err
+	}
+	var expires interface{}
+	if c.ExpiresAt != nil {
+		expires = *c.ExpiresAt
+	}
+	_, err = db.conn.Exec(`
+		INSERT OR REPLACE INTO certificate_checks (`+certificateCheckColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, c.RegistryID, c.Status, c.Reachable, c.Error, c.VerifyError, string(chain), c.Fingerprint,
+		expires, c.DaysLeft, c.ChainChanged, c.PreviousFingerprint, c.CheckedAt)
+	return err
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Image Policies & Compliance ---
+
+// GetImagePolicy returns the image policy for a registry, or a disabled default if not set
+func (db *DB) GetImagePolicy(registryID int64) (*models.ImagePolicy, error) {
+	p := &models.ImagePolicy{RegistryID: registryID, Action: "flag"}
+	var lastEval sql.NullTime
+	err := db.conn.QueryRow(`
+		SELECT id, registry_id, enabled, denied_namespaces, approved_base_images, action, alert_webhook_url, last_evaluated_at
+		FROM image_policies WHERE registry_id=?
+	`, registryID).Scan(&p.ID, &p.RegistryID, &p.Enabled, &p.DeniedNamespaces, &p.ApprovedBaseImages, &p.Action, &p.AlertWebhookURL, &lastEval)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return p, nil
+		}
+		return nil, err
+	}
+	if lastEval.Valid {
+		p.LastEvaluatedAt = lastEval.Time
+	}
+	return p, nil
+}
+
+// SaveImagePolicy creates or updates an image policy
+func (db *DB) SaveImagePolicy(p *models.ImagePolicy) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO image_policies (registry_id, enabled, denied_namespaces, approved_base_images, action, alert_webhook_url)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(registry_id) DO UPDATE SET
+			enabled=excluded.enabled,
+			denied_namespaces=excluded.denied_namespaces,
+			approved_base_images=excluded.approved_base_images,
+			action=excluded.action,
+			alert_webhook_url=excluded.alert_webhook_url
+	`, p.RegistryID, p.Enabled, p.DeniedNamespaces, p.ApprovedBaseImages, p.Action, p.AlertWebhookURL)
+	return err
+}
+
+// ReplaceViolations stores the violations found by an evaluation, replacing the previous ones
+func (db *DB) ReplaceViolations(registryID int64, violations []models.ComplianceViolation, evaluatedAt time.Time) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM compliance_violations WHERE registry_id=?", registryID); err != nil {
+		return err
+	}
+	for _, v := range violations {
+		if _, err := tx.Exec(`
+			INSERT INTO compliance_violations (registry_id, repository, tag, digest, rule, detail, detected_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, registryID, v.Repository, v.Tag, v.Digest, v.Rule, v.Detail, v.DetectedAt); err != nil {
+			return fmt.Errorf("failed to insert violation for %s:%s: %w", v.Repository, v.Tag, err)
+		}
+	}
+	if _, err := tx.Exec("UPDATE image_policies SET last_evaluated_at=? WHERE registry_id=?", evaluatedAt, registryID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListViolations returns the current violations of a registry
+func (db *DB) ListViolations(registryID int64) ([]models.ComplianceViolation, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, registry_id, repository, tag, digest, rule, detail, detected_at
+		FROM compliance_violations WHERE registry_id=? ORDER BY repository, tag, rule
+	`, registryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	violations := []models.ComplianceViolation{}
+	for rows.Next() {
+		var v models.ComplianceViolation
+		var detectedAt sql.NullTime
+		if err := rows.Scan(&v.ID, &v.RegistryID, &v.Repository, &v.Tag, &v.Digest, &v.Rule, &v.Detail, &detectedAt); err != nil {
+			continue
+		}
+		if detectedAt.Valid {
+			v.DetectedAt = detectedAt.Time
+		}
+		violations = append(violations, v)
+	}
+	return violations, nil
+}
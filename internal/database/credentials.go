@@ -0,0 +1,123 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Credential Rotation ---
+
+// GetCredentialRotation returns the credential rotation of a registry, or sql.ErrNoRows if none
+func (db *DB) GetCredentialRotation(registryID int64) (*models.CredentialRotation, error) {
+	var c models.CredentialRotation
+	var validated, switched, rollbackUntil sql.NullTime
+	err := db.conn.QueryRow(`
+		SELECT registry_id, state, new_username, new_password, old_username, old_password, staged_at, validated_at, switched_at, rollback_until, last_error
+		FROM credential_rotations WHERE registry_id=?
+	`, registryID).Scan(&c.RegistryID, &c.State, &c.NewUsername, &c.NewPassword, &c.OldUsername, &c.OldPassword,
+		&c.StagedAt, &validated, &switched, &rollbackUntil, &c.LastError)
+	if err != nil {
+		return nil, err
+	}
+	c.ValidatedAt = timePtr(validated)
+	c.SwitchedAt = timePtr(switched)
+	c.RollbackUntil = timePtr(rollbackUntil)
+	return &c, nil
+}
+
+// StageCredentials stores a secondary credential set for a registry, replacing any earlier rotation
+func (db *DB) StageCredentials(registryID int64, username, password string) error {
+	_, err := db.conn.Exec(`
+		INSERT OR REPLACE INTO credential_rotations (registry_id, state, new_username, new_password, staged_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, registryID, models.RotationStaged, username, password, time.Now())
+	return err
+}
+
+// SetCredentialValidation records the outcome of validating the staged credentials
+func (db *DB) SetCredentialValidation(registryID int64, validatedAt time.Time, errMsg string) error {
+	state := models.RotationValidated
+	if errMsg != "" {
+		state = models.RotationStaged
+	}
+	_, err := db.conn.Exec(`
+		UPDATE credential_rotations SET state=?, validated_at=?, last_error=?
+		WHERE registry_id=? AND state IN (?, ?, ?)
+	`, state, nullTime(validatedAt), errMsg, registryID, models.RotationStaged, models.RotationValidated, models.RotationRolledBack)
+	return err
+}
+
+// SwitchCredentials makes the validated secondary credentials the registry's active ones and keeps
+// the previous ones for rollback until rollbackUntil. Both rows change in one transaction;
+// sql.ErrNoRows means no validated rotation exists.
+func (db *DB) SwitchCredentials(registryID int64, rollbackUntil time.Time) error {
+	return db.swapCredentials(registryID, models.RotationValidated, models.RotationSwitched, rollbackUntil)
+}
+
+// RollbackCredentials restores the credentials replaced by the last switch and stages the replaced
+// set again; sql.ErrNoRows means there is nothing to roll back to
+func (db *DB) RollbackCredentials(registryID int64) error {
+	return db.swapCredentials(registryID, models.RotationSwitched, models.RotationRolledBack, time.Time{})
+}
+
+// swapCredentials exchanges the registry's active credentials with the rotation's other set
+func (db *DB) swapCredentials(registryID int64, from, to string, rollbackUntil time.Time) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var activeUser, activePass, otherUser, otherPass string
+	query := "SELECT r.username, r.password, c.new_username, c.new_password FROM registries r JOIN credential_rotations c ON c.registry_id=r.id WHERE r.id=? AND c.state=?"
+	args := []interface{}{registryID, from}
+	if from == models.RotationSwitched {
+		query = "SELECT r.username, r.password, c.old_username, c.old_password FROM registries r JOIN credential_rotations c ON c.registry_id=r.id WHERE r.id=? AND c.state=? AND c.rollback_until > ?"
+		args = append(args, now)
+	}
+	if err := tx.QueryRow(query, args...).Scan(&activeUser, &activePass, &otherUser, &otherPass); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("UPDATE registries SET username=?, password=?, updated_at=? WHERE id=?", otherUser, otherPass, now, registryID); err != nil {
+		return err
+	}
+	if to == models.RotationSwitched {
+		_, err = tx.Exec(`
+			UPDATE credential_rotations SET state=?, old_username=?, old_password=?, switched_at=?, rollback_until=?, last_error=''
+			WHERE registry_id=?
+		`, to, activeUser, activePass, now, rollbackUntil, registryID)
+	} else {
+		// The rolled back set becomes the staged one again so it can be fixed and retried
+		_, err = tx.Exec(`
+			UPDATE credential_rotations SET state=?, new_username=?, new_password=?, old_username='', old_password='',
+				validated_at=NULL, rollback_until=NULL
+			WHERE registry_id=?
+		`, to, activeUser, activePass, registryID)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteCredentialRotation discards a registry's staged credentials or its rollback credentials
+func (db *DB) DeleteCredentialRotation(registryID int64) error {
+	_, err := db.conn.Exec("DELETE FROM credential_rotations WHERE registry_id=?", registryID)
+	return err
+}
+
+// CompleteExpiredRotations discards the old credentials of switched rotations whose rollback window has passed
+func (db *DB) CompleteExpiredRotations(now time.Time) (int64, error) {
+	res, err := db.conn.Exec(`
+		UPDATE credential_rotations SET state=?, old_username='', old_password=''
+		WHERE state=? AND rollback_until <= ?
+	`, models.RotationCompleted, models.RotationSwitched, now)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- DefectDojo Mappings ---
+
+const defectDojoMappingColumns = `id, registry_id, repo_pattern, engagement_id, trigger, interval_hours, enabled, last_export_at, last_error, created_at`
+
+func scanDefectDojoMapping(row rowScanner) (*models.DefectDojoMapping, error) {
+	var m models.DefectDojoMapping
+	var lastExport, created sql.NullTime
+	if err := row.Scan(&m.ID, &m.RegistryID, &m.RepoPattern, &m.EngagementID, &m.Trigger, &m.IntervalHours, &m.Enabled, &lastExport, &m.LastError, &created); err != nil {
+		return nil, err
+	}
+	if lastExport.Valid {
+		m.LastExportAt = lastExport.Time
+	}
+	if created.Valid {
+		m.CreatedAt = created.Time
+	}
+	return &m, nil
+}
+
+// ListDefectDojoMappings returns all mappings ordered by registry
+func (db *DB) ListDefectDojoMappings() ([]models.DefectDojoMapping, error) {
+	rows, err := db.conn.Query("SELECT " + defectDojoMappingColumns + " FROM defectdojo_mappings ORDER BY registry_id, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mappings := []models.DefectDojoMapping{}
+	for rows.Next() {
+		m, err := scanDefectDojoMapping(rows)
+		if err != nil {
+			continue
+		}
+		mappings = append(mappings, *m)
+	}
+	return mappings, nil
+}
+
+// GetDefectDojoMapping returns a single mapping
+func (db *DB) GetDefectDojoMapping(id int64) (*models.DefectDojoMapping, error) {
+	return scanDefectDojoMapping(db.conn.QueryRow("SELECT "+defectDojoMappingColumns+" FROM defectdojo_mappings WHERE id=?", id))
+}
+
+// SaveDefectDojoMapping creates (ID 0) or updates a mapping
+func (db *DB) SaveDefectDojoMapping(m *models.DefectDojoMapping) error {
+	if m.ID == 0 {
+		m.CreatedAt = time.Now()
+		res, err := db.conn.Exec(`INSERT INTO defectdojo_mappings (registry_id, repo_pattern, engagement_id, trigger, interval_hours, enabled, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`, m.RegistryID, m.RepoPattern, m.EngagementID, m.Trigger, m.IntervalHours, m.Enabled, m.CreatedAt)
+		if err != nil {
+			return err
+		}
+		m.ID, err = res.LastInsertId()
+		return err
+	}
+	res, err := db.conn.Exec(`UPDATE defectdojo_mappings SET registry_id=?, repo_pattern=?, engagement_id=?, trigger=?, interval_hours=?, enabled=? WHERE id=?`,
+		m.RegistryID, m.RepoPattern, m.EngagementID, m.Trigger, m.IntervalHours, m.Enabled, m.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteDefectDojoMapping removes a mapping and forgets the tests it created
+func (db *DB) DeleteDefectDojoMapping(id int64) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM defectdojo_mappings WHERE id=?", id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM defectdojo_tests WHERE mapping_id=?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetDefectDojoExportResult records the outcome of an export run
+func (db *DB) SetDefectDojoExportResult(id int64, at time.Time, errMsg string) error {
+	_, err := db.conn.Exec("UPDATE defectdojo_mappings SET last_export_at=?, last_error=? WHERE id=?", at, errMsg, id)
+	return err
+}
+
+// GetDefectDojoTest returns the DefectDojo test an image was imported into and the scan time exported,
+// or sql.ErrNoRows if it was never exported through the mapping
+func (db *DB) GetDefectDojoTest(mappingID, registryID int64, repo, tag string) (int64, time.Time, error) {
+	var testID int64
+	var scannedAt sql.NullTime
+	err := db.conn.QueryRow("SELECT test_id, scanned_at FROM defectdojo_tests WHERE mapping_id=? AND registry_id=? AND repository=? AND tag=?",
+		mappingID, registryID, repo, tag).Scan(&testID, &scannedAt)
+	return testID, scannedAt.Time, err
+}
+
+// SaveDefectDojoTest remembers the test an image was imported into
+func (db *DB) SaveDefectDojoTest(mappingID, registryID int64, repo, tag string, testID int64, scannedAt time.Time) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO defectdojo_tests (mapping_id, registry_id, repository, tag, test_id, scanned_at, exported_at) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(mapping_id, registry_id, repository, tag) DO UPDATE SET
+			test_id=excluded.test_id,
+			scanned_at=excluded.scanned_at,
+			exported_at=excluded.exported_at
+	`, mappingID, registryID, repo, tag, testID, scannedAt, time.Now())
+	return err
+}
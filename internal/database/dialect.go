@@ -0,0 +1,178 @@
+package database
+
+import (
+	"database/sql"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// dialect is what differs between the database drivers: the queries are written for SQLite and
+// rewritten for the others
+type dialect struct {
+	name       string
+	migrations []Migration
+	rewrite    func(query string) string // nil: the queries run as they are
+	splitDDL   bool                      // Migration scripts must run one statement at a time
+}
+
+var sqliteDialect = &dialect{name: "sqlite", migrations: sqliteMigrations}
+
+var mysqlDialect = &dialect{name: "mysql", migrations: mysqlMigrations, rewrite: mysqlQuery, splitDDL: true}
+
+var (
+	conflictNothing = regexp.MustCompile(`(?s)^(\s*)INSERT INTO(.*?)\s*ON CONFLICT\s*\([^)]*\)\s*DO NOTHING`)
+	conflictUpdate  = regexp.MustCompile(`ON CONFLICT\s*\([^)]*\)\s*DO UPDATE SET`)
+	excludedColumn  = regexp.MustCompile(`\bexcluded\.(\w+)`)
+	reservedColumn  = regexp.MustCompile(`\btrigger\b`)
+)
+
+// mysqlQuery rewrites the SQLite-specific parts of a query for MySQL and MariaDB: upserts, INSERT
+// OR REPLACE, the LIKE escape character, blob casts and column names MySQL reserves
+func mysqlQuery(query string) string {
+	query = conflictNothing.ReplaceAllString(query, "${1}INSERT IGNORE INTO$2")
+	query = conflictUpdate.ReplaceAllString(query, "ON DUPLICATE KEY UPDATE")
+	query = excludedColumn.ReplaceAllString(query, "VALUES($1)")
+	query = strings.ReplaceAll(query, "INSERT OR REPLACE INTO", "REPLACE INTO")
+	query = strings.ReplaceAll(query, "INSERT OR IGNORE INTO", "INSERT IGNORE INTO")
+	query = strings.ReplaceAll(query, `ESCAPE '\'`, `ESCAPE '\\'`)
+	query = strings.ReplaceAll(query, "AS BLOB)", "AS BINARY)")
+	return reservedColumn.ReplaceAllString(query, "`trigger`")
+}
+
+// statements splits a migration script into the statements the driver runs one at a time
+func (d *dialect) statements(script string) []string {
+	if !d.splitDDL {
+		return []string{script}
+	}
+	var list []string
+	for _, stmt := range strings.Split(script, ";") {
+		if stmt = strings.TrimSpace(stmt); stmt != "" {
+			list = append(list, stmt)
+		}
+	}
+	return list
+}
+
+// sqlConn is the connection pool, rewriting each query for the dialect. With a separate read
+// pool, statements that write go through the main pool and queries through the read pool.
+type sqlConn struct {
+	*sql.DB
+	read    *sql.DB // nil: queries use the main pool
+	dialect *dialect
+
+	mu    sync.Mutex
+	stmts map[string]*sql.Stmt // Statements of hot paths, prepared once per pool
+}
+
+func (c *sqlConn) query(q string) string {
+	if c.dialect.rewrite == nil {
+		return q
+	}
+	return c.dialect.rewrite(q)
+}
+
+// reader is the pool queries run on
+func (c *sqlConn) reader() *sql.DB {
+	if c.read != nil {
+		return c.read
+	}
+	return c.DB
+}
+
+func (c *sqlConn) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return c.DB.Exec(c.query(query), args...)
+}
+
+func (c *sqlConn) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return c.reader().Query(c.query(query), args...)
+}
+
+func (c *sqlConn) QueryRow(query string, args ...interface{}) *sql.Row {
+	return c.reader().QueryRow(c.query(query), args...)
+}
+
+func (c *sqlConn) Begin() (*sqlTx, error) {
+	tx, err := c.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &sqlTx{Tx: tx, conn: c}, nil
+}
+
+// prepared returns the cached prepared statement of a query, preparing it on first use. Only
+// constant queries are prepared, so the cache stays small.
+func (c *sqlConn) prepared(query string) (*sql.Stmt, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if stmt, ok := c.stmts[query]; ok {
+		return stmt, nil
+	}
+	pool := c.DB
+	if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(query)), "SELECT") {
+		pool = c.reader()
+	}
+	stmt, err := pool.Prepare(c.query(query))
+	if err != nil {
+		return nil, err
+	}
+	if c.stmts == nil {
+		c.stmts = make(map[string]*sql.Stmt)
+	}
+	c.stmts[query] = stmt
+	return stmt, nil
+}
+
+// execCached is Exec with a prepared statement reused across calls, for statements run often
+func (c *sqlConn) execCached(query string, args ...interface{}) (sql.Result, error) {
+	stmt, err := c.prepared(query)
+	if err != nil {
+		return nil, err
+	}
+	return stmt.Exec(args...)
+}
+
+// queryRowCached is QueryRow with a prepared statement reused across calls
+func (c *sqlConn) queryRowCached(query string, args ...interface{}) *sql.Row {
+	stmt, err := c.prepared(query)
+	if err != nil {
+		return c.QueryRow(query, args...) // Reports the same error on Scan
+	}
+	return stmt.QueryRow(args...)
+}
+
+// Close closes the prepared statements and the pools
+func (c *sqlConn) Close() error {
+	c.mu.Lock()
+	for _, stmt := range c.stmts {
+		stmt.Close()
+	}
+	c.stmts = nil
+	c.mu.Unlock()
+	if c.read != nil {
+		c.read.Close()
+	}
+	return c.DB.Close()
+}
+
+// sqlTx is a transaction, rewriting each query for the dialect
+type sqlTx struct {
+	*sql.Tx
+	conn *sqlConn
+}
+
+func (t *sqlTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return t.Tx.Exec(t.conn.query(query), args...)
+}
+
+func (t *sqlTx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return t.Tx.Query(t.conn.query(query), args...)
+}
+
+func (t *sqlTx) QueryRow(query string, args ...interface{}) *sql.Row {
+	return t.Tx.QueryRow(t.conn.query(query), args...)
+}
+
+func (t *sqlTx) Prepare(query string) (*sql.Stmt, error) {
+	return t.Tx.Prepare(t.conn.query(query))
+}
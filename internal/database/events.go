@@ -0,0 +1,186 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Registry Events ---
+
+const registryEventColumns = `id, registry_id, source, action, repository, tag, digest, media_type, actor, COALESCE(actor_ip, ''), COALESCE(detail, ''), timestamp`
+
+// AddRegistryEvent stores an event received from a registry webhook or raised by the dashboard
+func (db *DB) AddRegistryEvent(e *models.RegistryEvent) error {
+	// Stored in UTC so the timestamps compare as text in SQLite
+	e.Timestamp = e.Timestamp.UTC()
+	res, err := db.conn.execCached(`
+		INSERT INTO registry_events (registry_id, source, action, repository, tag, digest, media_type, actor, actor_ip, detail, timestamp)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, e.RegistryID, e.Source, e.Action, e.Repository, e.Tag, e.Digest, e.MediaType, e.Actor, e.ActorIP, e.Detail, e.Timestamp)
+	if err != nil {
+		return err
+	}
+	e.ID, _ = res.LastInsertId()
+	return nil
+}
+
+// RegistryEventFilter narrows an event query; zero values match everything
+type RegistryEventFilter struct {
+	RegistryID int64
+	Source     string
+	Action     string
+	Repository string
+	Tag        string
+	Actor      string // User name or client address
+	Since      time.Time
+	Until      time.Time
+	Limit      int
+}
+
+// ListRegistryEvents returns the events matching the filter, newest first
+func (db *DB) ListRegistryEvents(f RegistryEventFilter) ([]models.RegistryEvent, error) {
+	query := `SELECT ` + registryEventColumns + ` FROM registry_events WHERE 1=1`
+	var args []interface{}
+	if f.RegistryID != 0 {
+		query += " AND registry_id=?"
+		args = append(args, f.RegistryID)
+	}
+	for _, match := range []struct{ column, value string }{
+		{"source", f.Source}, {"action", f.Action}, {"repository", f.Repository}, {"tag", f.Tag},
+	} {
+		if match.value != "" {
+			query += " AND " + match.column + "=?"
+			args = append(args, match.value)
+		}
+	}
+	if f.Actor != "" {
+		query += " AND (actor=? OR actor_ip=?)"
+		args = append(args, f.Actor, f.Actor)
+	}
+	if !f.Since.IsZero() {
+		query += " AND timestamp>=?"
+		args = append(args, f.Since.UTC())
+	}
+	if !f.Until.IsZero() {
+		query += " AND timestamp<?"
+		args = append(args, f.Until.UTC())
+	}
+	query += " ORDER BY timestamp DESC, id DESC LIMIT ?"
+	args = append(args, f.Limit)
+	return db.queryRegistryEvents(query, args...)
+}
+
+// ListPushEvents returns the newest push events of a repository first; tag "" means all its tags
+func (db *DB) ListPushEvents(registryID int64, repository, tag string, limit int) ([]models.RegistryEvent, error) {
+	query := `SELECT ` + registryEventColumns + `
+		FROM registry_events WHERE registry_id=? AND repository=? AND action='push'`
+	args := []interface{}{registryID, repository}
+	if tag != "" {
+		query += " AND tag=?"
+		args = append(args, tag)
+	}
+	query += " ORDER BY timestamp DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+	return db.queryRegistryEvents(query, args...)
+}
+
+func (db *DB) queryRegistryEvents(query string, args ...interface{}) ([]models.RegistryEvent, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.RegistryEvent{}
+	for rows.Next() {
+		var e models.RegistryEvent
+		var ts sql.NullTime
+		if err := rows.Scan(&e.ID, &e.RegistryID, &e.Source, &e.Action, &e.Repository, &e.Tag, &e.Digest, &e.MediaType, &e.Actor, &e.ActorIP, &e.Detail, &ts); err != nil {
+			continue
+		}
+		if ts.Valid {
+			e.Timestamp = ts.Time
+		}
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// --- Tag Usage ---
+
+// RecordTagUsage updates the pull/push counters for a push or pull event. Pulls by digest
+// are counted for every tag last pushed with that digest.
+func (db *DB) RecordTagUsage(e *models.RegistryEvent) error {
+	switch {
+	case e.Action == "push" && e.Tag != "":
+		_, err := db.conn.execCached(`
+			INSERT INTO tag_usage (registry_id, repository, tag, digest, push_count, last_pushed_at) VALUES (?, ?, ?, ?, 1, ?)
+			ON CONFLICT(registry_id, repository, tag) DO UPDATE SET
+				digest=CASE WHEN excluded.digest<>'' THEN excluded.digest ELSE tag_usage.digest END,
+				push_count=tag_usage.push_count+1,
+				last_pushed_at=excluded.last_pushed_at
+		`, e.RegistryID, e.Repository, e.Tag, e.Digest, e.Timestamp)
+		return err
+	case e.Action == "pull" && e.Tag != "":
+		_, err := db.conn.execCached(`
+			INSERT INTO tag_usage (registry_id, repository, tag, digest, pull_count, last_pulled_at) VALUES (?, ?, ?, ?, 1, ?)
+			ON CONFLICT(registry_id, repository, tag) DO UPDATE SET
+				pull_count=tag_usage.pull_count+1,
+				last_pulled_at=excluded.last_pulled_at
+		`, e.RegistryID, e.Repository, e.Tag, e.Digest, e.Timestamp)
+		return err
+	case e.Action == "pull" && e.Digest != "":
+		_, err := db.conn.execCached(`
+			UPDATE tag_usage SET pull_count=pull_count+1, last_pulled_at=?
+			WHERE registry_id=? AND repository=? AND digest=?
+		`, e.Timestamp, e.RegistryID, e.Repository, e.Digest)
+		return err
+	}
+	return nil
+}
+
+// PushedDigest returns the digest a tag was last pushed with, "" when no push of it was seen
+func (db *DB) PushedDigest(registryID int64, repository, tag string) (string, error) {
+	var digest sql.NullString
+	err := db.conn.QueryRow("SELECT digest FROM tag_usage WHERE registry_id=? AND repository=? AND tag=?",
+		registryID, repository, tag).Scan(&digest)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return digest.String, err
+}
+
+// GetTagUsage returns the usage counters of a repository's tags, keyed by tag
+func (db *DB) GetTagUsage(registryID int64, repository string) (map[string]models.TagUsage, error) {
+	rows, err := db.conn.Query(`
+		SELECT tag, pull_count, push_count, last_pulled_at, last_pushed_at
+		FROM tag_usage WHERE registry_id=? AND repository=?
+	`, registryID, repository)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usage := make(map[string]models.TagUsage)
+	for rows.Next() {
+		var tag string
+		var u models.TagUsage
+		var pulled, pushed sql.NullTime
+		if err := rows.Scan(&tag, &u.PullCount, &u.PushCount, &pulled, &pushed); err != nil {
+			continue
+		}
+		u.LastPulledAt = timePtr(pulled)
+		u.LastPushedAt = timePtr(pushed)
+		usage[tag] = u
+	}
+	return usage, nil
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
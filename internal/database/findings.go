@@ -0,0 +1,386 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/scanner"
+)
+
+// --- Normalized Findings ---
+
+// replaceFindings rebuilds the normalized findings of a scan from its report
+func (db *DB) replaceFindings(s *models.VulnerabilityScan) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM vuln_findings WHERE scan_id=?", s.ID); err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO vuln_findings (scan_id, registry_id, repository, tag, digest, scanner, vuln_id, package, version, fixed_version, severity, description, scanned_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, f := range scanner.ParseFindings(s.Report) {
+		if _, err := stmt.Exec(s.ID, s.RegistryID, s.Repository, s.Tag, s.Digest, f.Scanner, f.ID, f.Package, f.Version, f.FixedVersion, f.Severity, f.Description, s.ScannedAt); err != nil {
+			return fmt.Errorf("failed to insert finding %s: %w", f.ID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// CountFindingsByImage returns the number of findings of the latest completed scan of every image
+// with any, by ImageKey and upper-cased severity. Images that are gone from their registry keep
+// their scans, so callers count only the images they listed.
+func (db *DB) CountFindingsByImage() (map[string]map[string]int, error) {
+	rows, err := db.conn.Query(`
+		SELECT f.registry_id, f.repository, f.tag, UPPER(f.severity), COUNT(*)
+		FROM vuln_findings f JOIN vuln_scans s ON s.id = f.scan_id
+		WHERE s.status = 'completed'
+		GROUP BY f.registry_id, f.repository, f.tag, UPPER(f.severity)
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]map[string]int)
+	for rows.Next() {
+		var registryID int64
+		var repo, tag, severity string
+		var count int
+		if err := rows.Scan(&registryID, &repo, &tag, &severity, &count); err != nil {
+			continue
+		}
+		key := ImageKey(registryID, repo, tag)
+		if counts[key] == nil {
+			counts[key] = make(map[string]int)
+		}
+		counts[key][severity] = count
+	}
+	return counts, rows.Err()
+}
+
+// ListCompletedScanTimes returns the last completed scan time per image, keyed by "registryID|repo:tag"
+func (db *DB) ListCompletedScanTimes() (map[string]time.Time, error) {
+	rows, err := db.conn.Query("SELECT registry_id, repository, tag, scanned_at FROM vuln_scans WHERE status='completed'")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	times := make(map[string]time.Time)
+	for rows.Next() {
+		var registryID int64
+		var repo, tag string
+		var scannedAt sql.NullTime
+		if err := rows.Scan(&registryID, &repo, &tag, &scannedAt); err != nil {
+			continue
+		}
+		times[ImageKey(registryID, repo, tag)] = scannedAt.Time
+	}
+	return times, nil
+}
+
+// ImageKey builds the map key used to identify an image across registries
+func ImageKey(registryID int64, repo, tag string) string {
+	return fmt.Sprintf("%d|%s:%s", registryID, repo, tag)
+}
+
+// FindingSearch narrows a full-text search over findings
+type FindingSearch struct {
+	Query      string // Words matched as prefixes; "package:", "vuln_id:" or "description:" limit a word to a column
+	RegistryID int64
+	Severity   string
+	Limit      int // Maximum number of findings
+}
+
+// findingColumns are the columns of the full-text index a search word may be limited to
+var findingColumns = map[string]bool{"vuln_id": true, "package": true, "description": true}
+
+// SearchFindings finds the latest findings of every image matching a full-text query, best matches
+// first, grouped by image. It reports whether the limit cut the results short.
+func (db *DB) SearchFindings(s FindingSearch) ([]models.FindingSearchResult, bool, error) {
+	if db.conn.dialect == mysqlDialect {
+		return db.searchFindingsMySQL(s)
+	}
+	match := ftsQuery(s.Query)
+	if match == "" {
+		return []models.FindingSearchResult{}, false, nil
+	}
+	where := "vuln_findings_fts MATCH ?"
+	args := []interface{}{match}
+	if s.RegistryID != 0 {
+		where += " AND f.registry_id=?"
+		args = append(args, s.RegistryID)
+	}
+	if s.Severity != "" {
+		where += " AND UPPER(f.severity)=UPPER(?)"
+		args = append(args, s.Severity)
+	}
+	args = append(args, s.Limit+1)
+
+	rows, err := db.conn.Query(`
+		SELECT f.registry_id, f.repository, f.tag, COALESCE(f.digest, ''), f.scanned_at, f.vuln_id, f.package,
+			COALESCE(f.version, ''), f.fixed_version, f.severity, snippet(vuln_findings_fts, 2, '[', ']', '…', 16)
+		FROM vuln_findings_fts JOIN vuln_findings f ON f.id = vuln_findings_fts.rowid
+		WHERE `+where+`
+		ORDER BY bm25(vuln_findings_fts)
+		LIMIT ?
+	`, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	return groupFindingMatches(rows, s.Limit)
+}
+
+// searchFindingsMySQL is SearchFindings over the FULLTEXT index of MySQL: column-limited words
+// are matched with LIKE, and the snippet is the start of the description
+func (db *DB) searchFindingsMySQL(s FindingSearch) ([]models.FindingSearchResult, bool, error) {
+	var words []string
+	var conds []string
+	var args []interface{}
+	for _, word := range strings.Fields(s.Query) {
+		if col, value, ok := strings.Cut(word, ":"); ok && findingColumns[strings.ToLower(col)] {
+			if value != "" {
+				conds = append(conds, "f."+strings.ToLower(col)+` LIKE ? ESCAPE '\'`)
+				args = append(args, likePattern(value))
+			}
+			continue
+		}
+		// Boolean mode operators would change the query; every word is required as a prefix
+		word = strings.Trim(strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`+-<>()~*"@`, r) {
+				return ' '
+			}
+			return r
+		}, word), " ")
+		for _, w := range strings.Fields(word) {
+			words = append(words, "+"+w+"*")
+		}
+	}
+	if len(words) == 0 && len(conds) == 0 {
+		return []models.FindingSearchResult{}, false, nil
+	}
+	match := "MATCH(f.vuln_id, f.package, f.description) AGAINST (? IN BOOLEAN MODE)"
+	var score []interface{}
+	if len(words) > 0 {
+		conds = append([]string{match}, conds...)
+		args = append([]interface{}{strings.Join(words, " ")}, args...)
+		score = []interface{}{strings.Join(words, " ")}
+	}
+	if s.RegistryID != 0 {
+		conds = append(conds, "f.registry_id=?")
+		args = append(args, s.RegistryID)
+	}
+	if s.Severity != "" {
+		conds = append(conds, "UPPER(f.severity)=UPPER(?)")
+		args = append(args, s.Severity)
+	}
+	order := "f.id"
+	if score != nil {
+		order = match + " DESC"
+	}
+	args = append(append(args, score...), s.Limit+1)
+
+	rows, err := db.conn.Query(`
+		SELECT f.registry_id, f.repository, f.tag, COALESCE(f.digest, ''), f.scanned_at, f.vuln_id, f.package,
+			COALESCE(f.version, ''), f.fixed_version, f.severity, LEFT(COALESCE(f.description, ''), 120)
+		FROM vuln_findings f
+		WHERE `+strings.Join(conds, " AND ")+`
+		ORDER BY `+order+`
+		LIMIT ?
+	`, args...)
+	if err != nil {
+		return nil, false, err
+	}
+	return groupFindingMatches(rows, s.Limit)
+}
+
+// groupFindingMatches groups the rows of a finding search by image, reporting whether there were
+// more than limit
+func groupFindingMatches(rows *sql.Rows, limit int) ([]models.FindingSearchResult, bool, error) {
+	defer rows.Close()
+
+	results := []models.FindingSearchResult{}
+	byImage := make(map[string]int)
+	count := 0
+	for rows.Next() {
+		var img models.FindingSearchResult
+		var m models.FindingMatch
+		var scannedAt sql.NullTime
+		if err := rows.Scan(&img.RegistryID, &img.Repository, &img.Tag, &img.Digest, &scannedAt, &m.VulnID, &m.Package,
+			&m.Version, &m.FixedVersion, &m.Severity, &m.Snippet); err != nil {
+			continue
+		}
+		if count++; count > limit {
+			return results, true, nil
+		}
+		key := ImageKey(img.RegistryID, img.Repository, img.Tag)
+		i, ok := byImage[key]
+		if !ok {
+			img.ScannedAt = scannedAt.Time
+			img.Findings = []models.FindingMatch{}
+			results = append(results, img)
+			i = len(results) - 1
+			byImage[key] = i
+		}
+		results[i].Findings = append(results[i].Findings, m)
+	}
+	return results, false, rows.Err()
+}
+
+// ftsQuery turns search words into an FTS5 query: every word must match as a prefix
+func ftsQuery(query string) string {
+	var terms []string
+	for _, word := range strings.Fields(query) {
+		column := ""
+		if col, value, ok := strings.Cut(word, ":"); ok && findingColumns[strings.ToLower(col)] {
+			column, word = strings.ToLower(col)+" : ", value
+		}
+		if word == "" {
+			continue
+		}
+		terms = append(terms, column+`"`+strings.ReplaceAll(word, `"`, `""`)+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
+
+// FindingFilter selects the findings of the latest completed scans
+type FindingFilter struct {
+	RegistryID   int64
+	Severities   []string // Upper-cased
+	Package      string   // Substring of the package name
+	Repository   string
+	Repositories []string // Only these repositories, unless nil
+	Tag          string
+	FixableOnly  bool   // Only findings with a fixed version
+	Query        string // Substring of the vulnerability ID or package name
+	Sort         string // severity, vuln_id, package, repository or scanned_at
+	Desc         bool
+	Offset       int
+	Limit        int
+}
+
+// findingSortColumns maps sort fields to SQL; severity ranks the most severe first
+var findingSortColumns = map[string]string{
+	"severity":   severityRank("f.severity"),
+	"vuln_id":    "f.vuln_id",
+	"package":    "f.package",
+	"repository": "f.repository",
+	"scanned_at": "f.scanned_at",
+}
+
+// severityLevels are the severities from most to least severe; severityRank maps them to their index
+var severityLevels = []string{"CRITICAL", "HIGH", "MEDIUM", "LOW", "UNKNOWN"}
+
+// severityRank returns SQL ranking a severity column by severityLevels
+func severityRank(col string) string {
+	return "CASE UPPER(" + col + ") WHEN 'CRITICAL' THEN 0 WHEN 'HIGH' THEN 1 WHEN 'MEDIUM' THEN 2 WHEN 'LOW' THEN 3 ELSE 4 END"
+}
+
+func (f FindingFilter) where() (string, []interface{}) {
+	conds := []string{"f.registry_id=?", "s.status='completed'"}
+	args := []interface{}{f.RegistryID}
+	if len(f.Severities) > 0 {
+		conds = append(conds, "UPPER(f.severity) IN (?"+strings.Repeat(", ?", len(f.Severities)-1)+")")
+		for _, s := range f.Severities {
+			args = append(args, s)
+		}
+	}
+	if f.Package != "" {
+		conds = append(conds, `f.package LIKE ? ESCAPE '\'`)
+		args = append(args, likePattern(f.Package))
+	}
+	if f.Repository != "" {
+		conds = append(conds, "f.repository=?")
+		args = append(args, f.Repository)
+	}
+	if f.Repositories != nil {
+		cond, repoArgs := repositoryIn("f.repository", f.Repositories)
+		conds = append(conds, cond)
+		args = append(args, repoArgs...)
+	}
+	if f.Tag != "" {
+		conds = append(conds, "f.tag=?")
+		args = append(args, f.Tag)
+	}
+	if f.FixableOnly {
+		conds = append(conds, "f.fixed_version<>''")
+	}
+	if f.Query != "" {
+		conds = append(conds, `(f.vuln_id LIKE ? ESCAPE '\' OR f.package LIKE ? ESCAPE '\')`)
+		args = append(args, likePattern(f.Query), likePattern(f.Query))
+	}
+	return "FROM vuln_findings f JOIN vuln_scans s ON s.id=f.scan_id WHERE " + strings.Join(conds, " AND "), args
+}
+
+// repositoryIn returns the condition that a column is one of the repositories, false when there are none
+func repositoryIn(col string, repos []string) (string, []interface{}) {
+	if len(repos) == 0 {
+		return "1=0", nil
+	}
+	args := make([]interface{}, len(repos))
+	for i, repo := range repos {
+		args[i] = repo
+	}
+	return col + " IN (?" + strings.Repeat(", ?", len(repos)-1) + ")", args
+}
+
+// CountFindings returns the number of findings matching a filter
+func (db *DB) CountFindings(f FindingFilter) (int, error) {
+	where, args := f.where()
+	var n int
+	err := db.conn.QueryRow("SELECT COUNT(*) "+where, args...).Scan(&n)
+	return n, err
+}
+
+// ListFindings returns one page of the findings matching a filter
+func (db *DB) ListFindings(f FindingFilter) ([]models.VulnerabilityItem, error) {
+	where, args := f.where()
+	order := findingSortColumns[f.Sort]
+	if order == "" {
+		order = findingSortColumns["severity"]
+	}
+	if f.Desc {
+		order += " DESC"
+	}
+	args = append(args, f.Limit, f.Offset)
+
+	rows, err := db.conn.Query(`
+		SELECT f.vuln_id, f.package, COALESCE(f.version, ''), f.fixed_version, f.severity, f.description, f.scanner,
+			f.repository, f.tag, COALESCE(f.digest, ''), f.registry_id, f.scanned_at
+		`+where+`
+		ORDER BY `+order+`, f.id
+		LIMIT ? OFFSET ?
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.VulnerabilityItem{}
+	for rows.Next() {
+		var v models.VulnerabilityItem
+		var scannedAt sql.NullTime
+		if err := rows.Scan(&v.ID, &v.Package, &v.Version, &v.FixedVersion, &v.Severity, &v.Description, &v.Scanner,
+			&v.Repository, &v.Tag, &v.Digest, &v.RegistryID, &scannedAt); err != nil {
+			continue
+		}
+		v.ScannedAt = scannedAt.Time
+		items = append(items, v)
+	}
+	return items, rows.Err()
+}
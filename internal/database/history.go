@@ -0,0 +1,69 @@
+package database
+
+import (
+	"database/sql"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Scan History ---
+
+// addScanHistory appends a snapshot of a completed scan
+func (db *DB) addScanHistory(s *models.VulnerabilityScan) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO scan_history (registry_id, repository, tag, digest, summary, report, scanned_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, s.RegistryID, s.Repository, s.Tag, s.Digest, s.Summary, s.Report, s.ScannedAt)
+	return err
+}
+
+// ListScanHistory returns the scan snapshots of an image, newest first (reports are omitted)
+func (db *DB) ListScanHistory(registryID int64, repo, tag string) ([]models.ScanHistory, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, registry_id, repository, tag, digest, summary, scanned_at, report_evicted_at IS NOT NULL
+		FROM scan_history WHERE registry_id=? AND repository=? AND tag=?
+		ORDER BY scanned_at DESC, id DESC
+	`, registryID, repo, tag)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []models.ScanHistory
+	for rows.Next() {
+		var h models.ScanHistory
+		var scannedAt sql.NullTime
+		if err := rows.Scan(&h.ID, &h.RegistryID, &h.Repository, &h.Tag, &h.Digest, &h.Summary, &scannedAt, &h.ReportEvicted); err != nil {
+			continue
+		}
+		if scannedAt.Valid {
+			h.ScannedAt = scannedAt.Time
+		}
+		history = append(history, h)
+	}
+	return history, nil
+}
+
+// GetScanHistory returns a single scan snapshot including its report. When the quota evicted the
+// report of an image's latest scan, the copy kept with the current scan is returned instead.
+func (db *DB) GetScanHistory(id int64) (*models.ScanHistory, error) {
+	var h models.ScanHistory
+	var scannedAt sql.NullTime
+	err := db.conn.QueryRow(`
+		SELECT h.id, h.registry_id, h.repository, h.tag, h.digest, h.summary,
+		       COALESCE(NULLIF(h.report, ''), (
+		           SELECT s.report FROM vuln_scans s
+		           WHERE s.registry_id=h.registry_id AND s.repository=h.repository AND s.tag=h.tag AND s.scanned_at=h.scanned_at
+		       ), ''),
+		       h.scanned_at, h.report_evicted_at IS NOT NULL
+		FROM scan_history h WHERE h.id=?
+	`, id).Scan(&h.ID, &h.RegistryID, &h.Repository, &h.Tag, &h.Digest, &h.Summary, &h.Report, &scannedAt, &h.ReportEvicted)
+	if err != nil {
+		return nil, err
+	}
+	h.ReportEvicted = h.ReportEvicted && h.Report == ""
+	if scannedAt.Valid {
+		h.ScannedAt = scannedAt.Time
+	}
+	return &h, nil
+}
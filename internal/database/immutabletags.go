@@ -0,0 +1,107 @@
+package database
+
+import (
+	"database/sql"
+	"log"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Immutable Tag Rules ---
+
+const immutableTagRuleColumns = "id, registry_id, repository, tag_pattern, created_by, created_at"
+
+func scanImmutableTagRule(row rowScanner) (*models.ImmutableTagRule, error) {
+	var rule models.ImmutableTagRule
+	var createdAt sql.NullTime
+	if err := row.Scan(&rule.ID, &rule.RegistryID, &rule.Repository, &rule.TagPattern, &rule.CreatedBy, &createdAt); err != nil {
+		return nil, err
+	}
+	if createdAt.Valid {
+		rule.CreatedAt = createdAt.Time
+	}
+	return &rule, nil
+}
+
+func (db *DB) listImmutableTagRules(query string, args ...interface{}) ([]models.ImmutableTagRule, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []models.ImmutableTagRule{}
+	for rows.Next() {
+		rule, err := scanImmutableTagRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *rule)
+	}
+	return rules, rows.Err()
+}
+
+// ListImmutableTagRules returns the immutable tag rules of a registry
+func (db *DB) ListImmutableTagRules(registryID int64) ([]models.ImmutableTagRule, error) {
+	return db.listImmutableTagRules("SELECT "+immutableTagRuleColumns+" FROM immutable_tag_rules WHERE registry_id=? ORDER BY id", registryID)
+}
+
+// IsImmutableTag reports whether a rule of the registries at a URL makes a tag immutable, which is
+// how an embedded registry finds the rules of its dashboard entry before it takes a push
+func (db *DB) IsImmutableTag(registryURL, repo, tag string) bool {
+	rules, err := db.listImmutableTagRules(`
+		SELECT i.id, i.registry_id, i.repository, i.tag_pattern, i.created_by, i.created_at
+		FROM immutable_tag_rules i JOIN registries r ON r.id = i.registry_id
+		WHERE r.url=? ORDER BY i.id`, registryURL)
+	if err != nil {
+		// Refusing every push while the database fails would be worse
+		log.Printf("⚠️ Failed to load immutable tag rules: %v", err)
+		return false
+	}
+	immutable := models.ImmutableTags(rules)
+	return immutable != nil && immutable(repo, tag)
+}
+
+// ImmutableTagRule returns the first rule of a registry making a tag immutable, nil when none does
+func (db *DB) ImmutableTagRule(registryID int64, repo, tag string) (*models.ImmutableTagRule, error) {
+	rules, err := db.ListImmutableTagRules(registryID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rules {
+		if rules[i].Matches(repo, tag) {
+			return &rules[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// CreateImmutableTagRule adds an immutable tag rule
+func (db *DB) CreateImmutableTagRule(rule *models.ImmutableTagRule) error {
+	rule.CreatedAt = time.Now()
+	res, err := db.conn.Exec("INSERT INTO immutable_tag_rules (registry_id, repository, tag_pattern, created_by, created_at) VALUES (?, ?, ?, ?, ?)",
+		rule.RegistryID, rule.Repository, rule.TagPattern, rule.CreatedBy, rule.CreatedAt)
+	if err != nil {
+		return err
+	}
+	rule.ID, err = res.LastInsertId()
+	return err
+}
+
+// GetImmutableTagRule returns a single immutable tag rule
+func (db *DB) GetImmutableTagRule(id int64) (*models.ImmutableTagRule, error) {
+	return scanImmutableTagRule(db.conn.QueryRow("SELECT "+immutableTagRuleColumns+" FROM immutable_tag_rules WHERE id=?", id))
+}
+
+// DeleteImmutableTagRule removes an immutable tag rule; its tags can be deleted and overwritten again
+func (db *DB) DeleteImmutableTagRule(id int64) error {
+	res, err := db.conn.Exec("DELETE FROM immutable_tag_rules WHERE id=?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
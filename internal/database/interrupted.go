@@ -0,0 +1,53 @@
+package database
+
+import (
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Interrupted Scans ---
+
+// AddInterruptedScan records a scan a shutdown cut short so the next start queues it again
+func (db *DB) AddInterruptedScan(registryID int64, repository, tag, scanner, platform string) error {
+	_, err := db.conn.Exec(`
+		INSERT INTO interrupted_scans (registry_id, repository, tag, scanner, platform) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(registry_id, repository, tag, scanner) DO UPDATE SET
+			platform=excluded.platform,
+			interrupted_at=CURRENT_TIMESTAMP
+	`, registryID, repository, tag, scanner, platform)
+	return err
+}
+
+// TakeInterruptedScans returns and forgets the recorded interrupted scans. With includeStuck, scans
+// still marked "scanning" or "interrupted" without a record (left by a crash) are returned as well,
+// to run with the default scanner.
+func (db *DB) TakeInterruptedScans(includeStuck bool) ([]models.InterruptedScan, error) {
+	query := "SELECT registry_id, repository, tag, scanner, platform FROM interrupted_scans"
+	if includeStuck {
+		query += ` UNION
+			SELECT registry_id, repository, tag, '', '' FROM vuln_scans s
+			WHERE status IN ('scanning', 'interrupted') AND NOT EXISTS (
+				SELECT 1 FROM interrupted_scans i
+				WHERE i.registry_id=s.registry_id AND i.repository=s.repository AND i.tag=s.tag)`
+	}
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	scans := []models.InterruptedScan{}
+	for rows.Next() {
+		var s models.InterruptedScan
+		if err := rows.Scan(&s.RegistryID, &s.Repository, &s.Tag, &s.Scanner, &s.Platform); err != nil {
+			continue
+		}
+		scans = append(scans, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := db.conn.Exec("DELETE FROM interrupted_scans"); err != nil {
+		return nil, err
+	}
+	return scans, nil
+}
@@ -0,0 +1,81 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Deleted Image Ledger ---
+
+// AddDeletedImage appends an entry to the deleted-image ledger; entries are never updated or removed
+func (db *DB) AddDeletedImage(d *models.DeletedImage) error {
+	if d.DeletedAt.IsZero() {
+		d.DeletedAt = time.Now()
+	}
+	res, err := db.conn.Exec(`
+		INSERT INTO deleted_images (registry_id, registry_name, repository, tag, digest, size, deleted_by, remote_addr, source, policy, reason, deleted_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, d.RegistryID, d.RegistryName, d.Repository, d.Tag, d.Digest, d.Size, d.DeletedBy, d.RemoteAddr, d.Source, d.Policy, d.Reason, d.DeletedAt)
+	if err != nil {
+		return err
+	}
+	d.ID, _ = res.LastInsertId()
+	return nil
+}
+
+// DeletedImageFilter narrows a ledger query; zero values match everything
+type DeletedImageFilter struct {
+	Digest     string // Full digest or prefix (e.g. "sha256:4f2a")
+	RegistryID int64
+	Repository string
+	Limit      int
+}
+
+// ListDeletedImages returns ledger entries matching the filter, newest first
+func (db *DB) ListDeletedImages(f DeletedImageFilter) ([]models.DeletedImage, error) {
+	var where []string
+	var args []interface{}
+	if f.Digest != "" {
+		where = append(where, "digest LIKE ? ESCAPE '\\'")
+		args = append(args, strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(f.Digest)+"%")
+	}
+	if f.RegistryID != 0 {
+		where = append(where, "registry_id=?")
+		args = append(args, f.RegistryID)
+	}
+	if f.Repository != "" {
+		where = append(where, "repository=?")
+		args = append(args, f.Repository)
+	}
+
+	query := `SELECT id, registry_id, registry_name, repository, tag, digest, size, deleted_by, remote_addr, source, policy, reason, deleted_at FROM deleted_images`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY deleted_at DESC, id DESC LIMIT ?"
+	args = append(args, f.Limit)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.DeletedImage{}
+	for rows.Next() {
+		var d models.DeletedImage
+		var deletedAt sql.NullTime
+		if err := rows.Scan(&d.ID, &d.RegistryID, &d.RegistryName, &d.Repository, &d.Tag, &d.Digest, &d.Size,
+			&d.DeletedBy, &d.RemoteAddr, &d.Source, &d.Policy, &d.Reason, &deletedAt); err != nil {
+			continue
+		}
+		if deletedAt.Valid {
+			d.DeletedAt = deletedAt.Time
+		}
+		entries = append(entries, d)
+	}
+	return entries, nil
+}
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/scanner"
+)
+
+const legacyScanMigration = "wrap_legacy_scan_reports"
+
+// migrateLegacyScans rewrites scan rows stored in the old unwrapped Trivy format
+// and backfills the normalized findings. It runs once per database.
+func (db *DB) migrateLegacyScans() error {
+	if _, err := db.conn.Exec(`CREATE TABLE IF NOT EXISTS data_migrations (
+		name TEXT PRIMARY KEY,
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`); err != nil {
+		return err
+	}
+
+	var applied int
+	db.conn.QueryRow("SELECT COUNT(*) FROM data_migrations WHERE name=?", legacyScanMigration).Scan(&applied)
+	if applied > 0 {
+		return nil
+	}
+
+	scans, err := db.listAllScans()
+	if err != nil {
+		return err
+	}
+
+	converted := 0
+	for _, s := range scans {
+		report := scanner.WrapLegacy(s.Report)
+		summary := scanner.WrapLegacy(s.Summary)
+		if report != s.Report || summary != s.Summary {
+			if _, err := db.conn.Exec("UPDATE vuln_scans SET report=?, summary=? WHERE id=?", report, summary, s.ID); err != nil {
+				return fmt.Errorf("failed to rewrite scan %d: %w", s.ID, err)
+			}
+			s.Report, s.Summary = report, summary
+			converted++
+		}
+		if s.Status == "completed" {
+			if err := db.replaceFindings(&s); err != nil {
+				return fmt.Errorf("failed to backfill findings for scan %d: %w", s.ID, err)
+			}
+		}
+	}
+
+	rows, err := db.conn.Query("SELECT id, report, summary FROM scan_history")
+	if err != nil {
+		return err
+	}
+	type historyRow struct {
+		id              int64
+		report, summary string
+	}
+	var history []historyRow
+	for rows.Next() {
+		var h historyRow
+		var report, summary sql.NullString
+		if err := rows.Scan(&h.id, &report, &summary); err != nil {
+			continue
+		}
+		h.report, h.summary = report.String, summary.String
+		history = append(history, h)
+	}
+	rows.Close()
+
+	for _, h := range history {
+		report := scanner.WrapLegacy(h.report)
+		summary := scanner.WrapLegacy(h.summary)
+		if report != h.report || summary != h.summary {
+			if _, err := db.conn.Exec("UPDATE scan_history SET report=?, summary=? WHERE id=?", report, summary, h.id); err != nil {
+				return fmt.Errorf("failed to rewrite scan history %d: %w", h.id, err)
+			}
+			converted++
+		}
+	}
+
+	if _, err := db.conn.Exec("INSERT INTO data_migrations (name, applied_at) VALUES (?, ?)", legacyScanMigration, time.Now()); err != nil {
+		return err
+	}
+	if converted > 0 {
+		fmt.Printf("🔧 Normalized %d legacy scan reports\n", converted)
+	}
+	return nil
+}
+
+// listAllScans returns every scan row across all registries
+func (db *DB) listAllScans() ([]models.VulnerabilityScan, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, registry_id, repository, tag, COALESCE(digest, ''), COALESCE(status, ''), COALESCE(summary, ''), COALESCE(report, ''), scanned_at
+		FROM vuln_scans
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scans []models.VulnerabilityScan
+	for rows.Next() {
+		var s models.VulnerabilityScan
+		var scannedAt sql.NullTime
+		if err := rows.Scan(&s.ID, &s.RegistryID, &s.Repository, &s.Tag, &s.Digest, &s.Status, &s.Summary, &s.Report, &scannedAt); err != nil {
+			continue
+		}
+		if scannedAt.Valid {
+			s.ScannedAt = scannedAt.Time
+		}
+		scans = append(scans, s)
+	}
+	return scans, nil
+}
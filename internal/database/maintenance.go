@@ -0,0 +1,53 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Maintenance Mode ---
+
+// GetMaintenanceMode returns the read-only switch; it is off until it was first turned on. It is
+// read from the database every time, so every dashboard sharing it sees the same switch.
+func (db *DB) GetMaintenanceMode() (*models.MaintenanceMode, error) {
+	var m models.MaintenanceMode
+	var started sql.NullTime
+	err := db.conn.QueryRow("SELECT enabled, reason, started_by, started_at FROM maintenance_mode WHERE id = 1").
+		Scan(&m.Enabled, &m.Reason, &m.StartedBy, &started)
+	if err == sql.ErrNoRows {
+		return &m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if started.Valid {
+		m.StartedAt = started.Time
+	}
+	return &m, nil
+}
+
+// SaveMaintenanceMode turns the read-only switch on or off; StartedAt is set when it is turned on
+func (db *DB) SaveMaintenanceMode(m *models.MaintenanceMode) error {
+	if m.Enabled {
+		m.StartedAt = time.Now()
+	} else {
+		m.StartedAt = time.Time{}
+	}
+	var started any
+	if m.Enabled {
+		started = m.StartedAt
+	}
+	_, err := db.conn.Exec(`
+		INSERT OR REPLACE INTO maintenance_mode (id, enabled, reason, started_by, started_at) VALUES (1, ?, ?, ?, ?)
+	`, m.Enabled, m.Reason, m.StartedBy, started)
+	return err
+}
+
+// InMaintenance reports whether the read-only switch is on. A switch that cannot be read counts
+// as off, so a database error does not stop background work on its own.
+func (db *DB) InMaintenance() bool {
+	m, err := db.GetMaintenanceMode()
+	return err == nil && m.Enabled
+}
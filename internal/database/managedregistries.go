@@ -0,0 +1,96 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Managed Registries ---
+
+const managedRegistryColumns = "id, name, port, storage, username, password_hash, enabled, registry_id, created_at"
+
+func scanManagedRegistry(row rowScanner) (*models.ManagedRegistry, error) {
+	var m models.ManagedRegistry
+	var storage string
+	var created sql.NullTime
+	if err := row.Scan(&m.ID, &m.Name, &m.Port, &storage, &m.Username, &m.PasswordHash, &m.Enabled, &m.RegistryID, &created); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(storage), &m.Storage); err != nil {
+		return nil, err
+	}
+	if created.Valid {
+		m.CreatedAt = created.Time
+	}
+	return &m, nil
+}
+
+// ListManagedRegistries returns the additional embedded registries by name
+func (db *DB) ListManagedRegistries() ([]models.ManagedRegistry, error) {
+	rows, err := db.conn.Query("SELECT " + managedRegistryColumns + " FROM managed_registries ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	registries := []models.ManagedRegistry{}
+	for rows.Next() {
+		m, err := scanManagedRegistry(rows)
+		if err != nil {
+			return nil, err
+		}
+		registries = append(registries, *m)
+	}
+	return registries, rows.Err()
+}
+
+// GetManagedRegistry returns a single additional embedded registry
+func (db *DB) GetManagedRegistry(id int64) (*models.ManagedRegistry, error) {
+	return scanManagedRegistry(db.conn.QueryRow("SELECT "+managedRegistryColumns+" FROM managed_registries WHERE id=?", id))
+}
+
+// CreateManagedRegistry adds an additional embedded registry; PasswordHash must already be set
+// when it has an account
+func (db *DB) CreateManagedRegistry(m *models.ManagedRegistry) error {
+	storage, err := json.Marshal(m.Storage)
+	if err != nil {
+		return err
+	}
+	m.CreatedAt = time.Now()
+	res, err := db.conn.Exec(`
+		INSERT INTO managed_registries (name, port, storage, username, password_hash, enabled, registry_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, m.Name, m.Port, string(storage), m.Username, m.PasswordHash, m.Enabled, m.RegistryID, m.CreatedAt)
+	if err != nil {
+		return err
+	}
+	m.ID, err = res.LastInsertId()
+	return err
+}
+
+// SetManagedRegistryEnabled records whether an additional embedded registry starts with the dashboard
+func (db *DB) SetManagedRegistryEnabled(id int64, enabled bool) error {
+	_, err := db.conn.Exec("UPDATE managed_registries SET enabled=? WHERE id=?", enabled, id)
+	return err
+}
+
+// SetManagedRegistryEntry records the registry entry an additional embedded registry was registered as
+func (db *DB) SetManagedRegistryEntry(id, registryID int64) error {
+	_, err := db.conn.Exec("UPDATE managed_registries SET registry_id=? WHERE id=?", registryID, id)
+	return err
+}
+
+// DeleteManagedRegistry removes an additional embedded registry
+func (db *DB) DeleteManagedRegistry(id int64) error {
+	res, err := db.conn.Exec("DELETE FROM managed_registries WHERE id=?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
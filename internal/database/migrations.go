@@ -0,0 +1,578 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Migration is one numbered schema change. Up applies it and Down reverts it; each runs in a
+// transaction together with its record in schema_migrations.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string // Empty: the migration cannot be reverted
+
+	upFunc func(s *schemaScript) error // Applies a migration that is not plain SQL, outside a transaction
+}
+
+// checksum fingerprints the scripts of a migration, so edits after it was applied are noticed
+func (m Migration) checksum() string {
+	up := m.Up
+	if m.upFunc != nil {
+		script := &schemaScript{}
+		m.upFunc(script)
+		up = strings.Join(script.statements, ";\n")
+	}
+	sum := sha256.Sum256([]byte(up + "\n-- down\n" + m.Down))
+	return hex.EncodeToString(sum[:8])
+}
+
+// sqliteMigrations are the schema changes of the SQLite database, in order. Schema changes are
+// appended as new migrations; a released migration is never edited or renumbered. The list of
+// each other driver (mysqlMigrations) has the same versions.
+var sqliteMigrations = []Migration{
+	{Version: 1, Name: "baseline", upFunc: baselineSchema},
+	{
+		Version: 2,
+		Name:    "vuln_scans_image_index",
+		Up:      "CREATE INDEX IF NOT EXISTS idx_vuln_scans_image ON vuln_scans(registry_id, repository, tag)",
+		Down:    "DROP INDEX IF EXISTS idx_vuln_scans_image",
+	},
+	{
+		Version: 3,
+		Name:    "registries_type_region",
+		Up: `ALTER TABLE registries ADD COLUMN type TEXT DEFAULT '';
+ALTER TABLE registries ADD COLUMN region TEXT DEFAULT ''`,
+		Down: `ALTER TABLE registries DROP COLUMN region;
+ALTER TABLE registries DROP COLUMN type`,
+	},
+	{
+		Version: 4,
+		Name:    "registries_tenant",
+		Up:      "ALTER TABLE registries ADD COLUMN tenant TEXT DEFAULT ''",
+		Down:    "ALTER TABLE registries DROP COLUMN tenant",
+	},
+	{
+		Version: 5,
+		Name:    "registries_namespaces",
+		Up:      "ALTER TABLE registries ADD COLUMN namespaces TEXT DEFAULT ''",
+		Down:    "ALTER TABLE registries DROP COLUMN namespaces",
+	},
+	{
+		Version: 6,
+		Name:    "registries_ca_cert",
+		Up:      "ALTER TABLE registries ADD COLUMN ca_cert TEXT DEFAULT ''",
+		Down:    "ALTER TABLE registries DROP COLUMN ca_cert",
+	},
+	{
+		Version: 7,
+		Name:    "registries_proxy",
+		Up:      "ALTER TABLE registries ADD COLUMN proxy TEXT DEFAULT ''",
+		Down:    "ALTER TABLE registries DROP COLUMN proxy",
+	},
+	{
+		Version: 8,
+		Name:    "replication_rules",
+		Up: `CREATE TABLE IF NOT EXISTS replication_rules (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	source_registry_id INTEGER NOT NULL,
+	target_registry_id INTEGER NOT NULL,
+	filter_repos TEXT DEFAULT '',
+	filter_tags TEXT DEFAULT '',
+	target_prefix TEXT DEFAULT '',
+	conflict TEXT DEFAULT 'overwrite',
+	interval_minutes INTEGER DEFAULT 0,
+	enabled BOOLEAN DEFAULT 1,
+	last_run_at DATETIME,
+	last_status TEXT DEFAULT '',
+	last_error TEXT DEFAULT '',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY(source_registry_id) REFERENCES registries(id) ON DELETE CASCADE,
+	FOREIGN KEY(target_registry_id) REFERENCES registries(id) ON DELETE CASCADE
+)`,
+		Down: "DROP TABLE IF EXISTS replication_rules",
+	},
+	{
+		Version: 9,
+		Name:    "replication_deletes_and_runs",
+		Up: `ALTER TABLE replication_rules ADD COLUMN propagate_deletes BOOLEAN DEFAULT 0;
+CREATE TABLE IF NOT EXISTS replication_runs (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	rule_id INTEGER NOT NULL,
+	trigger_type VARCHAR(32) DEFAULT '',
+	status VARCHAR(32) NOT NULL,
+	repositories INTEGER DEFAULT 0,
+	tags INTEGER DEFAULT 0,
+	copied INTEGER DEFAULT 0,
+	up_to_date INTEGER DEFAULT 0,
+	conflicts INTEGER DEFAULT 0,
+	failed INTEGER DEFAULT 0,
+	deleted INTEGER DEFAULT 0,
+	copied_blobs INTEGER DEFAULT 0,
+	skipped_blobs INTEGER DEFAULT 0,
+	copied_bytes INTEGER DEFAULT 0,
+	errors TEXT DEFAULT '',
+	started_at DATETIME,
+	finished_at DATETIME,
+	FOREIGN KEY(rule_id) REFERENCES replication_rules(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_replication_runs_rule ON replication_runs(rule_id, id)`,
+		Down: `DROP TABLE IF EXISTS replication_runs;
+ALTER TABLE replication_rules DROP COLUMN propagate_deletes`,
+	},
+	{
+		Version: 10,
+		Name:    "storage_configs_proxy",
+		Up: `ALTER TABLE storage_configs ADD COLUMN proxy_remote_url TEXT DEFAULT '';
+ALTER TABLE storage_configs ADD COLUMN proxy_username TEXT DEFAULT '';
+ALTER TABLE storage_configs ADD COLUMN proxy_password TEXT DEFAULT ''`,
+		Down: `ALTER TABLE storage_configs DROP COLUMN proxy_password;
+ALTER TABLE storage_configs DROP COLUMN proxy_username;
+ALTER TABLE storage_configs DROP COLUMN proxy_remote_url`,
+	},
+	{
+		Version: 11,
+		Name:    "registry_users",
+		Up: `CREATE TABLE IF NOT EXISTS registry_users (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	username TEXT NOT NULL UNIQUE,
+	password_hash TEXT NOT NULL,
+	managed BOOLEAN DEFAULT 0,
+	created_at DATETIME
+)`,
+		Down: "DROP TABLE IF EXISTS registry_users",
+	},
+	{
+		Version: 12,
+		Name:    "registry_permissions",
+		Up: `CREATE TABLE IF NOT EXISTS registry_permissions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id INTEGER NOT NULL,
+	repository TEXT NOT NULL,
+	actions TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_registry_permissions_user ON registry_permissions(user_id)`,
+		Down: "DROP TABLE IF EXISTS registry_permissions",
+	},
+	{
+		Version: 13,
+		Name:    "storage_configs_gcs",
+		Up: `ALTER TABLE storage_configs ADD COLUMN gcs_bucket TEXT DEFAULT '';
+ALTER TABLE storage_configs ADD COLUMN gcs_credentials TEXT DEFAULT '';
+ALTER TABLE storage_configs ADD COLUMN gcs_root_directory TEXT DEFAULT ''`,
+		Down: `ALTER TABLE storage_configs DROP COLUMN gcs_root_directory;
+ALTER TABLE storage_configs DROP COLUMN gcs_credentials;
+ALTER TABLE storage_configs DROP COLUMN gcs_bucket`,
+	},
+	{
+		Version: 14,
+		Name:    "storage_configs_swift",
+		Up: `ALTER TABLE storage_configs ADD COLUMN swift_auth_url TEXT DEFAULT '';
+ALTER TABLE storage_configs ADD COLUMN swift_username TEXT DEFAULT '';
+ALTER TABLE storage_configs ADD COLUMN swift_password TEXT DEFAULT '';
+ALTER TABLE storage_configs ADD COLUMN swift_container TEXT DEFAULT '';
+ALTER TABLE storage_configs ADD COLUMN swift_region TEXT DEFAULT ''`,
+		Down: `ALTER TABLE storage_configs DROP COLUMN swift_region;
+ALTER TABLE storage_configs DROP COLUMN swift_container;
+ALTER TABLE storage_configs DROP COLUMN swift_password;
+ALTER TABLE storage_configs DROP COLUMN swift_username;
+ALTER TABLE storage_configs DROP COLUMN swift_auth_url`,
+	},
+	{
+		Version: 15,
+		Name:    "storage_configs_redis_cache",
+		Up: `ALTER TABLE storage_configs ADD COLUMN redis_cache INTEGER DEFAULT 0;
+ALTER TABLE storage_configs ADD COLUMN redis_addr TEXT DEFAULT '';
+ALTER TABLE storage_configs ADD COLUMN redis_password TEXT DEFAULT '';
+ALTER TABLE storage_configs ADD COLUMN redis_db INTEGER DEFAULT 0`,
+		Down: `ALTER TABLE storage_configs DROP COLUMN redis_db;
+ALTER TABLE storage_configs DROP COLUMN redis_password;
+ALTER TABLE storage_configs DROP COLUMN redis_addr;
+ALTER TABLE storage_configs DROP COLUMN redis_cache`,
+	},
+	{
+		Version: 16,
+		Name:    "storage_configs_overrides",
+		Up:      `ALTER TABLE storage_configs ADD COLUMN config_overrides TEXT DEFAULT ''`,
+		Down:    `ALTER TABLE storage_configs DROP COLUMN config_overrides`,
+	},
+	{
+		Version: 17,
+		Name:    "storage_configs_registry_image",
+		Up:      `ALTER TABLE storage_configs ADD COLUMN registry_image TEXT DEFAULT ''`,
+		Down:    `ALTER TABLE storage_configs DROP COLUMN registry_image`,
+	},
+	{
+		Version: 18,
+		Name:    "managed_registries",
+		Up: `CREATE TABLE IF NOT EXISTS managed_registries (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE,
+	port INTEGER NOT NULL UNIQUE,
+	storage TEXT NOT NULL,
+	username TEXT DEFAULT '',
+	password_hash TEXT DEFAULT '',
+	enabled BOOLEAN DEFAULT 1,
+	registry_id INTEGER DEFAULT 0,
+	created_at DATETIME
+)`,
+		Down: "DROP TABLE IF EXISTS managed_registries",
+	},
+	{
+		Version: 19,
+		Name:    "embedded_registry_settings",
+		Up: `CREATE TABLE IF NOT EXISTS embedded_registry_settings (
+	id INTEGER PRIMARY KEY,
+	port INTEGER NOT NULL,
+	container_name TEXT NOT NULL,
+	updated_at DATETIME
+)`,
+		Down: "DROP TABLE IF EXISTS embedded_registry_settings",
+	},
+	{
+		Version: 20,
+		Name:    "registry_events_actor_ip",
+		Up: `ALTER TABLE registry_events ADD COLUMN actor_ip TEXT DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_registry_events_timestamp ON registry_events(timestamp)`,
+		Down: `DROP INDEX IF EXISTS idx_registry_events_timestamp;
+ALTER TABLE registry_events DROP COLUMN actor_ip`,
+	},
+	{
+		Version: 21,
+		Name:    "maintenance_mode",
+		Up: `CREATE TABLE IF NOT EXISTS maintenance_mode (
+	id INTEGER PRIMARY KEY,
+	enabled BOOLEAN DEFAULT 0,
+	reason TEXT DEFAULT '',
+	started_by TEXT DEFAULT '',
+	started_at DATETIME
+)`,
+		Down: "DROP TABLE IF EXISTS maintenance_mode",
+	},
+	{
+		Version: 22,
+		Name:    "projects_and_teams",
+		Up: `CREATE TABLE IF NOT EXISTS projects (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE,
+	description TEXT DEFAULT '',
+	registries TEXT DEFAULT '[]',
+	repositories TEXT DEFAULT '[]',
+	created_at DATETIME
+);
+CREATE TABLE IF NOT EXISTS teams (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE,
+	members TEXT DEFAULT '[]',
+	projects TEXT DEFAULT '[]',
+	created_at DATETIME
+)`,
+		Down: `DROP TABLE IF EXISTS teams;
+DROP TABLE IF EXISTS projects`,
+	},
+	{
+		Version: 23,
+		Name:    "access_rules",
+		Up: `CREATE TABLE IF NOT EXISTS access_rules (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	team_id INTEGER NOT NULL,
+	registry_id INTEGER DEFAULT 0,
+	repository TEXT NOT NULL,
+	actions TEXT NOT NULL,
+	created_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_access_rules_team ON access_rules(team_id)`,
+		Down: "DROP TABLE IF EXISTS access_rules",
+	},
+	{
+		Version: 24,
+		Name:    "immutable_tag_rules",
+		Up: `CREATE TABLE IF NOT EXISTS immutable_tag_rules (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	registry_id INTEGER NOT NULL,
+	repository TEXT DEFAULT '',
+	tag_pattern TEXT NOT NULL,
+	created_by TEXT DEFAULT '',
+	created_at DATETIME
+);
+CREATE INDEX IF NOT EXISTS idx_immutable_tag_rules_registry ON immutable_tag_rules(registry_id)`,
+		Down: "DROP TABLE IF EXISTS immutable_tag_rules",
+	},
+	{
+		Version: 25,
+		Name:    "repository_quotas",
+		Up: `CREATE TABLE IF NOT EXISTS repository_quotas (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	registry_id INTEGER DEFAULT 0,
+	repository TEXT DEFAULT '',
+	project_id INTEGER DEFAULT 0,
+	limit_bytes INTEGER NOT NULL,
+	warn_percent INTEGER DEFAULT 80,
+	block_copies INTEGER DEFAULT 0,
+	alert_webhook_url TEXT DEFAULT '',
+	used_bytes INTEGER DEFAULT 0,
+	state TEXT DEFAULT '',
+	check_error TEXT DEFAULT '',
+	checked_at DATETIME,
+	created_at DATETIME
+)`,
+		Down: "DROP TABLE IF EXISTS repository_quotas",
+	},
+	{
+		Version: 26,
+		Name:    "repo_metadata",
+		Up: `CREATE TABLE IF NOT EXISTS repo_metadata (
+	registry_id INTEGER NOT NULL,
+	repository TEXT NOT NULL,
+	description TEXT DEFAULT '',
+	readme TEXT DEFAULT '',
+	links TEXT DEFAULT '[]',
+	updated_by TEXT DEFAULT '',
+	updated_at DATETIME,
+	PRIMARY KEY(registry_id, repository),
+	FOREIGN KEY(registry_id) REFERENCES registries(id) ON DELETE CASCADE
+)`,
+		Down: "DROP TABLE IF EXISTS repo_metadata",
+	},
+	{
+		Version: 27,
+		Name:    "notation_trust",
+		Up: `CREATE TABLE IF NOT EXISTS notation_trust_policy (
+	id INTEGER PRIMARY KEY,
+	document TEXT NOT NULL,
+	updated_by TEXT DEFAULT '',
+	updated_at DATETIME
+);
+CREATE TABLE IF NOT EXISTS notation_trust_stores (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	type TEXT NOT NULL,
+	name TEXT NOT NULL,
+	certificates TEXT NOT NULL,
+	created_at DATETIME,
+	UNIQUE(type, name)
+)`,
+		Down: `DROP TABLE IF EXISTS notation_trust_stores;
+DROP TABLE IF EXISTS notation_trust_policy`,
+	},
+	{
+		Version: 28,
+		Name:    "signing_keys",
+		Up: `CREATE TABLE IF NOT EXISTS signing_keys (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL UNIQUE,
+	public_key TEXT NOT NULL,
+	private_key TEXT NOT NULL,
+	created_by TEXT DEFAULT '',
+	created_at DATETIME
+)`,
+		Down: `DROP TABLE IF EXISTS signing_keys`,
+	},
+	{
+		Version: 29,
+		Name:    "signing_key_projects",
+		Up:      "ALTER TABLE signing_keys ADD COLUMN projects TEXT DEFAULT '[]'",
+		Down:    "ALTER TABLE signing_keys DROP COLUMN projects",
+	},
+	{
+		Version: 30,
+		Name:    "registries_repositories_synced_at",
+		Up: `ALTER TABLE registries ADD COLUMN repositories_synced_at DATETIME;
+UPDATE registries SET repositories_synced_at = (SELECT MAX(last_seen_at) FROM repositories WHERE repositories.registry_id = registries.id)`,
+		Down: "ALTER TABLE registries DROP COLUMN repositories_synced_at",
+	},
+}
+
+// LatestMigration is the schema version this build expects
+func LatestMigration() int {
+	return sqliteMigrations[len(sqliteMigrations)-1].Version
+}
+
+// MigrationStatus is a migration and whether it is applied to a database
+type MigrationStatus struct {
+	Version    int
+	Name       string
+	Applied    bool
+	AppliedAt  time.Time
+	Modified   bool // Applied from scripts that differ from this build's
+	Revertible bool // Has a down script
+}
+
+// Migrate applies the pending migrations, then normalizes data left by older versions
+func (db *DB) Migrate() error {
+	if err := db.MigrateTo(LatestMigration()); err != nil {
+		return err
+	}
+	return db.migrateLegacyScans()
+}
+
+// appliedMigrations returns the checksum and time of each applied migration by version
+func (db *DB) appliedMigrations() (map[int]MigrationStatus, error) {
+	_, err := db.conn.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		name VARCHAR(255) NOT NULL,
+		checksum VARCHAR(64) DEFAULT '',
+		applied_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := db.conn.Query("SELECT version, name, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]MigrationStatus)
+	var unsummed []Migration
+	for rows.Next() {
+		var s MigrationStatus
+		var checksum string
+		if err := rows.Scan(&s.Version, &s.Name, &checksum, &s.AppliedAt); err != nil {
+			return nil, err
+		}
+		s.Applied = true
+		for _, m := range db.conn.dialect.migrations {
+			if m.Version != s.Version {
+				continue
+			}
+			if checksum == "" && m.upFunc != nil {
+				// Recorded before migrations written in Go had a checksum
+				unsummed = append(unsummed, m)
+				continue
+			}
+			s.Modified = m.checksum() != checksum
+		}
+		applied[s.Version] = s
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	for _, m := range unsummed {
+		if _, err := db.conn.Exec("UPDATE schema_migrations SET checksum=? WHERE version=?", m.checksum(), m.Version); err != nil {
+			return nil, err
+		}
+	}
+	return applied, nil
+}
+
+// Migrations lists the known migrations and which of them are applied
+func (db *DB) Migrations() ([]MigrationStatus, error) {
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+	migrations := db.conn.dialect.migrations
+	list := make([]MigrationStatus, 0, len(migrations))
+	for _, m := range migrations {
+		s := applied[m.Version]
+		s.Version, s.Name, s.Revertible = m.Version, m.Name, m.Down != ""
+		list = append(list, s)
+	}
+	return list, nil
+}
+
+// MigrateTo applies or reverts migrations until the schema is at the target version. It refuses a
+// database whose schema is newer than this build, which would misread it.
+func (db *DB) MigrateTo(target int) error {
+	if target < 0 || target > LatestMigration() {
+		return fmt.Errorf("unknown schema version %d (latest is %d)", target, LatestMigration())
+	}
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return err
+	}
+	for version, s := range applied {
+		if version > LatestMigration() {
+			return fmt.Errorf("database schema version %d is newer than this build (%d); upgrade the dashboard", version, LatestMigration())
+		}
+		if s.Modified {
+			log.Printf("⚠️  Migration %d (%s) changed after it was applied to this database", version, s.Name)
+		}
+	}
+
+	migrations := db.conn.dialect.migrations
+	for _, m := range migrations {
+		if m.Version > target {
+			break
+		}
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		if err := db.applyMigration(m); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		if len(applied) > 0 {
+			log.Printf("🗃️  Applied migration %d (%s)", m.Version, m.Name)
+		}
+	}
+
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if m.Version <= target {
+			break
+		}
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if err := db.revertMigration(m); err != nil {
+			return fmt.Errorf("reverting migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		log.Printf("🗃️  Reverted migration %d (%s)", m.Version, m.Name)
+	}
+	return nil
+}
+
+func (db *DB) applyMigration(m Migration) error {
+	if m.upFunc != nil {
+		if err := m.upFunc(&schemaScript{conn: db.conn}); err != nil {
+			return err
+		}
+		_, err := db.conn.Exec("INSERT INTO schema_migrations (version, name, checksum) VALUES (?, ?, ?)", m.Version, m.Name, m.checksum())
+		return err
+	}
+	return db.inTx(func(tx *sqlTx) error {
+		for _, stmt := range db.conn.dialect.statements(m.Up) {
+			if _, err := tx.Exec(stmt); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec("INSERT INTO schema_migrations (version, name, checksum) VALUES (?, ?, ?)", m.Version, m.Name, m.checksum())
+		return err
+	})
+}
+
+func (db *DB) revertMigration(m Migration) error {
+	if m.Down == "" {
+		return fmt.Errorf("it cannot be reverted")
+	}
+	return db.inTx(func(tx *sqlTx) error {
+		for _, stmt := range db.conn.dialect.statements(m.Down) {
+			if _, err := tx.Exec(stmt); err != nil {
+				return err
+			}
+		}
+		_, err := tx.Exec("DELETE FROM schema_migrations WHERE version = ?", m.Version)
+		return err
+	})
+}
+
+// inTx runs fn in a transaction, committing when it succeeds. MySQL commits schema changes as
+// they run, so a failed MySQL migration may be left half applied.
+func (db *DB) inTx(fn func(tx *sqlTx) error) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
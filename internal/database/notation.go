@@ -0,0 +1,118 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Notation Trust Policy ---
+
+// GetNotationTrustPolicy returns the Notation trust policy; an empty version 1.0 policy until one is saved
+func (db *DB) GetNotationTrustPolicy() (*models.NotationTrustPolicyDocument, error) {
+	var document string
+	err := db.conn.QueryRow("SELECT document FROM notation_trust_policy WHERE id = 1").Scan(&document)
+	if err == sql.ErrNoRows {
+		return &models.NotationTrustPolicyDocument{Version: "1.0", TrustPolicies: []models.NotationTrustPolicy{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var policy models.NotationTrustPolicyDocument
+	if err := json.Unmarshal([]byte(document), &policy); err != nil {
+		return nil, err
+	}
+	if policy.TrustPolicies == nil {
+		policy.TrustPolicies = []models.NotationTrustPolicy{}
+	}
+	return &policy, nil
+}
+
+// SaveNotationTrustPolicy replaces the Notation trust policy
+func (db *DB) SaveNotationTrustPolicy(policy *models.NotationTrustPolicyDocument, updatedBy string) error {
+	document, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	_, err = db.conn.Exec("INSERT OR REPLACE INTO notation_trust_policy (id, document, updated_by, updated_at) VALUES (1, ?, ?, ?)",
+		string(document), updatedBy, time.Now())
+	return err
+}
+
+// --- Notation Trust Stores ---
+
+const notationTrustStoreColumns = "id, type, name, certificates, created_at"
+
+func scanNotationTrustStore(row rowScanner) (*models.NotationTrustStore, error) {
+	var s models.NotationTrustStore
+	var createdAt sql.NullTime
+	if err := row.Scan(&s.ID, &s.Type, &s.Name, &s.Certificates, &createdAt); err != nil {
+		return nil, err
+	}
+	if createdAt.Valid {
+		s.CreatedAt = createdAt.Time
+	}
+	return &s, nil
+}
+
+// ListNotationTrustStores returns the Notation trust stores by type and name
+func (db *DB) ListNotationTrustStores() ([]models.NotationTrustStore, error) {
+	rows, err := db.conn.Query("SELECT " + notationTrustStoreColumns + " FROM notation_trust_stores ORDER BY type, name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stores := []models.NotationTrustStore{}
+	for rows.Next() {
+		s, err := scanNotationTrustStore(rows)
+		if err != nil {
+			return nil, err
+		}
+		stores = append(stores, *s)
+	}
+	return stores, rows.Err()
+}
+
+// GetNotationTrustStore returns a single Notation trust store
+func (db *DB) GetNotationTrustStore(id int64) (*models.NotationTrustStore, error) {
+	return scanNotationTrustStore(db.conn.QueryRow("SELECT "+notationTrustStoreColumns+" FROM notation_trust_stores WHERE id=?", id))
+}
+
+// SaveNotationTrustStore creates (ID 0) or updates a Notation trust store
+func (db *DB) SaveNotationTrustStore(s *models.NotationTrustStore) error {
+	if s.ID == 0 {
+		s.CreatedAt = time.Now()
+		res, err := db.conn.Exec("INSERT INTO notation_trust_stores (type, name, certificates, created_at) VALUES (?, ?, ?, ?)",
+			s.Type, s.Name, s.Certificates, s.CreatedAt)
+		if err != nil {
+			return err
+		}
+		s.ID, err = res.LastInsertId()
+		return err
+	}
+
+	res, err := db.conn.Exec("UPDATE notation_trust_stores SET type=?, name=?, certificates=? WHERE id=?",
+		s.Type, s.Name, s.Certificates, s.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteNotationTrustStore removes a Notation trust store; policies naming it fail to verify until it is added again
+func (db *DB) DeleteNotationTrustStore(id int64) error {
+	res, err := db.conn.Exec("DELETE FROM notation_trust_stores WHERE id=?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Retention Templates ---
+
+// ListRetentionTemplates returns all retention templates
+func (db *DB) ListRetentionTemplates() ([]models.RetentionTemplate, error) {
+	rows, err := db.conn.Query("SELECT id, name, keep_last_count, keep_days, exclude_tags FROM retention_templates ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []models.RetentionTemplate{}
+	for rows.Next() {
+		var t models.RetentionTemplate
+		if err := rows.Scan(&t.ID, &t.Name, &t.KeepLastCount, &t.KeepDays, &t.ExcludeTags); err != nil {
+			continue
+		}
+		templates = append(templates, t)
+	}
+	return templates, nil
+}
+
+// GetRetentionTemplate returns a single retention template
+func (db *DB) GetRetentionTemplate(id int64) (*models.RetentionTemplate, error) {
+	var t models.RetentionTemplate
+	err := db.conn.QueryRow("SELECT id, name, keep_last_count, keep_days, exclude_tags FROM retention_templates WHERE id=?", id).
+		Scan(&t.ID, &t.Name, &t.KeepLastCount, &t.KeepDays, &t.ExcludeTags)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// RepositoryRetentionTemplates maps repositories of a registry to their assigned retention template
+func (db *DB) RepositoryRetentionTemplates(registryID int64) (map[string]models.RetentionTemplate, error) {
+	infos, err := db.ListRepositoryInfo(registryID)
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make(map[string]models.RetentionTemplate)
+	for name, info := range infos {
+		if info.RetentionTemplateID == 0 {
+			continue
+		}
+		t, err := db.GetRetentionTemplate(info.RetentionTemplateID)
+		if err != nil {
+			continue // Template was deleted
+		}
+		templates[name] = *t
+	}
+	return templates, nil
+}
+
+// SaveRetentionTemplate creates (ID 0) or updates a retention template
+func (db *DB) SaveRetentionTemplate(t *models.RetentionTemplate) error {
+	if t.ID == 0 {
+		res, err := db.conn.Exec("INSERT INTO retention_templates (name, keep_last_count, keep_days, exclude_tags) VALUES (?, ?, ?, ?)",
+			t.Name, t.KeepLastCount, t.KeepDays, t.ExcludeTags)
+		if err != nil {
+			return err
+		}
+		t.ID, err = res.LastInsertId()
+		return err
+	}
+	res, err := db.conn.Exec("UPDATE retention_templates SET name=?, keep_last_count=?, keep_days=?, exclude_tags=? WHERE id=?",
+		t.Name, t.KeepLastCount, t.KeepDays, t.ExcludeTags, t.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteRetentionTemplate deletes a template and unassigns it from repositories and rules
+func (db *DB) DeleteRetentionTemplate(id int64) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM retention_templates WHERE id=?", id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE repositories SET retention_template_id=0 WHERE retention_template_id=?", id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE onboarding_rules SET retention_template_id=0 WHERE retention_template_id=?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// --- Onboarding Rules ---
+
+// ListOnboardingRules returns all onboarding rules in evaluation order (oldest first)
+func (db *DB) ListOnboardingRules() ([]models.OnboardingRule, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, name, registry_id, pattern, enabled, owner, labels, scan_mode, retention_template_id, created_at
+		FROM onboarding_rules ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []models.OnboardingRule{}
+	for rows.Next() {
+		var r models.OnboardingRule
+		var labels string
+		var createdAt sql.NullTime
+		if err := rows.Scan(&r.ID, &r.Name, &r.RegistryID, &r.Pattern, &r.Enabled, &r.Owner, &labels, &r.ScanMode, &r.RetentionTemplateID, &createdAt); err != nil {
+			continue
+		}
+		r.Labels = decodeLabels(labels)
+		if createdAt.Valid {
+			r.CreatedAt = createdAt.Time
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+// SaveOnboardingRule creates (ID 0) or updates an onboarding rule
+func (db *DB) SaveOnboardingRule(r *models.OnboardingRule) error {
+	labels, err := json.Marshal(r.Labels)
+	if err != nil {
+		return err
+	}
+
+	if r.ID == 0 {
+		r.CreatedAt = time.Now()
+		res, err := db.conn.Exec(`
+			INSERT INTO onboarding_rules (name, registry_id, pattern, enabled, owner, labels, scan_mode, retention_template_id, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, r.Name, r.RegistryID, r.Pattern, r.Enabled, r.Owner, string(labels), r.ScanMode, r.RetentionTemplateID, r.CreatedAt)
+		if err != nil {
+			return err
+		}
+		r.ID, err = res.LastInsertId()
+		return err
+	}
+
+	res, err := db.conn.Exec(`
+		UPDATE onboarding_rules SET name=?, registry_id=?, pattern=?, enabled=?, owner=?, labels=?, scan_mode=?, retention_template_id=?
+		WHERE id=?
+	`, r.Name, r.RegistryID, r.Pattern, r.Enabled, r.Owner, string(labels), r.ScanMode, r.RetentionTemplateID, r.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteOnboardingRule deletes an onboarding rule; repositories it already set up keep their metadata
+func (db *DB) DeleteOnboardingRule(id int64) error {
+	_, err := db.conn.Exec("DELETE FROM onboarding_rules WHERE id=?", id)
+	return err
+}
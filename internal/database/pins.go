@@ -0,0 +1,128 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Tag Pins ---
+
+const tagPinColumns = `id, registry_id, repository, tag, digest, alert_webhook_url, pinned_by, pinned_at, last_checked_at, drifted, observed_digest`
+
+func scanTagPin(row rowScanner) (*models.TagPin, error) {
+	var p models.TagPin
+	var checked sql.NullTime
+	if err := row.Scan(&p.ID, &p.RegistryID, &p.Repository, &p.Tag, &p.Digest, &p.AlertWebhookURL,
+		&p.PinnedBy, &p.PinnedAt, &checked, &p.Drifted, &p.ObservedDigest); err != nil {
+		return nil, err
+	}
+	p.LastCheckedAt = timePtr(checked)
+	return &p, nil
+}
+
+// ListTagPins returns the pinned tags of a registry
+func (db *DB) ListTagPins(registryID int64) ([]models.TagPin, error) {
+	rows, err := db.conn.Query("SELECT "+tagPinColumns+" FROM tag_pins WHERE registry_id=? ORDER BY repository, tag", registryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pins := []models.TagPin{}
+	for rows.Next() {
+		p, err := scanTagPin(rows)
+		if err != nil {
+			continue
+		}
+		pins = append(pins, *p)
+	}
+	return pins, nil
+}
+
+// GetTagPin returns a single tag pin
+func (db *DB) GetTagPin(id int64) (*models.TagPin, error) {
+	return scanTagPin(db.conn.QueryRow("SELECT "+tagPinColumns+" FROM tag_pins WHERE id=?", id))
+}
+
+// SaveTagPin pins a tag to p.Digest; pinning an already pinned tag moves the pin and clears its drift
+func (db *DB) SaveTagPin(p *models.TagPin) error {
+	if p.PinnedAt.IsZero() {
+		p.PinnedAt = time.Now()
+	}
+	_, err := db.conn.Exec(`
+		INSERT INTO tag_pins (registry_id, repository, tag, digest, alert_webhook_url, pinned_by, pinned_at) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(registry_id, repository, tag) DO UPDATE SET
+			digest=excluded.digest,
+			alert_webhook_url=excluded.alert_webhook_url,
+			pinned_by=excluded.pinned_by,
+			pinned_at=excluded.pinned_at,
+			drifted=0,
+			observed_digest=''
+	`, p.RegistryID, p.Repository, p.Tag, p.Digest, p.AlertWebhookURL, p.PinnedBy, p.PinnedAt)
+	if err != nil {
+		return err
+	}
+	// Not RETURNING, which MySQL lacks; the last insert ID is not set when the pin is moved
+	err = db.conn.QueryRow("SELECT id FROM tag_pins WHERE registry_id=? AND repository=? AND tag=?", p.RegistryID, p.Repository, p.Tag).Scan(&p.ID)
+	if err != nil {
+		return err
+	}
+	p.Drifted, p.ObservedDigest = false, ""
+	return nil
+}
+
+// DeleteTagPin removes a pin; its drift history is kept
+func (db *DB) DeleteTagPin(id int64) error {
+	res, err := db.conn.Exec("DELETE FROM tag_pins WHERE id=?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetTagPinCheck stores the outcome of checking a pin during sync
+func (db *DB) SetTagPinCheck(id int64, checkedAt time.Time, drifted bool, observedDigest string) error {
+	_, err := db.conn.Exec("UPDATE tag_pins SET last_checked_at=?, drifted=?, observed_digest=? WHERE id=?",
+		checkedAt, drifted, observedDigest, id)
+	return err
+}
+
+// AddTagDrift records a drift of a pinned tag
+func (db *DB) AddTagDrift(d *models.TagDrift) error {
+	res, err := db.conn.Exec(`
+		INSERT INTO tag_drifts (pin_id, registry_id, repository, tag, pinned_digest, observed_digest, detected_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, d.PinID, d.RegistryID, d.Repository, d.Tag, d.PinnedDigest, d.ObservedDigest, d.DetectedAt)
+	if err != nil {
+		return err
+	}
+	d.ID, _ = res.LastInsertId()
+	return nil
+}
+
+// ListTagDrifts returns the drifts recorded for a registry, newest first
+func (db *DB) ListTagDrifts(registryID int64, limit int) ([]models.TagDrift, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, pin_id, registry_id, repository, tag, pinned_digest, observed_digest, detected_at
+		FROM tag_drifts WHERE registry_id=? ORDER BY detected_at DESC, id DESC LIMIT ?
+	`, registryID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drifts := []models.TagDrift{}
+	for rows.Next() {
+		var d models.TagDrift
+		if err := rows.Scan(&d.ID, &d.PinID, &d.RegistryID, &d.Repository, &d.Tag, &d.PinnedDigest, &d.ObservedDigest, &d.DetectedAt); err != nil {
+			continue
+		}
+		drifts = append(drifts, d)
+	}
+	return drifts, nil
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"encoding/json"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Registry Profiles ---
+
+// GetRegistryProfile returns the capability profile of a registry, or sql.ErrNoRows if it was never probed
+func (db *DB) GetRegistryProfile(registryID int64) (*models.RegistryProfile, error) {
+	var data string
+	if err := db.conn.QueryRow("SELECT profile FROM registry_profiles WHERE registry_id=?", registryID).Scan(&data); err != nil {
+		return nil, err
+	}
+	var p models.RegistryProfile
+	if err := json.Unmarshal([]byte(data), &p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ListRegistryProfiles returns the profiles probed at each registry's current URL, keyed by that URL
+func (db *DB) ListRegistryProfiles() (map[string]*models.RegistryProfile, error) {
+	rows, err := db.conn.Query(`
+		SELECT p.url, p.profile FROM registry_profiles p
+		JOIN registries r ON r.id = p.registry_id AND r.url = p.url
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	profiles := make(map[string]*models.RegistryProfile)
+	for rows.Next() {
+		var url, data string
+		if err := rows.Scan(&url, &data); err != nil {
+			return nil, err
+		}
+		var p models.RegistryProfile
+		if json.Unmarshal([]byte(data), &p) != nil {
+			continue
+		}
+		profiles[url] = &p
+	}
+	return profiles, rows.Err()
+}
+
+// SaveRegistryProfile replaces the stored profile of a registry, probed at url
+func (db *DB) SaveRegistryProfile(url string, p *models.RegistryProfile) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	_, err = db.conn.Exec(`
+		INSERT OR REPLACE INTO registry_profiles (registry_id, url, profile, probed_at) VALUES (?, ?, ?, ?)
+	`, p.RegistryID, url, string(data), p.ProbedAt)
+	return err
+}
+
+// DeleteRegistryProfile forgets the profile of a registry, e.g. when it moves to another URL
+func (db *DB) DeleteRegistryProfile(registryID int64) error {
+	_, err := db.conn.Exec("DELETE FROM registry_profiles WHERE registry_id=?", registryID)
+	return err
+}
@@ -0,0 +1,277 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Projects ---
+
+func scanProject(row rowScanner) (*models.Project, error) {
+	var p models.Project
+	var registries, repositories string
+	var createdAt sql.NullTime
+	if err := row.Scan(&p.ID, &p.Name, &p.Description, &registries, &repositories, &createdAt); err != nil {
+		return nil, err
+	}
+	p.Registries = []int64{}
+	p.Repositories = []models.ProjectRepository{}
+	if registries != "" {
+		json.Unmarshal([]byte(registries), &p.Registries)
+	}
+	if repositories != "" {
+		json.Unmarshal([]byte(repositories), &p.Repositories)
+	}
+	if createdAt.Valid {
+		p.CreatedAt = createdAt.Time
+	}
+	return &p, nil
+}
+
+// ListProjects returns all projects by name
+func (db *DB) ListProjects() ([]models.Project, error) {
+	rows, err := db.conn.Query("SELECT id, name, description, registries, repositories, created_at FROM projects ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := []models.Project{}
+	for rows.Next() {
+		p, err := scanProject(rows)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, *p)
+	}
+	return projects, rows.Err()
+}
+
+// GetProject returns a single project
+func (db *DB) GetProject(id int64) (*models.Project, error) {
+	return scanProject(db.conn.QueryRow("SELECT id, name, description, registries, repositories, created_at FROM projects WHERE id=?", id))
+}
+
+// SaveProject creates (ID 0) or updates a project
+func (db *DB) SaveProject(p *models.Project) error {
+	if p.Registries == nil {
+		p.Registries = []int64{}
+	}
+	if p.Repositories == nil {
+		p.Repositories = []models.ProjectRepository{}
+	}
+	registries, err := json.Marshal(p.Registries)
+	if err != nil {
+		return err
+	}
+	repositories, err := json.Marshal(p.Repositories)
+	if err != nil {
+		return err
+	}
+
+	if p.ID == 0 {
+		p.CreatedAt = time.Now()
+		res, err := db.conn.Exec("INSERT INTO projects (name, description, registries, repositories, created_at) VALUES (?, ?, ?, ?, ?)",
+			p.Name, p.Description, string(registries), string(repositories), p.CreatedAt)
+		if err != nil {
+			return err
+		}
+		p.ID, err = res.LastInsertId()
+		return err
+	}
+
+	res, err := db.conn.Exec("UPDATE projects SET name=?, description=?, registries=?, repositories=? WHERE id=?",
+		p.Name, p.Description, string(registries), string(repositories), p.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteProject deletes a project; the roles teams had in it no longer grant anything
+func (db *DB) DeleteProject(id int64) error {
+	res, err := db.conn.Exec("DELETE FROM projects WHERE id=?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// --- Teams ---
+
+func scanTeam(row rowScanner) (*models.Team, error) {
+	var t models.Team
+	var members, projects string
+	var createdAt sql.NullTime
+	if err := row.Scan(&t.ID, &t.Name, &members, &projects, &createdAt); err != nil {
+		return nil, err
+	}
+	t.Members = []int64{}
+	t.Projects = []models.TeamProject{}
+	if members != "" {
+		json.Unmarshal([]byte(members), &t.Members)
+	}
+	if projects != "" {
+		json.Unmarshal([]byte(projects), &t.Projects)
+	}
+	if createdAt.Valid {
+		t.CreatedAt = createdAt.Time
+	}
+	return &t, nil
+}
+
+// ListTeams returns all teams by name
+func (db *DB) ListTeams() ([]models.Team, error) {
+	rows, err := db.conn.Query("SELECT id, name, members, projects, created_at FROM teams ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := []models.Team{}
+	for rows.Next() {
+		t, err := scanTeam(rows)
+		if err != nil {
+			return nil, err
+		}
+		teams = append(teams, *t)
+	}
+	return teams, rows.Err()
+}
+
+// GetTeam returns a single team
+func (db *DB) GetTeam(id int64) (*models.Team, error) {
+	return scanTeam(db.conn.QueryRow("SELECT id, name, members, projects, created_at FROM teams WHERE id=?", id))
+}
+
+// SaveTeam creates (ID 0) or updates a team
+func (db *DB) SaveTeam(t *models.Team) error {
+	if t.Members == nil {
+		t.Members = []int64{}
+	}
+	if t.Projects == nil {
+		t.Projects = []models.TeamProject{}
+	}
+	members, err := json.Marshal(t.Members)
+	if err != nil {
+		return err
+	}
+	projects, err := json.Marshal(t.Projects)
+	if err != nil {
+		return err
+	}
+
+	if t.ID == 0 {
+		t.CreatedAt = time.Now()
+		res, err := db.conn.Exec("INSERT INTO teams (name, members, projects, created_at) VALUES (?, ?, ?, ?)",
+			t.Name, string(members), string(projects), t.CreatedAt)
+		if err != nil {
+			return err
+		}
+		t.ID, err = res.LastInsertId()
+		return err
+	}
+
+	res, err := db.conn.Exec("UPDATE teams SET name=?, members=?, projects=? WHERE id=?",
+		t.Name, string(members), string(projects), t.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteTeam deletes a team with its access rules; its members keep their accounts
+func (db *DB) DeleteTeam(id int64) error {
+	res, err := db.conn.Exec("DELETE FROM teams WHERE id=?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	_, err = db.conn.Exec("DELETE FROM access_rules WHERE team_id=?", id)
+	return err
+}
+
+// --- Access Rules ---
+
+const accessRuleColumns = "id, team_id, registry_id, repository, actions, created_at"
+
+func scanAccessRule(row rowScanner) (*models.AccessRule, error) {
+	var a models.AccessRule
+	var createdAt sql.NullTime
+	if err := row.Scan(&a.ID, &a.TeamID, &a.RegistryID, &a.Repository, &a.Actions, &createdAt); err != nil {
+		return nil, err
+	}
+	if createdAt.Valid {
+		a.CreatedAt = createdAt.Time
+	}
+	return &a, nil
+}
+
+// ListAccessRules returns the access rules by team
+func (db *DB) ListAccessRules() ([]models.AccessRule, error) {
+	rows, err := db.conn.Query("SELECT " + accessRuleColumns + " FROM access_rules ORDER BY team_id, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []models.AccessRule{}
+	for rows.Next() {
+		a, err := scanAccessRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *a)
+	}
+	return rules, rows.Err()
+}
+
+// SaveAccessRule creates (ID 0) or updates an access rule
+func (db *DB) SaveAccessRule(a *models.AccessRule) error {
+	if a.ID == 0 {
+		a.CreatedAt = time.Now()
+		res, err := db.conn.Exec("INSERT INTO access_rules (team_id, registry_id, repository, actions, created_at) VALUES (?, ?, ?, ?, ?)",
+			a.TeamID, a.RegistryID, a.Repository, a.Actions, a.CreatedAt)
+		if err != nil {
+			return err
+		}
+		a.ID, err = res.LastInsertId()
+		return err
+	}
+
+	res, err := db.conn.Exec("UPDATE access_rules SET team_id=?, registry_id=?, repository=?, actions=? WHERE id=?",
+		a.TeamID, a.RegistryID, a.Repository, a.Actions, a.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteAccessRule removes an access rule
+func (db *DB) DeleteAccessRule(id int64) error {
+	res, err := db.conn.Exec("DELETE FROM access_rules WHERE id=?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
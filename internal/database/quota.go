@@ -0,0 +1,196 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Scan Report Quota ---
+
+// SetScanReportQuota caps the bytes taken by stored full scan reports; 0 removes the cap.
+// Call it before the database is used concurrently.
+func (db *DB) SetScanReportQuota(bytes int64) {
+	db.scanReportQuota = bytes
+}
+
+// reportBytes is the stored size of a report column
+const reportBytes = "COALESCE(length(CAST(report AS BLOB)), 0)"
+
+// ScanStorageUsage returns the space taken by full scan reports, counting the latest report of
+// every image and the reports kept in the scan history
+func (db *DB) ScanStorageUsage() (*models.ScanStorageUsage, error) {
+	u := &models.ScanStorageUsage{QuotaBytes: db.scanReportQuota}
+	var current, history int64
+	var currentCount, historyCount int
+	if err := db.conn.QueryRow("SELECT COALESCE(SUM("+reportBytes+"), 0), COUNT(*) FROM vuln_scans WHERE report<>''").Scan(&current, &currentCount); err != nil {
+		return nil, err
+	}
+	if err := db.conn.QueryRow(`
+		SELECT COALESCE(SUM(CASE WHEN report<>'' THEN `+reportBytes+` END), 0),
+		       COUNT(CASE WHEN report<>'' THEN 1 END),
+		       COUNT(report_evicted_at)
+		FROM scan_history
+	`).Scan(&history, &historyCount, &u.EvictedReports); err != nil {
+		return nil, err
+	}
+	u.UsedBytes = current + history
+	u.Reports = currentCount + historyCount
+
+	var last sql.NullTime
+	db.conn.QueryRow("SELECT report_evicted_at FROM scan_history WHERE report_evicted_at IS NOT NULL ORDER BY report_evicted_at DESC LIMIT 1").Scan(&last)
+	u.LastEvictionAt = timePtr(last)
+
+	db.retentionMu.Lock()
+	u.Retention, u.LastPrune = db.retention, db.lastPrune
+	db.retentionMu.Unlock()
+	return u, nil
+}
+
+// EnforceScanReportQuota evicts the oldest full reports of the scan history until the stored
+// reports fit the quota again. Summaries and normalized findings are kept, as is the latest
+// report of every image, which scanners merge into and findings are derived from; the quota
+// is soft when those alone exceed it. Each eviction is recorded as a "quota" registry event.
+func (db *DB) EnforceScanReportQuota() ([]models.RegistryEvent, error) {
+	if db.scanReportQuota <= 0 {
+		return nil, nil
+	}
+	usage, err := db.ScanStorageUsage()
+	if err != nil {
+		return nil, err
+	}
+	used := usage.UsedBytes
+	if used <= db.scanReportQuota {
+		return nil, nil
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT id, registry_id, repository, tag, digest, scanned_at, ` + reportBytes + `
+		FROM scan_history WHERE report<>'' ORDER BY scanned_at ASC, id ASC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	type candidate struct {
+		event models.RegistryEvent
+		id    int64
+		size  int64
+	}
+	var candidates []candidate
+	for rows.Next() && used > db.scanReportQuota {
+		var c candidate
+		var scannedAt sql.NullTime
+		if err := rows.Scan(&c.id, &c.event.RegistryID, &c.event.Repository, &c.event.Tag, &c.event.Digest, &scannedAt, &c.size); err != nil {
+			continue
+		}
+		c.event.Detail = fmt.Sprintf("Evicted the %d-byte report of the scan from %s to stay within the %d-byte scan storage quota",
+			c.size, scannedAt.Time.UTC().Format(time.RFC3339), db.scanReportQuota)
+		candidates = append(candidates, c)
+		used -= c.size
+	}
+	rows.Close()
+
+	now := time.Now()
+	events := []models.RegistryEvent{}
+	for _, c := range candidates {
+		if _, err := db.conn.Exec("UPDATE scan_history SET report='', report_evicted_at=? WHERE id=?", now, c.id); err != nil {
+			return events, err
+		}
+		e := c.event
+		e.Source, e.Action, e.Timestamp = "quota", "evict", now
+		if err := db.AddRegistryEvent(&e); err != nil {
+			fmt.Printf("⚠️ Failed to record report eviction: %v\n", err)
+		}
+		events = append(events, e)
+	}
+	if used > db.scanReportQuota {
+		fmt.Printf("⚠️ Latest scan reports take %d bytes, more than the %d-byte scan storage quota\n", used, db.scanReportQuota)
+	}
+	return events, nil
+}
+
+// --- Scan History Retention ---
+
+// pruneBatch is how many history rows are deleted per statement
+const pruneBatch = 500
+
+// SetScanRetention sets how much of the scan history PruneScanHistory keeps
+func (db *DB) SetScanRetention(r models.ScanRetention) {
+	db.retentionMu.Lock()
+	defer db.retentionMu.Unlock()
+	db.retention = r
+}
+
+// ScanRetention returns the scan history retention settings
+func (db *DB) ScanRetention() models.ScanRetention {
+	db.retentionMu.Lock()
+	defer db.retentionMu.Unlock()
+	return db.retention
+}
+
+// PruneScanHistory applies the retention settings to the scan history: history scans beyond the
+// newest KeepScans of each image are deleted, and the full reports of those older than
+// ReportMaxAgeDays are removed while their summaries stay. Like the quota, it leaves the latest
+// scan of every image alone.
+func (db *DB) PruneScanHistory() (*models.ScanPruneResult, error) {
+	r := db.ScanRetention()
+	result := &models.ScanPruneResult{PrunedAt: time.Now()}
+
+	if r.KeepScans > 0 {
+		rows, err := db.conn.Query(`
+			SELECT id, size FROM (
+				SELECT id, `+reportBytes+` AS size, ROW_NUMBER() OVER (
+					PARTITION BY registry_id, repository, tag ORDER BY scanned_at DESC, id DESC
+				) AS n
+				FROM scan_history
+			) ranked WHERE n > ?
+		`, r.KeepScans)
+		if err != nil {
+			return nil, err
+		}
+		var ids []interface{}
+		for rows.Next() {
+			var id, size int64
+			if err := rows.Scan(&id, &size); err != nil {
+				continue
+			}
+			ids = append(ids, id)
+			result.FreedBytes += size
+		}
+		rows.Close()
+
+		for len(ids) > 0 {
+			batch := ids[:min(pruneBatch, len(ids))]
+			ids = ids[len(batch):]
+			res, err := db.conn.Exec("DELETE FROM scan_history WHERE id IN (?"+strings.Repeat(", ?", len(batch)-1)+")", batch...)
+			if err != nil {
+				return result, err
+			}
+			n, _ := res.RowsAffected()
+			result.DeletedScans += int(n)
+		}
+	}
+
+	if r.ReportMaxAgeDays > 0 {
+		cutoff := result.PrunedAt.AddDate(0, 0, -r.ReportMaxAgeDays)
+		var freed int64
+		if err := db.conn.QueryRow("SELECT COALESCE(SUM("+reportBytes+"), 0) FROM scan_history WHERE report<>'' AND scanned_at<?", cutoff).Scan(&freed); err != nil {
+			return result, err
+		}
+		res, err := db.conn.Exec("UPDATE scan_history SET report='', report_evicted_at=? WHERE report<>'' AND scanned_at<?", result.PrunedAt, cutoff)
+		if err != nil {
+			return result, err
+		}
+		n, _ := res.RowsAffected()
+		result.EvictedReports = int(n)
+		result.FreedBytes += freed
+	}
+
+	db.retentionMu.Lock()
+	db.lastPrune = result
+	db.retentionMu.Unlock()
+	return result, nil
+}
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Embedded Registry Users ---
+
+const registryUserColumns = "id, username, password_hash, managed, created_at"
+
+func scanRegistryUser(row rowScanner) (*models.RegistryUser, error) {
+	var u models.RegistryUser
+	var created sql.NullTime
+	if err := row.Scan(&u.ID, &u.Username, &u.PasswordHash, &u.Managed, &created); err != nil {
+		return nil, err
+	}
+	if created.Valid {
+		u.CreatedAt = created.Time
+	}
+	return &u, nil
+}
+
+// ListRegistryUsers returns the accounts of the embedded registry with their permissions
+func (db *DB) ListRegistryUsers() ([]models.RegistryUser, error) {
+	rows, err := db.conn.Query("SELECT " + registryUserColumns + " FROM registry_users ORDER BY username")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.RegistryUser{}
+	for rows.Next() {
+		u, err := scanRegistryUser(rows)
+		if err != nil {
+			continue
+		}
+		users = append(users, *u)
+	}
+	rows.Close()
+
+	perms, err := db.listRegistryPermissions("")
+	if err != nil {
+		return nil, err
+	}
+	for i := range users {
+		users[i].Permissions = perms[users[i].ID]
+		if users[i].Permissions == nil {
+			users[i].Permissions = []models.RegistryPermission{}
+		}
+	}
+	return users, nil
+}
+
+// GetRegistryUser returns a single embedded registry account
+func (db *DB) GetRegistryUser(id int64) (*models.RegistryUser, error) {
+	u, err := scanRegistryUser(db.conn.QueryRow("SELECT "+registryUserColumns+" FROM registry_users WHERE id=?", id))
+	if err != nil {
+		return nil, err
+	}
+	return db.withRegistryPermissions(u)
+}
+
+// GetRegistryUserByName returns an embedded registry account by username
+func (db *DB) GetRegistryUserByName(username string) (*models.RegistryUser, error) {
+	u, err := scanRegistryUser(db.conn.QueryRow("SELECT "+registryUserColumns+" FROM registry_users WHERE username=?", username))
+	if err != nil {
+		return nil, err
+	}
+	return db.withRegistryPermissions(u)
+}
+
+func (db *DB) withRegistryPermissions(u *models.RegistryUser) (*models.RegistryUser, error) {
+	perms, err := db.listRegistryPermissions("WHERE user_id=?", u.ID)
+	if err != nil {
+		return nil, err
+	}
+	u.Permissions = perms[u.ID]
+	if u.Permissions == nil {
+		u.Permissions = []models.RegistryPermission{}
+	}
+	return u, nil
+}
+
+// CreateRegistryUser adds an embedded registry account; PasswordHash must already be set
+func (db *DB) CreateRegistryUser(u *models.RegistryUser) error {
+	u.CreatedAt = time.Now()
+	res, err := db.conn.Exec("INSERT INTO registry_users (username, password_hash, managed, created_at) VALUES (?, ?, ?, ?)",
+		u.Username, u.PasswordHash, u.Managed, u.CreatedAt)
+	if err != nil {
+		return err
+	}
+	u.ID, err = res.LastInsertId()
+	return err
+}
+
+// DeleteRegistryUser removes an embedded registry account with its permissions
+func (db *DB) DeleteRegistryUser(id int64) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("DELETE FROM registry_users WHERE id=?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	if _, err := tx.Exec("DELETE FROM registry_permissions WHERE user_id=?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// listRegistryPermissions returns the permissions matching a WHERE clause by user ID
+func (db *DB) listRegistryPermissions(where string, args ...interface{}) (map[int64][]models.RegistryPermission, error) {
+	rows, err := db.conn.Query("SELECT id, user_id, repository, actions FROM registry_permissions "+where+" ORDER BY id", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	perms := make(map[int64][]models.RegistryPermission)
+	for rows.Next() {
+		var p models.RegistryPermission
+		if err := rows.Scan(&p.ID, &p.UserID, &p.Repository, &p.Actions); err != nil {
+			continue
+		}
+		perms[p.UserID] = append(perms[p.UserID], p)
+	}
+	return perms, nil
+}
+
+// SetRegistryPermissions replaces the permissions of an embedded registry account
+func (db *DB) SetRegistryPermissions(userID int64, perms []models.RegistryPermission) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM registry_permissions WHERE user_id=?", userID); err != nil {
+		return err
+	}
+	for i := range perms {
+		perms[i].UserID = userID
+		res, err := tx.Exec("INSERT INTO registry_permissions (user_id, repository, actions) VALUES (?, ?, ?)",
+			userID, perms[i].Repository, perms[i].Actions)
+		if err != nil {
+			return err
+		}
+		perms[i].ID, _ = res.LastInsertId()
+	}
+	return tx.Commit()
+}
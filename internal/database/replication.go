@@ -0,0 +1,173 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Replication Rules ---
+
+const replicationRuleColumns = `id, name, source_registry_id, target_registry_id, filter_repos, filter_tags, target_prefix,
+	conflict, propagate_deletes, interval_minutes, enabled, last_run_at, last_status, last_error, created_at`
+
+func scanReplicationRule(row rowScanner) (*models.ReplicationRule, error) {
+	var r models.ReplicationRule
+	var lastRun, created sql.NullTime
+	if err := row.Scan(&r.ID, &r.Name, &r.SourceRegistryID, &r.TargetRegistryID, &r.FilterRepos, &r.FilterTags, &r.TargetPrefix,
+		&r.Conflict, &r.PropagateDeletes, &r.IntervalMinutes, &r.Enabled, &lastRun, &r.LastStatus, &r.LastError, &created); err != nil {
+		return nil, err
+	}
+	if lastRun.Valid {
+		r.LastRunAt = lastRun.Time
+	}
+	if created.Valid {
+		r.CreatedAt = created.Time
+	}
+	return &r, nil
+}
+
+// ListReplicationRules returns all replication rules
+func (db *DB) ListReplicationRules() ([]models.ReplicationRule, error) {
+	rows, err := db.conn.Query("SELECT " + replicationRuleColumns + " FROM replication_rules ORDER BY name, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []models.ReplicationRule{}
+	for rows.Next() {
+		r, err := scanReplicationRule(rows)
+		if err != nil {
+			continue
+		}
+		rules = append(rules, *r)
+	}
+	return rules, nil
+}
+
+// GetReplicationRule returns a single replication rule
+func (db *DB) GetReplicationRule(id int64) (*models.ReplicationRule, error) {
+	return scanReplicationRule(db.conn.QueryRow("SELECT "+replicationRuleColumns+" FROM replication_rules WHERE id=?", id))
+}
+
+// SaveReplicationRule creates (ID 0) or updates a replication rule
+func (db *DB) SaveReplicationRule(r *models.ReplicationRule) error {
+	if r.ID == 0 {
+		r.CreatedAt = time.Now()
+		res, err := db.conn.Exec(`INSERT INTO replication_rules (name, source_registry_id, target_registry_id, filter_repos, filter_tags,
+			target_prefix, conflict, propagate_deletes, interval_minutes, enabled, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			r.Name, r.SourceRegistryID, r.TargetRegistryID, r.FilterRepos, r.FilterTags, r.TargetPrefix, r.Conflict,
+			r.PropagateDeletes, r.IntervalMinutes, r.Enabled, r.CreatedAt)
+		if err != nil {
+			return err
+		}
+		r.ID, err = res.LastInsertId()
+		return err
+	}
+	res, err := db.conn.Exec(`UPDATE replication_rules SET name=?, source_registry_id=?, target_registry_id=?, filter_repos=?,
+		filter_tags=?, target_prefix=?, conflict=?, propagate_deletes=?, interval_minutes=?, enabled=? WHERE id=?`,
+		r.Name, r.SourceRegistryID, r.TargetRegistryID, r.FilterRepos, r.FilterTags, r.TargetPrefix, r.Conflict,
+		r.PropagateDeletes, r.IntervalMinutes, r.Enabled, r.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteReplicationRule removes a replication rule with its run history; images already
+// replicated are kept
+func (db *DB) DeleteReplicationRule(id int64) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM replication_rules WHERE id=?", id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM replication_runs WHERE rule_id=?", id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetReplicationResult records the outcome of a replication run
+func (db *DB) SetReplicationResult(id int64, at time.Time, status, errMsg string) error {
+	_, err := db.conn.Exec("UPDATE replication_rules SET last_run_at=?, last_status=?, last_error=? WHERE id=?", at, status, errMsg, id)
+	return err
+}
+
+// --- Replication Runs ---
+
+// replicationRunsKept is how many finished runs of each rule the history keeps
+const replicationRunsKept = 50
+
+const replicationRunColumns = `id, rule_id, trigger_type, status, repositories, tags, copied, up_to_date, conflicts, failed,
+	deleted, copied_blobs, skipped_blobs, copied_bytes, errors, started_at, finished_at`
+
+// AddReplicationRun stores a finished run of a replication rule, dropping the oldest runs beyond
+// the history kept per rule
+func (db *DB) AddReplicationRun(run *models.ReplicationRun) error {
+	errs, err := json.Marshal(run.Errors)
+	if err != nil {
+		return err
+	}
+	res, err := db.conn.Exec(`INSERT INTO replication_runs (rule_id, trigger_type, status, repositories, tags, copied, up_to_date,
+		conflicts, failed, deleted, copied_blobs, skipped_blobs, copied_bytes, errors, started_at, finished_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		run.RuleID, run.Trigger, run.Status, run.Repositories, run.Tags, run.Copied, run.UpToDate, run.Conflicts, run.Failed,
+		run.Deleted, run.CopiedBlobs, run.SkippedBlobs, run.CopiedBytes, string(errs), run.StartedAt, run.FinishedAt)
+	if err != nil {
+		return err
+	}
+	run.ID, _ = res.LastInsertId()
+
+	var oldest int64
+	err = db.conn.QueryRow("SELECT id FROM replication_runs WHERE rule_id=? ORDER BY id DESC LIMIT 1 OFFSET ?",
+		run.RuleID, replicationRunsKept).Scan(&oldest)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	_, err = db.conn.Exec("DELETE FROM replication_runs WHERE rule_id=? AND id<=?", run.RuleID, oldest)
+	return err
+}
+
+// ListReplicationRuns returns the finished runs of a rule, newest first
+func (db *DB) ListReplicationRuns(ruleID int64, limit int) ([]models.ReplicationRun, error) {
+	rows, err := db.conn.Query("SELECT "+replicationRunColumns+" FROM replication_runs WHERE rule_id=? ORDER BY id DESC LIMIT ?", ruleID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []models.ReplicationRun{}
+	for rows.Next() {
+		var run models.ReplicationRun
+		var errs string
+		var started, finished sql.NullTime
+		if err := rows.Scan(&run.ID, &run.RuleID, &run.Trigger, &run.Status, &run.Repositories, &run.Tags, &run.Copied,
+			&run.UpToDate, &run.Conflicts, &run.Failed, &run.Deleted, &run.CopiedBlobs, &run.SkippedBlobs, &run.CopiedBytes,
+			&errs, &started, &finished); err != nil {
+			continue
+		}
+		json.Unmarshal([]byte(errs), &run.Errors)
+		if started.Valid {
+			run.StartedAt = started.Time
+		}
+		if finished.Valid {
+			run.FinishedAt = finished.Time
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Repository Descriptions ---
+
+const repoMetadataColumns = "registry_id, repository, description, readme, links, updated_by, updated_at"
+
+func scanRepoMetadata(row rowScanner) (*models.RepositoryMetadata, error) {
+	var m models.RepositoryMetadata
+	var links string
+	var updatedAt sql.NullTime
+	if err := row.Scan(&m.RegistryID, &m.Repository, &m.Description, &m.Readme, &links, &m.UpdatedBy, &updatedAt); err != nil {
+		return nil, err
+	}
+	m.Links = []models.RepositoryLink{}
+	if links != "" {
+		json.Unmarshal([]byte(links), &m.Links)
+	}
+	m.UpdatedAt = timePtr(updatedAt)
+	return &m, nil
+}
+
+// GetRepoMetadata returns the description, README and links of a repository (empty metadata if
+// none were written). The owner is filled by the caller from the repository info.
+func (db *DB) GetRepoMetadata(registryID int64, repository string) (*models.RepositoryMetadata, error) {
+	row := db.conn.QueryRow("SELECT "+repoMetadataColumns+" FROM repo_metadata WHERE registry_id=? AND repository=?", registryID, repository)
+	m, err := scanRepoMetadata(row)
+	if err == sql.ErrNoRows {
+		return &models.RepositoryMetadata{RegistryID: registryID, Repository: repository, Links: []models.RepositoryLink{}}, nil
+	}
+	return m, err
+}
+
+// ListRepoMetadata returns the metadata written for the repositories of a registry, keyed by name
+func (db *DB) ListRepoMetadata(registryID int64) (map[string]models.RepositoryMetadata, error) {
+	rows, err := db.conn.Query("SELECT "+repoMetadataColumns+" FROM repo_metadata WHERE registry_id=?", registryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	metadata := make(map[string]models.RepositoryMetadata)
+	for rows.Next() {
+		m, err := scanRepoMetadata(rows)
+		if err != nil {
+			return nil, err
+		}
+		metadata[m.Repository] = *m
+	}
+	return metadata, rows.Err()
+}
+
+// SaveRepoMetadata creates or replaces the description, README and links of a repository
+func (db *DB) SaveRepoMetadata(m *models.RepositoryMetadata) error {
+	if m.Links == nil {
+		m.Links = []models.RepositoryLink{}
+	}
+	links, err := json.Marshal(m.Links)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	m.UpdatedAt = &now
+	_, err = db.conn.Exec(`
+		INSERT INTO repo_metadata (registry_id, repository, description, readme, links, updated_by, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(registry_id, repository) DO UPDATE SET
+			description=excluded.description,
+			readme=excluded.readme,
+			links=excluded.links,
+			updated_by=excluded.updated_by,
+			updated_at=excluded.updated_at
+	`, m.RegistryID, m.Repository, m.Description, m.Readme, string(links), m.UpdatedBy, now)
+	return err
+}
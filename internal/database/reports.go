@@ -0,0 +1,205 @@
+package database
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Scan Reports ---
+
+// ScanReport gathers the latest completed scans matching a filter's registry, repository and tag:
+// finding counts per severity and image, and the top most severe vulnerabilities and fixable packages
+func (db *DB) ScanReport(f FindingFilter, top int) (*models.ScanReport, error) {
+	rep := &models.ScanReport{
+		RegistryID:         f.RegistryID,
+		Repository:         f.Repository,
+		Tag:                f.Tag,
+		Severities:         make(map[string]int),
+		Images:             []models.ReportImage{},
+		TopVulnerabilities: []models.ReportVulnerability{},
+		FixableItems:       []models.ReportFix{},
+	}
+	if err := db.reportImages(f, rep); err != nil {
+		return nil, err
+	}
+	if err := db.reportTopVulnerabilities(f, top, rep); err != nil {
+		return nil, err
+	}
+	if err := db.reportFixes(f, top, rep); err != nil {
+		return nil, err
+	}
+	return rep, nil
+}
+
+// ScannedImages returns every image of a registry with a completed scan and its finding counts
+func (db *DB) ScannedImages(registryID int64) ([]models.ReportImage, error) {
+	rep := &models.ScanReport{Severities: make(map[string]int), Images: []models.ReportImage{}}
+	if err := db.reportImages(FindingFilter{RegistryID: registryID}, rep); err != nil {
+		return nil, err
+	}
+	return rep.Images, nil
+}
+
+// ScannedRepositories returns the repositories of a registry with a completed scan
+func (db *DB) ScannedRepositories(registryID int64) ([]string, error) {
+	rows, err := db.conn.Query("SELECT DISTINCT repository FROM vuln_scans WHERE registry_id=? AND status='completed' ORDER BY repository", registryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var repos []string
+	for rows.Next() {
+		var repo string
+		if err := rows.Scan(&repo); err != nil {
+			continue
+		}
+		repos = append(repos, repo)
+	}
+	return repos, rows.Err()
+}
+
+// reportImages adds every scanned image with its finding counts, including images without findings
+func (db *DB) reportImages(f FindingFilter, rep *models.ScanReport) error {
+	conds := []string{"s.registry_id=?", "s.status='completed'"}
+	args := []interface{}{f.RegistryID}
+	if f.Repository != "" {
+		conds = append(conds, "s.repository=?")
+		args = append(args, f.Repository)
+	}
+	if f.Repositories != nil {
+		cond, repoArgs := repositoryIn("s.repository", f.Repositories)
+		conds = append(conds, cond)
+		args = append(args, repoArgs...)
+	}
+	if f.Tag != "" {
+		conds = append(conds, "s.tag=?")
+		args = append(args, f.Tag)
+	}
+
+	rows, err := db.conn.Query(`
+		SELECT s.id, s.repository, s.tag, COALESCE(s.digest, ''), s.scanned_at,
+			UPPER(COALESCE(f.severity, '')), COUNT(f.id), SUM(CASE WHEN f.fixed_version<>'' THEN 1 ELSE 0 END)
+		FROM vuln_scans s LEFT JOIN vuln_findings f ON f.scan_id=s.id
+		WHERE `+strings.Join(conds, " AND ")+`
+		GROUP BY s.id, UPPER(f.severity)
+		ORDER BY s.repository, s.tag
+	`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	index := make(map[int64]int)
+	for rows.Next() {
+		var scanID int64
+		var img models.ReportImage
+		var scannedAt sql.NullTime
+		var severity string
+		var count, fixable int
+		if err := rows.Scan(&scanID, &img.Repository, &img.Tag, &img.Digest, &scannedAt, &severity, &count, &fixable); err != nil {
+			continue
+		}
+		i, ok := index[scanID]
+		if !ok {
+			img.ScannedAt = scannedAt.Time
+			img.Severities = make(map[string]int)
+			rep.Images = append(rep.Images, img)
+			i = len(rep.Images) - 1
+			index[scanID] = i
+		}
+		if count == 0 {
+			continue
+		}
+		if severity == "" {
+			severity = "UNKNOWN"
+		}
+		rep.Images[i].Severities[severity] += count
+		rep.Images[i].Total += count
+		rep.Images[i].Fixable += fixable
+		rep.Severities[severity] += count
+		rep.Total += count
+		rep.Fixable += fixable
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Most vulnerable images first
+	sort.SliceStable(rep.Images, func(i, j int) bool {
+		a, b := rep.Images[i], rep.Images[j]
+		if a.Severities["CRITICAL"] != b.Severities["CRITICAL"] {
+			return a.Severities["CRITICAL"] > b.Severities["CRITICAL"]
+		}
+		if a.Severities["HIGH"] != b.Severities["HIGH"] {
+			return a.Severities["HIGH"] > b.Severities["HIGH"]
+		}
+		return a.Total > b.Total
+	})
+	return nil
+}
+
+// reportTopVulnerabilities adds the most severe vulnerabilities, the most widespread first
+func (db *DB) reportTopVulnerabilities(f FindingFilter, top int, rep *models.ScanReport) error {
+	where, args := f.where()
+	rank := severityRank("f.severity")
+	rows, err := db.conn.Query(`
+		SELECT f.vuln_id, MIN(`+rank+`), GROUP_CONCAT(DISTINCT f.package), MAX(f.fixed_version),
+			MAX(f.description), COUNT(DISTINCT f.scan_id)
+		`+where+`
+		GROUP BY f.vuln_id
+		ORDER BY MIN(`+rank+`), COUNT(DISTINCT f.scan_id) DESC, f.vuln_id
+		LIMIT ?
+	`, append(args, top)...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v models.ReportVulnerability
+		var level int
+		var packages, fixed, description sql.NullString
+		if err := rows.Scan(&v.ID, &level, &packages, &fixed, &description, &v.Images); err != nil {
+			continue
+		}
+		v.Severity = severityLevels[level]
+		v.Packages = strings.ReplaceAll(packages.String, ",", ", ")
+		v.FixedVersion, v.Description = fixed.String, description.String
+		rep.TopVulnerabilities = append(rep.TopVulnerabilities, v)
+	}
+	return rows.Err()
+}
+
+// reportFixes adds the package upgrades that fix the most severe and the most vulnerabilities
+func (db *DB) reportFixes(f FindingFilter, top int, rep *models.ScanReport) error {
+	f.FixableOnly = true
+	where, args := f.where()
+	rank := severityRank("f.severity")
+	rows, err := db.conn.Query(`
+		SELECT f.package, COALESCE(f.version, ''), f.fixed_version, MIN(`+rank+`),
+			COUNT(DISTINCT f.vuln_id), COUNT(DISTINCT f.scan_id)
+		`+where+`
+		GROUP BY f.package, f.version, f.fixed_version
+		ORDER BY MIN(`+rank+`), COUNT(DISTINCT f.vuln_id) DESC, f.package
+		LIMIT ?
+	`, append(args, top)...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var fix models.ReportFix
+		var level int
+		if err := rows.Scan(&fix.Package, &fix.Version, &fix.FixedVersion, &level, &fix.Vulnerabilities, &fix.Images); err != nil {
+			continue
+		}
+		fix.Severity = severityLevels[level]
+		rep.FixableItems = append(rep.FixableItems, fix)
+	}
+	return rows.Err()
+}
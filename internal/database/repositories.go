@@ -0,0 +1,154 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Repository Metadata ---
+
+const repositoryColumns = `registry_id, name, owner, labels, scan_mode, retention_template_id, onboarding_rule_id, first_seen_at, last_seen_at`
+
+// TrackRepositories records the repositories seen by a sync and returns the ones never seen before.
+// The first sync of a registry only seeds the set: what it already holds is not reported as new.
+func (db *DB) TrackRepositories(registryID int64, names []string, seenAt time.Time) ([]string, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var synced bool
+	if err := tx.QueryRow("SELECT repositories_synced_at IS NOT NULL FROM registries WHERE id=?", registryID).Scan(&synced); err != nil {
+		return nil, err
+	}
+
+	discovered := []string{}
+	for _, name := range names {
+		res, err := tx.Exec(`
+			INSERT INTO repositories (registry_id, name, first_seen_at, last_seen_at)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT(registry_id, name) DO NOTHING
+		`, registryID, name, seenAt, seenAt)
+		if err != nil {
+			return nil, err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			if synced {
+				discovered = append(discovered, name)
+			}
+			continue
+		}
+		if _, err := tx.Exec("UPDATE repositories SET last_seen_at=? WHERE registry_id=? AND name=?", seenAt, registryID, name); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := tx.Exec("UPDATE registries SET repositories_synced_at=? WHERE id=?", seenAt, registryID); err != nil {
+		return nil, err
+	}
+	return discovered, tx.Commit()
+}
+
+// GetRepositoryInfo returns the metadata of a repository (empty metadata if it was never synced)
+func (db *DB) GetRepositoryInfo(registryID int64, name string) (*models.RepositoryInfo, error) {
+	row := db.conn.QueryRow("SELECT "+repositoryColumns+" FROM repositories WHERE registry_id=? AND name=?", registryID, name)
+	info, err := scanRepositoryInfo(row)
+	if err == sql.ErrNoRows {
+		return &models.RepositoryInfo{RegistryID: registryID, Name: name, Labels: map[string]string{}}, nil
+	}
+	return info, err
+}
+
+// ListRepositoryInfo returns the metadata of all tracked repositories of a registry, keyed by name
+func (db *DB) ListRepositoryInfo(registryID int64) (map[string]models.RepositoryInfo, error) {
+	rows, err := db.conn.Query("SELECT "+repositoryColumns+" FROM repositories WHERE registry_id=?", registryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	infos := make(map[string]models.RepositoryInfo)
+	for rows.Next() {
+		info, err := scanRepositoryInfo(rows)
+		if err != nil {
+			continue
+		}
+		infos[info.Name] = *info
+	}
+	return infos, nil
+}
+
+// SaveRepositoryInfo creates or updates the editable metadata of a repository
+func (db *DB) SaveRepositoryInfo(info *models.RepositoryInfo) error {
+	labels, err := json.Marshal(info.Labels)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	_, err = db.conn.Exec(`
+		INSERT INTO repositories (registry_id, name, owner, labels, scan_mode, retention_template_id, onboarding_rule_id, first_seen_at, last_seen_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(registry_id, name) DO UPDATE SET
+			owner=excluded.owner,
+			labels=excluded.labels,
+			scan_mode=excluded.scan_mode,
+			retention_template_id=excluded.retention_template_id,
+			onboarding_rule_id=excluded.onboarding_rule_id
+	`, info.RegistryID, info.Name, info.Owner, string(labels), info.ScanMode, info.RetentionTemplateID, info.OnboardingRuleID, now, now)
+	return err
+}
+
+// RenameRepository moves what the dashboard keeps about a repository (metadata, description, tag
+// pins, usage counters and the search index) to its new name, replacing any rows of the new name
+func (db *DB) RenameRepository(registryID int64, from, to string) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range []string{
+		"UPDATE OR REPLACE repositories SET name=? WHERE registry_id=? AND name=?",
+		"UPDATE OR REPLACE repo_metadata SET repository=? WHERE registry_id=? AND repository=?",
+		"UPDATE OR REPLACE tag_pins SET repository=? WHERE registry_id=? AND repository=?",
+		"UPDATE OR REPLACE tag_usage SET repository=? WHERE registry_id=? AND repository=?",
+		"UPDATE OR REPLACE search_index SET repository=? WHERE registry_id=? AND repository=?",
+	} {
+		if _, err := tx.Exec(stmt, to, registryID, from); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRepositoryInfo(row rowScanner) (*models.RepositoryInfo, error) {
+	var info models.RepositoryInfo
+	var labels string
+	var firstSeen, lastSeen sql.NullTime
+	if err := row.Scan(&info.RegistryID, &info.Name, &info.Owner, &labels, &info.ScanMode, &info.RetentionTemplateID, &info.OnboardingRuleID, &firstSeen, &lastSeen); err != nil {
+		return nil, err
+	}
+	info.Labels = decodeLabels(labels)
+	if firstSeen.Valid {
+		info.FirstSeenAt = firstSeen.Time
+	}
+	if lastSeen.Valid {
+		info.LastSeenAt = lastSeen.Time
+	}
+	return &info, nil
+}
+
+func decodeLabels(s string) map[string]string {
+	labels := map[string]string{}
+	if s != "" {
+		json.Unmarshal([]byte(s), &labels)
+	}
+	return labels
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"database/sql"
+	"slices"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Repository Quotas ---
+
+const repositoryQuotaColumns = `id, registry_id, repository, project_id, limit_bytes, warn_percent, block_copies, alert_webhook_url,
+	used_bytes, state, check_error, checked_at, created_at`
+
+func scanRepositoryQuota(row rowScanner) (*models.RepositoryQuota, error) {
+	var q models.RepositoryQuota
+	var checked, createdAt sql.NullTime
+	if err := row.Scan(&q.ID, &q.RegistryID, &q.Repository, &q.ProjectID, &q.LimitBytes, &q.WarnPercent, &q.Block, &q.AlertWebhookURL,
+		&q.UsedBytes, &q.State, &q.CheckError, &checked, &createdAt); err != nil {
+		return nil, err
+	}
+	q.CheckedAt = timePtr(checked)
+	if createdAt.Valid {
+		q.CreatedAt = createdAt.Time
+	}
+	return &q, nil
+}
+
+// ListRepositoryQuotas returns all repository and project quotas
+func (db *DB) ListRepositoryQuotas() ([]models.RepositoryQuota, error) {
+	rows, err := db.conn.Query("SELECT " + repositoryQuotaColumns + " FROM repository_quotas ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	quotas := []models.RepositoryQuota{}
+	for rows.Next() {
+		q, err := scanRepositoryQuota(rows)
+		if err != nil {
+			return nil, err
+		}
+		quotas = append(quotas, *q)
+	}
+	return quotas, rows.Err()
+}
+
+// GetRepositoryQuota returns a single quota
+func (db *DB) GetRepositoryQuota(id int64) (*models.RepositoryQuota, error) {
+	return scanRepositoryQuota(db.conn.QueryRow("SELECT "+repositoryQuotaColumns+" FROM repository_quotas WHERE id=?", id))
+}
+
+// SaveRepositoryQuota creates (ID 0) or updates a quota's settings; its measured usage is kept
+func (db *DB) SaveRepositoryQuota(q *models.RepositoryQuota) error {
+	if q.ID == 0 {
+		q.CreatedAt = time.Now()
+		res, err := db.conn.Exec(`INSERT INTO repository_quotas (registry_id, repository, project_id, limit_bytes, warn_percent, block_copies, alert_webhook_url, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			q.RegistryID, q.Repository, q.ProjectID, q.LimitBytes, q.WarnPercent, q.Block, q.AlertWebhookURL, q.CreatedAt)
+		if err != nil {
+			return err
+		}
+		q.ID, err = res.LastInsertId()
+		return err
+	}
+
+	res, err := db.conn.Exec("UPDATE repository_quotas SET registry_id=?, repository=?, project_id=?, limit_bytes=?, warn_percent=?, block_copies=?, alert_webhook_url=? WHERE id=?",
+		q.RegistryID, q.Repository, q.ProjectID, q.LimitBytes, q.WarnPercent, q.Block, q.AlertWebhookURL, q.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SetRepositoryQuotaUsage records the outcome of measuring a quota's usage
+func (db *DB) SetRepositoryQuotaUsage(id int64, used int64, state, checkErr string, checkedAt time.Time) error {
+	_, err := db.conn.Exec("UPDATE repository_quotas SET used_bytes=?, state=?, check_error=?, checked_at=? WHERE id=?",
+		used, state, checkErr, checkedAt, id)
+	return err
+}
+
+// DeleteRepositoryQuota removes a quota
+func (db *DB) DeleteRepositoryQuota(id int64) error {
+	res, err := db.conn.Exec("DELETE FROM repository_quotas WHERE id=?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// BlockingQuota returns an exceeded quota that blocks copies into a repository, nil when none does
+func (db *DB) BlockingQuota(registryID int64, repo string) (*models.RepositoryQuota, error) {
+	quotas, err := db.ListRepositoryQuotas()
+	if err != nil {
+		return nil, err
+	}
+	for i := range quotas {
+		q := &quotas[i]
+		if !q.Block || q.State != "exceeded" {
+			continue
+		}
+		if q.ProjectID == 0 {
+			if q.RegistryID == registryID && models.MatchRepository(q.Repository, repo) {
+				return q, nil
+			}
+			continue
+		}
+		p, err := db.GetProject(q.ProjectID)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(p.Registries, registryID) || slices.ContainsFunc(p.Repositories, func(pr models.ProjectRepository) bool {
+			return pr.RegistryID == registryID && models.MatchRepository(pr.Repository, repo)
+		}) {
+			return q, nil
+		}
+	}
+	return nil, nil
+}
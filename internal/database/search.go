@@ -0,0 +1,138 @@
+package database
+
+import (
+	"strings"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Search Index ---
+
+// IndexRegistry replaces the indexed repositories of a registry with the ones seen by a sync. Tags are
+// replaced for the repositories in listed; a repository whose tags could not be listed keeps its old ones.
+// It returns the listed tags that were not indexed before, by repository; nothing is reported the
+// first time a registry is indexed, when every tag would be new.
+func (db *DB) IndexRegistry(registryID int64, repos []string, listed map[string][]models.Tag, now time.Time) (map[string][]string, error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	present := make(map[string]bool, len(repos))
+	for _, repo := range repos {
+		present[repo] = true
+	}
+
+	rows, err := tx.Query("SELECT repository, tag FROM search_index WHERE registry_id=?", registryID)
+	if err != nil {
+		return nil, err
+	}
+	indexed := make(map[string]bool)
+	gone := make(map[string]bool)
+	for rows.Next() {
+		var repo, tag string
+		if rows.Scan(&repo, &tag) != nil {
+			continue
+		}
+		indexed[repo+":"+tag] = true
+		if !present[repo] {
+			gone[repo] = true
+		}
+	}
+	rows.Close()
+	for repo := range gone {
+		if _, err := tx.Exec("DELETE FROM search_index WHERE registry_id=? AND repository=?", registryID, repo); err != nil {
+			return nil, err
+		}
+	}
+
+	added := make(map[string][]string)
+
+	for _, repo := range repos {
+		if _, err := tx.Exec("INSERT OR REPLACE INTO search_index (registry_id, repository, tag, indexed_at) VALUES (?, ?, '', ?)", registryID, repo, now); err != nil {
+			return nil, err
+		}
+		tags, ok := listed[repo]
+		if !ok {
+			continue
+		}
+		if _, err := tx.Exec("DELETE FROM search_index WHERE registry_id=? AND repository=? AND tag<>''", registryID, repo); err != nil {
+			return nil, err
+		}
+		for _, t := range tags {
+			if _, err := tx.Exec("INSERT INTO search_index (registry_id, repository, tag, indexed_at) VALUES (?, ?, ?, ?)", registryID, repo, t.Name, now); err != nil {
+				return nil, err
+			}
+			if len(indexed) > 0 && !indexed[repo+":"+t.Name] {
+				added[repo] = append(added[repo], t.Name)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return added, nil
+}
+
+// IndexTag adds a pushed tag, and its repository, to the search index
+func (db *DB) IndexTag(registryID int64, repository, tag string, now time.Time) error {
+	_, err := db.conn.Exec(`
+		INSERT OR REPLACE INTO search_index (registry_id, repository, tag, indexed_at) VALUES (?, ?, '', ?), (?, ?, ?, ?)
+	`, registryID, repository, now, registryID, repository, tag, now)
+	return err
+}
+
+// UnindexTag removes a deleted tag from the search index
+func (db *DB) UnindexTag(registryID int64, repository, tag string) error {
+	_, err := db.conn.Exec("DELETE FROM search_index WHERE registry_id=? AND repository=? AND tag=?", registryID, repository, tag)
+	return err
+}
+
+// SearchIndex finds indexed repositories and tags whose name contains query (case-insensitive).
+// A query of the form repo:tag matches tags by both parts. Exact name matches come first.
+func (db *DB) SearchIndex(query string, limit int) ([]models.SearchHit, error) {
+	var where string
+	var args []interface{}
+	if repo, tag, ok := strings.Cut(query, ":"); ok {
+		where = `s.tag<>'' AND s.repository LIKE ? ESCAPE '\' AND s.tag LIKE ? ESCAPE '\'`
+		args = append(args, likePattern(repo), likePattern(tag))
+	} else {
+		where = `(s.tag='' AND s.repository LIKE ? ESCAPE '\') OR (s.tag<>'' AND s.tag LIKE ? ESCAPE '\')`
+		args = append(args, likePattern(query), likePattern(query))
+	}
+	args = append(args, query, query, limit)
+
+	rows, err := db.conn.Query(`
+		SELECT s.registry_id, r.name, s.repository, s.tag, s.indexed_at
+		FROM search_index s JOIN registries r ON r.id=s.registry_id
+		WHERE `+where+`
+		ORDER BY CASE WHEN s.repository=? OR s.tag=? THEN 0 ELSE 1 END, s.tag<>'', r.name, s.repository, s.tag
+		LIMIT ?
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hits := []models.SearchHit{}
+	for rows.Next() {
+		var h models.SearchHit
+		if err := rows.Scan(&h.RegistryID, &h.RegistryName, &h.Repository, &h.Tag, &h.IndexedAt); err != nil {
+			continue
+		}
+		h.Match = "repository"
+		if h.Tag != "" {
+			h.Match = "tag"
+		}
+		hits = append(hits, h)
+	}
+	return hits, nil
+}
+
+// likePattern turns a substring into a LIKE pattern, escaping its wildcards
+func likePattern(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+	return "%" + s + "%"
+}
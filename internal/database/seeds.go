@@ -0,0 +1,88 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Seed Images ---
+
+const seedImageColumns = `id, image, target_registry_id, target_repository, interval_hours, enabled, last_digest, last_run_at, last_error, created_at`
+
+func scanSeedImage(row rowScanner) (*models.SeedImage, error) {
+	var s models.SeedImage
+	var lastRun, created sql.NullTime
+	if err := row.Scan(&s.ID, &s.Image, &s.TargetRegistryID, &s.TargetRepository, &s.IntervalHours, &s.Enabled,
+		&s.LastDigest, &lastRun, &s.LastError, &created); err != nil {
+		return nil, err
+	}
+	if lastRun.Valid {
+		s.LastRunAt = lastRun.Time
+	}
+	if created.Valid {
+		s.CreatedAt = created.Time
+	}
+	return &s, nil
+}
+
+// ListSeedImages returns all seed images
+func (db *DB) ListSeedImages() ([]models.SeedImage, error) {
+	rows, err := db.conn.Query("SELECT " + seedImageColumns + " FROM seed_images ORDER BY image, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	seeds := []models.SeedImage{}
+	for rows.Next() {
+		s, err := scanSeedImage(rows)
+		if err != nil {
+			continue
+		}
+		seeds = append(seeds, *s)
+	}
+	return seeds, nil
+}
+
+// GetSeedImage returns a single seed image
+func (db *DB) GetSeedImage(id int64) (*models.SeedImage, error) {
+	return scanSeedImage(db.conn.QueryRow("SELECT "+seedImageColumns+" FROM seed_images WHERE id=?", id))
+}
+
+// SaveSeedImage creates (ID 0) or updates a seed image
+func (db *DB) SaveSeedImage(s *models.SeedImage) error {
+	if s.ID == 0 {
+		s.CreatedAt = time.Now()
+		res, err := db.conn.Exec(`INSERT INTO seed_images (image, target_registry_id, target_repository, interval_hours, enabled, created_at)
+			VALUES (?, ?, ?, ?, ?, ?)`, s.Image, s.TargetRegistryID, s.TargetRepository, s.IntervalHours, s.Enabled, s.CreatedAt)
+		if err != nil {
+			return err
+		}
+		s.ID, err = res.LastInsertId()
+		return err
+	}
+	res, err := db.conn.Exec(`UPDATE seed_images SET image=?, target_registry_id=?, target_repository=?, interval_hours=?, enabled=? WHERE id=?`,
+		s.Image, s.TargetRegistryID, s.TargetRepository, s.IntervalHours, s.Enabled, s.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteSeedImage removes a seed image; images already mirrored are kept
+func (db *DB) DeleteSeedImage(id int64) error {
+	_, err := db.conn.Exec("DELETE FROM seed_images WHERE id=?", id)
+	return err
+}
+
+// SetSeedResult records the outcome of a seed run; the digest is only updated when non-empty
+func (db *DB) SetSeedResult(id int64, at time.Time, digest, errMsg string) error {
+	_, err := db.conn.Exec(`UPDATE seed_images SET last_run_at=?, last_error=?, last_digest=CASE WHEN ?<>'' THEN ? ELSE last_digest END WHERE id=?`,
+		at, errMsg, digest, digest, id)
+	return err
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+const signingKeyColumns = "id, name, public_key, private_key, projects, created_by, created_at"
+
+func scanSigningKey(row rowScanner) (*models.SigningKey, error) {
+	var k models.SigningKey
+	var projects sql.NullString
+	var createdAt sql.NullTime
+	if err := row.Scan(&k.ID, &k.Name, &k.PublicKey, &k.PrivateKey, &projects, &k.CreatedBy, &createdAt); err != nil {
+		return nil, err
+	}
+	k.Projects = []int64{}
+	if projects.String != "" {
+		json.Unmarshal([]byte(projects.String), &k.Projects)
+	}
+	if createdAt.Valid {
+		k.CreatedAt = createdAt.Time
+	}
+	return &k, nil
+}
+
+// ListSigningKeys returns the image signing keys by name
+func (db *DB) ListSigningKeys() ([]models.SigningKey, error) {
+	rows, err := db.conn.Query("SELECT " + signingKeyColumns + " FROM signing_keys ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.SigningKey{}
+	for rows.Next() {
+		k, err := scanSigningKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *k)
+	}
+	return keys, rows.Err()
+}
+
+// GetSigningKey returns a single image signing key with its encrypted private key
+func (db *DB) GetSigningKey(id int64) (*models.SigningKey, error) {
+	return scanSigningKey(db.conn.QueryRow("SELECT "+signingKeyColumns+" FROM signing_keys WHERE id=?", id))
+}
+
+// CreateSigningKey stores a new image signing key; keys are replaced by adding a new one, and only
+// their projects are edited
+func (db *DB) CreateSigningKey(k *models.SigningKey) error {
+	if k.Projects == nil {
+		k.Projects = []int64{}
+	}
+	projects, err := json.Marshal(k.Projects)
+	if err != nil {
+		return err
+	}
+	k.CreatedAt = time.Now()
+	res, err := db.conn.Exec("INSERT INTO signing_keys (name, public_key, private_key, projects, created_by, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+		k.Name, k.PublicKey, k.PrivateKey, string(projects), k.CreatedBy, k.CreatedAt)
+	if err != nil {
+		return err
+	}
+	k.ID, err = res.LastInsertId()
+	return err
+}
+
+// SetSigningKeyProjects replaces the projects that may use a signing key
+func (db *DB) SetSigningKeyProjects(id int64, projects []int64) error {
+	if projects == nil {
+		projects = []int64{}
+	}
+	data, err := json.Marshal(projects)
+	if err != nil {
+		return err
+	}
+	res, err := db.conn.Exec("UPDATE signing_keys SET projects=? WHERE id=?", string(data), id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// DeleteSigningKey removes an image signing key; signatures it made stay valid for its public key
+func (db *DB) DeleteSigningKey(id int64) error {
+	res, err := db.conn.Exec("DELETE FROM signing_keys WHERE id=?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"database/sql"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Storage Sizes ---
+
+// GetRegistrySize returns the cached storage size of a registry, or sql.ErrNoRows if never calculated
+func (db *DB) GetRegistrySize(registryID int64) (*models.RegistrySize, error) {
+	s := &models.RegistrySize{RegistryID: registryID, Repositories: []models.RepositorySize{}}
+	var calculated sql.NullTime
+	err := db.conn.QueryRow("SELECT total_size, blob_count, calculated_at FROM registry_sizes WHERE registry_id=?", registryID).
+		Scan(&s.TotalSize, &s.BlobCount, &calculated)
+	if err != nil {
+		return nil, err
+	}
+	if calculated.Valid {
+		s.CalculatedAt = calculated.Time
+	}
+
+	rows, err := db.conn.Query("SELECT name, size, blob_count, tag_count FROM repository_sizes WHERE registry_id=? ORDER BY size DESC, name", registryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var r models.RepositorySize
+		if err := rows.Scan(&r.Name, &r.Size, &r.BlobCount, &r.TagCount); err != nil {
+			continue
+		}
+		s.Repositories = append(s.Repositories, r)
+	}
+	return s, nil
+}
+
+// SaveRegistrySize replaces the cached storage size of a registry and its repositories
+func (db *DB) SaveRegistrySize(s *models.RegistrySize) error {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO registry_sizes (registry_id, total_size, blob_count, calculated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(registry_id) DO UPDATE SET
+			total_size=excluded.total_size,
+			blob_count=excluded.blob_count,
+			calculated_at=excluded.calculated_at
+	`, s.RegistryID, s.TotalSize, s.BlobCount, s.CalculatedAt); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM repository_sizes WHERE registry_id=?", s.RegistryID); err != nil {
+		return err
+	}
+	for _, r := range s.Repositories {
+		if _, err := tx.Exec("INSERT INTO repository_sizes (registry_id, name, size, blob_count, tag_count) VALUES (?, ?, ?, ?, ?)",
+			s.RegistryID, r.Name, r.Size, r.BlobCount, r.TagCount); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
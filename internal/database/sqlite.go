@@ -2,11 +2,17 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
 	"time"
 
+	"docker-registry-dashboard/internal/logging"
 	"docker-registry-dashboard/internal/models"
 
 	_ "modernc.org/sqlite"
@@ -14,41 +20,110 @@ import (
 
 // DB wraps the SQL database connection
 type DB struct {
-	conn *sql.DB
+	conn *sqlConn
+
+	// scanReportQuota caps the bytes of stored full scan reports; 0 means no cap
+	scanReportQuota int64
+
+	retentionMu sync.Mutex
+	retention   models.ScanRetention
+	lastPrune   *models.ScanPruneResult
 }
 
-// New creates a new database connection and initializes schema
+// New opens a database and applies the pending migrations
 func New(dbPath string) (*DB, error) {
+	db, err := Open(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	}
+	return db, nil
+}
+
+// Open opens a database without touching its schema: a SQLite file, created if needed, or a
+// mysql:// URL
+func Open(dbPath string) (*DB, error) {
+	if IsMySQL(dbPath) {
+		return openMySQL(dbPath)
+	}
+
 	// Ensure directory exists
-	dir := filepath.Dir(dbPath)
+	path, query, _ := strings.Cut(dbPath, "?")
+	dir := filepath.Dir(FilePath(dbPath))
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create database directory: %w", err)
 	}
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database path: %w", err)
+	}
 
-	conn, err := sql.Open("sqlite", dbPath)
+	// All writes go through one connection, beginning transactions with the write lock, so they
+	// queue in the pool instead of failing with "database is locked"; busy_timeout covers writes
+	// of other processes (the CLI). In WAL mode the read connections never wait for the writer.
+	// Options already in the path (e.g. file:registry.db?cache=shared) are kept.
+	params.Add("_pragma", "busy_timeout("+fmt.Sprint(busyTimeout.Milliseconds())+")")
+	params.Add("_pragma", "synchronous(NORMAL)")
+	dsn := path + "?" + params.Encode()
+	conn, err := sql.Open("sqlite", dsn+"&_txlock=immediate")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
+	conn.SetMaxOpenConns(1)
 
 	// Enable WAL mode for better performance
 	if _, err := conn.Exec("PRAGMA journal_mode=WAL"); err != nil {
+		conn.Close()
 		return nil, fmt.Errorf("failed to set WAL mode: %w", err)
 	}
 
-	db := &DB{conn: conn}
-	if err := db.migrate(); err != nil {
-		return nil, fmt.Errorf("failed to run migrations: %w", err)
+	read, err := sql.Open("sqlite", dsn+"&_pragma=query_only(1)")
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
-
+	db := &DB{conn: &sqlConn{DB: conn, read: read, dialect: sqliteDialect}}
+	db.SetMaxConns(0)
 	return db, nil
 }
 
+// FilePath returns the file of a SQLite database location, without a file: prefix or options
+func FilePath(dbPath string) string {
+	path, _, _ := strings.Cut(dbPath, "?")
+	return strings.TrimPrefix(path, "file:")
+}
+
+// busyTimeout is how long SQLite waits for a lock held by another process
+const busyTimeout = 10 * time.Second
+
+// SetMaxConns sizes the connection pool used by queries: SQLite writes always go through one
+// connection. 0 picks a default by the number of CPUs.
+func (db *DB) SetMaxConns(n int) {
+	if n <= 0 {
+		n = max(4, runtime.NumCPU())
+	}
+	pool := db.conn.reader()
+	pool.SetMaxOpenConns(n)
+	pool.SetMaxIdleConns(n)
+}
+
 // Close closes the database connection
 func (db *DB) Close() error {
 	return db.conn.Close()
 }
 
-func (db *DB) migrate() error {
+// Ping checks that the database can be queried
+func (db *DB) Ping() error {
+	var one int
+	return db.conn.QueryRow("SELECT 1").Scan(&one)
+}
+
+// baselineSchema is migration 1: the schema as it was built before migrations were numbered. It
+// is idempotent, so it also brings databases created before then up to date.
+func baselineSchema(s *schemaScript) error {
 	schema := `
 	CREATE TABLE IF NOT EXISTS registries (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -93,15 +168,10 @@ func (db *DB) migrate() error {
 		FOREIGN KEY(registry_id) REFERENCES registries(id) ON DELETE CASCADE
 	);
 	`
-	if _, err := db.conn.Exec(schema); err != nil {
+	if err := s.exec(schema); err != nil {
 		return err
 	}
 
-	// Migrations for existing tables (ignore errors if columns exist)
-	// We use a simple way: try to add column, ignore error.
-	// In Go sqlite driver, we can't easily suppress specific errors without parsing string.
-	// But Exec will return error if column exists. We can ignore it.
-
 	scanPolicySchema := `
 	CREATE TABLE IF NOT EXISTS scan_policies (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -115,17 +185,27 @@ func (db *DB) migrate() error {
 		FOREIGN KEY(registry_id) REFERENCES registries(id) ON DELETE CASCADE
 	);
 	`
-	if _, err := db.conn.Exec(scanPolicySchema); err != nil {
+	if err := s.exec(scanPolicySchema); err != nil {
 		return err
 	}
-	db.conn.Exec("ALTER TABLE scan_policies ADD COLUMN filter_tags TEXT DEFAULT ''")
-	db.conn.Exec("ALTER TABLE retention_policies ADD COLUMN filter_repos TEXT DEFAULT ''")
-	db.conn.Exec("ALTER TABLE retention_policies ADD COLUMN exclude_repos TEXT DEFAULT ''")
-	db.conn.Exec("ALTER TABLE retention_policies ADD COLUMN exclude_tags TEXT DEFAULT ''")
-	db.conn.Exec("ALTER TABLE scan_policies ADD COLUMN filter_tags TEXT DEFAULT ''")
+	// Columns added to existing tables before migrations were numbered
+	for _, stmt := range []string{
+		"ALTER TABLE scan_policies ADD COLUMN filter_tags TEXT DEFAULT ''",
+		"ALTER TABLE retention_policies ADD COLUMN filter_repos TEXT DEFAULT ''",
+		"ALTER TABLE retention_policies ADD COLUMN exclude_repos TEXT DEFAULT ''",
+		"ALTER TABLE retention_policies ADD COLUMN exclude_tags TEXT DEFAULT ''",
+		"ALTER TABLE retention_policies ADD COLUMN rules TEXT DEFAULT '[]'",
+		"ALTER TABLE retention_policies ADD COLUMN max_repo_size_gb REAL DEFAULT 0",
+		"ALTER TABLE scan_policies ADD COLUMN filter_tags TEXT DEFAULT ''",
+		"ALTER TABLE scan_policies ADD COLUMN scan_on_push BOOLEAN DEFAULT 0",
+	} {
+		if err := s.addColumn(stmt); err != nil {
+			return err
+		}
+	}
 
 	// Vulnerability Scans table
-	_, err := db.conn.Exec(`CREATE TABLE IF NOT EXISTS vuln_scans (
+	err := s.exec(`CREATE TABLE IF NOT EXISTS vuln_scans (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		registry_id INTEGER,
 		repository TEXT,
@@ -142,6 +222,512 @@ func (db *DB) migrate() error {
 		return err
 	}
 
+	// Scan history table (one row per completed scan, never updated)
+	err = s.exec(`CREATE TABLE IF NOT EXISTS scan_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		registry_id INTEGER,
+		repository TEXT,
+		tag TEXT,
+		digest TEXT,
+		summary TEXT,
+		report TEXT,
+		scanned_at DATETIME,
+		FOREIGN KEY(registry_id) REFERENCES registries(id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		return err
+	}
+	if err := s.exec("CREATE INDEX IF NOT EXISTS idx_scan_history_image ON scan_history(registry_id, repository, tag)"); err != nil {
+		return err
+	}
+	if err := s.addColumn("ALTER TABLE scan_history ADD COLUMN report_evicted_at DATETIME"); err != nil {
+		return err
+	}
+
+	// Normalized findings of the latest scan of each image
+	err = s.exec(`CREATE TABLE IF NOT EXISTS vuln_findings (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		scan_id INTEGER NOT NULL,
+		registry_id INTEGER,
+		repository TEXT,
+		tag TEXT,
+		digest TEXT,
+		scanner TEXT,
+		vuln_id TEXT,
+		package TEXT,
+		version TEXT,
+		fixed_version TEXT DEFAULT '',
+		severity TEXT,
+		description TEXT DEFAULT '',
+		scanned_at DATETIME,
+		FOREIGN KEY(scan_id) REFERENCES vuln_scans(id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range []string{
+		"CREATE INDEX IF NOT EXISTS idx_vuln_findings_scan ON vuln_findings(scan_id)",
+		"CREATE INDEX IF NOT EXISTS idx_vuln_findings_registry ON vuln_findings(registry_id, severity)",
+	} {
+		if err := s.exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	// Full-text index over findings, kept in step with vuln_findings by triggers
+	var ftsExists int
+	if s.conn != nil {
+		s.conn.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name='vuln_findings_fts'").Scan(&ftsExists)
+	}
+	err = s.exec(`
+	CREATE VIRTUAL TABLE IF NOT EXISTS vuln_findings_fts USING fts5(vuln_id, package, description, content='vuln_findings', content_rowid='id');
+	CREATE TRIGGER IF NOT EXISTS vuln_findings_fts_insert AFTER INSERT ON vuln_findings BEGIN
+		INSERT INTO vuln_findings_fts(rowid, vuln_id, package, description) VALUES (new.id, new.vuln_id, new.package, new.description);
+	END;
+	CREATE TRIGGER IF NOT EXISTS vuln_findings_fts_delete AFTER DELETE ON vuln_findings BEGIN
+		INSERT INTO vuln_findings_fts(vuln_findings_fts, rowid, vuln_id, package, description) VALUES ('delete', old.id, old.vuln_id, old.package, old.description);
+	END;
+	CREATE TRIGGER IF NOT EXISTS vuln_findings_fts_update AFTER UPDATE ON vuln_findings BEGIN
+		INSERT INTO vuln_findings_fts(vuln_findings_fts, rowid, vuln_id, package, description) VALUES ('delete', old.id, old.vuln_id, old.package, old.description);
+		INSERT INTO vuln_findings_fts(rowid, vuln_id, package, description) VALUES (new.id, new.vuln_id, new.package, new.description);
+	END;`)
+	if err != nil {
+		return err
+	}
+	if ftsExists == 0 {
+		// Index the findings stored before the full-text index existed
+		if err := s.exec("INSERT INTO vuln_findings_fts(vuln_findings_fts) VALUES('rebuild')"); err != nil {
+			return err
+		}
+	}
+
+	// Registry events received through webhooks (activity feed)
+	err = s.exec(`CREATE TABLE IF NOT EXISTS registry_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		registry_id INTEGER,
+		source TEXT,
+		action TEXT,
+		repository TEXT,
+		tag TEXT DEFAULT '',
+		digest TEXT DEFAULT '',
+		media_type TEXT DEFAULT '',
+		actor TEXT DEFAULT '',
+		timestamp DATETIME,
+		FOREIGN KEY(registry_id) REFERENCES registries(id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		return err
+	}
+	if err := s.addColumn("ALTER TABLE registry_events ADD COLUMN detail TEXT DEFAULT ''"); err != nil {
+		return err
+	}
+	for _, stmt := range []string{
+		"CREATE INDEX IF NOT EXISTS idx_registry_events_registry ON registry_events(registry_id, timestamp)",
+		"CREATE INDEX IF NOT EXISTS idx_registry_events_repository ON registry_events(registry_id, repository, timestamp)",
+	} {
+		if err := s.exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	// Pull/push counters per tag, maintained from registry events
+	err = s.exec(`CREATE TABLE IF NOT EXISTS tag_usage (
+		registry_id INTEGER NOT NULL,
+		repository TEXT NOT NULL,
+		tag TEXT NOT NULL,
+		digest TEXT DEFAULT '',
+		pull_count INTEGER DEFAULT 0,
+		push_count INTEGER DEFAULT 0,
+		last_pulled_at DATETIME,
+		last_pushed_at DATETIME,
+		PRIMARY KEY(registry_id, repository, tag),
+		FOREIGN KEY(registry_id) REFERENCES registries(id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		return err
+	}
+	if err := s.exec("CREATE INDEX IF NOT EXISTS idx_tag_usage_digest ON tag_usage(registry_id, repository, digest)"); err != nil {
+		return err
+	}
+
+	// Permanent ledger of images deleted through the dashboard (no foreign key: entries outlive registries)
+	err = s.exec(`CREATE TABLE IF NOT EXISTS deleted_images (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		registry_id INTEGER NOT NULL,
+		registry_name TEXT DEFAULT '',
+		repository TEXT NOT NULL,
+		tag TEXT DEFAULT '',
+		digest TEXT NOT NULL,
+		size INTEGER DEFAULT 0,
+		deleted_by TEXT DEFAULT '',
+		remote_addr TEXT DEFAULT '',
+		source TEXT NOT NULL,
+		policy TEXT DEFAULT '',
+		reason TEXT DEFAULT '',
+		deleted_at DATETIME NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range []string{
+		"CREATE INDEX IF NOT EXISTS idx_deleted_images_digest ON deleted_images(digest)",
+		"CREATE INDEX IF NOT EXISTS idx_deleted_images_repo ON deleted_images(registry_id, repository, deleted_at)",
+	} {
+		if err := s.exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	// Tags pinned to a digest and the drifts found by catalog sync
+	err = s.exec(`CREATE TABLE IF NOT EXISTS tag_pins (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		registry_id INTEGER NOT NULL,
+		repository TEXT NOT NULL,
+		tag TEXT NOT NULL,
+		digest TEXT NOT NULL,
+		alert_webhook_url TEXT DEFAULT '',
+		pinned_by TEXT DEFAULT '',
+		pinned_at DATETIME NOT NULL,
+		last_checked_at DATETIME,
+		drifted BOOLEAN DEFAULT 0,
+		observed_digest TEXT DEFAULT '',
+		UNIQUE(registry_id, repository, tag),
+		FOREIGN KEY(registry_id) REFERENCES registries(id) ON DELETE CASCADE
+	);
+	CREATE TABLE IF NOT EXISTS tag_drifts (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		pin_id INTEGER NOT NULL,
+		registry_id INTEGER NOT NULL,
+		repository TEXT NOT NULL,
+		tag TEXT NOT NULL,
+		pinned_digest TEXT NOT NULL,
+		observed_digest TEXT DEFAULT '',
+		detected_at DATETIME NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+	if err := s.exec("CREATE INDEX IF NOT EXISTS idx_tag_drifts_registry ON tag_drifts(registry_id, detected_at)"); err != nil {
+		return err
+	}
+
+	// Applications grouping the same logical app across registries
+	err = s.exec(`CREATE TABLE IF NOT EXISTS applications (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		description TEXT DEFAULT '',
+		members TEXT DEFAULT '[]',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// Blue/green registry credential rotations; old_* hold the previous credentials for rollback
+	err = s.exec(`CREATE TABLE IF NOT EXISTS credential_rotations (
+		registry_id INTEGER PRIMARY KEY,
+		state TEXT NOT NULL,
+		new_username TEXT DEFAULT '',
+		new_password TEXT DEFAULT '',
+		old_username TEXT DEFAULT '',
+		old_password TEXT DEFAULT '',
+		staged_at DATETIME NOT NULL,
+		validated_at DATETIME,
+		switched_at DATETIME,
+		rollback_until DATETIME,
+		last_error TEXT DEFAULT '',
+		FOREIGN KEY(registry_id) REFERENCES registries(id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// Search index of repository and tag names across registries, kept by catalog sync and webhook events
+	err = s.exec(`CREATE TABLE IF NOT EXISTS search_index (
+		registry_id INTEGER NOT NULL,
+		repository TEXT NOT NULL,
+		tag TEXT NOT NULL DEFAULT '',
+		indexed_at DATETIME NOT NULL,
+		PRIMARY KEY(registry_id, repository, tag),
+		FOREIGN KEY(registry_id) REFERENCES registries(id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		return err
+	}
+	if err := s.exec("CREATE INDEX IF NOT EXISTS idx_search_index_tag ON search_index(tag)"); err != nil {
+		return err
+	}
+
+	// Latest connectivity and TLS certificate check of each registry
+	err = s.exec(`CREATE TABLE IF NOT EXISTS certificate_checks (
+		registry_id INTEGER PRIMARY KEY,
+		status TEXT NOT NULL,
+		reachable INTEGER DEFAULT 0,
+		error TEXT DEFAULT '',
+		verify_error TEXT DEFAULT '',
+		chain TEXT DEFAULT '[]',
+		fingerprint TEXT DEFAULT '',
+		expires_at DATETIME,
+		days_left INTEGER DEFAULT 0,
+		chain_changed INTEGER DEFAULT 0,
+		previous_fingerprint TEXT DEFAULT '',
+		checked_at DATETIME NOT NULL,
+		FOREIGN KEY(registry_id) REFERENCES registries(id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// Public images mirrored into a local registry by the seed job
+	err = s.exec(`CREATE TABLE IF NOT EXISTS seed_images (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		image TEXT NOT NULL,
+		target_registry_id INTEGER NOT NULL,
+		target_repository TEXT DEFAULT '',
+		interval_hours INTEGER DEFAULT 24,
+		enabled BOOLEAN DEFAULT 1,
+		last_digest TEXT DEFAULT '',
+		last_run_at DATETIME,
+		last_error TEXT DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY(target_registry_id) REFERENCES registries(id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// Capability profile of each registry from its latest conformance probe
+	err = s.exec(`CREATE TABLE IF NOT EXISTS registry_profiles (
+		registry_id INTEGER PRIMARY KEY,
+		url TEXT NOT NULL,
+		profile TEXT NOT NULL,
+		probed_at DATETIME NOT NULL,
+		FOREIGN KEY(registry_id) REFERENCES registries(id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// Latest dashboard statistics snapshot, computed in the background
+	err = s.exec(`CREATE TABLE IF NOT EXISTS dashboard_stats (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		data TEXT NOT NULL,
+		refreshed_at DATETIME NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// DefectDojo engagement mappings and the test each exported image was imported into
+	err = s.exec(`CREATE TABLE IF NOT EXISTS defectdojo_mappings (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		registry_id INTEGER NOT NULL,
+		repo_pattern TEXT DEFAULT '',
+		engagement_id INTEGER NOT NULL,
+		trigger TEXT DEFAULT 'scan',
+		interval_hours INTEGER DEFAULT 24,
+		enabled BOOLEAN DEFAULT 1,
+		last_export_at DATETIME,
+		last_error TEXT DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY(registry_id) REFERENCES registries(id) ON DELETE CASCADE
+	);
+	CREATE TABLE IF NOT EXISTS defectdojo_tests (
+		mapping_id INTEGER NOT NULL,
+		registry_id INTEGER NOT NULL,
+		repository TEXT NOT NULL,
+		tag TEXT NOT NULL,
+		test_id INTEGER NOT NULL,
+		scanned_at DATETIME,
+		exported_at DATETIME,
+		PRIMARY KEY(mapping_id, registry_id, repository, tag),
+		FOREIGN KEY(mapping_id) REFERENCES defectdojo_mappings(id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// Image allowlist/denylist policies and the violations found by the last sync
+	err = s.exec(`CREATE TABLE IF NOT EXISTS image_policies (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		registry_id INTEGER NOT NULL UNIQUE,
+		enabled BOOLEAN DEFAULT 0,
+		denied_namespaces TEXT DEFAULT '',
+		approved_base_images TEXT DEFAULT '',
+		action TEXT DEFAULT 'flag',
+		alert_webhook_url TEXT DEFAULT '',
+		last_evaluated_at DATETIME,
+		FOREIGN KEY(registry_id) REFERENCES registries(id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		return err
+	}
+	err = s.exec(`CREATE TABLE IF NOT EXISTS compliance_violations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		registry_id INTEGER,
+		repository TEXT,
+		tag TEXT,
+		digest TEXT DEFAULT '',
+		rule TEXT,
+		detail TEXT DEFAULT '',
+		detected_at DATETIME,
+		FOREIGN KEY(registry_id) REFERENCES registries(id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		return err
+	}
+	if err := s.exec("CREATE INDEX IF NOT EXISTS idx_compliance_violations_registry ON compliance_violations(registry_id)"); err != nil {
+		return err
+	}
+
+	// Repository metadata tracked by catalog sync, and the rules that onboard new repositories
+	err = s.exec(`CREATE TABLE IF NOT EXISTS repositories (
+		registry_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		owner TEXT DEFAULT '',
+		labels TEXT DEFAULT '{}',
+		scan_mode TEXT DEFAULT '',
+		retention_template_id INTEGER DEFAULT 0,
+		onboarding_rule_id INTEGER DEFAULT 0,
+		first_seen_at DATETIME,
+		last_seen_at DATETIME,
+		PRIMARY KEY(registry_id, name),
+		FOREIGN KEY(registry_id) REFERENCES registries(id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		return err
+	}
+	err = s.exec(`CREATE TABLE IF NOT EXISTS retention_templates (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		keep_last_count INTEGER DEFAULT 0,
+		keep_days INTEGER DEFAULT 0,
+		exclude_tags TEXT DEFAULT ''
+	)`)
+	if err != nil {
+		return err
+	}
+	err = s.exec(`CREATE TABLE IF NOT EXISTS onboarding_rules (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		registry_id INTEGER DEFAULT 0,
+		pattern TEXT NOT NULL,
+		enabled BOOLEAN DEFAULT 1,
+		owner TEXT DEFAULT '',
+		labels TEXT DEFAULT '{}',
+		scan_mode TEXT DEFAULT '',
+		retention_template_id INTEGER DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// Local accounts, their sessions and API tokens, and the audit log
+	err = s.exec(`CREATE TABLE IF NOT EXISTS users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		username TEXT NOT NULL UNIQUE,
+		password_hash TEXT NOT NULL,
+		role TEXT DEFAULT 'user',
+		password_changed_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE IF NOT EXISTS sessions (
+		token_hash TEXT PRIMARY KEY,
+		user_id INTEGER NOT NULL,
+		expires_at DATETIME NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+	CREATE TABLE IF NOT EXISTS api_tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		prefix TEXT DEFAULT '',
+		expires_at DATETIME,
+		last_used_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+	CREATE TABLE IF NOT EXISTS audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		actor TEXT DEFAULT '',
+		action TEXT NOT NULL,
+		target TEXT DEFAULT '',
+		detail TEXT DEFAULT '',
+		remote_addr TEXT DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range []string{
+		"CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id)",
+		"CREATE INDEX IF NOT EXISTS idx_api_tokens_user ON api_tokens(user_id)",
+	} {
+		if err := s.exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	// Cached storage consumption of registries and their repositories
+	err = s.exec(`CREATE TABLE IF NOT EXISTS registry_sizes (
+		registry_id INTEGER PRIMARY KEY,
+		total_size INTEGER DEFAULT 0,
+		blob_count INTEGER DEFAULT 0,
+		calculated_at DATETIME,
+		FOREIGN KEY(registry_id) REFERENCES registries(id) ON DELETE CASCADE
+	);
+	CREATE TABLE IF NOT EXISTS repository_sizes (
+		registry_id INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		size INTEGER DEFAULT 0,
+		blob_count INTEGER DEFAULT 0,
+		tag_count INTEGER DEFAULT 0,
+		PRIMARY KEY(registry_id, name),
+		FOREIGN KEY(registry_id) REFERENCES registries(id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		return err
+	}
+
+	// Scans a shutdown interrupted, queued again on the next start
+	err = s.exec(`CREATE TABLE IF NOT EXISTS interrupted_scans (
+		registry_id INTEGER NOT NULL,
+		repository TEXT NOT NULL,
+		tag TEXT NOT NULL,
+		scanner TEXT DEFAULT '',
+		platform TEXT DEFAULT '',
+		interrupted_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY(registry_id, repository, tag, scanner),
+		FOREIGN KEY(registry_id) REFERENCES registries(id) ON DELETE CASCADE
+	)`)
+	return err
+}
+
+// schemaScript runs the statements of a migration written in Go. Without a connection it only
+// records them, which is how the checksum of such a migration is taken.
+type schemaScript struct {
+	conn       *sqlConn
+	statements []string
+}
+
+func (s *schemaScript) exec(stmt string) error {
+	s.statements = append(s.statements, stmt)
+	if s.conn == nil {
+		return nil
+	}
+	_, err := s.conn.Exec(stmt)
+	return err
+}
+
+// addColumn runs an ALTER TABLE ... ADD COLUMN that databases from before migrations were
+// numbered may already have
+func (s *schemaScript) addColumn(stmt string) error {
+	if err := s.exec(stmt); err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+		return err
+	}
 	return nil
 }
 
@@ -153,12 +739,12 @@ func (db *DB) migrate() error {
 func (db *DB) SaveScan(s *models.VulnerabilityScan) error {
 	// Check if exists for same repo:tag
 	var id int64
-	err := db.conn.QueryRow("SELECT id FROM vuln_scans WHERE registry_id=? AND repository=? AND tag=?", s.RegistryID, s.Repository, s.Tag).Scan(&id)
+	err := db.conn.queryRowCached("SELECT id FROM vuln_scans WHERE registry_id=? AND repository=? AND tag=?", s.RegistryID, s.Repository, s.Tag).Scan(&id)
 
 	if err == nil {
 		// Update
-		fmt.Printf("📝 Updating scan for %s:%s. Report size: %d, Summary size: %d, Status: %s\n", s.Repository, s.Tag, len(s.Report), len(s.Summary), s.Status)
-		_, err = db.conn.Exec(`
+		logging.Debugf("📝 Updating scan for %s:%s. Report size: %d, Summary size: %d, Status: %s", s.Repository, s.Tag, len(s.Report), len(s.Summary), s.Status)
+		_, err = db.conn.execCached(`
 			UPDATE vuln_scans SET digest=?, status=?, summary=?, report=?, scanned_at=?
 			WHERE id=?
 		`, s.Digest, s.Status, s.Summary, s.Report, s.ScannedAt, id)
@@ -169,8 +755,8 @@ func (db *DB) SaveScan(s *models.VulnerabilityScan) error {
 		}
 	} else if err == sql.ErrNoRows {
 		// Insert new record
-		fmt.Printf("➕ Inserting new scan for %s:%s. Report size: %d, Summary size: %d, Status: %s\n", s.Repository, s.Tag, len(s.Report), len(s.Summary), s.Status)
-		res, execErr := db.conn.Exec(`
+		logging.Debugf("➕ Inserting new scan for %s:%s. Report size: %d, Summary size: %d, Status: %s", s.Repository, s.Tag, len(s.Report), len(s.Summary), s.Status)
+		res, execErr := db.conn.execCached(`
 			INSERT INTO vuln_scans (registry_id, repository, tag, digest, status, summary, report, scanned_at)
 			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 		`, s.RegistryID, s.Repository, s.Tag, s.Digest, s.Status, s.Summary, s.Report, s.ScannedAt)
@@ -183,6 +769,20 @@ func (db *DB) SaveScan(s *models.VulnerabilityScan) error {
 		fmt.Printf("❌ SaveScan QueryRow error: %v\n", err)
 		return err
 	}
+
+	// Keep an immutable copy of every completed scan for diffing,
+	// and refresh the normalized findings of this image
+	if s.Status == "completed" {
+		if err := db.addScanHistory(s); err != nil {
+			fmt.Printf("⚠️ SaveScan history error: %v\n", err)
+		}
+		if err := db.replaceFindings(s); err != nil {
+			fmt.Printf("⚠️ SaveScan findings error: %v\n", err)
+		}
+		if _, err := db.EnforceScanReportQuota(); err != nil {
+			fmt.Printf("⚠️ SaveScan quota error: %v\n", err)
+		}
+	}
 	return nil
 }
 
@@ -235,12 +835,12 @@ func (db *DB) ListScans(registryID int64) ([]models.VulnerabilityScan, error) {
 // GetScanPolicy returns the policy for a registry, or default if not set
 func (db *DB) GetScanPolicy(registryID int64) (*models.ScanPolicy, error) {
 	row := db.conn.QueryRow(`
-		SELECT id, registry_id, enabled, interval_hours, next_run_at, last_run_at, filter_repos, filter_tags 
+		SELECT id, registry_id, enabled, interval_hours, next_run_at, last_run_at, filter_repos, filter_tags, scan_on_push
 		FROM scan_policies WHERE registry_id=?`, registryID)
 
 	p := &models.ScanPolicy{RegistryID: registryID, IntervalHours: 24, FilterTags: "latest"}
 	var nextRun, lastRun sql.NullTime
-	if err := row.Scan(&p.ID, &p.RegistryID, &p.Enabled, &p.IntervalHours, &nextRun, &lastRun, &p.FilterRepos, &p.FilterTags); err != nil {
+	if err := row.Scan(&p.ID, &p.RegistryID, &p.Enabled, &p.IntervalHours, &nextRun, &lastRun, &p.FilterRepos, &p.FilterTags, &p.ScanOnPush); err != nil {
 		if err == sql.ErrNoRows {
 			return p, nil
 		}
@@ -258,22 +858,23 @@ func (db *DB) GetScanPolicy(registryID int64) (*models.ScanPolicy, error) {
 // SaveScanPolicy creates or updates a policy
 func (db *DB) SaveScanPolicy(p *models.ScanPolicy) error {
 	_, err := db.conn.Exec(`
-		INSERT INTO scan_policies (registry_id, enabled, interval_hours, next_run_at, last_run_at, filter_repos, filter_tags)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO scan_policies (registry_id, enabled, interval_hours, next_run_at, last_run_at, filter_repos, filter_tags, scan_on_push)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(registry_id) DO UPDATE SET
 			enabled=excluded.enabled,
 			interval_hours=excluded.interval_hours,
 			next_run_at=excluded.next_run_at,
 			filter_repos=excluded.filter_repos,
-			filter_tags=excluded.filter_tags
-	`, p.RegistryID, p.Enabled, p.IntervalHours, p.NextRunAt, p.LastRunAt, p.FilterRepos, p.FilterTags)
+			filter_tags=excluded.filter_tags,
+			scan_on_push=excluded.scan_on_push
+	`, p.RegistryID, p.Enabled, p.IntervalHours, p.NextRunAt, p.LastRunAt, p.FilterRepos, p.FilterTags, p.ScanOnPush)
 	return err
 }
 
 // ListEnabledScanPolicies returns policies that are enabled
 func (db *DB) ListEnabledScanPolicies() ([]models.ScanPolicy, error) {
 	rows, err := db.conn.Query(`
-		SELECT id, registry_id, enabled, interval_hours, next_run_at, last_run_at, filter_repos, filter_tags
+		SELECT id, registry_id, enabled, interval_hours, next_run_at, last_run_at, filter_repos, filter_tags, scan_on_push
 		FROM scan_policies WHERE enabled=1
 	`)
 	if err != nil {
@@ -285,7 +886,7 @@ func (db *DB) ListEnabledScanPolicies() ([]models.ScanPolicy, error) {
 	for rows.Next() {
 		var p models.ScanPolicy
 		var nextRun, lastRun sql.NullTime
-		if err := rows.Scan(&p.ID, &p.RegistryID, &p.Enabled, &p.IntervalHours, &nextRun, &lastRun, &p.FilterRepos, &p.FilterTags); err != nil {
+		if err := rows.Scan(&p.ID, &p.RegistryID, &p.Enabled, &p.IntervalHours, &nextRun, &lastRun, &p.FilterRepos, &p.FilterTags, &p.ScanOnPush); err != nil {
 			continue
 		}
 		if nextRun.Valid {
@@ -308,7 +909,7 @@ func (db *DB) UpdatePolicyRunTime(id int64, lastRun, nextRun time.Time) error {
 // ListRegistries returns all registries
 func (db *DB) ListRegistries() ([]models.Registry, error) {
 	rows, err := db.conn.Query(`
-		SELECT id, name, url, username, password, insecure, created_at, updated_at
+		SELECT id, name, url, username, password, insecure, type, region, tenant, namespaces, ca_cert, proxy, created_at, updated_at
 		FROM registries ORDER BY created_at DESC
 	`)
 	if err != nil {
@@ -320,7 +921,7 @@ func (db *DB) ListRegistries() ([]models.Registry, error) {
 	for rows.Next() {
 		var r models.Registry
 		var insecure int
-		err := rows.Scan(&r.ID, &r.Name, &r.URL, &r.Username, &r.Password, &insecure, &r.CreatedAt, &r.UpdatedAt)
+		err := rows.Scan(&r.ID, &r.Name, &r.URL, &r.Username, &r.Password, &insecure, &r.Type, &r.Region, &r.Tenant, &r.Namespaces, &r.CACert, &r.Proxy, &r.CreatedAt, &r.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -335,9 +936,9 @@ func (db *DB) GetRegistry(id int64) (*models.Registry, error) {
 	var r models.Registry
 	var insecure int
 	err := db.conn.QueryRow(`
-		SELECT id, name, url, username, password, insecure, created_at, updated_at
+		SELECT id, name, url, username, password, insecure, type, region, tenant, namespaces, ca_cert, proxy, created_at, updated_at
 		FROM registries WHERE id = ?
-	`, id).Scan(&r.ID, &r.Name, &r.URL, &r.Username, &r.Password, &insecure, &r.CreatedAt, &r.UpdatedAt)
+	`, id).Scan(&r.ID, &r.Name, &r.URL, &r.Username, &r.Password, &insecure, &r.Type, &r.Region, &r.Tenant, &r.Namespaces, &r.CACert, &r.Proxy, &r.CreatedAt, &r.UpdatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -345,6 +946,18 @@ func (db *DB) GetRegistry(id int64) (*models.Registry, error) {
 	return &r, nil
 }
 
+// RegistryCredentials returns the account of the registry entry at a URL, "" when it has none
+func (db *DB) RegistryCredentials(registryURL string) (string, string, error) {
+	var username, password string
+	err := db.conn.QueryRow(`
+		SELECT username, password FROM registries WHERE url = ? ORDER BY username = '', id LIMIT 1
+	`, registryURL).Scan(&username, &password)
+	if err == sql.ErrNoRows {
+		return "", "", nil
+	}
+	return username, password, err
+}
+
 // CreateRegistry creates a new registry
 func (db *DB) CreateRegistry(r *models.Registry) error {
 	insecure := 0
@@ -353,9 +966,9 @@ func (db *DB) CreateRegistry(r *models.Registry) error {
 	}
 	now := time.Now()
 	result, err := db.conn.Exec(`
-		INSERT INTO registries (name, url, username, password, insecure, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-	`, r.Name, r.URL, r.Username, r.Password, insecure, now, now)
+		INSERT INTO registries (name, url, username, password, insecure, type, region, tenant, namespaces, ca_cert, proxy, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, r.Name, r.URL, r.Username, r.Password, insecure, r.Type, r.Region, r.Tenant, r.Namespaces, r.CACert, r.Proxy, now, now)
 	if err != nil {
 		return err
 	}
@@ -373,9 +986,9 @@ func (db *DB) UpdateRegistry(r *models.Registry) error {
 	}
 	now := time.Now()
 	_, err := db.conn.Exec(`
-		UPDATE registries SET name=?, url=?, username=?, password=?, insecure=?, updated_at=?
+		UPDATE registries SET name=?, url=?, username=?, password=?, insecure=?, type=?, region=?, tenant=?, namespaces=?, ca_cert=?, proxy=?, updated_at=?
 		WHERE id=?
-	`, r.Name, r.URL, r.Username, r.Password, insecure, now, r.ID)
+	`, r.Name, r.URL, r.Username, r.Password, insecure, r.Type, r.Region, r.Tenant, r.Namespaces, r.CACert, r.Proxy, now, r.ID)
 	r.UpdatedAt = now
 	return err
 }
@@ -391,13 +1004,21 @@ func (db *DB) DeleteRegistry(id int64) error {
 // GetStorageConfig returns the current storage configuration
 func (db *DB) GetStorageConfig() (*models.StorageConfig, error) {
 	var s models.StorageConfig
-	var useSSL int
+	var useSSL, redisCache int
 	err := db.conn.QueryRow(`
 		SELECT id, type, local_path, s3_endpoint, s3_bucket, s3_region, s3_access_key, s3_secret_key, s3_use_ssl,
-		       sftp_host, sftp_port, sftp_user, sftp_password, sftp_private_key, sftp_path, created_at, updated_at
+		       sftp_host, sftp_port, sftp_user, sftp_password, sftp_private_key, sftp_path,
+		       proxy_remote_url, proxy_username, proxy_password, gcs_bucket, gcs_credentials, gcs_root_directory,
+		       swift_auth_url, swift_username, swift_password, swift_container, swift_region,
+		       redis_cache, redis_addr, redis_password, redis_db, config_overrides, registry_image,
+		       created_at, updated_at
 		FROM storage_configs ORDER BY id DESC LIMIT 1
 	`).Scan(&s.ID, &s.Type, &s.LocalPath, &s.S3Endpoint, &s.S3Bucket, &s.S3Region, &s.S3AccessKey, &s.S3SecretKey, &useSSL,
-		&s.SFTPHost, &s.SFTPPort, &s.SFTPUser, &s.SFTPPassword, &s.SFTPPrivateKey, &s.SFTPPath, &s.CreatedAt, &s.UpdatedAt)
+		&s.SFTPHost, &s.SFTPPort, &s.SFTPUser, &s.SFTPPassword, &s.SFTPPrivateKey, &s.SFTPPath,
+		&s.ProxyRemoteURL, &s.ProxyUsername, &s.ProxyPassword, &s.GCSBucket, &s.GCSCredentials, &s.GCSRootDirectory,
+		&s.SwiftAuthURL, &s.SwiftUsername, &s.SwiftPassword, &s.SwiftContainer, &s.SwiftRegion,
+		&redisCache, &s.RedisAddr, &s.RedisPassword, &s.RedisDB, &s.ConfigOverrides, &s.RegistryImage,
+		&s.CreatedAt, &s.UpdatedAt)
 	if err == sql.ErrNoRows {
 		// Return default config
 		return &models.StorageConfig{Type: "local", LocalPath: "/var/lib/registry"}, nil
@@ -406,6 +1027,7 @@ func (db *DB) GetStorageConfig() (*models.StorageConfig, error) {
 		return nil, err
 	}
 	s.S3UseSSL = useSSL == 1
+	s.RedisCache = redisCache == 1
 	return &s, nil
 }
 
@@ -416,6 +1038,10 @@ func (db *DB) SaveStorageConfig(s *models.StorageConfig) error {
 	if s.S3UseSSL {
 		useSSL = 1
 	}
+	redisCache := 0
+	if s.RedisCache {
+		redisCache = 1
+	}
 
 	// Delete existing config and insert new one (only keep one config)
 	tx, err := db.conn.Begin()
@@ -431,10 +1057,17 @@ func (db *DB) SaveStorageConfig(s *models.StorageConfig) error {
 
 	result, err := tx.Exec(`
 		INSERT INTO storage_configs (type, local_path, s3_endpoint, s3_bucket, s3_region, s3_access_key, s3_secret_key, s3_use_ssl,
-		                             sftp_host, sftp_port, sftp_user, sftp_password, sftp_private_key, sftp_path, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		                             sftp_host, sftp_port, sftp_user, sftp_password, sftp_private_key, sftp_path,
+		                             proxy_remote_url, proxy_username, proxy_password, gcs_bucket, gcs_credentials,
+		                             gcs_root_directory, swift_auth_url, swift_username, swift_password, swift_container,
+		                             swift_region, redis_cache, redis_addr, redis_password, redis_db, config_overrides,
+		                             registry_image, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`, s.Type, s.LocalPath, s.S3Endpoint, s.S3Bucket, s.S3Region, s.S3AccessKey, s.S3SecretKey, useSSL,
-		s.SFTPHost, s.SFTPPort, s.SFTPUser, s.SFTPPassword, s.SFTPPrivateKey, s.SFTPPath, now, now)
+		s.SFTPHost, s.SFTPPort, s.SFTPUser, s.SFTPPassword, s.SFTPPrivateKey, s.SFTPPath,
+		s.ProxyRemoteURL, s.ProxyUsername, s.ProxyPassword, s.GCSBucket, s.GCSCredentials, s.GCSRootDirectory,
+		s.SwiftAuthURL, s.SwiftUsername, s.SwiftPassword, s.SwiftContainer, s.SwiftRegion,
+		redisCache, s.RedisAddr, s.RedisPassword, s.RedisDB, s.ConfigOverrides, s.RegistryImage, now, now)
 	if err != nil {
 		return err
 	}
@@ -446,6 +1079,33 @@ func (db *DB) SaveStorageConfig(s *models.StorageConfig) error {
 	return tx.Commit()
 }
 
+// --- Embedded Registry Settings ---
+
+// GetEmbeddedRegistrySettings returns the port and container name the embedded registry was moved
+// to, or sql.ErrNoRows when it never was
+func (db *DB) GetEmbeddedRegistrySettings() (*models.EmbeddedRegistrySettings, error) {
+	var s models.EmbeddedRegistrySettings
+	var updated sql.NullTime
+	err := db.conn.QueryRow("SELECT port, container_name, updated_at FROM embedded_registry_settings WHERE id = 1").
+		Scan(&s.Port, &s.ContainerName, &updated)
+	if err != nil {
+		return nil, err
+	}
+	if updated.Valid {
+		s.UpdatedAt = updated.Time
+	}
+	return &s, nil
+}
+
+// SaveEmbeddedRegistrySettings keeps the port and container name of the embedded registry
+func (db *DB) SaveEmbeddedRegistrySettings(s *models.EmbeddedRegistrySettings) error {
+	s.UpdatedAt = time.Now()
+	_, err := db.conn.Exec(`
+		INSERT OR REPLACE INTO embedded_registry_settings (id, port, container_name, updated_at) VALUES (1, ?, ?, ?)
+	`, s.Port, s.ContainerName, s.UpdatedAt)
+	return err
+}
+
 // RegistryEntry is a simplified struct for auto-registration
 type RegistryEntry struct {
 	Name string
@@ -469,6 +1129,7 @@ func (db *DB) GetRetentionPolicy(registryID int64) (*models.RetentionPolicy, err
 	var p models.RetentionPolicy
 	var dryRun int
 	var lastRunAt sql.NullTime
+	var rules string
 
 	// Ensure we scan all new fields. Use simple query.
 	// Note: if columns were just added, they are NULL or default.
@@ -476,9 +1137,11 @@ func (db *DB) GetRetentionPolicy(registryID int64) (*models.RetentionPolicy, err
 
 	err := db.conn.QueryRow(`
 		SELECT id, registry_id, keep_last_count, keep_days, dry_run, last_run_at,
-		       COALESCE(filter_repos, ''), COALESCE(exclude_repos, ''), COALESCE(exclude_tags, '')
+		       COALESCE(filter_repos, ''), COALESCE(exclude_repos, ''), COALESCE(exclude_tags, ''), COALESCE(rules, '[]'),
+		       COALESCE(max_repo_size_gb, 0)
 		FROM retention_policies WHERE registry_id = ?
-	`, registryID).Scan(&p.ID, &p.RegistryID, &p.KeepLastCount, &p.KeepDays, &dryRun, &lastRunAt, &p.FilterRepos, &p.ExcludeRepos, &p.ExcludeTags)
+	`, registryID).Scan(&p.ID, &p.RegistryID, &p.KeepLastCount, &p.KeepDays, &dryRun, &lastRunAt, &p.FilterRepos, &p.ExcludeRepos, &p.ExcludeTags, &rules,
+		&p.MaxRepoSizeGB)
 
 	if err == sql.ErrNoRows {
 		// Return default policy
@@ -488,6 +1151,7 @@ func (db *DB) GetRetentionPolicy(registryID int64) (*models.RetentionPolicy, err
 			KeepDays:      0,
 			DryRun:        true,
 			ExcludeTags:   `^latest$|^main$|^master$`, // Default safe exclude (exact match)
+			Rules:         []models.TagRetentionRule{},
 		}, nil
 	}
 	if err != nil {
@@ -498,9 +1162,37 @@ func (db *DB) GetRetentionPolicy(registryID int64) (*models.RetentionPolicy, err
 	if lastRunAt.Valid {
 		p.LastRunAt = lastRunAt.Time
 	}
+	p.Rules = []models.TagRetentionRule{}
+	json.Unmarshal([]byte(rules), &p.Rules)
 	return &p, nil
 }
 
+// ListRetentionPolicies returns the saved retention policies of all registries
+func (db *DB) ListRetentionPolicies() ([]models.RetentionPolicy, error) {
+	rows, err := db.conn.Query("SELECT registry_id FROM retention_policies ORDER BY registry_id")
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	policies := []models.RetentionPolicy{}
+	for _, id := range ids {
+		p, err := db.GetRetentionPolicy(id)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, *p)
+	}
+	return policies, nil
+}
+
 // SaveRetentionPolicy saves or updates a retention policy
 func (db *DB) SaveRetentionPolicy(p *models.RetentionPolicy) error {
 	dryRun := 0
@@ -508,18 +1200,28 @@ func (db *DB) SaveRetentionPolicy(p *models.RetentionPolicy) error {
 		dryRun = 1
 	}
 
+	if p.Rules == nil {
+		p.Rules = []models.TagRetentionRule{}
+	}
+	rules, err := json.Marshal(p.Rules)
+	if err != nil {
+		return err
+	}
+
 	// Upsert policy
-	_, err := db.conn.Exec(`
-		INSERT INTO retention_policies (registry_id, keep_last_count, keep_days, dry_run, filter_repos, exclude_repos, exclude_tags)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+	_, err = db.conn.Exec(`
+		INSERT INTO retention_policies (registry_id, keep_last_count, keep_days, dry_run, filter_repos, exclude_repos, exclude_tags, rules, max_repo_size_gb)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(registry_id) DO UPDATE SET
 			keep_last_count = excluded.keep_last_count,
 			keep_days = excluded.keep_days,
 			dry_run = excluded.dry_run,
 			filter_repos = excluded.filter_repos,
 			exclude_repos = excluded.exclude_repos,
-			exclude_tags = excluded.exclude_tags
-	`, p.RegistryID, p.KeepLastCount, p.KeepDays, dryRun, p.FilterRepos, p.ExcludeRepos, p.ExcludeTags)
+			exclude_tags = excluded.exclude_tags,
+			rules = excluded.rules,
+			max_repo_size_gb = excluded.max_repo_size_gb
+	`, p.RegistryID, p.KeepLastCount, p.KeepDays, dryRun, p.FilterRepos, p.ExcludeRepos, p.ExcludeTags, string(rules), p.MaxRepoSizeGB)
 
 	return err
 }
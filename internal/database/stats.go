@@ -0,0 +1,35 @@
+package database
+
+import (
+	"encoding/json"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// --- Dashboard Stats ---
+
+// GetDashboardStats returns the latest stored stats snapshot, or sql.ErrNoRows if none was computed yet
+func (db *DB) GetDashboardStats() (*models.DashboardStats, error) {
+	var data string
+	if err := db.conn.QueryRow("SELECT data FROM dashboard_stats WHERE id=1").Scan(&data); err != nil {
+		return nil, err
+	}
+	var stats models.DashboardStats
+	if err := json.Unmarshal([]byte(data), &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// SaveDashboardStats replaces the stored stats snapshot
+func (db *DB) SaveDashboardStats(stats *models.DashboardStats) error {
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return err
+	}
+	_, err = db.conn.Exec(`
+		INSERT INTO dashboard_stats (id, data, refreshed_at) VALUES (1, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET data=excluded.data, refreshed_at=excluded.refreshed_at
+	`, string(data), stats.RefreshedAt)
+	return err
+}
@@ -0,0 +1,178 @@
+// Package defectdojo pushes normalized scan findings to DefectDojo through its import API.
+package defectdojo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"docker-registry-dashboard/internal/scanner"
+)
+
+// ScanType is the DefectDojo parser used for uploads; the generic format works for every scanner
+const ScanType = "Generic Findings Import"
+
+// Client talks to the DefectDojo v2 API
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for a DefectDojo instance (e.g. https://dojo.example.com)
+func NewClient(baseURL, apiKey string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// genericFinding is one entry of the "Generic Findings Import" JSON format
+type genericFinding struct {
+	Title            string `json:"title"`
+	Description      string `json:"description"`
+	Severity         string `json:"severity"`
+	Mitigation       string `json:"mitigation,omitempty"`
+	CVE              string `json:"cve,omitempty"`
+	ComponentName    string `json:"component_name"`
+	ComponentVersion string `json:"component_version"`
+	VulnIDFromTool   string `json:"vuln_id_from_tool"`
+	UniqueIDFromTool string `json:"unique_id_from_tool"`
+	StaticFinding    bool   `json:"static_finding"`
+	DynamicFinding   bool   `json:"dynamic_finding"`
+}
+
+// importResponse holds the fields of an import/reimport response we need
+type importResponse struct {
+	Test   int64 `json:"test"`
+	TestID int64 `json:"test_id"`
+}
+
+// ImportFindings uploads the findings of one image. The first upload (testID 0) creates a test in
+// the engagement; later uploads reimport into that test so DefectDojo closes fixed findings.
+// It returns the test ID to use for the next upload.
+func (c *Client) ImportFindings(engagementID, testID int64, image string, findings []scanner.Finding) (int64, error) {
+	report, err := json.Marshal(map[string]interface{}{"findings": genericFindings(findings)})
+	if err != nil {
+		return 0, err
+	}
+
+	fields := map[string]string{
+		"scan_type":          ScanType,
+		"active":             "true",
+		"verified":           "false",
+		"close_old_findings": "true",
+		"test_title":         image,
+		"service":            image,
+	}
+	endpoint := "/api/v2/import-scan/"
+	if testID > 0 {
+		endpoint = "/api/v2/reimport-scan/"
+		fields["test"] = strconv.FormatInt(testID, 10)
+	} else {
+		fields["engagement"] = strconv.FormatInt(engagementID, 10)
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range fields {
+		mw.WriteField(k, v)
+	}
+	part, err := mw.CreateFormFile("file", "findings.json")
+	if err != nil {
+		return 0, err
+	}
+	part.Write(report)
+	if err := mw.Close(); err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+endpoint, &body)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Token "+c.apiKey)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("defectdojo request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return 0, fmt.Errorf("defectdojo returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	var result importResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return 0, fmt.Errorf("invalid defectdojo response: %w", err)
+	}
+	if result.Test == 0 {
+		result.Test = result.TestID
+	}
+	if result.Test == 0 {
+		result.Test = testID
+	}
+	return result.Test, nil
+}
+
+// genericFindings converts normalized findings; the same vulnerability reported by several
+// scanners is uploaded once
+func genericFindings(findings []scanner.Finding) []genericFinding {
+	out := []genericFinding{}
+	seen := make(map[string]bool)
+	for _, f := range findings {
+		key := f.ID + "|" + f.Package + "|" + f.Version
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		g := genericFinding{
+			Title:            fmt.Sprintf("%s in %s %s", f.ID, f.Package, f.Version),
+			Description:      f.Description,
+			Severity:         dojoSeverity(f.Severity),
+			ComponentName:    f.Package,
+			ComponentVersion: f.Version,
+			VulnIDFromTool:   f.ID,
+			UniqueIDFromTool: key,
+			StaticFinding:    true,
+		}
+		if g.Description == "" {
+			g.Description = fmt.Sprintf("%s reported by %s", f.ID, f.Scanner)
+		}
+		if strings.HasPrefix(f.ID, "CVE-") {
+			g.CVE = f.ID
+		}
+		if f.FixedVersion != "" {
+			g.Mitigation = fmt.Sprintf("Upgrade %s to %s", f.Package, f.FixedVersion)
+		}
+		out = append(out, g)
+	}
+	return out
+}
+
+// dojoSeverity maps scanner severities onto DefectDojo's Info/Low/Medium/High/Critical
+func dojoSeverity(severity string) string {
+	switch strings.ToUpper(severity) {
+	case "CRITICAL":
+		return "Critical"
+	case "HIGH":
+		return "High"
+	case "MEDIUM", "MODERATE":
+		return "Medium"
+	case "LOW":
+		return "Low"
+	}
+	return "Info"
+}
@@ -0,0 +1,462 @@
+// Package docker is a minimal Docker Engine API client. It only implements what the embedded
+// registry needs: pulling an image, managing one container, reading its logs, running commands
+// in it and following its events.
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when the container, image or exec instance does not exist
+var ErrNotFound = errors.New("docker: not found")
+
+// defaultHost is the daemon socket used when DOCKER_HOST is not set
+const defaultHost = "unix:///var/run/docker.sock"
+
+// Client talks to a Docker daemon over its Unix socket or TCP, with TLS when configured
+type Client struct {
+	http    *http.Client
+	baseURL string // http://docker for Unix sockets
+	version string // API version path prefix, e.g. "/v1.45"; empty: the daemon's own version
+	host    string
+}
+
+// NewClientFromEnv creates a client the way the docker CLI does: DOCKER_HOST selects the daemon
+// (unix:// or tcp://), DOCKER_CERT_PATH holds ca.pem, cert.pem and key.pem for TLS,
+// DOCKER_TLS_VERIFY verifies the daemon's certificate, and DOCKER_API_VERSION pins the API version.
+func NewClientFromEnv() (*Client, error) {
+	host := os.Getenv("DOCKER_HOST")
+	if host == "" {
+		host = defaultHost
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DOCKER_HOST %q: %w", host, err)
+	}
+
+	c := &Client{host: host}
+	if v := os.Getenv("DOCKER_API_VERSION"); v != "" {
+		c.version = "/v" + strings.TrimPrefix(v, "v")
+	}
+	transport := &http.Transport{MaxIdleConns: 4, IdleConnTimeout: 30 * time.Second}
+
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		}
+		c.baseURL = "http://docker"
+	case "tcp", "http", "https":
+		tlsConfig, err := tlsConfigFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		scheme := "http"
+		if tlsConfig != nil || u.Scheme == "https" {
+			scheme = "https"
+			transport.TLSClientConfig = tlsConfig
+		}
+		c.baseURL = scheme + "://" + u.Host
+	default:
+		return nil, fmt.Errorf("unsupported DOCKER_HOST scheme %q (use unix:// or tcp://)", u.Scheme)
+	}
+	c.http = &http.Client{Transport: transport}
+	return c, nil
+}
+
+// tlsConfigFromEnv returns the TLS settings of DOCKER_CERT_PATH and DOCKER_TLS_VERIFY, or nil
+// when TLS is not configured
+func tlsConfigFromEnv() (*tls.Config, error) {
+	certPath := os.Getenv("DOCKER_CERT_PATH")
+	verify := os.Getenv("DOCKER_TLS_VERIFY") != ""
+	if certPath == "" && verify {
+		if home, err := os.UserHomeDir(); err == nil {
+			certPath = filepath.Join(home, ".docker")
+		}
+	}
+	if certPath == "" {
+		return nil, nil
+	}
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: !verify}
+	if ca, err := os.ReadFile(filepath.Join(certPath, "ca.pem")); err == nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates in %s", filepath.Join(certPath, "ca.pem"))
+		}
+		config.RootCAs = pool
+	} else if verify {
+		return nil, fmt.Errorf("failed to read the daemon CA: %w", err)
+	}
+	certFile, keyFile := filepath.Join(certPath, "cert.pem"), filepath.Join(certPath, "key.pem")
+	if _, err := os.Stat(certFile); err == nil {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the Docker client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// Host returns the daemon address the client talks to
+func (c *Client) Host() string {
+	return c.host
+}
+
+// do sends a request to the API; a 404 becomes ErrNotFound and other errors carry the daemon's message
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body io.Reader, contentType string) (*http.Response, error) {
+	u := c.baseURL + c.version + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	var apiErr struct {
+		Message string `json:"message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if json.Unmarshal(data, &apiErr) != nil || apiErr.Message == "" {
+		apiErr.Message = strings.TrimSpace(string(data))
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, apiErr.Message)
+	}
+	return nil, fmt.Errorf("docker API returned status %d: %s", resp.StatusCode, apiErr.Message)
+}
+
+// doJSON sends a request with an optional JSON body and decodes the JSON answer into out, if not nil
+func (c *Client) doJSON(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	var body io.Reader
+	contentType := ""
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body, contentType = bytes.NewReader(data), "application/json"
+	}
+	resp, err := c.do(ctx, method, path, query, body, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Ping checks that the daemon answers
+func (c *Client) Ping(ctx context.Context) error {
+	resp, err := c.do(ctx, "GET", "/_ping", nil, nil, "")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// ContainerState is the part of a container's inspection the dashboard uses
+type ContainerState struct {
+	ID        string
+	Image     string // Image ID
+	Status    string // created, running, restarting, exited, ...
+	Running   bool
+	StartedAt string
+	ExitCode  int
+}
+
+// InspectContainer returns the state of a container by name or ID
+func (c *Client) InspectContainer(ctx context.Context, name string) (*ContainerState, error) {
+	var info struct {
+		ID    string `json:"Id"`
+		Image string `json:"Image"`
+		State struct {
+			Status    string `json:"Status"`
+			Running   bool   `json:"Running"`
+			StartedAt string `json:"StartedAt"`
+			ExitCode  int    `json:"ExitCode"`
+		} `json:"State"`
+	}
+	if err := c.doJSON(ctx, "GET", "/containers/"+url.PathEscape(name)+"/json", nil, nil, &info); err != nil {
+		return nil, err
+	}
+	return &ContainerState{
+		ID:        info.ID,
+		Image:     info.Image,
+		Status:    info.State.Status,
+		Running:   info.State.Running,
+		StartedAt: info.State.StartedAt,
+		ExitCode:  info.State.ExitCode,
+	}, nil
+}
+
+// PullImage pulls an image such as "registry:2", reading the progress stream to its end
+func (c *Client) PullImage(ctx context.Context, image string) error {
+	name, tag := image, "latest"
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		name, tag = image[:i], image[i+1:]
+	}
+	resp, err := c.do(ctx, "POST", "/images/create", url.Values{"fromImage": {name}, "tag": {tag}}, nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// Failures after the pull started are reported inside the stream
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var msg struct {
+			Error string `json:"error"`
+		}
+		if err := dec.Decode(&msg); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if msg.Error != "" {
+			return fmt.Errorf("failed to pull %s: %s", image, msg.Error)
+		}
+	}
+}
+
+// ContainerSpec is what a container is created with
+type ContainerSpec struct {
+	Image         string
+	Cmd           []string
+	Binds         []string          // host-path:container-path[:ro]
+	Ports         map[string]string // container port ("5000/tcp") to host port
+	RestartPolicy string            // e.g. unless-stopped; empty: no
+	Links         []string          // container:alias, resolvable by the alias inside the container
+}
+
+// CreateContainer creates a container and returns its ID
+func (c *Client) CreateContainer(ctx context.Context, name string, spec ContainerSpec) (string, error) {
+	type portBinding struct {
+		HostPort string `json:"HostPort"`
+	}
+	exposed := map[string]struct{}{}
+	bindings := map[string][]portBinding{}
+	for containerPort, hostPort := range spec.Ports {
+		exposed[containerPort] = struct{}{}
+		bindings[containerPort] = []portBinding{{HostPort: hostPort}}
+	}
+	body := map[string]interface{}{
+		"Image":        spec.Image,
+		"ExposedPorts": exposed,
+		"HostConfig": map[string]interface{}{
+			"Binds":         spec.Binds,
+			"PortBindings":  bindings,
+			"RestartPolicy": map[string]string{"Name": spec.RestartPolicy},
+			"Links":         spec.Links,
+		},
+	}
+	if len(spec.Cmd) > 0 {
+		body["Cmd"] = spec.Cmd
+	}
+
+	var created struct {
+		ID string `json:"Id"`
+	}
+	if err := c.doJSON(ctx, "POST", "/containers/create", url.Values{"name": {name}}, body, &created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// StartContainer starts a created or stopped container
+func (c *Client) StartContainer(ctx context.Context, name string) error {
+	return c.doJSON(ctx, "POST", "/containers/"+url.PathEscape(name)+"/start", nil, nil, nil)
+}
+
+// StopContainer stops a container, killing it after timeout
+func (c *Client) StopContainer(ctx context.Context, name string, timeout time.Duration) error {
+	query := url.Values{"t": {strconv.Itoa(int(timeout.Seconds()))}}
+	return c.doJSON(ctx, "POST", "/containers/"+url.PathEscape(name)+"/stop", query, nil, nil)
+}
+
+// RemoveContainer removes a container, killing it first if it is running
+func (c *Client) RemoveContainer(ctx context.Context, name string) error {
+	return c.doJSON(ctx, "DELETE", "/containers/"+url.PathEscape(name), url.Values{"force": {"1"}}, nil, nil)
+}
+
+// CopyToContainer writes files into a directory of a container, which need not be running
+func (c *Client) CopyToContainer(ctx context.Context, name, dir string, files map[string][]byte) error {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for file, data := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: file, Mode: 0644, Size: int64(len(data)), ModTime: time.Now()}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	resp, err := c.do(ctx, "PUT", "/containers/"+url.PathEscape(name)+"/archive", url.Values{"path": {dir}}, &buf, "application/x-tar")
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Logs returns the last lines of a container's stdout and stderr
+func (c *Client) Logs(ctx context.Context, name string, tail int) (string, error) {
+	query := url.Values{"stdout": {"1"}, "stderr": {"1"}, "tail": {strconv.Itoa(tail)}}
+	resp, err := c.do(ctx, "GET", "/containers/"+url.PathEscape(name)+"/logs", query, nil, "")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var out bytes.Buffer
+	if err := demux(&out, resp.Body); err != nil {
+		return out.String(), err
+	}
+	return out.String(), nil
+}
+
+// Exec runs a command in a running container, writing its stdout and stderr to out, and returns
+// its exit code
+func (c *Client) Exec(ctx context.Context, name string, cmd []string, out io.Writer) (int, error) {
+	var created struct {
+		ID string `json:"Id"`
+	}
+	err := c.doJSON(ctx, "POST", "/containers/"+url.PathEscape(name)+"/exec", nil, map[string]interface{}{
+		"Cmd":          cmd,
+		"AttachStdout": true,
+		"AttachStderr": true,
+	}, &created)
+	if err != nil {
+		return -1, err
+	}
+
+	// Without an upgrade the daemon streams the output on the response and closes it when the command exits
+	body, _ := json.Marshal(map[string]bool{"Detach": false, "Tty": false})
+	resp, err := c.do(ctx, "POST", "/exec/"+created.ID+"/start", nil, bytes.NewReader(body), "application/json")
+	if err != nil {
+		return -1, err
+	}
+	err = demux(out, resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return -1, err
+	}
+
+	var inspect struct {
+		ExitCode int `json:"ExitCode"`
+	}
+	if err := c.doJSON(ctx, "GET", "/exec/"+created.ID+"/json", nil, nil, &inspect); err != nil {
+		return -1, err
+	}
+	return inspect.ExitCode, nil
+}
+
+// demux copies the stdout and stderr frames of a multiplexed stream (8-byte headers holding the
+// stream and frame size) to out
+func demux(out io.Writer, r io.Reader) error {
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.CopyN(out, r, size); err != nil {
+			return err
+		}
+	}
+}
+
+// Event is a container event such as start, die or destroy
+type Event struct {
+	Action     string
+	ID         string
+	Attributes map[string]string // name, image, exitCode, ...
+	Time       time.Time
+}
+
+// EventStream delivers the events of a container as the daemon reports them
+type EventStream struct {
+	body io.ReadCloser
+	dec  *json.Decoder
+}
+
+// Events subscribes to the events of the named container; the stream ends when ctx is done or
+// the connection breaks
+func (c *Client) Events(ctx context.Context, container string) (*EventStream, error) {
+	filters, err := json.Marshal(map[string][]string{"type": {"container"}, "container": {container}})
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(ctx, "GET", "/events", url.Values{"filters": {string(filters)}}, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	return &EventStream{body: resp.Body, dec: json.NewDecoder(resp.Body)}, nil
+}
+
+// Next blocks until the next event
+func (s *EventStream) Next() (Event, error) {
+	var msg struct {
+		Action string `json:"Action"`
+		Actor  struct {
+			ID         string            `json:"ID"`
+			Attributes map[string]string `json:"Attributes"`
+		} `json:"Actor"`
+		TimeNano int64 `json:"timeNano"`
+	}
+	if err := s.dec.Decode(&msg); err != nil {
+		return Event{}, err
+	}
+	return Event{
+		Action:     msg.Action,
+		ID:         msg.Actor.ID,
+		Attributes: msg.Actor.Attributes,
+		Time:       time.Unix(0, msg.TimeNano),
+	}, nil
+}
+
+// Close ends the subscription
+func (s *EventStream) Close() error {
+	return s.body.Close()
+}
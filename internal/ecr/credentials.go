@@ -0,0 +1,130 @@
+package ecr
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// metadataClient reaches the instance and container metadata services, which answer quickly or not at all
+var metadataClient = &http.Client{Timeout: 2 * time.Second}
+
+// Endpoints of the AWS metadata services
+const (
+	containerCredentialsHost = "http://169.254.170.2"
+	instanceMetadataHost     = "http://169.254.169.254"
+)
+
+// roleCredentials is how the container and instance metadata services return role credentials
+type roleCredentials struct {
+	AccessKeyID     string `json:"AccessKeyId"`
+	SecretAccessKey string `json:"SecretAccessKey"`
+	Token           string `json:"Token"`
+}
+
+// environmentCredentials finds the AWS credentials of the environment the dashboard runs in: the
+// AWS_ACCESS_KEY_ID variables, then the ECS task or EKS pod role, then the EC2 instance role
+func environmentCredentials(ctx context.Context) (*Credentials, error) {
+	if id := os.Getenv("AWS_ACCESS_KEY_ID"); id != "" {
+		return &Credentials{AccessKeyID: id, SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"), SessionToken: os.Getenv("AWS_SESSION_TOKEN")}, nil
+	}
+
+	if uri := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); uri != "" {
+		return fetchRoleCredentials(ctx, containerCredentialsHost+uri, containerAuthorization())
+	}
+	if uri := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI"); uri != "" {
+		return fetchRoleCredentials(ctx, uri, containerAuthorization())
+	}
+
+	creds, err := instanceRoleCredentials(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("no AWS credentials: set an access key on the registry, or run with an instance or task role (%v)", err)
+	}
+	return creds, nil
+}
+
+// containerAuthorization is the header EKS Pod Identity requires on the container credentials endpoint
+func containerAuthorization() map[string]string {
+	token := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if file := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); file != "" {
+		if data, err := os.ReadFile(file); err == nil {
+			token = strings.TrimSpace(string(data))
+		}
+	}
+	if token == "" {
+		return nil
+	}
+	return map[string]string{"Authorization": token}
+}
+
+// instanceRoleCredentials reads the credentials of the EC2 instance role with IMDSv2
+func instanceRoleCredentials(ctx context.Context) (*Credentials, error) {
+	req, err := http.NewRequestWithContext(ctx, "PUT", instanceMetadataHost+"/latest/api/token", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "300")
+	token, err := metadataText(req)
+	if err != nil {
+		return nil, fmt.Errorf("instance metadata: %w", err)
+	}
+	headers := map[string]string{"X-aws-ec2-metadata-token": token}
+
+	base := instanceMetadataHost + "/latest/meta-data/iam/security-credentials/"
+	req, err = http.NewRequestWithContext(ctx, "GET", base, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+	roles, err := metadataText(req)
+	if err != nil {
+		return nil, fmt.Errorf("instance role: %w", err)
+	}
+	role, _, _ := strings.Cut(strings.TrimSpace(roles), "\n")
+	if role == "" {
+		return nil, fmt.Errorf("the instance has no IAM role")
+	}
+	return fetchRoleCredentials(ctx, base+role, headers)
+}
+
+// fetchRoleCredentials reads role credentials from a metadata service
+func fetchRoleCredentials(ctx context.Context, url string, headers map[string]string) (*Credentials, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	body, err := metadataText(req)
+	if err != nil {
+		return nil, fmt.Errorf("role credentials: %w", err)
+	}
+	var rc roleCredentials
+	if err := json.Unmarshal([]byte(body), &rc); err != nil || rc.AccessKeyID == "" {
+		return nil, fmt.Errorf("invalid role credentials from %s", url)
+	}
+	return &Credentials{AccessKeyID: rc.AccessKeyID, SecretAccessKey: rc.SecretAccessKey, SessionToken: rc.Token}, nil
+}
+
+// metadataText sends a metadata service request and returns the response body
+func metadataText(req *http.Request) (string, error) {
+	resp, err := metadataClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s returned %d", req.URL.Path, resp.StatusCode)
+	}
+	return string(body), nil
+}
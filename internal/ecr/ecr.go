@@ -0,0 +1,223 @@
+// Package ecr exchanges AWS credentials for the docker credentials of Amazon ECR registries with
+// GetAuthorizationToken, signed with Signature V4. ECR credentials are valid for 12 hours; they
+// are cached and replaced shortly before they expire.
+package ecr
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// refreshBefore is how long before they expire cached registry credentials are replaced
+const refreshBefore = 30 * time.Minute
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// ecrHost matches <account>.dkr.ecr.<region>.amazonaws.com[.cn] and the FIPS variant
+var ecrHost = regexp.MustCompile(`^\d{12}\.dkr\.ecr(?:-fips)?\.([a-z0-9-]+)\.amazonaws\.com(?:\.cn)?$`)
+
+// RegionFromURL returns the region of an ECR registry URL, or "" when it is not one
+func RegionFromURL(rawURL string) string {
+	host := rawURL
+	if _, rest, ok := strings.Cut(host, "://"); ok {
+		host = rest
+	}
+	host, _, _ = strings.Cut(host, "/")
+	host, _, _ = strings.Cut(host, ":")
+	if m := ecrHost.FindStringSubmatch(strings.ToLower(host)); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// Credentials are AWS access keys
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Set for temporary credentials
+}
+
+type registryLogin struct {
+	username, password string
+	expires            time.Time
+}
+
+var (
+	loginsMu sync.Mutex
+	logins   = map[string]registryLogin{} // By region and access key
+)
+
+// Login returns the docker username and password of the ECR registries of a region. The access
+// key is exchanged for them; without one, the credentials of the environment are used: the
+// AWS_ACCESS_KEY_ID variables, the ECS/EKS container role, or the EC2 instance role.
+func Login(ctx context.Context, region, accessKey, secretKey string) (string, string, error) {
+	if region == "" {
+		return "", "", fmt.Errorf("the ECR region is not set")
+	}
+	key := region + "\x00" + accessKey + "\x00" + secretKey
+	loginsMu.Lock()
+	cached, ok := logins[key]
+	loginsMu.Unlock()
+	if ok && time.Until(cached.expires) > refreshBefore {
+		return cached.username, cached.password, nil
+	}
+
+	creds := &Credentials{AccessKeyID: accessKey, SecretAccessKey: secretKey}
+	if accessKey == "" {
+		var err error
+		if creds, err = environmentCredentials(ctx); err != nil {
+			return "", "", err
+		}
+	}
+	login, err := getAuthorizationToken(ctx, region, creds)
+	if err != nil {
+		return "", "", err
+	}
+	loginsMu.Lock()
+	logins[key] = *login
+	loginsMu.Unlock()
+	return login.username, login.password, nil
+}
+
+// endpoint is the ECR API of a region; AWS_ENDPOINT_URL_ECR or AWS_ENDPOINT_URL override it, as
+// with the AWS CLI, e.g. for a VPC endpoint
+func endpoint(region string) string {
+	for _, name := range []string{"AWS_ENDPOINT_URL_ECR", "AWS_ENDPOINT_URL"} {
+		if v := os.Getenv(name); v != "" {
+			return strings.TrimRight(v, "/")
+		}
+	}
+	if strings.HasPrefix(region, "cn-") {
+		return "https://api.ecr." + region + ".amazonaws.com.cn"
+	}
+	return "https://api.ecr." + region + ".amazonaws.com"
+}
+
+// getAuthorizationToken calls the ECR GetAuthorizationToken action
+func getAuthorizationToken(ctx context.Context, region string, creds *Credentials) (*registryLogin, error) {
+	body := []byte("{}")
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint(region)+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "AmazonEC2ContainerRegistry_V20150921.GetAuthorizationToken")
+	sign(req, body, region, creds, time.Now().UTC())
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ECR GetAuthorizationToken failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(msg, &apiErr) == nil && apiErr.Message != "" {
+			return nil, fmt.Errorf("ECR GetAuthorizationToken returned %d: %s", resp.StatusCode, apiErr.Message)
+		}
+		return nil, fmt.Errorf("ECR GetAuthorizationToken returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var out struct {
+		AuthorizationData []struct {
+			AuthorizationToken string  `json:"authorizationToken"`
+			ExpiresAt          float64 `json:"expiresAt"` // Unix seconds
+		} `json:"authorizationData"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode the ECR token: %w", err)
+	}
+	if len(out.AuthorizationData) == 0 {
+		return nil, fmt.Errorf("ECR returned no authorization data")
+	}
+	data := out.AuthorizationData[0]
+	decoded, err := base64.StdEncoding.DecodeString(data.AuthorizationToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ECR token: %w", err)
+	}
+	username, password, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid ECR token")
+	}
+	sec, frac := math.Modf(data.ExpiresAt)
+	expires := time.Unix(int64(sec), int64(frac*1e9))
+	if data.ExpiresAt == 0 {
+		expires = time.Now().Add(12 * time.Hour)
+	}
+	return &registryLogin{username: username, password: password, expires: expires}, nil
+}
+
+// sign adds a Signature V4 Authorization header for the ECR API covering every header set
+func sign(req *http.Request, body []byte, region string, creds *Credentials, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	payloadHash := sha256.Sum256(body)
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	scope := day + "/" + region + "/ecr/aws4_request"
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "ecr")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
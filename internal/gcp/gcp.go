@@ -0,0 +1,228 @@
+// Package gcp mints OAuth access tokens for Google Artifact Registry and Container Registry from a
+// service account key, or from the workload identity of the machine the dashboard runs on. Access
+// tokens last an hour; they are cached and replaced shortly before they expire.
+package gcp
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Username is the registry username that goes with an access token
+const Username = "oauth2accesstoken"
+
+// scope is the OAuth scope of the tokens, which covers pulling, pushing and listing
+const scope = "https://www.googleapis.com/auth/cloud-platform"
+
+// refreshBefore is how long before they expire cached access tokens are replaced
+const refreshBefore = 5 * time.Minute
+
+const defaultTokenURI = "https://oauth2.googleapis.com/token"
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// ServiceAccountKey is the JSON key file of a service account
+type ServiceAccountKey struct {
+	Type         string `json:"type"`
+	ProjectID    string `json:"project_id"`
+	PrivateKeyID string `json:"private_key_id"`
+	PrivateKey   string `json:"private_key"`
+	ClientEmail  string `json:"client_email"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// ParseKey parses and checks a service account JSON key
+func ParseKey(keyJSON string) (*ServiceAccountKey, error) {
+	var key ServiceAccountKey
+	if err := json.Unmarshal([]byte(keyJSON), &key); err != nil {
+		return nil, fmt.Errorf("invalid service account key: %w", err)
+	}
+	if key.Type != "service_account" || key.ClientEmail == "" || key.PrivateKey == "" {
+		return nil, fmt.Errorf("invalid service account key: not a service account JSON key")
+	}
+	if _, err := key.signer(); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// signer parses the PEM private key of the service account
+func (k *ServiceAccountKey) signer() (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(k.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("invalid service account key: no PEM private key")
+	}
+	if parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		if rsaKey, ok := parsed.(*rsa.PrivateKey); ok {
+			return rsaKey, nil
+		}
+		return nil, fmt.Errorf("invalid service account key: not an RSA key")
+	}
+	rsaKey, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid service account key: %w", err)
+	}
+	return rsaKey, nil
+}
+
+type accessToken struct {
+	token   string
+	expires time.Time
+}
+
+var (
+	tokensMu sync.Mutex
+	tokens   = map[string]accessToken{} // By service account key, "" for the environment's
+)
+
+// Login returns the registry username and password (an access token) for a service account JSON
+// key. Without a key, the environment's credentials are used: the key file GOOGLE_APPLICATION_CREDENTIALS
+// names, or the workload identity of the GKE pod or GCE instance from the metadata server.
+func Login(ctx context.Context, keyJSON string) (string, string, error) {
+	tokensMu.Lock()
+	cached, ok := tokens[keyJSON]
+	tokensMu.Unlock()
+	if ok && time.Until(cached.expires) > refreshBefore {
+		return Username, cached.token, nil
+	}
+
+	var token *accessToken
+	var err error
+	switch {
+	case keyJSON != "":
+		token, err = serviceAccountToken(ctx, keyJSON)
+	case os.Getenv("GOOGLE_APPLICATION_CREDENTIALS") != "":
+		var data []byte
+		if data, err = os.ReadFile(os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")); err == nil {
+			token, err = serviceAccountToken(ctx, string(data))
+		}
+	default:
+		token, err = metadataToken(ctx)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	tokensMu.Lock()
+	tokens[keyJSON] = *token
+	tokensMu.Unlock()
+	return Username, token.token, nil
+}
+
+// serviceAccountToken exchanges a JWT signed with the service account key for an access token
+func serviceAccountToken(ctx context.Context, keyJSON string) (*accessToken, error) {
+	key, err := ParseKey(keyJSON)
+	if err != nil {
+		return nil, err
+	}
+	tokenURI := key.TokenURI
+	if tokenURI == "" {
+		tokenURI = defaultTokenURI
+	}
+	assertion, err := key.assertion(tokenURI, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return requestToken(req)
+}
+
+// assertion is the signed JWT requesting an access token for the service account
+func (k *ServiceAccountKey) assertion(audience string, now time.Time) (string, error) {
+	signer, err := k.signer()
+	if err != nil {
+		return "", err
+	}
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": k.PrivateKeyID})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   k.ClientEmail,
+		"scope": scope,
+		"aud":   audience,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, signer, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// metadataToken reads an access token of the instance's service account from the metadata server.
+// GCE_METADATA_HOST overrides the server, as with the Google client libraries.
+func metadataToken(ctx context.Context) (*accessToken, error) {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = "metadata.google.internal"
+	}
+	u := "http://" + host + "/computeMetadata/v1/instance/service-accounts/default/token?scopes=" + url.QueryEscape(scope)
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	token, err := requestToken(req)
+	if err != nil {
+		return nil, fmt.Errorf("no GCP credentials: set a service account key on the registry, or run with workload identity (%v)", err)
+	}
+	return token, nil
+}
+
+// requestToken sends a token request and decodes the OAuth token response
+func requestToken(req *http.Request) (*accessToken, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(msg, &oauthErr) == nil && oauthErr.Error != "" {
+			return nil, fmt.Errorf("token request returned %d: %s %s", resp.StatusCode, oauthErr.Error, oauthErr.Description)
+		}
+		return nil, fmt.Errorf("token request returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode the access token: %w", err)
+	}
+	if body.AccessToken == "" {
+		return nil, fmt.Errorf("the token response has no access token")
+	}
+	if body.ExpiresIn <= 0 {
+		body.ExpiresIn = 3600
+	}
+	return &accessToken{token: body.AccessToken, expires: time.Now().Add(time.Duration(body.ExpiresIn) * time.Second)}, nil
+}
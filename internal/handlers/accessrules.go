@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"docker-registry-dashboard/internal/auth"
+	"docker-registry-dashboard/internal/models"
+)
+
+// Actions access rules limit
+const (
+	actionPush   = "push"   // Retag, copy into, rename into
+	actionDelete = "delete" // Tag deletes, retention runs, rename out of
+)
+
+// actionScope returns the repositories of a registry the caller may take an action in, nil when
+// no access rule limits it: for admins, anonymous callers and users whose teams have no rule
+// for the action
+func (h *Handler) actionScope(r *http.Request, registryID int64, action string) (func(repo string) bool, error) {
+	id := auth.FromContext(r.Context())
+	if id == nil || id.User.Role == "admin" {
+		return nil, nil
+	}
+	teams, err := h.db.ListTeams()
+	if err != nil {
+		return nil, err
+	}
+	rules, err := h.db.ListAccessRules()
+	if err != nil {
+		return nil, err
+	}
+	var mine []models.AccessRule
+	for _, rule := range rules {
+		if !slices.Contains(strings.Split(rule.Actions, ","), action) {
+			continue
+		}
+		if slices.ContainsFunc(teams, func(t models.Team) bool { return t.ID == rule.TeamID && slices.Contains(t.Members, id.User.ID) }) {
+			mine = append(mine, rule)
+		}
+	}
+	if len(mine) == 0 {
+		return nil, nil
+	}
+	return func(repo string) bool {
+		return slices.ContainsFunc(mine, func(rule models.AccessRule) bool {
+			return (rule.RegistryID == 0 || rule.RegistryID == registryID) && models.MatchRepository(rule.Repository, repo)
+		})
+	}, nil
+}
+
+// allowAction checks that the access rules of the caller's teams let them take an action in a
+// repository, writing the error response when they do not
+func (h *Handler) allowAction(w http.ResponseWriter, r *http.Request, registryID int64, repo, action string) bool {
+	allowed, err := h.actionScope(r, registryID, action)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return false
+	}
+	if allowed != nil && !allowed(repo) {
+		h.errorResponse(w, http.StatusForbidden, h.tr(w, "Access rules do not allow %s in %s", action, repo))
+		return false
+	}
+	return true
+}
+
+// --- Access Rules ---
+
+// ListAccessRules returns all access rules to admins, and to other users the rules of their teams
+func (h *Handler) ListAccessRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.db.ListAccessRules()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	id := auth.FromContext(r.Context())
+	if id != nil && id.User.Role != "admin" {
+		teams, err := h.db.ListTeams()
+		if err != nil {
+			h.errorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		rules = slices.DeleteFunc(rules, func(rule models.AccessRule) bool {
+			return !slices.ContainsFunc(teams, func(t models.Team) bool { return t.ID == rule.TeamID && slices.Contains(t.Members, id.User.ID) })
+		})
+	}
+	h.successResponse(w, rules)
+}
+
+// CreateAccessRule adds an access rule to a team (admin only)
+func (h *Handler) CreateAccessRule(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	var rule models.AccessRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	rule.ID = 0
+	h.saveAccessRule(w, r, &rule, "access_rule.create")
+}
+
+// UpdateAccessRule replaces an access rule (admin only)
+func (h *Handler) UpdateAccessRule(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid access rule ID")
+		return
+	}
+	var rule models.AccessRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	rule.ID = id
+	h.saveAccessRule(w, r, &rule, "access_rule.update")
+}
+
+func (h *Handler) saveAccessRule(w http.ResponseWriter, r *http.Request, rule *models.AccessRule, action string) {
+	team, err := h.db.GetTeam(rule.TeamID)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Team not found")
+		return
+	}
+	if rule.RegistryID != 0 {
+		if _, err := h.db.GetRegistry(rule.RegistryID); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Registry not found")
+			return
+		}
+	}
+	rule.Repository = strings.TrimSpace(rule.Repository)
+	if rule.Repository == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Repository is required")
+		return
+	}
+	actions, ok := normalizeActions(rule.Actions, actionPush, actionDelete)
+	if !ok {
+		h.errorResponse(w, http.StatusBadRequest, "Access rule actions must be push or delete")
+		return
+	}
+	rule.Actions = actions
+
+	if err := h.db.SaveAccessRule(rule); err != nil {
+		if err == sql.ErrNoRows {
+			h.errorResponse(w, http.StatusNotFound, "Access rule not found")
+			return
+		}
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to save access rule: %v", err))
+		return
+	}
+	h.audit(r, action, team.Name, rule.Actions+" in "+rule.Repository)
+	h.successResponse(w, rule)
+}
+
+// DeleteAccessRule removes an access rule (admin only)
+func (h *Handler) DeleteAccessRule(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid access rule ID")
+		return
+	}
+	if err := h.db.DeleteAccessRule(id); err != nil {
+		if err == sql.ErrNoRows {
+			h.errorResponse(w, http.StatusNotFound, "Access rule not found")
+			return
+		}
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.audit(r, "access_rule.delete", fmt.Sprintf("access rule %d", id), "")
+	h.messageResponse(w, "Access rule deleted")
+}
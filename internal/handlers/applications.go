@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/registry"
+)
+
+// --- Applications ---
+
+// ListApplications returns all applications
+func (h *Handler) ListApplications(w http.ResponseWriter, r *http.Request) {
+	apps, err := h.db.ListApplications()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	access, err := h.projectAccess(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if access != nil {
+		apps = slices.DeleteFunc(apps, func(app models.Application) bool { return !access.seesApplication(&app) })
+	}
+	h.successResponse(w, apps)
+}
+
+// seesApplication reports whether the user sees the repository of every environment of an application
+func (a *projectAccess) seesApplication(app *models.Application) bool {
+	for _, m := range app.Members {
+		if a.repositoryRole(m.RegistryID, m.Repository) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// maintainsApplication reports whether the user is a maintainer of the repository of every
+// environment of an application
+func (a *projectAccess) maintainsApplication(app *models.Application) bool {
+	for _, m := range app.Members {
+		if a.repositoryRole(m.RegistryID, m.Repository) != roleMaintainer {
+			return false
+		}
+	}
+	return true
+}
+
+// allowApplication checks that the caller maintains every repository of an application, writing
+// a 404 when they do not see one of them and a 403 when they only see them all
+func (h *Handler) allowApplication(w http.ResponseWriter, r *http.Request, app *models.Application) bool {
+	access, err := h.projectAccess(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return false
+	}
+	if access == nil || access.maintainsApplication(app) {
+		return true
+	}
+	if !access.seesApplication(app) {
+		h.errorResponse(w, http.StatusNotFound, "Application not found")
+		return false
+	}
+	h.errorResponse(w, http.StatusForbidden, "Maintainer role required in the project")
+	return false
+}
+
+// CreateApplication adds an application of repositories the caller maintains
+func (h *Handler) CreateApplication(w http.ResponseWriter, r *http.Request) {
+	var app models.Application
+	if err := json.NewDecoder(r.Body).Decode(&app); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	app.ID = 0
+	if !h.allowApplication(w, r, &app) {
+		return
+	}
+	h.saveApplication(w, &app)
+}
+
+// UpdateApplication replaces an application; the caller must maintain the repositories of both
+// the current and the new environments
+func (h *Handler) UpdateApplication(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid application ID")
+		return
+	}
+	current, err := h.db.GetApplication(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Application not found")
+		return
+	}
+	if !h.allowApplication(w, r, current) {
+		return
+	}
+
+	var app models.Application
+	if err := json.NewDecoder(r.Body).Decode(&app); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	app.ID = id
+	if !h.allowApplication(w, r, &app) {
+		return
+	}
+	h.saveApplication(w, &app)
+}
+
+func (h *Handler) saveApplication(w http.ResponseWriter, app *models.Application) {
+	if app.Name == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+	seen := make(map[string]bool)
+	for _, m := range app.Members {
+		if m.Environment == "" || m.RegistryID == 0 || m.Repository == "" {
+			h.errorResponse(w, http.StatusBadRequest, "Each member needs an environment, registry_id and repository")
+			return
+		}
+		if seen[m.Environment] {
+			h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Duplicate environment %q", m.Environment))
+			return
+		}
+		seen[m.Environment] = true
+		if _, err := h.db.GetRegistry(m.RegistryID); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Registry not found")
+			return
+		}
+	}
+
+	if err := h.db.SaveApplication(app); err != nil {
+		if err == sql.ErrNoRows {
+			h.errorResponse(w, http.StatusNotFound, "Application not found")
+			return
+		}
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to save application: %v", err))
+		return
+	}
+	h.successResponse(w, app)
+}
+
+// DeleteApplication removes an application of repositories the caller maintains
+func (h *Handler) DeleteApplication(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid application ID")
+		return
+	}
+	app, err := h.db.GetApplication(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Application not found")
+		return
+	}
+	if !h.allowApplication(w, r, app) {
+		return
+	}
+	if err := h.db.DeleteApplication(id); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.messageResponse(w, "Application deleted")
+}
+
+// GetApplicationView lists the tags of an application in every environment with their digests
+// and whether the environments match (?tag=prod,latest limits the tags, ?refresh=true bypasses the cache)
+func (h *Handler) GetApplicationView(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid application ID")
+		return
+	}
+	app, err := h.db.GetApplication(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Application not found")
+		return
+	}
+	access, err := h.projectAccess(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if access != nil && !access.seesApplication(app) {
+		h.errorResponse(w, http.StatusNotFound, "Application not found")
+		return
+	}
+
+	var only map[string]bool
+	if v := r.URL.Query().Get("tag"); v != "" {
+		only = make(map[string]bool)
+		for _, t := range strings.Split(v, ",") {
+			only[strings.TrimSpace(t)] = true
+		}
+	}
+	refresh := r.URL.Query().Get("refresh") == "true"
+
+	view := models.ApplicationView{Application: *app, Environments: []models.EnvironmentState{}, Tags: []models.ApplicationTagState{}}
+	digests := make(map[string]map[string]string) // tag -> environment -> digest
+	failed := false
+	for _, m := range app.Members {
+		env := models.EnvironmentState{ApplicationMember: m}
+		reg, err := h.db.GetRegistry(m.RegistryID)
+		if err != nil {
+			env.Error = h.tr(w, "Registry not found")
+			failed = true
+			view.Environments = append(view.Environments, env)
+			continue
+		}
+		env.RegistryName = reg.Name
+
+		client := registry.NewClientFromRegistry(reg)
+		tags, err := h.listTagsCached(r.Context(), reg, client, m.Repository, refresh)
+		if err != nil {
+			env.Error = h.tr(w, "Failed to list tags: %v", err)
+			failed = true
+			view.Environments = append(view.Environments, env)
+			continue
+		}
+		env.TagCount = len(tags)
+		if only != nil {
+			var wanted []models.Tag
+			for _, t := range tags {
+				if only[t.Name] {
+					wanted = append(wanted, t)
+				}
+			}
+			tags = wanted
+		}
+		h.resolveDigestsCached(r.Context(), reg, client, m.Repository, tags, refresh)
+		for _, t := range tags {
+			if digests[t.Name] == nil {
+				digests[t.Name] = make(map[string]string)
+			}
+			digests[t.Name][m.Environment] = t.Digest
+		}
+		view.Environments = append(view.Environments, env)
+	}
+	for tag := range only {
+		if tag != "" && digests[tag] == nil {
+			digests[tag] = make(map[string]string)
+		}
+	}
+
+	view.InSync = !failed
+	for tag, byEnv := range digests {
+		state := models.ApplicationTagState{Tag: tag, Digests: make(map[string]string), Match: true}
+		first := ""
+		for i, m := range app.Members {
+			d := byEnv[m.Environment]
+			state.Digests[m.Environment] = d
+			if i == 0 {
+				first = d
+			}
+			if d == "" || d != first {
+				state.Match = false
+			}
+		}
+		if !state.Match {
+			view.InSync = false
+		}
+		view.Tags = append(view.Tags, state)
+	}
+	sort.Slice(view.Tags, func(i, j int) bool { return view.Tags[i].Tag < view.Tags[j].Tag })
+
+	h.successResponse(w, view)
+}
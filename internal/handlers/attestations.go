@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"docker-registry-dashboard/internal/registry"
+)
+
+// SetAttestationTrust sets the keys and CA certificates attestation signatures are verified
+// against; with nil, signed attestations are reported as signed but never as verified
+func (h *Handler) SetAttestationTrust(trust *registry.AttestationTrust) {
+	h.attestationTrust = trust
+}
+
+// GetImageAttestations returns the in-toto attestations of a tag (?repo=&tag=) with their
+// verification status and the SLSA provenance they carry: builder, source repository and commit
+func (h *Handler) GetImageAttestations(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	repoName := r.URL.Query().Get("repo")
+	tag := r.URL.Query().Get("tag")
+	if repoName == "" || tag == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Repository name and tag are required")
+		return
+	}
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+
+	client := registry.NewClientFromRegistry(reg)
+	attestations, err := client.ListAttestations(r.Context(), h.attestationTrust, repoName, tag)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Failed to read attestations: %v", err))
+		return
+	}
+	h.successResponse(w, attestations)
+}
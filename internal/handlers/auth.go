@@ -0,0 +1,527 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"docker-registry-dashboard/internal/auth"
+	"docker-registry-dashboard/internal/models"
+)
+
+const (
+	sessionCookie   = "dashboard_session"
+	sessionDuration = 12 * time.Hour
+)
+
+// SetAuthRequired makes every API endpoint (except login and registry webhooks) require a session or API token
+func (h *Handler) SetAuthRequired(required bool) {
+	h.authRequired = required
+}
+
+// BootstrapAdmin creates the "admin" account when no accounts exist yet
+func (h *Handler) BootstrapAdmin(password string) (bool, error) {
+	if n, err := h.db.CountUsers(); err != nil || n > 0 {
+		return false, err
+	}
+	if len(password) < auth.MinPasswordLength {
+		return false, fmt.Errorf("admin password must be at least %d characters", auth.MinPasswordLength)
+	}
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return false, err
+	}
+	return true, h.db.CreateUser(&models.User{Username: "admin", Role: "admin", PasswordHash: hash})
+}
+
+// RegisterAuthenticator adds an authentication mechanism; mechanisms are tried in registration
+// order. Tokens and local sessions are registered by New.
+func (h *Handler) RegisterAuthenticator(a auth.Authenticator) {
+	h.authenticators = append(h.authenticators, a)
+}
+
+// Authenticate resolves the caller with the registered authenticators
+// and, when auth is required, rejects anonymous API requests
+func (h *Handler) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := h.identify(r); id != nil {
+			r = r.WithContext(auth.WithIdentity(r.Context(), id))
+		} else if h.authRequired && requiresAuth(r) {
+			h.errorResponse(w, http.StatusUnauthorized, "Authentication required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requiresAuth excludes the UI's static files, login, capabilities, the API description, the
+// embedded registry's token service (which checks registry accounts) and registry webhooks (which
+// have their own secret)
+func requiresAuth(r *http.Request) bool {
+	path := r.URL.Path
+	if !strings.HasPrefix(path, "/api/") || path == "/api/v1/auth/login" || path == "/api/v1/capabilities" ||
+		path == "/api/v1/openapi.json" || path == "/api/v1/client.js" || path == "/api/v1/registry/token" {
+		return false
+	}
+	return !isWebhook(r)
+}
+
+// isFeedPath reports whether a path serves a feed meant for feed readers
+func isFeedPath(path string) bool {
+	return strings.HasPrefix(path, "/api/v1/registries/") && strings.HasSuffix(path, "/feed")
+}
+
+func (h *Handler) identify(r *http.Request) *auth.Identity {
+	for _, a := range h.authenticators {
+		if id := a.Authenticate(r); id != nil {
+			return id
+		}
+	}
+	return nil
+}
+
+// currentUser returns the authenticated user or writes a 401
+func (h *Handler) currentUser(w http.ResponseWriter, r *http.Request) *models.User {
+	id := auth.FromContext(r.Context())
+	if id == nil {
+		h.errorResponse(w, http.StatusUnauthorized, "Authentication required")
+		return nil
+	}
+	return id.User
+}
+
+// requireAdmin returns the authenticated admin or writes a 401/403
+func (h *Handler) requireAdmin(w http.ResponseWriter, r *http.Request) *models.User {
+	user := h.currentUser(w, r)
+	if user == nil {
+		return nil
+	}
+	if user.Role != "admin" {
+		h.errorResponse(w, http.StatusForbidden, "Admin role required")
+		return nil
+	}
+	return user
+}
+
+// requestActor returns the username of the caller ("" when anonymous) and its address without port
+func requestActor(r *http.Request) (string, string) {
+	actor, addr := "", r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		addr = host
+	}
+	if id := auth.FromContext(r.Context()); id != nil {
+		actor = id.User.Username
+	}
+	return actor, addr
+}
+
+// audit records an action of the current caller
+func (h *Handler) audit(r *http.Request, action, target, detail string) {
+	entry := &models.AuditEntry{Action: action, Target: target, Detail: detail}
+	entry.Actor, entry.RemoteAddr = requestActor(r)
+	if err := h.db.AddAuditEntry(entry); err != nil {
+		log.Printf("⚠️ Failed to write audit entry %s: %v", action, err)
+	}
+}
+
+// --- Sessions ---
+
+// LoginRequest carries local account credentials
+type LoginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Login starts a session; the token is set as a cookie and also returned for non-browser clients
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	var req LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	user := h.verifyPassword(req.Username, req.Password)
+	if user == nil {
+		h.audit(r, "login.failed", req.Username, "")
+		h.errorResponse(w, http.StatusUnauthorized, "Invalid username or password")
+		return
+	}
+
+	token, hash, err := auth.NewToken("")
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	expires := time.Now().Add(sessionDuration)
+	if err := h.db.CreateSession(hash, user.ID, expires); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	r = r.WithContext(auth.WithIdentity(r.Context(), &auth.Identity{User: user, SessionHash: hash, Mechanism: "local"}))
+	h.audit(r, "login", user.Username, "")
+
+	h.successResponse(w, map[string]interface{}{"user": user, "token": token, "expires_at": expires})
+}
+
+// verifyPassword checks login credentials with every registered mechanism that accepts passwords
+func (h *Handler) verifyPassword(username, password string) *models.User {
+	for _, a := range h.authenticators {
+		v, ok := a.(auth.PasswordVerifier)
+		if !ok {
+			continue
+		}
+		if user, err := v.VerifyPassword(username, password); err == nil {
+			return user
+		}
+	}
+	return nil
+}
+
+// Logout ends the caller's session
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	if id := auth.FromContext(r.Context()); id != nil && id.SessionHash != "" {
+		h.db.DeleteSession(id.SessionHash)
+		h.audit(r, "logout", id.User.Username, "")
+	}
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: "", Path: "/", MaxAge: -1, HttpOnly: true})
+	h.messageResponse(w, "Logged out")
+}
+
+// CurrentUser returns the authenticated account
+func (h *Handler) CurrentUser(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(w, r)
+	if user == nil {
+		return
+	}
+	h.successResponse(w, user)
+}
+
+// ChangePasswordRequest changes the caller's own password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// ChangePassword sets a new password and ends the caller's other sessions
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(w, r)
+	if user == nil {
+		return
+	}
+
+	var req ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !auth.CheckPassword(user.PasswordHash, req.CurrentPassword) {
+		h.audit(r, "password.change_failed", user.Username, "wrong current password")
+		h.errorResponse(w, http.StatusForbidden, "Current password is incorrect")
+		return
+	}
+	if len(req.NewPassword) < auth.MinPasswordLength {
+		h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Password must be at least %d characters", auth.MinPasswordLength))
+		return
+	}
+
+	hash, err := auth.HashPassword(req.NewPassword)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := h.db.SetUserPassword(user.ID, hash); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	ended, _ := h.db.DeleteUserSessions(user.ID, auth.FromContext(r.Context()).SessionHash)
+	h.audit(r, "password.change", user.Username, fmt.Sprintf("%d other sessions ended", ended))
+
+	h.messageResponse(w, "Password changed")
+}
+
+// --- API Tokens ---
+
+// APITokenRequest issues or rotates an API token
+type APITokenRequest struct {
+	Name          string `json:"name"`
+	ExpiresInDays int    `json:"expires_in_days"` // 0 = never expires
+}
+
+func tokenExpiry(days int) time.Time {
+	if days <= 0 {
+		return time.Time{}
+	}
+	return time.Now().AddDate(0, 0, days)
+}
+
+// tokenPrefix is the part of a token shown in listings
+func tokenPrefix(token string) string {
+	return token[:len(auth.APITokenPrefix)+6]
+}
+
+// ListAPITokens returns the caller's API tokens (without secrets)
+func (h *Handler) ListAPITokens(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(w, r)
+	if user == nil {
+		return
+	}
+	tokens, err := h.db.ListAPITokens(user.ID)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	h.successResponse(w, tokens)
+}
+
+// CreateAPIToken issues a token for the caller; its secret is only returned now
+func (h *Handler) CreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(w, r)
+	if user == nil {
+		return
+	}
+
+	var req APITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Name == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+
+	token, hash, err := auth.NewToken(auth.APITokenPrefix)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	t := &models.APIToken{UserID: user.ID, Name: req.Name, Prefix: tokenPrefix(token), ExpiresAt: tokenExpiry(req.ExpiresInDays)}
+	if err := h.db.CreateAPIToken(t, hash); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	h.audit(r, "token.create", user.Username, fmt.Sprintf("token %d (%s)", t.ID, t.Name))
+
+	t.Token = token
+	h.jsonResponse(w, http.StatusCreated, models.APIResponse{Success: true, Data: t})
+}
+
+// RotateAPIToken replaces the secret of one of the caller's tokens; the old secret stops working at once
+func (h *Handler) RotateAPIToken(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(w, r)
+	if user == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid token ID")
+		return
+	}
+
+	var req APITokenRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	token, hash, err := auth.NewToken(auth.APITokenPrefix)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := h.db.RotateAPIToken(user.ID, id, hash, tokenPrefix(token), tokenExpiry(req.ExpiresInDays)); err != nil {
+		if err == sql.ErrNoRows {
+			h.errorResponse(w, http.StatusNotFound, "API token not found")
+			return
+		}
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	t, err := h.db.GetAPIToken(user.ID, id)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	h.audit(r, "token.rotate", user.Username, fmt.Sprintf("token %d (%s)", t.ID, t.Name))
+
+	t.Token = token
+	h.successResponse(w, t)
+}
+
+// DeleteAPIToken revokes one of the caller's tokens
+func (h *Handler) DeleteAPIToken(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(w, r)
+	if user == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid token ID")
+		return
+	}
+	if err := h.db.DeleteAPIToken(user.ID, id); err != nil {
+		if err == sql.ErrNoRows {
+			h.errorResponse(w, http.StatusNotFound, "API token not found")
+			return
+		}
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	h.audit(r, "token.revoke", user.Username, fmt.Sprintf("token %d", id))
+	h.messageResponse(w, "API token revoked")
+}
+
+// --- User administration ---
+
+// CreateUserRequest creates a local account
+type CreateUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Role     string `json:"role"` // admin or user (default)
+}
+
+// ListUsers returns all accounts (admin only)
+func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	users, err := h.db.ListUsers()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	h.successResponse(w, users)
+}
+
+// CreateUser adds an account (admin only)
+func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+
+	var req CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Role == "" {
+		req.Role = "user"
+	}
+	if req.Username == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Username is required")
+		return
+	}
+	if req.Role != "admin" && req.Role != "user" {
+		h.errorResponse(w, http.StatusBadRequest, "Role must be 'admin' or 'user'")
+		return
+	}
+	if len(req.Password) < auth.MinPasswordLength {
+		h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Password must be at least %d characters", auth.MinPasswordLength))
+		return
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	user := &models.User{Username: req.Username, Role: req.Role, PasswordHash: hash}
+	if err := h.db.CreateUser(user); err != nil {
+		h.errorResponse(w, http.StatusConflict, "Username already exists")
+		return
+	}
+	h.audit(r, "user.create", user.Username, "role "+user.Role)
+
+	h.jsonResponse(w, http.StatusCreated, models.APIResponse{Success: true, Data: user})
+}
+
+// ExpireCredentialsRequest selects what to expire; both default to true
+type ExpireCredentialsRequest struct {
+	Sessions *bool `json:"sessions"`
+	Tokens   *bool `json:"tokens"`
+}
+
+// ExpireUserCredentials ends all sessions and/or revokes all API tokens of a user (admin only)
+func (h *Handler) ExpireUserCredentials(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+	target, err := h.db.GetUser(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	var req ExpireCredentialsRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	result := map[string]int64{"sessions": 0, "tokens": 0}
+	if req.Sessions == nil || *req.Sessions {
+		if result["sessions"], err = h.db.DeleteUserSessions(target.ID, ""); err != nil {
+			h.errorResponse(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+	}
+	if req.Tokens == nil || *req.Tokens {
+		if result["tokens"], err = h.db.DeleteUserAPITokens(target.ID); err != nil {
+			h.errorResponse(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+	}
+	h.audit(r, "user.expire_credentials", target.Username,
+		fmt.Sprintf("%d sessions ended, %d tokens revoked", result["sessions"], result["tokens"]))
+
+	h.successResponse(w, result)
+}
+
+// ListAuditLog returns audit entries, newest first (admin only); ?actor= filters by user
+func (h *Handler) ListAuditLog(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	limit := 200
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = min(n, 1000)
+	}
+	entries, err := h.db.ListAuditEntries(r.URL.Query().Get("actor"), limit)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	h.successResponse(w, entries)
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+func TestAuthenticateRequiresCredentials(t *testing.T) {
+	s := newTestServer(t, true)
+	_, token := s.addUser(t, "alice", "user")
+
+	tests := []struct {
+		name, path, token string
+		want              int
+	}{
+		{"anonymous", "/api/v1/registries", "", http.StatusUnauthorized},
+		{"unknown token", "/api/v1/registries", "rdt_unknown", http.StatusUnauthorized},
+		{"API token", "/api/v1/registries", token, http.StatusOK},
+		{"anonymous capabilities", "/api/v1/capabilities", "", http.StatusOK},
+		{"anonymous health probe", "/healthz", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := s.do(http.MethodGet, tt.path, tt.token, ""); w.Code != tt.want {
+				t.Errorf("GET %s = %d %s, want %d", tt.path, w.Code, w.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthenticateIsOptionalWithoutAuth(t *testing.T) {
+	s := newTestServer(t, false)
+	if w := s.do(http.MethodGet, "/api/v1/registries", "", ""); w.Code != http.StatusOK {
+		t.Fatalf("anonymous GET /api/v1/registries = %d %s, want 200", w.Code, w.Body)
+	}
+	// Endpoints about the caller still need one
+	if w := s.do(http.MethodGet, "/api/v1/auth/me", "", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous GET /api/v1/auth/me = %d %s, want 401", w.Code, w.Body)
+	}
+}
+
+func TestLoginIssuesSessions(t *testing.T) {
+	s := newTestServer(t, true)
+	s.addUser(t, "alice", "user")
+
+	for _, body := range []string{
+		`{"username":"alice","password":"wrong-password"}`,
+		`{"username":"nobody","password":"password123"}`,
+	} {
+		w := s.do(http.MethodPost, "/api/v1/auth/login", "", body)
+		// Unknown accounts and wrong passwords answer alike
+		if resp := decode(t, w, nil); w.Code != http.StatusUnauthorized || resp.Error != "Invalid username or password" {
+			t.Errorf("login with %s = %d %q, want 401", body, w.Code, resp.Error)
+		}
+	}
+
+	w := s.do(http.MethodPost, "/api/v1/auth/login", "", `{"username":"alice","password":"password123"}`)
+	var session struct {
+		Token string      `json:"token"`
+		User  models.User `json:"user"`
+	}
+	if decode(t, w, &session); w.Code != http.StatusOK || session.Token == "" {
+		t.Fatalf("login = %d %s, want a session token", w.Code, w.Body)
+	}
+
+	w = s.do(http.MethodGet, "/api/v1/auth/me", session.Token, "")
+	var me models.User
+	if decode(t, w, &me); w.Code != http.StatusOK || me.Username != "alice" {
+		t.Fatalf("GET /api/v1/auth/me with the session = %d %s, want alice", w.Code, w.Body)
+	}
+
+	if w := s.do(http.MethodPost, "/api/v1/auth/logout", session.Token, ""); w.Code != http.StatusOK {
+		t.Fatalf("logout = %d %s", w.Code, w.Body)
+	}
+	if w := s.do(http.MethodGet, "/api/v1/auth/me", session.Token, ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("GET /api/v1/auth/me after logout = %d, want 401", w.Code)
+	}
+}
+
+func TestRequireAdmin(t *testing.T) {
+	s := newTestServer(t, true)
+	_, userToken := s.addUser(t, "alice", "user")
+	_, adminToken := s.addUser(t, "root", "admin")
+
+	tests := []struct {
+		name, token string
+		want        int
+	}{
+		{"anonymous", "", http.StatusUnauthorized},
+		{"user", userToken, http.StatusForbidden},
+		{"admin", adminToken, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := s.do(http.MethodGet, "/api/v1/users", tt.token, ""); w.Code != tt.want {
+				t.Errorf("GET /api/v1/users = %d %s, want %d", w.Code, w.Body, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"docker-registry-dashboard/internal/registry"
+)
+
+// maxRestoreBytes caps the size of an uploaded database backup
+const maxRestoreBytes = 1 << 30
+
+// BackupDatabase downloads a consistent snapshot of the dashboard database, taken with SQLite's
+// online backup API while the dashboard keeps running
+func (h *Handler) BackupDatabase(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	dir, err := os.MkdirTemp("", "dashboard-backup-")
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Backup failed: %v", err))
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "backup.db")
+	if err := h.db.Backup(path); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Backup failed: %v", err))
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Backup failed: %v", err))
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Backup failed: %v", err))
+		return
+	}
+
+	name := "registry-dashboard-" + time.Now().UTC().Format("20060102-150405") + ".db"
+	h.audit(r, "database.backup", name, fmt.Sprintf("%d bytes", info.Size()))
+	w.Header().Set("Content-Type", "application/vnd.sqlite3")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, name))
+	w.Header().Set("Content-Length", fmt.Sprint(info.Size()))
+	io.Copy(w, f)
+}
+
+// RestoreDatabase replaces the dashboard database with an uploaded backup (the raw file as the
+// request body) and upgrades its schema. The backup is checked before anything is replaced.
+func (h *Handler) RestoreDatabase(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	f, err := os.CreateTemp("", "dashboard-restore-*.db")
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Restore failed: %v", err))
+		return
+	}
+	defer os.Remove(f.Name())
+	size, err := io.Copy(f, http.MaxBytesReader(w, r.Body, maxRestoreBytes))
+	f.Close()
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.errorResponse(w, http.StatusRequestEntityTooLarge, "Backup file is too large")
+			return
+		}
+		h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Failed to read backup: %v", err))
+		return
+	}
+	if size == 0 {
+		h.errorResponse(w, http.StatusBadRequest, "Send the backup file as the request body")
+		return
+	}
+
+	if err := h.db.Restore(f.Name()); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Restore failed: %v", err))
+		return
+	}
+
+	// Nothing cached from the replaced database may be served
+	registries, err := h.db.ListRegistries()
+	if err == nil {
+		for _, reg := range registries {
+			h.invalidateListings(reg.ID)
+		}
+	}
+	if profiles, err := h.db.ListRegistryProfiles(); err == nil {
+		for url, p := range profiles {
+			registry.SetProfile(url, p)
+		}
+	}
+	go h.refreshDashboardStats()
+
+	log.Printf("♻️  Database restored from a %d-byte backup", size)
+	h.audit(r, "database.restore", "", fmt.Sprintf("%d bytes, %d registries", size, len(registries)))
+	h.messageResponse(w, "Database restored")
+}
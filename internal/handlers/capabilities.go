@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+
+	"docker-registry-dashboard/internal/auth"
+	"docker-registry-dashboard/internal/models"
+)
+
+// GetCapabilities describes the active authentication mechanisms and optional features. It is
+// served without authentication so clients can find out how to sign in.
+func (h *Handler) GetCapabilities(w http.ResponseWriter, r *http.Request) {
+	caps := models.Capabilities{
+		APIVersions: supportedAPIVersions,
+		Auth:        models.AuthCapabilities{Required: h.authRequired, Mechanisms: []string{}, Login: []string{}},
+		Features: map[string]bool{
+			"webhook_secret": h.webhookSecret != "",
+			"catalog_cache":  h.catalogTTL > 0,
+			"rate_limit":     h.rateLimiter != nil,
+			"cors":           h.cors != nil,
+			"config_reload":  h.configReloader != nil,
+		},
+	}
+	for _, a := range h.authenticators {
+		caps.Auth.Mechanisms = append(caps.Auth.Mechanisms, a.Name())
+		if _, ok := a.(auth.PasswordVerifier); ok {
+			caps.Auth.Login = append(caps.Auth.Login, a.Name())
+		}
+	}
+	h.successResponse(w, caps)
+}
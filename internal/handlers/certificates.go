@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+
+	"docker-registry-dashboard/internal/tasks"
+)
+
+// --- Certificate Checks ---
+
+// GetCertificateCheck returns the latest connectivity and TLS certificate check of a registry
+func (h *Handler) GetCertificateCheck(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	check, err := h.db.GetCertificateCheck(id)
+	if err == sql.ErrNoRows {
+		h.errorResponse(w, http.StatusNotFound, "Certificate not checked yet")
+		return
+	}
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.successResponse(w, check)
+}
+
+// CheckCertificate checks a registry's connectivity and TLS certificates now
+func (h *Handler) CheckCertificate(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+
+	check, err := tasks.CheckCertificate(h.db, reg)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.successResponse(w, check)
+}
+
+// AcceptCertificateChain accepts a registry's changed certificate chain as the expected one
+func (h *Handler) AcceptCertificateChain(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+
+	check, err := tasks.AcceptCertificateChain(h.db, id)
+	if err == sql.ErrNoRows {
+		h.errorResponse(w, http.StatusNotFound, "Certificate not checked yet")
+		return
+	}
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.audit(r, "registry.certificate.accept", reg.Name, check.Fingerprint)
+	h.successResponse(w, check)
+}
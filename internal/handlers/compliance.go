@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+
+	"docker-registry-dashboard/internal/compliance"
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/tasks"
+)
+
+// GetImagePolicy retrieves the allowlist/denylist policy for a registry
+func (h *Handler) GetImagePolicy(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+
+	policy, err := h.db.GetImagePolicy(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to get image policy: %v", err))
+		return
+	}
+	h.successResponse(w, policy)
+}
+
+// SaveImagePolicy saves the allowlist/denylist policy for a registry
+func (h *Handler) SaveImagePolicy(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+
+	var policy models.ImagePolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	policy.RegistryID = id
+
+	if policy.Action == "" {
+		policy.Action = "flag"
+	}
+	if policy.Action != "flag" && policy.Action != "alert" {
+		h.errorResponse(w, http.StatusBadRequest, "Action must be 'flag' or 'alert'")
+		return
+	}
+	if policy.Action == "alert" && policy.AlertWebhookURL == "" {
+		h.errorResponse(w, http.StatusBadRequest, "alert_webhook_url is required for action 'alert'")
+		return
+	}
+	for _, pattern := range compliance.SplitList(policy.ApprovedBaseImages) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Invalid base image pattern %q", pattern))
+			return
+		}
+	}
+
+	if err := h.db.SaveImagePolicy(&policy); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to save policy: %v", err))
+		return
+	}
+	h.successResponse(w, policy)
+}
+
+// SyncRegistry walks a registry's catalog now and evaluates its image policy
+func (h *Handler) SyncRegistry(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+
+	result, err := tasks.SyncRegistry(r.Context(), h.db, reg)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Sync failed: %v", err))
+		return
+	}
+	h.invalidateListings(reg.ID)
+	h.successResponse(w, result)
+}
+
+// GetComplianceReport returns the policy violations found by the last sync (?registry_id= for one
+// registry), limited to the repositories the caller sees
+func (h *Handler) GetComplianceReport(w http.ResponseWriter, r *http.Request) {
+	registries, err := h.db.ListRegistries()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	access, err := h.projectAccess(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	var filterID int64
+	if v := r.URL.Query().Get("registry_id"); v != "" {
+		if filterID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid registry_id")
+			return
+		}
+	}
+
+	reports := []models.ComplianceReport{}
+	for _, reg := range registries {
+		if filterID != 0 && reg.ID != filterID {
+			continue
+		}
+		if access != nil && !access.seesRegistry(reg.ID) {
+			continue
+		}
+
+		policy, err := h.db.GetImagePolicy(reg.ID)
+		if err != nil {
+			h.errorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		violations, err := h.db.ListViolations(reg.ID)
+		if err != nil {
+			h.errorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		if access != nil {
+			violations = slices.DeleteFunc(violations, func(v models.ComplianceViolation) bool {
+				return access.repositoryRole(reg.ID, v.Repository) == ""
+			})
+		}
+
+		reports = append(reports, models.ComplianceReport{
+			RegistryID:      reg.ID,
+			RegistryName:    reg.Name,
+			PolicyEnabled:   policy.Enabled,
+			LastEvaluatedAt: policy.LastEvaluatedAt,
+			Violations:      violations,
+		})
+	}
+	h.successResponse(w, reports)
+}
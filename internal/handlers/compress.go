@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// defaultCompressMinBytes is the smallest response body worth compressing
+const defaultCompressMinBytes = 1024
+
+// compressibleTypes are the content types compressed besides text/*, *+json and *+xml
+var compressibleTypes = map[string]bool{
+	"application/json":       true,
+	"application/javascript": true,
+	"application/xml":        true,
+	"application/x-ndjson":   true,
+	"application/yaml":       true,
+	"image/svg+xml":          true,
+}
+
+var gzipWriters = sync.Pool{New: func() interface{} { return gzip.NewWriter(io.Discard) }}
+
+// SetCompression sets the smallest response body that is compressed; negative disables compression
+func (h *Handler) SetCompression(minBytes int) {
+	h.compressMinBytes = minBytes
+}
+
+// Compress gzip- or deflate-encodes responses with a compressible content type (JSON, text, XML,
+// JavaScript) of at least the minimum size for clients that accept it. Responses that are already
+// encoded, partial or empty, and archives or images, are sent as they are. It wraps every other
+// middleware, as i18n.Language needs to see the i18n response writer.
+func (h *Handler) Compress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if h.compressMinBytes < 0 || encoding == "" || r.Method == http.MethodHead || r.Header.Get("Range") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding, minBytes: h.compressMinBytes}
+		next.ServeHTTP(cw, r)
+		// Not deferred: a handler aborting mid-stream must not get a valid encoding trailer
+		cw.Close()
+	})
+}
+
+// negotiateEncoding picks gzip or deflate from an Accept-Encoding header, honoring q=0
+func negotiateEncoding(header string) string {
+	accepted := map[string]bool{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if q, ok := strings.CutPrefix(strings.ReplaceAll(params, " ", ""), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				continue
+			}
+		}
+		accepted[name] = true
+	}
+	for _, enc := range []string{"gzip", "deflate"} {
+		if accepted[enc] || accepted["*"] {
+			return enc
+		}
+	}
+	return ""
+}
+
+// isCompressible reports whether a content type benefits from compression
+func isCompressible(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	return strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "+json") ||
+		strings.HasSuffix(mediaType, "+xml") || compressibleTypes[mediaType]
+}
+
+// compressWriter holds back the start of a response until it knows whether to compress it: once
+// minBytes are written, or when the handler ends or flushes
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minBytes int
+
+	status  int
+	buf     []byte
+	decided bool
+	enc     io.WriteCloser
+	gz      *gzip.Writer // Returned to the pool on Close
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.decided || cw.status != 0 {
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if status < 200 {
+		// Informational responses (e.g. 103 Early Hints) go out right away
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+	cw.status = status
+}
+
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if !cw.decided {
+		cw.buf = append(cw.buf, p...)
+		if len(cw.buf) < cw.minBytes {
+			return len(p), nil
+		}
+		if err := cw.decide(); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if cw.enc != nil {
+		return cw.enc.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// decide sends the status line, compressed or not, and the buffered start of the body
+func (cw *compressWriter) decide() error {
+	cw.decided = true
+	hdr := cw.Header()
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if hdr.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		hdr.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+	compress := len(cw.buf) >= cw.minBytes && hdr.Get("Content-Encoding") == "" &&
+		cw.status != http.StatusNoContent && cw.status != http.StatusNotModified &&
+		cw.status != http.StatusPartialContent && isCompressible(hdr.Get("Content-Type"))
+	if isCompressible(hdr.Get("Content-Type")) {
+		hdr.Add("Vary", "Accept-Encoding")
+	}
+
+	if compress {
+		hdr.Set("Content-Encoding", cw.encoding)
+		hdr.Del("Content-Length")
+		if cw.encoding == "gzip" {
+			cw.gz = gzipWriters.Get().(*gzip.Writer)
+			cw.gz.Reset(cw.ResponseWriter)
+			cw.enc = cw.gz
+		} else {
+			cw.enc, _ = flate.NewWriter(cw.ResponseWriter, flate.DefaultCompression)
+		}
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if cw.enc != nil {
+		_, err = cw.enc.Write(buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// Flush sends what was written so far, deciding on compression with what is known
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		cw.decide()
+	}
+	if f, ok := cw.enc.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Close sends a response shorter than minBytes as it is and finishes the encoding of others
+func (cw *compressWriter) Close() {
+	if !cw.decided {
+		if cw.status == 0 && len(cw.buf) == 0 {
+			return // Nothing written: let the server send its default response
+		}
+		cw.decide()
+	}
+	if cw.enc != nil {
+		cw.enc.Close()
+	}
+	if cw.gz != nil {
+		cw.gz.Reset(io.Discard)
+		gzipWriters.Put(cw.gz)
+		cw.gz = nil
+	}
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
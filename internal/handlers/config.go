@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// SetConfigReloader sets what rereads the configuration file and applies its changes
+func (h *Handler) SetConfigReloader(reload func() (*models.ConfigReload, error)) {
+	h.configReloader = reload
+}
+
+// ReloadConfig rereads the configuration file and applies changed settings without a restart
+func (h *Handler) ReloadConfig(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	if h.configReloader == nil {
+		h.errorResponse(w, http.StatusConflict, "No configuration file to reload; start the dashboard with -config")
+		return
+	}
+	result, err := h.configReloader()
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Failed to reload configuration: %v", err))
+		return
+	}
+	detail := "no changes"
+	if len(result.Changed) > 0 {
+		detail = "changed " + strings.Join(result.Changed, ", ")
+	}
+	if len(result.RestartRequired) > 0 {
+		detail += fmt.Sprintf("; %s need a restart", strings.Join(result.RestartRequired, ", "))
+	}
+	h.audit(r, "config.reload", result.File, detail)
+	h.successResponse(w, result)
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+
+	"docker-registry-dashboard/internal/registry"
+)
+
+// --- Conformance ---
+
+// GetRegistryProfile returns the capability profile of a registry from its latest conformance probe
+func (h *Handler) GetRegistryProfile(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	p, err := h.db.GetRegistryProfile(id)
+	if err == sql.ErrNoRows {
+		h.errorResponse(w, http.StatusNotFound, "Registry not probed yet")
+		return
+	}
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.successResponse(w, p)
+}
+
+// ProbeConformance runs the OCI distribution conformance checks against a registry and stores the
+// resulting profile, which registry clients then use to choose how to list tags, resolve digests,
+// delete manifests and look up referrers
+func (h *Handler) ProbeConformance(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+
+	// Probe with every code path enabled, not the ones an earlier profile narrowed down
+	registry.SetProfile(reg.URL, nil)
+	p, err := registry.NewClientFromRegistry(reg).ProbeConformance(r.Context())
+	if err != nil {
+		h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Conformance probe failed: %v", err))
+		return
+	}
+	p.RegistryID = id
+	if err := h.db.SaveRegistryProfile(reg.URL, p); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	registry.SetProfile(reg.URL, p)
+	h.invalidateListings(id)
+
+	failed := 0
+	for _, c := range p.Checks {
+		if c.Status == registry.CheckFail {
+			failed++
+		}
+	}
+	h.audit(r, "registry.conformance", reg.Name, fmt.Sprintf("%d checks, %d failed", len(p.Checks), failed))
+	h.successResponse(w, p)
+}
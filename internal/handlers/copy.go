@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/registry"
+)
+
+// CopyRequest asks to copy an image from one registered registry to another
+type CopyRequest struct {
+	SourceRegistryID int64  `json:"source_registry_id"`
+	SourceRepository string `json:"source_repository"`
+	SourceTag        string `json:"source_tag"`
+	TargetRegistryID int64  `json:"target_registry_id"`
+	TargetRepository string `json:"target_repository"` // Defaults to the source repository
+	TargetTag        string `json:"target_tag"`        // Defaults to the source tag
+}
+
+// copyTracker keeps the progress of copy jobs started by this process
+type copyTracker struct {
+	mu   sync.Mutex
+	jobs map[string]*models.CopyJob
+}
+
+func (t *copyTracker) add(job *models.CopyJob) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.jobs == nil {
+		t.jobs = make(map[string]*models.CopyJob)
+	}
+	for id, old := range t.jobs {
+		if finishedLongAgo(old.FinishedAt) {
+			delete(t.jobs, id)
+		}
+	}
+	t.jobs[job.ID] = job
+}
+
+func (t *copyTracker) update(id string, apply func(job *models.CopyJob)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if job, ok := t.jobs[id]; ok {
+		apply(job)
+	}
+}
+
+func (t *copyTracker) get(id string) (models.CopyJob, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	job, ok := t.jobs[id]
+	if !ok {
+		return models.CopyJob{}, false
+	}
+	return *job, true
+}
+
+func (t *copyTracker) list() []models.CopyJob {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	jobs := make([]models.CopyJob, 0, len(t.jobs))
+	for _, job := range t.jobs {
+		jobs = append(jobs, *job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].StartedAt.After(jobs[j].StartedAt) })
+	return jobs
+}
+
+// finishedJobTTL is how long copy, rename and export jobs stay readable after they finished.
+// Finished jobs older than that are dropped when another job starts.
+const finishedJobTTL = 24 * time.Hour
+
+func finishedLongAgo(finishedAt time.Time) bool {
+	return !finishedAt.IsZero() && time.Since(finishedAt) > finishedJobTTL
+}
+
+func newJobID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// CopyImage starts copying a repo:tag between registries; poll GET /api/v1/images/copy/{id} for progress
+func (h *Handler) CopyImage(w http.ResponseWriter, r *http.Request) {
+	var req CopyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.SourceRepository == "" || req.SourceTag == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Source repository and tag are required")
+		return
+	}
+	if req.TargetRepository == "" {
+		req.TargetRepository = req.SourceRepository
+	}
+	if req.TargetTag == "" {
+		req.TargetTag = req.SourceTag
+	}
+
+	srcReg, err := h.db.GetRegistry(req.SourceRegistryID)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Source registry not found")
+		return
+	}
+	dstReg, err := h.db.GetRegistry(req.TargetRegistryID)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Target registry not found")
+		return
+	}
+	if srcReg.ID == dstReg.ID && req.SourceRepository == req.TargetRepository && req.SourceTag == req.TargetTag {
+		h.errorResponse(w, http.StatusBadRequest, "Source and target are the same image")
+		return
+	}
+	if !h.allowRepository(w, r, srcReg.ID, req.SourceRepository, false) || !h.allowRepository(w, r, dstReg.ID, req.TargetRepository, true) {
+		return
+	}
+	if !h.allowAction(w, r, dstReg.ID, req.TargetRepository, actionPush) {
+		return
+	}
+	if !h.allowTagWrite(r.Context(), w, registry.NewClientFromRegistry(dstReg), dstReg.ID, req.TargetRepository, req.TargetTag) || !h.allowCopyInto(w, dstReg.ID, req.TargetRepository) {
+		return
+	}
+
+	job := &models.CopyJob{
+		ID:               newJobID(),
+		SourceRegistryID: srcReg.ID,
+		SourceRepository: req.SourceRepository,
+		SourceTag:        req.SourceTag,
+		TargetRegistryID: dstReg.ID,
+		TargetRepository: req.TargetRepository,
+		TargetTag:        req.TargetTag,
+		Status:           "running",
+		StartedAt:        time.Now(),
+	}
+	h.copyJobs.add(job)
+	snapshot, _ := h.copyJobs.get(job.ID)
+
+	go h.runCopy(job.ID, srcReg, dstReg, req)
+
+	h.jsonResponse(w, http.StatusAccepted, models.APIResponse{Success: true, Data: snapshot})
+}
+
+func (h *Handler) runCopy(id string, srcReg, dstReg *models.Registry, req CopyRequest) {
+	src := registry.NewClientFromRegistry(srcReg)
+	dst := registry.NewClientFromRegistry(dstReg)
+
+	digest, err := registry.CopyImage(context.Background(), src, req.SourceRepository, req.SourceTag,
+		dst, req.TargetRepository, req.TargetTag, func(stats registry.CopyStats) {
+			h.copyJobs.update(id, func(job *models.CopyJob) {
+				job.Manifests = stats.Manifests
+				job.TotalBlobs = stats.TotalBlobs
+				job.CopiedBlobs = stats.CopiedBlobs
+				job.SkippedBlobs = stats.SkippedBlobs
+				job.TotalBytes = stats.TotalBytes
+				job.CopiedBytes = stats.CopiedBytes
+			})
+		})
+
+	h.copyJobs.update(id, func(job *models.CopyJob) {
+		job.FinishedAt = time.Now()
+		if err != nil {
+			job.Status = "failed"
+			job.Error = err.Error()
+			return
+		}
+		job.Status = "completed"
+		job.Digest = digest
+	})
+
+	if err != nil {
+		log.Printf("❌ Copy %s:%s -> %s:%s failed: %v", req.SourceRepository, req.SourceTag, req.TargetRepository, req.TargetTag, err)
+		return
+	}
+	h.invalidateListings(dstReg.ID)
+	log.Printf("✅ Copied %s:%s (registry %d) -> %s:%s (registry %d)",
+		req.SourceRepository, req.SourceTag, srcReg.ID, req.TargetRepository, req.TargetTag, dstReg.ID)
+}
+
+// GetCopyJob returns the progress of a copy job
+func (h *Handler) GetCopyJob(w http.ResponseWriter, r *http.Request) {
+	access, err := h.projectAccess(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	job, ok := h.copyJobs.get(r.PathValue("id"))
+	if !ok || access != nil && !access.seesCopy(job) {
+		h.errorResponse(w, http.StatusNotFound, "Copy job not found")
+		return
+	}
+	h.successResponse(w, job)
+}
+
+// ListCopyJobs returns the copy jobs started since the dashboard started, newest first
+func (h *Handler) ListCopyJobs(w http.ResponseWriter, r *http.Request) {
+	access, err := h.projectAccess(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	jobs := h.copyJobs.list()
+	if access != nil {
+		jobs = slices.DeleteFunc(jobs, func(job models.CopyJob) bool { return !access.seesCopy(job) })
+	}
+	h.successResponse(w, jobs)
+}
+
+// seesCopy reports whether the user sees both the source and the target repository of a copy job
+func (a *projectAccess) seesCopy(job models.CopyJob) bool {
+	return a.repositoryRole(job.SourceRegistryID, job.SourceRepository) != "" &&
+		a.repositoryRole(job.TargetRegistryID, job.TargetRepository) != ""
+}
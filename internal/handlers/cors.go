@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig lets browser apps on other origins call the API
+type CORSConfig struct {
+	AllowedOrigins   []string // Exact origins, "https://*.example.com" for subdomains, or "*" for any
+	AllowedMethods   []string
+	AllowedHeaders   []string // "*" allows whatever a preflight asks for
+	AllowCredentials bool     // Let browsers send the session cookie; not allowed with the "*" origin
+	MaxAge           time.Duration
+}
+
+// corsExposedHeaders are the response headers of the API that cross-origin scripts may read
+var corsExposedHeaders = []string{
+	"API-Version", "API-Supported-Versions", "Deprecation", "Link", "Content-Disposition",
+	"Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-Export-Job",
+}
+
+// SetCORS enables cross-origin access to the API for the configured origins
+func (h *Handler) SetCORS(cfg CORSConfig) error {
+	for _, o := range cfg.AllowedOrigins {
+		if o == "*" && cfg.AllowCredentials {
+			return fmt.Errorf("credentials cannot be allowed for any origin (*); list the origins")
+		}
+		if o != "*" && !strings.HasPrefix(o, "http://") && !strings.HasPrefix(o, "https://") {
+			return fmt.Errorf("origin %q must start with http:// or https://", o)
+		}
+	}
+	h.cors = &cfg
+	return nil
+}
+
+// originAllowed reports whether a request origin matches an allowed origin
+func (c *CORSConfig) originAllowed(origin string) bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+		// https://*.example.com matches https://app.example.com, not https://example.com
+		if scheme, domain, ok := strings.Cut(allowed, "://*."); ok {
+			rest, found := strings.CutPrefix(strings.ToLower(origin), strings.ToLower(scheme)+"://")
+			if found && strings.HasSuffix(rest, "."+strings.ToLower(domain)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CORS adds the CORS headers to API responses for allowed origins and answers their preflight
+// requests. It runs before authentication, since browsers send preflights without credentials.
+func (h *Handler) CORS(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := h.cors
+		if c == nil || !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin")
+		origin := r.Header.Get("Origin")
+		if origin == "" || !c.originAllowed(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(c.AllowedOrigins) == 1 && c.AllowedOrigins[0] == "*" {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		if c.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(c.AllowedMethods, ", "))
+			headers := strings.Join(c.AllowedHeaders, ", ")
+			if headers == "*" {
+				headers = r.Header.Get("Access-Control-Request-Headers")
+			}
+			if headers != "" {
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+			}
+			if c.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge/time.Second)))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		w.Header().Set("Access-Control-Expose-Headers", strings.Join(corsExposedHeaders, ", "))
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,211 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/registry"
+)
+
+// defaultRollbackHours is how long replaced credentials are kept when a switch does not say
+const defaultRollbackHours = 24
+
+// StageCredentialsRequest carries the secondary credential set of a rotation
+type StageCredentialsRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// SwitchCredentialsRequest sets how long the replaced credentials stay available for rollback
+type SwitchCredentialsRequest struct {
+	RollbackHours int `json:"rollback_hours"`
+}
+
+// --- Credential Rotation ---
+
+// GetCredentialRotation returns the state of a registry's credential rotation (passwords are never returned)
+func (h *Handler) GetCredentialRotation(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	rotation, err := h.db.GetCredentialRotation(id)
+	if err == sql.ErrNoRows {
+		h.errorResponse(w, http.StatusNotFound, "No credential rotation in progress")
+		return
+	}
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.successResponse(w, rotation)
+}
+
+// StageCredentials stores a secondary credential set next to the active one
+func (h *Handler) StageCredentials(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	var req StageCredentialsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Username == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Username is required")
+		return
+	}
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+
+	if err := h.db.StageCredentials(reg.ID, req.Username, req.Password); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.audit(r, "registry.credentials.stage", reg.Name, "user "+req.Username)
+	h.respondRotation(w, reg.ID)
+}
+
+// ValidateCredentials checks the staged credentials against the registry without using them yet
+func (h *Handler) ValidateCredentials(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+	rotation, err := h.db.GetCredentialRotation(id)
+	if err != nil || (rotation.State != models.RotationStaged && rotation.State != models.RotationValidated && rotation.State != models.RotationRolledBack) {
+		h.errorResponse(w, http.StatusConflict, "No staged credentials to validate")
+		return
+	}
+
+	errMsg := ""
+	staged := *reg
+	staged.Username, staged.Password = rotation.NewUsername, rotation.NewPassword
+	client := registry.NewClientFromRegistry(&staged)
+	if err := client.Ping(r.Context()); err != nil {
+		errMsg = err.Error()
+	} else if _, err := client.ListRepositories(r.Context()); err != nil {
+		errMsg = err.Error()
+	}
+	if err := h.db.SetCredentialValidation(id, time.Now(), errMsg); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if errMsg != "" {
+		h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Credential validation failed: %s", errMsg))
+		return
+	}
+	h.respondRotation(w, id)
+}
+
+// SwitchCredentials atomically makes the validated credentials active, keeping the old ones for rollback
+func (h *Handler) SwitchCredentials(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	var req SwitchCredentialsRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+	if req.RollbackHours < 0 {
+		h.errorResponse(w, http.StatusBadRequest, "rollback_hours must not be negative")
+		return
+	}
+	if req.RollbackHours == 0 {
+		req.RollbackHours = defaultRollbackHours
+	}
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+
+	until := time.Now().Add(time.Duration(req.RollbackHours) * time.Hour)
+	if err := h.db.SwitchCredentials(id, until); err != nil {
+		if err == sql.ErrNoRows {
+			h.errorResponse(w, http.StatusConflict, "Validate the staged credentials before switching")
+			return
+		}
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.credentialsChanged(reg)
+	h.audit(r, "registry.credentials.switch", reg.Name, fmt.Sprintf("rollback possible for %dh", req.RollbackHours))
+	h.respondRotation(w, id)
+}
+
+// RollbackCredentials restores the credentials replaced by the last switch while they are still kept
+func (h *Handler) RollbackCredentials(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+
+	if err := h.db.RollbackCredentials(id); err != nil {
+		if err == sql.ErrNoRows {
+			h.errorResponse(w, http.StatusConflict, "No previous credentials to roll back to")
+			return
+		}
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.credentialsChanged(reg)
+	h.audit(r, "registry.credentials.rollback", reg.Name, "")
+	h.respondRotation(w, id)
+}
+
+// DeleteCredentialRotation discards staged credentials, or the kept old credentials after a switch
+func (h *Handler) DeleteCredentialRotation(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	if err := h.db.DeleteCredentialRotation(id); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.messageResponse(w, "Credential rotation discarded")
+}
+
+// credentialsChanged drops state tied to the registry's previous credentials
+func (h *Handler) credentialsChanged(reg *models.Registry) {
+	registry.ResetCircuit(reg.URL)
+	h.invalidateListings(reg.ID)
+}
+
+func (h *Handler) respondRotation(w http.ResponseWriter, registryID int64) {
+	rotation, err := h.db.GetCredentialRotation(registryID)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.successResponse(w, rotation)
+}
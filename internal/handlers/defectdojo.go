@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"slices"
+
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/tasks"
+)
+
+// --- DefectDojo Mappings ---
+
+// ListDefectDojoMappings returns the DefectDojo engagement mappings of the registries the caller sees
+func (h *Handler) ListDefectDojoMappings(w http.ResponseWriter, r *http.Request) {
+	mappings, err := h.db.ListDefectDojoMappings()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	access, err := h.projectAccess(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if access != nil {
+		mappings = slices.DeleteFunc(mappings, func(m models.DefectDojoMapping) bool { return !access.seesRegistry(m.RegistryID) })
+	}
+	h.successResponse(w, map[string]interface{}{
+		"enabled":  tasks.DefectDojoEnabled(),
+		"mappings": mappings,
+	})
+}
+
+// CreateDefectDojoMapping adds a DefectDojo engagement mapping (admin only)
+func (h *Handler) CreateDefectDojoMapping(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	m := models.DefectDojoMapping{Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	m.ID = 0
+	h.saveDefectDojoMapping(w, &m)
+}
+
+// UpdateDefectDojoMapping replaces a DefectDojo engagement mapping (admin only)
+func (h *Handler) UpdateDefectDojoMapping(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid mapping ID")
+		return
+	}
+
+	var m models.DefectDojoMapping
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	m.ID = id
+	h.saveDefectDojoMapping(w, &m)
+}
+
+func (h *Handler) saveDefectDojoMapping(w http.ResponseWriter, m *models.DefectDojoMapping) {
+	if m.EngagementID <= 0 {
+		h.errorResponse(w, http.StatusBadRequest, "engagement_id is required")
+		return
+	}
+	if _, err := h.db.GetRegistry(m.RegistryID); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Registry not found")
+		return
+	}
+	if _, err := regexp.Compile(m.RepoPattern); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Invalid pattern: %v", err))
+		return
+	}
+	if m.Trigger == "" {
+		m.Trigger = "scan"
+	}
+	if m.Trigger != "scan" && m.Trigger != "schedule" {
+		h.errorResponse(w, http.StatusBadRequest, "trigger must be 'scan' or 'schedule'")
+		return
+	}
+	if m.IntervalHours <= 0 {
+		m.IntervalHours = 24
+	}
+
+	if err := h.db.SaveDefectDojoMapping(m); err != nil {
+		if err == sql.ErrNoRows {
+			h.errorResponse(w, http.StatusNotFound, "DefectDojo mapping not found")
+			return
+		}
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to save DefectDojo mapping: %v", err))
+		return
+	}
+	h.successResponse(w, m)
+}
+
+// DeleteDefectDojoMapping removes a DefectDojo engagement mapping (admin only)
+func (h *Handler) DeleteDefectDojoMapping(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid mapping ID")
+		return
+	}
+	if err := h.db.DeleteDefectDojoMapping(id); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.messageResponse(w, "DefectDojo mapping deleted")
+}
+
+// ExportDefectDojoMapping exports the mapping's changed scans now (admin only)
+func (h *Handler) ExportDefectDojoMapping(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	if !tasks.DefectDojoEnabled() {
+		h.errorResponse(w, http.StatusServiceUnavailable, "DefectDojo is not configured")
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid mapping ID")
+		return
+	}
+	m, err := h.db.GetDefectDojoMapping(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "DefectDojo mapping not found")
+		return
+	}
+
+	exported, err := tasks.ExportDefectDojoMapping(h.db, m)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadGateway, h.tr(w, "DefectDojo export failed: %v", err))
+		return
+	}
+	h.successResponse(w, map[string]int{"exported": exported})
+}
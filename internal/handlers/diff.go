@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// ScanDiff describes how the findings of an image changed between two scans
+type ScanDiff struct {
+	From       models.ScanHistory         `json:"from"`
+	To         models.ScanHistory         `json:"to"`
+	Introduced []models.VulnerabilityItem `json:"introduced"`
+	Fixed      []models.VulnerabilityItem `json:"fixed"`
+	Unchanged  []models.VulnerabilityItem `json:"unchanged"`
+}
+
+// ListScanHistory returns the completed scans recorded for an image
+func (h *Handler) ListScanHistory(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	repo := q.Get("repo")
+	tag := q.Get("tag")
+	id, err := strconv.ParseInt(q.Get("registry_id"), 10, 64)
+	if err != nil || repo == "" || tag == "" {
+		h.errorResponse(w, http.StatusBadRequest, "registry_id, repo and tag are required")
+		return
+	}
+	if !h.allowRepository(w, r, id, repo, false) {
+		return
+	}
+
+	history, err := h.db.ListScanHistory(id, repo, tag)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if history == nil {
+		history = []models.ScanHistory{}
+	}
+	h.successResponse(w, history)
+}
+
+// DiffScans compares two scans of the same image.
+// from/to are scan history IDs; when omitted the two most recent scans are used.
+func (h *Handler) DiffScans(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	repo := q.Get("repo")
+	tag := q.Get("tag")
+	id, err := strconv.ParseInt(q.Get("registry_id"), 10, 64)
+	if err != nil || repo == "" || tag == "" {
+		h.errorResponse(w, http.StatusBadRequest, "registry_id, repo and tag are required")
+		return
+	}
+	if !h.allowRepository(w, r, id, repo, false) {
+		return
+	}
+
+	history, err := h.db.ListScanHistory(id, repo, tag)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	fromID, toID := int64(0), int64(0)
+	if v := q.Get("from"); v != "" {
+		if fromID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid from scan ID")
+			return
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if toID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid to scan ID")
+			return
+		}
+	}
+
+	// Default to the two most recent scans (history is newest first)
+	if toID == 0 && len(history) > 0 {
+		toID = history[0].ID
+	}
+	if fromID == 0 {
+		for _, entry := range history {
+			if entry.ID != toID {
+				fromID = entry.ID
+				break
+			}
+		}
+	}
+	if fromID == 0 || toID == 0 {
+		h.errorResponse(w, http.StatusNotFound, "At least two completed scans are required for a diff")
+		return
+	}
+
+	from, err := h.db.GetScanHistory(fromID)
+	if err != nil || from.RegistryID != id || from.Repository != repo || from.Tag != tag {
+		h.errorResponse(w, http.StatusNotFound, "From scan not found for this image")
+		return
+	}
+	to, err := h.db.GetScanHistory(toID)
+	if err != nil || to.RegistryID != id || to.Repository != repo || to.Tag != tag {
+		h.errorResponse(w, http.StatusNotFound, "To scan not found for this image")
+		return
+	}
+	if from.ReportEvicted || to.ReportEvicted {
+		h.errorResponse(w, http.StatusGone, "The full report of this scan was evicted by the storage quota")
+		return
+	}
+
+	h.successResponse(w, diffScanHistory(from, to))
+}
+
+// diffScanHistory buckets findings by scanner, vulnerability ID and package
+func diffScanHistory(from, to *models.ScanHistory) ScanDiff {
+	key := func(v models.VulnerabilityItem) string {
+		return v.Scanner + "|" + v.ID + "|" + v.Package
+	}
+
+	fromItems := extractScanVulnerabilities(historyAsScan(from))
+	before := make(map[string]bool)
+	for _, v := range fromItems {
+		before[key(v)] = true
+	}
+
+	diff := ScanDiff{
+		Introduced: []models.VulnerabilityItem{},
+		Fixed:      []models.VulnerabilityItem{},
+		Unchanged:  []models.VulnerabilityItem{},
+	}
+
+	after := make(map[string]bool)
+	for _, v := range extractScanVulnerabilities(historyAsScan(to)) {
+		k := key(v)
+		if after[k] {
+			continue
+		}
+		after[k] = true
+		if before[k] {
+			diff.Unchanged = append(diff.Unchanged, v)
+		} else {
+			diff.Introduced = append(diff.Introduced, v)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, v := range fromItems {
+		k := key(v)
+		if !after[k] && !seen[k] {
+			seen[k] = true
+			diff.Fixed = append(diff.Fixed, v)
+		}
+	}
+
+	// Reports are large; the caller only needs the scan metadata
+	diff.From, diff.To = *from, *to
+	diff.From.Report, diff.To.Report = "", ""
+	return diff
+}
+
+func historyAsScan(entry *models.ScanHistory) models.VulnerabilityScan {
+	return models.VulnerabilityScan{
+		ID:         entry.ID,
+		RegistryID: entry.RegistryID,
+		Repository: entry.Repository,
+		Tag:        entry.Tag,
+		Digest:     entry.Digest,
+		Status:     "completed",
+		Summary:    entry.Summary,
+		Report:     entry.Report,
+		ScannedAt:  entry.ScannedAt,
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"docker-registry-dashboard/internal/registry"
+)
+
+// --- Docker Hub ---
+
+// registryErrorStatus is the status of a failed registry call: 429 while Docker Hub pulls are
+// held back, 502 otherwise
+func registryErrorStatus(err error) int {
+	if errors.Is(err, registry.ErrPullRateLimited) {
+		return http.StatusTooManyRequests
+	}
+	return http.StatusBadGateway
+}
+
+// GetPullRateLimit asks Docker Hub for the pull rate limit of a registry's credentials; the check
+// does not count as a pull
+func (h *Handler) GetPullRateLimit(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+	if !registry.IsDockerHub(reg.URL) {
+		h.errorResponse(w, http.StatusNotFound, "Not a Docker Hub registry")
+		return
+	}
+	limit, err := registry.NewClientFromRegistry(reg).RefreshPullRateLimit(r.Context())
+	if err != nil {
+		h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Failed to read the Docker Hub rate limit: %v", err))
+		return
+	}
+	h.successResponse(w, limit)
+}
@@ -0,0 +1,324 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/registry"
+	"docker-registry-dashboard/internal/s3"
+)
+
+// ExportRequest selects the tags of an OCI layout export and where the archive goes
+type ExportRequest struct {
+	Repositories []string `json:"repositories"` // "repo" for all its tags, "repo:tag" for one
+	TagPattern   string   `json:"tag_pattern"`  // Regex narrowing the tags of whole repositories
+	Destination  string   `json:"destination"`  // "download" (default) or "s3"
+	Key          string   `json:"key"`          // S3 object key, default exports/<registry>/<time>.tar
+}
+
+// unsafeFilename matches characters kept out of archive file names
+var unsafeFilename = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// exportTracker keeps the progress of OCI layout exports started by this process
+type exportTracker struct {
+	mu   sync.Mutex
+	jobs map[string]*models.ExportJob
+}
+
+func (t *exportTracker) add(job *models.ExportJob) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.jobs == nil {
+		t.jobs = make(map[string]*models.ExportJob)
+	}
+	for id, old := range t.jobs {
+		if finishedLongAgo(old.FinishedAt) {
+			delete(t.jobs, id)
+		}
+	}
+	t.jobs[job.ID] = job
+}
+
+func (t *exportTracker) update(id string, apply func(job *models.ExportJob)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if job, ok := t.jobs[id]; ok {
+		apply(job)
+	}
+}
+
+func (t *exportTracker) get(id string) (models.ExportJob, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	job, ok := t.jobs[id]
+	if !ok {
+		return models.ExportJob{}, false
+	}
+	return *job, true
+}
+
+func (t *exportTracker) list(registryID int64) []models.ExportJob {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	jobs := []models.ExportJob{}
+	for _, job := range t.jobs {
+		if job.RegistryID == registryID {
+			jobs = append(jobs, *job)
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].StartedAt.After(jobs[j].StartedAt) })
+	return jobs
+}
+
+// SetExportS3 sets the bucket OCI layout exports can be written to; nil disables S3 exports
+func (h *Handler) SetExportS3(c *s3.Client) {
+	h.exportS3 = c
+}
+
+// ExportOCILayout writes the selected tags with all their platforms into one OCI image layout
+// archive (a tar with index.json). The archive is streamed back as the response, or with
+// "destination": "s3" uploaded to the export bucket by a job; poll
+// GET /api/v1/registries/{id}/export/oci/{job} for its progress.
+func (h *Handler) ExportOCILayout(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	var req ExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(req.Repositories) == 0 {
+		h.errorResponse(w, http.StatusBadRequest, "At least one repository is required")
+		return
+	}
+	for _, entry := range req.Repositories {
+		repo := entry
+		if i := strings.LastIndex(entry, ":"); i > strings.LastIndex(entry, "/") {
+			repo = entry[:i]
+		}
+		if !h.allowRepository(w, r, id, repo, false) {
+			return
+		}
+	}
+	var tagRe *regexp.Regexp
+	if req.TagPattern != "" {
+		if tagRe, err = regexp.Compile(req.TagPattern); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Invalid tag pattern: %v", err))
+			return
+		}
+	}
+	switch req.Destination {
+	case "", "download":
+		req.Destination = "download"
+	case "s3":
+		if h.exportS3 == nil {
+			h.errorResponse(w, http.StatusBadRequest, "S3 export is not configured (start the dashboard with -export-s3-bucket)")
+			return
+		}
+	default:
+		h.errorResponse(w, http.StatusBadRequest, "Destination must be download or s3")
+		return
+	}
+
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+	client := registry.NewClientFromRegistry(reg)
+
+	images, err := selectLayoutImages(r.Context(), client, req.Repositories, tagRe)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Failed to list tags: %v", err))
+		return
+	}
+	if len(images) == 0 {
+		h.errorResponse(w, http.StatusNotFound, "No tags match the selection")
+		return
+	}
+
+	now := time.Now()
+	name := fmt.Sprintf("%s-%s.tar", unsafeFilename.ReplaceAllString(reg.Name, "_"), now.UTC().Format("20060102-150405"))
+	job := &models.ExportJob{
+		ID:           newJobID(),
+		RegistryID:   reg.ID,
+		Repositories: req.Repositories,
+		Destination:  req.Destination,
+		Status:       "running",
+		Images:       len(images),
+		StartedAt:    now,
+	}
+	h.audit(r, "registry.export", fmt.Sprintf("registry:%d", reg.ID), fmt.Sprintf("%d tags of %s to %s", len(images), strings.Join(req.Repositories, ", "), req.Destination))
+
+	if req.Destination == "s3" {
+		key := req.Key
+		if key == "" {
+			key = fmt.Sprintf("exports/%d/%s", reg.ID, name)
+		}
+		job.Location = h.exportS3.Location(key)
+		h.exportJobs.add(job)
+		snapshot, _ := h.exportJobs.get(job.ID)
+		go h.runS3Export(job.ID, client, images, key, h.exportProgress(job.ID))
+		h.jsonResponse(w, http.StatusAccepted, models.APIResponse{Success: true, Data: snapshot})
+		return
+	}
+	h.streamExport(w, r, client, images, job, name)
+}
+
+// ExportImage streams one tag (?repo=, ?tag=) with all its platforms back as an OCI image layout
+// archive, which docker load (Docker 25 and later), skopeo and ctr import read
+func (h *Handler) ExportImage(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	repoName, tag := r.URL.Query().Get("repo"), r.URL.Query().Get("tag")
+	if repoName == "" || tag == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Repository name and tag are required")
+		return
+	}
+	if !h.allowRepository(w, r, id, repoName, false) {
+		return
+	}
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+	client := registry.NewClientFromRegistry(reg)
+
+	// A missing tag is reported before the archive starts
+	if _, err := client.GetDigestForTag(r.Context(), repoName, tag); err != nil {
+		h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Failed to get digest: %v", err))
+		return
+	}
+	entry := repoName + ":" + tag
+	job := &models.ExportJob{
+		ID:           newJobID(),
+		RegistryID:   reg.ID,
+		Repositories: []string{entry},
+		Destination:  "download",
+		Status:       "running",
+		Images:       1,
+		StartedAt:    time.Now(),
+	}
+	h.audit(r, "registry.export", fmt.Sprintf("registry:%d", reg.ID), entry+" to download")
+	name := unsafeFilename.ReplaceAllString(repoName, "_") + "_" + unsafeFilename.ReplaceAllString(tag, "_") + ".tar"
+	h.streamExport(w, r, client, []registry.LayoutImage{{Repository: repoName, Tag: tag}}, job, name)
+}
+
+// streamExport writes the archive of an export job as the response, a tar download named name
+func (h *Handler) streamExport(w http.ResponseWriter, r *http.Request, client *registry.Client, images []registry.LayoutImage, job *models.ExportJob, name string) {
+	h.exportJobs.add(job)
+	w.Header().Set("Content-Type", "application/x-tar")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, name))
+	w.Header().Set("X-Export-Job", job.ID)
+	_, err := registry.WriteOCILayout(r.Context(), client, images, w, h.exportProgress(job.ID))
+	h.finishExport(job.ID, err)
+	if err != nil {
+		// The status line is already sent: break the connection so the client sees an incomplete archive
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// exportProgress copies the progress of an archive being written into its export job
+func (h *Handler) exportProgress(id string) func(registry.LayoutStats) {
+	return func(stats registry.LayoutStats) {
+		h.exportJobs.update(id, func(job *models.ExportJob) {
+			job.ExportedImages, job.Manifests, job.Blobs, job.Bytes = stats.Images, stats.Manifests, stats.Blobs, stats.Bytes
+		})
+	}
+}
+
+// runS3Export streams the archive into a multipart upload; a failure on either side aborts both
+func (h *Handler) runS3Export(id string, client *registry.Client, images []registry.LayoutImage, key string, progress func(registry.LayoutStats)) {
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := registry.WriteOCILayout(context.Background(), client, images, pw, progress)
+		pw.CloseWithError(err)
+	}()
+	_, err := h.exportS3.Upload(context.Background(), key, pr)
+	pr.CloseWithError(err)
+	h.finishExport(id, err)
+}
+
+func (h *Handler) finishExport(id string, err error) {
+	h.exportJobs.update(id, func(job *models.ExportJob) {
+		job.FinishedAt = time.Now()
+		if err != nil {
+			job.Status, job.Error = "failed", err.Error()
+			log.Printf("❌ OCI layout export %s of registry %d failed: %v", job.ID, job.RegistryID, err)
+			return
+		}
+		job.Status = "completed"
+		log.Printf("📦 Exported %d images of registry %d to an OCI layout (%s)", job.ExportedImages, job.RegistryID, job.Destination)
+	})
+}
+
+// selectLayoutImages expands "repo" entries to their tags (filtered by tagRe) and keeps "repo:tag"
+// entries as they are, without duplicates
+func selectLayoutImages(ctx context.Context, client *registry.Client, entries []string, tagRe *regexp.Regexp) ([]registry.LayoutImage, error) {
+	images := []registry.LayoutImage{}
+	seen := make(map[registry.LayoutImage]bool)
+	add := func(img registry.LayoutImage) {
+		if !seen[img] {
+			seen[img] = true
+			images = append(images, img)
+		}
+	}
+	for _, entry := range entries {
+		if i := strings.LastIndex(entry, ":"); i > strings.LastIndex(entry, "/") {
+			add(registry.LayoutImage{Repository: entry[:i], Tag: entry[i+1:]})
+			continue
+		}
+		tags, err := client.ListTags(ctx, entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry, err)
+		}
+		for _, t := range tags {
+			if tagRe == nil || tagRe.MatchString(t.Name) {
+				add(registry.LayoutImage{Repository: entry, Tag: t.Name})
+			}
+		}
+	}
+	return images, nil
+}
+
+// GetExportJob returns the progress of an OCI layout export
+func (h *Handler) GetExportJob(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	job, ok := h.exportJobs.get(r.PathValue("job"))
+	if !ok || job.RegistryID != id {
+		h.errorResponse(w, http.StatusNotFound, "Export job not found")
+		return
+	}
+	h.successResponse(w, job)
+}
+
+// ListExportJobs returns the OCI layout exports of a registry started since the dashboard started, newest first
+func (h *Handler) ListExportJobs(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	h.successResponse(w, h.exportJobs.list(id))
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// defaultFeedLimit is how many pushes a feed lists when it does not say
+const defaultFeedLimit = 50
+
+// atomFeed is the subset of RFC 4287 the push feed uses
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    []atomLink  `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Author  *atomAuthor `xml:"author,omitempty"`
+	Summary string      `xml:"summary"`
+}
+
+// RepositoryFeed serves an Atom feed of the pushes to a repository (?repo=&tag=&limit=), taken from
+// webhook events and from the new tags found by catalog syncs. Feed readers that cannot send an
+// Authorization header may pass an API token as ?token=.
+func (h *Handler) RepositoryFeed(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	q := r.URL.Query()
+	repo, tag := q.Get("repo"), q.Get("tag")
+	if repo == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Repository name is required (query param: repo)")
+		return
+	}
+	limit := defaultFeedLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = min(n, maxPageSize)
+	}
+
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+	events, err := h.db.ListPushEvents(id, repo, tag, limit)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	base := requestBaseURL(r)
+	params := url.Values{"repo": {repo}}
+	title := fmt.Sprintf("%s: pushes to %s", reg.Name, repo)
+	if tag != "" {
+		params.Set("tag", tag)
+		title = fmt.Sprintf("%s: pushes to %s:%s", reg.Name, repo, tag)
+	}
+	self := fmt.Sprintf("%s/api/v1/registries/%d/feed?%s", base, id, params.Encode())
+
+	feed := atomFeed{
+		ID:      self,
+		Title:   title,
+		Updated: time.Now().UTC().Format(time.RFC3339),
+		Link:    []atomLink{{Rel: "self", Href: self}, {Href: base + "/#images"}},
+		Author:  atomAuthor{Name: reg.Name},
+		Entries: []atomEntry{},
+	}
+	if len(events) > 0 {
+		feed.Updated = events[0].Timestamp.UTC().Format(time.RFC3339)
+	}
+	for _, e := range events {
+		feed.Entries = append(feed.Entries, pushEntry(reg, e, base))
+	}
+
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(feed); err != nil {
+		fmt.Printf("⚠️ Failed to write feed of %s: %v\n", repo, err)
+	}
+}
+
+// pushEntry describes a push event as a feed entry
+func pushEntry(reg *models.Registry, e models.RegistryEvent, base string) atomEntry {
+	ref := e.Repository
+	if e.Tag != "" {
+		ref += ":" + e.Tag
+	}
+	var summary []string
+	if e.Digest != "" {
+		summary = append(summary, "Digest: "+e.Digest)
+	}
+	if e.Source == "sync" {
+		summary = append(summary, "Detected by catalog sync")
+	} else {
+		summary = append(summary, "Reported by "+e.Source+" webhook")
+	}
+
+	entry := atomEntry{
+		ID:      fmt.Sprintf("urn:docker-registry-dashboard:registry:%d:event:%d", reg.ID, e.ID),
+		Title:   fmt.Sprintf("%s pushed to %s", ref, reg.Name),
+		Updated: e.Timestamp.UTC().Format(time.RFC3339),
+		Link:    atomLink{Href: base + "/#images"},
+		Summary: strings.Join(summary, " · "),
+	}
+	if e.Actor != "" {
+		entry.Author = &atomAuthor{Name: e.Actor}
+	}
+	return entry
+}
+
+// requestBaseURL returns the scheme and host the client used to reach the dashboard
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if v := r.Header.Get("X-Forwarded-Proto"); v != "" {
+		scheme = v
+	}
+	return scheme + "://" + r.Host
+}
@@ -1,30 +1,76 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
+	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"docker-registry-dashboard/internal/auth"
+	"docker-registry-dashboard/internal/cache"
 	"docker-registry-dashboard/internal/database"
+	"docker-registry-dashboard/internal/ecr"
+	"docker-registry-dashboard/internal/gcp"
+	"docker-registry-dashboard/internal/i18n"
 	"docker-registry-dashboard/internal/models"
 	"docker-registry-dashboard/internal/registry"
+	"docker-registry-dashboard/internal/s3"
 )
 
 // Handler holds dependencies for HTTP handlers
 type Handler struct {
 	db          *database.DB
 	embeddedReg *registry.EmbeddedRegistry
+	fleet       *registry.Fleet // The additional embedded registries; nil when there are none
+	cache       cache.Cache
+
+	webhookSecret    string
+	authRequired     bool
+	authenticators   []auth.Authenticator
+	copyJobs         copyTracker
+	renameJobs       renameTracker
+	exportJobs       exportTracker
+	exportS3         *s3.Client
+	attestationTrust *registry.AttestationTrust
+	signingSecret    []byte
+	importMaxBytes   int64
+	rateLimiter      *rateLimiter
+	cors             *CORSConfig
+	compressMinBytes int
+	catalogTTL       time.Duration
+	configReloader   func() (*models.ConfigReload, error)
+	healthChecks     []healthCheck
+	startedAt        time.Time
+
+	// statsMu serializes dashboard stats refreshes; statsRefreshing is set while one runs
+	statsMu         sync.Mutex
+	statsRefreshing atomic.Bool
 }
 
 // New creates a new Handler
-func New(db *database.DB, embeddedReg *registry.EmbeddedRegistry) *Handler {
-	return &Handler{db: db, embeddedReg: embeddedReg}
+func New(db *database.DB, embeddedReg *registry.EmbeddedRegistry, c cache.Cache) *Handler {
+	if c == nil {
+		c = cache.NewMemoryCache()
+	}
+	h := &Handler{db: db, embeddedReg: embeddedReg, cache: c, catalogTTL: defaultCatalogCacheTTL, compressMinBytes: defaultCompressMinBytes, importMaxBytes: defaultImportMaxBytes, startedAt: time.Now()}
+	h.RegisterHealthCheck("database", true, func() (string, error) { return "", db.Ping() })
+	h.RegisterAuthenticator(&auth.TokenAuthenticator{Store: db, AllowQuery: func(r *http.Request) bool { return isFeedPath(r.URL.Path) }})
+	h.RegisterAuthenticator(&auth.SessionAuthenticator{Store: db, Cookie: sessionCookie})
+	return h
 }
 
 // --- Helper methods ---
@@ -45,17 +91,22 @@ func (h *Handler) successResponse(w http.ResponseWriter, data interface{}) {
 func (h *Handler) messageResponse(w http.ResponseWriter, message string) {
 	h.jsonResponse(w, http.StatusOK, models.APIResponse{
 		Success: true,
-		Message: message,
+		Message: i18n.Translate(i18n.Language(w), message),
 	})
 }
 
 func (h *Handler) errorResponse(w http.ResponseWriter, status int, err string) {
 	h.jsonResponse(w, status, models.APIResponse{
 		Success: false,
-		Error:   err,
+		Error:   i18n.Translate(i18n.Language(w), err),
 	})
 }
 
+// tr formats a message in the language negotiated for the response
+func (h *Handler) tr(w http.ResponseWriter, format string, a ...interface{}) string {
+	return i18n.Sprintf(i18n.Language(w), format, a...)
+}
+
 func (h *Handler) getRegistryID(r *http.Request) (int64, error) {
 	idStr := r.PathValue("id")
 	return strconv.ParseInt(idStr, 10, 64)
@@ -63,15 +114,111 @@ func (h *Handler) getRegistryID(r *http.Request) (int64, error) {
 
 // --- Dashboard ---
 
-// GetDashboardStats returns overview statistics
+// GetDashboardStats returns the latest overview statistics snapshot. The snapshot is refreshed in
+// the background; it is only computed inline when none exists yet.
 func (h *Handler) GetDashboardStats(w http.ResponseWriter, r *http.Request) {
-	registries, err := h.db.ListRegistries()
+	stats, err := h.db.GetDashboardStats()
+	if err != nil {
+		if stats, err = h.refreshDashboardStats(); err != nil {
+			h.errorResponse(w, http.StatusInternalServerError, "Failed to load registries")
+			return
+		}
+	}
+	access, err := h.projectAccess(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to load registries")
+		return
+	}
+	if access != nil {
+		stats = h.scopeDashboardStats(r.Context(), stats, access)
+	}
+
+	// The embedded registry is started and stopped from the dashboard itself, so its status is always live
+	if h.embeddedReg != nil {
+		stats.EmbeddedRegistry = h.embeddedReg.Status()
+	}
+	stats.Refreshing = h.statsRefreshing.Load()
+
+	h.successResponse(w, stats)
+}
+
+// RefreshDashboardStats recomputes the overview statistics now and returns them
+func (h *Handler) RefreshDashboardStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.refreshDashboardStats()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to load registries")
+		return
+	}
+	access, err := h.projectAccess(r)
 	if err != nil {
 		h.errorResponse(w, http.StatusInternalServerError, "Failed to load registries")
 		return
 	}
+	if access != nil {
+		stats = h.scopeDashboardStats(r.Context(), stats, access)
+	}
+	if h.embeddedReg != nil {
+		stats.EmbeddedRegistry = h.embeddedReg.Status()
+	}
+	h.successResponse(w, stats)
+}
+
+// RefreshStatsEvery recomputes the dashboard statistics on an interval until quit is closed
+func (h *Handler) RefreshStatsEvery(interval time.Duration, quit <-chan struct{}) {
+	if _, err := h.refreshDashboardStats(); err != nil {
+		log.Printf("⚠️  Failed to refresh dashboard stats: %v", err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := h.refreshDashboardStats(); err != nil {
+				log.Printf("⚠️  Failed to refresh dashboard stats: %v", err)
+			}
+		case <-quit:
+			return
+		}
+	}
+}
 
-	stats := models.DashboardStats{
+// statsRefreshTimeout bounds one computation of the dashboard statistics, which walks every registry
+const statsRefreshTimeout = 10 * time.Minute
+
+// refreshDashboardStats computes and stores a new stats snapshot; concurrent callers wait for
+// the running refresh and get its result instead of starting another one
+func (h *Handler) refreshDashboardStats() (*models.DashboardStats, error) {
+	started := time.Now()
+	h.statsMu.Lock()
+	defer h.statsMu.Unlock()
+	if stats, err := h.db.GetDashboardStats(); err == nil && !stats.RefreshedAt.Before(started) {
+		return stats, nil
+	}
+
+	h.statsRefreshing.Store(true)
+	defer h.statsRefreshing.Store(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), statsRefreshTimeout)
+	defer cancel()
+	stats, err := h.computeDashboardStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.db.SaveDashboardStats(stats); err != nil {
+		log.Printf("⚠️  Failed to save dashboard stats: %v", err)
+	}
+	return stats, nil
+}
+
+// computeDashboardStats pings every registry and counts its repositories and tags
+func (h *Handler) computeDashboardStats(ctx context.Context) (*models.DashboardStats, error) {
+	registries, err := h.db.ListRegistries()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.DashboardStats{
 		TotalRegistries: len(registries),
 	}
 
@@ -80,43 +227,144 @@ func (h *Handler) GetDashboardStats(w http.ResponseWriter, r *http.Request) {
 		stats.StorageType = storageConfig.Type
 	}
 
-	// Get embedded registry status
-	if h.embeddedReg != nil {
-		stats.EmbeddedRegistry = h.embeddedReg.Status()
+	// Images seen in this pass, used to compute scan coverage
+	var images []string
+
+	certChecks, err := h.db.ListCertificateChecks()
+	if err != nil {
+		log.Printf("⚠️  Failed to load certificate checks: %v", err)
 	}
 
 	for _, reg := range registries {
 		regStat := models.RegistryStats{
-			ID:   reg.ID,
-			Name: reg.Name,
-			URL:  reg.URL,
+			ID:          reg.ID,
+			Name:        reg.Name,
+			URL:         reg.URL,
+			Certificate: certChecks[reg.ID].Status,
 		}
 
 		client := registry.NewClientFromRegistry(&reg)
-		if err := client.Ping(); err != nil {
+		err := client.Ping(ctx)
+		regStat.Circuit = registry.CircuitStatusFor(reg.URL).State
+		if err != nil {
 			regStat.Status = "offline"
-			log.Printf("Registry %s is offline: %v", reg.Name, err)
+			if !errors.Is(err, registry.ErrCircuitOpen) {
+				log.Printf("Registry %s is offline: %v", reg.Name, err)
+			}
 		} else {
 			regStat.Status = "online"
-			repos, err := client.ListRepositories()
-			if err == nil {
-				regStat.ImageCount = len(repos)
-				stats.TotalImages += len(repos)
-
-				// Count tags for each repo
-				for _, repo := range repos {
-					tags, err := client.ListTags(repo.Name)
-					if err == nil {
-						stats.TotalTags += len(tags)
-					}
+			if registry.IsDockerHub(reg.URL) {
+				// Read with a HEAD request, which Docker Hub does not count as a pull
+				if limit, err := client.RefreshPullRateLimit(ctx); err == nil {
+					regStat.PullRateLimit = limit
+				} else {
+					regStat.PullRateLimit = client.PullRateLimit()
 				}
 			}
+			if repoCount, tagged, err := h.registryImages(ctx, &reg, client, nil); err == nil {
+				regStat.ImageCount = repoCount
+				stats.TotalImages += repoCount
+				stats.TotalTags += len(tagged)
+				images = append(images, tagged...)
+			}
 		}
 
 		stats.Registries = append(stats.Registries, regStat)
 	}
 
-	h.successResponse(w, stats)
+	stats.Security = h.securityPosture(images)
+	stats.RefreshedAt = time.Now()
+	return stats, nil
+}
+
+// registryImages counts the repositories of a registry that keep accepts (all of them when keep
+// is nil) and returns the ImageKey of each of their tags
+func (h *Handler) registryImages(ctx context.Context, reg *models.Registry, client *registry.Client, keep func(repo string) bool) (int, []string, error) {
+	repos, err := h.listRepositoriesCached(ctx, reg, client, false)
+	if err != nil {
+		return 0, nil, err
+	}
+	count := 0
+	var images []string
+	for _, repo := range repos {
+		if keep != nil && !keep(repo.Name) {
+			continue
+		}
+		count++
+		tags, err := h.listTagsCached(ctx, reg, client, repo.Name, false)
+		if err != nil {
+			continue
+		}
+		for _, tag := range tags {
+			images = append(images, database.ImageKey(reg.ID, repo.Name, tag.Name))
+		}
+	}
+	return count, images, nil
+}
+
+// scopeDashboardStats narrows a statistics snapshot to the registries and repositories of a user's
+// projects. Their repositories and tags are counted again from the listings, which are usually cached.
+func (h *Handler) scopeDashboardStats(ctx context.Context, stats *models.DashboardStats, access *projectAccess) *models.DashboardStats {
+	scoped := &models.DashboardStats{StorageType: stats.StorageType, RefreshedAt: stats.RefreshedAt}
+	var images []string
+	for _, regStat := range stats.Registries {
+		if !access.seesRegistry(regStat.ID) {
+			continue
+		}
+		regStat.ImageCount = 0
+		if reg, err := h.db.GetRegistry(regStat.ID); err == nil && regStat.Status == "online" {
+			keep := func(repo string) bool { return access.repositoryRole(reg.ID, repo) != "" }
+			if repoCount, tagged, err := h.registryImages(ctx, reg, registry.NewClientFromRegistry(reg), keep); err == nil {
+				regStat.ImageCount = repoCount
+				scoped.TotalTags += len(tagged)
+				images = append(images, tagged...)
+			}
+		}
+		scoped.TotalImages += regStat.ImageCount
+		scoped.Registries = append(scoped.Registries, regStat)
+	}
+	scoped.TotalRegistries = len(scoped.Registries)
+	scoped.Security = h.securityPosture(images)
+	return scoped
+}
+
+// securityPosture combines the findings and scan coverage of the given images, the tags the
+// registries list now
+func (h *Handler) securityPosture(images []string) models.SecurityPosture {
+	var posture models.SecurityPosture
+
+	if counts, err := h.db.CountFindingsByImage(); err == nil {
+		for _, key := range images {
+			posture.TotalCritical += counts[key]["CRITICAL"]
+			posture.TotalHigh += counts[key]["HIGH"]
+		}
+	} else {
+		log.Printf("⚠️  Failed to count findings: %v", err)
+	}
+
+	scanTimes, err := h.db.ListCompletedScanTimes()
+	if err != nil {
+		log.Printf("⚠️  Failed to load scan times: %v", err)
+		return posture
+	}
+
+	weekAgo := time.Now().AddDate(0, 0, -7)
+	recent := 0
+	for _, key := range images {
+		scannedAt, ok := scanTimes[key]
+		if !ok {
+			posture.UnscannedImages++
+			continue
+		}
+		posture.ScannedImages++
+		if scannedAt.After(weekAgo) {
+			recent++
+		}
+	}
+	if len(images) > 0 {
+		posture.ScannedLast7DaysPct = math.Round(float64(recent)*1000/float64(len(images))) / 10
+	}
+	return posture
 }
 
 // --- Registry CRUD ---
@@ -131,6 +379,14 @@ func (h *Handler) ListRegistries(w http.ResponseWriter, r *http.Request) {
 	if registries == nil {
 		registries = []models.Registry{}
 	}
+	access, err := h.projectAccess(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to load registries")
+		return
+	}
+	if access != nil {
+		registries = slices.DeleteFunc(registries, func(reg models.Registry) bool { return !access.seesRegistry(reg.ID) })
+	}
 	h.successResponse(w, registries)
 }
 
@@ -149,19 +405,87 @@ func (h *Handler) CreateRegistry(w http.ResponseWriter, r *http.Request) {
 
 	// Normalize URL - remove trailing slash
 	reg.URL = strings.TrimRight(reg.URL, "/")
+	if !h.checkRegistryType(w, &reg) {
+		return
+	}
 
 	if err := h.db.CreateRegistry(&reg); err != nil {
 		h.errorResponse(w, http.StatusInternalServerError, "Failed to create registry")
 		return
 	}
 
+	go h.refreshDashboardStats()
 	h.jsonResponse(w, http.StatusCreated, models.APIResponse{
 		Success: true,
 		Data:    reg,
-		Message: "Registry created successfully",
+		Message: h.tr(w, "Registry created successfully"),
 	})
 }
 
+// checkRegistryType validates the type, CA bundle and proxy of a registry, filling in the region
+// of an ECR registry from its URL when it is not given
+func (h *Handler) checkRegistryType(w http.ResponseWriter, reg *models.Registry) bool {
+	if reg.Proxy = strings.TrimSpace(reg.Proxy); reg.Proxy != "" {
+		if _, err := registry.ParseProxy(reg.Proxy); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, err.Error())
+			return false
+		}
+	}
+	if reg.CACert = strings.TrimSpace(reg.CACert); reg.CACert != "" {
+		if _, err := registry.CertPool(reg.CACert); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, err.Error())
+			return false
+		}
+		reg.CACert += "\n"
+	}
+	if reg.Type != models.RegistryTypeECR {
+		reg.Region = ""
+	}
+	if reg.Type != models.RegistryTypeACR {
+		reg.Tenant = ""
+	}
+	if reg.Type != models.RegistryTypeQuay && reg.Type != models.RegistryTypeGHCR {
+		reg.Namespaces = ""
+	}
+	switch reg.Type {
+	case "":
+	case models.RegistryTypeECR:
+		if reg.Region == "" {
+			reg.Region = ecr.RegionFromURL(reg.URL)
+		}
+		if reg.Region == "" {
+			h.errorResponse(w, http.StatusBadRequest, "An ECR registry needs a region")
+			return false
+		}
+	case models.RegistryTypeGCP:
+		if reg.Password != "" {
+			if _, err := gcp.ParseKey(reg.Password); err != nil {
+				h.errorResponse(w, http.StatusBadRequest, err.Error())
+				return false
+			}
+		}
+	case models.RegistryTypeACR:
+		if reg.Password != "" && (reg.Username == "" || reg.Tenant == "") {
+			h.errorResponse(w, http.StatusBadRequest, "An ACR service principal needs a client ID and a tenant")
+			return false
+		}
+	case models.RegistryTypeQuay:
+		if reg.Password == "" && strings.TrimSpace(reg.Namespaces) == "" {
+			h.errorResponse(w, http.StatusBadRequest, "A Quay registry needs an OAuth token or the namespaces to list")
+			return false
+		}
+	case models.RegistryTypeGHCR:
+		if reg.Password == "" {
+			h.errorResponse(w, http.StatusBadRequest, "A GHCR registry needs a personal access token as its password")
+			return false
+		}
+	default:
+		h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Unknown registry type: %s", reg.Type))
+		return false
+	}
+	return true
+}
+
 // UpdateRegistry updates an existing registry
 func (h *Handler) UpdateRegistry(w http.ResponseWriter, r *http.Request) {
 	id, err := h.getRegistryID(r)
@@ -178,12 +502,23 @@ func (h *Handler) UpdateRegistry(w http.ResponseWriter, r *http.Request) {
 
 	reg.ID = id
 	reg.URL = strings.TrimRight(reg.URL, "/")
+	if !h.checkRegistryType(w, &reg) {
+		return
+	}
+	old, _ := h.db.GetRegistry(id)
 
 	if err := h.db.UpdateRegistry(&reg); err != nil {
 		h.errorResponse(w, http.StatusInternalServerError, "Failed to update registry")
 		return
 	}
+	h.invalidateListings(id)
+	// A profile describes the registry it was probed at, not the one the URL now points to
+	if old != nil && old.URL != reg.URL {
+		registry.SetProfile(old.URL, nil)
+		h.db.DeleteRegistryProfile(id)
+	}
 
+	go h.refreshDashboardStats()
 	h.messageResponse(w, "Registry updated successfully")
 }
 
@@ -200,6 +535,7 @@ func (h *Handler) DeleteRegistry(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	go h.refreshDashboardStats()
 	h.messageResponse(w, "Registry deleted successfully")
 }
 
@@ -217,10 +553,12 @@ func (h *Handler) TestRegistryConnection(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// An explicit test always reaches the registry, even while its circuit is open
+	registry.ResetCircuit(reg.URL)
 	client := registry.NewClientFromRegistry(reg)
 	start := time.Now()
-	if err := client.Ping(); err != nil {
-		h.errorResponse(w, http.StatusBadGateway, fmt.Sprintf("Connection failed: %v", err))
+	if err := client.Ping(r.Context()); err != nil {
+		h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Connection failed: %v", err))
 		return
 	}
 	duration := time.Since(start)
@@ -234,13 +572,19 @@ func (h *Handler) TestRegistryConnection(w http.ResponseWriter, r *http.Request)
 
 // --- Repository/Image browsing ---
 
-// ListRepositories returns all repositories from a registry
+// ListRepositories returns the repositories of a registry (cached; ?refresh=true bypasses the cache).
+// ?q= filters by name, ?sort=name|tag_count|size (prefix "-" for descending) orders them and
+// ?page=&page_size= select a page; without page_size every repository is returned.
 func (h *Handler) ListRepositories(w http.ResponseWriter, r *http.Request) {
 	id, err := h.getRegistryID(r)
 	if err != nil {
 		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
 		return
 	}
+	page, ok := h.parsePageRequest(w, r, "name", "tag_count", "size")
+	if !ok {
+		return
+	}
 
 	reg, err := h.db.GetRegistry(id)
 	if err != nil {
@@ -248,25 +592,91 @@ func (h *Handler) ListRepositories(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	refresh := r.URL.Query().Get("refresh") == "true"
 	client := registry.NewClientFromRegistry(reg)
-	repos, err := client.ListRepositories()
+	all, err := h.listRepositoriesCached(r.Context(), reg, client, refresh)
 	if err != nil {
-		h.errorResponse(w, http.StatusBadGateway, fmt.Sprintf("Failed to list repositories: %v", err))
+		h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Failed to list repositories: %v", err))
 		return
 	}
 
-	// Fetch tag counts for each repo
+	access, err := h.projectAccess(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	repos := []models.Repository{}
+	for _, repo := range all {
+		if access != nil && access.repositoryRole(id, repo.Name) == "" {
+			continue
+		}
+		if page.matches(repo.Name) {
+			repos = append(repos, repo)
+		}
+	}
+	if size, err := h.db.GetRegistrySize(id); err == nil {
+		sizes := make(map[string]int64, len(size.Repositories))
+		for _, rs := range size.Repositories {
+			sizes[rs.Name] = rs.Size
+		}
+		for i := range repos {
+			repos[i].Size = sizes[repos[i].Name]
+		}
+	}
+
+	// Tag counts cost a request per repository, so they are only fetched for the page
+	// unless the order depends on them
+	countTags := func(list []models.Repository) {
+		for i := range list {
+			tags, err := h.listTagsCached(r.Context(), reg, client, list[i].Name, refresh)
+			if err == nil {
+				list[i].TagCount = len(tags)
+			}
+		}
+	}
+	if page.Sort == "tag_count" {
+		countTags(repos)
+	}
+	sort.SliceStable(repos, func(i, j int) bool {
+		a, b := repos[i], repos[j]
+		switch page.Sort {
+		case "tag_count":
+			return page.less(int64(a.TagCount), int64(b.TagCount), a.Name, b.Name)
+		case "size":
+			return page.less(a.Size, b.Size, a.Name, b.Name)
+		}
+		return page.less(0, 0, a.Name, b.Name)
+	})
+
+	start, end, meta := page.bounds(len(repos))
+	repos = repos[start:end]
+	if page.Sort != "tag_count" {
+		countTags(repos)
+	}
+
+	infos, _ := h.db.ListRepositoryInfo(id)
+	for i := range repos {
+		if info, ok := infos[repos[i].Name]; ok {
+			repos[i].Owner = info.Owner
+			repos[i].Labels = info.Labels
+		}
+	}
+	metadata, _ := h.db.ListRepoMetadata(id)
 	for i := range repos {
-		tags, err := client.ListTags(repos[i].Name)
-		if err == nil {
-			repos[i].TagCount = len(tags)
+		if meta, ok := metadata[repos[i].Name]; ok {
+			repos[i].Description = meta.Description
+			repos[i].Links = meta.Links
+			repos[i].HasReadme = meta.Readme != ""
 		}
 	}
 
-	h.successResponse(w, repos)
+	h.pageResponse(w, repos, meta)
 }
 
-// ListTags returns all tags for a repository
+// ListTags returns the tags of a repository (cached; ?refresh=true bypasses the cache).
+// ?q= filters by name, ?sort=name|pull_count|push_count (prefix "-" for descending) orders them
+// and ?page=&page_size= select a page; without page_size every tag is returned. ?digests=false
+// skips looking up the digest of each tag listed.
 func (h *Handler) ListTags(w http.ResponseWriter, r *http.Request) {
 	id, err := h.getRegistryID(r)
 	if err != nil {
@@ -279,6 +689,10 @@ func (h *Handler) ListTags(w http.ResponseWriter, r *http.Request) {
 		h.errorResponse(w, http.StatusBadRequest, "Repository name is required (query param: repo)")
 		return
 	}
+	page, ok := h.parsePageRequest(w, r, "name", "pull_count", "push_count")
+	if !ok {
+		return
+	}
 
 	reg, err := h.db.GetRegistry(id)
 	if err != nil {
@@ -286,25 +700,49 @@ func (h *Handler) ListTags(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	refresh := r.URL.Query().Get("refresh") == "true"
 	client := registry.NewClientFromRegistry(reg)
-	tags, err := client.ListTags(repoName)
+	all, err := h.listTagsCached(r.Context(), reg, client, repoName, refresh)
 	if err != nil {
-		h.errorResponse(w, http.StatusBadGateway, fmt.Sprintf("Failed to list tags: %v", err))
+		h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Failed to list tags: %v", err))
 		return
 	}
 
-	// Optionally get digest for each tag
-	for i := range tags {
-		digest, err := client.GetDigestForTag(repoName, tags[i].Name)
-		if err == nil {
-			tags[i].Digest = digest
+	// Usage counters maintained from registry notifications
+	usage, err := h.db.GetTagUsage(id, repoName)
+	if err != nil {
+		log.Printf("⚠️  Failed to load tag usage for %s: %v", repoName, err)
+	}
+	tags := []models.Tag{}
+	for _, t := range all {
+		if page.matches(t.Name) {
+			t.TagUsage = usage[t.Name]
+			tags = append(tags, t)
 		}
 	}
 
-	h.successResponse(w, tags)
+	sort.SliceStable(tags, func(i, j int) bool {
+		a, b := tags[i], tags[j]
+		switch page.Sort {
+		case "pull_count":
+			return page.less(a.PullCount, b.PullCount, a.Name, b.Name)
+		case "push_count":
+			return page.less(a.PushCount, b.PushCount, a.Name, b.Name)
+		}
+		return page.less(0, 0, a.Name, b.Name)
+	})
+
+	start, end, meta := page.bounds(len(tags))
+	tags = tags[start:end]
+	if r.URL.Query().Get("digests") != "false" {
+		h.resolveDigestsCached(r.Context(), reg, client, repoName, tags, refresh)
+	}
+	addHarborMetadata(r.Context(), client, repoName, tags)
+
+	h.pageResponse(w, tags, meta)
 }
 
-// GetManifest returns the manifest for a specific tag
+// GetManifest returns the manifest for a specific tag (optionally a platform of a multi-arch image)
 func (h *Handler) GetManifest(w http.ResponseWriter, r *http.Request) {
 	id, err := h.getRegistryID(r)
 	if err != nil {
@@ -326,15 +764,53 @@ func (h *Handler) GetManifest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	client := registry.NewClientFromRegistry(reg)
-	manifest, err := client.GetManifest(repoName, tag)
+
+	// For multi-arch images, ?platform=linux/arm64 resolves the child manifest
+	var manifest *models.ImageManifest
+	if platform := r.URL.Query().Get("platform"); platform != "" {
+		manifest, err = client.ResolvePlatformManifest(r.Context(), repoName, tag, platform)
+	} else {
+		manifest, err = client.GetManifest(r.Context(), repoName, tag)
+	}
 	if err != nil {
-		h.errorResponse(w, http.StatusBadGateway, fmt.Sprintf("Failed to get manifest: %v", err))
+		h.errorResponse(w, registryErrorStatus(err), h.tr(w, "Failed to get manifest: %v", err))
 		return
 	}
 
 	h.successResponse(w, manifest)
 }
 
+// GetImageConfig returns the runtime configuration, labels and history of an image
+func (h *Handler) GetImageConfig(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+
+	repoName := r.URL.Query().Get("repo")
+	tag := r.URL.Query().Get("tag")
+	if repoName == "" || tag == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Repository name and tag are required")
+		return
+	}
+
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+
+	client := registry.NewClientFromRegistry(reg)
+	config, err := client.GetImageConfig(r.Context(), repoName, tag, r.URL.Query().Get("platform"))
+	if err != nil {
+		h.errorResponse(w, registryErrorStatus(err), h.tr(w, "Failed to get image config: %v", err))
+		return
+	}
+
+	h.successResponse(w, config)
+}
+
 // DeleteTag deletes a tag from a repository
 func (h *Handler) DeleteTag(w http.ResponseWriter, r *http.Request) {
 	id, err := h.getRegistryID(r)
@@ -349,6 +825,9 @@ func (h *Handler) DeleteTag(w http.ResponseWriter, r *http.Request) {
 		h.errorResponse(w, http.StatusBadRequest, "Repository name and tag are required")
 		return
 	}
+	if !h.allowAction(w, r, id, repoName, actionDelete) || !h.allowTagDelete(w, r, id, repoName, tag) {
+		return
+	}
 
 	reg, err := h.db.GetRegistry(id)
 	if err != nil {
@@ -359,35 +838,151 @@ func (h *Handler) DeleteTag(w http.ResponseWriter, r *http.Request) {
 	client := registry.NewClientFromRegistry(reg)
 
 	// First get the digest for this tag
-	digest, err := client.GetDigestForTag(repoName, tag)
+	digest, err := client.GetDigestForTag(r.Context(), repoName, tag)
 	if err != nil {
-		h.errorResponse(w, http.StatusBadGateway, fmt.Sprintf("Failed to get digest: %v", err))
+		h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Failed to get digest: %v", err))
+		return
+	}
+	// Deleting the manifest deletes the other tags pointing at it as well
+	if other, err := h.immutableTagOf(r.Context(), client, id, repoName, digest); err != nil {
+		h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Failed to list tags: %v", err))
+		return
+	} else if other != "" {
+		h.errorResponse(w, http.StatusForbidden, h.tr(w, "Tag %s:%s points at the same image as the immutable tag %s", repoName, tag, other))
 		return
 	}
 
+	// Size it while the manifest still exists, for the deleted-image ledger
+	size, err := client.ImageSize(r.Context(), repoName, digest)
+	if err != nil {
+		log.Printf("⚠️  Failed to get size of %s:%s: %v", repoName, tag, err)
+	}
+
 	// Delete the manifest by digest
-	if err := client.DeleteManifest(repoName, digest); err != nil {
-		h.errorResponse(w, http.StatusBadGateway, fmt.Sprintf("Failed to delete tag: %v", err))
+	if err := client.DeleteManifest(r.Context(), repoName, digest); err != nil {
+		h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Failed to delete tag: %v", err))
 		return
 	}
 
-	h.messageResponse(w, fmt.Sprintf("Tag %s:%s deleted successfully", repoName, tag))
+	h.recordDeletion(r, reg, models.DeletedImage{Repository: repoName, Tag: tag, Digest: digest, Size: size, Source: "manual"})
+	h.invalidateListings(reg.ID)
+	if err := h.db.UnindexTag(reg.ID, repoName, tag); err != nil {
+		log.Printf("⚠️  Failed to remove %s:%s from the search index: %v", repoName, tag, err)
+	}
+
+	h.messageResponse(w, h.tr(w, "Tag %s:%s deleted successfully", repoName, tag))
+}
+
+// RetagRequest asks to add a tag pointing at the manifest of an existing tag
+type RetagRequest struct {
+	Repository string `json:"repository"`
+	SourceTag  string `json:"source_tag"`
+	TargetTag  string `json:"target_tag"`
+}
+
+// RetagImage re-PUTs the manifest of source_tag under target_tag in the same repository
+func (h *Handler) RetagImage(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+
+	var req RetagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Repository == "" || req.SourceTag == "" || req.TargetTag == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Repository, source_tag and target_tag are required")
+		return
+	}
+	if !h.allowRepository(w, r, id, req.Repository, true) || !h.allowAction(w, r, id, req.Repository, actionPush) {
+		return
+	}
+
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+
+	client := registry.NewClientFromRegistry(reg)
+	if !h.allowTagWrite(r.Context(), w, client, id, req.Repository, req.TargetTag) {
+		return
+	}
+	digest, err := client.Retag(r.Context(), req.Repository, req.SourceTag, req.TargetTag)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Failed to retag image: %v", err))
+		return
+	}
+	h.invalidateListings(reg.ID)
+
+	h.successResponse(w, map[string]string{
+		"repository": req.Repository,
+		"tag":        req.TargetTag,
+		"digest":     digest,
+	})
 }
 
 // --- Storage Configuration ---
 
-// GetStorageConfig returns the current storage configuration
+// maskedSecret stands in for a stored secret in responses, as in the registry config preview;
+// a config posted back with it keeps the stored secret
+const maskedSecret = "********"
+
+// storageSecrets returns the secret fields of a storage config
+func storageSecrets(config *models.StorageConfig) []*string {
+	return []*string{
+		&config.S3SecretKey, &config.GCSCredentials, &config.SwiftPassword, &config.SFTPPassword, &config.SFTPPrivateKey,
+		&config.RedisPassword, &config.ProxyPassword,
+	}
+}
+
+// maskStorageSecrets replaces the secrets that are set with maskedSecret
+func maskStorageSecrets(config *models.StorageConfig) {
+	for _, secret := range storageSecrets(config) {
+		if *secret != "" {
+			*secret = maskedSecret
+		}
+	}
+}
+
+// keepStorageSecrets fills the secrets a posted config left masked in with the saved ones
+func (h *Handler) keepStorageSecrets(w http.ResponseWriter, config *models.StorageConfig) bool {
+	saved, err := h.db.GetStorageConfig()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to load storage config")
+		return false
+	}
+	stored := storageSecrets(saved)
+	for i, secret := range storageSecrets(config) {
+		if *secret == maskedSecret {
+			*secret = *stored[i]
+		}
+	}
+	return true
+}
+
+// GetStorageConfig returns the current storage configuration with its secrets masked (admin only)
 func (h *Handler) GetStorageConfig(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
 	config, err := h.db.GetStorageConfig()
 	if err != nil {
 		h.errorResponse(w, http.StatusInternalServerError, "Failed to load storage config")
 		return
 	}
+	maskStorageSecrets(config)
 	h.successResponse(w, config)
 }
 
-// SaveStorageConfig saves the storage configuration and restarts the registry
+// SaveStorageConfig saves the storage configuration and restarts the registry (admin only)
 func (h *Handler) SaveStorageConfig(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
 	var config models.StorageConfig
 	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
 		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
@@ -398,6 +993,26 @@ func (h *Handler) SaveStorageConfig(w http.ResponseWriter, r *http.Request) {
 		h.errorResponse(w, http.StatusBadRequest, "Storage type is required")
 		return
 	}
+	if !h.keepStorageSecrets(w, &config) {
+		return
+	}
+
+	// Refuse configs the registry would not start with, before they replace the saved one
+	if h.embeddedReg != nil {
+		check, err := h.embeddedReg.ValidateConfig(&config)
+		if err != nil {
+			h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to validate registry config: %v", err))
+			return
+		}
+		if !check.Valid {
+			h.jsonResponse(w, http.StatusBadRequest, models.APIResponse{
+				Success: false,
+				Data:    check,
+				Error:   h.tr(w, "Registry config is invalid: %s", strings.Join(check.Problems, "; ")),
+			})
+			return
+		}
+	}
 
 	if err := h.db.SaveStorageConfig(&config); err != nil {
 		h.errorResponse(w, http.StatusInternalServerError, "Failed to save storage config")
@@ -415,20 +1030,101 @@ func (h *Handler) SaveStorageConfig(w http.ResponseWriter, r *http.Request) {
 		restartMsg = " Registry is restarting with new configuration."
 	}
 
+	saved := config
+	maskStorageSecrets(&saved)
 	h.jsonResponse(w, http.StatusOK, models.APIResponse{
 		Success: true,
-		Data:    config,
-		Message: "Storage configuration saved successfully." + restartMsg,
+		Data:    saved,
+		Message: h.tr(w, "Storage configuration saved successfully.") + h.tr(w, restartMsg),
+	})
+}
+
+// storageConfigFromRequest decodes a storage config from the body, falling back to the saved one for an empty body
+func (h *Handler) storageConfigFromRequest(w http.ResponseWriter, r *http.Request) (*models.StorageConfig, bool) {
+	if r.ContentLength == 0 {
+		config, err := h.db.GetStorageConfig()
+		if err != nil {
+			h.errorResponse(w, http.StatusInternalServerError, "Failed to load storage config")
+			return nil, false
+		}
+		return config, true
+	}
+
+	var config models.StorageConfig
+	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return nil, false
+	}
+	if !h.keepStorageSecrets(w, &config) {
+		return nil, false
+	}
+	return &config, true
+}
+
+// PreviewRegistryConfig renders the registry config.yml for a storage config with secrets masked (admin only)
+func (h *Handler) PreviewRegistryConfig(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	config, ok := h.storageConfigFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	rendered, err := registry.RenderConfig(config, h.embeddedAuth())
+	if errors.Is(err, registry.ErrInvalidOverrides) {
+		h.successResponse(w, models.RegistryConfigCheck{Problems: registry.SettingsProblems(config)})
+		return
+	}
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	problems := append(registry.SettingsProblems(config), registry.LintConfig(rendered)...)
+	h.successResponse(w, models.RegistryConfigCheck{
+		Config:   registry.MaskConfigSecrets(rendered),
+		Valid:    len(problems) == 0,
+		Problems: problems,
 	})
 }
 
-// TestStorageConnection tests the storage backend connection
+// ValidateRegistryConfig renders a storage config and checks it in a throwaway registry container (admin only)
+func (h *Handler) ValidateRegistryConfig(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	if h.embeddedReg == nil {
+		h.errorResponse(w, http.StatusServiceUnavailable, "Embedded registry is not available")
+		return
+	}
+
+	config, ok := h.storageConfigFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	check, err := h.embeddedReg.ValidateConfig(config)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to validate registry config: %v", err))
+		return
+	}
+	h.successResponse(w, check)
+}
+
+// TestStorageConnection tests the storage backend connection (admin only)
 func (h *Handler) TestStorageConnection(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
 	var config models.StorageConfig
 	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
 		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
+	if !h.keepStorageSecrets(w, &config) {
+		return
+	}
 
 	switch config.Type {
 	case "local":
@@ -445,7 +1141,7 @@ func (h *Handler) TestStorageConnection(w http.ResponseWriter, r *http.Request)
 				})
 				return
 			}
-			h.errorResponse(w, http.StatusBadRequest, fmt.Sprintf("Cannot access path: %v", err))
+			h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Cannot access path: %v", err))
 			return
 		}
 		if !info.IsDir() {
@@ -472,7 +1168,7 @@ func (h *Handler) TestStorageConnection(w http.ResponseWriter, r *http.Request)
 		}
 		conn, err := net.DialTimeout("tcp", host, 5*time.Second)
 		if err != nil {
-			h.errorResponse(w, http.StatusBadGateway, fmt.Sprintf("Cannot connect to S3 endpoint: %v", err))
+			h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Cannot connect to S3 endpoint: %v", err))
 			return
 		}
 		conn.Close()
@@ -481,6 +1177,49 @@ func (h *Handler) TestStorageConnection(w http.ResponseWriter, r *http.Request)
 			"message": "S3 endpoint is reachable",
 		})
 
+	case "gcs":
+		if config.GCSBucket == "" {
+			h.errorResponse(w, http.StatusBadRequest, "GCS bucket is required")
+			return
+		}
+		if err := checkGCSBucket(r.Context(), config.GCSBucket, config.GCSCredentials); err != nil {
+			h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Cannot access GCS bucket: %v", err))
+			return
+		}
+		h.successResponse(w, map[string]string{
+			"status":  "connected",
+			"message": "GCS bucket is accessible",
+		})
+
+	case "swift":
+		if config.SwiftAuthURL == "" || config.SwiftContainer == "" {
+			h.errorResponse(w, http.StatusBadRequest, "Swift auth URL and container are required")
+			return
+		}
+		u, err := url.Parse(config.SwiftAuthURL)
+		if err != nil || u.Host == "" {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid Swift auth URL")
+			return
+		}
+		host := u.Host
+		if u.Port() == "" {
+			if u.Scheme == "http" {
+				host += ":80"
+			} else {
+				host += ":443"
+			}
+		}
+		conn, err := net.DialTimeout("tcp", host, 5*time.Second)
+		if err != nil {
+			h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Cannot connect to Swift auth URL: %v", err))
+			return
+		}
+		conn.Close()
+		h.successResponse(w, map[string]string{
+			"status":  "connected",
+			"message": "Swift auth URL is reachable",
+		})
+
 	case "sftp":
 		if config.SFTPHost == "" || config.SFTPUser == "" {
 			h.errorResponse(w, http.StatusBadRequest, "SFTP host and user are required")
@@ -493,7 +1232,7 @@ func (h *Handler) TestStorageConnection(w http.ResponseWriter, r *http.Request)
 		addr := net.JoinHostPort(config.SFTPHost, strconv.Itoa(port))
 		conn, err := net.DialTimeout("tcp", addr, 5*time.Second)
 		if err != nil {
-			h.errorResponse(w, http.StatusBadGateway, fmt.Sprintf("Cannot connect to SFTP server: %v", err))
+			h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Cannot connect to SFTP server: %v", err))
 			return
 		}
 		conn.Close()
@@ -507,6 +1246,37 @@ func (h *Handler) TestStorageConnection(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// checkGCSBucket reads a bucket's metadata with the access token of a service account key, or of
+// the dashboard's own environment without one
+func checkGCSBucket(ctx context.Context, bucket, keyJSON string) error {
+	_, token, err := gcp.Login(ctx, keyJSON)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", "https://storage.googleapis.com/storage/v1/b/"+url.PathEscape(bucket)+"?fields=name", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("bucket %s does not exist", bucket)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("the credentials have no access to bucket %s", bucket)
+	default:
+		return fmt.Errorf("storage API returned status %d", resp.StatusCode)
+	}
+}
+
 // --- Embedded Registry Management ---
 
 // GetEmbeddedRegistryStatus returns the status of the embedded registry
@@ -522,8 +1292,11 @@ func (h *Handler) GetEmbeddedRegistryStatus(w http.ResponseWriter, r *http.Reque
 	h.successResponse(w, h.embeddedReg.Status())
 }
 
-// RestartEmbeddedRegistry restarts the embedded registry with current storage config
+// RestartEmbeddedRegistry restarts the embedded registry with current storage config (admin only)
 func (h *Handler) RestartEmbeddedRegistry(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
 	if h.embeddedReg == nil {
 		h.errorResponse(w, http.StatusServiceUnavailable, "Embedded registry is not available")
 		return
@@ -536,30 +1309,183 @@ func (h *Handler) RestartEmbeddedRegistry(w http.ResponseWriter, r *http.Request
 	}
 
 	if err := h.embeddedReg.Restart(config); err != nil {
-		h.errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to restart registry: %v", err))
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to restart registry: %v", err))
 		return
 	}
 
 	h.messageResponse(w, "Registry restarted successfully")
 }
 
-// StopEmbeddedRegistry stops the embedded registry
+// UpgradeEmbeddedRegistry moves the embedded registry container to another image, given as
+// {"image": "registry:2.8.3"}, or without one to the newest build of its current image. The image
+// is saved in the storage config once the registry runs on it.
+func (h *Handler) UpgradeEmbeddedRegistry(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	if h.embeddedReg == nil {
+		h.errorResponse(w, http.StatusServiceUnavailable, "Embedded registry is not available")
+		return
+	}
+
+	var req struct {
+		Image string `json:"image"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+	config, err := h.db.GetStorageConfig()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to load storage config")
+		return
+	}
+	if image := strings.TrimSpace(req.Image); image != "" {
+		if _, _, _, err := registry.ParseImageReference(image); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Invalid registry image: %v", err))
+			return
+		}
+		config.RegistryImage = image
+	}
+
+	result, err := h.embeddedReg.Upgrade(config)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Failed to upgrade registry: %v", err))
+		return
+	}
+	if err := h.db.SaveStorageConfig(config); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to save storage config")
+		return
+	}
+	h.audit(r, "registry.upgrade", result.Image, fmt.Sprintf("from %s %s to %s", result.PreviousImage, result.PreviousVersion, result.Version))
+	h.successResponse(w, result)
+}
+
+// containerNamePattern is what Docker accepts as a container name
+var containerNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]{0,127}$`)
+
+// GetEmbeddedRegistrySettings returns the host port and container name of the embedded registry (admin only)
+func (h *Handler) GetEmbeddedRegistrySettings(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	if h.embeddedReg == nil {
+		h.errorResponse(w, http.StatusServiceUnavailable, "Embedded registry is not available")
+		return
+	}
+	h.successResponse(w, models.EmbeddedRegistrySettings{Port: h.embeddedReg.Port(), ContainerName: h.embeddedReg.Container()})
+}
+
+// UpdateEmbeddedRegistrySettings moves the embedded registry to another host port or container
+// name, given as {"port": 5050, "container_name": "registry"}, recreating a running container. The
+// registry entries at its old URL follow it, and the settings are kept over the -registry-port flag.
+func (h *Handler) UpdateEmbeddedRegistrySettings(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	if h.embeddedReg == nil {
+		h.errorResponse(w, http.StatusServiceUnavailable, "Embedded registry is not available")
+		return
+	}
+	var settings models.EmbeddedRegistrySettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if settings.Port == 0 {
+		settings.Port = h.embeddedReg.Port()
+	}
+	if settings.ContainerName = strings.TrimSpace(settings.ContainerName); settings.ContainerName == "" {
+		settings.ContainerName = h.embeddedReg.Container()
+	}
+	if settings.Port < 1 || settings.Port > 65535 {
+		h.errorResponse(w, http.StatusBadRequest, "Port must be between 1 and 65535")
+		return
+	}
+	if !containerNamePattern.MatchString(settings.ContainerName) {
+		h.errorResponse(w, http.StatusBadRequest, "Container name must start with a letter or digit and contain only letters, digits, dots, dashes and underscores")
+		return
+	}
+	instances, err := h.db.ListManagedRegistries()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for _, instance := range instances {
+		if instance.Port == settings.Port || registry.ManagedContainerName(instance.Name) == settings.ContainerName {
+			h.errorResponse(w, http.StatusConflict, h.tr(w, "Port or container name is used by the registry %s", instance.Name))
+			return
+		}
+	}
+
+	oldPort, oldContainer, oldURL := h.embeddedReg.Port(), h.embeddedReg.Container(), h.embeddedReg.URL()
+	if err := h.embeddedReg.Relocate(settings.Port, settings.ContainerName); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to move registry: %v", err))
+		return
+	}
+	if err := h.db.SaveEmbeddedRegistrySettings(&settings); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Registry moved, but the settings could not be saved: %v", err))
+		return
+	}
+
+	// The registry entries of the embedded registry follow it, with their probed profile
+	if newURL := h.embeddedReg.URL(); newURL != oldURL {
+		registries, err := h.db.ListRegistries()
+		if err != nil {
+			log.Printf("⚠️  Could not update the registry entries at %s: %v", oldURL, err)
+		}
+		for i := range registries {
+			entry := &registries[i]
+			if entry.URL != oldURL {
+				continue
+			}
+			entry.URL = newURL
+			if err := h.db.UpdateRegistry(entry); err != nil {
+				log.Printf("⚠️  Could not move registry %s to %s: %v", entry.Name, newURL, err)
+				continue
+			}
+			h.invalidateListings(entry.ID)
+			if profile, err := h.db.GetRegistryProfile(entry.ID); err == nil {
+				h.db.SaveRegistryProfile(newURL, profile)
+				registry.SetProfile(newURL, profile)
+			}
+			registry.SetProfile(oldURL, nil)
+		}
+		go h.refreshDashboardStats()
+	}
+	h.audit(r, "registry.relocate", settings.ContainerName, fmt.Sprintf("from %s on port %d to %s on port %d", oldContainer, oldPort, settings.ContainerName, settings.Port))
+	h.jsonResponse(w, http.StatusOK, models.APIResponse{
+		Success: true,
+		Data:    settings,
+		Message: h.tr(w, "Registry moved to %s", h.embeddedReg.URL()),
+	})
+}
+
+// StopEmbeddedRegistry stops the embedded registry (admin only)
 func (h *Handler) StopEmbeddedRegistry(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
 	if h.embeddedReg == nil {
 		h.errorResponse(w, http.StatusServiceUnavailable, "Embedded registry is not available")
 		return
 	}
 
 	if err := h.embeddedReg.Stop(); err != nil {
-		h.errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to stop registry: %v", err))
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to stop registry: %v", err))
 		return
 	}
 
 	h.messageResponse(w, "Registry stopped")
 }
 
-// StartEmbeddedRegistry starts the embedded registry
+// StartEmbeddedRegistry starts the embedded registry (admin only)
 func (h *Handler) StartEmbeddedRegistry(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
 	if h.embeddedReg == nil {
 		h.errorResponse(w, http.StatusServiceUnavailable, "Embedded registry is not available")
 		return
@@ -572,15 +1498,18 @@ func (h *Handler) StartEmbeddedRegistry(w http.ResponseWriter, r *http.Request)
 	}
 
 	if err := h.embeddedReg.Start(config); err != nil {
-		h.errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to start registry: %v", err))
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to start registry: %v", err))
 		return
 	}
 
 	h.messageResponse(w, "Registry started successfully")
 }
 
-// GetEmbeddedRegistryLogs returns recent container logs
+// GetEmbeddedRegistryLogs returns recent container logs (admin only)
 func (h *Handler) GetEmbeddedRegistryLogs(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
 	if h.embeddedReg == nil {
 		h.errorResponse(w, http.StatusServiceUnavailable, "Embedded registry is not available")
 		return
@@ -588,7 +1517,7 @@ func (h *Handler) GetEmbeddedRegistryLogs(w http.ResponseWriter, r *http.Request
 
 	logs, err := h.embeddedReg.GetContainerLogs(100)
 	if err != nil {
-		h.errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get logs: %v", err))
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to get logs: %v", err))
 		return
 	}
 
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"docker-registry-dashboard/internal/auth"
+	"docker-registry-dashboard/internal/database"
+	"docker-registry-dashboard/internal/models"
+)
+
+// testServer is a Handler over a fresh database, serving the API routes behind the
+// authentication, maintenance and project middleware in the order main.go chains them
+type testServer struct {
+	h       *Handler
+	db      *database.DB
+	handler http.Handler
+}
+
+func newTestServer(t *testing.T, authRequired bool) *testServer {
+	t.Helper()
+	db, err := database.New(filepath.Join(t.TempDir(), "registry.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	h := New(db, nil, nil)
+	h.SetAuthRequired(authRequired)
+	mux := http.NewServeMux()
+	for _, rt := range apiRoutes {
+		fn, ok := reflect.ValueOf(h).MethodByName(rt.Handler).Interface().(func(http.ResponseWriter, *http.Request))
+		if !ok {
+			t.Fatalf("%s is not a handler", rt.Handler)
+		}
+		mux.HandleFunc(rt.Method+" "+rt.Pattern, fn)
+	}
+	return &testServer{h: h, db: db, handler: h.Authenticate(h.ReadOnly(h.ScopeProjects(mux)))}
+}
+
+// addUser creates an account with the password "password123" and returns it with an API token
+func (s *testServer) addUser(t *testing.T, username, role string) (*models.User, string) {
+	t.Helper()
+	hash, err := auth.HashPassword("password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{Username: username, Role: role, PasswordHash: hash}
+	if err := s.db.CreateUser(user); err != nil {
+		t.Fatal(err)
+	}
+	token, tokenHash, err := auth.NewToken(auth.APITokenPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.db.CreateAPIToken(&models.APIToken{UserID: user.ID, Name: "test"}, tokenHash); err != nil {
+		t.Fatal(err)
+	}
+	return user, token
+}
+
+// addRegistry creates a registry that nothing listens on
+func (s *testServer) addRegistry(t *testing.T, name string) *models.Registry {
+	t.Helper()
+	reg := &models.Registry{Name: name, URL: "http://127.0.0.1:1"}
+	if err := s.db.CreateRegistry(reg); err != nil {
+		t.Fatal(err)
+	}
+	return reg
+}
+
+// do sends a request, with the bearer token unless it is empty
+func (s *testServer) do(method, path, token, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	s.handler.ServeHTTP(w, r)
+	return w
+}
+
+// decode unmarshals the data of an API response
+func decode(t *testing.T, w *httptest.ResponseRecorder, data interface{}) models.APIResponse {
+	t.Helper()
+	var resp struct {
+		models.APIResponse
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid response %q: %v", w.Body.String(), err)
+	}
+	if data != nil && len(resp.Data) > 0 {
+		if err := json.Unmarshal(resp.Data, data); err != nil {
+			t.Fatalf("invalid data %s: %v", resp.Data, err)
+		}
+	}
+	return resp.APIResponse
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/registry"
+)
+
+// --- Harbor ---
+
+// harborClient returns a client for the registry of the request, answering the request itself when
+// the registry is missing
+func (h *Handler) harborClient(w http.ResponseWriter, r *http.Request) *registry.Client {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return nil
+	}
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return nil
+	}
+	return registry.NewClientFromRegistry(reg)
+}
+
+// harborError reports a failed Harbor API call
+func (h *Handler) harborError(w http.ResponseWriter, err error) {
+	if errors.Is(err, registry.ErrNotHarbor) {
+		h.errorResponse(w, http.StatusNotFound, "Not a Harbor registry")
+		return
+	}
+	h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Harbor API request failed: %v", err))
+}
+
+// GetHarborInfo detects whether a registry is Harbor and returns its system information
+func (h *Handler) GetHarborInfo(w http.ResponseWriter, r *http.Request) {
+	client := h.harborClient(w, r)
+	if client == nil {
+		return
+	}
+	info, err := client.Harbor(r.Context())
+	if err != nil {
+		h.harborError(w, err)
+		return
+	}
+	h.successResponse(w, info)
+}
+
+// ListHarborProjects returns the Harbor projects of a registry with their storage quota and usage
+func (h *Handler) ListHarborProjects(w http.ResponseWriter, r *http.Request) {
+	client := h.harborClient(w, r)
+	if client == nil {
+		return
+	}
+	projects, err := client.ListHarborProjects(r.Context())
+	if err != nil {
+		h.harborError(w, err)
+		return
+	}
+	h.successResponse(w, projects)
+}
+
+// ListHarborLabels returns the global Harbor labels, and those of ?project= when given
+func (h *Handler) ListHarborLabels(w http.ResponseWriter, r *http.Request) {
+	client := h.harborClient(w, r)
+	if client == nil {
+		return
+	}
+	labels, err := client.ListHarborLabels(r.Context(), r.URL.Query().Get("project"))
+	if err != nil {
+		h.harborError(w, err)
+		return
+	}
+	h.successResponse(w, labels)
+}
+
+// ListHarborArtifacts returns the Harbor artifacts of ?repo= with their tags, labels, size and
+// push and pull times
+func (h *Handler) ListHarborArtifacts(w http.ResponseWriter, r *http.Request) {
+	repo := r.URL.Query().Get("repo")
+	if repo == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Repository name is required (query param: repo)")
+		return
+	}
+	client := h.harborClient(w, r)
+	if client == nil {
+		return
+	}
+	artifacts, err := client.ListHarborArtifacts(r.Context(), repo)
+	if err != nil {
+		h.harborError(w, err)
+		return
+	}
+	h.successResponse(w, artifacts)
+}
+
+// addHarborMetadata attaches the Harbor artifact of each tag to a tag listing of a Harbor registry
+func addHarborMetadata(ctx context.Context, client *registry.Client, repo string, tags []models.Tag) {
+	if len(tags) == 0 {
+		return
+	}
+	if _, err := client.Harbor(ctx); err != nil {
+		return
+	}
+	artifacts, err := client.ListHarborArtifacts(ctx, repo)
+	if err != nil {
+		log.Printf("⚠️  Failed to load Harbor artifacts of %s: %v", repo, err)
+		return
+	}
+	byTag := make(map[string]*models.HarborArtifact)
+	for i := range artifacts {
+		for _, name := range artifacts[i].Tags {
+			byTag[name] = &artifacts[i]
+		}
+	}
+	for i := range tags {
+		if a := byTag[tags[i].Name]; a != nil {
+			tags[i].Harbor = a
+			if tags[i].Digest == "" {
+				tags[i].Digest = a.Digest
+			}
+		}
+	}
+}
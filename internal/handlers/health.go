@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// healthCheckTimeout bounds each component check, so a hung dependency fails the probe instead of blocking it
+const healthCheckTimeout = 5 * time.Second
+
+// healthCheck checks one component; detail describes a healthy state
+type healthCheck struct {
+	name     string
+	critical bool
+	check    func() (detail string, err error)
+}
+
+// RegisterHealthCheck adds a component to the readiness probe. A failing critical component makes
+// the dashboard unready; a failing non-critical one only marks it degraded. The database is
+// registered by New.
+func (h *Handler) RegisterHealthCheck(name string, critical bool, check func() (string, error)) {
+	h.healthChecks = append(h.healthChecks, healthCheck{name: name, critical: critical, check: check})
+}
+
+// Healthz answers the liveness probe as long as the process serves requests
+func (h *Handler) Healthz(w http.ResponseWriter, r *http.Request) {
+	h.successResponse(w, models.HealthReport{Status: "ok", UptimeSeconds: h.uptimeSeconds()})
+}
+
+// Readyz answers the readiness probe with the state of every registered component, checked
+// concurrently, and 503 when a critical one fails.
+func (h *Handler) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := models.HealthReport{
+		Status:        "ok",
+		UptimeSeconds: h.uptimeSeconds(),
+		Components:    make([]models.ComponentHealth, len(h.healthChecks)),
+	}
+
+	var wg sync.WaitGroup
+	for i, c := range h.healthChecks {
+		wg.Add(1)
+		go func(i int, c healthCheck) {
+			defer wg.Done()
+			report.Components[i] = runHealthCheck(c)
+		}(i, c)
+	}
+	wg.Wait()
+
+	status := http.StatusOK
+	for _, c := range report.Components {
+		if c.Status == "ok" {
+			continue
+		}
+		if c.Critical {
+			report.Status = "unavailable"
+			status = http.StatusServiceUnavailable
+		} else if report.Status == "ok" {
+			report.Status = "degraded"
+		}
+	}
+	h.jsonResponse(w, status, models.APIResponse{Success: status == http.StatusOK, Data: report})
+}
+
+// runHealthCheck runs a component check within healthCheckTimeout
+func runHealthCheck(c healthCheck) models.ComponentHealth {
+	result := models.ComponentHealth{Name: c.name, Status: "ok", Critical: c.critical}
+	type outcome struct {
+		detail string
+		err    error
+	}
+	done := make(chan outcome, 1)
+	start := time.Now()
+	go func() {
+		detail, err := c.check()
+		done <- outcome{detail, err}
+	}()
+
+	select {
+	case o := <-done:
+		result.Detail = o.detail
+		if o.err != nil {
+			result.Status, result.Error = "fail", o.err.Error()
+		}
+	case <-time.After(healthCheckTimeout):
+		result.Status, result.Error = "fail", fmt.Sprintf("no answer within %s", healthCheckTimeout)
+	}
+	result.DurationMS = time.Since(start).Milliseconds()
+	return result
+}
+
+func (h *Handler) uptimeSeconds() int64 {
+	return int64(time.Since(h.startedAt) / time.Second)
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/registry"
+)
+
+// allowTagDelete checks that no immutable tag rule of the registry protects a tag, writing the
+// error response when one does
+func (h *Handler) allowTagDelete(w http.ResponseWriter, r *http.Request, registryID int64, repo, tag string) bool {
+	rule, err := h.db.ImmutableTagRule(registryID, repo, tag)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return false
+	}
+	if rule != nil {
+		h.errorResponse(w, http.StatusForbidden, h.tr(w, "Tag %s:%s is immutable: it matches %s", repo, tag, rule.TagPattern))
+		return false
+	}
+	return true
+}
+
+// immutableTagOf returns an immutable tag of a repository pointing at a manifest, "" when there is
+// none; deleting the manifest would delete that tag too
+func (h *Handler) immutableTagOf(ctx context.Context, client *registry.Client, registryID int64, repo, digest string) (string, error) {
+	immutable, err := h.immutableTags(registryID)
+	if err != nil || immutable == nil {
+		return "", err
+	}
+	tags, err := client.ListTags(ctx, repo)
+	if err != nil {
+		return "", err
+	}
+	for _, t := range tags {
+		if !immutable(repo, t.Name) {
+			continue
+		}
+		if d, err := client.GetDigestForTag(ctx, repo, t.Name); err == nil && d == digest {
+			return t.Name, nil
+		}
+	}
+	return "", nil
+}
+
+// allowTagWrite checks that a tag about to be pushed is not an immutable tag that already exists,
+// writing the error response when it is. An immutable tag may be pushed once.
+func (h *Handler) allowTagWrite(ctx context.Context, w http.ResponseWriter, client *registry.Client, registryID int64, repo, tag string) bool {
+	rule, err := h.db.ImmutableTagRule(registryID, repo, tag)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return false
+	}
+	if rule == nil {
+		return true
+	}
+	if _, err := client.GetDigestForTag(ctx, repo, tag); err != nil {
+		return true
+	}
+	h.errorResponse(w, http.StatusForbidden, h.tr(w, "Tag %s:%s is immutable: it matches %s", repo, tag, rule.TagPattern))
+	return false
+}
+
+// immutableTags returns what reports the immutable tags of a registry, nil when it has no rules
+func (h *Handler) immutableTags(registryID int64) (func(repo, tag string) bool, error) {
+	rules, err := h.db.ListImmutableTagRules(registryID)
+	if err != nil {
+		return nil, err
+	}
+	return models.ImmutableTags(rules), nil
+}
+
+// --- Immutable Tag Rules ---
+
+// ListImmutableTagRules returns the immutable tag rules of a registry
+func (h *Handler) ListImmutableTagRules(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	rules, err := h.db.ListImmutableTagRules(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.successResponse(w, rules)
+}
+
+// CreateImmutableTagRule makes the tags matching a pattern immutable (admin only)
+func (h *Handler) CreateImmutableTagRule(w http.ResponseWriter, r *http.Request) {
+	user := h.requireAdmin(w, r)
+	if user == nil {
+		return
+	}
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+
+	var rule models.ImmutableTagRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	rule.Repository = strings.TrimSpace(rule.Repository)
+	rule.TagPattern = strings.TrimSpace(rule.TagPattern)
+	if rule.TagPattern == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Tag pattern is required")
+		return
+	}
+	for _, pattern := range []string{rule.Repository, rule.TagPattern} {
+		if _, err := regexp.Compile(pattern); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Invalid pattern: %v", err))
+			return
+		}
+	}
+	rule.RegistryID = id
+	rule.CreatedBy = user.Username
+	if err := h.db.CreateImmutableTagRule(&rule); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to save immutable tag rule: %v", err))
+		return
+	}
+	h.audit(r, "immutable_tag.create", reg.Name, rule.TagPattern)
+	h.successResponse(w, rule)
+}
+
+// DeleteImmutableTagRule removes an immutable tag rule (admin only)
+func (h *Handler) DeleteImmutableTagRule(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid rule ID")
+		return
+	}
+	rule, err := h.db.GetImmutableTagRule(id)
+	if err == nil {
+		err = h.db.DeleteImmutableTagRule(id)
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.errorResponse(w, http.StatusNotFound, "Immutable tag rule not found")
+			return
+		}
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.audit(r, "immutable_tag.delete", fmt.Sprintf("registry %d", rule.RegistryID), rule.TagPattern)
+	h.messageResponse(w, "Immutable tag rule deleted")
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/registry"
+)
+
+// defaultImportMaxBytes caps image tarball uploads unless -import-max-mb says otherwise
+const defaultImportMaxBytes = 10 << 30
+
+// SetImportLimit sets the largest image tarball, once decompressed, an import accepts
+func (h *Handler) SetImportLimit(maxBytes int64) {
+	h.importMaxBytes = maxBytes
+}
+
+// importTarget is an image of an uploaded archive with the name it is pushed under
+type importTarget struct {
+	image      registry.ArchiveImage
+	repository string
+	tag        string
+}
+
+// ImportImages pushes the images of an image tarball sent as the request body: a docker save
+// archive or an OCI image layout (such as the dashboard's exports), optionally gzipped. The upload
+// is spooled to a temporary file. Images keep the names they were saved under, without the
+// registry host; ?repository= renames them all, and ?tag= the image of a single-image archive.
+func (h *Handler) ImportImages(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+
+	spool, err := os.CreateTemp("", "dashboard-import-*.tar")
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Import failed: %v", err))
+		return
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+	archive, err := registry.ReadImageArchive(http.MaxBytesReader(w, r.Body, h.importMaxBytes), spool, h.importMaxBytes)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) || errors.Is(err, registry.ErrArchiveTooLarge) {
+			h.errorResponse(w, http.StatusRequestEntityTooLarge, "Image archive is too large")
+			return
+		}
+		h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Invalid image archive: %v", err))
+		return
+	}
+	if len(archive.Images) == 0 {
+		h.errorResponse(w, http.StatusBadRequest, "The archive contains no images")
+		return
+	}
+
+	query := r.URL.Query()
+	if query.Get("tag") != "" && len(archive.Images) > 1 {
+		h.errorResponse(w, http.StatusBadRequest, "tag can only be given for an archive with one image")
+		return
+	}
+	targets := make([]importTarget, 0, len(archive.Images))
+	for i, img := range archive.Images {
+		t := importTarget{image: img, repository: img.Repository, tag: img.Tag}
+		if repo := query.Get("repository"); repo != "" {
+			t.repository = repo
+		}
+		if tag := query.Get("tag"); tag != "" {
+			t.tag = tag
+		}
+		if t.repository == "" || t.tag == "" {
+			h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Image %d of the archive has no name; give it one with repository and tag", i+1))
+			return
+		}
+		if !registry.ValidRepositoryName(t.repository) {
+			h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Invalid repository name %q", t.repository))
+			return
+		}
+		targets = append(targets, t)
+	}
+
+	client := registry.NewClientFromRegistry(reg)
+	for _, t := range targets {
+		if !h.allowRepository(w, r, reg.ID, t.repository, true) || !h.allowAction(w, r, reg.ID, t.repository, actionPush) ||
+			!h.allowTagWrite(r.Context(), w, client, reg.ID, t.repository, t.tag) || !h.allowCopyInto(w, reg.ID, t.repository) {
+			return
+		}
+	}
+
+	var stats registry.ImportStats
+	result := models.ImageImport{Images: []models.ImportedImage{}}
+	for _, t := range targets {
+		digest, err := archive.PushImage(r.Context(), client, t.image, t.repository, t.tag, &stats)
+		if err != nil {
+			// Images pushed before the failure stay
+			h.invalidateListings(reg.ID)
+			h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Failed to import %s:%s: %v", t.repository, t.tag, err))
+			return
+		}
+		result.Images = append(result.Images, models.ImportedImage{Repository: t.repository, Tag: t.tag, Digest: digest})
+	}
+	result.Blobs, result.SkippedBlobs, result.Bytes = stats.Blobs, stats.SkippedBlobs, stats.Bytes
+	h.invalidateListings(reg.ID)
+
+	h.audit(r, "image.import", reg.Name, fmt.Sprintf("%d images, %d blobs (%d bytes) uploaded", len(result.Images), result.Blobs, result.Bytes))
+	h.successResponse(w, result)
+}
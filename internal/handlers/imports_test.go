@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestImportImagesRejectsArchives(t *testing.T) {
+	s := newTestServer(t, false)
+	s.h.SetImportLimit(16 << 10)
+	reg := s.addRegistry(t, "prod")
+
+	var tarball bytes.Buffer
+	tw := tar.NewWriter(&tarball)
+	tw.WriteHeader(&tar.Header{Name: "layer.tar", Mode: 0644, Size: 1 << 20, Typeflag: tar.TypeReg})
+	tw.Write(make([]byte, 1<<20))
+	tw.Close()
+	// Small once compressed, but far larger than the limit once decompressed
+	var bomb bytes.Buffer
+	gz := gzip.NewWriter(&bomb)
+	gz.Write(tarball.Bytes())
+	gz.Close()
+
+	var empty bytes.Buffer
+	tar.NewWriter(&empty).Close()
+
+	tests := []struct {
+		name string
+		body []byte
+		want int
+	}{
+		{"larger than the limit", tarball.Bytes(), http.StatusRequestEntityTooLarge},
+		{"larger than the limit once decompressed", bomb.Bytes(), http.StatusRequestEntityTooLarge},
+		{"not a tar archive", []byte("not an archive"), http.StatusBadRequest},
+		{"without images", empty.Bytes(), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := s.do(http.MethodPost, fmt.Sprintf("/api/v1/registries/%d/import", reg.ID), "", string(tt.body))
+			if w.Code != tt.want {
+				t.Fatalf("import = %d %s, want %d", w.Code, w.Body, tt.want)
+			}
+			if tt.want == http.StatusRequestEntityTooLarge && !strings.Contains(w.Body.String(), "Image archive is too large") {
+				t.Errorf("import = %s, want the archive reported as too large", w.Body)
+			}
+		})
+	}
+}
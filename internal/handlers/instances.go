@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"docker-registry-dashboard/internal/auth"
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/registry"
+)
+
+// instanceName is what the name of an additional embedded registry may contain; it names the
+// registry's container and directory
+var instanceName = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+// SetRegistryFleet sets the manager of the additional embedded registries
+func (h *Handler) SetRegistryFleet(fleet *registry.Fleet) {
+	h.fleet = fleet
+}
+
+// --- Additional Embedded Registries ---
+
+// ListRegistryInstances returns the additional embedded registries with the status of those that run (admin only)
+func (h *Handler) ListRegistryInstances(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	instances, err := h.db.ListManagedRegistries()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for i := range instances {
+		if h.fleet == nil {
+			break
+		}
+		if reg := h.fleet.Registry(instances[i].ID); reg != nil {
+			instances[i].Status = reg.Status()
+		}
+	}
+	h.successResponse(w, instances)
+}
+
+// CreateRegistryInstance provisions an additional embedded registry on its own port, with its own
+// storage and optionally an account, starts it and registers it as a registry entry
+func (h *Handler) CreateRegistryInstance(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	if h.fleet == nil {
+		h.errorResponse(w, http.StatusServiceUnavailable, "Embedded registry is not available")
+		return
+	}
+	var instance models.ManagedRegistry
+	if err := json.NewDecoder(r.Body).Decode(&instance); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	instance.Name = strings.TrimSpace(instance.Name)
+	if !instanceName.MatchString(instance.Name) {
+		h.errorResponse(w, http.StatusBadRequest, "Registry name must be 1-32 lowercase letters, digits and dashes")
+		return
+	}
+	if instance.Port < 1 || instance.Port > 65535 {
+		h.errorResponse(w, http.StatusBadRequest, "Port must be between 1 and 65535")
+		return
+	}
+	if h.embeddedReg != nil && instance.Port == h.embeddedReg.Port() {
+		h.errorResponse(w, http.StatusConflict, "Port is used by another embedded registry")
+		return
+	}
+	existing, err := h.db.ListManagedRegistries()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for _, other := range existing {
+		if other.Name == instance.Name {
+			h.errorResponse(w, http.StatusConflict, "A registry with this name already exists")
+			return
+		}
+		if other.Port == instance.Port {
+			h.errorResponse(w, http.StatusConflict, "Port is used by another embedded registry")
+			return
+		}
+	}
+	if instance.Username != "" {
+		if !registryUsername.MatchString(instance.Username) {
+			h.errorResponse(w, http.StatusBadRequest, "Username must be 1-64 letters, digits, dots, dashes or underscores")
+			return
+		}
+		if len(instance.Password) < auth.MinPasswordLength {
+			h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Password must be at least %d characters", auth.MinPasswordLength))
+			return
+		}
+		hash, err := registry.HashRegistryPassword(instance.Password)
+		if err != nil {
+			h.errorResponse(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		instance.PasswordHash = hash
+	}
+	instance.Storage.ID = 0
+	if instance.Storage.Type == "" {
+		instance.Storage.Type = "local"
+	}
+	if instance.Storage.Type == "local" && instance.Storage.LocalPath == "" {
+		instance.Storage.LocalPath = "/var/lib/registry"
+	}
+
+	// Refuse storage settings the registry would not start with
+	check, err := h.fleet.Validate(&instance)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to validate registry config: %v", err))
+		return
+	}
+	if !check.Valid {
+		h.jsonResponse(w, http.StatusBadRequest, models.APIResponse{
+			Success: false,
+			Data:    check,
+			Error:   h.tr(w, "Registry config is invalid: %s", strings.Join(check.Problems, "; ")),
+		})
+		return
+	}
+
+	instance.Enabled = true
+	if err := h.db.CreateManagedRegistry(&instance); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Register it so its repositories show up like any other registry's
+	entry := &models.Registry{
+		Name:     "Local Registry " + instance.Name,
+		URL:      fmt.Sprintf("http://localhost:%d", instance.Port),
+		Username: instance.Username,
+		Password: instance.Password,
+	}
+	if err := h.db.CreateRegistry(entry); err != nil {
+		log.Printf("⚠️  Could not auto-register registry %s: %v", instance.Name, err)
+	} else if err := h.db.SetManagedRegistryEntry(instance.ID, entry.ID); err == nil {
+		instance.RegistryID = entry.ID
+	}
+	instance.Password = ""
+	h.audit(r, "registry_instance.create", instance.Name, fmt.Sprintf("port %d, %s storage", instance.Port, instance.Storage.Type))
+	go h.refreshDashboardStats()
+
+	message := h.tr(w, "Registry created and started")
+	if err := h.fleet.Start(&instance); err != nil {
+		log.Printf("⚠️  %v", err)
+		message = h.tr(w, "Registry created, but it failed to start: %v", err)
+	} else if reg := h.fleet.Registry(instance.ID); reg != nil {
+		instance.Status = reg.Status()
+	}
+	h.jsonResponse(w, http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    instance,
+		Message: message,
+	})
+}
+
+// DeleteRegistryInstance stops an additional embedded registry and removes it with its registry
+// entry. Its data directory is kept.
+func (h *Handler) DeleteRegistryInstance(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	instance, ok := h.registryInstance(w, r)
+	if !ok {
+		return
+	}
+	if h.fleet != nil {
+		if err := h.fleet.Remove(instance); err != nil {
+			log.Printf("⚠️  Failed to remove the config of registry %s: %v", instance.Name, err)
+		}
+	}
+	if err := h.db.DeleteManagedRegistry(instance.ID); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if instance.RegistryID != 0 {
+		if err := h.db.DeleteRegistry(instance.RegistryID); err != nil {
+			log.Printf("⚠️  Failed to remove the registry entry of %s: %v", instance.Name, err)
+		}
+		go h.refreshDashboardStats()
+	}
+	h.audit(r, "registry_instance.delete", instance.Name, "")
+	h.messageResponse(w, "Registry removed. Its data directory was kept.")
+}
+
+// StartRegistryInstance starts an additional embedded registry, which then starts with the dashboard again (admin only)
+func (h *Handler) StartRegistryInstance(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	instance, ok := h.registryInstance(w, r)
+	if !ok {
+		return
+	}
+	if h.fleet == nil {
+		h.errorResponse(w, http.StatusServiceUnavailable, "Embedded registry is not available")
+		return
+	}
+	if err := h.db.SetManagedRegistryEnabled(instance.ID, true); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := h.fleet.Start(instance); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to start registry: %v", err))
+		return
+	}
+	h.messageResponse(w, "Registry started successfully")
+}
+
+// StopRegistryInstance stops an additional embedded registry until it is started again (admin only)
+func (h *Handler) StopRegistryInstance(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	instance, ok := h.registryInstance(w, r)
+	if !ok {
+		return
+	}
+	if err := h.db.SetManagedRegistryEnabled(instance.ID, false); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if h.fleet != nil {
+		h.fleet.Stop(instance.ID)
+	}
+	h.messageResponse(w, "Registry stopped")
+}
+
+// registryInstance loads the additional embedded registry of the request's path, writing the
+// error response when there is none
+func (h *Handler) registryInstance(w http.ResponseWriter, r *http.Request) (*models.ManagedRegistry, bool) {
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return nil, false
+	}
+	instance, err := h.db.GetManagedRegistry(id)
+	if err == sql.ErrNoRows {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return nil, false
+	}
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return nil, false
+	}
+	return instance, true
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"docker-registry-dashboard/internal/registry"
+)
+
+const (
+	defaultLayerFiles = 5000
+	maxLayerFiles     = 50000
+)
+
+// ListLayerFiles lists the files inside a layer (?repo=&digest=&path=&limit=)
+func (h *Handler) ListLayerFiles(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+
+	q := r.URL.Query()
+	repoName := q.Get("repo")
+	digest := q.Get("digest")
+	if repoName == "" || !strings.HasPrefix(digest, "sha256:") {
+		h.errorResponse(w, http.StatusBadRequest, "Repository name and layer digest (sha256:...) are required")
+		return
+	}
+
+	limit := defaultLayerFiles
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = min(n, maxLayerFiles)
+	}
+
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+
+	client := registry.NewClientFromRegistry(reg)
+	listing, err := client.ListLayerFiles(r.Context(), repoName, digest, q.Get("path"), limit)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Failed to list layer files: %v", err))
+		return
+	}
+
+	h.successResponse(w, listing)
+}
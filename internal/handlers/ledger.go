@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"docker-registry-dashboard/internal/database"
+	"docker-registry-dashboard/internal/models"
+)
+
+// recordDeletion appends an image deleted by the current caller to the deleted-image ledger
+func (h *Handler) recordDeletion(r *http.Request, reg *models.Registry, d models.DeletedImage) {
+	d.RegistryID, d.RegistryName = reg.ID, reg.Name
+	d.DeletedBy, d.RemoteAddr = requestActor(r)
+	if err := h.db.AddDeletedImage(&d); err != nil {
+		log.Printf("⚠️ Failed to record deletion of %s@%s: %v", d.Repository, d.Digest, err)
+	}
+}
+
+// ListDeletedImages queries the deleted-image ledger (?digest=&registry_id=&repository=&limit=)
+func (h *Handler) ListDeletedImages(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := database.DeletedImageFilter{
+		Digest:     q.Get("digest"),
+		Repository: q.Get("repository"),
+		Limit:      100,
+	}
+
+	if v := q.Get("registry_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid registry_id")
+			return
+		}
+		filter.RegistryID = id
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		filter.Limit = n
+	}
+
+	entries, err := h.db.ListDeletedImages(filter)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	access, err := h.projectAccess(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if access != nil {
+		entries = slices.DeleteFunc(entries, func(d models.DeletedImage) bool { return access.repositoryRole(d.RegistryID, d.Repository) == "" })
+	}
+	h.successResponse(w, entries)
+}
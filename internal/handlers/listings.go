@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/registry"
+)
+
+// defaultCatalogCacheTTL is how long a registry's repository and tag lists are reused
+const defaultCatalogCacheTTL = time.Minute
+
+// listingGenerationTTL outlives every cached listing, so an expired generation cannot revive stale entries
+const listingGenerationTTL = 24 * time.Hour
+
+// digestWorkers bounds the digest requests a tag listing sends to a registry at once
+const digestWorkers = 8
+
+// SetCatalogCacheTTL sets how long repository and tag lists are cached; 0 disables the cache
+func (h *Handler) SetCatalogCacheTTL(ttl time.Duration) {
+	h.catalogTTL = ttl
+}
+
+// listingGeneration returns the cache generation of a registry's listings. Every cached
+// catalog and tag list key includes it, so bumping it drops them all at once.
+func (h *Handler) listingGeneration(registryID int64) string {
+	if data, ok := h.cache.Get(fmt.Sprintf("listings:%d", registryID)); ok {
+		return string(data)
+	}
+	return "0"
+}
+
+// invalidateListings drops the cached catalog and tag lists of a registry after it changed
+func (h *Handler) invalidateListings(registryID int64) {
+	gen := strconv.FormatInt(time.Now().UnixNano(), 10)
+	h.cache.Set(fmt.Sprintf("listings:%d", registryID), []byte(gen), listingGenerationTTL)
+}
+
+// cachedListing loads a cached listing into v unless refresh is set; it reports whether it did
+func (h *Handler) cachedListing(key string, refresh bool, v interface{}) bool {
+	if refresh || h.catalogTTL <= 0 {
+		return false
+	}
+	data, ok := h.cache.Get(key)
+	return ok && json.Unmarshal(data, v) == nil
+}
+
+func (h *Handler) storeListing(key string, v interface{}) {
+	if h.catalogTTL <= 0 {
+		return
+	}
+	if data, err := json.Marshal(v); err == nil {
+		h.cache.Set(key, data, h.catalogTTL)
+	}
+}
+
+// listRepositoriesCached returns a registry's catalog, shared through the cache
+func (h *Handler) listRepositoriesCached(ctx context.Context, reg *models.Registry, client *registry.Client, refresh bool) ([]models.Repository, error) {
+	key := fmt.Sprintf("catalog:%d:%s", reg.ID, h.listingGeneration(reg.ID))
+	var repos []models.Repository
+	if h.cachedListing(key, refresh, &repos) {
+		return repos, nil
+	}
+
+	repos, err := client.ListRepositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h.storeListing(key, repos)
+	return repos, nil
+}
+
+// listTagsCached returns the tags of a repository, shared through the cache
+func (h *Handler) listTagsCached(ctx context.Context, reg *models.Registry, client *registry.Client, repo string, refresh bool) ([]models.Tag, error) {
+	key := fmt.Sprintf("tags:%d:%s:%s", reg.ID, h.listingGeneration(reg.ID), repo)
+	var tags []models.Tag
+	if h.cachedListing(key, refresh, &tags) {
+		return tags, nil
+	}
+	tags, err := client.ListTags(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	h.storeListing(key, tags)
+	return tags, nil
+}
+
+// resolveDigestsCached fills in the digest of each tag, costing a request per tag not cached yet.
+// Up to digestWorkers requests run at once. Tags listed with their digest (Quay) are left as they are.
+func (h *Handler) resolveDigestsCached(ctx context.Context, reg *models.Registry, client *registry.Client, repo string, tags []models.Tag, refresh bool) {
+	gen := h.listingGeneration(reg.ID)
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, digestWorkers)
+	for i := range tags {
+		if tags[i].Digest != "" {
+			continue
+		}
+		key := fmt.Sprintf("digest:%d:%s:%s:%s", reg.ID, gen, repo, tags[i].Name)
+		if h.cachedListing(key, refresh, &tags[i].Digest) {
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(t *models.Tag, key string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if digest, err := client.GetDigestForTag(ctx, repo, t.Name); err == nil {
+				t.Digest = digest
+				h.storeListing(key, digest)
+			}
+		}(&tags[i], key)
+	}
+	wg.Wait()
+}
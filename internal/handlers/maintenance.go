@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// maintenanceRoutes change nothing or only the caller's session, so they stay open in maintenance
+// mode: checks, measurements, previews, the embedded registry's start and stop (which garbage collection
+// needs) and the switch itself
+var maintenanceRoutes = []string{
+	"POST /api/v1/auth/",
+	"DELETE /api/v1/auth/",
+	"PUT /api/v1/maintenance",
+	"POST /api/v1/registries/{id}/test",
+	"POST /api/v1/registries/{id}/certificate/check",
+	"POST /api/v1/registries/{id}/conformance",
+	"POST /api/v1/registries/{id}/credentials/rotation/validate",
+	"POST /api/v1/storage/test",
+	"POST /api/v1/quotas/{id}/check",
+	"POST /api/v1/registry/config/preview",
+	"POST /api/v1/registry/config/validate",
+	"POST /api/v1/registry/start",
+	"POST /api/v1/registry/stop",
+	"POST /api/v1/registry/restart",
+}
+
+// maintenanceAllowed matches maintenanceRoutes
+var maintenanceAllowed = func() *http.ServeMux {
+	mux := http.NewServeMux()
+	for _, pattern := range maintenanceRoutes {
+		mux.Handle(pattern, http.NotFoundHandler())
+	}
+	return mux
+}()
+
+// ReadOnly refuses the API requests that change something with a 503 while maintenance mode is
+// on. Reads, maintenanceRoutes and registry webhooks (which only record what the registry did)
+// still go through. When the mode cannot be read, those requests are refused as well.
+func (h *Handler) ReadOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !strings.HasPrefix(r.URL.Path, "/api/") || isWebhook(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if _, pattern := maintenanceAllowed.Handler(r); pattern != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// A change could land in the middle of maintenance when its state cannot be read
+		mode, err := h.db.GetMaintenanceMode()
+		if err != nil {
+			log.Printf("⚠️ Failed to read maintenance mode: %v", err)
+			h.errorResponse(w, http.StatusServiceUnavailable, "Maintenance mode could not be checked; try again later")
+			return
+		}
+		if !mode.Enabled {
+			next.ServeHTTP(w, r)
+			return
+		}
+		msg := h.tr(w, "The dashboard is in read-only maintenance mode")
+		if mode.Reason != "" {
+			msg += ": " + mode.Reason
+		}
+		w.Header().Set("X-Maintenance-Mode", "on")
+		h.jsonResponse(w, http.StatusServiceUnavailable, models.APIResponse{Success: false, Data: mode, Error: msg})
+	})
+}
+
+// GetMaintenanceMode returns whether the dashboard is in read-only maintenance mode
+func (h *Handler) GetMaintenanceMode(w http.ResponseWriter, r *http.Request) {
+	mode, err := h.db.GetMaintenanceMode()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.successResponse(w, mode)
+}
+
+// SetMaintenanceMode turns read-only maintenance mode on or off, e.g. around registry garbage
+// collection or a database migration
+func (h *Handler) SetMaintenanceMode(w http.ResponseWriter, r *http.Request) {
+	user := h.requireAdmin(w, r)
+	if user == nil {
+		return
+	}
+	var req struct {
+		Enabled bool   `json:"enabled"`
+		Reason  string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	mode := &models.MaintenanceMode{Enabled: req.Enabled}
+	if req.Enabled {
+		mode.Reason = strings.TrimSpace(req.Reason)
+		mode.StartedBy = user.Username
+	}
+	if err := h.db.SaveMaintenanceMode(mode); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if mode.Enabled {
+		log.Printf("🚧 Maintenance mode on (%s): %s", user.Username, mode.Reason)
+		h.audit(r, "maintenance.enable", "", mode.Reason)
+		h.jsonResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: mode, Message: h.tr(w, "Maintenance mode enabled")})
+		return
+	}
+	log.Printf("✅ Maintenance mode off (%s)", user.Username)
+	h.audit(r, "maintenance.disable", "", "")
+	h.jsonResponse(w, http.StatusOK, models.APIResponse{Success: true, Data: mode, Message: h.tr(w, "Maintenance mode disabled")})
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/registry"
+)
+
+// notationTrust loads the Notation trust policy and trust stores, which are read for every
+// verification so changes apply right away
+func (h *Handler) notationTrust() (*registry.NotationTrust, error) {
+	policy, err := h.db.GetNotationTrustPolicy()
+	if err != nil {
+		return nil, err
+	}
+	stores, err := h.db.ListNotationTrustStores()
+	if err != nil {
+		return nil, err
+	}
+	return registry.NewNotationTrust(policy, stores)
+}
+
+// VerifyNotationSignatures verifies the Notation signatures of the tags of a repository (?repo=,
+// or one tag with ?tag=) against the trust policy, giving each tag's status: verified, failed,
+// unsigned, skipped, no_policy or error
+func (h *Handler) VerifyNotationSignatures(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	repoName := r.URL.Query().Get("repo")
+	if repoName == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Repository name is required (query param: repo)")
+		return
+	}
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+	trust, err := h.notationTrust()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to load the Notation trust policy: %v", err))
+		return
+	}
+
+	client := registry.NewClientFromRegistry(reg)
+	var tags []string
+	if tag := r.URL.Query().Get("tag"); tag != "" {
+		tags = []string{tag}
+	} else {
+		all, err := h.listTagsCached(r.Context(), reg, client, repoName, false)
+		if err != nil {
+			h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Failed to list tags: %v", err))
+			return
+		}
+		for _, t := range all {
+			if !registry.IsCosignArtifactTag(t.Name) && !registry.IsReferrersTag(t.Name) {
+				tags = append(tags, t.Name)
+			}
+		}
+	}
+
+	results := make([]*models.NotationVerification, len(tags))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, trustConcurrency)
+	for i, tag := range tags {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, tag string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			digest, err := client.GetDigestForTag(r.Context(), repoName, tag)
+			if err != nil {
+				results[i] = &models.NotationVerification{Tag: tag, Status: models.NotationError, Signatures: []models.NotationSignature{}, Error: err.Error()}
+				return
+			}
+			results[i] = client.VerifyNotation(r.Context(), trust, repoName, digest)
+			results[i].Tag = tag
+		}(i, tag)
+	}
+	wg.Wait()
+	h.successResponse(w, results)
+}
+
+// --- Notation Trust Policy ---
+
+// GetNotationTrustPolicy returns the Notation trust policy (trustpolicy.json)
+func (h *Handler) GetNotationTrustPolicy(w http.ResponseWriter, r *http.Request) {
+	policy, err := h.db.GetNotationTrustPolicy()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.successResponse(w, policy)
+}
+
+// SaveNotationTrustPolicy replaces the Notation trust policy with a trustpolicy.json document (admin only)
+func (h *Handler) SaveNotationTrustPolicy(w http.ResponseWriter, r *http.Request) {
+	user := h.requireAdmin(w, r)
+	if user == nil {
+		return
+	}
+	var policy models.NotationTrustPolicyDocument
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if policy.TrustPolicies == nil {
+		policy.TrustPolicies = []models.NotationTrustPolicy{}
+	}
+	if err := registry.ValidateNotationTrustPolicy(&policy); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Invalid trust policy: %v", err))
+		return
+	}
+	if err := h.db.SaveNotationTrustPolicy(&policy, user.Username); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to save trust policy: %v", err))
+		return
+	}
+	h.audit(r, "notation.trust_policy", "trust policy", fmt.Sprintf("%d policies", len(policy.TrustPolicies)))
+	h.successResponse(w, policy)
+}
+
+// --- Notation Trust Stores ---
+
+// ListNotationTrustStores returns the Notation trust stores with the subjects of their certificates
+func (h *Handler) ListNotationTrustStores(w http.ResponseWriter, r *http.Request) {
+	stores, err := h.db.ListNotationTrustStores()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for i := range stores {
+		certs, _ := registry.ParseCertificates(stores[i].Certificates)
+		for _, cert := range certs {
+			stores[i].Subjects = append(stores[i].Subjects, cert.Subject.String())
+		}
+	}
+	h.successResponse(w, stores)
+}
+
+// CreateNotationTrustStore adds a trust store of PEM certificates (admin only)
+func (h *Handler) CreateNotationTrustStore(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	var store models.NotationTrustStore
+	if err := json.NewDecoder(r.Body).Decode(&store); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	store.ID = 0
+	h.saveNotationTrustStore(w, r, &store, "notation.trust_store.create")
+}
+
+// UpdateNotationTrustStore replaces the certificates of a trust store (admin only)
+func (h *Handler) UpdateNotationTrustStore(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid trust store ID")
+		return
+	}
+	var store models.NotationTrustStore
+	if err := json.NewDecoder(r.Body).Decode(&store); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	store.ID = id
+	h.saveNotationTrustStore(w, r, &store, "notation.trust_store.update")
+}
+
+func (h *Handler) saveNotationTrustStore(w http.ResponseWriter, r *http.Request, store *models.NotationTrustStore, action string) {
+	store.Name = strings.TrimSpace(store.Name)
+	if !slices.Contains(registry.NotationTrustStoreTypes, store.Type) {
+		h.errorResponse(w, http.StatusBadRequest, "Trust store type must be ca, signingAuthority or tsa")
+		return
+	}
+	if store.Name == "" || strings.ContainsAny(store.Name, ": ") {
+		h.errorResponse(w, http.StatusBadRequest, "Trust store name is required and cannot contain ':' or spaces")
+		return
+	}
+	certs, err := registry.ParseCertificates(store.Certificates)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Invalid certificates: %v", err))
+		return
+	}
+	store.Subjects = nil
+	for _, cert := range certs {
+		store.Subjects = append(store.Subjects, cert.Subject.String())
+	}
+
+	if err := h.db.SaveNotationTrustStore(store); err != nil {
+		if err == sql.ErrNoRows {
+			h.errorResponse(w, http.StatusNotFound, "Trust store not found")
+			return
+		}
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to save trust store: %v", err))
+		return
+	}
+	h.audit(r, action, store.Type+":"+store.Name, fmt.Sprintf("%d certificates", len(certs)))
+	h.successResponse(w, store)
+}
+
+// DeleteNotationTrustStore removes a trust store (admin only)
+func (h *Handler) DeleteNotationTrustStore(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid trust store ID")
+		return
+	}
+	store, err := h.db.GetNotationTrustStore(id)
+	if err == nil {
+		err = h.db.DeleteNotationTrustStore(id)
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.errorResponse(w, http.StatusNotFound, "Trust store not found")
+			return
+		}
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.audit(r, "notation.trust_store.delete", store.Type+":"+store.Name, "")
+	h.messageResponse(w, "Trust store deleted")
+}
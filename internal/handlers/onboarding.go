@@ -0,0 +1,285 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+func validScanMode(mode string) bool {
+	return mode == "" || mode == "always" || mode == "never"
+}
+
+func pathID(r *http.Request) (int64, error) {
+	return strconv.ParseInt(r.PathValue("id"), 10, 64)
+}
+
+// --- Onboarding Rules ---
+
+// ListOnboardingRules returns the onboarding rules; users that projects limit only see the rules
+// of the registries they see, not those applying to all registries
+func (h *Handler) ListOnboardingRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.db.ListOnboardingRules()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	access, err := h.projectAccess(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if access != nil {
+		rules = slices.DeleteFunc(rules, func(rule models.OnboardingRule) bool {
+			return rule.RegistryID == 0 || !access.seesRegistry(rule.RegistryID)
+		})
+	}
+	h.successResponse(w, rules)
+}
+
+// CreateOnboardingRule adds an onboarding rule (admin only)
+func (h *Handler) CreateOnboardingRule(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	var rule models.OnboardingRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	rule.ID = 0
+	h.saveOnboardingRule(w, &rule)
+}
+
+// UpdateOnboardingRule replaces an onboarding rule (admin only)
+func (h *Handler) UpdateOnboardingRule(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid rule ID")
+		return
+	}
+
+	var rule models.OnboardingRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	rule.ID = id
+	h.saveOnboardingRule(w, &rule)
+}
+
+func (h *Handler) saveOnboardingRule(w http.ResponseWriter, rule *models.OnboardingRule) {
+	if rule.Name == "" || rule.Pattern == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Name and pattern are required")
+		return
+	}
+	if _, err := regexp.Compile(rule.Pattern); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Invalid pattern: %v", err))
+		return
+	}
+	if !validScanMode(rule.ScanMode) {
+		h.errorResponse(w, http.StatusBadRequest, "scan_mode must be '', 'always' or 'never'")
+		return
+	}
+	if rule.RetentionTemplateID != 0 {
+		if _, err := h.db.GetRetentionTemplate(rule.RetentionTemplateID); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Retention template not found")
+			return
+		}
+	}
+
+	if err := h.db.SaveOnboardingRule(rule); err != nil {
+		if err == sql.ErrNoRows {
+			h.errorResponse(w, http.StatusNotFound, "Onboarding rule not found")
+			return
+		}
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to save onboarding rule: %v", err))
+		return
+	}
+	h.successResponse(w, rule)
+}
+
+// DeleteOnboardingRule removes an onboarding rule (admin only)
+func (h *Handler) DeleteOnboardingRule(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid rule ID")
+		return
+	}
+	if err := h.db.DeleteOnboardingRule(id); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.messageResponse(w, "Onboarding rule deleted")
+}
+
+// --- Retention Templates ---
+
+// ListRetentionTemplates returns all retention templates
+func (h *Handler) ListRetentionTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.db.ListRetentionTemplates()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.successResponse(w, templates)
+}
+
+// CreateRetentionTemplate adds a retention template (admin only)
+func (h *Handler) CreateRetentionTemplate(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	var t models.RetentionTemplate
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	t.ID = 0
+	h.saveRetentionTemplate(w, &t)
+}
+
+// UpdateRetentionTemplate replaces a retention template (admin only)
+func (h *Handler) UpdateRetentionTemplate(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid template ID")
+		return
+	}
+
+	var t models.RetentionTemplate
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	t.ID = id
+	h.saveRetentionTemplate(w, &t)
+}
+
+func (h *Handler) saveRetentionTemplate(w http.ResponseWriter, t *models.RetentionTemplate) {
+	if t.Name == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+	if t.ExcludeTags != "" {
+		if _, err := regexp.Compile(t.ExcludeTags); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Invalid exclude_tags: %v", err))
+			return
+		}
+	}
+
+	if err := h.db.SaveRetentionTemplate(t); err != nil {
+		if err == sql.ErrNoRows {
+			h.errorResponse(w, http.StatusNotFound, "Retention template not found")
+			return
+		}
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to save retention template: %v", err))
+		return
+	}
+	h.successResponse(w, t)
+}
+
+// DeleteRetentionTemplate removes a retention template (admin only)
+func (h *Handler) DeleteRetentionTemplate(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid template ID")
+		return
+	}
+	if err := h.db.DeleteRetentionTemplate(id); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.messageResponse(w, "Retention template deleted")
+}
+
+// --- Repository Metadata ---
+
+// GetRepositoryInfo returns owner, labels and policy assignments of a repository (?repo=)
+func (h *Handler) GetRepositoryInfo(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	repoName := r.URL.Query().Get("repo")
+	if repoName == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Repository name is required")
+		return
+	}
+
+	info, err := h.db.GetRepositoryInfo(id, repoName)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.successResponse(w, info)
+}
+
+// SaveRepositoryInfo updates owner, labels and policy assignments of a repository (?repo=)
+func (h *Handler) SaveRepositoryInfo(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	repoName := r.URL.Query().Get("repo")
+	if repoName == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Repository name is required")
+		return
+	}
+
+	current, err := h.db.GetRepositoryInfo(id, repoName)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	var info models.RepositoryInfo
+	if err := json.NewDecoder(r.Body).Decode(&info); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !validScanMode(info.ScanMode) {
+		h.errorResponse(w, http.StatusBadRequest, "scan_mode must be '', 'always' or 'never'")
+		return
+	}
+	if info.RetentionTemplateID != 0 {
+		if _, err := h.db.GetRetentionTemplate(info.RetentionTemplateID); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Retention template not found")
+			return
+		}
+	}
+	info.RegistryID = id
+	info.Name = repoName
+	info.OnboardingRuleID = current.OnboardingRuleID
+
+	if err := h.db.SaveRepositoryInfo(&info); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to save repository info: %v", err))
+		return
+	}
+
+	saved, err := h.db.GetRepositoryInfo(id, repoName)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.successResponse(w, saved)
+}
@@ -0,0 +1,331 @@
+package handlers
+
+//go:generate go run ./routegen
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// apiRoute describes an API endpoint for the OpenAPI document; the list in routes_gen.go is
+// generated from main.go and the handlers by routegen
+type apiRoute struct {
+	Method   string
+	Pattern  string
+	Handler  string
+	Group    string
+	Doc      string
+	Query    []string
+	HasBody  bool
+	Body     interface{} // Zero value of the JSON request body, when its type is known
+	Produces []string    // Content types of documents written instead of (or besides) the JSON envelope
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// pageQuery are the query parameters of paginated listings, see parsePageRequest
+var pageQuery = map[string]string{
+	"page":      "Page number, from 1",
+	"page_size": "Items per page",
+	"sort":      "Sort field, prefixed with - for descending order",
+	"q":         "Case-insensitive filter",
+}
+
+// GetOpenAPISpec serves an OpenAPI 3 description of the dashboard API, for Swagger UI and client generators
+func (h *Handler) GetOpenAPISpec(w http.ResponseWriter, r *http.Request) {
+	h.jsonResponse(w, http.StatusOK, openAPISpec(requestBaseURL(r)))
+}
+
+// GetAPIClient serves a JavaScript client with one method per API operation, generated from the same routes
+func (h *Handler) GetAPIClient(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/javascript; charset=utf-8")
+	w.Write([]byte(apiClientJS()))
+}
+
+// openAPISpec builds the OpenAPI document of apiRoutes
+func openAPISpec(server string) map[string]interface{} {
+	schemas := map[string]interface{}{
+		"APIResponse": map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"success":    map[string]interface{}{"type": "boolean"},
+				"data":       map[string]interface{}{"description": "Result of the operation"},
+				"message":    map[string]interface{}{"type": "string"},
+				"error":      map[string]interface{}{"type": "string"},
+				"pagination": map[string]interface{}{"type": "object", "description": "Present on paginated listings"},
+			},
+			"required": []string{"success"},
+		},
+	}
+	envelope := map[string]interface{}{
+		"description": "API response envelope",
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": map[string]interface{}{"$ref": "#/components/schemas/APIResponse"}},
+		},
+	}
+
+	paths := map[string]map[string]interface{}{}
+	var groups []string
+	seenGroup := map[string]bool{}
+	for _, rt := range apiRoutes {
+		if !seenGroup[rt.Group] {
+			seenGroup[rt.Group] = true
+			groups = append(groups, rt.Group)
+		}
+
+		params := []interface{}{}
+		for _, m := range pathParam.FindAllStringSubmatch(rt.Pattern, -1) {
+			params = append(params, map[string]interface{}{"name": m[1], "in": "path", "required": true, "schema": map[string]interface{}{"type": "string"}})
+		}
+		for _, q := range rt.Query {
+			param := map[string]interface{}{"name": q, "in": "query", "schema": map[string]interface{}{"type": "string"}}
+			if d, ok := pageQuery[q]; ok {
+				param["description"] = d
+			}
+			params = append(params, param)
+		}
+
+		summary, description := splitDoc(rt.Handler, rt.Doc)
+		op := map[string]interface{}{
+			"operationId": lowerFirst(rt.Handler),
+			"tags":        []string{rt.Group},
+			"summary":     summary,
+			"parameters":  params,
+			"responses":   map[string]interface{}{"200": envelope, "default": envelope},
+		}
+		if description != "" {
+			op["description"] = description
+		}
+		if len(rt.Produces) > 0 {
+			content := map[string]interface{}{}
+			for _, ct := range rt.Produces {
+				content[ct] = map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}
+				if ct == "application/json" {
+					content[ct] = envelope["content"].(map[string]interface{})[ct]
+				}
+			}
+			op["responses"] = map[string]interface{}{"200": map[string]interface{}{"description": "Document", "content": content}, "default": envelope}
+		}
+		if rt.HasBody {
+			schema := map[string]interface{}{"type": "object"}
+			if rt.Body != nil {
+				schema = jsonSchema(reflect.TypeOf(rt.Body), schemas)
+			}
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}},
+			}
+		}
+
+		path := rt.Pattern
+		if paths[path] == nil {
+			paths[path] = map[string]interface{}{}
+		}
+		paths[path][strings.ToLower(rt.Method)] = op
+	}
+
+	tags := make([]interface{}, len(groups))
+	for i, g := range groups {
+		tags[i] = map[string]interface{}{"name": g}
+	}
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Docker Registry Dashboard API",
+			"description": "Responses are wrapped in an envelope with success, data, message and error. When the dashboard requires authentication, send an API token or session token as a Bearer token.",
+			"version":     strconv.Itoa(APIVersion) + ".0",
+		},
+		"servers": []interface{}{map[string]interface{}{"url": server}},
+		"tags":    tags,
+		"paths":   paths,
+		"components": map[string]interface{}{
+			"schemas": schemas,
+			"securitySchemes": map[string]interface{}{
+				"bearer":  map[string]interface{}{"type": "http", "scheme": "bearer"},
+				"session": map[string]interface{}{"type": "apiKey", "in": "cookie", "name": sessionCookie},
+			},
+		},
+		"security": []interface{}{
+			map[string]interface{}{"bearer": []string{}},
+			map[string]interface{}{"session": []string{}},
+		},
+	}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// jsonSchema describes how encoding/json encodes a Go type; named structs are added to schemas
+// and referenced
+func jsonSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case t.Kind() == reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return map[string]interface{}{"type": "string", "format": "byte"}
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return map[string]interface{}{"type": "array", "items": jsonSchema(t.Elem(), schemas)}
+	case t.Kind() == reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": jsonSchema(t.Elem(), schemas)}
+	case t.Kind() != reflect.Struct:
+		return map[string]interface{}{}
+	}
+
+	if t.Name() != "" {
+		ref := map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+		if _, ok := schemas[t.Name()]; ok {
+			return ref
+		}
+		schemas[t.Name()] = nil // Placeholder for recursive types
+		schemas[t.Name()] = structSchema(t, schemas)
+		return ref
+	}
+	return structSchema(t, schemas)
+}
+
+func structSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	props := map[string]interface{}{}
+	var addFields func(t reflect.Type)
+	addFields = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if tag == "-" || (!f.IsExported() && !f.Anonymous) {
+				continue
+			}
+			name, _, _ := strings.Cut(tag, ",")
+			if f.Anonymous && name == "" {
+				ft := f.Type
+				for ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+				if ft.Kind() == reflect.Struct {
+					addFields(ft)
+					continue
+				}
+			}
+			if name == "" {
+				name = f.Name
+			}
+			props[name] = jsonSchema(f.Type, schemas)
+		}
+	}
+	addFields(t)
+	return map[string]interface{}{"type": "object", "properties": props}
+}
+
+// splitDoc turns a handler doc comment into an operation summary (its first sentence, without the
+// handler name) and description (the rest)
+func splitDoc(handler, doc string) (string, string) {
+	if doc == "" {
+		return handler, ""
+	}
+	doc = strings.TrimSpace(strings.TrimPrefix(doc, handler))
+	summary, rest := doc, ""
+	if i := strings.Index(doc, ". "); i >= 0 {
+		summary, rest = doc[:i], strings.TrimSpace(doc[i+2:])
+	} else if i := strings.Index(doc, "\n\n"); i >= 0 {
+		summary, rest = doc[:i], strings.TrimSpace(doc[i+2:])
+	}
+	summary = strings.Join(strings.Fields(strings.TrimSuffix(summary, ".")), " ")
+	return upperFirst(summary), strings.Join(strings.Fields(rest), " ")
+}
+
+func upperFirst(s string) string {
+	for i, c := range s {
+		return string(unicode.ToUpper(c)) + s[i+len(string(c)):]
+	}
+	return s
+}
+
+func lowerFirst(s string) string {
+	for i, c := range s {
+		return string(unicode.ToLower(c)) + s[i+len(string(c)):]
+	}
+	return s
+}
+
+// apiClientJS generates a dependency-free JavaScript client: new DashboardClient(baseURL, token)
+// with a promise-returning method per operation, named like its operationId. Path parameters come
+// first, then an object of query parameters, then the request body.
+func apiClientJS() string {
+	var b strings.Builder
+	b.WriteString(`// Docker Registry Dashboard API client, generated from the dashboard's route metadata.
+// Usage: const api = new DashboardClient('https://dashboard.example.com', 'rdt_...');
+//        const regs = await api.listRegistries();
+class DashboardClient {
+    constructor(baseURL = '', token = '') {
+        this.baseURL = baseURL.replace(/\/+$/, '');
+        this.token = token;
+    }
+
+    async request(method, path, query, body) {
+        const params = new URLSearchParams();
+        for (const [k, v] of Object.entries(query || {})) if (v !== undefined && v !== null && v !== '') params.append(k, v);
+        const headers = {};
+        if (this.token) headers['Authorization'] = 'Bearer ' + this.token;
+        if (body !== undefined) headers['Content-Type'] = 'application/json';
+        const res = await fetch(this.baseURL + path + (params.toString() ? '?' + params : ''), {
+            method, headers, credentials: 'same-origin', body: body !== undefined ? JSON.stringify(body) : undefined,
+        });
+        if (!(res.headers.get('Content-Type') || '').startsWith('application/json')) {
+            if (!res.ok) throw new Error(method + ' ' + path + ' returned ' + res.status);
+            return res;
+        }
+        const data = await res.json();
+        if (!res.ok || data.success === false) throw new Error(data.error || method + ' ' + path + ' returned ' + res.status);
+        return data;
+    }
+`)
+	routes := append([]apiRoute(nil), apiRoutes...)
+	sort.SliceStable(routes, func(i, j int) bool { return routes[i].Handler < routes[j].Handler })
+	for _, rt := range routes {
+		var args []string
+		path := "`" + pathParam.ReplaceAllStringFunc(rt.Pattern, func(m string) string {
+			name := jsIdent(m[1 : len(m)-1])
+			args = append(args, name)
+			return "${encodeURIComponent(" + name + ")}"
+		}) + "`"
+		query, body := "undefined", "undefined"
+		if len(rt.Query) > 0 {
+			args = append(args, "query = {}")
+			query = "query"
+		}
+		if rt.HasBody {
+			args = append(args, "body = {}")
+			body = "body"
+		}
+		summary, _ := splitDoc(rt.Handler, rt.Doc)
+		fmt.Fprintf(&b, "\n    /** %s (%s %s) */\n", strings.ReplaceAll(summary, "*/", "* /"), rt.Method, rt.Pattern)
+		fmt.Fprintf(&b, "    %s(%s) { return this.request('%s', %s, %s, %s); }\n", lowerFirst(rt.Handler), strings.Join(args, ", "), rt.Method, path, query, body)
+	}
+	b.WriteString("}\n\nif (typeof module !== 'undefined') module.exports = { DashboardClient };\n")
+	return b.String()
+}
+
+// jsIdent turns a path parameter into a JavaScript identifier
+func jsIdent(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
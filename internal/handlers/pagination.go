@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// maxPageSize caps the page_size of paginated listings
+const maxPageSize = 500
+
+// pageRequest holds the page, page_size, sort and q parameters of a listing
+type pageRequest struct {
+	Page     int
+	PageSize int // 0 = everything on one page
+	Sort     string
+	Desc     bool // sort=-field sorts descending
+	Query    string
+}
+
+// parsePageRequest reads the listing parameters; sort must be one of sortFields (the first is the
+// default). On invalid input it writes the error response and returns false.
+func (h *Handler) parsePageRequest(w http.ResponseWriter, r *http.Request, sortFields ...string) (*pageRequest, bool) {
+	q := r.URL.Query()
+	p := &pageRequest{Page: 1, Sort: sortFields[0], Query: strings.ToLower(strings.TrimSpace(q.Get("q")))}
+
+	if v := q.Get("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid page")
+			return nil, false
+		}
+		p.Page = n
+	}
+	if v := q.Get("page_size"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxPageSize {
+			h.errorResponse(w, http.StatusBadRequest, h.tr(w, "page_size must be between 1 and %d", maxPageSize))
+			return nil, false
+		}
+		p.PageSize = n
+	} else if q.Get("page") != "" {
+		p.PageSize = 50
+	}
+
+	if v := q.Get("sort"); v != "" {
+		p.Desc = strings.HasPrefix(v, "-")
+		p.Sort = strings.TrimPrefix(v, "-")
+		valid := false
+		for _, f := range sortFields {
+			valid = valid || f == p.Sort
+		}
+		if !valid {
+			h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Invalid sort field %q (use %s)", p.Sort, strings.Join(sortFields, ", ")))
+			return nil, false
+		}
+	}
+	return p, true
+}
+
+// matches reports whether a name passes the q filter (case-insensitive substring)
+func (p *pageRequest) matches(name string) bool {
+	return p.Query == "" || strings.Contains(strings.ToLower(name), p.Query)
+}
+
+// less orders two items by a sort key, honoring the direction and falling back to the name
+func (p *pageRequest) less(a, b int64, nameA, nameB string) bool {
+	if a != b {
+		return (a < b) != p.Desc
+	}
+	return (nameA < nameB) != (p.Desc && p.Sort == "name")
+}
+
+// bounds returns the slice bounds of the requested page within total items and its description
+func (p *pageRequest) bounds(total int) (int, int, *models.Pagination) {
+	size := p.PageSize
+	if size == 0 {
+		size = total
+	}
+	meta := &models.Pagination{Page: p.Page, PageSize: size, Total: total, TotalPages: 1}
+	if size > 0 {
+		meta.TotalPages = max((total+size-1)/size, 1)
+	}
+	start := min((p.Page-1)*size, total)
+	end := min(start+size, total)
+	return start, end, meta
+}
+
+// pageResponse sends one page of a listing with its pagination metadata
+func (h *Handler) pageResponse(w http.ResponseWriter, data interface{}, meta *models.Pagination) {
+	h.jsonResponse(w, http.StatusOK, models.APIResponse{
+		Success:    true,
+		Data:       data,
+		Pagination: meta,
+	})
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/registry"
+)
+
+// --- Tag Pins ---
+
+// ListTagPins returns the pinned tags of a registry with their drift state
+func (h *Handler) ListTagPins(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+
+	pins, err := h.db.ListTagPins(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.successResponse(w, pins)
+}
+
+// PinTag pins a tag to the digest it points at now. Pinning an already pinned tag accepts
+// its current digest and clears the drift.
+func (h *Handler) PinTag(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+
+	var pin models.TagPin
+	if err := json.NewDecoder(r.Body).Decode(&pin); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if pin.Repository == "" || pin.Tag == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Repository name and tag are required")
+		return
+	}
+	if !h.allowRepository(w, r, id, pin.Repository, true) {
+		return
+	}
+
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+	client := registry.NewClientFromRegistry(reg)
+	digest, err := client.GetDigestForTag(r.Context(), pin.Repository, pin.Tag)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Failed to get digest: %v", err))
+		return
+	}
+
+	pin.RegistryID = id
+	pin.Digest = digest
+	pin.PinnedBy, _ = requestActor(r)
+	pin.PinnedAt = time.Now()
+	pin.LastCheckedAt = nil
+	if err := h.db.SaveTagPin(&pin); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to save tag pin: %v", err))
+		return
+	}
+	h.successResponse(w, pin)
+}
+
+// DeleteTagPin unpins a tag
+func (h *Handler) DeleteTagPin(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid pin ID")
+		return
+	}
+	pin, err := h.db.GetTagPin(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Tag pin not found")
+		return
+	}
+	if !h.allowRepository(w, r, pin.RegistryID, pin.Repository, true) {
+		return
+	}
+	if err := h.db.DeleteTagPin(id); err != nil {
+		if err == sql.ErrNoRows {
+			h.errorResponse(w, http.StatusNotFound, "Tag pin not found")
+			return
+		}
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.messageResponse(w, "Tag unpinned")
+}
+
+// ListTagDrifts returns the drifts of pinned tags found by catalog sync (?limit=, default 100)
+func (h *Handler) ListTagDrifts(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = n
+	}
+
+	drifts, err := h.db.ListTagDrifts(id, limit)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.successResponse(w, drifts)
+}
@@ -0,0 +1,475 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"docker-registry-dashboard/internal/auth"
+	"docker-registry-dashboard/internal/database"
+	"docker-registry-dashboard/internal/models"
+)
+
+// Project roles, from least to most privileged
+const (
+	roleViewer     = "viewer"
+	roleMaintainer = "maintainer"
+)
+
+// roleRank orders the project roles; an unknown role grants nothing
+func roleRank(role string) int {
+	switch role {
+	case roleViewer:
+		return 1
+	case roleMaintainer:
+		return 2
+	}
+	return 0
+}
+
+// higherRole returns the more privileged of two roles
+func higherRole(a, b string) string {
+	if roleRank(b) > roleRank(a) {
+		return b
+	}
+	return a
+}
+
+// projectAccess is what a user may see and change through the projects of their teams
+type projectAccess struct {
+	registries   map[int64]string // Role on whole registries
+	repositories []repositoryGrant
+}
+
+type repositoryGrant struct {
+	registryID int64
+	pattern    string
+	role       string
+}
+
+// registryRole returns the role on a whole registry, "" when there is none
+func (a *projectAccess) registryRole(id int64) string {
+	return a.registries[id]
+}
+
+// seesRegistry reports whether any project of the user holds the registry or a repository of it
+func (a *projectAccess) seesRegistry(id int64) bool {
+	if a.registries[id] != "" {
+		return true
+	}
+	return slices.ContainsFunc(a.repositories, func(g repositoryGrant) bool { return g.registryID == id })
+}
+
+// repositoryRole returns the highest role on a repository, "" when the user may not see it
+func (a *projectAccess) repositoryRole(id int64, repo string) string {
+	role := a.registries[id]
+	for _, g := range a.repositories {
+		if g.registryID == id && models.MatchRepository(g.pattern, repo) {
+			role = higherRole(role, g.role)
+		}
+	}
+	return role
+}
+
+// projectAccess returns what the caller may see and change, nil when nothing is hidden from them:
+// for admins, anonymous callers of a dashboard without authentication, and as long as there are
+// no projects. Anonymous callers of a dashboard that requires authentication see nothing.
+func (h *Handler) projectAccess(r *http.Request) (*projectAccess, error) {
+	id := auth.FromContext(r.Context())
+	if id == nil && h.authRequired {
+		return &projectAccess{registries: make(map[int64]string)}, nil
+	}
+	if id == nil || id.User.Role == "admin" {
+		return nil, nil
+	}
+	projects, err := h.db.ListProjects()
+	if err != nil || len(projects) == 0 {
+		return nil, err
+	}
+	roles, err := h.projectRoles(id.User.ID)
+	if err != nil {
+		return nil, err
+	}
+	access := &projectAccess{registries: make(map[int64]string)}
+	for _, p := range projects {
+		role := roles[p.ID]
+		if role == "" {
+			continue
+		}
+		for _, regID := range p.Registries {
+			access.registries[regID] = higherRole(access.registries[regID], role)
+		}
+		for _, repo := range p.Repositories {
+			access.repositories = append(access.repositories, repositoryGrant{registryID: repo.RegistryID, pattern: repo.Repository, role: role})
+		}
+	}
+	return access, nil
+}
+
+// projectRoles returns a user's highest role in each project one of their teams has a role in
+func (h *Handler) projectRoles(userID int64) (map[int64]string, error) {
+	teams, err := h.db.ListTeams()
+	if err != nil {
+		return nil, err
+	}
+	roles := make(map[int64]string)
+	for _, t := range teams {
+		if !slices.Contains(t.Members, userID) {
+			continue
+		}
+		for _, tp := range t.Projects {
+			roles[tp.ProjectID] = higherRole(roles[tp.ProjectID], tp.Role)
+		}
+	}
+	return roles, nil
+}
+
+// allowRepository checks that the caller may see a repository, or with write change it, writing
+// the error response when they may not
+func (h *Handler) allowRepository(w http.ResponseWriter, r *http.Request, registryID int64, repo string, write bool) bool {
+	access, err := h.projectAccess(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return false
+	}
+	if access == nil {
+		return true
+	}
+	role := access.repositoryRole(registryID, repo)
+	if role == "" {
+		h.errorResponse(w, http.StatusNotFound, "Repository not found")
+		return false
+	}
+	if write && role != roleMaintainer {
+		h.errorResponse(w, http.StatusForbidden, "Maintainer role required in the project")
+		return false
+	}
+	return true
+}
+
+// scopeFindings limits a findings filter to the scanned repositories the caller may see, writing
+// the error response when they see nothing of the registry
+func (h *Handler) scopeFindings(w http.ResponseWriter, r *http.Request, f *database.FindingFilter) bool {
+	access, err := h.projectAccess(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return false
+	}
+	if access == nil || access.registryRole(f.RegistryID) != "" {
+		return true
+	}
+	if !access.seesRegistry(f.RegistryID) {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return false
+	}
+	repos, err := h.db.ScannedRepositories(f.RegistryID)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return false
+	}
+	f.Repositories = slices.DeleteFunc(repos, func(repo string) bool { return access.repositoryRole(f.RegistryID, repo) == "" })
+	if f.Repositories == nil {
+		f.Repositories = []string{}
+	}
+	return true
+}
+
+// projectRepositoryRoutes act on repositories named in the request body; the handlers check the
+// role on each of them, so ScopeProjects lets them through for anyone who sees the registry
+var projectRepositoryRoutes = func() *http.ServeMux {
+	mux := http.NewServeMux()
+	for _, pattern := range []string{
+		"POST /api/v1/registries/{id}/retag",
+		"POST /api/v1/registries/{id}/repository/rename",
+		"POST /api/v1/registries/{id}/pins",
+		"POST /api/v1/registries/{id}/export/oci",
+		"POST /api/v1/registries/{id}/import",
+	} {
+		mux.Handle(pattern, http.NotFoundHandler())
+	}
+	return mux
+}()
+
+// ScopeProjects limits the requests for a registry to the callers whose projects hold it. Reads
+// need any role; changes need the maintainer role on the repository of the ?repo= parameter, or
+// else on the whole registry. Registries a caller has no project for do not exist for them.
+func (h *Handler) ScopeProjects(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rest, ok := strings.CutPrefix(r.URL.Path, "/api/v1/registries/")
+		if !ok || isWebhook(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		idPart, _, _ := strings.Cut(rest, "/")
+		id, err := strconv.ParseInt(idPart, 10, 64)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		access, err := h.projectAccess(r)
+		if err != nil {
+			h.errorResponse(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		if access == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		write := r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodOptions
+		repo := r.URL.Query().Get("repo")
+		_, repoRoute := projectRepositoryRoutes.Handler(r)
+		switch {
+		case !access.seesRegistry(id):
+			h.errorResponse(w, http.StatusNotFound, "Registry not found")
+			return
+		case repo != "":
+			if !h.allowRepository(w, r, id, repo, write) {
+				return
+			}
+		case !write:
+		case repoRoute != "":
+		case access.registryRole(id) != roleMaintainer:
+			h.errorResponse(w, http.StatusForbidden, "Maintainer role required in the project")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// --- Projects ---
+
+// ListProjects returns all projects to admins, and to other users the projects their teams have
+// a role in, with that role
+func (h *Handler) ListProjects(w http.ResponseWriter, r *http.Request) {
+	projects, err := h.db.ListProjects()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	id := auth.FromContext(r.Context())
+	if id == nil || id.User.Role == "admin" {
+		h.successResponse(w, projects)
+		return
+	}
+	roles, err := h.projectRoles(id.User.ID)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	mine := []models.Project{}
+	for _, p := range projects {
+		if p.Role = roles[p.ID]; p.Role != "" {
+			mine = append(mine, p)
+		}
+	}
+	h.successResponse(w, mine)
+}
+
+// CreateProject adds a project (admin only)
+func (h *Handler) CreateProject(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	var p models.Project
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	p.ID = 0
+	h.saveProject(w, r, &p, "project.create")
+}
+
+// UpdateProject replaces a project (admin only)
+func (h *Handler) UpdateProject(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+	var p models.Project
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	p.ID = id
+	h.saveProject(w, r, &p, "project.update")
+}
+
+func (h *Handler) saveProject(w http.ResponseWriter, r *http.Request, p *models.Project, action string) {
+	p.Name = strings.TrimSpace(p.Name)
+	p.Role = ""
+	if p.Name == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+	for _, regID := range p.Registries {
+		if _, err := h.db.GetRegistry(regID); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Registry not found")
+			return
+		}
+	}
+	for _, repo := range p.Repositories {
+		if repo.RegistryID == 0 || repo.Repository == "" {
+			h.errorResponse(w, http.StatusBadRequest, "Each repository needs a registry_id and repository")
+			return
+		}
+		if _, err := h.db.GetRegistry(repo.RegistryID); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Registry not found")
+			return
+		}
+	}
+
+	if err := h.db.SaveProject(p); err != nil {
+		if err == sql.ErrNoRows {
+			h.errorResponse(w, http.StatusNotFound, "Project not found")
+			return
+		}
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to save project: %v", err))
+		return
+	}
+	h.audit(r, action, p.Name, "")
+	h.successResponse(w, p)
+}
+
+// DeleteProject removes a project (admin only); its registries are not touched
+func (h *Handler) DeleteProject(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+	p, err := h.db.GetProject(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Project not found")
+		return
+	}
+	if err := h.db.DeleteProject(id); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.audit(r, "project.delete", p.Name, "")
+	h.messageResponse(w, "Project deleted")
+}
+
+// --- Teams ---
+
+// ListTeams returns all teams to admins, and to other users the teams they belong to
+func (h *Handler) ListTeams(w http.ResponseWriter, r *http.Request) {
+	teams, err := h.db.ListTeams()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	id := auth.FromContext(r.Context())
+	if id != nil && id.User.Role != "admin" {
+		teams = slices.DeleteFunc(teams, func(t models.Team) bool { return !slices.Contains(t.Members, id.User.ID) })
+	}
+	h.successResponse(w, teams)
+}
+
+// CreateTeam adds a team (admin only)
+func (h *Handler) CreateTeam(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	var t models.Team
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	t.ID = 0
+	h.saveTeam(w, r, &t, "team.create")
+}
+
+// UpdateTeam replaces a team (admin only)
+func (h *Handler) UpdateTeam(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid team ID")
+		return
+	}
+	var t models.Team
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	t.ID = id
+	h.saveTeam(w, r, &t, "team.update")
+}
+
+func (h *Handler) saveTeam(w http.ResponseWriter, r *http.Request, t *models.Team, action string) {
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Name is required")
+		return
+	}
+	for _, userID := range t.Members {
+		if _, err := h.db.GetUser(userID); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "User not found")
+			return
+		}
+	}
+	seen := make(map[int64]bool)
+	for _, tp := range t.Projects {
+		if roleRank(tp.Role) == 0 {
+			h.errorResponse(w, http.StatusBadRequest, "Role must be 'viewer' or 'maintainer'")
+			return
+		}
+		if seen[tp.ProjectID] {
+			h.errorResponse(w, http.StatusBadRequest, "Each project may only be listed once")
+			return
+		}
+		seen[tp.ProjectID] = true
+		if _, err := h.db.GetProject(tp.ProjectID); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Project not found")
+			return
+		}
+	}
+
+	if err := h.db.SaveTeam(t); err != nil {
+		if err == sql.ErrNoRows {
+			h.errorResponse(w, http.StatusNotFound, "Team not found")
+			return
+		}
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to save team: %v", err))
+		return
+	}
+	h.audit(r, action, t.Name, "")
+	h.successResponse(w, t)
+}
+
+// DeleteTeam removes a team (admin only); its members keep their accounts
+func (h *Handler) DeleteTeam(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid team ID")
+		return
+	}
+	t, err := h.db.GetTeam(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Team not found")
+		return
+	}
+	if err := h.db.DeleteTeam(id); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.audit(r, "team.delete", t.Name, "")
+	h.messageResponse(w, "Team deleted")
+}
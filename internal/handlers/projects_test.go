@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"testing"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+func TestProjectAccessDeniesAnonymousCallersUnderAuth(t *testing.T) {
+	s := newTestServer(t, true)
+	reg := s.addRegistry(t, "prod")
+
+	access, err := s.h.projectAccess(httptest.NewRequest(http.MethodGet, "/api/v1/registries", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if access == nil || access.seesRegistry(reg.ID) {
+		t.Fatalf("anonymous access = %+v, want one that sees nothing", access)
+	}
+
+	s.h.SetAuthRequired(false)
+	if access, _ := s.h.projectAccess(httptest.NewRequest(http.MethodGet, "/api/v1/registries", nil)); access != nil {
+		t.Fatalf("anonymous access without -auth = %+v, want everything", access)
+	}
+}
+
+func TestScopeProjects(t *testing.T) {
+	s := newTestServer(t, true)
+	shared := s.addRegistry(t, "shared")
+	other := s.addRegistry(t, "other")
+	viewer, viewerToken := s.addUser(t, "viewer", "user")
+	maintainer, maintainerToken := s.addUser(t, "maintainer", "user")
+	_, outsiderToken := s.addUser(t, "outsider", "user")
+	_, adminToken := s.addUser(t, "root", "admin")
+
+	project := &models.Project{Name: "apps", Registries: []int64{shared.ID}}
+	if err := s.db.SaveProject(project); err != nil {
+		t.Fatal(err)
+	}
+	for _, team := range []*models.Team{
+		{Name: "readers", Members: []int64{viewer.ID}, Projects: []models.TeamProject{{ProjectID: project.ID, Role: roleViewer}}},
+		{Name: "owners", Members: []int64{maintainer.ID}, Projects: []models.TeamProject{{ProjectID: project.ID, Role: roleMaintainer}}},
+	} {
+		if err := s.db.SaveTeam(team); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("registry lists", func(t *testing.T) {
+		tests := []struct {
+			name, token string
+			want        []string
+		}{
+			{"admin", adminToken, []string{"other", "shared"}},
+			{"viewer", viewerToken, []string{"shared"}},
+			{"outsider", outsiderToken, nil},
+		}
+		for _, tt := range tests {
+			w := s.do(http.MethodGet, "/api/v1/registries", tt.token, "")
+			var registries []models.Registry
+			decode(t, w, &registries)
+			var names []string
+			for _, reg := range registries {
+				names = append(names, reg.Name)
+			}
+			slices.Sort(names)
+			if !slices.Equal(names, tt.want) {
+				t.Errorf("%s lists %v, want %v", tt.name, names, tt.want)
+			}
+		}
+	})
+
+	t.Run("registry routes", func(t *testing.T) {
+		tests := []struct {
+			name, method, path, token string
+			want                      int
+		}{
+			{"viewer reads its registry", http.MethodGet, fmt.Sprintf("/api/v1/registries/%d/pins", shared.ID), viewerToken, http.StatusOK},
+			{"viewer reads another registry", http.MethodGet, fmt.Sprintf("/api/v1/registries/%d/pins", other.ID), viewerToken, http.StatusNotFound},
+			{"viewer changes its registry", http.MethodPost, fmt.Sprintf("/api/v1/registries/%d/scan-policy", shared.ID), viewerToken, http.StatusForbidden},
+			{"maintainer changes its registry", http.MethodPost, fmt.Sprintf("/api/v1/registries/%d/scan-policy", shared.ID), maintainerToken, http.StatusOK},
+			{"maintainer changes another registry", http.MethodPost, fmt.Sprintf("/api/v1/registries/%d/scan-policy", other.ID), maintainerToken, http.StatusNotFound},
+			{"admin changes any registry", http.MethodPost, fmt.Sprintf("/api/v1/registries/%d/scan-policy", other.ID), adminToken, http.StatusOK},
+		}
+		for _, tt := range tests {
+			if w := s.do(tt.method, tt.path, tt.token, "{}"); w.Code != tt.want {
+				t.Errorf("%s: %s %s = %d %s, want %d", tt.name, tt.method, tt.path, w.Code, w.Body, tt.want)
+			}
+		}
+	})
+
+	t.Run("cross-registry settings", func(t *testing.T) {
+		for _, rule := range []*models.OnboardingRule{
+			{Name: "everywhere", Pattern: ".*"},
+			{Name: "on shared", RegistryID: shared.ID, Pattern: ".*"},
+			{Name: "on other", RegistryID: other.ID, Pattern: ".*"},
+		} {
+			if err := s.db.SaveOnboardingRule(rule); err != nil {
+				t.Fatal(err)
+			}
+		}
+		w := s.do(http.MethodGet, "/api/v1/onboarding-rules", viewerToken, "")
+		var rules []models.OnboardingRule
+		if decode(t, w, &rules); len(rules) != 1 || rules[0].Name != "on shared" {
+			t.Errorf("viewer lists onboarding rules %+v, want only the one on their registry", rules)
+		}
+		w = s.do(http.MethodGet, "/api/v1/onboarding-rules", adminToken, "")
+		if decode(t, w, &rules); len(rules) != 3 {
+			t.Errorf("admin lists %d onboarding rules, want 3", len(rules))
+		}
+	})
+}
+
+// routePath fills the wildcards of a route pattern with an ID that does not exist
+func routePath(pattern string) string {
+	return routeWildcard.ReplaceAllString(pattern, "999")
+}
+
+var routeWildcard = regexp.MustCompile(`\{[^}]+\}`)
+
+// adminOnlyRoutes are the routes only admins may call
+var adminOnlyRoutes = map[string]bool{
+	"POST /api/v1/signing-keys":                    true,
+	"PUT /api/v1/signing-keys/{id}/projects":       true,
+	"DELETE /api/v1/signing-keys/{id}":             true,
+	"PUT /api/v1/notation/trust-policy":            true,
+	"POST /api/v1/notation/trust-stores":           true,
+	"PUT /api/v1/notation/trust-stores/{id}":       true,
+	"DELETE /api/v1/notation/trust-stores/{id}":    true,
+	"POST /api/v1/registries/{id}/immutable-tags":  true,
+	"DELETE /api/v1/immutable-tags/{id}":           true,
+	"POST /api/v1/quotas":                          true,
+	"PUT /api/v1/quotas/{id}":                      true,
+	"DELETE /api/v1/quotas/{id}":                   true,
+	"POST /api/v1/quotas/{id}/check":               true,
+	"POST /api/v1/onboarding-rules":                true,
+	"PUT /api/v1/onboarding-rules/{id}":            true,
+	"DELETE /api/v1/onboarding-rules/{id}":         true,
+	"POST /api/v1/projects":                        true,
+	"PUT /api/v1/projects/{id}":                    true,
+	"DELETE /api/v1/projects/{id}":                 true,
+	"POST /api/v1/teams":                           true,
+	"PUT /api/v1/teams/{id}":                       true,
+	"DELETE /api/v1/teams/{id}":                    true,
+	"POST /api/v1/access-rules":                    true,
+	"PUT /api/v1/access-rules/{id}":                true,
+	"DELETE /api/v1/access-rules/{id}":             true,
+	"POST /api/v1/seeds":                           true,
+	"PUT /api/v1/seeds/{id}":                       true,
+	"DELETE /api/v1/seeds/{id}":                    true,
+	"POST /api/v1/seeds/{id}/run":                  true,
+	"POST /api/v1/replications":                    true,
+	"PUT /api/v1/replications/{id}":                true,
+	"DELETE /api/v1/replications/{id}":             true,
+	"POST /api/v1/replications/{id}/run":           true,
+	"POST /api/v1/defectdojo/mappings":             true,
+	"PUT /api/v1/defectdojo/mappings/{id}":         true,
+	"DELETE /api/v1/defectdojo/mappings/{id}":      true,
+	"POST /api/v1/defectdojo/mappings/{id}/export": true,
+	"POST /api/v1/retention-templates":             true,
+	"PUT /api/v1/retention-templates/{id}":         true,
+	"DELETE /api/v1/retention-templates/{id}":      true,
+	"GET /api/v1/scan/storage":                     true,
+	"GET /api/v1/scan/workers":                     true,
+	"GET /api/v1/users":                            true,
+	"POST /api/v1/users":                           true,
+	"POST /api/v1/users/{id}/expire-credentials":   true,
+	"GET /api/v1/audit":                            true,
+	"POST /api/v1/config/reload":                   true,
+	"GET /api/v1/admin/backup":                     true,
+	"POST /api/v1/admin/restore":                   true,
+	"PUT /api/v1/maintenance":                      true,
+	"GET /api/v1/storage":                          true,
+	"POST /api/v1/storage":                         true,
+	"POST /api/v1/storage/test":                    true,
+	"POST /api/v1/registry/restart":                true,
+	"POST /api/v1/registry/stop":                   true,
+	"POST /api/v1/registry/upgrade":                true,
+	"GET /api/v1/registry/settings":                true,
+	"PUT /api/v1/registry/settings":                true,
+	"POST /api/v1/registry/start":                  true,
+	"GET /api/v1/registry/logs":                    true,
+	"GET /api/v1/registry/storage-usage":           true,
+	"POST /api/v1/registry/config/preview":         true,
+	"POST /api/v1/registry/config/validate":        true,
+	"GET /api/v1/registry/instances":               true,
+	"POST /api/v1/registry/instances":              true,
+	"DELETE /api/v1/registry/instances/{id}":       true,
+	"POST /api/v1/registry/instances/{id}/start":   true,
+	"POST /api/v1/registry/instances/{id}/stop":    true,
+	"GET /api/v1/registry/users":                   true,
+	"POST /api/v1/registry/users":                  true,
+	"DELETE /api/v1/registry/users/{id}":           true,
+	"PUT /api/v1/registry/users/{id}/permissions":  true,
+}
+
+// TestAdminOnlyRoutes sends every route a request of a signed-in user who is not an admin: the
+// admin-only routes must refuse it, and no other route may ask for the admin role
+func TestAdminOnlyRoutes(t *testing.T) {
+	s := newTestServer(t, true)
+	_, token := s.addUser(t, "alice", "user")
+
+	seen := make(map[string]bool)
+	for _, rt := range apiRoutes {
+		route := rt.Method + " " + rt.Pattern
+		seen[route] = true
+		w := s.do(rt.Method, routePath(rt.Pattern), token, "{}")
+		refused := w.Code == http.StatusForbidden && decode(t, w, nil).Error == "Admin role required"
+		if adminOnlyRoutes[route] && !refused {
+			t.Errorf("%s answered a non-admin with %d %s, want 403", route, w.Code, w.Body)
+		}
+		if !adminOnlyRoutes[route] && refused {
+			t.Errorf("%s requires the admin role; add it to adminOnlyRoutes and document it", route)
+		}
+	}
+	for route := range adminOnlyRoutes {
+		if !seen[route] {
+			t.Errorf("%s is not a route", route)
+		}
+	}
+}
+
+// TestAnonymousCallersNeedAuth sends every route an anonymous request under -auth
+func TestAnonymousCallersNeedAuth(t *testing.T) {
+	s := newTestServer(t, true)
+	for _, rt := range apiRoutes {
+		r := httptest.NewRequest(rt.Method, routePath(rt.Pattern), nil)
+		if !requiresAuth(r) {
+			continue
+		}
+		if w := s.do(rt.Method, routePath(rt.Pattern), "", "{}"); w.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s answered an anonymous caller with %d, want 401", rt.Method, rt.Pattern, w.Code)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"docker-registry-dashboard/internal/auth"
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/tasks"
+)
+
+// allowCopyInto checks that no exceeded quota blocks copies into a repository, writing the error
+// response when one does
+func (h *Handler) allowCopyInto(w http.ResponseWriter, registryID int64, repo string) bool {
+	q, err := h.db.BlockingQuota(registryID, repo)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return false
+	}
+	if q != nil {
+		h.errorResponse(w, http.StatusInsufficientStorage, h.tr(w, "Repository %s is over its storage quota (%d of %d bytes used)", repo, q.UsedBytes, q.LimitBytes))
+		return false
+	}
+	return true
+}
+
+// --- Repository Quotas ---
+
+// ListRepositoryQuotas returns the quotas with their last measured usage and state; users that
+// projects limit see the quotas of what they can see
+func (h *Handler) ListRepositoryQuotas(w http.ResponseWriter, r *http.Request) {
+	quotas, err := h.db.ListRepositoryQuotas()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	access, err := h.projectAccess(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if access != nil {
+		roles := map[int64]string{}
+		if id := auth.FromContext(r.Context()); id != nil {
+			if roles, err = h.projectRoles(id.User.ID); err != nil {
+				h.errorResponse(w, http.StatusInternalServerError, "Database error")
+				return
+			}
+		}
+		quotas = slices.DeleteFunc(quotas, func(q models.RepositoryQuota) bool {
+			if q.ProjectID != 0 {
+				return roles[q.ProjectID] == ""
+			}
+			return !access.seesRegistry(q.RegistryID)
+		})
+	}
+	h.successResponse(w, quotas)
+}
+
+// CreateRepositoryQuota adds a quota (admin only)
+func (h *Handler) CreateRepositoryQuota(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	var q models.RepositoryQuota
+	if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	q.ID = 0
+	h.saveRepositoryQuota(w, r, &q, "quota.create")
+}
+
+// UpdateRepositoryQuota replaces the settings of a quota (admin only)
+func (h *Handler) UpdateRepositoryQuota(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid quota ID")
+		return
+	}
+	var q models.RepositoryQuota
+	if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	q.ID = id
+	h.saveRepositoryQuota(w, r, &q, "quota.update")
+}
+
+func (h *Handler) saveRepositoryQuota(w http.ResponseWriter, r *http.Request, q *models.RepositoryQuota, action string) {
+	q.Repository = strings.TrimSpace(q.Repository)
+	var target string
+	switch {
+	case q.ProjectID != 0:
+		if q.RegistryID != 0 || q.Repository != "" {
+			h.errorResponse(w, http.StatusBadRequest, "A quota is for a project or for repositories of a registry, not both")
+			return
+		}
+		p, err := h.db.GetProject(q.ProjectID)
+		if err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Project not found")
+			return
+		}
+		target = "project " + p.Name
+	default:
+		reg, err := h.db.GetRegistry(q.RegistryID)
+		if err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Registry not found")
+			return
+		}
+		if q.Repository == "" {
+			h.errorResponse(w, http.StatusBadRequest, "Repository is required")
+			return
+		}
+		target = reg.Name + "/" + q.Repository
+	}
+	if q.LimitBytes <= 0 {
+		h.errorResponse(w, http.StatusBadRequest, "The quota limit must be positive")
+		return
+	}
+	if q.WarnPercent == 0 {
+		q.WarnPercent = 80
+	}
+	if q.WarnPercent < 1 || q.WarnPercent > 100 {
+		h.errorResponse(w, http.StatusBadRequest, "The warning threshold must be between 1 and 100 percent")
+		return
+	}
+
+	if err := h.db.SaveRepositoryQuota(q); err != nil {
+		if err == sql.ErrNoRows {
+			h.errorResponse(w, http.StatusNotFound, "Quota not found")
+			return
+		}
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to save quota: %v", err))
+		return
+	}
+	h.audit(r, action, target, fmt.Sprintf("%d bytes", q.LimitBytes))
+	saved, err := h.db.GetRepositoryQuota(q.ID)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.successResponse(w, saved)
+}
+
+// DeleteRepositoryQuota removes a quota (admin only)
+func (h *Handler) DeleteRepositoryQuota(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid quota ID")
+		return
+	}
+	if err := h.db.DeleteRepositoryQuota(id); err != nil {
+		if err == sql.ErrNoRows {
+			h.errorResponse(w, http.StatusNotFound, "Quota not found")
+			return
+		}
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.audit(r, "quota.delete", fmt.Sprintf("quota %d", id), "")
+	h.messageResponse(w, "Quota deleted")
+}
+
+// CheckRepositoryQuota measures the usage of a quota now instead of at the next hourly check (admin only)
+func (h *Handler) CheckRepositoryQuota(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid quota ID")
+		return
+	}
+	q, err := h.db.GetRepositoryQuota(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Quota not found")
+		return
+	}
+	if err := tasks.CheckQuota(r.Context(), h.db, q); err != nil {
+		h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Failed to measure quota usage: %v", err))
+		return
+	}
+	h.successResponse(w, q)
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"docker-registry-dashboard/internal/auth"
+)
+
+// RateLimit is a token bucket: Rate requests per second on average, up to Burst at once
+type RateLimit struct {
+	Rate  float64 // 0 disables the limit
+	Burst int
+}
+
+// expensiveRoutes are rate limited separately and more strictly: they walk registries, start
+// scanners or aggregate every scan
+var expensiveRoutes = []string{
+	"GET /api/v1/dashboard/stats",
+	"POST /api/v1/dashboard/stats/refresh",
+	"POST /api/v1/scan/trigger",
+	"GET /api/v1/search",
+	"GET /api/v1/vulnerabilities/search",
+	"GET /api/v1/reports/scan",
+	"GET /api/v1/registries/{id}/size",
+	"GET /api/v1/registries/{id}/content-trust",
+	"GET /api/v1/registries/{id}/notation-verification",
+	"GET /api/v1/registries/{id}/attestations",
+	"POST /api/v1/registries/{id}/sync",
+	"POST /api/v1/registries/{id}/conformance",
+	"POST /api/v1/registries/{id}/retention/run",
+	"POST /api/v1/registries/{id}/export/oci",
+	"GET /api/v1/registries/{id}/export",
+	"POST /api/v1/registries/{id}/import",
+	"GET /api/v1/admin/backup",
+	"POST /api/v1/admin/restore",
+}
+
+// bucketIdleTTL is how long the bucket of a client that sent no requests is kept
+const bucketIdleTTL = 10 * time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter keeps a token bucket per client and limit class
+type rateLimiter struct {
+	mu        sync.Mutex
+	limits    map[string]RateLimit // "default" and "expensive"
+	buckets   map[string]*bucket
+	expensive *http.ServeMux // Matches expensiveRoutes
+	lastSweep time.Time
+}
+
+func newRateLimiter(general, expensive RateLimit) *rateLimiter {
+	l := &rateLimiter{
+		limits:    map[string]RateLimit{"default": general, "expensive": expensive},
+		buckets:   make(map[string]*bucket),
+		expensive: http.NewServeMux(),
+		lastSweep: time.Now(),
+	}
+	for _, pattern := range expensiveRoutes {
+		l.expensive.Handle(pattern, http.NotFoundHandler())
+	}
+	return l
+}
+
+// SetRateLimits limits API requests per client: an API token or login session, or else the
+// remote address. Expensive endpoints draw from their own, usually smaller, bucket as well.
+func (h *Handler) SetRateLimits(general, expensive RateLimit) {
+	h.rateLimiter = newRateLimiter(general, expensive)
+}
+
+// take removes a token from a client's bucket of a limit class; when the bucket is empty it
+// returns how long until the next token is available
+func (l *rateLimiter) take(class, client string, now time.Time) (ok bool, remaining int, retryAfter time.Duration) {
+	limit := l.limits[class]
+	if limit.Rate <= 0 {
+		return true, -1, 0
+	}
+	burst := float64(max(limit.Burst, 1))
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastSweep) > bucketIdleTTL {
+		for key, b := range l.buckets {
+			if now.Sub(b.last) > bucketIdleTTL {
+				delete(l.buckets, key)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	key := class + "|" + client
+	b, found := l.buckets[key]
+	if !found {
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false, 0, time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, int(b.tokens), 0
+}
+
+// rateLimitClient identifies the caller a bucket belongs to
+func rateLimitClient(r *http.Request) string {
+	if id := auth.FromContext(r.Context()); id != nil {
+		switch {
+		case id.TokenID != 0:
+			return fmt.Sprintf("token:%d", id.TokenID)
+		case id.SessionHash != "":
+			return "session:" + id.SessionHash
+		default:
+			return fmt.Sprintf("user:%d", id.User.ID)
+		}
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return "ip:" + host
+	}
+	return "ip:" + r.RemoteAddr
+}
+
+// LimitRate answers API requests beyond the client's rate limits with 429 and a Retry-After
+// header. Registry webhooks are not limited, so bursts of push events are never dropped. It
+// runs after Authenticate, which identifies the client.
+func (h *Handler) LimitRate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l := h.rateLimiter
+		if l == nil || !strings.HasPrefix(r.URL.Path, "/api/") || isWebhook(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		client := rateLimitClient(r)
+		now := time.Now()
+
+		classes := []string{"default"}
+		if _, pattern := l.expensive.Handler(r); pattern != "" {
+			classes = append(classes, "expensive")
+		}
+		for _, class := range classes {
+			ok, remaining, retryAfter := l.take(class, client, now)
+			if !ok {
+				w.Header().Set("X-RateLimit-Limit", strconv.Itoa(max(l.limits[class].Burst, 1)))
+				w.Header().Set("X-RateLimit-Remaining", "0")
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+				h.errorResponse(w, http.StatusTooManyRequests, "Too many requests, slow down")
+				return
+			}
+			if remaining >= 0 {
+				w.Header().Set("X-RateLimit-Limit", strconv.Itoa(max(l.limits[class].Burst, 1)))
+				w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
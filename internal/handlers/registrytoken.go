@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/registry"
+)
+
+// RegistryTokenResponse is the answer of the token service, in the form Docker clients expect
+type RegistryTokenResponse struct {
+	Token       string    `json:"token"`
+	AccessToken string    `json:"access_token"`
+	ExpiresIn   int       `json:"expires_in"`
+	IssuedAt    time.Time `json:"issued_at"`
+}
+
+// IssueRegistryToken is the token service of the embedded registry (the distribution token
+// authentication scheme). Clients send their registry account with basic auth and the scopes they
+// need (?scope=repository:team/app:pull,push, repeatable); the token grants the requested actions
+// the account's permissions allow. Requests without credentials get a token granting nothing.
+func (h *Handler) IssueRegistryToken(w http.ResponseWriter, r *http.Request) {
+	if h.embeddedReg == nil || h.embeddedReg.Tokens() == nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry token authentication is not enabled")
+		return
+	}
+
+	var user *models.RegistryUser
+	if username, password, ok := r.BasicAuth(); ok {
+		u, err := h.db.GetRegistryUserByName(username)
+		if err != nil || !registry.CheckRegistryPassword(u.PasswordHash, password) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="`+registry.TokenService+`"`)
+			h.errorResponse(w, http.StatusUnauthorized, "Invalid registry credentials")
+			return
+		}
+		user = u
+	}
+
+	granted := []registry.TokenAccess{}
+	for _, param := range r.URL.Query()["scope"] {
+		for _, scope := range strings.Fields(param) {
+			requested, ok := registry.ParseTokenScope(scope)
+			if !ok {
+				continue
+			}
+			if actions := grantedRegistryActions(user, requested); len(actions) > 0 {
+				requested.Actions = actions
+				granted = append(granted, requested)
+			}
+		}
+	}
+
+	subject := ""
+	if user != nil {
+		subject = user.Username
+	}
+	tokens := h.embeddedReg.Tokens()
+	token, issuedAt, err := tokens.Issue(subject, granted)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.jsonResponse(w, http.StatusOK, RegistryTokenResponse{
+		Token:       token,
+		AccessToken: token,
+		ExpiresIn:   int(tokens.TokenTTL().Seconds()),
+		IssuedAt:    issuedAt.UTC(),
+	})
+}
+
+// grantedRegistryActions returns the requested actions an account may take. The dashboard's own
+// account may do anything, including listing the catalog; other accounts get the actions of the
+// permissions matching the repository, and anonymous requests get none.
+func grantedRegistryActions(user *models.RegistryUser, requested registry.TokenAccess) []string {
+	if user == nil {
+		return nil
+	}
+	if user.Managed {
+		if requested.Type == "repository" || (requested.Type == "registry" && requested.Name == "catalog") {
+			return requested.Actions
+		}
+		return nil
+	}
+	if requested.Type != "repository" {
+		return nil
+	}
+
+	var allowed []string
+	for _, p := range user.Permissions {
+		if models.MatchRepository(p.Repository, requested.Name) {
+			allowed = append(allowed, strings.Split(p.Actions, ",")...)
+		}
+	}
+	var actions []string
+	for _, action := range requested.Actions {
+		if slices.Contains(allowed, action) {
+			actions = append(actions, action)
+		}
+	}
+	return actions
+}
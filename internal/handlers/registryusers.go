@@ -0,0 +1,312 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+
+	"docker-registry-dashboard/internal/auth"
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/registry"
+)
+
+// dashboardRegistryUser is the embedded registry account the dashboard creates for its Local Registry entry
+const dashboardRegistryUser = "dashboard"
+
+// registryUsername is what an htpasswd username may contain
+var registryUsername = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// --- Embedded Registry Users ---
+
+// CreateRegistryUserRequest adds an account to the embedded registry
+type CreateRegistryUserRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// ListRegistryUsers returns the accounts of the embedded registry (admin only); with none it takes anonymous requests
+func (h *Handler) ListRegistryUsers(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	users, err := h.db.ListRegistryUsers()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.successResponse(w, users)
+}
+
+// CreateRegistryUser adds an account to the embedded registry's htpasswd file and restarts the
+// registry. The first account turns authentication on, so the dashboard then also creates its own
+// account for the Local Registry entry.
+func (h *Handler) CreateRegistryUser(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	if h.embeddedReg == nil {
+		h.errorResponse(w, http.StatusServiceUnavailable, "Embedded registry is not available")
+		return
+	}
+
+	var req CreateRegistryUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !registryUsername.MatchString(req.Username) {
+		h.errorResponse(w, http.StatusBadRequest, "Username must be 1-64 letters, digits, dots, dashes or underscores")
+		return
+	}
+	if req.Username == dashboardRegistryUser {
+		h.errorResponse(w, http.StatusBadRequest, "This username is reserved for the dashboard")
+		return
+	}
+	if len(req.Password) < auth.MinPasswordLength {
+		h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Password must be at least %d characters", auth.MinPasswordLength))
+		return
+	}
+
+	users, err := h.db.ListRegistryUsers()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	hash, err := registry.HashRegistryPassword(req.Password)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	user := &models.RegistryUser{Username: req.Username, PasswordHash: hash}
+	if err := h.db.CreateRegistryUser(user); err != nil {
+		h.errorResponse(w, http.StatusConflict, "Username already exists")
+		return
+	}
+	if len(users) == 0 {
+		if err := h.createDashboardRegistryUser(); err != nil {
+			log.Printf("⚠️  Failed to give the dashboard an embedded registry account: %v", err)
+		}
+	}
+	h.audit(r, "registry_user.create", user.Username, "")
+
+	h.jsonResponse(w, http.StatusCreated, models.APIResponse{
+		Success: true,
+		Data:    user,
+		Message: h.tr(w, h.applyRegistryUsers()),
+	})
+}
+
+// DeleteRegistryUser removes an account from the embedded registry and restarts it. Removing the
+// last account also removes the dashboard's own, which turns authentication off.
+func (h *Handler) DeleteRegistryUser(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	if h.embeddedReg == nil {
+		h.errorResponse(w, http.StatusServiceUnavailable, "Embedded registry is not available")
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry user ID")
+		return
+	}
+	user, err := h.db.GetRegistryUser(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry user not found")
+		return
+	}
+	if user.Managed {
+		h.errorResponse(w, http.StatusBadRequest, "The dashboard's account is removed with the last registry user")
+		return
+	}
+	if err := h.db.DeleteRegistryUser(id); err != nil {
+		if err == sql.ErrNoRows {
+			h.errorResponse(w, http.StatusNotFound, "Registry user not found")
+			return
+		}
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	users, err := h.db.ListRegistryUsers()
+	if err == nil && len(users) == 1 && users[0].Managed {
+		if err := h.removeDashboardRegistryUser(&users[0]); err != nil {
+			log.Printf("⚠️  Failed to remove the dashboard's embedded registry account: %v", err)
+		}
+	}
+	h.audit(r, "registry_user.delete", user.Username, "")
+	h.messageResponse(w, h.applyRegistryUsers())
+}
+
+// UpdateRegistryPermissions replaces the repository permissions of an embedded registry account,
+// which the registry enforces when it uses token authentication (admin only)
+func (h *Handler) UpdateRegistryPermissions(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry user ID")
+		return
+	}
+	user, err := h.db.GetRegistryUser(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry user not found")
+		return
+	}
+	if user.Managed {
+		h.errorResponse(w, http.StatusBadRequest, "The dashboard's account always has full access")
+		return
+	}
+
+	var perms []models.RegistryPermission
+	if err := json.NewDecoder(r.Body).Decode(&perms); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	for i := range perms {
+		perms[i].Repository = strings.TrimSpace(perms[i].Repository)
+		if perms[i].Repository == "" {
+			h.errorResponse(w, http.StatusBadRequest, "Permission repository is required")
+			return
+		}
+		actions, ok := normalizeActions(perms[i].Actions, "pull", "push", "delete")
+		if !ok {
+			h.errorResponse(w, http.StatusBadRequest, "Permission actions must be pull, push or delete")
+			return
+		}
+		perms[i].Actions = actions
+	}
+
+	if err := h.db.SetRegistryPermissions(user.ID, perms); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.audit(r, "registry_user.permissions", user.Username, fmt.Sprintf("%d permissions", len(perms)))
+	user.Permissions = perms
+	if user.Permissions == nil {
+		user.Permissions = []models.RegistryPermission{}
+	}
+	h.successResponse(w, user)
+}
+
+// normalizeActions checks a comma-separated list of the allowed actions, returning it without
+// blanks or duplicates
+func normalizeActions(list string, allowed ...string) (string, bool) {
+	var actions []string
+	for _, action := range strings.Split(list, ",") {
+		action = strings.ToLower(strings.TrimSpace(action))
+		if action == "" {
+			continue
+		}
+		if !slices.Contains(allowed, action) {
+			return "", false
+		}
+		if !slices.Contains(actions, action) {
+			actions = append(actions, action)
+		}
+	}
+	return strings.Join(actions, ","), len(actions) > 0
+}
+
+// embeddedAuth returns how the embedded registry authenticates clients
+func (h *Handler) embeddedAuth() registry.RegistryAuth {
+	if h.embeddedReg == nil {
+		return registry.RegistryAuth{}
+	}
+	return h.embeddedReg.Auth()
+}
+
+// localRegistryEntry returns the registry entry of the embedded registry, if it is registered
+func (h *Handler) localRegistryEntry() *models.Registry {
+	registries, err := h.db.ListRegistries()
+	if err != nil {
+		return nil
+	}
+	for i := range registries {
+		if registries[i].URL == h.embeddedReg.URL() {
+			return &registries[i]
+		}
+	}
+	return nil
+}
+
+// createDashboardRegistryUser gives the Local Registry entry an account with a random password,
+// unless the entry already has credentials
+func (h *Handler) createDashboardRegistryUser() error {
+	entry := h.localRegistryEntry()
+	if entry == nil || entry.Username != "" {
+		return nil
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	password := hex.EncodeToString(secret)
+	hash, err := registry.HashRegistryPassword(password)
+	if err != nil {
+		return err
+	}
+	if err := h.db.CreateRegistryUser(&models.RegistryUser{Username: dashboardRegistryUser, PasswordHash: hash, Managed: true}); err != nil {
+		return err
+	}
+	entry.Username, entry.Password = dashboardRegistryUser, password
+	if err := h.db.UpdateRegistry(entry); err != nil {
+		return err
+	}
+	h.invalidateListings(entry.ID)
+	return nil
+}
+
+// removeDashboardRegistryUser removes the dashboard's account and clears it from the Local Registry entry
+func (h *Handler) removeDashboardRegistryUser(user *models.RegistryUser) error {
+	if err := h.db.DeleteRegistryUser(user.ID); err != nil {
+		return err
+	}
+	entry := h.localRegistryEntry()
+	if entry == nil || entry.Username != user.Username {
+		return nil
+	}
+	entry.Username, entry.Password = "", ""
+	if err := h.db.UpdateRegistry(entry); err != nil {
+		return err
+	}
+	h.invalidateListings(entry.ID)
+	return nil
+}
+
+// applyRegistryUsers rewrites the htpasswd file and restarts a running registry in the background,
+// returning the message to show
+func (h *Handler) applyRegistryUsers() string {
+	users, err := h.db.ListRegistryUsers()
+	if err == nil {
+		err = h.embeddedReg.SetUsers(users)
+	}
+	if err != nil {
+		log.Printf("⚠️  Failed to write the registry's htpasswd file: %v", err)
+		return "Registry users saved, but the htpasswd file could not be written."
+	}
+	if !h.embeddedReg.IsRunning() {
+		return "Registry users saved. They apply when the registry starts."
+	}
+
+	config, err := h.db.GetStorageConfig()
+	if err != nil {
+		return "Registry users saved. They apply when the registry restarts."
+	}
+	go func() {
+		if err := h.embeddedReg.Restart(config); err != nil {
+			log.Printf("⚠️  Failed to restart registry: %v", err)
+		}
+	}()
+	return "Registry users saved. Registry is restarting with new configuration."
+}
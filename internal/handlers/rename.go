@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/registry"
+)
+
+// RenameRequest asks to move a repository to a new name within its registry
+type RenameRequest struct {
+	Repository    string `json:"repository"`
+	NewRepository string `json:"new_repository"`
+	KeepSource    bool   `json:"keep_source"` // Copy and verify only, leaving the source in place
+}
+
+// renameTracker keeps the progress of repository renames started by this process
+type renameTracker struct {
+	mu   sync.Mutex
+	jobs map[string]*models.RenameJob
+}
+
+func (t *renameTracker) add(job *models.RenameJob) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.jobs == nil {
+		t.jobs = make(map[string]*models.RenameJob)
+	}
+	for id, old := range t.jobs {
+		if finishedLongAgo(old.FinishedAt) {
+			delete(t.jobs, id)
+		}
+	}
+	t.jobs[job.ID] = job
+}
+
+func (t *renameTracker) update(id string, apply func(job *models.RenameJob)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if job, ok := t.jobs[id]; ok {
+		apply(job)
+	}
+}
+
+func (t *renameTracker) get(id string) (models.RenameJob, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	job, ok := t.jobs[id]
+	if !ok {
+		return models.RenameJob{}, false
+	}
+	return snapshotRename(job), true
+}
+
+func (t *renameTracker) list(registryID int64) []models.RenameJob {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	jobs := []models.RenameJob{}
+	for _, job := range t.jobs {
+		if job.RegistryID == registryID {
+			jobs = append(jobs, snapshotRename(job))
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].StartedAt.After(jobs[j].StartedAt) })
+	return jobs
+}
+
+// busy reports whether a running rename of the registry involves one of the repositories
+func (t *renameTracker) busy(registryID int64, repos ...string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, job := range t.jobs {
+		if job.RegistryID != registryID || job.Status != "running" {
+			continue
+		}
+		for _, repo := range repos {
+			if repo == job.Repository || repo == job.NewRepository {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// snapshotRename copies a job including its tag list, which the job goroutine keeps updating
+func snapshotRename(job *models.RenameJob) models.RenameJob {
+	snapshot := *job
+	snapshot.Tags = append([]models.RenameTag(nil), job.Tags...)
+	return snapshot
+}
+
+// RenameRepository starts moving a repository to a new name: every tag is copied at the manifest
+// level (blobs are mounted, not uploaded), the copies are verified against the source digests and
+// only then is the source deleted. Poll GET /api/v1/registries/{id}/repository/rename/{job} for progress.
+func (h *Handler) RenameRepository(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	var req RenameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Repository == "" || req.NewRepository == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Repository and new repository name are required")
+		return
+	}
+	if req.Repository == req.NewRepository {
+		h.errorResponse(w, http.StatusBadRequest, "The new name is the current name")
+		return
+	}
+	if !registry.ValidRepositoryName(req.NewRepository) {
+		h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Invalid repository name %q", req.NewRepository))
+		return
+	}
+	if !h.allowRepository(w, r, id, req.Repository, true) || !h.allowRepository(w, r, id, req.NewRepository, true) {
+		return
+	}
+	if !h.allowAction(w, r, id, req.Repository, actionDelete) || !h.allowAction(w, r, id, req.NewRepository, actionPush) {
+		return
+	}
+	if !h.allowCopyInto(w, id, req.NewRepository) {
+		return
+	}
+
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+	if h.renameJobs.busy(reg.ID, req.Repository, req.NewRepository) {
+		h.errorResponse(w, http.StatusConflict, "A rename of this repository is already running")
+		return
+	}
+	client := registry.NewClientFromRegistry(reg)
+	if !req.KeepSource && client.DeletesDisabled() {
+		h.errorResponse(w, http.StatusConflict, "The registry does not allow deletes; set keep_source to copy the repository without removing it")
+		return
+	}
+
+	tags, err := client.ListTags(r.Context(), req.Repository)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Failed to list tags: %v", err))
+		return
+	}
+	if len(tags) == 0 {
+		h.errorResponse(w, http.StatusNotFound, h.tr(w, "Repository %s has no tags", req.Repository))
+		return
+	}
+	if !req.KeepSource {
+		immutable, err := h.immutableTags(reg.ID)
+		if err != nil {
+			h.errorResponse(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		for _, t := range tags {
+			if immutable != nil && immutable(req.Repository, t.Name) {
+				h.errorResponse(w, http.StatusConflict, h.tr(w, "Repository %s has the immutable tag %s; set keep_source to copy the repository without removing it", req.Repository, t.Name))
+				return
+			}
+		}
+	}
+	if existing, err := client.ListTags(r.Context(), req.NewRepository); err == nil && len(existing) > 0 {
+		h.errorResponse(w, http.StatusConflict, h.tr(w, "Repository %s already exists", req.NewRepository))
+		return
+	}
+
+	job := &models.RenameJob{
+		ID:            newJobID(),
+		RegistryID:    reg.ID,
+		Repository:    req.Repository,
+		NewRepository: req.NewRepository,
+		KeepSource:    req.KeepSource,
+		Status:        "running",
+		Phase:         "copying",
+		Tags:          make([]models.RenameTag, len(tags)),
+		StartedAt:     time.Now(),
+	}
+	for i, t := range tags {
+		job.Tags[i] = models.RenameTag{Tag: t.Name, Status: "pending"}
+	}
+	h.renameJobs.add(job)
+	snapshot, _ := h.renameJobs.get(job.ID)
+
+	deletedBy, remoteAddr := requestActor(r)
+	h.audit(r, "repository.rename", fmt.Sprintf("registry:%d", reg.ID), req.Repository+" → "+req.NewRepository)
+	go h.runRename(job.ID, reg, client, req, deletedBy, remoteAddr)
+
+	h.jsonResponse(w, http.StatusAccepted, models.APIResponse{Success: true, Data: snapshot})
+}
+
+// runRename copies, verifies and deletes; any failure before the delete phase leaves the source untouched
+func (h *Handler) runRename(id string, reg *models.Registry, client *registry.Client, req RenameRequest, deletedBy, remoteAddr string) {
+	ctx := context.Background()
+	from, to := req.Repository, req.NewRepository
+	job, _ := h.renameJobs.get(id)
+	fail := func(i int, format string, a ...interface{}) {
+		err := fmt.Sprintf(format, a...)
+		h.renameJobs.update(id, func(job *models.RenameJob) {
+			if i >= 0 {
+				job.Tags[i].Status, job.Tags[i].Error = "failed", err
+			}
+			job.Status, job.Error, job.FinishedAt = "failed", err, time.Now()
+		})
+		h.invalidateListings(reg.ID)
+		log.Printf("❌ Rename of %s to %s (registry %d) failed: %s", from, to, reg.ID, err)
+	}
+
+	// Copy every tag, remembering the digest each one had in the source
+	digests := make([]string, len(job.Tags))
+	for i, t := range job.Tags {
+		digest, err := client.GetDigestForTag(ctx, from, t.Tag)
+		if err != nil {
+			fail(i, "failed to resolve %s:%s: %v", from, t.Tag, err)
+			return
+		}
+		if _, err := registry.CopyImage(ctx, client, from, t.Tag, client, to, t.Tag, nil); err != nil {
+			fail(i, "failed to copy %s:%s: %v; the source is untouched", from, t.Tag, err)
+			return
+		}
+		digests[i] = digest
+		h.renameJobs.update(id, func(job *models.RenameJob) {
+			job.Tags[i].Digest, job.Tags[i].Status = digest, "copied"
+			job.CopiedTags++
+		})
+	}
+
+	// Every copy must resolve to the digest of its source before anything is deleted
+	h.renameJobs.update(id, func(job *models.RenameJob) { job.Phase = "verifying" })
+	for i, t := range job.Tags {
+		copied, err := client.GetDigestForTag(ctx, to, t.Tag)
+		if err != nil {
+			fail(i, "failed to verify %s:%s: %v; the source is untouched", to, t.Tag, err)
+			return
+		}
+		if copied != digests[i] {
+			fail(i, "%s:%s has digest %s instead of %s; the source is untouched", to, t.Tag, copied, digests[i])
+			return
+		}
+		h.renameJobs.update(id, func(job *models.RenameJob) {
+			job.Tags[i].Status = "verified"
+			job.VerifiedTags++
+		})
+	}
+
+	if !req.KeepSource {
+		h.renameJobs.update(id, func(job *models.RenameJob) { job.Phase = "deleting" })
+
+		// Deleting a manifest removes every tag pointing at it, so each digest is deleted once
+		byDigest := make(map[string][]int)
+		order := []string{}
+		for i, d := range digests {
+			if _, ok := byDigest[d]; !ok {
+				order = append(order, d)
+			}
+			byDigest[d] = append(byDigest[d], i)
+		}
+		for _, digest := range order {
+			size, err := client.ImageSize(ctx, from, digest)
+			if err != nil {
+				log.Printf("⚠️  Failed to get size of %s@%s: %v", from, digest, err)
+			}
+			if err := client.DeleteManifest(ctx, from, digest); err != nil {
+				fail(byDigest[digest][0], "copied and verified %s, but deleting %s@%s failed: %v", to, from, digest, err)
+				return
+			}
+			for _, i := range byDigest[digest] {
+				d := models.DeletedImage{
+					RegistryID: reg.ID, RegistryName: reg.Name, Repository: from, Tag: job.Tags[i].Tag, Digest: digest,
+					Size: size, Source: "rename", Reason: "renamed to " + to, DeletedBy: deletedBy, RemoteAddr: remoteAddr,
+				}
+				if err := h.db.AddDeletedImage(&d); err != nil {
+					log.Printf("⚠️ Failed to record deletion of %s@%s: %v", from, digest, err)
+				}
+			}
+			h.renameJobs.update(id, func(job *models.RenameJob) {
+				for _, i := range byDigest[digest] {
+					job.Tags[i].Status = "deleted"
+				}
+				job.DeletedManifests++
+			})
+		}
+		if err := h.db.RenameRepository(reg.ID, from, to); err != nil {
+			log.Printf("⚠️  Failed to move the records of %s to %s: %v", from, to, err)
+		}
+	}
+
+	h.renameJobs.update(id, func(job *models.RenameJob) {
+		job.Status, job.Phase, job.FinishedAt = "completed", "done", time.Now()
+	})
+	h.invalidateListings(reg.ID)
+	log.Printf("✅ Renamed %s to %s in registry %d (%d tags)", from, to, reg.ID, len(job.Tags))
+}
+
+// GetRenameJob returns the progress of a repository rename
+func (h *Handler) GetRenameJob(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	job, ok := h.renameJobs.get(r.PathValue("job"))
+	if !ok || job.RegistryID != id {
+		h.errorResponse(w, http.StatusNotFound, "Rename job not found")
+		return
+	}
+	h.successResponse(w, job)
+}
+
+// ListRenameJobs returns the repository renames of a registry started since the dashboard started, newest first
+func (h *Handler) ListRenameJobs(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	h.successResponse(w, h.renameJobs.list(id))
+}
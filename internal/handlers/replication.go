@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/tasks"
+)
+
+// --- Replication Rules ---
+
+// ListReplicationRules returns the rules replicating repositories between registries
+func (h *Handler) ListReplicationRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.db.ListReplicationRules()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	access, err := h.projectAccess(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if access != nil {
+		rules = slices.DeleteFunc(rules, func(rule models.ReplicationRule) bool { return !access.seesReplication(&rule) })
+	}
+	h.successResponse(w, rules)
+}
+
+// seesReplication reports whether the user sees both registries of a replication rule
+func (a *projectAccess) seesReplication(rule *models.ReplicationRule) bool {
+	return a.seesRegistry(rule.SourceRegistryID) && a.seesRegistry(rule.TargetRegistryID)
+}
+
+// replicationRule returns a rule the caller sees, writing a 404 when there is none
+func (h *Handler) replicationRule(w http.ResponseWriter, r *http.Request) *models.ReplicationRule {
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid replication rule ID")
+		return nil
+	}
+	rule, err := h.db.GetReplicationRule(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Replication rule not found")
+		return nil
+	}
+	access, err := h.projectAccess(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return nil
+	}
+	if access != nil && !access.seesReplication(rule) {
+		h.errorResponse(w, http.StatusNotFound, "Replication rule not found")
+		return nil
+	}
+	return rule
+}
+
+// CreateReplicationRule adds a replication rule (admin only)
+func (h *Handler) CreateReplicationRule(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	rule := models.ReplicationRule{Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	rule.ID = 0
+	h.saveReplicationRule(w, r, &rule)
+}
+
+// UpdateReplicationRule replaces a replication rule (admin only)
+func (h *Handler) UpdateReplicationRule(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid replication rule ID")
+		return
+	}
+
+	var rule models.ReplicationRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	rule.ID = id
+	h.saveReplicationRule(w, r, &rule)
+}
+
+func (h *Handler) saveReplicationRule(w http.ResponseWriter, r *http.Request, rule *models.ReplicationRule) {
+	if rule.Name == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Rule name is required")
+		return
+	}
+	if _, err := h.db.GetRegistry(rule.SourceRegistryID); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Source registry not found")
+		return
+	}
+	if _, err := h.db.GetRegistry(rule.TargetRegistryID); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Target registry not found")
+		return
+	}
+	if rule.SourceRegistryID == rule.TargetRegistryID && rule.TargetPrefix == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Replicating a registry into itself needs a target prefix")
+		return
+	}
+	switch rule.Conflict {
+	case "":
+		rule.Conflict = tasks.ConflictOverwrite
+	case tasks.ConflictOverwrite, tasks.ConflictSkip:
+	default:
+		h.errorResponse(w, http.StatusBadRequest, "Conflict must be overwrite or skip")
+		return
+	}
+	if rule.IntervalMinutes < 0 {
+		rule.IntervalMinutes = 0
+	}
+	if _, _, err := tasks.CompileReplicationFilters(rule); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.db.SaveReplicationRule(rule); err != nil {
+		if err == sql.ErrNoRows {
+			h.errorResponse(w, http.StatusNotFound, "Replication rule not found")
+			return
+		}
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to save replication rule: %v", err))
+		return
+	}
+	h.audit(r, "replication.save", rule.Name, "")
+	h.successResponse(w, rule)
+}
+
+// DeleteReplicationRule stops replicating; images already in the target registry are kept (admin only)
+func (h *Handler) DeleteReplicationRule(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid replication rule ID")
+		return
+	}
+	if err := h.db.DeleteReplicationRule(id); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.audit(r, "replication.delete", r.PathValue("id"), "")
+	h.messageResponse(w, "Replication rule deleted")
+}
+
+// RunReplicationRule starts a run of a replication rule (admin only); poll GET /api/v1/replications/{id}/status for progress
+func (h *Handler) RunReplicationRule(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	rule := h.replicationRule(w, r)
+	if rule == nil {
+		return
+	}
+
+	run, err := tasks.StartReplication(h.db, rule, "manual", func(run models.ReplicationRun) {
+		if run.Copied > 0 || run.Deleted > 0 {
+			h.invalidateListings(rule.TargetRegistryID)
+		}
+	})
+	if err != nil {
+		h.errorResponse(w, http.StatusConflict, "Replication is already running")
+		return
+	}
+	h.audit(r, "replication.run", rule.Name, "")
+	h.jsonResponse(w, http.StatusAccepted, models.APIResponse{Success: true, Data: run})
+}
+
+// GetReplicationStatus returns the progress of the running or latest run of a replication rule
+func (h *Handler) GetReplicationStatus(w http.ResponseWriter, r *http.Request) {
+	rule := h.replicationRule(w, r)
+	if rule == nil {
+		return
+	}
+	id := rule.ID
+	if run, ok := tasks.ReplicationStatus(id); ok {
+		h.successResponse(w, run)
+		return
+	}
+	// Runs of an earlier process are only in the history
+	runs, err := h.db.ListReplicationRuns(id, 1)
+	if err != nil || len(runs) == 0 {
+		h.errorResponse(w, http.StatusNotFound, "The rule has not run yet")
+		return
+	}
+	h.successResponse(w, runs[0])
+}
+
+// ListReplicationRuns returns the history of finished runs of a replication rule, newest first (?limit=, default 20)
+func (h *Handler) ListReplicationRuns(w http.ResponseWriter, r *http.Request) {
+	rule := h.replicationRule(w, r)
+	if rule == nil {
+		return
+	}
+	id := rule.ID
+	limit := 20
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = l
+	}
+	runs, err := h.db.ListReplicationRuns(id, limit)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.successResponse(w, runs)
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// maxReadmeBytes caps the size of a repository README
+const maxReadmeBytes = 1 << 20
+
+// maxRepositoryMetadataBytes caps the request body, leaving room for JSON escaping of the README
+const maxRepositoryMetadataBytes = 2 * maxReadmeBytes
+
+// --- Repository Descriptions ---
+
+// GetRepositoryMetadata returns the description, README, owner and links of a repository (?repo=)
+func (h *Handler) GetRepositoryMetadata(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	repoName := r.URL.Query().Get("repo")
+	if repoName == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Repository name is required")
+		return
+	}
+
+	meta, err := h.repositoryMetadata(id, repoName)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.successResponse(w, meta)
+}
+
+// SaveRepositoryMetadata replaces the description, README, owner and links of a repository (?repo=)
+func (h *Handler) SaveRepositoryMetadata(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	repoName := r.URL.Query().Get("repo")
+	if repoName == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Repository name is required")
+		return
+	}
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+
+	var meta models.RepositoryMetadata
+	r.Body = http.MaxBytesReader(w, r.Body, maxRepositoryMetadataBytes)
+	if err := json.NewDecoder(r.Body).Decode(&meta); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.errorResponse(w, http.StatusRequestEntityTooLarge, h.tr(w, "The README is larger than %d bytes", maxReadmeBytes))
+			return
+		}
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	meta.Description = strings.TrimSpace(meta.Description)
+	meta.Owner = strings.TrimSpace(meta.Owner)
+	if len(meta.Readme) > maxReadmeBytes {
+		h.errorResponse(w, http.StatusRequestEntityTooLarge, h.tr(w, "The README is larger than %d bytes", maxReadmeBytes))
+		return
+	}
+	for i, link := range meta.Links {
+		link.Title, link.URL = strings.TrimSpace(link.Title), strings.TrimSpace(link.URL)
+		if !strings.HasPrefix(link.URL, "http://") && !strings.HasPrefix(link.URL, "https://") {
+			h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Link %q must start with http:// or https://", link.URL))
+			return
+		}
+		if link.Title == "" {
+			link.Title = link.URL
+		}
+		meta.Links[i] = link
+	}
+	meta.RegistryID = id
+	meta.Repository = repoName
+	meta.UpdatedBy, _ = requestActor(r)
+
+	// The owner lives with the rest of the repository info, which the catalog sync and
+	// onboarding rules also maintain
+	info, err := h.db.GetRepositoryInfo(id, repoName)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if info.Owner != meta.Owner {
+		info.Owner = meta.Owner
+		if err := h.db.SaveRepositoryInfo(info); err != nil {
+			h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to save repository info: %v", err))
+			return
+		}
+	}
+	if err := h.db.SaveRepoMetadata(&meta); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to save repository metadata: %v", err))
+		return
+	}
+	h.audit(r, "repository.metadata", reg.Name+"/"+repoName, "")
+	h.successResponse(w, meta)
+}
+
+// repositoryMetadata returns the metadata of a repository with its owner
+func (h *Handler) repositoryMetadata(registryID int64, repo string) (*models.RepositoryMetadata, error) {
+	meta, err := h.db.GetRepoMetadata(registryID, repo)
+	if err != nil {
+		return nil, err
+	}
+	info, err := h.db.GetRepositoryInfo(registryID, repo)
+	if err != nil {
+		return nil, err
+	}
+	meta.Owner = info.Owner
+	return meta, nil
+}
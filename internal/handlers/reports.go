@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+
+	"docker-registry-dashboard/internal/database"
+	"docker-registry-dashboard/internal/report"
+)
+
+// defaultReportTop is how many vulnerabilities and fixable packages a report lists when it does not say
+const defaultReportTop = 20
+
+// ExportScanReport renders a downloadable report of the latest scans for auditors: summary counts,
+// the most severe vulnerabilities and the package upgrades that fix them
+// (?registry_id=&repo=&tag=&top=&format=html|pdf|json). Without repo it covers the whole registry.
+func (h *Handler) ExportScanReport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	id, err := strconv.ParseInt(q.Get("registry_id"), 10, 64)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	repo, tag := q.Get("repo"), q.Get("tag")
+	if tag != "" && repo == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Repository name is required (query param: repo)")
+		return
+	}
+	top := defaultReportTop
+	if v := q.Get("top"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid top")
+			return
+		}
+		top = min(n, maxPageSize)
+	}
+	format := q.Get("format")
+	if format == "" {
+		format = "html"
+	}
+	if format != "html" && format != "pdf" && format != "json" {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid format (html, pdf or json)")
+		return
+	}
+
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+	filter := database.FindingFilter{RegistryID: id, Repository: repo, Tag: tag}
+	if repo != "" && !h.allowRepository(w, r, id, repo, false) || !h.scopeFindings(w, r, &filter) {
+		return
+	}
+	rep, err := h.db.ScanReport(filter, top)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to build report: %v", err))
+		return
+	}
+	rep.RegistryName = reg.Name
+	rep.GeneratedAt = time.Now()
+	if repo != "" && len(rep.Images) == 0 {
+		h.errorResponse(w, http.StatusNotFound, "No completed scan found")
+		return
+	}
+
+	if format == "json" {
+		h.successResponse(w, rep)
+		return
+	}
+
+	// Render fully before writing so a failure can still be reported as an error
+	var buf bytes.Buffer
+	contentType := "text/html; charset=utf-8"
+	if format == "pdf" {
+		contentType = "application/pdf"
+		err = report.PDF(&buf, rep)
+	} else {
+		err = report.HTML(&buf, rep)
+	}
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to build report: %v", err))
+		return
+	}
+	h.audit(r, "report.export", report.Scope(rep), format)
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+report.Filename(rep, format)+`"`)
+	w.Write(buf.Bytes())
+}
@@ -2,12 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
+	"regexp"
 	"strconv"
 
 	"docker-registry-dashboard/internal/models"
 	"docker-registry-dashboard/internal/registry"
+	"docker-registry-dashboard/internal/tasks"
 )
 
 // GetRetentionPolicy retrieves the retention policy for a registry
@@ -21,7 +22,7 @@ func (h *Handler) GetRetentionPolicy(w http.ResponseWriter, r *http.Request) {
 
 	policy, err := h.db.GetRetentionPolicy(id)
 	if err != nil {
-		h.errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get retention policy: %v", err))
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to get retention policy: %v", err))
 		return
 	}
 	h.successResponse(w, policy)
@@ -42,16 +43,33 @@ func (h *Handler) SaveRetentionPolicy(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	for _, rule := range policy.Rules {
+		if _, err := regexp.Compile(rule.TagPattern); err != nil || rule.TagPattern == "" {
+			h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Invalid tag pattern %q", rule.TagPattern))
+			return
+		}
+		if rule.KeepCount < 0 || rule.KeepDays < 0 {
+			h.errorResponse(w, http.StatusBadRequest, "Rule keep counts must not be negative")
+			return
+		}
+	}
+
+	if policy.MaxRepoSizeGB < 0 {
+		h.errorResponse(w, http.StatusBadRequest, "Size budget must not be negative")
+		return
+	}
+
 	policy.RegistryID = id
 	if err := h.db.SaveRetentionPolicy(&policy); err != nil {
-		h.errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to save policy: %v", err))
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to save policy: %v", err))
 		return
 	}
 
 	h.successResponse(w, policy)
 }
 
-// RunRetention executes the retention policy
+// RunRetention executes the retention policy, skipping the repositories the caller's access rules
+// do not let them delete in and keeping immutable tags
 func (h *Handler) RunRetention(w http.ResponseWriter, r *http.Request) {
 	idStr := r.PathValue("id")
 	id, err := strconv.ParseInt(idStr, 10, 64)
@@ -82,15 +100,56 @@ func (h *Handler) RunRetention(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logs, err := registry.RunRetention(reg, policy)
+	templates, err := h.db.RepositoryRetentionTemplates(id)
 	if err != nil {
-		h.errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Retention run failed: %v", err))
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to load retention templates: %v", err))
+		return
+	}
+	// The caller's access rules may limit the repositories they can delete in
+	allowed, err := h.actionScope(r, id, actionDelete)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	immutable, err := h.immutableTags(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	// A shutdown waits for the run and, once its grace period is over, stops it before the next delete
+	done := tasks.BeginWork()
+	logs, err := registry.RunRetentionWhere(tasks.WorkContext(), reg, policy, templates, allowed, immutable)
+	done()
+	if err != nil && !tasks.Interrupted() {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Retention run failed: %v", err))
 		return
 	}
 
 	// Update last run timestamp if successful
-	if !policy.DryRun {
+	if err == nil && !policy.DryRun {
 		h.db.UpdateRetentionLastRun(id)
+		h.invalidateListings(id)
+	}
+
+	for _, l := range logs {
+		if l.Action != "deleted" {
+			continue
+		}
+		policyName := "registry retention policy"
+		if t, ok := templates[l.Repository]; ok {
+			policyName = "retention template " + t.Name
+		}
+		h.recordDeletion(r, reg, models.DeletedImage{
+			Repository: l.Repository, Tag: l.Tag, Digest: l.Digest, Size: l.Size,
+			Source: "retention", Policy: policyName, Reason: l.Reason,
+		})
+	}
+	if err != nil {
+		// Interrupted by a shutdown: the images deleted until then are recorded above
+		h.invalidateListings(id)
+		h.errorResponse(w, http.StatusServiceUnavailable, h.tr(w, "Retention run failed: %v", err))
+		return
 	}
 
 	h.successResponse(w, logs)
@@ -0,0 +1,437 @@
+// Command routegen generates the API route metadata behind /api/v1/openapi.json. It reads the routes
+// registered in main.go and, for each handler, its doc comment, the query parameters it reads, the
+// request body it decodes and the content types it writes. Run it with `go generate ./...` after
+// adding or changing a route.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// route is one mux.HandleFunc registration with what was learned about its handler
+type route struct {
+	Method, Pattern, Handler, Group string
+	Doc                             string
+	Query                           []string
+	Body                            string // Go type expression of the decoded request body
+	HasBody                         bool
+	Produces                        []string
+}
+
+func main() {
+	mainFile := flag.String("main", "../../main.go", "File registering the routes")
+	handlersDir := flag.String("handlers", ".", "Directory of the handlers package")
+	out := flag.String("out", "routes_gen.go", "Generated file")
+	flag.Parse()
+
+	fset := token.NewFileSet()
+	routes, err := parseRoutes(fset, *mainFile)
+	if err != nil {
+		log.Fatalf("routegen: %v", err)
+	}
+
+	pkg, err := loadPackage(fset, *handlersDir)
+	if err != nil {
+		log.Fatalf("routegen: %v", err)
+	}
+	for i := range routes {
+		fn, ok := pkg.funcs[routes[i].Handler]
+		if !ok {
+			log.Fatalf("routegen: handler %s of %s %s not found", routes[i].Handler, routes[i].Method, routes[i].Pattern)
+		}
+		pkg.describe(&routes[i], fn)
+	}
+
+	src, err := pkg.render(routes)
+	if err != nil {
+		log.Fatalf("routegen: %v", err)
+	}
+	if err := os.WriteFile(*out, src, 0644); err != nil {
+		log.Fatalf("routegen: %v", err)
+	}
+}
+
+// parseRoutes returns the routes registered with mux.HandleFunc("METHOD /path", h.Handler), grouped
+// by the comment line above each block of registrations
+func parseRoutes(fset *token.FileSet, path string) ([]route, error) {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	// Comments on lines of their own (not trailing a statement), by the line they end on
+	headings := make(map[int]string)
+	for _, cg := range file.Comments {
+		offset := fset.Position(cg.Pos()).Offset
+		lineStart := bytes.LastIndexByte(src[:offset], '\n') + 1
+		if len(bytes.TrimSpace(src[lineStart:offset])) == 0 {
+			headings[fset.Position(cg.End()).Line] = strings.TrimSpace(cg.Text())
+		}
+	}
+
+	var routes []route
+	group := ""
+	ast.Inspect(file, func(n ast.Node) bool {
+		stmt, ok := n.(*ast.ExprStmt)
+		if !ok {
+			return true
+		}
+		call, ok := stmt.X.(*ast.CallExpr)
+		if !ok || len(call.Args) != 2 || !isSelector(call.Fun, "mux", "HandleFunc") {
+			return true
+		}
+		lit, ok := call.Args[0].(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return true
+		}
+		pattern, _ := strconv.Unquote(lit.Value)
+		method, path, ok := strings.Cut(pattern, " ")
+		if !ok {
+			return true
+		}
+		sel, ok := call.Args[1].(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if g, ok := headings[fset.Position(stmt.Pos()).Line-1]; ok {
+			group = g
+		}
+		routes = append(routes, route{Method: method, Pattern: path, Handler: sel.Sel.Name, Group: group})
+		return true
+	})
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("no routes found in %s", path)
+	}
+	return routes, nil
+}
+
+func isSelector(e ast.Expr, x, name string) bool {
+	sel, ok := e.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != name {
+		return false
+	}
+	id, ok := sel.X.(*ast.Ident)
+	return ok && id.Name == x
+}
+
+// handlerPackage is the parsed handlers package: its functions and methods by name, and the
+// import paths of the package names each file uses
+type handlerPackage struct {
+	fset    *token.FileSet
+	name    string
+	funcs   map[string]*ast.FuncDecl
+	imports map[*ast.FuncDecl]map[string]string
+	used    map[string]string // Imports needed by the generated file, by package name
+}
+
+func loadPackage(fset *token.FileSet, dir string) (*handlerPackage, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	pkg := &handlerPackage{fset: fset, funcs: map[string]*ast.FuncDecl{}, imports: map[*ast.FuncDecl]map[string]string{}, used: map[string]string{}}
+	for _, path := range files {
+		if strings.HasSuffix(path, "_gen.go") || strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		pkg.name = file.Name.Name
+		imports := map[string]string{}
+		for _, imp := range file.Imports {
+			p, _ := strconv.Unquote(imp.Path.Value)
+			name := filepath.Base(p)
+			if imp.Name != nil {
+				name = imp.Name.Name
+			}
+			imports[name] = p
+		}
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Body != nil {
+				pkg.funcs[fn.Name.Name] = fn
+				pkg.imports[fn] = imports
+			}
+		}
+	}
+	return pkg, nil
+}
+
+// describe fills in what a route's handler reads and writes, following the calls it makes into
+// other functions of the package
+func (pkg *handlerPackage) describe(rt *route, fn *ast.FuncDecl) {
+	if fn.Doc != nil {
+		rt.Doc = strings.TrimSpace(fn.Doc.Text())
+	}
+	query := map[string]bool{}
+	produces := map[string]bool{}
+	pkg.walk(fn, map[*ast.FuncDecl]bool{}, query, produces)
+
+	// The request body, from json.NewDecoder(r.Body).Decode(&v) in the handler itself
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || rt.HasBody || !isBodyDecode(call) {
+			return true
+		}
+		rt.HasBody = true
+		if u, ok := call.Args[0].(*ast.UnaryExpr); ok && u.Op == token.AND {
+			if id, ok := u.X.(*ast.Ident); ok {
+				rt.Body = pkg.declaredType(fn, id.Name)
+			}
+		}
+		return true
+	})
+
+	// Handlers writing documents may still answer some requests with the JSON envelope
+	if len(produces) > 0 {
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			if call, ok := n.(*ast.CallExpr); ok {
+				if sel, ok := call.Fun.(*ast.SelectorExpr); ok && (sel.Sel.Name == "successResponse" || sel.Sel.Name == "messageResponse" || sel.Sel.Name == "jsonResponse") {
+					produces["application/json"] = true
+				}
+			}
+			return true
+		})
+	}
+
+	rt.Query = sortedKeys(query)
+	rt.Produces = sortedKeys(produces)
+}
+
+// walk collects the query parameters and content types of fn and of the package functions it calls
+func (pkg *handlerPackage) walk(fn *ast.FuncDecl, seen map[*ast.FuncDecl]bool, query, produces map[string]bool) {
+	if seen[fn] {
+		return
+	}
+	seen[fn] = true
+
+	// Names holding the query values: url.Values parameters and r.URL.Query() results
+	aliases := map[string]bool{}
+	for _, field := range fn.Type.Params.List {
+		if isSelector(field.Type, "url", "Values") {
+			for _, name := range field.Names {
+				aliases[name.Name] = true
+			}
+		}
+	}
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if as, ok := n.(*ast.AssignStmt); ok && len(as.Lhs) == 1 && len(as.Rhs) == 1 && isQueryCall(as.Rhs[0]) {
+			if id, ok := as.Lhs[0].(*ast.Ident); ok {
+				aliases[id.Name] = true
+			}
+		}
+		return true
+	})
+
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok {
+			return true
+		}
+		switch fun := call.Fun.(type) {
+		case *ast.SelectorExpr:
+			// q.Get("name"), r.URL.Query().Get("name")
+			if (fun.Sel.Name == "Get" || fun.Sel.Name == "Has") && len(call.Args) == 1 {
+				if id, ok := fun.X.(*ast.Ident); (ok && aliases[id.Name]) || isQueryCall(fun.X) {
+					if name, ok := stringLit(call.Args[0]); ok {
+						query[name] = true
+					}
+				}
+			}
+			// w.Header().Set("Content-Type", "...")
+			if fun.Sel.Name == "Set" && len(call.Args) == 2 {
+				if key, ok := stringLit(call.Args[0]); ok && key == "Content-Type" {
+					for _, ct := range stringValues(fn, call.Args[1]) {
+						ct = strings.TrimSpace(strings.Split(ct, ";")[0])
+						if ct != "application/json" {
+							produces[ct] = true
+						}
+					}
+				}
+			}
+			// h.helper(...)
+			if id, ok := fun.X.(*ast.Ident); ok && fn.Recv != nil && len(fn.Recv.List[0].Names) > 0 && id.Name == fn.Recv.List[0].Names[0].Name {
+				if callee, ok := pkg.funcs[fun.Sel.Name]; ok {
+					pkg.walk(callee, seen, query, produces)
+				}
+			}
+		case *ast.Ident:
+			if callee, ok := pkg.funcs[fun.Name]; ok {
+				pkg.walk(callee, seen, query, produces)
+			}
+		}
+		return true
+	})
+}
+
+// stringValues returns the value of a string literal, or the literals assigned in fn to a variable
+func stringValues(fn *ast.FuncDecl, e ast.Expr) []string {
+	if s, ok := stringLit(e); ok {
+		return []string{s}
+	}
+	id, ok := e.(*ast.Ident)
+	if !ok {
+		return nil
+	}
+	var values []string
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if as, ok := n.(*ast.AssignStmt); ok && len(as.Lhs) == 1 && len(as.Rhs) == 1 {
+			if lhs, ok := as.Lhs[0].(*ast.Ident); ok && lhs.Name == id.Name {
+				if s, ok := stringLit(as.Rhs[0]); ok {
+					values = append(values, s)
+				}
+			}
+		}
+		return true
+	})
+	return values
+}
+
+// isQueryCall reports whether e is X.URL.Query()
+func isQueryCall(e ast.Expr) bool {
+	call, ok := e.(*ast.CallExpr)
+	if !ok {
+		return false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Query" {
+		return false
+	}
+	inner, ok := sel.X.(*ast.SelectorExpr)
+	return ok && inner.Sel.Name == "URL"
+}
+
+// isBodyDecode reports whether call is json.NewDecoder(X.Body).Decode(...)
+func isBodyDecode(call *ast.CallExpr) bool {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Decode" || len(call.Args) != 1 {
+		return false
+	}
+	inner, ok := sel.X.(*ast.CallExpr)
+	if !ok || !isSelector(inner.Fun, "json", "NewDecoder") || len(inner.Args) != 1 {
+		return false
+	}
+	body, ok := inner.Args[0].(*ast.SelectorExpr)
+	return ok && body.Sel.Name == "Body"
+}
+
+func stringLit(e ast.Expr) (string, bool) {
+	lit, ok := e.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
+
+// declaredType returns the type of a variable declared in fn as `var name T`, recording the
+// imports the type needs, or "" when it cannot tell
+func (pkg *handlerPackage) declaredType(fn *ast.FuncDecl, name string) string {
+	var typ ast.Expr
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		spec, ok := n.(*ast.ValueSpec)
+		if !ok || spec.Type == nil || typ != nil {
+			return true
+		}
+		for _, id := range spec.Names {
+			if id.Name == name {
+				typ = spec.Type
+			}
+		}
+		return true
+	})
+	if typ == nil {
+		return ""
+	}
+
+	ast.Inspect(typ, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				if path, ok := pkg.imports[fn][id.Name]; ok {
+					pkg.used[id.Name] = path
+				}
+			}
+		}
+		return true
+	})
+	var buf bytes.Buffer
+	printer.Fprint(&buf, pkg.fset, typ)
+	return buf.String()
+}
+
+// render writes the generated Go file
+func (pkg *handlerPackage) render(routes []route) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("// Code generated by routegen from the routes in main.go and the handler doc comments. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package %s\n\n", pkg.name)
+	if len(pkg.used) > 0 {
+		b.WriteString("import (\n")
+		for _, name := range sortedKeys(boolSet(pkg.used)) {
+			if filepath.Base(pkg.used[name]) == name {
+				fmt.Fprintf(&b, "\t%q\n", pkg.used[name])
+			} else {
+				fmt.Fprintf(&b, "\t%s %q\n", name, pkg.used[name])
+			}
+		}
+		b.WriteString(")\n\n")
+	}
+
+	b.WriteString("var apiRoutes = []apiRoute{\n")
+	for _, rt := range routes {
+		b.WriteString("\t{\n")
+		fmt.Fprintf(&b, "\t\tMethod: %q,\n\t\tPattern: %q,\n\t\tHandler: %q,\n\t\tGroup: %q,\n", rt.Method, rt.Pattern, rt.Handler, rt.Group)
+		if rt.Doc != "" {
+			fmt.Fprintf(&b, "\t\tDoc: %q,\n", rt.Doc)
+		}
+		if len(rt.Query) > 0 {
+			fmt.Fprintf(&b, "\t\tQuery: %#v,\n", rt.Query)
+		}
+		if rt.HasBody {
+			b.WriteString("\t\tHasBody: true,\n")
+		}
+		if rt.Body != "" {
+			fmt.Fprintf(&b, "\t\tBody: %s{},\n", rt.Body)
+		}
+		if len(rt.Produces) > 0 {
+			fmt.Fprintf(&b, "\t\tProduces: %#v,\n", rt.Produces)
+		}
+		b.WriteString("\t},\n")
+	}
+	b.WriteString("}\n")
+	return format.Source(b.Bytes())
+}
+
+func boolSet(m map[string]string) map[string]bool {
+	s := make(map[string]bool, len(m))
+	for k := range m {
+		s[k] = true
+	}
+	return s
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
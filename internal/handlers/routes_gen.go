@@ -0,0 +1,1476 @@
+// Code generated by routegen from the routes in main.go and the handler doc comments. DO NOT EDIT.
+
+package handlers
+
+import (
+	"docker-registry-dashboard/internal/models"
+)
+
+var apiRoutes = []apiRoute{
+	{
+		Method:  "GET",
+		Pattern: "/healthz",
+		Handler: "Healthz",
+		Group:   "Health probes",
+		Doc:     "Healthz answers the liveness probe as long as the process serves requests",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/readyz",
+		Handler: "Readyz",
+		Group:   "Health probes",
+		Doc:     "Readyz answers the readiness probe with the state of every registered component, checked\nconcurrently, and 503 when a critical one fails.",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/dashboard/stats",
+		Handler: "GetDashboardStats",
+		Group:   "Dashboard",
+		Doc:     "GetDashboardStats returns the latest overview statistics snapshot. The snapshot is refreshed in\nthe background; it is only computed inline when none exists yet.",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/dashboard/stats/refresh",
+		Handler: "RefreshDashboardStats",
+		Group:   "Dashboard",
+		Doc:     "RefreshDashboardStats recomputes the overview statistics now and returns them",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/search",
+		Handler: "Search",
+		Group:   "Search",
+		Doc:     "Search finds repositories and tags by name across all registries (?q=&limit=). It reads the\nlocal search index, which catalog sync and registry webhooks keep current, so no registry is queried.",
+		Query:   []string{"limit", "q"},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries",
+		Handler: "ListRegistries",
+		Group:   "Registry CRUD",
+		Doc:     "ListRegistries returns all registries",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registries",
+		Handler: "CreateRegistry",
+		Group:   "Registry CRUD",
+		Doc:     "CreateRegistry adds a new registry",
+		HasBody: true,
+		Body:    models.Registry{},
+	},
+	{
+		Method:  "PUT",
+		Pattern: "/api/v1/registries/{id}",
+		Handler: "UpdateRegistry",
+		Group:   "Registry CRUD",
+		Doc:     "UpdateRegistry updates an existing registry",
+		HasBody: true,
+		Body:    models.Registry{},
+	},
+	{
+		Method:  "DELETE",
+		Pattern: "/api/v1/registries/{id}",
+		Handler: "DeleteRegistry",
+		Group:   "Registry CRUD",
+		Doc:     "DeleteRegistry removes a registry",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registries/{id}/test",
+		Handler: "TestRegistryConnection",
+		Group:   "Registry CRUD",
+		Doc:     "TestRegistryConnection tests the connection to a registry",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/size",
+		Handler: "GetRegistrySize",
+		Group:   "Registry CRUD",
+		Doc:     "GetRegistrySize returns the storage consumed per repository and for the whole registry,\nrecalculating it when the cached value is older than sizeCacheTTL or ?refresh=true",
+		Query:   []string{"refresh"},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/certificate",
+		Handler: "GetCertificateCheck",
+		Group:   "Registry CRUD",
+		Doc:     "GetCertificateCheck returns the latest connectivity and TLS certificate check of a registry",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registries/{id}/certificate/check",
+		Handler: "CheckCertificate",
+		Group:   "Registry CRUD",
+		Doc:     "CheckCertificate checks a registry's connectivity and TLS certificates now",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registries/{id}/certificate/accept",
+		Handler: "AcceptCertificateChain",
+		Group:   "Registry CRUD",
+		Doc:     "AcceptCertificateChain accepts a registry's changed certificate chain as the expected one",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/conformance",
+		Handler: "GetRegistryProfile",
+		Group:   "Registry CRUD",
+		Doc:     "GetRegistryProfile returns the capability profile of a registry from its latest conformance probe",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registries/{id}/conformance",
+		Handler: "ProbeConformance",
+		Group:   "Registry CRUD",
+		Doc:     "ProbeConformance runs the OCI distribution conformance checks against a registry and stores the\nresulting profile, which registry clients then use to choose how to list tags, resolve digests,\ndelete manifests and look up referrers",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/rate-limit",
+		Handler: "GetPullRateLimit",
+		Group:   "Registry CRUD",
+		Doc:     "GetPullRateLimit asks Docker Hub for the pull rate limit of a registry's credentials; the check\ndoes not count as a pull",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/harbor",
+		Handler: "GetHarborInfo",
+		Group:   "Registry CRUD",
+		Doc:     "GetHarborInfo detects whether a registry is Harbor and returns its system information",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/harbor/projects",
+		Handler: "ListHarborProjects",
+		Group:   "Registry CRUD",
+		Doc:     "ListHarborProjects returns the Harbor projects of a registry with their storage quota and usage",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/harbor/labels",
+		Handler: "ListHarborLabels",
+		Group:   "Registry CRUD",
+		Doc:     "ListHarborLabels returns the global Harbor labels, and those of ?project= when given",
+		Query:   []string{"project"},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/harbor/artifacts",
+		Handler: "ListHarborArtifacts",
+		Group:   "Registry CRUD",
+		Doc:     "ListHarborArtifacts returns the Harbor artifacts of ?repo= with their tags, labels, size and\npush and pull times",
+		Query:   []string{"repo"},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/credentials/rotation",
+		Handler: "GetCredentialRotation",
+		Group:   "Registry CRUD",
+		Doc:     "GetCredentialRotation returns the state of a registry's credential rotation (passwords are never returned)",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registries/{id}/credentials/rotation",
+		Handler: "StageCredentials",
+		Group:   "Registry CRUD",
+		Doc:     "StageCredentials stores a secondary credential set next to the active one",
+		HasBody: true,
+		Body:    StageCredentialsRequest{},
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registries/{id}/credentials/rotation/validate",
+		Handler: "ValidateCredentials",
+		Group:   "Registry CRUD",
+		Doc:     "ValidateCredentials checks the staged credentials against the registry without using them yet",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registries/{id}/credentials/rotation/switch",
+		Handler: "SwitchCredentials",
+		Group:   "Registry CRUD",
+		Doc:     "SwitchCredentials atomically makes the validated credentials active, keeping the old ones for rollback",
+		HasBody: true,
+		Body:    SwitchCredentialsRequest{},
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registries/{id}/credentials/rotation/rollback",
+		Handler: "RollbackCredentials",
+		Group:   "Registry CRUD",
+		Doc:     "RollbackCredentials restores the credentials replaced by the last switch while they are still kept",
+	},
+	{
+		Method:  "DELETE",
+		Pattern: "/api/v1/registries/{id}/credentials/rotation",
+		Handler: "DeleteCredentialRotation",
+		Group:   "Registry CRUD",
+		Doc:     "DeleteCredentialRotation discards staged credentials, or the kept old credentials after a switch",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/repositories",
+		Handler: "ListRepositories",
+		Group:   "Repository & Tag",
+		Doc:     "ListRepositories returns the repositories of a registry (cached; ?refresh=true bypasses the cache).\n?q= filters by name, ?sort=name|tag_count|size (prefix \"-\" for descending) orders them and\n?page=&page_size= select a page; without page_size every repository is returned.",
+		Query:   []string{"page", "page_size", "q", "refresh", "sort"},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/tags",
+		Handler: "ListTags",
+		Group:   "Repository & Tag",
+		Doc:     "ListTags returns the tags of a repository (cached; ?refresh=true bypasses the cache).\n?q= filters by name, ?sort=name|pull_count|push_count (prefix \"-\" for descending) orders them\nand ?page=&page_size= select a page; without page_size every tag is returned. ?digests=false\nskips looking up the digest of each tag listed.",
+		Query:   []string{"digests", "page", "page_size", "q", "refresh", "repo", "sort"},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/manifest",
+		Handler: "GetManifest",
+		Group:   "Repository & Tag",
+		Doc:     "GetManifest returns the manifest for a specific tag (optionally a platform of a multi-arch image)",
+		Query:   []string{"platform", "repo", "tag"},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/image-config",
+		Handler: "GetImageConfig",
+		Group:   "Repository & Tag",
+		Doc:     "GetImageConfig returns the runtime configuration, labels and history of an image",
+		Query:   []string{"platform", "repo", "tag"},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/layer-files",
+		Handler: "ListLayerFiles",
+		Group:   "Repository & Tag",
+		Doc:     "ListLayerFiles lists the files inside a layer (?repo=&digest=&path=&limit=)",
+		Query:   []string{"digest", "limit", "path", "repo"},
+	},
+	{
+		Method:  "DELETE",
+		Pattern: "/api/v1/registries/{id}/tag",
+		Handler: "DeleteTag",
+		Group:   "Repository & Tag",
+		Doc:     "DeleteTag deletes a tag from a repository",
+		Query:   []string{"repo", "tag"},
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registries/{id}/retag",
+		Handler: "RetagImage",
+		Group:   "Repository & Tag",
+		Doc:     "RetagImage re-PUTs the manifest of source_tag under target_tag in the same repository",
+		HasBody: true,
+		Body:    RetagRequest{},
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registries/{id}/repository/rename",
+		Handler: "RenameRepository",
+		Group:   "Repository & Tag",
+		Doc:     "RenameRepository starts moving a repository to a new name: every tag is copied at the manifest\nlevel (blobs are mounted, not uploaded), the copies are verified against the source digests and\nonly then is the source deleted. Poll GET /api/v1/registries/{id}/repository/rename/{job} for progress.",
+		HasBody: true,
+		Body:    RenameRequest{},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/repository/rename",
+		Handler: "ListRenameJobs",
+		Group:   "Repository & Tag",
+		Doc:     "ListRenameJobs returns the repository renames of a registry started since the dashboard started, newest first",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/repository/rename/{job}",
+		Handler: "GetRenameJob",
+		Group:   "Repository & Tag",
+		Doc:     "GetRenameJob returns the progress of a repository rename",
+	},
+	{
+		Method:   "POST",
+		Pattern:  "/api/v1/registries/{id}/export/oci",
+		Handler:  "ExportOCILayout",
+		Group:    "Repository & Tag",
+		Doc:      "ExportOCILayout writes the selected tags with all their platforms into one OCI image layout\narchive (a tar with index.json). The archive is streamed back as the response, or with\n\"destination\": \"s3\" uploaded to the export bucket by a job; poll\nGET /api/v1/registries/{id}/export/oci/{job} for its progress.",
+		HasBody:  true,
+		Body:     ExportRequest{},
+		Produces: []string{"application/json", "application/x-tar"},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/export/oci",
+		Handler: "ListExportJobs",
+		Group:   "Repository & Tag",
+		Doc:     "ListExportJobs returns the OCI layout exports of a registry started since the dashboard started, newest first",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/export/oci/{job}",
+		Handler: "GetExportJob",
+		Group:   "Repository & Tag",
+		Doc:     "GetExportJob returns the progress of an OCI layout export",
+	},
+	{
+		Method:   "GET",
+		Pattern:  "/api/v1/registries/{id}/export",
+		Handler:  "ExportImage",
+		Group:    "Repository & Tag",
+		Doc:      "ExportImage streams one tag (?repo=, ?tag=) with all its platforms back as an OCI image layout\narchive, which docker load (Docker 25 and later), skopeo and ctr import read",
+		Query:    []string{"repo", "tag"},
+		Produces: []string{"application/x-tar"},
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registries/{id}/import",
+		Handler: "ImportImages",
+		Group:   "Repository & Tag",
+		Doc:     "ImportImages pushes the images of an image tarball sent as the request body: a docker save\narchive or an OCI image layout (such as the dashboard's exports), optionally gzipped. The upload\nis spooled to a temporary file. Images keep the names they were saved under, without the\nregistry host; ?repository= renames them all, and ?tag= the image of a single-image archive.",
+		Query:   []string{"repository", "tag"},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/deleted-images",
+		Handler: "ListDeletedImages",
+		Group:   "Repository & Tag",
+		Doc:     "ListDeletedImages queries the deleted-image ledger (?digest=&registry_id=&repository=&limit=)",
+		Query:   []string{"digest", "limit", "registry_id", "repository"},
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/images/copy",
+		Handler: "CopyImage",
+		Group:   "Copy / promote images between registries",
+		Doc:     "CopyImage starts copying a repo:tag between registries; poll GET /api/v1/images/copy/{id} for progress",
+		HasBody: true,
+		Body:    CopyRequest{},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/images/copy",
+		Handler: "ListCopyJobs",
+		Group:   "Copy / promote images between registries",
+		Doc:     "ListCopyJobs returns the copy jobs started since the dashboard started, newest first",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/images/copy/{id}",
+		Handler: "GetCopyJob",
+		Group:   "Copy / promote images between registries",
+		Doc:     "GetCopyJob returns the progress of a copy job",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/images/sign",
+		Handler: "SignImage",
+		Group:   "Copy / promote images between registries",
+		Doc:     "SignImage signs an image digest with a signing key and pushes the cosign signature next to it,\nso a promotion step can sign what it promotes. Needs a signed-in user with push access to the\nrepository who may use the key.",
+		HasBody: true,
+		Body:    SignRequest{},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/retention",
+		Handler: "GetRetentionPolicy",
+		Group:   "Retention Policy",
+		Doc:     "GetRetentionPolicy retrieves the retention policy for a registry",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registries/{id}/retention",
+		Handler: "SaveRetentionPolicy",
+		Group:   "Retention Policy",
+		Doc:     "SaveRetentionPolicy saves the retention policy",
+		HasBody: true,
+		Body:    models.RetentionPolicy{},
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registries/{id}/retention/run",
+		Handler: "RunRetention",
+		Group:   "Retention Policy",
+		Doc:     "RunRetention executes the retention policy, skipping the repositories the caller's access rules\ndo not let them delete in and keeping immutable tags",
+		Query:   []string{"dry_run"},
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registries/{id}/sync",
+		Handler: "SyncRegistry",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "SyncRegistry walks a registry's catalog now and evaluates its image policy",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/image-policy",
+		Handler: "GetImagePolicy",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "GetImagePolicy retrieves the allowlist/denylist policy for a registry",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registries/{id}/image-policy",
+		Handler: "SaveImagePolicy",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "SaveImagePolicy saves the allowlist/denylist policy for a registry",
+		HasBody: true,
+		Body:    models.ImagePolicy{},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/compliance",
+		Handler: "GetComplianceReport",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "GetComplianceReport returns the policy violations found by the last sync (?registry_id= for one\nregistry), limited to the repositories the caller sees",
+		Query:   []string{"registry_id"},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/content-trust",
+		Handler: "GetContentTrust",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "GetContentTrust summarizes a registry's content trust: the share of images (tags) with a\ncompleted scan of their current digest, with a cosign or notation signature, and passing the gate\n(?max_critical=0&max_high=-1&require_signature=false, as with the gate command), overall and\nper namespace. Results are cached like catalog listings; ?refresh=true recomputes them.",
+		Query:   []string{"refresh", "require_signature"},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/notation-verification",
+		Handler: "VerifyNotationSignatures",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "VerifyNotationSignatures verifies the Notation signatures of the tags of a repository (?repo=,\nor one tag with ?tag=) against the trust policy, giving each tag's status: verified, failed,\nunsigned, skipped, no_policy or error",
+		Query:   []string{"repo", "tag"},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/attestations",
+		Handler: "GetImageAttestations",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "GetImageAttestations returns the in-toto attestations of a tag (?repo=&tag=) with their\nverification status and the SLSA provenance they carry: builder, source repository and commit",
+		Query:   []string{"repo", "tag"},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/signing-keys",
+		Handler: "ListSigningKeys",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "ListSigningKeys returns the image signing keys with their public keys",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/signing-keys",
+		Handler: "CreateSigningKey",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "CreateSigningKey adds a signing key (admin only): an uploaded cosign.key with its password, or\na newly generated key pair when no private key is given",
+		HasBody: true,
+		Body: struct {
+			Name       string  `json:"name"`
+			PrivateKey string  `json:"private_key"` // cosign.key; generated when empty
+			Password   string  `json:"password"`    // Of the uploaded private key
+			Projects   []int64 `json:"projects"`
+		}{},
+	},
+	{
+		Method:  "PUT",
+		Pattern: "/api/v1/signing-keys/{id}/projects",
+		Handler: "UpdateSigningKeyProjects",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "UpdateSigningKeyProjects replaces the projects whose maintainers may use a signing key (admin only)",
+		HasBody: true,
+		Body: struct {
+			Projects []int64 `json:"projects"`
+		}{},
+	},
+	{
+		Method:  "DELETE",
+		Pattern: "/api/v1/signing-keys/{id}",
+		Handler: "DeleteSigningKey",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "DeleteSigningKey removes a signing key (admin only)",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/notation/trust-policy",
+		Handler: "GetNotationTrustPolicy",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "GetNotationTrustPolicy returns the Notation trust policy (trustpolicy.json)",
+	},
+	{
+		Method:  "PUT",
+		Pattern: "/api/v1/notation/trust-policy",
+		Handler: "SaveNotationTrustPolicy",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "SaveNotationTrustPolicy replaces the Notation trust policy with a trustpolicy.json document (admin only)",
+		HasBody: true,
+		Body:    models.NotationTrustPolicyDocument{},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/notation/trust-stores",
+		Handler: "ListNotationTrustStores",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "ListNotationTrustStores returns the Notation trust stores with the subjects of their certificates",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/notation/trust-stores",
+		Handler: "CreateNotationTrustStore",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "CreateNotationTrustStore adds a trust store of PEM certificates (admin only)",
+		HasBody: true,
+		Body:    models.NotationTrustStore{},
+	},
+	{
+		Method:  "PUT",
+		Pattern: "/api/v1/notation/trust-stores/{id}",
+		Handler: "UpdateNotationTrustStore",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "UpdateNotationTrustStore replaces the certificates of a trust store (admin only)",
+		HasBody: true,
+		Body:    models.NotationTrustStore{},
+	},
+	{
+		Method:  "DELETE",
+		Pattern: "/api/v1/notation/trust-stores/{id}",
+		Handler: "DeleteNotationTrustStore",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "DeleteNotationTrustStore removes a trust store (admin only)",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/pins",
+		Handler: "ListTagPins",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "ListTagPins returns the pinned tags of a registry with their drift state",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registries/{id}/pins",
+		Handler: "PinTag",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "PinTag pins a tag to the digest it points at now. Pinning an already pinned tag accepts\nits current digest and clears the drift.",
+		HasBody: true,
+		Body:    models.TagPin{},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/pins/drifts",
+		Handler: "ListTagDrifts",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "ListTagDrifts returns the drifts of pinned tags found by catalog sync (?limit=, default 100)",
+		Query:   []string{"limit"},
+	},
+	{
+		Method:  "DELETE",
+		Pattern: "/api/v1/pins/{id}",
+		Handler: "DeleteTagPin",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "DeleteTagPin unpins a tag",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/immutable-tags",
+		Handler: "ListImmutableTagRules",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "ListImmutableTagRules returns the immutable tag rules of a registry",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registries/{id}/immutable-tags",
+		Handler: "CreateImmutableTagRule",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "CreateImmutableTagRule makes the tags matching a pattern immutable (admin only)",
+		HasBody: true,
+		Body:    models.ImmutableTagRule{},
+	},
+	{
+		Method:  "DELETE",
+		Pattern: "/api/v1/immutable-tags/{id}",
+		Handler: "DeleteImmutableTagRule",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "DeleteImmutableTagRule removes an immutable tag rule (admin only)",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/quotas",
+		Handler: "ListRepositoryQuotas",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "ListRepositoryQuotas returns the quotas with their last measured usage and state; users that\nprojects limit see the quotas of what they can see",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/quotas",
+		Handler: "CreateRepositoryQuota",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "CreateRepositoryQuota adds a quota (admin only)",
+		HasBody: true,
+		Body:    models.RepositoryQuota{},
+	},
+	{
+		Method:  "PUT",
+		Pattern: "/api/v1/quotas/{id}",
+		Handler: "UpdateRepositoryQuota",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "UpdateRepositoryQuota replaces the settings of a quota (admin only)",
+		HasBody: true,
+		Body:    models.RepositoryQuota{},
+	},
+	{
+		Method:  "DELETE",
+		Pattern: "/api/v1/quotas/{id}",
+		Handler: "DeleteRepositoryQuota",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "DeleteRepositoryQuota removes a quota (admin only)",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/quotas/{id}/check",
+		Handler: "CheckRepositoryQuota",
+		Group:   "Catalog sync & image compliance",
+		Doc:     "CheckRepositoryQuota measures the usage of a quota now instead of at the next hourly check (admin only)",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/repository-info",
+		Handler: "GetRepositoryInfo",
+		Group:   "Repository onboarding",
+		Doc:     "GetRepositoryInfo returns owner, labels and policy assignments of a repository (?repo=)",
+		Query:   []string{"repo"},
+	},
+	{
+		Method:  "PUT",
+		Pattern: "/api/v1/registries/{id}/repository-info",
+		Handler: "SaveRepositoryInfo",
+		Group:   "Repository onboarding",
+		Doc:     "SaveRepositoryInfo updates owner, labels and policy assignments of a repository (?repo=)",
+		Query:   []string{"repo"},
+		HasBody: true,
+		Body:    models.RepositoryInfo{},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/repository-metadata",
+		Handler: "GetRepositoryMetadata",
+		Group:   "Repository onboarding",
+		Doc:     "GetRepositoryMetadata returns the description, README, owner and links of a repository (?repo=)",
+		Query:   []string{"repo"},
+	},
+	{
+		Method:  "PUT",
+		Pattern: "/api/v1/registries/{id}/repository-metadata",
+		Handler: "SaveRepositoryMetadata",
+		Group:   "Repository onboarding",
+		Doc:     "SaveRepositoryMetadata replaces the description, README, owner and links of a repository (?repo=)",
+		Query:   []string{"repo"},
+		HasBody: true,
+		Body:    models.RepositoryMetadata{},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/onboarding-rules",
+		Handler: "ListOnboardingRules",
+		Group:   "Repository onboarding",
+		Doc:     "ListOnboardingRules returns the onboarding rules; users that projects limit only see the rules\nof the registries they see, not those applying to all registries",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/onboarding-rules",
+		Handler: "CreateOnboardingRule",
+		Group:   "Repository onboarding",
+		Doc:     "CreateOnboardingRule adds an onboarding rule (admin only)",
+		HasBody: true,
+		Body:    models.OnboardingRule{},
+	},
+	{
+		Method:  "PUT",
+		Pattern: "/api/v1/onboarding-rules/{id}",
+		Handler: "UpdateOnboardingRule",
+		Group:   "Repository onboarding",
+		Doc:     "UpdateOnboardingRule replaces an onboarding rule (admin only)",
+		HasBody: true,
+		Body:    models.OnboardingRule{},
+	},
+	{
+		Method:  "DELETE",
+		Pattern: "/api/v1/onboarding-rules/{id}",
+		Handler: "DeleteOnboardingRule",
+		Group:   "Repository onboarding",
+		Doc:     "DeleteOnboardingRule removes an onboarding rule (admin only)",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/projects",
+		Handler: "ListProjects",
+		Group:   "Projects and teams sharing the dashboard",
+		Doc:     "ListProjects returns all projects to admins, and to other users the projects their teams have\na role in, with that role",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/projects",
+		Handler: "CreateProject",
+		Group:   "Projects and teams sharing the dashboard",
+		Doc:     "CreateProject adds a project (admin only)",
+		HasBody: true,
+		Body:    models.Project{},
+	},
+	{
+		Method:  "PUT",
+		Pattern: "/api/v1/projects/{id}",
+		Handler: "UpdateProject",
+		Group:   "Projects and teams sharing the dashboard",
+		Doc:     "UpdateProject replaces a project (admin only)",
+		HasBody: true,
+		Body:    models.Project{},
+	},
+	{
+		Method:  "DELETE",
+		Pattern: "/api/v1/projects/{id}",
+		Handler: "DeleteProject",
+		Group:   "Projects and teams sharing the dashboard",
+		Doc:     "DeleteProject removes a project (admin only); its registries are not touched",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/teams",
+		Handler: "ListTeams",
+		Group:   "Projects and teams sharing the dashboard",
+		Doc:     "ListTeams returns all teams to admins, and to other users the teams they belong to",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/teams",
+		Handler: "CreateTeam",
+		Group:   "Projects and teams sharing the dashboard",
+		Doc:     "CreateTeam adds a team (admin only)",
+		HasBody: true,
+		Body:    models.Team{},
+	},
+	{
+		Method:  "PUT",
+		Pattern: "/api/v1/teams/{id}",
+		Handler: "UpdateTeam",
+		Group:   "Projects and teams sharing the dashboard",
+		Doc:     "UpdateTeam replaces a team (admin only)",
+		HasBody: true,
+		Body:    models.Team{},
+	},
+	{
+		Method:  "DELETE",
+		Pattern: "/api/v1/teams/{id}",
+		Handler: "DeleteTeam",
+		Group:   "Projects and teams sharing the dashboard",
+		Doc:     "DeleteTeam removes a team (admin only); its members keep their accounts",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/access-rules",
+		Handler: "ListAccessRules",
+		Group:   "Projects and teams sharing the dashboard",
+		Doc:     "ListAccessRules returns all access rules to admins, and to other users the rules of their teams",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/access-rules",
+		Handler: "CreateAccessRule",
+		Group:   "Projects and teams sharing the dashboard",
+		Doc:     "CreateAccessRule adds an access rule to a team (admin only)",
+		HasBody: true,
+		Body:    models.AccessRule{},
+	},
+	{
+		Method:  "PUT",
+		Pattern: "/api/v1/access-rules/{id}",
+		Handler: "UpdateAccessRule",
+		Group:   "Projects and teams sharing the dashboard",
+		Doc:     "UpdateAccessRule replaces an access rule (admin only)",
+		HasBody: true,
+		Body:    models.AccessRule{},
+	},
+	{
+		Method:  "DELETE",
+		Pattern: "/api/v1/access-rules/{id}",
+		Handler: "DeleteAccessRule",
+		Group:   "Projects and teams sharing the dashboard",
+		Doc:     "DeleteAccessRule removes an access rule (admin only)",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/applications",
+		Handler: "ListApplications",
+		Group:   "Applications across registries",
+		Doc:     "ListApplications returns all applications",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/applications",
+		Handler: "CreateApplication",
+		Group:   "Applications across registries",
+		Doc:     "CreateApplication adds an application of repositories the caller maintains",
+		HasBody: true,
+		Body:    models.Application{},
+	},
+	{
+		Method:  "PUT",
+		Pattern: "/api/v1/applications/{id}",
+		Handler: "UpdateApplication",
+		Group:   "Applications across registries",
+		Doc:     "UpdateApplication replaces an application; the caller must maintain the repositories of both\nthe current and the new environments",
+		HasBody: true,
+		Body:    models.Application{},
+	},
+	{
+		Method:  "DELETE",
+		Pattern: "/api/v1/applications/{id}",
+		Handler: "DeleteApplication",
+		Group:   "Applications across registries",
+		Doc:     "DeleteApplication removes an application of repositories the caller maintains",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/applications/{id}/view",
+		Handler: "GetApplicationView",
+		Group:   "Applications across registries",
+		Doc:     "GetApplicationView lists the tags of an application in every environment with their digests\nand whether the environments match (?tag=prod,latest limits the tags, ?refresh=true bypasses the cache)",
+		Query:   []string{"refresh", "tag"},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/seeds",
+		Handler: "ListSeedImages",
+		Group:   "Seeding public images into local registries",
+		Doc:     "ListSeedImages returns the public images mirrored by the seed job into the registries the caller sees",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/seeds",
+		Handler: "CreateSeedImage",
+		Group:   "Seeding public images into local registries",
+		Doc:     "CreateSeedImage adds an image to mirror (admin only)",
+		HasBody: true,
+	},
+	{
+		Method:  "PUT",
+		Pattern: "/api/v1/seeds/{id}",
+		Handler: "UpdateSeedImage",
+		Group:   "Seeding public images into local registries",
+		Doc:     "UpdateSeedImage replaces a seed image (admin only)",
+		HasBody: true,
+		Body:    models.SeedImage{},
+	},
+	{
+		Method:  "DELETE",
+		Pattern: "/api/v1/seeds/{id}",
+		Handler: "DeleteSeedImage",
+		Group:   "Seeding public images into local registries",
+		Doc:     "DeleteSeedImage stops mirroring an image; copies already in the target registry are kept (admin only)",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/seeds/{id}/run",
+		Handler: "RunSeedImage",
+		Group:   "Seeding public images into local registries",
+		Doc:     "RunSeedImage mirrors a seed image now and returns its updated state (admin only)",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/replications",
+		Handler: "ListReplicationRules",
+		Group:   "Replication between registries",
+		Doc:     "ListReplicationRules returns the rules replicating repositories between registries",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/replications",
+		Handler: "CreateReplicationRule",
+		Group:   "Replication between registries",
+		Doc:     "CreateReplicationRule adds a replication rule (admin only)",
+		HasBody: true,
+	},
+	{
+		Method:  "PUT",
+		Pattern: "/api/v1/replications/{id}",
+		Handler: "UpdateReplicationRule",
+		Group:   "Replication between registries",
+		Doc:     "UpdateReplicationRule replaces a replication rule (admin only)",
+		HasBody: true,
+		Body:    models.ReplicationRule{},
+	},
+	{
+		Method:  "DELETE",
+		Pattern: "/api/v1/replications/{id}",
+		Handler: "DeleteReplicationRule",
+		Group:   "Replication between registries",
+		Doc:     "DeleteReplicationRule stops replicating; images already in the target registry are kept (admin only)",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/replications/{id}/run",
+		Handler: "RunReplicationRule",
+		Group:   "Replication between registries",
+		Doc:     "RunReplicationRule starts a run of a replication rule (admin only); poll GET /api/v1/replications/{id}/status for progress",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/replications/{id}/status",
+		Handler: "GetReplicationStatus",
+		Group:   "Replication between registries",
+		Doc:     "GetReplicationStatus returns the progress of the running or latest run of a replication rule",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/replications/{id}/runs",
+		Handler: "ListReplicationRuns",
+		Group:   "Replication between registries",
+		Doc:     "ListReplicationRuns returns the history of finished runs of a replication rule, newest first (?limit=, default 20)",
+		Query:   []string{"limit"},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/defectdojo/mappings",
+		Handler: "ListDefectDojoMappings",
+		Group:   "DefectDojo export",
+		Doc:     "ListDefectDojoMappings returns the DefectDojo engagement mappings of the registries the caller sees",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/defectdojo/mappings",
+		Handler: "CreateDefectDojoMapping",
+		Group:   "DefectDojo export",
+		Doc:     "CreateDefectDojoMapping adds a DefectDojo engagement mapping (admin only)",
+		HasBody: true,
+	},
+	{
+		Method:  "PUT",
+		Pattern: "/api/v1/defectdojo/mappings/{id}",
+		Handler: "UpdateDefectDojoMapping",
+		Group:   "DefectDojo export",
+		Doc:     "UpdateDefectDojoMapping replaces a DefectDojo engagement mapping (admin only)",
+		HasBody: true,
+		Body:    models.DefectDojoMapping{},
+	},
+	{
+		Method:  "DELETE",
+		Pattern: "/api/v1/defectdojo/mappings/{id}",
+		Handler: "DeleteDefectDojoMapping",
+		Group:   "DefectDojo export",
+		Doc:     "DeleteDefectDojoMapping removes a DefectDojo engagement mapping (admin only)",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/defectdojo/mappings/{id}/export",
+		Handler: "ExportDefectDojoMapping",
+		Group:   "DefectDojo export",
+		Doc:     "ExportDefectDojoMapping exports the mapping's changed scans now (admin only)",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/retention-templates",
+		Handler: "ListRetentionTemplates",
+		Group:   "DefectDojo export",
+		Doc:     "ListRetentionTemplates returns all retention templates",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/retention-templates",
+		Handler: "CreateRetentionTemplate",
+		Group:   "DefectDojo export",
+		Doc:     "CreateRetentionTemplate adds a retention template (admin only)",
+		HasBody: true,
+		Body:    models.RetentionTemplate{},
+	},
+	{
+		Method:  "PUT",
+		Pattern: "/api/v1/retention-templates/{id}",
+		Handler: "UpdateRetentionTemplate",
+		Group:   "DefectDojo export",
+		Doc:     "UpdateRetentionTemplate replaces a retention template (admin only)",
+		HasBody: true,
+		Body:    models.RetentionTemplate{},
+	},
+	{
+		Method:  "DELETE",
+		Pattern: "/api/v1/retention-templates/{id}",
+		Handler: "DeleteRetentionTemplate",
+		Group:   "DefectDojo export",
+		Doc:     "DeleteRetentionTemplate removes a retention template (admin only)",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/scan/trigger",
+		Handler: "TriggerScan",
+		Group:   "Vulnerability Scanning",
+		Doc:     "TriggerScan initiates a vulnerability scan",
+		HasBody: true,
+		Body:    ScanRequest{},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/scan/result",
+		Handler: "GetScanResult",
+		Group:   "Vulnerability Scanning",
+		Doc:     "GetScanResult returns the latest scan for an image",
+		Query:   []string{"registry_id", "repository", "tag"},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/scan/list",
+		Handler: "ListScans",
+		Group:   "Vulnerability Scanning",
+		Doc:     "ListScans returns all scans for a registry",
+		Query:   []string{"registry_id"},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/scan/history",
+		Handler: "ListScanHistory",
+		Group:   "Vulnerability Scanning",
+		Doc:     "ListScanHistory returns the completed scans recorded for an image",
+		Query:   []string{"registry_id", "repo", "tag"},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/scan/diff",
+		Handler: "DiffScans",
+		Group:   "Vulnerability Scanning",
+		Doc:     "DiffScans compares two scans of the same image.\nfrom/to are scan history IDs; when omitted the two most recent scans are used.",
+		Query:   []string{"from", "registry_id", "repo", "tag", "to"},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/scan/storage",
+		Handler: "GetScanStorage",
+		Group:   "Vulnerability Scanning",
+		Doc:     "GetScanStorage returns the space taken by stored scan reports and the soft quota on it (admin only)",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/scan/workers",
+		Handler: "GetScanWorkers",
+		Group:   "Vulnerability Scanning",
+		Doc:     "GetScanWorkers returns the resource limits of scanner containers and how many run or wait for a slot (admin only)",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/vulnerabilities/list",
+		Handler: "ListVulnerabilities",
+		Group:   "Vulnerability Scanning",
+		Doc:     "ListVulnerabilities returns the findings of the latest completed scans of a registry's images.\nFilters: severity (comma-separated), package (substring), repository, tag, fixable=true and q\n(substring of the vulnerability ID or package); sort by severity (most severe first), vuln_id,\npackage, repository or scanned_at; page and page_size paginate. Everything runs in SQL.",
+		Query:   []string{"fixable", "package", "page", "page_size", "q", "registry_id", "repository", "severity", "sort", "tag"},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/vulnerabilities/search",
+		Handler: "SearchVulnerabilities",
+		Group:   "Vulnerability Scanning",
+		Doc:     "SearchVulnerabilities searches the findings of the latest scans by CVE ID, package name or\ndescription (?q=log4j&registry_id=&severity=&limit=) and returns the affected images",
+		Query:   []string{"limit", "q", "registry_id", "severity"},
+	},
+	{
+		Method:   "GET",
+		Pattern:  "/api/v1/reports/scan",
+		Handler:  "ExportScanReport",
+		Group:    "Vulnerability Scanning",
+		Doc:      "ExportScanReport renders a downloadable report of the latest scans for auditors: summary counts,\nthe most severe vulnerabilities and the package upgrades that fix them\n(?registry_id=&repo=&tag=&top=&format=html|pdf|json). Without repo it covers the whole registry.",
+		Query:    []string{"format", "registry_id", "repo", "tag", "top"},
+		Produces: []string{"application/json", "application/pdf", "text/html"},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registries/{id}/scan-policy",
+		Handler: "GetScanPolicy",
+		Group:   "Vulnerability Scanning",
+		Doc:     "GetScanPolicy returns the scheduler policy",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registries/{id}/scan-policy",
+		Handler: "SaveScanPolicy",
+		Group:   "Vulnerability Scanning",
+		Doc:     "SaveScanPolicy updates scheduler policy",
+		HasBody: true,
+		Body:    models.ScanPolicy{},
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registries/{id}/notifications",
+		Handler: "ReceiveDistributionEvents",
+		Group:   "Registry webhooks & activity feed",
+		Doc:     "ReceiveDistributionEvents handles Docker Distribution notifications",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registries/{id}/notifications/harbor",
+		Handler: "ReceiveHarborEvents",
+		Group:   "Registry webhooks & activity feed",
+		Doc:     "ReceiveHarborEvents handles Harbor webhooks",
+		HasBody: true,
+		Body:    harborPayload{},
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registries/{id}/notifications/gitlab",
+		Handler: "ReceiveGitLabEvents",
+		Group:   "Registry webhooks & activity feed",
+		Doc:     "ReceiveGitLabEvents handles notifications from the GitLab container registry",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/events",
+		Handler: "ListEvents",
+		Group:   "Registry webhooks & activity feed",
+		Doc:     "ListEvents returns the activity feed (?registry_id=&source=&action=&repository=&tag=&actor=\n&since=&until=&limit=); actor matches the user name or client address, since and until are\nRFC 3339 times, and source \"quota\" lists the scan reports evicted by the storage quota",
+		Query:   []string{"action", "actor", "limit", "registry_id", "repository", "source", "tag"},
+	},
+	{
+		Method:   "GET",
+		Pattern:  "/api/v1/registries/{id}/feed",
+		Handler:  "RepositoryFeed",
+		Group:    "Registry webhooks & activity feed",
+		Doc:      "RepositoryFeed serves an Atom feed of the pushes to a repository (?repo=&tag=&limit=), taken from\nwebhook events and from the new tags found by catalog syncs. Feed readers that cannot send an\nAuthorization header may pass an API token as ?token=.",
+		Query:    []string{"limit", "repo", "tag"},
+		Produces: []string{"application/atom+xml"},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/openapi.json",
+		Handler: "GetOpenAPISpec",
+		Group:   "API description",
+		Doc:     "GetOpenAPISpec serves an OpenAPI 3 description of the dashboard API, for Swagger UI and client generators",
+	},
+	{
+		Method:   "GET",
+		Pattern:  "/api/v1/client.js",
+		Handler:  "GetAPIClient",
+		Group:    "API description",
+		Doc:      "GetAPIClient serves a JavaScript client with one method per API operation, generated from the same routes",
+		Produces: []string{"text/javascript"},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/capabilities",
+		Handler: "GetCapabilities",
+		Group:   "Accounts, API tokens & audit log",
+		Doc:     "GetCapabilities describes the active authentication mechanisms and optional features. It is\nserved without authentication so clients can find out how to sign in.",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/auth/login",
+		Handler: "Login",
+		Group:   "Accounts, API tokens & audit log",
+		Doc:     "Login starts a session; the token is set as a cookie and also returned for non-browser clients",
+		HasBody: true,
+		Body:    LoginRequest{},
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/auth/logout",
+		Handler: "Logout",
+		Group:   "Accounts, API tokens & audit log",
+		Doc:     "Logout ends the caller's session",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/auth/me",
+		Handler: "CurrentUser",
+		Group:   "Accounts, API tokens & audit log",
+		Doc:     "CurrentUser returns the authenticated account",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/auth/password",
+		Handler: "ChangePassword",
+		Group:   "Accounts, API tokens & audit log",
+		Doc:     "ChangePassword sets a new password and ends the caller's other sessions",
+		HasBody: true,
+		Body:    ChangePasswordRequest{},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/auth/tokens",
+		Handler: "ListAPITokens",
+		Group:   "Accounts, API tokens & audit log",
+		Doc:     "ListAPITokens returns the caller's API tokens (without secrets)",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/auth/tokens",
+		Handler: "CreateAPIToken",
+		Group:   "Accounts, API tokens & audit log",
+		Doc:     "CreateAPIToken issues a token for the caller; its secret is only returned now",
+		HasBody: true,
+		Body:    APITokenRequest{},
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/auth/tokens/{id}/rotate",
+		Handler: "RotateAPIToken",
+		Group:   "Accounts, API tokens & audit log",
+		Doc:     "RotateAPIToken replaces the secret of one of the caller's tokens; the old secret stops working at once",
+		HasBody: true,
+		Body:    APITokenRequest{},
+	},
+	{
+		Method:  "DELETE",
+		Pattern: "/api/v1/auth/tokens/{id}",
+		Handler: "DeleteAPIToken",
+		Group:   "Accounts, API tokens & audit log",
+		Doc:     "DeleteAPIToken revokes one of the caller's tokens",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/users",
+		Handler: "ListUsers",
+		Group:   "Accounts, API tokens & audit log",
+		Doc:     "ListUsers returns all accounts (admin only)",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/users",
+		Handler: "CreateUser",
+		Group:   "Accounts, API tokens & audit log",
+		Doc:     "CreateUser adds an account (admin only)",
+		HasBody: true,
+		Body:    CreateUserRequest{},
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/users/{id}/expire-credentials",
+		Handler: "ExpireUserCredentials",
+		Group:   "Accounts, API tokens & audit log",
+		Doc:     "ExpireUserCredentials ends all sessions and/or revokes all API tokens of a user (admin only)",
+		HasBody: true,
+		Body:    ExpireCredentialsRequest{},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/audit",
+		Handler: "ListAuditLog",
+		Group:   "Accounts, API tokens & audit log",
+		Doc:     "ListAuditLog returns audit entries, newest first (admin only); ?actor= filters by user",
+		Query:   []string{"actor", "limit"},
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/config/reload",
+		Handler: "ReloadConfig",
+		Group:   "Accounts, API tokens & audit log",
+		Doc:     "ReloadConfig rereads the configuration file and applies changed settings without a restart",
+	},
+	{
+		Method:   "GET",
+		Pattern:  "/api/v1/admin/backup",
+		Handler:  "BackupDatabase",
+		Group:    "Accounts, API tokens & audit log",
+		Doc:      "BackupDatabase downloads a consistent snapshot of the dashboard database, taken with SQLite's\nonline backup API while the dashboard keeps running",
+		Produces: []string{"application/vnd.sqlite3"},
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/admin/restore",
+		Handler: "RestoreDatabase",
+		Group:   "Accounts, API tokens & audit log",
+		Doc:     "RestoreDatabase replaces the dashboard database with an uploaded backup (the raw file as the\nrequest body) and upgrades its schema. The backup is checked before anything is replaced.",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/maintenance",
+		Handler: "GetMaintenanceMode",
+		Group:   "Accounts, API tokens & audit log",
+		Doc:     "GetMaintenanceMode returns whether the dashboard is in read-only maintenance mode",
+	},
+	{
+		Method:  "PUT",
+		Pattern: "/api/v1/maintenance",
+		Handler: "SetMaintenanceMode",
+		Group:   "Accounts, API tokens & audit log",
+		Doc:     "SetMaintenanceMode turns read-only maintenance mode on or off, e.g. around registry garbage\ncollection or a database migration",
+		HasBody: true,
+		Body: struct {
+			Enabled bool   `json:"enabled"`
+			Reason  string `json:"reason"`
+		}{},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/storage",
+		Handler: "GetStorageConfig",
+		Group:   "Storage config",
+		Doc:     "GetStorageConfig returns the current storage configuration with its secrets masked (admin only)",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/storage",
+		Handler: "SaveStorageConfig",
+		Group:   "Storage config",
+		Doc:     "SaveStorageConfig saves the storage configuration and restarts the registry (admin only)",
+		HasBody: true,
+		Body:    models.StorageConfig{},
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/storage/test",
+		Handler: "TestStorageConnection",
+		Group:   "Storage config",
+		Doc:     "TestStorageConnection tests the storage backend connection (admin only)",
+		HasBody: true,
+		Body:    models.StorageConfig{},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registry/status",
+		Handler: "GetEmbeddedRegistryStatus",
+		Group:   "Embedded registry management",
+		Doc:     "GetEmbeddedRegistryStatus returns the status of the embedded registry",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registry/restart",
+		Handler: "RestartEmbeddedRegistry",
+		Group:   "Embedded registry management",
+		Doc:     "RestartEmbeddedRegistry restarts the embedded registry with current storage config (admin only)",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registry/stop",
+		Handler: "StopEmbeddedRegistry",
+		Group:   "Embedded registry management",
+		Doc:     "StopEmbeddedRegistry stops the embedded registry (admin only)",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registry/upgrade",
+		Handler: "UpgradeEmbeddedRegistry",
+		Group:   "Embedded registry management",
+		Doc:     "UpgradeEmbeddedRegistry moves the embedded registry container to another image, given as\n{\"image\": \"registry:2.8.3\"}, or without one to the newest build of its current image. The image\nis saved in the storage config once the registry runs on it.",
+		HasBody: true,
+		Body: struct {
+			Image string `json:"image"`
+		}{},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registry/settings",
+		Handler: "GetEmbeddedRegistrySettings",
+		Group:   "Embedded registry management",
+		Doc:     "GetEmbeddedRegistrySettings returns the host port and container name of the embedded registry (admin only)",
+	},
+	{
+		Method:  "PUT",
+		Pattern: "/api/v1/registry/settings",
+		Handler: "UpdateEmbeddedRegistrySettings",
+		Group:   "Embedded registry management",
+		Doc:     "UpdateEmbeddedRegistrySettings moves the embedded registry to another host port or container\nname, given as {\"port\": 5050, \"container_name\": \"registry\"}, recreating a running container. The\nregistry entries at its old URL follow it, and the settings are kept over the -registry-port flag.",
+		HasBody: true,
+		Body:    models.EmbeddedRegistrySettings{},
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registry/start",
+		Handler: "StartEmbeddedRegistry",
+		Group:   "Embedded registry management",
+		Doc:     "StartEmbeddedRegistry starts the embedded registry (admin only)",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registry/logs",
+		Handler: "GetEmbeddedRegistryLogs",
+		Group:   "Embedded registry management",
+		Doc:     "GetEmbeddedRegistryLogs returns recent container logs (admin only)",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registry/storage-usage",
+		Handler: "GetEmbeddedStorageUsage",
+		Group:   "Embedded registry management",
+		Doc:     "GetEmbeddedStorageUsage reports the bytes, blobs and uploads in the embedded registry's storage,\nread from the storage backend itself",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registry/config/preview",
+		Handler: "PreviewRegistryConfig",
+		Group:   "Embedded registry management",
+		Doc:     "PreviewRegistryConfig renders the registry config.yml for a storage config with secrets masked (admin only)",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registry/config/validate",
+		Handler: "ValidateRegistryConfig",
+		Group:   "Embedded registry management",
+		Doc:     "ValidateRegistryConfig renders a storage config and checks it in a throwaway registry container (admin only)",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registry/instances",
+		Handler: "ListRegistryInstances",
+		Group:   "Embedded registry management",
+		Doc:     "ListRegistryInstances returns the additional embedded registries with the status of those that run (admin only)",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registry/instances",
+		Handler: "CreateRegistryInstance",
+		Group:   "Embedded registry management",
+		Doc:     "CreateRegistryInstance provisions an additional embedded registry on its own port, with its own\nstorage and optionally an account, starts it and registers it as a registry entry",
+		HasBody: true,
+		Body:    models.ManagedRegistry{},
+	},
+	{
+		Method:  "DELETE",
+		Pattern: "/api/v1/registry/instances/{id}",
+		Handler: "DeleteRegistryInstance",
+		Group:   "Embedded registry management",
+		Doc:     "DeleteRegistryInstance stops an additional embedded registry and removes it with its registry\nentry. Its data directory is kept.",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registry/instances/{id}/start",
+		Handler: "StartRegistryInstance",
+		Group:   "Embedded registry management",
+		Doc:     "StartRegistryInstance starts an additional embedded registry, which then starts with the dashboard again (admin only)",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registry/instances/{id}/stop",
+		Handler: "StopRegistryInstance",
+		Group:   "Embedded registry management",
+		Doc:     "StopRegistryInstance stops an additional embedded registry until it is started again (admin only)",
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registry/users",
+		Handler: "ListRegistryUsers",
+		Group:   "Embedded registry management",
+		Doc:     "ListRegistryUsers returns the accounts of the embedded registry (admin only); with none it takes anonymous requests",
+	},
+	{
+		Method:  "POST",
+		Pattern: "/api/v1/registry/users",
+		Handler: "CreateRegistryUser",
+		Group:   "Embedded registry management",
+		Doc:     "CreateRegistryUser adds an account to the embedded registry's htpasswd file and restarts the\nregistry. The first account turns authentication on, so the dashboard then also creates its own\naccount for the Local Registry entry.",
+		HasBody: true,
+		Body:    CreateRegistryUserRequest{},
+	},
+	{
+		Method:  "DELETE",
+		Pattern: "/api/v1/registry/users/{id}",
+		Handler: "DeleteRegistryUser",
+		Group:   "Embedded registry management",
+		Doc:     "DeleteRegistryUser removes an account from the embedded registry and restarts it. Removing the\nlast account also removes the dashboard's own, which turns authentication off.",
+	},
+	{
+		Method:  "PUT",
+		Pattern: "/api/v1/registry/users/{id}/permissions",
+		Handler: "UpdateRegistryPermissions",
+		Group:   "Embedded registry management",
+		Doc:     "UpdateRegistryPermissions replaces the repository permissions of an embedded registry account,\nwhich the registry enforces when it uses token authentication (admin only)",
+		HasBody: true,
+		Body:    []models.RegistryPermission{},
+	},
+	{
+		Method:  "GET",
+		Pattern: "/api/v1/registry/token",
+		Handler: "IssueRegistryToken",
+		Group:   "Embedded registry management",
+		Doc:     "IssueRegistryToken is the token service of the embedded registry (the distribution token\nauthentication scheme). Clients send their registry account with basic auth and the scopes they\nneed (?scope=repository:team/app:pull,push, repeatable); the token grants the requested actions\nthe account's permissions allow. Requests without credentials get a token granting nothing.",
+	},
+}
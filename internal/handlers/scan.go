@@ -4,11 +4,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
+	"docker-registry-dashboard/internal/database"
+	"docker-registry-dashboard/internal/logging"
 	"docker-registry-dashboard/internal/models"
 	"docker-registry-dashboard/internal/scanner"
+	"docker-registry-dashboard/internal/tasks"
 )
 
 type ScanRequest struct {
@@ -16,7 +21,8 @@ type ScanRequest struct {
 	Repository string `json:"repository"`
 	Tag        string `json:"tag"`
 	Digest     string `json:"digest"`
-	Scanner    string `json:"scanner"` // "trivy" (default) or "osv"
+	Scanner    string `json:"scanner"`  // "trivy" (default) or "osv"
+	Platform   string `json:"platform"` // e.g. "linux/arm64" for multi-arch images (optional)
 }
 
 // TriggerScan initiates a vulnerability scan
@@ -46,10 +52,24 @@ func (h *Handler) TriggerScan(w http.ResponseWriter, r *http.Request) {
 		h.errorResponse(w, http.StatusNotFound, "Registry not found")
 		return
 	}
+	if !h.allowRepository(w, r, registry.ID, req.Repository, false) {
+		return
+	}
+
+	scan, err := h.startScan(registry, req)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to create scan record: %v", err))
+		return
+	}
 
+	h.successResponse(w, scan)
+}
+
+// startScan records a scan as in progress and runs the scanner in the background
+func (h *Handler) startScan(reg *models.Registry, req ScanRequest) (*models.VulnerabilityScan, error) {
 	// Create scan record
 	scan := &models.VulnerabilityScan{
-		RegistryID: req.RegistryID,
+		RegistryID: reg.ID,
 		Repository: req.Repository,
 		Tag:        req.Tag,
 		Digest:     req.Digest,
@@ -57,23 +77,34 @@ func (h *Handler) TriggerScan(w http.ResponseWriter, r *http.Request) {
 		ScannedAt:  time.Now(),
 	}
 
+	// Keep other scanners' results while this one runs
+	if existing, errGet := h.db.GetScan(reg.ID, req.Repository, req.Tag); errGet == nil {
+		scan.Report = existing.Report
+		scan.Summary = existing.Summary
+	}
+
 	if err := h.db.SaveScan(scan); err != nil {
-		h.errorResponse(w, http.StatusInternalServerError, fmt.Sprintf("Failed to create scan record: %v", err))
-		return
+		return nil, err
 	}
 
-	// Start async scan
-	go func(s *models.VulnerabilityScan, regURL string, scannerType string) {
+	// Start async scan; a shutdown waits for it and records it for requeue once its grace period is over
+	done := tasks.BeginWork()
+	go func(s *models.VulnerabilityScan, regURL, scannerType, platform string) {
+		defer done()
 		var report, summary string
 		var err error
 
 		if scannerType == "osv" {
-			report, summary, err = scanner.ScanImageOSV(regURL, s.Repository, s.Tag)
+			report, summary, err = scanner.ScanImageOSV(tasks.WorkContext(), regURL, s.Repository, s.Tag, platform)
 		} else {
 			if scannerType == "" {
 				scannerType = "trivy"
 			} // Default
-			report, summary, err = scanner.ScanImage(regURL, s.Repository, s.Tag)
+			report, summary, err = scanner.ScanImage(tasks.WorkContext(), regURL, s.Repository, s.Tag, platform)
+		}
+		if err != nil && tasks.Interrupted() {
+			tasks.MarkScanInterrupted(h.db, s, scannerType, platform)
+			return
 		}
 
 		// Fetch existing scan to merge
@@ -86,10 +117,10 @@ func (h *Handler) TriggerScan(w http.ResponseWriter, r *http.Request) {
 
 		if err != nil {
 			// Merge error instead of overwrite
-			errorJson := fmt.Sprintf(`{"error": "%s"}`, err.Error())
-			s.Report = mergeScanData(existingReport, scannerType, errorJson)
+			errorJSON, _ := json.Marshal(map[string]string{"error": err.Error()})
+			s.Report = scanner.MergeReport(existingReport, scannerType, string(errorJSON))
 			// Dummy summary for failed scan to ensure key existence
-			s.Summary = mergeScanData(existingSummary, scannerType, `{"Unknown":0}`)
+			s.Summary = scanner.MergeReport(existingSummary, scannerType, `{"Unknown":0}`)
 
 			// If other scanner data exists, don't mark as failed completely
 			if existingReport != "" && existingReport != "{}" {
@@ -98,11 +129,11 @@ func (h *Handler) TriggerScan(w http.ResponseWriter, r *http.Request) {
 				s.Status = "failed"
 			}
 		} else {
-			fmt.Printf("🎯 Scan successful! Report length: %d, Summary: %s\n", len(report), summary)
+			logging.Debugf("🎯 Scan successful! Report length: %d, Summary: %s", len(report), summary)
 			s.Status = "completed"
-			s.Report = mergeScanData(existingReport, scannerType, report)
-			s.Summary = mergeScanData(existingSummary, scannerType, summary)
-			fmt.Printf("📦 After merge - Report length: %d, Summary length: %d\n", len(s.Report), len(s.Summary))
+			s.Report = scanner.MergeReport(existingReport, scannerType, report)
+			s.Summary = scanner.MergeReport(existingSummary, scannerType, summary)
+			logging.Debugf("📦 After merge - Report length: %d, Summary length: %d", len(s.Report), len(s.Summary))
 		}
 		s.ScannedAt = time.Now()
 
@@ -110,11 +141,13 @@ func (h *Handler) TriggerScan(w http.ResponseWriter, r *http.Request) {
 		if err := h.db.SaveScan(s); err != nil {
 			fmt.Printf("❌ Failed to save scan result for scan %d: %v\n", s.ID, err)
 		} else {
-			fmt.Printf("✅ Scan result saved successfully!\n")
+			logging.Debugf("✅ Scan result saved successfully!")
+			tasks.ExportScanToDefectDojo(h.db, s)
 		}
-	}(scan, registry.URL, req.Scanner)
+	}(scan, reg.URL, req.Scanner, req.Platform)
+
+	return scan, nil
 
-	h.successResponse(w, scan)
 }
 
 // GetScanResult returns the latest scan for an image
@@ -136,6 +169,10 @@ func (h *Handler) GetScanResult(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !h.allowRepository(w, r, id, repo, false) {
+		return
+	}
+
 	scan, err := h.db.GetScan(id, repo, tag)
 	if err != nil {
 		// Not found is okay, return null/404?
@@ -167,15 +204,44 @@ func (h *Handler) ListScans(w http.ResponseWriter, r *http.Request) {
 		h.errorResponse(w, http.StatusInternalServerError, "Database error")
 		return
 	}
+	access, err := h.projectAccess(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if access != nil {
+		scans = slices.DeleteFunc(scans, func(s models.VulnerabilityScan) bool { return access.repositoryRole(id, s.Repository) == "" })
+	}
 	if scans == nil {
 		scans = []models.VulnerabilityScan{}
 	}
 	h.successResponse(w, scans)
 }
 
+// GetScanStorage returns the space taken by stored scan reports and the soft quota on it (admin only)
+func (h *Handler) GetScanStorage(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	usage, err := h.db.ScanStorageUsage()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	h.successResponse(w, usage)
+}
+
+// GetScanWorkers returns the resource limits of scanner containers and how many run or wait for a slot (admin only)
+func (h *Handler) GetScanWorkers(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	h.successResponse(w, scanner.Status())
+}
+
 // GetScanPolicy returns the scheduler policy
 func (h *Handler) GetScanPolicy(w http.ResponseWriter, r *http.Request) {
-	// Pattern: /api/registries/{id}/scan-policy
+	// Pattern: /api/v1/registries/{id}/scan-policy
 
 	idStr := r.PathValue("id")
 	if idStr == "" {
@@ -221,25 +287,13 @@ func (h *Handler) SaveScanPolicy(w http.ResponseWriter, r *http.Request) {
 	h.successResponse(w, map[string]string{"status": "saved"})
 }
 
-// VulnerabilityItem represents a single vulnerability finding
-type VulnerabilityItem struct {
-	ID           string    `json:"id"`
-	Package      string    `json:"package"`
-	Version      string    `json:"version"`
-	FixedVersion string    `json:"fixed_version"`
-	Severity     string    `json:"severity"`
-	Description  string    `json:"description"`
-	Scanner      string    `json:"scanner"` // "trivy" or "osv"
-	Repository   string    `json:"repository"`
-	Tag          string    `json:"tag"`
-	Digest       string    `json:"digest"`
-	RegistryID   int64     `json:"registry_id"`
-	ScannedAt    time.Time `json:"scanned_at"`
-}
-
-// ListVulnerabilities returns all vulnerabilities from all scans
+// ListVulnerabilities returns the findings of the latest completed scans of a registry's images.
+// Filters: severity (comma-separated), package (substring), repository, tag, fixable=true and q
+// (substring of the vulnerability ID or package); sort by severity (most severe first), vuln_id,
+// package, repository or scanned_at; page and page_size paginate. Everything runs in SQL.
 func (h *Handler) ListVulnerabilities(w http.ResponseWriter, r *http.Request) {
-	regID := r.URL.Query().Get("registry_id")
+	q := r.URL.Query()
+	regID := q.Get("registry_id")
 	if regID == "" {
 		h.errorResponse(w, http.StatusBadRequest, "Missing registry_id")
 		return
@@ -252,142 +306,116 @@ func (h *Handler) ListVulnerabilities(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	scans, err := h.db.ListScans(id)
-	if err != nil {
-		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+	page, ok := h.parsePageRequest(w, r, "severity", "vuln_id", "package", "repository", "scanned_at")
+	if !ok {
 		return
 	}
-
-	var vulnerabilities []VulnerabilityItem
-
-	for _, scan := range scans {
-		if scan.Status != "completed" || scan.Report == "" {
-			continue
-		}
-
-		// Parse report - it's wrapped with scanner keys
-		var reportWrapper map[string]json.RawMessage
-		if err := json.Unmarshal([]byte(scan.Report), &reportWrapper); err != nil {
-			continue
-		}
-
-		// Extract Trivy vulnerabilities
-		if trivyData, ok := reportWrapper["trivy"]; ok {
-			trivyVulns := extractTrivyVulnerabilities(trivyData, scan)
-			vulnerabilities = append(vulnerabilities, trivyVulns...)
-		}
-
-		// Extract OSV vulnerabilities
-		if osvData, ok := reportWrapper["osv"]; ok {
-			osvVulns := extractOSVVulnerabilities(osvData, scan)
-			vulnerabilities = append(vulnerabilities, osvVulns...)
+	filter := database.FindingFilter{
+		RegistryID:  id,
+		Package:     q.Get("package"),
+		Repository:  q.Get("repository"),
+		Tag:         q.Get("tag"),
+		FixableOnly: q.Get("fixable") == "true",
+		Query:       page.Query,
+		Sort:        page.Sort,
+		Desc:        page.Desc,
+	}
+	if v := q.Get("severity"); v != "" {
+		for _, s := range strings.Split(v, ",") {
+			filter.Severities = append(filter.Severities, strings.ToUpper(strings.TrimSpace(s)))
 		}
 	}
+	if !h.scopeFindings(w, r, &filter) {
+		return
+	}
 
-	h.successResponse(w, vulnerabilities)
-}
-
-func extractTrivyVulnerabilities(data json.RawMessage, scan models.VulnerabilityScan) []VulnerabilityItem {
-	var result []VulnerabilityItem
-
-	var trivyReport scanner.TrivyReport
-	if err := json.Unmarshal(data, &trivyReport); err != nil {
-		return result
-	}
-
-	for _, res := range trivyReport.Results {
-		for _, vuln := range res.Vulnerabilities {
-			item := VulnerabilityItem{
-				ID:           vuln.VulnerabilityID,
-				Package:      vuln.PkgName,
-				Version:      vuln.InstalledVersion,
-				FixedVersion: vuln.FixedVersion,
-				Severity:     vuln.Severity,
-				Description:  vuln.Title,
-				Scanner:      "Trivy",
-				Repository:   scan.Repository,
-				Tag:          scan.Tag,
-				Digest:       scan.Digest,
-				RegistryID:   scan.RegistryID,
-				ScannedAt:    scan.ScannedAt,
-			}
-			result = append(result, item)
-		}
+	total, err := h.db.CountFindings(filter)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
 	}
+	start, end, meta := page.bounds(total)
+	filter.Offset, filter.Limit = start, end-start
 
-	return result
+	vulnerabilities, err := h.db.ListFindings(filter)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	h.pageResponse(w, vulnerabilities, meta)
 }
 
-func extractOSVVulnerabilities(data json.RawMessage, scan models.VulnerabilityScan) []VulnerabilityItem {
-	var result []VulnerabilityItem
-
-	var osvOutput scanner.OSVOutput
-	if err := json.Unmarshal(data, &osvOutput); err != nil {
-		return result
-	}
-
-	for _, res := range osvOutput.Results {
-		for _, pkg := range res.Packages {
-			for _, vuln := range pkg.Vulnerabilities {
-				severity := "UNKNOWN"
-				if vuln.DatabaseSpecific != nil {
-					if s, ok := vuln.DatabaseSpecific["severity"].(string); ok {
-						severity = s
-					}
-				}
-				if severity == "UNKNOWN" && len(vuln.Severity) > 0 {
-					severity = vuln.Severity[0].Score
-				}
-
-				item := VulnerabilityItem{
-					ID:           vuln.ID,
-					Package:      pkg.Package.Name,
-					Version:      pkg.Package.Version,
-					FixedVersion: "",
-					Severity:     severity,
-					Description:  vuln.Summary,
-					Scanner:      "OSV",
-					Repository:   scan.Repository,
-					Tag:          scan.Tag,
-					Digest:       scan.Digest,
-					RegistryID:   scan.RegistryID,
-					ScannedAt:    scan.ScannedAt,
-				}
-				result = append(result, item)
-			}
+// defaultFindingSearchLimit and maxFindingSearchLimit bound the findings a full-text search returns
+const (
+	defaultFindingSearchLimit = 500
+	maxFindingSearchLimit     = 5000
+)
+
+// SearchVulnerabilities searches the findings of the latest scans by CVE ID, package name or
+// description (?q=log4j&registry_id=&severity=&limit=) and returns the affected images
+func (h *Handler) SearchVulnerabilities(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	search := database.FindingSearch{Query: strings.TrimSpace(q.Get("q")), Severity: q.Get("severity"), Limit: defaultFindingSearchLimit}
+	if search.Query == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Query parameter q is required")
+		return
+	}
+	if v := q.Get("registry_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid registry_id")
+			return
 		}
+		search.RegistryID = id
 	}
-
-	return result
-}
-
-func mergeScanData(originalJSON, key string, newJSON string) string {
-	data := make(map[string]json.RawMessage)
-
-	// Try parse original
-	var parsedOriginal map[string]json.RawMessage
-	if originalJSON != "" {
-		if err := json.Unmarshal([]byte(originalJSON), &parsedOriginal); err == nil {
-			// Check if it has scanner keys
-			_, hasTrivy := parsedOriginal["trivy"]
-			_, hasOsv := parsedOriginal["osv"]
-			if hasTrivy || hasOsv {
-				data = parsedOriginal
-			} else {
-				// Not wrapped, assume old format is trivy
-				data["trivy"] = json.RawMessage(originalJSON)
-			}
-		} else {
-			// Failed to parse as map, maybe it's just a string or broken.
-			// Try to treat as raw trivy result
-			data["trivy"] = json.RawMessage(originalJSON)
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid limit")
+			return
 		}
+		search.Limit = min(n, maxFindingSearchLimit)
 	}
 
-	if newJSON != "" {
-		data[key] = json.RawMessage(newJSON)
+	images, truncated, err := h.db.SearchFindings(search)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Search failed: %v", err))
+		return
+	}
+	access, err := h.projectAccess(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if access != nil {
+		images = slices.DeleteFunc(images, func(img models.FindingSearchResult) bool {
+			return access.repositoryRole(img.RegistryID, img.Repository) == ""
+		})
 	}
+	h.successResponse(w, map[string]interface{}{
+		"images":    images,
+		"truncated": truncated,
+	})
+}
 
-	b, _ := json.Marshal(data)
-	return string(b)
+// extractScanVulnerabilities flattens a wrapped scan report into findings
+func extractScanVulnerabilities(scan models.VulnerabilityScan) []models.VulnerabilityItem {
+	var result []models.VulnerabilityItem
+	for _, f := range scanner.ParseFindings(scan.Report) {
+		result = append(result, models.VulnerabilityItem{
+			ID:           f.ID,
+			Package:      f.Package,
+			Version:      f.Version,
+			FixedVersion: f.FixedVersion,
+			Severity:     f.Severity,
+			Description:  f.Description,
+			Scanner:      f.Scanner,
+			Repository:   scan.Repository,
+			Tag:          scan.Tag,
+			Digest:       scan.Digest,
+			RegistryID:   scan.RegistryID,
+			ScannedAt:    scan.ScannedAt,
+		})
+	}
+	return result
 }
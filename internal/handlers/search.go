@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// defaultSearchLimit is how many hits a search returns when it does not say
+const defaultSearchLimit = 50
+
+// Search finds repositories and tags by name across all registries (?q=&limit=). It reads the
+// local search index, which catalog sync and registry webhooks keep current, so no registry is queried.
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := strings.TrimSpace(q.Get("q"))
+	if query == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Query parameter q is required")
+		return
+	}
+
+	limit := defaultSearchLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = min(n, maxPageSize)
+	}
+
+	hits, err := h.db.SearchIndex(query, limit)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	access, err := h.projectAccess(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if access != nil {
+		hits = slices.DeleteFunc(hits, func(hit models.SearchHit) bool { return access.repositoryRole(hit.RegistryID, hit.Repository) == "" })
+	}
+	h.successResponse(w, hits)
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"slices"
+
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/registry"
+	"docker-registry-dashboard/internal/tasks"
+)
+
+// --- Seed Images ---
+
+// ListSeedImages returns the public images mirrored by the seed job into the registries the caller sees
+func (h *Handler) ListSeedImages(w http.ResponseWriter, r *http.Request) {
+	seeds, err := h.db.ListSeedImages()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	access, err := h.projectAccess(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if access != nil {
+		seeds = slices.DeleteFunc(seeds, func(s models.SeedImage) bool { return !access.seesRegistry(s.TargetRegistryID) })
+	}
+	h.successResponse(w, seeds)
+}
+
+// CreateSeedImage adds an image to mirror (admin only)
+func (h *Handler) CreateSeedImage(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	seed := models.SeedImage{Enabled: true}
+	if err := json.NewDecoder(r.Body).Decode(&seed); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	seed.ID = 0
+	h.saveSeedImage(w, &seed)
+}
+
+// UpdateSeedImage replaces a seed image (admin only)
+func (h *Handler) UpdateSeedImage(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid seed image ID")
+		return
+	}
+
+	var seed models.SeedImage
+	if err := json.NewDecoder(r.Body).Decode(&seed); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	seed.ID = id
+	h.saveSeedImage(w, &seed)
+}
+
+func (h *Handler) saveSeedImage(w http.ResponseWriter, seed *models.SeedImage) {
+	if _, _, _, err := registry.ParseImageReference(seed.Image); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, err := h.db.GetRegistry(seed.TargetRegistryID); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Registry not found")
+		return
+	}
+	if seed.IntervalHours <= 0 {
+		seed.IntervalHours = 24
+	}
+
+	if err := h.db.SaveSeedImage(seed); err != nil {
+		if err == sql.ErrNoRows {
+			h.errorResponse(w, http.StatusNotFound, "Seed image not found")
+			return
+		}
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to save seed image: %v", err))
+		return
+	}
+	h.successResponse(w, seed)
+}
+
+// DeleteSeedImage stops mirroring an image; copies already in the target registry are kept (admin only)
+func (h *Handler) DeleteSeedImage(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid seed image ID")
+		return
+	}
+	if err := h.db.DeleteSeedImage(id); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.messageResponse(w, "Seed image deleted")
+}
+
+// RunSeedImage mirrors a seed image now and returns its updated state (admin only)
+func (h *Handler) RunSeedImage(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid seed image ID")
+		return
+	}
+	seed, err := h.db.GetSeedImage(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Seed image not found")
+		return
+	}
+
+	copied, err := tasks.RunSeed(r.Context(), h.db, seed)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Seeding failed: %v", err))
+		return
+	}
+	if copied {
+		h.invalidateListings(seed.TargetRegistryID)
+	}
+	if seed, err = h.db.GetSeedImage(id); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.successResponse(w, map[string]interface{}{"copied": copied, "seed": seed})
+}
@@ -0,0 +1,306 @@
+package handlers
+
+import (
+	"crypto"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/registry"
+)
+
+// SetSigningKeySecret sets the secret image signing keys are encrypted with; without one, signing
+// keys cannot be added or used
+func (h *Handler) SetSigningKeySecret(secret string) {
+	h.signingSecret = []byte(secret)
+}
+
+// SignRequest signs an image with a signing key
+type SignRequest struct {
+	RegistryID  int64             `json:"registry_id"`
+	Repository  string            `json:"repository"`
+	Tag         string            `json:"tag"`    // Resolved to its digest when no digest is given
+	Digest      string            `json:"digest"` // Signed as is
+	KeyID       int64             `json:"key_id"`
+	Annotations map[string]string `json:"annotations"` // Signed along, as with cosign sign -a
+}
+
+// --- Signing Keys ---
+
+// ListSigningKeys returns the image signing keys with their public keys
+func (h *Handler) ListSigningKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.db.ListSigningKeys()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for i := range keys {
+		keys[i].Fingerprint = registry.PublicKeyFingerprint(keys[i].PublicKey)
+	}
+	h.successResponse(w, keys)
+}
+
+// CreateSigningKey adds a signing key (admin only): an uploaded cosign.key with its password, or
+// a newly generated key pair when no private key is given
+func (h *Handler) CreateSigningKey(w http.ResponseWriter, r *http.Request) {
+	user := h.requireAdmin(w, r)
+	if user == nil {
+		return
+	}
+	if len(h.signingSecret) == 0 {
+		h.errorResponse(w, http.StatusBadRequest, "Image signing is not configured (start the dashboard with -signing-key-secret)")
+		return
+	}
+	var req struct {
+		Name       string  `json:"name"`
+		PrivateKey string  `json:"private_key"` // cosign.key; generated when empty
+		Password   string  `json:"password"`    // Of the uploaded private key
+		Projects   []int64 `json:"projects"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		h.errorResponse(w, http.StatusBadRequest, "Key name is required")
+		return
+	}
+	if !h.validProjects(w, req.Projects) {
+		return
+	}
+	existing, err := h.db.ListSigningKeys()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	for _, k := range existing {
+		if k.Name == req.Name {
+			h.errorResponse(w, http.StatusConflict, "A signing key with this name already exists")
+			return
+		}
+	}
+
+	var signer crypto.Signer
+	detail := "generated"
+	if req.PrivateKey == "" {
+		signer, err = registry.GenerateSigningKey()
+	} else {
+		signer, err = registry.DecryptSigningKey([]byte(req.PrivateKey), []byte(req.Password))
+		detail = "uploaded"
+	}
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Invalid private key: %v", err))
+		return
+	}
+	key := &models.SigningKey{Name: req.Name, Projects: req.Projects, CreatedBy: user.Username}
+	if key.PublicKey, err = registry.PublicKeyPEM(signer); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Invalid private key: %v", err))
+		return
+	}
+	// Stored in cosign's format, under the dashboard's secret rather than the uploader's password
+	encrypted, err := registry.EncryptSigningKey(signer, h.signingSecret)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to encrypt the private key: %v", err))
+		return
+	}
+	key.PrivateKey = string(encrypted)
+	if err := h.db.CreateSigningKey(key); err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to save signing key: %v", err))
+		return
+	}
+	key.Fingerprint = registry.PublicKeyFingerprint(key.PublicKey)
+	h.audit(r, "signing_key.create", key.Name, detail+" "+key.Fingerprint)
+	h.jsonResponse(w, http.StatusCreated, models.APIResponse{Success: true, Data: key})
+}
+
+// UpdateSigningKeyProjects replaces the projects whose maintainers may use a signing key (admin only)
+func (h *Handler) UpdateSigningKeyProjects(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid signing key ID")
+		return
+	}
+	var req struct {
+		Projects []int64 `json:"projects"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !h.validProjects(w, req.Projects) {
+		return
+	}
+	if err := h.db.SetSigningKeyProjects(id, req.Projects); err != nil {
+		if err == sql.ErrNoRows {
+			h.errorResponse(w, http.StatusNotFound, "Signing key not found")
+			return
+		}
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	key, err := h.db.GetSigningKey(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	key.Fingerprint = registry.PublicKeyFingerprint(key.PublicKey)
+	h.audit(r, "signing_key.update", key.Name, fmt.Sprintf("projects %v", key.Projects))
+	h.successResponse(w, key)
+}
+
+// validProjects checks that the projects a signing key is given exist
+func (h *Handler) validProjects(w http.ResponseWriter, ids []int64) bool {
+	for _, id := range ids {
+		if _, err := h.db.GetProject(id); err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Project not found")
+			return false
+		}
+	}
+	return true
+}
+
+// DeleteSigningKey removes a signing key (admin only)
+func (h *Handler) DeleteSigningKey(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid signing key ID")
+		return
+	}
+	key, err := h.db.GetSigningKey(id)
+	if err == nil {
+		err = h.db.DeleteSigningKey(id)
+	}
+	if err != nil {
+		if err == sql.ErrNoRows {
+			h.errorResponse(w, http.StatusNotFound, "Signing key not found")
+			return
+		}
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.audit(r, "signing_key.delete", key.Name, "")
+	h.messageResponse(w, "Signing key deleted")
+}
+
+// --- Image Signing ---
+
+// SignImage signs an image digest with a signing key and pushes the cosign signature next to it,
+// so a promotion step can sign what it promotes. Needs a signed-in user with push access to the
+// repository who may use the key.
+func (h *Handler) SignImage(w http.ResponseWriter, r *http.Request) {
+	user := h.currentUser(w, r)
+	if user == nil {
+		return
+	}
+	var req SignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.Repository == "" || (req.Tag == "" && req.Digest == "") {
+		h.errorResponse(w, http.StatusBadRequest, "Repository and a tag or digest are required")
+		return
+	}
+	if len(h.signingSecret) == 0 {
+		h.errorResponse(w, http.StatusBadRequest, "Image signing is not configured (start the dashboard with -signing-key-secret)")
+		return
+	}
+	reg, err := h.db.GetRegistry(req.RegistryID)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+	if !h.allowRepository(w, r, reg.ID, req.Repository, true) || !h.allowAction(w, r, reg.ID, req.Repository, actionPush) {
+		return
+	}
+	key, err := h.db.GetSigningKey(req.KeyID)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Signing key not found")
+		return
+	}
+	allowed, err := h.mayUseSigningKey(user, key, reg.ID, req.Repository)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	if !allowed {
+		h.errorResponse(w, http.StatusForbidden, "You may not sign this repository with this key")
+		return
+	}
+	signer, err := registry.DecryptSigningKey([]byte(key.PrivateKey), h.signingSecret)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to decrypt the signing key: %v", err))
+		return
+	}
+
+	client := registry.NewClientFromRegistry(reg)
+	digest := req.Digest
+	if digest == "" {
+		if digest, err = client.GetDigestForTag(r.Context(), req.Repository, req.Tag); err != nil {
+			h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Failed to get digest: %v", err))
+			return
+		}
+	}
+	sigDigest, err := client.SignImage(r.Context(), signer, req.Repository, digest, req.Annotations)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Failed to sign image: %v", err))
+		return
+	}
+	h.invalidateListings(reg.ID)
+
+	sig := models.ImageSignature{
+		RegistryID:      reg.ID,
+		Repository:      req.Repository,
+		Digest:          digest,
+		KeyID:           key.ID,
+		Fingerprint:     registry.PublicKeyFingerprint(key.PublicKey),
+		SignatureTag:    registry.CosignSignatureTag(digest),
+		SignatureDigest: sigDigest,
+	}
+	h.audit(r, "image.sign", reg.Name+"/"+req.Repository+"@"+digest, "key "+key.Name)
+	h.successResponse(w, sig)
+}
+
+// mayUseSigningKey reports whether a user may sign a repository with a key: admins may use any
+// key, other users one of whose projects they are a maintainer of and that holds the repository
+func (h *Handler) mayUseSigningKey(user *models.User, key *models.SigningKey, registryID int64, repo string) (bool, error) {
+	if user.Role == "admin" {
+		return true, nil
+	}
+	if len(key.Projects) == 0 {
+		return false, nil
+	}
+	roles, err := h.projectRoles(user.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range key.Projects {
+		if roles[id] != roleMaintainer {
+			continue
+		}
+		p, err := h.db.GetProject(id)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if slices.Contains(p.Registries, registryID) || slices.ContainsFunc(p.Repositories, func(pr models.ProjectRepository) bool {
+			return pr.RegistryID == registryID && models.MatchRepository(pr.Repository, repo)
+		}) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
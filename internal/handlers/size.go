@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"docker-registry-dashboard/internal/registry"
+)
+
+// sizeCacheTTL is how long a calculated registry size is served from the database
+const sizeCacheTTL = time.Hour
+
+// GetRegistrySize returns the storage consumed per repository and for the whole registry,
+// recalculating it when the cached value is older than sizeCacheTTL or ?refresh=true
+func (h *Handler) GetRegistrySize(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+
+	if r.URL.Query().Get("refresh") != "true" {
+		if cached, err := h.db.GetRegistrySize(id); err == nil && time.Since(cached.CalculatedAt) < sizeCacheTTL {
+			cached.Cached = true
+			h.successResponse(w, cached)
+			return
+		}
+	}
+
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+
+	size, err := registry.CalculateStorageSize(r.Context(), registry.NewClientFromRegistry(reg))
+	if err != nil {
+		h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Failed to calculate registry size: %v", err))
+		return
+	}
+	size.RegistryID = id
+	if err := h.db.SaveRegistrySize(size); err != nil {
+		log.Printf("⚠️  Failed to cache size of registry %s: %v", reg.Name, err)
+	}
+	h.successResponse(w, size)
+}
+
+// GetEmbeddedStorageUsage reports the bytes, blobs and uploads in the embedded registry's storage,
+// read from the storage backend itself
+func (h *Handler) GetEmbeddedStorageUsage(w http.ResponseWriter, r *http.Request) {
+	if h.requireAdmin(w, r) == nil {
+		return
+	}
+	if h.embeddedReg == nil {
+		h.errorResponse(w, http.StatusServiceUnavailable, "Embedded registry is not available")
+		return
+	}
+
+	config, err := h.db.GetStorageConfig()
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, "Failed to load storage config")
+		return
+	}
+
+	usage, err := h.embeddedReg.StorageUsage(r.Context(), config)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Failed to read storage usage: %v", err))
+		return
+	}
+	h.successResponse(w, usage)
+}
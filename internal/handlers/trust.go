@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/registry"
+)
+
+// trustConcurrency bounds the digest and signature lookups a content trust summary runs at once
+const trustConcurrency = 8
+
+// trustImage is what the content trust summary learned about one tag
+type trustImage struct {
+	namespace string
+	scanned   bool
+	signed    bool
+	passing   bool
+	unchecked bool
+}
+
+// GetContentTrust summarizes a registry's content trust: the share of images (tags) with a
+// completed scan of their current digest, with a cosign or notation signature, and passing the gate
+// (?max_critical=0&max_high=-1&require_signature=false, as with the gate command), overall and
+// per namespace. Results are cached like catalog listings; ?refresh=true recomputes them.
+func (h *Handler) GetContentTrust(w http.ResponseWriter, r *http.Request) {
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return
+	}
+	q := r.URL.Query()
+	gate := models.TrustGate{MaxCritical: 0, MaxHigh: -1, RequireSignature: q.Get("require_signature") == "true"}
+	for name, limit := range map[string]*int{"max_critical": &gate.MaxCritical, "max_high": &gate.MaxHigh} {
+		if v := q.Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < -1 {
+				h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Invalid %s", name))
+				return
+			}
+			*limit = n
+		}
+	}
+	refresh := q.Get("refresh") == "true"
+
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return
+	}
+
+	key := fmt.Sprintf("trust:%d:%s:%d:%d:%t", reg.ID, h.listingGeneration(reg.ID), gate.MaxCritical, gate.MaxHigh, gate.RequireSignature)
+	var trust models.ContentTrust
+	if h.cachedListing(key, refresh, &trust) {
+		h.successResponse(w, trust)
+		return
+	}
+
+	client := registry.NewClientFromRegistry(reg)
+	repos, err := h.listRepositoriesCached(r.Context(), reg, client, refresh)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadGateway, h.tr(w, "Failed to list repositories: %v", err))
+		return
+	}
+	scans, err := h.db.ScannedImages(reg.ID)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, h.tr(w, "Failed to load scans: %v", err))
+		return
+	}
+	scanned := make(map[string]models.ReportImage, len(scans))
+	for _, s := range scans {
+		scanned[s.Repository+":"+s.Tag] = s
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		images []trustImage
+	)
+	sem := make(chan struct{}, trustConcurrency)
+	for _, repo := range repos {
+		tags, err := h.listTagsCached(r.Context(), reg, client, repo.Name, refresh)
+		if err != nil {
+			continue
+		}
+		tagSet := make(map[string]bool, len(tags))
+		for _, t := range tags {
+			tagSet[t.Name] = true
+		}
+		namespace := ""
+		if i := strings.Index(repo.Name, "/"); i >= 0 {
+			namespace = repo.Name[:i]
+		}
+
+		for _, t := range tags {
+			if registry.IsCosignArtifactTag(t.Name) || registry.IsReferrersTag(t.Name) {
+				continue
+			}
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(repoName, tag string) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				img := trustImage{namespace: namespace}
+				defer func() {
+					mu.Lock()
+					images = append(images, img)
+					mu.Unlock()
+				}()
+
+				digest, err := client.GetDigestForTag(r.Context(), repoName, tag)
+				if err != nil {
+					img.unchecked = true
+					return
+				}
+				if tagSet[registry.CosignSignatureTag(digest)] {
+					img.signed = true
+				} else if img.signed, err = client.HasReferrerSignature(r.Context(), repoName, digest); err != nil {
+					img.unchecked = true
+				}
+
+				scan, ok := scanned[repoName+":"+tag]
+				img.scanned = ok && (scan.Digest == "" || scan.Digest == digest)
+				img.passing = img.scanned &&
+					withinLimit(scan.Severities["CRITICAL"], gate.MaxCritical) &&
+					withinLimit(scan.Severities["HIGH"], gate.MaxHigh) &&
+					(img.signed || !gate.RequireSignature)
+			}(repo.Name, t.Name)
+		}
+	}
+	wg.Wait()
+
+	trust = models.ContentTrust{
+		RegistryID:   reg.ID,
+		RegistryName: reg.Name,
+		Gate:         gate,
+		Namespaces:   []models.NamespaceTrust{},
+		GeneratedAt:  time.Now(),
+	}
+	byNamespace := make(map[string]*models.TrustCounts)
+	for _, img := range images {
+		ns, ok := byNamespace[img.namespace]
+		if !ok {
+			ns = &models.TrustCounts{}
+			byNamespace[img.namespace] = ns
+		}
+		for _, c := range []*models.TrustCounts{&trust.TrustCounts, ns} {
+			c.Images++
+			if img.scanned {
+				c.Scanned++
+			}
+			if img.signed {
+				c.Signed++
+			}
+			if img.passing {
+				c.PassingGate++
+			}
+		}
+		if img.unchecked {
+			trust.Unchecked++
+		}
+	}
+	setPercentages(&trust.TrustCounts)
+	for name, c := range byNamespace {
+		setPercentages(c)
+		trust.Namespaces = append(trust.Namespaces, models.NamespaceTrust{Namespace: name, TrustCounts: *c})
+	}
+	// Weakest namespaces first, where attention is needed
+	sort.Slice(trust.Namespaces, func(i, j int) bool {
+		a, b := trust.Namespaces[i], trust.Namespaces[j]
+		if a.Score != b.Score {
+			return a.Score < b.Score
+		}
+		return a.Namespace < b.Namespace
+	})
+
+	h.storeListing(key, trust)
+	h.successResponse(w, trust)
+}
+
+// withinLimit reports whether count meets a gate limit; -1 means no limit
+func withinLimit(count, limit int) bool {
+	return limit < 0 || count <= limit
+}
+
+// setPercentages fills in the coverage percentages and the score of counted images
+func setPercentages(c *models.TrustCounts) {
+	c.ScannedPct = percent(c.Scanned, c.Images)
+	c.SignedPct = percent(c.Signed, c.Images)
+	c.PassingGatePct = percent(c.PassingGate, c.Images)
+	c.Score = math.Round((c.ScannedPct+c.SignedPct+c.PassingGatePct)/3*10) / 10
+}
+
+// percent is part of whole in percent, rounded to one decimal
+func percent(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*1000/float64(whole)) / 10
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// APIVersion is the current version of the dashboard API. Breaking changes to requests or
+// responses (envelopes, pagination, authentication) get a new version; the routes of earlier
+// versions keep behaving as they did.
+const APIVersion = 1
+
+// supportedAPIVersions are the versions this server provides, oldest first
+var supportedAPIVersions = []int{1}
+
+// legacyAPIVersion is the version the unversioned /api/... paths are frozen to
+const legacyAPIVersion = 1
+
+const apiVersionHeader = "API-Version"
+
+var versionedPath = regexp.MustCompile(`^/api/v(\d+)(/|$)`)
+
+// VersionAPI serves each API version under /api/vN/. Unversioned /api/... paths are aliases: by
+// default of v1, whose behavior they keep, or of the version a client asks for in an API-Version
+// header. Responses name the version that served them in API-Version and list the supported
+// ones in API-Supported-Versions; unversioned requests without the header are also marked with
+// Deprecation and a Link to their versioned path. A request for a version this server does not
+// provide is rejected instead of being answered in another version's format.
+func (h *Handler) VersionAPI(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Set("API-Supported-Versions", supportedVersionList())
+
+		requested := 0
+		if v := r.Header.Get(apiVersionHeader); v != "" {
+			n, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(v)), "v"))
+			if err != nil || n < 1 {
+				h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Invalid API-Version header %q", v))
+				return
+			}
+			requested = n
+		}
+
+		version := legacyAPIVersion
+		if m := versionedPath.FindStringSubmatch(r.URL.Path); m != nil {
+			version, _ = strconv.Atoi(m[1])
+			if requested != 0 && requested != version {
+				h.errorResponse(w, http.StatusBadRequest, h.tr(w, "API-Version %d does not match the version %d of the path", requested, version))
+				return
+			}
+		} else {
+			if requested != 0 {
+				version = requested
+			}
+			canonical := "/api/v" + strconv.Itoa(version) + strings.TrimPrefix(r.URL.Path, "/api")
+			if requested == 0 {
+				w.Header().Set("Deprecation", "true")
+				w.Header().Set("Link", "<"+canonical+`>; rel="successor-version"`)
+			}
+			r = withPath(r, canonical)
+		}
+
+		if !isSupportedAPIVersion(version) {
+			h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Unsupported API version %d (supported: %s)", version, supportedVersionList()))
+			return
+		}
+		w.Header().Set(apiVersionHeader, strconv.Itoa(version))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withPath returns a shallow copy of r for another path, as http.StripPrefix does
+func withPath(r *http.Request, path string) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *r
+	u := *r.URL
+	u.Path, u.RawPath = path, ""
+	r2.URL = &u
+	return r2
+}
+
+func isSupportedAPIVersion(v int) bool {
+	for _, s := range supportedAPIVersions {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
+
+func supportedVersionList() string {
+	list := make([]string, len(supportedAPIVersions))
+	for i, v := range supportedAPIVersions {
+		list[i] = strconv.Itoa(v)
+	}
+	return strings.Join(list, ", ")
+}
@@ -0,0 +1,464 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"docker-registry-dashboard/internal/database"
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/registry"
+)
+
+// SetWebhookSecret requires registry webhooks to present the given shared secret
+func (h *Handler) SetWebhookSecret(secret string) {
+	h.webhookSecret = secret
+}
+
+// distributionEnvelope is the notification format of Docker Distribution (also used by GitLab's registry)
+type distributionEnvelope struct {
+	Events []struct {
+		ID        string    `json:"id"`
+		Timestamp time.Time `json:"timestamp"`
+		Action    string    `json:"action"`
+		Target    struct {
+			MediaType  string `json:"mediaType"`
+			Digest     string `json:"digest"`
+			Repository string `json:"repository"`
+			Tag        string `json:"tag"`
+		} `json:"target"`
+		Actor struct {
+			Name string `json:"name"`
+		} `json:"actor"`
+		Request struct {
+			Addr string `json:"addr"` // Client address, host:port
+		} `json:"request"`
+	} `json:"events"`
+}
+
+// harborPayload is the "Default" webhook payload sent by Harbor
+type harborPayload struct {
+	Type      string `json:"type"` // PUSH_ARTIFACT, PULL_ARTIFACT, DELETE_ARTIFACT, ...
+	OccurAt   int64  `json:"occur_at"`
+	Operator  string `json:"operator"`
+	EventData struct {
+		Resources []struct {
+			Digest string `json:"digest"`
+			Tag    string `json:"tag"`
+		} `json:"resources"`
+		Repository struct {
+			Name         string `json:"name"`
+			Namespace    string `json:"namespace"`
+			RepoFullName string `json:"repo_full_name"`
+		} `json:"repository"`
+	} `json:"event_data"`
+}
+
+var harborActions = map[string]string{
+	"PUSH_ARTIFACT":   "push",
+	"PULL_ARTIFACT":   "pull",
+	"DELETE_ARTIFACT": "delete",
+}
+
+// ReceiveDistributionEvents handles Docker Distribution notifications
+func (h *Handler) ReceiveDistributionEvents(w http.ResponseWriter, r *http.Request) {
+	h.receiveEnvelope(w, r, "distribution")
+}
+
+// ReceiveGitLabEvents handles notifications from the GitLab container registry
+func (h *Handler) ReceiveGitLabEvents(w http.ResponseWriter, r *http.Request) {
+	h.receiveEnvelope(w, r, "gitlab")
+}
+
+func (h *Handler) receiveEnvelope(w http.ResponseWriter, r *http.Request, source string) {
+	reg, ok := h.webhookRegistry(w, r)
+	if !ok {
+		return
+	}
+
+	var env distributionEnvelope
+	if err := json.NewDecoder(r.Body).Decode(&env); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid notification payload")
+		return
+	}
+
+	var events []models.RegistryEvent
+	for _, e := range env.Events {
+		// Blob pushes/pulls are noise; only manifests identify an image
+		if e.Action != "delete" && !isManifestMediaType(e.Target.MediaType) {
+			continue
+		}
+		events = append(events, models.RegistryEvent{
+			RegistryID: reg.ID,
+			Source:     source,
+			Action:     e.Action,
+			Repository: e.Target.Repository,
+			Tag:        e.Target.Tag,
+			Digest:     e.Target.Digest,
+			MediaType:  e.Target.MediaType,
+			Actor:      e.Actor.Name,
+			ActorIP:    addrHost(e.Request.Addr),
+			Timestamp:  e.Timestamp,
+		})
+	}
+
+	h.successResponse(w, map[string]int{"accepted": h.recordEvents(r, reg, events)})
+}
+
+// ReceiveHarborEvents handles Harbor webhooks
+func (h *Handler) ReceiveHarborEvents(w http.ResponseWriter, r *http.Request) {
+	reg, ok := h.webhookRegistry(w, r)
+	if !ok {
+		return
+	}
+
+	var p harborPayload
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid notification payload")
+		return
+	}
+
+	action, known := harborActions[p.Type]
+	if !known {
+		// Scanning, quota and replication events are acknowledged but not recorded
+		h.successResponse(w, map[string]int{"accepted": 0})
+		return
+	}
+
+	repo := p.EventData.Repository.RepoFullName
+	if repo == "" && p.EventData.Repository.Namespace != "" {
+		repo = p.EventData.Repository.Namespace + "/" + p.EventData.Repository.Name
+	}
+	ts := time.Unix(p.OccurAt, 0)
+
+	var events []models.RegistryEvent
+	for _, res := range p.EventData.Resources {
+		events = append(events, models.RegistryEvent{
+			RegistryID: reg.ID,
+			Source:     "harbor",
+			Action:     action,
+			Repository: repo,
+			Tag:        res.Tag,
+			Digest:     res.Digest,
+			Actor:      p.Operator,
+			Timestamp:  ts,
+		})
+	}
+
+	h.successResponse(w, map[string]int{"accepted": h.recordEvents(r, reg, events)})
+}
+
+// ListEvents returns the activity feed (?registry_id=&source=&action=&repository=&tag=&actor=
+// &since=&until=&limit=); actor matches the user name or client address, since and until are
+// RFC 3339 times, and source "quota" lists the scan reports evicted by the storage quota
+func (h *Handler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := database.RegistryEventFilter{
+		Source:     q.Get("source"),
+		Action:     q.Get("action"),
+		Repository: q.Get("repository"),
+		Tag:        q.Get("tag"),
+		Actor:      q.Get("actor"),
+		Limit:      100,
+	}
+
+	if v := q.Get("registry_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid registry_id")
+			return
+		}
+		filter.RegistryID = id
+	}
+	for _, bound := range []struct {
+		name string
+		t    *time.Time
+	}{{"since", &filter.Since}, {"until", &filter.Until}} {
+		if v := q.Get(bound.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				h.errorResponse(w, http.StatusBadRequest, h.tr(w, "Invalid %s: use an RFC 3339 time such as 2024-05-01T00:00:00Z", bound.name))
+				return
+			}
+			*bound.t = t
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			h.errorResponse(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		filter.Limit = n
+	}
+
+	events, err := h.db.ListRegistryEvents(filter)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	access, err := h.projectAccess(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if access != nil {
+		events = slices.DeleteFunc(events, func(e models.RegistryEvent) bool { return access.repositoryRole(e.RegistryID, e.Repository) == "" })
+	}
+	h.successResponse(w, events)
+}
+
+// addrHost returns the host of a host:port address, or the address when it has no port
+func addrHost(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// webhookRoutes are the registry webhook endpoints. They authenticate with the webhook secret
+// instead of a user, so login, project scoping, maintenance mode and rate limits leave them out.
+var webhookRoutes = func() *http.ServeMux {
+	mux := http.NewServeMux()
+	for _, pattern := range []string{
+		"POST /api/v1/registries/{id}/notifications",
+		"POST /api/v1/registries/{id}/notifications/harbor",
+		"POST /api/v1/registries/{id}/notifications/gitlab",
+	} {
+		mux.Handle(pattern, http.NotFoundHandler())
+	}
+	return mux
+}()
+
+// isWebhook reports whether a request is for one of the registry webhook endpoints
+func isWebhook(r *http.Request) bool {
+	_, pattern := webhookRoutes.Handler(r)
+	return pattern != ""
+}
+
+// webhookRegistry checks the shared secret and resolves the registry from the path. With -auth,
+// webhooks are refused unless a secret is set, as they would let anyone inject events.
+func (h *Handler) webhookRegistry(w http.ResponseWriter, r *http.Request) (*models.Registry, bool) {
+	if h.webhookSecret == "" && h.authRequired {
+		h.errorResponse(w, http.StatusForbidden, "Registry webhooks need -webhook-secret when authentication is required")
+		return nil, false
+	}
+	if h.webhookSecret != "" && !validWebhookSecret(r, h.webhookSecret) {
+		h.errorResponse(w, http.StatusUnauthorized, "Invalid webhook secret")
+		return nil, false
+	}
+
+	id, err := h.getRegistryID(r)
+	if err != nil {
+		h.errorResponse(w, http.StatusBadRequest, "Invalid registry ID")
+		return nil, false
+	}
+	reg, err := h.db.GetRegistry(id)
+	if err != nil {
+		h.errorResponse(w, http.StatusNotFound, "Registry not found")
+		return nil, false
+	}
+	// Without a secret, anyone could report events; only the registry's host is believed
+	if h.webhookSecret == "" && !fromRegistryHost(r, reg) {
+		h.errorResponse(w, http.StatusForbidden, "Registry webhooks without -webhook-secret are only accepted from the registry's address")
+		return nil, false
+	}
+	return reg, true
+}
+
+// fromRegistryHost reports whether a request comes from an address the host of the registry's URL
+// resolves to; a loopback registry accepts any loopback caller
+func fromRegistryHost(r *http.Request, reg *models.Registry) bool {
+	u, err := url.Parse(reg.URL)
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+	client := net.ParseIP(addrHost(r.RemoteAddr))
+	if client == nil {
+		return false
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(r.Context(), u.Hostname())
+	if err != nil {
+		return false
+	}
+	for _, a := range addrs {
+		if a.IP.Equal(client) || a.IP.IsLoopback() && client.IsLoopback() {
+			return true
+		}
+	}
+	return false
+}
+
+// validWebhookSecret accepts the secret as a raw or Bearer Authorization header, or as X-Gitlab-Token
+func validWebhookSecret(r *http.Request, secret string) bool {
+	candidates := []string{
+		r.Header.Get("Authorization"),
+		strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "),
+		r.Header.Get("X-Gitlab-Token"),
+	}
+	for _, c := range candidates {
+		if c != "" && subtle.ConstantTimeCompare([]byte(c), []byte(secret)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// recordEvents stores events, reverts pushes overwriting immutable tags and starts scan-on-push
+// scans; it returns the number stored
+func (h *Handler) recordEvents(r *http.Request, reg *models.Registry, events []models.RegistryEvent) int {
+	stored := 0
+	var kept []models.RegistryEvent
+	for i := range events {
+		e := &events[i]
+		if e.Timestamp.IsZero() {
+			e.Timestamp = time.Now()
+		}
+		reverted := h.revertImmutableOverwrite(r, reg, e)
+		if err := h.db.AddRegistryEvent(e); err != nil {
+			fmt.Printf("⚠️ Failed to store %s event for %s: %v\n", e.Source, e.Repository, err)
+			continue
+		}
+		if reverted {
+			// The tag keeps its usage and index entry; the push restoring it is reported separately
+			stored++
+			continue
+		}
+		kept = append(kept, *e)
+		if err := h.db.RecordTagUsage(e); err != nil {
+			fmt.Printf("⚠️ Failed to update usage of %s:%s: %v\n", e.Repository, e.Tag, err)
+		}
+		if err := h.indexEvent(e); err != nil {
+			fmt.Printf("⚠️ Failed to update search index for %s:%s: %v\n", e.Repository, e.Tag, err)
+		}
+		stored++
+	}
+
+	// Tags and catalogs changed, drop cached listings
+	if stored > 0 {
+		h.invalidateListings(reg.ID)
+	}
+
+	h.scanOnPush(reg, kept)
+	return stored
+}
+
+// immutableRevertTimeout bounds restoring an immutable tag from a webhook
+const immutableRevertTimeout = 30 * time.Second
+
+// revertImmutableOverwrite points an immutable tag that a push moved to another image back at the
+// image it was last pushed with, noting it in the event's detail, and reports whether it did.
+// Registries outside the dashboard take pushes directly, so their overwrites can only be undone
+// once their webhook reports them; tags without a push seen before are left as they are.
+func (h *Handler) revertImmutableOverwrite(r *http.Request, reg *models.Registry, e *models.RegistryEvent) bool {
+	if e.Action != "push" || e.Tag == "" || e.Digest == "" {
+		return false
+	}
+	rule, err := h.db.ImmutableTagRule(reg.ID, e.Repository, e.Tag)
+	if err != nil || rule == nil {
+		return false
+	}
+	previous, err := h.db.PushedDigest(reg.ID, e.Repository, e.Tag)
+	if err != nil || previous == "" || previous == e.Digest {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), immutableRevertTimeout)
+	defer cancel()
+	client := registry.NewClientFromRegistry(reg)
+	manifest, mediaType, _, err := client.GetRawManifest(ctx, e.Repository, previous)
+	if err == nil {
+		_, err = client.PutManifest(ctx, e.Repository, e.Tag, mediaType, manifest)
+	}
+	ref := e.Repository + ":" + e.Tag
+	if err != nil {
+		fmt.Printf("❌ Failed to restore immutable tag %s of registry %d to %s: %v\n", ref, reg.ID, previous, err)
+		h.audit(r, "tag.immutable_overwrite", ref, fmt.Sprintf("%s pushed by %q; restoring %s failed: %v", e.Digest, e.Actor, previous, err))
+		return false
+	}
+	fmt.Printf("🔒 Restored immutable tag %s of registry %d to %s after a push of %s\n", ref, reg.ID, previous, e.Digest)
+	h.audit(r, "tag.immutable_revert", ref, fmt.Sprintf("%s pushed by %q; restored %s", e.Digest, e.Actor, previous))
+	e.Detail = fmt.Sprintf("overwrote immutable tag (rule %d); restored %s", rule.ID, previous)
+	return true
+}
+
+// indexEvent keeps the search index current between syncs: pushed tags are added, deleted ones removed
+func (h *Handler) indexEvent(e *models.RegistryEvent) error {
+	if e.Tag == "" {
+		return nil
+	}
+	switch e.Action {
+	case "push":
+		return h.db.IndexTag(e.RegistryID, e.Repository, e.Tag, e.Timestamp)
+	case "delete":
+		return h.db.UnindexTag(e.RegistryID, e.Repository, e.Tag)
+	}
+	return nil
+}
+
+// scanOnPush scans pushed tags matching the registry's scan policy when scan_on_push is enabled
+func (h *Handler) scanOnPush(reg *models.Registry, events []models.RegistryEvent) {
+	policy, err := h.db.GetScanPolicy(reg.ID)
+	if err != nil || !policy.ScanOnPush {
+		return
+	}
+
+	var repoRe, tagRe *regexp.Regexp
+	if policy.FilterRepos != "" {
+		if repoRe, err = regexp.Compile(policy.FilterRepos); err != nil {
+			fmt.Printf("⚠️ Invalid filter regex for scan policy of registry %d: %v\n", reg.ID, err)
+			return
+		}
+	}
+	if policy.FilterTags != "" {
+		if tagRe, err = regexp.Compile(policy.FilterTags); err != nil {
+			fmt.Printf("⚠️ Invalid tag filter regex for scan policy of registry %d: %v\n", reg.ID, err)
+			return
+		}
+	}
+
+	scanned := make(map[string]bool)
+	for _, e := range events {
+		if e.Action != "push" || e.Tag == "" {
+			continue
+		}
+		info, err := h.db.GetRepositoryInfo(reg.ID, e.Repository)
+		if err != nil || info.ScanMode == "never" {
+			continue
+		}
+		if info.ScanMode != "always" && repoRe != nil && !repoRe.MatchString(e.Repository) {
+			continue
+		}
+		if tagRe != nil && !tagRe.MatchString(e.Tag) {
+			continue
+		}
+		key := e.Repository + ":" + e.Tag
+		if scanned[key] {
+			continue
+		}
+		scanned[key] = true
+
+		fmt.Printf("🔔 Scan on push: %s\n", key)
+		req := ScanRequest{RegistryID: reg.ID, Repository: e.Repository, Tag: e.Tag, Digest: e.Digest}
+		if _, err := h.startScan(reg, req); err != nil {
+			fmt.Printf("❌ Failed to start scan on push for %s: %v\n", key, err)
+		}
+	}
+}
+
+func isManifestMediaType(mediaType string) bool {
+	switch mediaType {
+	case registry.MediaTypeDockerManifest, registry.MediaTypeOCIManifest:
+		return true
+	}
+	return registry.IsIndexMediaType(mediaType)
+}
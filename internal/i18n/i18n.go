@@ -0,0 +1,103 @@
+// Package i18n translates user-facing API messages. Messages are keyed by their English text
+// (format strings included), so untranslated messages fall back to English unchanged.
+package i18n
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage is used when the client accepts none of the supported languages
+const DefaultLanguage = "en"
+
+// catalogs maps a language to its translations; English needs none
+var catalogs = map[string]map[string]string{
+	"id": indonesian,
+}
+
+// Supported returns the languages messages are available in
+func Supported() []string {
+	langs := []string{DefaultLanguage}
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs[1:])
+	return langs
+}
+
+// Translate returns message in lang, or message itself when there is no translation
+func Translate(lang, message string) string {
+	if translated, ok := catalogs[lang][message]; ok {
+		return translated
+	}
+	return message
+}
+
+// Sprintf translates a format string before formatting it
+func Sprintf(lang, format string, a ...interface{}) string {
+	return fmt.Sprintf(Translate(lang, format), a...)
+}
+
+// Negotiate picks the best supported language from an Accept-Language header
+func Negotiate(acceptLanguage string) string {
+	best, bestQ := DefaultLanguage, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			if v, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+
+		// "id-ID" matches "id"; "in" is the legacy code for Indonesian
+		base, _, _ := strings.Cut(tag, "-")
+		if base == "in" {
+			base = "id"
+		}
+		if _, ok := catalogs[base]; !ok && base != DefaultLanguage {
+			continue
+		}
+		if q > bestQ {
+			best, bestQ = base, q
+		}
+	}
+	return best
+}
+
+// responseWriter carries the negotiated language of a request to the response helpers
+type responseWriter struct {
+	http.ResponseWriter
+	lang string
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Middleware negotiates the language of every request from its Accept-Language header
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lang := Negotiate(r.Header.Get("Accept-Language"))
+		w.Header().Add("Vary", "Accept-Language")
+		next.ServeHTTP(&responseWriter{ResponseWriter: w, lang: lang}, r)
+	})
+}
+
+// Language returns the language negotiated for a response (DefaultLanguage outside the middleware)
+func Language(w http.ResponseWriter) string {
+	if lw, ok := w.(*responseWriter); ok {
+		return lw.lang
+	}
+	return DefaultLanguage
+}
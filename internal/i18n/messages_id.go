@@ -0,0 +1,339 @@
+package i18n
+
+// indonesian holds the Indonesian (id) translations of API messages
+var indonesian = map[string]string{
+	// Generic request errors
+	"Invalid request body":     "Isi permintaan tidak valid",
+	"Invalid JSON":             "JSON tidak valid",
+	"Invalid ID":               "ID tidak valid",
+	"Invalid limit":            "Batas tidak valid",
+	"Missing parameters":       "Parameter tidak lengkap",
+	"Database error":           "Kesalahan basis data",
+	"Name is required":         "Nama wajib diisi",
+	"Invalid pattern: %v":      "Pola tidak valid: %v",
+	"Invalid exclude_tags: %v": "exclude_tags tidak valid: %v",
+
+	// Registries
+	"Invalid registry ID":                              "ID registry tidak valid",
+	"Invalid registry_id":                              "registry_id tidak valid",
+	"Missing registry ID":                              "ID registry tidak diisi",
+	"Missing registry_id":                              "registry_id tidak diisi",
+	"Registry not found":                               "Registry tidak ditemukan",
+	"Source registry not found":                        "Registry sumber tidak ditemukan",
+	"Target registry not found":                        "Registry tujuan tidak ditemukan",
+	"Name and URL are required":                        "Nama dan URL wajib diisi",
+	"Failed to create registry":                        "Gagal membuat registry",
+	"Failed to update registry":                        "Gagal memperbarui registry",
+	"Failed to delete registry":                        "Gagal menghapus registry",
+	"Failed to load registries":                        "Gagal memuat daftar registry",
+	"Registry created successfully":                    "Registry berhasil dibuat",
+	"Registry updated successfully":                    "Registry berhasil diperbarui",
+	"Registry deleted successfully":                    "Registry berhasil dihapus",
+	"Connection failed: %v":                            "Koneksi gagal: %v",
+	"Sync failed: %v":                                  "Sinkronisasi gagal: %v",
+	"Invalid webhook secret":                           "Secret webhook tidak valid",
+	"Invalid notification payload":                     "Payload notifikasi tidak valid",
+	"Failed to calculate registry size: %v":            "Gagal menghitung ukuran registry: %v",
+	"No credential rotation in progress":               "Tidak ada rotasi kredensial yang berjalan",
+	"No staged credentials to validate":                "Tidak ada kredensial baru untuk divalidasi",
+	"Credential validation failed: %s":                 "Validasi kredensial gagal: %s",
+	"rollback_hours must not be negative":              "rollback_hours tidak boleh negatif",
+	"Validate the staged credentials before switching": "Validasi kredensial baru sebelum beralih",
+	"No previous credentials to roll back to":          "Tidak ada kredensial lama untuk dipulihkan",
+	"Credential rotation discarded":                    "Rotasi kredensial dibatalkan",
+
+	// Repositories, tags and images
+	"Repository name is required":                                "Nama repository wajib diisi",
+	"Repository name is required (query param: repo)":            "Nama repository wajib diisi (parameter query: repo)",
+	"Repository name and tag are required":                       "Nama repository dan tag wajib diisi",
+	"Repository name and layer digest (sha256:...) are required": "Nama repository dan digest layer (sha256:...) wajib diisi",
+	"Repository, source_tag and target_tag are required":         "Repository, source_tag dan target_tag wajib diisi",
+	"Source repository and tag are required":                     "Repository dan tag sumber wajib diisi",
+	"Source and target are the same image":                       "Sumber dan tujuan adalah image yang sama",
+	"Failed to list repositories: %v":                            "Gagal menampilkan daftar repository: %v",
+	"Failed to list tags: %v":                                    "Gagal menampilkan daftar tag: %v",
+	"Failed to get manifest: %v":                                 "Gagal mengambil manifest: %v",
+	"Failed to get image config: %v":                             "Gagal mengambil konfigurasi image: %v",
+	"Failed to get digest: %v":                                   "Gagal mengambil digest: %v",
+	"Failed to delete tag: %v":                                   "Gagal menghapus tag: %v",
+	"Tag %s:%s deleted successfully":                             "Tag %s:%s berhasil dihapus",
+	"Failed to retag image: %v":                                  "Gagal memberi tag ulang pada image: %v",
+	"Failed to list layer files: %v":                             "Gagal menampilkan isi layer: %v",
+	"Copy job not found":                                         "Tugas penyalinan tidak ditemukan",
+	"Failed to save repository info: %v":                         "Gagal menyimpan info repository: %v",
+	"scan_mode must be '', 'always' or 'never'":                  "scan_mode harus '', 'always' atau 'never'",
+
+	// Scanning
+	"registry_id, repo and tag are required":               "registry_id, repo dan tag wajib diisi",
+	"No scan found":                                        "Hasil pemindaian tidak ditemukan",
+	"Failed to create scan record: %v":                     "Gagal membuat catatan pemindaian: %v",
+	"Failed to load policy":                                "Gagal memuat kebijakan",
+	"Invalid from scan ID":                                 "ID pemindaian asal tidak valid",
+	"Invalid to scan ID":                                   "ID pemindaian tujuan tidak valid",
+	"From scan not found for this image":                   "Pemindaian asal tidak ditemukan untuk image ini",
+	"To scan not found for this image":                     "Pemindaian tujuan tidak ditemukan untuk image ini",
+	"At least two completed scans are required for a diff": "Dibutuhkan minimal dua pemindaian selesai untuk perbandingan",
+
+	// Tag pins
+	"Invalid pin ID":             "ID pin tidak valid",
+	"Tag pin not found":          "Pin tag tidak ditemukan",
+	"Tag unpinned":               "Pin tag dilepas",
+	"Failed to save tag pin: %v": "Gagal menyimpan pin tag: %v",
+
+	// Applications
+	"Invalid application ID": "ID aplikasi tidak valid",
+	"Application not found":  "Aplikasi tidak ditemukan",
+	"Application deleted":    "Aplikasi dihapus",
+	"Each member needs an environment, registry_id and repository": "Setiap anggota membutuhkan environment, registry_id dan repository",
+	"Duplicate environment %q":                                     "Environment %q duplikat",
+	"Failed to save application: %v":                               "Gagal menyimpan aplikasi: %v",
+
+	// Policies, retention and onboarding
+	"Failed to save policy: %v":                        "Gagal menyimpan kebijakan: %v",
+	"Failed to get image policy: %v":                   "Gagal mengambil kebijakan image: %v",
+	"Invalid base image pattern %q":                    "Pola base image %q tidak valid",
+	"Action must be 'flag' or 'alert'":                 "Aksi harus 'flag' atau 'alert'",
+	"alert_webhook_url is required for action 'alert'": "alert_webhook_url wajib diisi untuk aksi 'alert'",
+	"Failed to get retention policy: %v":               "Gagal mengambil kebijakan retensi: %v",
+	"Failed to load retention templates: %v":           "Gagal memuat templat retensi: %v",
+	"Invalid tag pattern %q":                           "Pola tag %q tidak valid",
+	"Rule keep counts must not be negative":            "Jumlah simpan pada aturan tidak boleh negatif",
+	"Size budget must not be negative":                 "Batas ukuran tidak boleh negatif",
+	"Retention run failed: %v":                         "Eksekusi retensi gagal: %v",
+	"Invalid template ID":                              "ID templat tidak valid",
+	"Retention template not found":                     "Templat retensi tidak ditemukan",
+	"Retention template deleted":                       "Templat retensi dihapus",
+	"Failed to save retention template: %v":            "Gagal menyimpan templat retensi: %v",
+	"Invalid rule ID":                                  "ID aturan tidak valid",
+	"Name and pattern are required":                    "Nama dan pola wajib diisi",
+	"Onboarding rule not found":                        "Aturan onboarding tidak ditemukan",
+	"Onboarding rule deleted":                          "Aturan onboarding dihapus",
+	"Failed to save onboarding rule: %v":               "Gagal menyimpan aturan onboarding: %v",
+
+	// DefectDojo export
+	"Invalid mapping ID":                    "ID pemetaan tidak valid",
+	"engagement_id is required":             "engagement_id wajib diisi",
+	"trigger must be 'scan' or 'schedule'":  "trigger harus 'scan' atau 'schedule'",
+	"DefectDojo mapping not found":          "Pemetaan DefectDojo tidak ditemukan",
+	"DefectDojo mapping deleted":            "Pemetaan DefectDojo dihapus",
+	"Failed to save DefectDojo mapping: %v": "Gagal menyimpan pemetaan DefectDojo: %v",
+	"DefectDojo is not configured":          "DefectDojo belum dikonfigurasi",
+	"DefectDojo export failed: %v":          "Ekspor ke DefectDojo gagal: %v",
+
+	// Accounts and API tokens
+	"Authentication required":                 "Autentikasi diperlukan",
+	"Admin role required":                     "Diperlukan peran admin",
+	"Invalid username or password":            "Username atau password salah",
+	"Logged out":                              "Berhasil keluar",
+	"Current password is incorrect":           "Password saat ini salah",
+	"Password must be at least %d characters": "Password minimal %d karakter",
+	"Password changed":                        "Password berhasil diubah",
+	"Invalid token ID":                        "ID token tidak valid",
+	"API token not found":                     "Token API tidak ditemukan",
+	"API token revoked":                       "Token API dicabut",
+	"Username is required":                    "Username wajib diisi",
+	"Role must be 'admin' or 'user'":          "Peran harus 'admin' atau 'user'",
+	"Username already exists":                 "Username sudah digunakan",
+	"Invalid user ID":                         "ID user tidak valid",
+	"User not found":                          "User tidak ditemukan",
+
+	// Storage and embedded registry
+	"Storage type is required":                                      "Jenis penyimpanan wajib diisi",
+	"Invalid storage type":                                          "Jenis penyimpanan tidak valid",
+	"Local path is required":                                        "Path lokal wajib diisi",
+	"S3 endpoint and bucket are required":                           "Endpoint dan bucket S3 wajib diisi",
+	"SFTP host and user are required":                               "Host dan user SFTP wajib diisi",
+	"Failed to load storage config":                                 "Gagal memuat konfigurasi penyimpanan",
+	"Failed to save storage config":                                 "Gagal menyimpan konfigurasi penyimpanan",
+	"Storage configuration saved successfully.":                     "Konfigurasi penyimpanan berhasil disimpan.",
+	" Registry is restarting with new configuration.":               " Registry sedang dimulai ulang dengan konfigurasi baru.",
+	"Path exists but is not a directory":                            "Path ada tetapi bukan direktori",
+	"Cannot access path: %v":                                        "Tidak dapat mengakses path: %v",
+	"Cannot connect to S3 endpoint: %v":                             "Tidak dapat terhubung ke endpoint S3: %v",
+	"Cannot connect to SFTP server: %v":                             "Tidak dapat terhubung ke server SFTP: %v",
+	"Embedded registry is not available":                            "Registry bawaan tidak tersedia",
+	"Registry started successfully":                                 "Registry berhasil dijalankan",
+	"Registry restarted successfully":                               "Registry berhasil dimulai ulang",
+	"Registry stopped":                                              "Registry dihentikan",
+	"Failed to start registry: %v":                                  "Gagal menjalankan registry: %v",
+	"Failed to restart registry: %v":                                "Gagal memulai ulang registry: %v",
+	"Failed to stop registry: %v":                                   "Gagal menghentikan registry: %v",
+	"Failed to get logs: %v":                                        "Gagal mengambil log: %v",
+	"Failed to validate registry config: %v":                        "Gagal memvalidasi konfigurasi registry: %v",
+	"Registry config is invalid: %s":                                "Konfigurasi registry tidak valid: %s",
+	"Invalid page":                                                  "Halaman tidak valid",
+	"page_size must be between 1 and %d":                            "page_size harus antara 1 dan %d",
+	"Invalid sort field %q (use %s)":                                "Kolom pengurutan %q tidak valid (gunakan %s)",
+	"Query parameter q is required":                                 "Parameter q wajib diisi",
+	"Certificate not checked yet":                                   "Sertifikat belum diperiksa",
+	"Invalid seed image ID":                                         "ID image seed tidak valid",
+	"Seed image not found":                                          "Image seed tidak ditemukan",
+	"Failed to save seed image: %v":                                 "Gagal menyimpan image seed: %v",
+	"Seed image deleted":                                            "Image seed dihapus",
+	"Seeding failed: %v":                                            "Seeding gagal: %v",
+	"Search failed: %v":                                             "Pencarian gagal: %v",
+	"Invalid top":                                                   "Nilai top tidak valid",
+	"Invalid format (html, pdf or json)":                            "Format tidak valid (html, pdf atau json)",
+	"No completed scan found":                                       "Tidak ada pemindaian selesai yang ditemukan",
+	"Failed to build report: %v":                                    "Gagal membuat laporan: %v",
+	"Registry not probed yet":                                       "Registry belum diuji kesesuaiannya",
+	"Conformance probe failed: %v":                                  "Uji kesesuaian gagal: %v",
+	"The full report of this scan was evicted by the storage quota": "Laporan lengkap pemindaian ini telah dihapus karena kuota penyimpanan",
+	"Invalid API-Version header %q":                                 "Header API-Version tidak valid %q",
+	"API-Version %d does not match the version %d of the path":      "API-Version %d tidak sesuai dengan versi %d pada path",
+	"Unsupported API version %d (supported: %s)":                    "Versi API %d tidak didukung (didukung: %s)",
+	"Repository and new repository name are required":               "Nama repository dan nama barunya wajib diisi",
+	"The new name is the current name":                              "Nama baru sama dengan nama saat ini",
+	"Invalid repository name %q":                                    "Nama repository tidak valid %q",
+	"A rename of this repository is already running":                "Penggantian nama repository ini sedang berjalan",
+	"The registry does not allow deletes; set keep_source to copy the repository without removing it": "Registry tidak mengizinkan penghapusan; atur keep_source untuk menyalin repository tanpa menghapusnya",
+	"Repository %s has no tags":           "Repository %s tidak memiliki tag",
+	"Repository %s already exists":        "Repository %s sudah ada",
+	"Rename job not found":                "Tugas penggantian nama tidak ditemukan",
+	"At least one repository is required": "Minimal satu repository wajib diisi",
+	"Invalid tag pattern: %v":             "Pola tag tidak valid: %v",
+	"S3 export is not configured (start the dashboard with -export-s3-bucket)": "Ekspor S3 belum dikonfigurasi (jalankan dashboard dengan -export-s3-bucket)",
+	"Destination must be download or s3":                                       "Tujuan harus download atau s3",
+	"No tags match the selection":                                              "Tidak ada tag yang cocok dengan pilihan",
+	"Export job not found":                                                     "Tugas ekspor tidak ditemukan",
+	"Invalid %s":                                                               "%s tidak valid",
+	"Failed to load scans: %v":                                                 "Gagal memuat pemindaian: %v",
+	"Too many requests, slow down":                                             "Terlalu banyak permintaan, mohon perlambat",
+	"No configuration file to reload; start the dashboard with -config":        "Tidak ada berkas konfigurasi untuk dimuat ulang; jalankan dasbor dengan -config",
+	"Failed to reload configuration: %v":                                       "Gagal memuat ulang konfigurasi: %v",
+	"Backup failed: %v":                                                        "Pencadangan gagal: %v",
+	"Restore failed: %v":                                                       "Pemulihan gagal: %v",
+	"Backup file is too large":                                                 "Berkas cadangan terlalu besar",
+	"Failed to read backup: %v":                                                "Gagal membaca cadangan: %v",
+	"Send the backup file as the request body":                                 "Kirim berkas cadangan sebagai isi permintaan",
+	"Database restored":                                                        "Basis data dipulihkan",
+	"An ECR registry needs a region":                                           "Registri ECR memerlukan region",
+	"Unknown registry type: %s":                                                "Jenis registri tidak dikenal: %s",
+	"An ACR service principal needs a client ID and a tenant":                  "Service principal ACR memerlukan client ID dan tenant",
+	"Not a Harbor registry":                                                    "Bukan registri Harbor",
+	"Harbor API request failed: %v":                                            "Permintaan API Harbor gagal: %v",
+	"A Quay registry needs an OAuth token or the namespaces to list":           "Registri Quay memerlukan token OAuth atau namespace yang akan didaftar",
+	"A GHCR registry needs a personal access token as its password":            "Registri GHCR memerlukan personal access token sebagai kata sandinya",
+	"Not a Docker Hub registry":                                                "Bukan registri Docker Hub",
+	"Failed to read the Docker Hub rate limit: %v":                             "Gagal membaca batas laju Docker Hub: %v",
+	"Invalid replication rule ID":                                              "ID aturan replikasi tidak valid",
+	"Replication rule not found":                                               "Aturan replikasi tidak ditemukan",
+	"Rule name is required":                                                    "Nama aturan wajib diisi",
+	"Replicating a registry into itself needs a target prefix":                 "Replikasi registri ke dirinya sendiri memerlukan prefiks tujuan",
+	"Conflict must be overwrite or skip":                                       "Conflict harus overwrite atau skip",
+	"Failed to save replication rule: %v":                                      "Gagal menyimpan aturan replikasi: %v",
+	"Replication rule deleted":                                                 "Aturan replikasi dihapus",
+	"Replication is already running":                                           "Replikasi sedang berjalan",
+	"The rule has not run yet":                                                 "Aturan belum pernah berjalan",
+	"Invalid registry user ID":                                                 "ID pengguna registri tidak valid",
+	"Registry user not found":                                                  "Pengguna registri tidak ditemukan",
+	"Username must be 1-64 letters, digits, dots, dashes or underscores":       "Username harus 1-64 huruf, angka, titik, tanda hubung atau garis bawah",
+	"This username is reserved for the dashboard":                              "Username ini dicadangkan untuk dasbor",
+	"The dashboard's account is removed with the last registry user":           "Akun dasbor dihapus bersama pengguna registri terakhir",
+	"Registry users saved, but the htpasswd file could not be written.":        "Pengguna registri disimpan, tetapi file htpasswd tidak dapat ditulis.",
+	"Registry users saved. They apply when the registry starts.":               "Pengguna registri disimpan. Perubahan berlaku saat registri dijalankan.",
+	"Registry users saved. They apply when the registry restarts.":             "Pengguna registri disimpan. Perubahan berlaku saat registri dimulai ulang.",
+	"Registry users saved. Registry is restarting with new configuration.":     "Pengguna registri disimpan. Registri sedang dimulai ulang dengan konfigurasi baru.",
+	"The dashboard's account always has full access":                           "Akun dasbor selalu memiliki akses penuh",
+	"Permission repository is required":                                        "Repositori izin wajib diisi",
+	"Permission actions must be pull, push or delete":                          "Aksi izin harus pull, push atau delete",
+	"Registry token authentication is not enabled":                             "Autentikasi token registri tidak diaktifkan",
+	"Invalid registry credentials":                                             "Kredensial registri tidak valid",
+	"GCS bucket is required":                                                   "Bucket GCS wajib diisi",
+	"Cannot access GCS bucket: %v":                                             "Tidak dapat mengakses bucket GCS: %v",
+	"Swift auth URL and container are required":                                "URL autentikasi dan container Swift wajib diisi",
+	"Invalid Swift auth URL":                                                   "URL autentikasi Swift tidak valid",
+	"Cannot connect to Swift auth URL: %v":                                     "Tidak dapat terhubung ke URL autentikasi Swift: %v",
+	"Failed to read storage usage: %v":                                         "Gagal membaca penggunaan penyimpanan: %v",
+	"Invalid registry image: %v":                                               "Image registry tidak valid: %v",
+	"Failed to upgrade registry: %v":                                           "Gagal memperbarui registry: %v",
+	"Registry name must be 1-32 lowercase letters, digits and dashes":          "Nama registry harus 1-32 huruf kecil, angka, dan tanda hubung",
+	"Port must be between 1 and 65535":                                         "Port harus antara 1 dan 65535",
+	"Port is used by another embedded registry":                                "Port sudah dipakai oleh registry tertanam lain",
+	"A registry with this name already exists":                                 "Registry dengan nama ini sudah ada",
+	"Registry created and started":                                             "Registry dibuat dan dijalankan",
+	"Registry created, but it failed to start: %v":                             "Registry dibuat, tetapi gagal dijalankan: %v",
+	"Registry removed. Its data directory was kept.":                           "Registry dihapus. Direktori datanya tetap disimpan.",
+	"Container name must start with a letter or digit and contain only letters, digits, dots, dashes and underscores": "Nama container harus diawali huruf atau angka dan hanya berisi huruf, angka, titik, tanda hubung, dan garis bawah",
+	"Port or container name is used by the registry %s":                                                               "Port atau nama container sudah dipakai oleh registry %s",
+	"Failed to move registry: %v":                                   "Gagal memindahkan registry: %v",
+	"Registry moved, but the settings could not be saved: %v":       "Registry dipindahkan, tetapi pengaturannya tidak dapat disimpan: %v",
+	"Registry moved to %s":                                          "Registry dipindahkan ke %s",
+	"Invalid %s: use an RFC 3339 time such as 2024-05-01T00:00:00Z": "%s tidak valid: gunakan waktu RFC 3339 seperti 2024-05-01T00:00:00Z",
+	"The dashboard is in read-only maintenance mode":                "Dasbor sedang dalam mode pemeliharaan baca-saja",
+	"Maintenance mode enabled":                                      "Mode pemeliharaan diaktifkan",
+	"Maintenance mode disabled":                                     "Mode pemeliharaan dinonaktifkan",
+	"Repository not found":                                          "Repositori tidak ditemukan",
+	"Maintainer role required in the project":                       "Diperlukan peran maintainer di proyek",
+	"Failed to save project: %v":                                    "Gagal menyimpan proyek: %v",
+	"Failed to save team: %v":                                       "Gagal menyimpan tim: %v",
+	"Each repository needs a registry_id and repository":            "Setiap repositori memerlukan registry_id dan repository",
+	"Role must be 'viewer' or 'maintainer'":                         "Peran harus 'viewer' atau 'maintainer'",
+	"Each project may only be listed once":                          "Setiap proyek hanya boleh dicantumkan sekali",
+	"Project not found":                                             "Proyek tidak ditemukan",
+	"Team not found":                                                "Tim tidak ditemukan",
+	"Project deleted":                                               "Proyek dihapus",
+	"Team deleted":                                                  "Tim dihapus",
+	"Invalid project ID":                                            "ID proyek tidak valid",
+	"Invalid team ID":                                               "ID tim tidak valid",
+	"Access rules do not allow %s in %s":                            "Aturan akses tidak mengizinkan %s di %s",
+	"Invalid access rule ID":                                        "ID aturan akses tidak valid",
+	"Access rule not found":                                         "Aturan akses tidak ditemukan",
+	"Access rule actions must be push or delete":                    "Aksi aturan akses harus push atau delete",
+	"Failed to save access rule: %v":                                "Gagal menyimpan aturan akses: %v",
+	"Access rule deleted":                                           "Aturan akses dihapus",
+	"Repository is required":                                        "Repositori wajib diisi",
+	"Tag %s:%s is immutable: it matches %s":                         "Tag %s:%s tidak dapat diubah: cocok dengan %s",
+	"Tag %s:%s points at the same image as the immutable tag %s":    "Tag %s:%s menunjuk image yang sama dengan tag tetap %s",
+	"Repository %s has the immutable tag %s; set keep_source to copy the repository without removing it": "Repositori %s memiliki tag tetap %s; atur keep_source untuk menyalin repositori tanpa menghapusnya",
+	"Tag pattern is required":                                              "Pola tag wajib diisi",
+	"Failed to save immutable tag rule: %v":                                "Gagal menyimpan aturan tag tetap: %v",
+	"Immutable tag rule not found":                                         "Aturan tag tetap tidak ditemukan",
+	"Immutable tag rule deleted":                                           "Aturan tag tetap dihapus",
+	"Repository %s is over its storage quota (%d of %d bytes used)":        "Repositori %s melebihi kuota penyimpanannya (%d dari %d byte terpakai)",
+	"A quota is for a project or for repositories of a registry, not both": "Kuota berlaku untuk proyek atau repositori registry, tidak keduanya",
+	"The quota limit must be positive":                                     "Batas kuota harus positif",
+	"The warning threshold must be between 1 and 100 percent":              "Ambang peringatan harus antara 1 dan 100 persen",
+	"Quota not found":                                                      "Kuota tidak ditemukan",
+	"Failed to save quota: %v":                                             "Gagal menyimpan kuota: %v",
+	"Quota deleted":                                                        "Kuota dihapus",
+	"Invalid quota ID":                                                     "ID kuota tidak valid",
+	"Failed to measure quota usage: %v":                                    "Gagal mengukur penggunaan kuota: %v",
+	"The README is larger than %d bytes":                                   "README lebih besar dari %d byte",
+	"Link %q must start with http:// or https://":                          "Tautan %q harus diawali http:// atau https://",
+	"Failed to save repository metadata: %v":                               "Gagal menyimpan metadata repository: %v",
+	"Failed to load the Notation trust policy: %v":                         "Gagal memuat kebijakan kepercayaan Notation: %v",
+	"Invalid trust policy: %v":                                             "Kebijakan kepercayaan tidak valid: %v",
+	"Failed to save trust policy: %v":                                      "Gagal menyimpan kebijakan kepercayaan: %v",
+	"Invalid trust store ID":                                               "ID trust store tidak valid",
+	"Trust store type must be ca, signingAuthority or tsa":                 "Tipe trust store harus ca, signingAuthority atau tsa",
+	"Trust store name is required and cannot contain ':' or spaces":        "Nama trust store wajib diisi dan tidak boleh berisi ':' atau spasi",
+	"Invalid certificates: %v":                                             "Sertifikat tidak valid: %v",
+	"Trust store not found":                                                "Trust store tidak ditemukan",
+	"Failed to save trust store: %v":                                       "Gagal menyimpan trust store: %v",
+	"Trust store deleted":                                                  "Trust store dihapus",
+	"Failed to read attestations: %v":                                      "Gagal membaca attestation: %v",
+	"Image signing is not configured (start the dashboard with -signing-key-secret)": "Penandatanganan image belum dikonfigurasi (jalankan dashboard dengan -signing-key-secret)",
+	"Key name is required":                                "Nama kunci wajib diisi",
+	"A signing key with this name already exists":         "Kunci penandatanganan dengan nama ini sudah ada",
+	"Invalid private key: %v":                             "Private key tidak valid: %v",
+	"Failed to encrypt the private key: %v":               "Gagal mengenkripsi private key: %v",
+	"Failed to save signing key: %v":                      "Gagal menyimpan kunci penandatanganan: %v",
+	"Invalid signing key ID":                              "ID kunci penandatanganan tidak valid",
+	"Signing key not found":                               "Kunci penandatanganan tidak ditemukan",
+	"Signing key deleted":                                 "Kunci penandatanganan dihapus",
+	"Repository and a tag or digest are required":         "Repository dan tag atau digest wajib diisi",
+	"Failed to decrypt the signing key: %v":               "Gagal mendekripsi kunci penandatanganan: %v",
+	"Failed to sign image: %v":                            "Gagal menandatangani image: %v",
+	"Import failed: %v":                                   "Impor gagal: %v",
+	"Image archive is too large":                          "Arsip image terlalu besar",
+	"Invalid image archive: %v":                           "Arsip image tidak valid: %v",
+	"The archive contains no images":                      "Arsip tidak berisi image",
+	"tag can only be given for an archive with one image": "tag hanya dapat diberikan untuk arsip dengan satu image",
+	"Image %d of the archive has no name; give it one with repository and tag": "Image %d dalam arsip tidak memiliki nama; beri nama dengan repository dan tag",
+	"Failed to import %s:%s: %v": "Gagal mengimpor %s:%s: %v",
+	"Registry webhooks need -webhook-secret when authentication is required":                  "Webhook registry memerlukan -webhook-secret saat autentikasi diwajibkan",
+	"You may not sign this repository with this key":                                          "Anda tidak boleh menandatangani repository ini dengan kunci ini",
+	"Registry webhooks without -webhook-secret are only accepted from the registry's address": "Webhook registry tanpa -webhook-secret hanya diterima dari alamat registry",
+	"Maintenance mode could not be checked; try again later":                                  "Mode pemeliharaan tidak dapat diperiksa; coba lagi nanti",
+}
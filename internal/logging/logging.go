@@ -0,0 +1,110 @@
+// Package logging filters the process log by severity. Log lines keep using the standard log
+// package; their severity is read from the marker they start with (❌ errors, ⚠️ warnings).
+package logging
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// Level is the least severe kind of log line that is written
+type Level int32
+
+const (
+	Debug Level = iota
+	Info
+	Warn
+	Error
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < Debug || l > Error {
+		return fmt.Sprintf("level(%d)", int(l))
+	}
+	return levelNames[l]
+}
+
+// Set parses a level name, so a Level can be a command line flag
+func (l *Level) Set(s string) error {
+	parsed, err := ParseLevel(s)
+	if err != nil {
+		return err
+	}
+	*l = parsed
+	return nil
+}
+
+// ParseLevel parses "debug", "info", "warn" or "error"
+func ParseLevel(s string) (Level, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "warning" {
+		s = "warn"
+	}
+	for i, name := range levelNames {
+		if s == name {
+			return Level(i), nil
+		}
+	}
+	return Info, fmt.Errorf("unknown log level %q (use debug, info, warn or error)", s)
+}
+
+var level atomic.Int32
+
+func init() {
+	level.Store(int32(Info))
+}
+
+// SetLevel changes the level; it takes effect for the next line logged
+func SetLevel(l Level) {
+	level.Store(int32(l))
+}
+
+// CurrentLevel returns the active level
+func CurrentLevel() Level {
+	return Level(level.Load())
+}
+
+// Debugf logs a message only at the debug level
+func Debugf(format string, args ...interface{}) {
+	if CurrentLevel() <= Debug {
+		log.Printf(format, args...)
+	}
+}
+
+// Install routes the standard logger through the level filter, writing to out
+func Install(out io.Writer) {
+	log.SetOutput(&filter{out: out})
+}
+
+// markerWindow is how far into a line, past the timestamp, the severity marker is looked for
+const markerWindow = 48
+
+// filter drops the log lines below the active level
+type filter struct {
+	out io.Writer
+}
+
+func (f *filter) Write(p []byte) (int, error) {
+	if severity(p) < CurrentLevel() {
+		return len(p), nil
+	}
+	return f.out.Write(p)
+}
+
+// severity classifies a log line by its marker
+func severity(line []byte) Level {
+	head := line[:min(len(line), markerWindow)]
+	switch {
+	case bytes.Contains(head, []byte("❌")):
+		return Error
+	case bytes.Contains(head, []byte("⚠")):
+		return Warn
+	}
+	return Info
+}
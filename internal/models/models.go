@@ -1,23 +1,79 @@
 package models
 
-import "time"
+import (
+	"regexp"
+	"strings"
+	"time"
+)
 
 // Registry represents a Docker Registry V2 connection
 type Registry struct {
-	ID        int64     `json:"id"`
-	Name      string    `json:"name"`
-	URL       string    `json:"url"`
-	Username  string    `json:"username,omitempty"`
-	Password  string    `json:"password,omitempty"`
-	Insecure  bool      `json:"insecure"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID         int64     `json:"id"`
+	Name       string    `json:"name"`
+	URL        string    `json:"url"`
+	Username   string    `json:"username,omitempty"`
+	Password   string    `json:"password,omitempty"`
+	Insecure   bool      `json:"insecure"`
+	Type       string    `json:"type,omitempty"`       // "" for a Docker Registry v2, or one of the RegistryType constants
+	Region     string    `json:"region,omitempty"`     // AWS region of an ECR registry
+	Tenant     string    `json:"tenant,omitempty"`     // Entra ID tenant of an ACR registry's service principal
+	Namespaces string    `json:"namespaces,omitempty"` // Comma-separated namespaces of a Quay or GHCR registry
+	CACert     string    `json:"ca_cert,omitempty"`    // PEM bundle of CAs trusted for the registry's certificate, besides the system roots
+	Proxy      string    `json:"proxy,omitempty"`      // Proxy URL of the registry's connections; empty: HTTPS_PROXY, HTTP_PROXY and NO_PROXY
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// RegistryTypeECR is an Amazon ECR registry. Username and Password hold an IAM access key, which
+// is exchanged for registry credentials; without one the dashboard's own AWS role is used.
+const RegistryTypeECR = "ecr"
+
+// RegistryTypeGCP is a Google Artifact Registry or Container Registry. Password holds a service
+// account JSON key, which mints access tokens; without one the dashboard's workload identity is used.
+const RegistryTypeGCP = "gcp"
+
+// RegistryTypeACR is an Azure Container Registry. Username and Password hold the client ID and
+// secret of a service principal of Tenant; with only a client ID, that user-assigned managed
+// identity is used, and with neither the dashboard's own Azure identity.
+const RegistryTypeACR = "acr"
+
+// RegistryTypeQuay is a Quay registry (quay.io or self-hosted), whose catalog is restricted.
+// Password holds an OAuth application token; repositories and tags are listed with the Quay API.
+const RegistryTypeQuay = "quay"
+
+// RegistryTypeGHCR is GitHub Container Registry, whose catalog is disabled. Password holds a
+// personal access token with read:packages; repositories are listed with the Packages API.
+const RegistryTypeGHCR = "ghcr"
+
+// Credential rotation states
+const (
+	RotationStaged     = "staged"      // Secondary credentials stored, not yet validated
+	RotationValidated  = "validated"   // Secondary credentials accepted by the registry
+	RotationSwitched   = "switched"    // Secondary credentials in use, old ones kept for rollback
+	RotationRolledBack = "rolled_back" // Old credentials restored, the new ones are staged again
+	RotationCompleted  = "completed"   // Rollback window over, old credentials discarded
+)
+
+// CredentialRotation is a blue/green change of a registry's credentials: the new set is staged
+// and validated next to the active one, switched in atomically and the old set kept for rollback
+type CredentialRotation struct {
+	RegistryID    int64      `json:"registry_id"`
+	State         string     `json:"state"`
+	NewUsername   string     `json:"new_username"`
+	NewPassword   string     `json:"-"`
+	OldUsername   string     `json:"old_username"`
+	OldPassword   string     `json:"-"`
+	StagedAt      time.Time  `json:"staged_at"`
+	ValidatedAt   *time.Time `json:"validated_at"`
+	SwitchedAt    *time.Time `json:"switched_at"`
+	RollbackUntil *time.Time `json:"rollback_until"` // Old credentials are kept until then
+	LastError     string     `json:"last_error"`
 }
 
 // StorageConfig represents storage backend configuration
 type StorageConfig struct {
 	ID   int64  `json:"id"`
-	Type string `json:"type"` // local, s3, sftp
+	Type string `json:"type"` // local, s3, gcs, swift, sftp
 
 	// Local storage
 	LocalPath string `json:"local_path,omitempty"`
@@ -30,6 +86,18 @@ type StorageConfig struct {
 	S3SecretKey string `json:"s3_secret_key,omitempty"`
 	S3UseSSL    bool   `json:"s3_use_ssl"`
 
+	// Google Cloud Storage; without credentials the registry uses the credentials of the machine it runs on
+	GCSBucket        string `json:"gcs_bucket,omitempty"`
+	GCSCredentials   string `json:"gcs_credentials,omitempty"` // Service account JSON key
+	GCSRootDirectory string `json:"gcs_root_directory,omitempty"`
+
+	// OpenStack Swift, authenticated with Keystone
+	SwiftAuthURL   string `json:"swift_auth_url,omitempty"`
+	SwiftUsername  string `json:"swift_username,omitempty"`
+	SwiftPassword  string `json:"swift_password,omitempty"`
+	SwiftContainer string `json:"swift_container,omitempty"`
+	SwiftRegion    string `json:"swift_region,omitempty"`
+
 	// SFTP
 	SFTPHost       string `json:"sftp_host,omitempty"`
 	SFTPPort       int    `json:"sftp_port,omitempty"`
@@ -38,10 +106,126 @@ type StorageConfig struct {
 	SFTPPrivateKey string `json:"sftp_private_key,omitempty"`
 	SFTPPath       string `json:"sftp_path,omitempty"`
 
+	// Pull-through cache: the registry mirrors this upstream (e.g. https://registry-1.docker.io)
+	// and rejects pushes
+	ProxyRemoteURL string `json:"proxy_remote_url,omitempty"`
+	ProxyUsername  string `json:"proxy_username,omitempty"`
+	ProxyPassword  string `json:"proxy_password,omitempty"`
+
+	// Redis blob-descriptor cache. Without an address the dashboard runs a Redis container linked
+	// to the registry's.
+	RedisCache    bool   `json:"redis_cache"`
+	RedisAddr     string `json:"redis_addr,omitempty"` // host:port of an existing Redis
+	RedisPassword string `json:"redis_password,omitempty"`
+	RedisDB       int    `json:"redis_db,omitempty"`
+
+	// YAML deep-merged into the generated config.yml, for registry features the settings do not cover
+	ConfigOverrides string `json:"config_overrides,omitempty"`
+
+	// Image of the registry container, such as registry:2.8.3 or ghcr.io/distribution/distribution:3;
+	// empty: registry:2
+	RegistryImage string `json:"registry_image,omitempty"`
+
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// RegistryIncident is a failure of the embedded registry, noticed by its watchdog or in the
+// container's events
+type RegistryIncident struct {
+	Time         time.Time `json:"time"`
+	Kind         string    `json:"kind"` // exited, oom, down or unresponsive
+	Detail       string    `json:"detail"`
+	Restarted    bool      `json:"restarted"` // Whether the watchdog restarted the registry
+	RestartError string    `json:"restart_error,omitempty"`
+}
+
+// RegistryUpgrade is the result of moving the embedded registry to another image
+type RegistryUpgrade struct {
+	PreviousImage   string `json:"previous_image"`
+	PreviousVersion string `json:"previous_version,omitempty"`
+	Image           string `json:"image"`
+	Version         string `json:"version,omitempty"`
+}
+
+// EmbeddedRegistrySettings are the host port and container name of the embedded registry. Once
+// changed at runtime they are kept over the -registry-port flag.
+type EmbeddedRegistrySettings struct {
+	Port          int       `json:"port"`
+	ContainerName string    `json:"container_name"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// MaintenanceMode is the read-only switch of the dashboard: while it is on, requests that change
+// registries, storage or settings are refused and scheduled work that writes to registries waits
+type MaintenanceMode struct {
+	Enabled   bool      `json:"enabled"`
+	Reason    string    `json:"reason,omitempty"`
+	StartedBy string    `json:"started_by,omitempty"`
+	StartedAt time.Time `json:"started_at,omitempty"`
+}
+
+// ManagedRegistry is an additional embedded registry the dashboard runs next to the primary one,
+// with its own container, port, storage and account
+type ManagedRegistry struct {
+	ID           int64         `json:"id"`
+	Name         string        `json:"name"`
+	Port         int           `json:"port"`
+	Storage      StorageConfig `json:"storage"`
+	Username     string        `json:"username,omitempty"` // Without one the registry takes anonymous requests
+	Password     string        `json:"password,omitempty"` // Only accepted when creating it
+	PasswordHash string        `json:"-"`
+	Enabled      bool          `json:"enabled"`     // Started with the dashboard; cleared by stopping it
+	RegistryID   int64         `json:"registry_id"` // The registry entry it was auto-registered as
+	CreatedAt    time.Time     `json:"created_at"`
+
+	Status map[string]interface{} `json:"status,omitempty"` // Of the running registry
+}
+
+// RegistryConfigCheck is the result of validating a generated embedded registry config
+type RegistryConfigCheck struct {
+	Config           string   `json:"config"` // Rendered config.yml with secrets masked
+	Valid            bool     `json:"valid"`
+	Problems         []string `json:"problems"`
+	ContainerChecked bool     `json:"container_checked"` // Whether a throwaway registry container loaded the config
+}
+
+// RegistryUser is an account of the embedded registry's htpasswd authentication
+type RegistryUser struct {
+	ID           int64     `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"-"`       // bcrypt, the only scheme the registry's htpasswd accepts
+	Managed      bool      `json:"managed"` // The dashboard's own account, used by the Local Registry entry
+	CreatedAt    time.Time `json:"created_at"`
+
+	Permissions []RegistryPermission `json:"permissions"` // Enforced when the registry uses token authentication
+}
+
+// RegistryPermission grants an embedded registry account actions on repositories
+type RegistryPermission struct {
+	ID         int64  `json:"id"`
+	UserID     int64  `json:"user_id"`
+	Repository string `json:"repository"` // Repository name, a prefix ending in "*", or "*" for all
+	Actions    string `json:"actions"`    // Comma-separated: pull, push, delete
+}
+
+// ProxyCacheStats are the pull-through cache counters of the embedded registry since its container started
+type ProxyCacheStats struct {
+	RemoteURL string             `json:"remote_url"`
+	Blobs     ProxyCacheCounters `json:"blobs"`
+	Manifests ProxyCacheCounters `json:"manifests"`
+	HitRate   float64            `json:"hit_rate"` // Percentage of blob and manifest requests served from the cache
+}
+
+// ProxyCacheCounters are the registry's proxy metrics for one kind of content
+type ProxyCacheCounters struct {
+	Requests    uint64 `json:"requests"`
+	Hits        uint64 `json:"hits"`
+	Misses      uint64 `json:"misses"`
+	BytesPulled uint64 `json:"bytes_pulled"` // Fetched from the upstream registry
+	BytesPushed uint64 `json:"bytes_pushed"`
+}
+
 // RetentionPolicy defines rules for image cleanup
 type RetentionPolicy struct {
 	ID            int64     `json:"id"`
@@ -53,6 +237,18 @@ type RetentionPolicy struct {
 	FilterRepos   string    `json:"filter_repos"`  // Regex to select specific repos (empty=all)
 	ExcludeRepos  string    `json:"exclude_repos"` // Regex to exclude specific repos
 	ExcludeTags   string    `json:"exclude_tags"`  // Regex to exclude specific tags (e.g. "latest")
+	// Per-tag-pattern rules, evaluated per repository; tags matching no rule use KeepLastCount/KeepDays
+	Rules []TagRetentionRule `json:"rules"`
+	// Size budget per repository in GB (0 = off): the oldest images are deleted until the repository fits
+	MaxRepoSizeGB float64 `json:"max_repo_size_gb"`
+}
+
+// TagRetentionRule keeps the newest KeepCount tags matching TagPattern (0 deletes them all).
+// A tag is governed by the first rule whose pattern matches it.
+type TagRetentionRule struct {
+	TagPattern string `json:"tag_pattern"`
+	KeepCount  int    `json:"keep_count"`
+	KeepDays   int    `json:"keep_days,omitempty"` // Also keep matching tags newer than N days
 }
 
 // ScanPolicy defines rules for vulnerability scanning
@@ -65,6 +261,82 @@ type ScanPolicy struct {
 	LastRunAt     time.Time `json:"last_run_at"`
 	FilterRepos   string    `json:"filter_repos"` // Regex to include repos
 	FilterTags    string    `json:"filter_tags"`  // Regex to include tags
+	ScanOnPush    bool      `json:"scan_on_push"` // Scan matching tags as soon as a push event arrives
+}
+
+// SyncResult summarizes one catalog sync of a registry
+type SyncResult struct {
+	RegistryID   int64     `json:"registry_id"`
+	Repositories int       `json:"repositories"`
+	Tags         int       `json:"tags"`
+	Violations   int       `json:"violations"`
+	Drifts       int       `json:"drifts"`     // Pinned tags newly found pointing at another digest
+	Pushes       int       `json:"pushes"`     // Tags found that were not seen by the previous sync
+	Discovered   []string  `json:"discovered"` // Repositories seen for the first time
+	Onboarded    int       `json:"onboarded"`  // Discovered repositories set up by an onboarding rule
+	StartedAt    time.Time `json:"started_at"`
+	FinishedAt   time.Time `json:"finished_at"`
+}
+
+// ImagePolicy restricts which images a registry may hold; violations are found during catalog sync
+type ImagePolicy struct {
+	ID                 int64     `json:"id"`
+	RegistryID         int64     `json:"registry_id"`
+	Enabled            bool      `json:"enabled"`
+	DeniedNamespaces   string    `json:"denied_namespaces"`    // Comma-separated namespaces (e.g. "tmp,dev/scratch")
+	ApprovedBaseImages string    `json:"approved_base_images"` // Comma-separated base images, globs allowed (e.g. "docker.io/library/alpine:*")
+	Action             string    `json:"action"`               // "flag" (report only) or "alert" (also POST new violations to AlertWebhookURL)
+	AlertWebhookURL    string    `json:"alert_webhook_url"`
+	LastEvaluatedAt    time.Time `json:"last_evaluated_at"`
+}
+
+// ComplianceViolation is an image that breaks its registry's image policy
+type ComplianceViolation struct {
+	ID         int64     `json:"id"`
+	RegistryID int64     `json:"registry_id"`
+	Repository string    `json:"repository"`
+	Tag        string    `json:"tag"`
+	Digest     string    `json:"digest,omitempty"`
+	Rule       string    `json:"rule"` // denied_namespace, unapproved_base, unknown_base
+	Detail     string    `json:"detail"`
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// TagPin holds a tag to the digest it pointed at when pinned; catalog sync raises a drift
+// alert when the tag is found pointing elsewhere
+type TagPin struct {
+	ID              int64      `json:"id"`
+	RegistryID      int64      `json:"registry_id"`
+	Repository      string     `json:"repository"`
+	Tag             string     `json:"tag"`
+	Digest          string     `json:"digest"`
+	AlertWebhookURL string     `json:"alert_webhook_url"` // Empty = the registry's image policy webhook, if any
+	PinnedBy        string     `json:"pinned_by"`
+	PinnedAt        time.Time  `json:"pinned_at"`
+	LastCheckedAt   *time.Time `json:"last_checked_at"`
+	Drifted         bool       `json:"drifted"`
+	ObservedDigest  string     `json:"observed_digest"` // Digest seen by the last sync when drifted ("" = tag missing)
+}
+
+// TagDrift records a pinned tag found pointing at a digest other than its pinned one
+type TagDrift struct {
+	ID             int64     `json:"id"`
+	PinID          int64     `json:"pin_id"`
+	RegistryID     int64     `json:"registry_id"`
+	Repository     string    `json:"repository"`
+	Tag            string    `json:"tag"`
+	PinnedDigest   string    `json:"pinned_digest"`
+	ObservedDigest string    `json:"observed_digest"` // "" when the tag was deleted
+	DetectedAt     time.Time `json:"detected_at"`
+}
+
+// ComplianceReport lists the current policy violations of a registry
+type ComplianceReport struct {
+	RegistryID      int64                 `json:"registry_id"`
+	RegistryName    string                `json:"registry_name"`
+	PolicyEnabled   bool                  `json:"policy_enabled"`
+	LastEvaluatedAt time.Time             `json:"last_evaluated_at"`
+	Violations      []ComplianceViolation `json:"violations"`
 }
 
 // VulnerabilityScan represents a trivy scan result
@@ -74,32 +346,359 @@ type VulnerabilityScan struct {
 	Repository string    `json:"repository"`
 	Tag        string    `json:"tag"`
 	Digest     string    `json:"digest"`
-	Status     string    `json:"status"`  // pending, scanning, completed, failed
+	Status     string    `json:"status"`  // pending, scanning, completed, failed, interrupted
 	Summary    string    `json:"summary"` // JSON string of severity counts
 	Report     string    `json:"report"`  // Full JSON report (compressed/text)
 	ScannedAt  time.Time `json:"scanned_at"`
 }
 
+// InterruptedScan is a scan a shutdown cut short, queued again on the next start
+type InterruptedScan struct {
+	RegistryID int64
+	Repository string
+	Tag        string
+	Scanner    string // Empty for the default scanner
+	Platform   string
+}
+
+// ScanHistory is an immutable snapshot of a completed scan, kept for comparisons
+type ScanHistory struct {
+	ID         int64     `json:"id"`
+	RegistryID int64     `json:"registry_id"`
+	Repository string    `json:"repository"`
+	Tag        string    `json:"tag"`
+	Digest     string    `json:"digest"`
+	Summary    string    `json:"summary"`
+	Report     string    `json:"report,omitempty"`
+	ScannedAt  time.Time `json:"scanned_at"`
+	// ReportEvicted is set once the storage quota or the report age limit removed the full report;
+	// the summary remains
+	ReportEvicted bool `json:"report_evicted,omitempty"`
+}
+
+// RegistryEvent is a push/pull/delete notification normalized from a registry webhook,
+// a push detected by a catalog sync, or a scan report evicted by the storage quota
+type RegistryEvent struct {
+	ID         int64     `json:"id"`
+	RegistryID int64     `json:"registry_id"`
+	Source     string    `json:"source"` // distribution, harbor, gitlab, sync, quota
+	Action     string    `json:"action"` // push, pull, delete, evict
+	Repository string    `json:"repository"`
+	Tag        string    `json:"tag,omitempty"`
+	Digest     string    `json:"digest,omitempty"`
+	MediaType  string    `json:"media_type,omitempty"`
+	Actor      string    `json:"actor,omitempty"`
+	ActorIP    string    `json:"actor_ip,omitempty"` // Address of the client the registry served
+	Detail     string    `json:"detail,omitempty"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
 // RetentionLog represents the result of a retention run
 type RetentionLog struct {
 	Repository string    `json:"repository"`
 	Tag        string    `json:"tag"`
 	Digest     string    `json:"digest"`
 	Created    time.Time `json:"created"`
-	Action     string    `json:"action"` // "kept" or "deleted" (or "would_delete")
+	Size       int64     `json:"size,omitempty"` // Bytes referenced by the image (computed for size budgets and deletions)
+	Action     string    `json:"action"`         // "kept" or "deleted" (or "would_delete")
 	Reason     string    `json:"reason"`
 }
 
+// DeletedImage is a permanent ledger entry for an image deleted through the dashboard
+type DeletedImage struct {
+	ID           int64     `json:"id"`
+	RegistryID   int64     `json:"registry_id"`
+	RegistryName string    `json:"registry_name"` // Kept so entries stay readable after the registry is removed
+	Repository   string    `json:"repository"`
+	Tag          string    `json:"tag"`
+	Digest       string    `json:"digest"`
+	Size         int64     `json:"size"`
+	DeletedBy    string    `json:"deleted_by"` // Username, empty when authentication is disabled
+	RemoteAddr   string    `json:"remote_addr"`
+	Source       string    `json:"source"` // "manual", "retention" or "rename"
+	Policy       string    `json:"policy,omitempty"`
+	Reason       string    `json:"reason,omitempty"`
+	DeletedAt    time.Time `json:"deleted_at"`
+}
+
 // Repository represents a Docker image repository
 type Repository struct {
-	Name     string `json:"name"`
-	TagCount int    `json:"tag_count,omitempty"`
+	Name        string            `json:"name"`
+	TagCount    int               `json:"tag_count,omitempty"`
+	Size        int64             `json:"size,omitempty"` // From the last storage size calculation
+	Owner       string            `json:"owner,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Links       []RepositoryLink  `json:"links,omitempty"`
+	HasReadme   bool              `json:"has_readme,omitempty"`
+}
+
+// RepositoryMetadata is what users write about a repository: a description, a markdown README,
+// its owner and links. The owner is kept with the repository's other metadata (RepositoryInfo).
+type RepositoryMetadata struct {
+	RegistryID  int64            `json:"registry_id"`
+	Repository  string           `json:"repository"`
+	Description string           `json:"description"`
+	Readme      string           `json:"readme"` // Markdown
+	Owner       string           `json:"owner"`
+	Links       []RepositoryLink `json:"links"`
+	UpdatedBy   string           `json:"updated_by"`
+	UpdatedAt   *time.Time       `json:"updated_at,omitempty"` // Nil until first saved
+}
+
+// RepositoryLink is a named link of a repository (source code, documentation, issue tracker...)
+type RepositoryLink struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// RepositoryInfo is the dashboard's metadata about a repository, tracked by catalog sync
+type RepositoryInfo struct {
+	RegistryID          int64             `json:"registry_id"`
+	Name                string            `json:"name"`
+	Owner               string            `json:"owner"`
+	Labels              map[string]string `json:"labels"`
+	ScanMode            string            `json:"scan_mode"`             // "" (follow scan policy), "always", "never"
+	RetentionTemplateID int64             `json:"retention_template_id"` // 0 = registry retention policy
+	OnboardingRuleID    int64             `json:"onboarding_rule_id"`    // Rule applied when first discovered
+	FirstSeenAt         time.Time         `json:"first_seen_at"`
+	LastSeenAt          time.Time         `json:"last_seen_at"`
+}
+
+// RetentionTemplate is a reusable set of retention limits that can be assigned to repositories
+type RetentionTemplate struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	KeepLastCount int    `json:"keep_last_count"`
+	KeepDays      int    `json:"keep_days"`
+	ExcludeTags   string `json:"exclude_tags"`
+}
+
+// OnboardingRule sets up newly discovered repositories whose name matches Pattern
+type OnboardingRule struct {
+	ID                  int64             `json:"id"`
+	Name                string            `json:"name"`
+	RegistryID          int64             `json:"registry_id"` // 0 = all registries
+	Pattern             string            `json:"pattern"`     // Regex on the repository name (e.g. "^team-x/")
+	Enabled             bool              `json:"enabled"`
+	Owner               string            `json:"owner"`
+	Labels              map[string]string `json:"labels"`
+	ScanMode            string            `json:"scan_mode"`
+	RetentionTemplateID int64             `json:"retention_template_id"`
+	CreatedAt           time.Time         `json:"created_at"`
+}
+
+// Application groups the repositories that hold the same logical app in different registries
+// (e.g. staging-reg/app and prod-reg/app), one per environment
+type Application struct {
+	ID          int64               `json:"id"`
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Members     []ApplicationMember `json:"members"`
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+// ApplicationMember places an application's repository in an environment
+type ApplicationMember struct {
+	Environment string `json:"environment"` // e.g. "staging", "prod"
+	RegistryID  int64  `json:"registry_id"`
+	Repository  string `json:"repository"`
+}
+
+// Project groups registries and repositories for a product team. While any project exists,
+// users who are not admins only see what the projects of their teams contain.
+type Project struct {
+	ID           int64               `json:"id"`
+	Name         string              `json:"name"`
+	Description  string              `json:"description"`
+	Registries   []int64             `json:"registries"`     // Registries the project holds as a whole
+	Repositories []ProjectRepository `json:"repositories"`   // Single repositories of other registries
+	Role         string              `json:"role,omitempty"` // The caller's role, in the list of a user's projects
+	CreatedAt    time.Time           `json:"created_at"`
+}
+
+// ProjectRepository assigns repositories of a registry to a project
+type ProjectRepository struct {
+	RegistryID int64  `json:"registry_id"`
+	Repository string `json:"repository"` // Repository name, or a prefix ending in "*"
+}
+
+// Team is a group of users with a role in each of its projects
+type Team struct {
+	ID        int64         `json:"id"`
+	Name      string        `json:"name"`
+	Members   []int64       `json:"members"` // User IDs
+	Projects  []TeamProject `json:"projects"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// TeamProject is the role of a team's members in a project: viewer browses it, maintainer may
+// also change it (delete and retag images, run retention, edit policies)
+type TeamProject struct {
+	ProjectID int64  `json:"project_id"`
+	Role      string `json:"role"`
+}
+
+// AccessRule limits an action of a team's members to matching repositories. Once a team of a
+// user has a rule for an action, the user may only take it where one of those rules matches.
+type AccessRule struct {
+	ID         int64     `json:"id"`
+	TeamID     int64     `json:"team_id"`
+	RegistryID int64     `json:"registry_id"` // 0 = all registries
+	Repository string    `json:"repository"`  // Repository name, a prefix ending in "*", or "*" for all
+	Actions    string    `json:"actions"`     // Comma-separated: push, delete
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// MatchRepository reports whether a repository matches the repository of a permission, rule or
+// quota: the exact name, a prefix ending in "*", or "*" for all
+func MatchRepository(pattern, name string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(name, prefix)
+	}
+	return pattern == name
+}
+
+// ImmutableTagRule makes the tags of a registry matching its patterns immutable: the dashboard
+// refuses to delete them, retention keeps them, and the embedded registry rejects pushes that
+// would point them at another image
+type ImmutableTagRule struct {
+	ID         int64     `json:"id"`
+	RegistryID int64     `json:"registry_id"`
+	Repository string    `json:"repository"`  // Regex of repository names; empty matches all
+	TagPattern string    `json:"tag_pattern"` // Regex of tag names, e.g. ^v\d+\.\d+\.\d+$
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Matches reports whether the rule makes a tag of a repository immutable
+func (r *ImmutableTagRule) Matches(repo, tag string) bool {
+	if r.Repository != "" {
+		if ok, err := regexp.MatchString(r.Repository, repo); err != nil || !ok {
+			return false
+		}
+	}
+	ok, err := regexp.MatchString(r.TagPattern, tag)
+	return err == nil && ok
+}
+
+// ImmutableTags reports whether one of the rules makes a tag immutable, nil when there are none
+func ImmutableTags(rules []ImmutableTagRule) func(repo, tag string) bool {
+	if len(rules) == 0 {
+		return nil
+	}
+	return func(repo, tag string) bool {
+		for i := range rules {
+			if rules[i].Matches(repo, tag) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// RepositoryQuota caps the storage of the repositories of a registry matching Repository, or of
+// the registries and repositories of a project. Usage is measured from the manifests of the
+// covered tags, counting layers they share once per registry.
+type RepositoryQuota struct {
+	ID              int64      `json:"id"`
+	RegistryID      int64      `json:"registry_id,omitempty"`
+	Repository      string     `json:"repository,omitempty"` // Repository name, a prefix ending in "*", or "*" for all
+	ProjectID       int64      `json:"project_id,omitempty"` // Set instead of RegistryID and Repository
+	LimitBytes      int64      `json:"limit_bytes"`
+	WarnPercent     int        `json:"warn_percent"`      // Usage from which the quota warns; default 80
+	Block           bool       `json:"block"`             // Refuse copies into the repositories while exceeded
+	AlertWebhookURL string     `json:"alert_webhook_url"` // Receives quota.warning and quota.exceeded; default the alert webhook
+	UsedBytes       int64      `json:"used_bytes"`
+	State           string     `json:"state"` // ok, warning, exceeded; "" until checked
+	CheckError      string     `json:"check_error,omitempty"`
+	CheckedAt       *time.Time `json:"checked_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// ApplicationView is the consolidated state of an application across its environments
+type ApplicationView struct {
+	Application  Application           `json:"application"`
+	Environments []EnvironmentState    `json:"environments"`
+	Tags         []ApplicationTagState `json:"tags"`
+	InSync       bool                  `json:"in_sync"` // Every tag present in all environments with the same digest
+}
+
+// EnvironmentState reports whether an environment's repository could be listed
+type EnvironmentState struct {
+	ApplicationMember
+	RegistryName string `json:"registry_name"`
+	TagCount     int    `json:"tag_count"`
+	Error        string `json:"error,omitempty"`
+}
+
+// ApplicationTagState maps one tag to its digest in each environment ("" = tag absent)
+type ApplicationTagState struct {
+	Tag     string            `json:"tag"`
+	Digests map[string]string `json:"digests"`
+	Match   bool              `json:"match"` // Present everywhere with one digest
+}
+
+// SearchHit is a repository or tag matching a global search, with the registry it lives in
+type SearchHit struct {
+	RegistryID   int64     `json:"registry_id"`
+	RegistryName string    `json:"registry_name"`
+	Repository   string    `json:"repository"`
+	Tag          string    `json:"tag,omitempty"` // Empty for repository hits
+	Match        string    `json:"match"`         // repository, tag
+	IndexedAt    time.Time `json:"indexed_at"`
+}
+
+// VulnerabilityItem represents a single vulnerability finding
+type VulnerabilityItem struct {
+	ID           string    `json:"id"`
+	Package      string    `json:"package"`
+	Version      string    `json:"version"`
+	FixedVersion string    `json:"fixed_version"`
+	Severity     string    `json:"severity"`
+	Description  string    `json:"description"`
+	Scanner      string    `json:"scanner"` // "trivy" or "osv"
+	Repository   string    `json:"repository"`
+	Tag          string    `json:"tag"`
+	Digest       string    `json:"digest"`
+	RegistryID   int64     `json:"registry_id"`
+	ScannedAt    time.Time `json:"scanned_at"`
+}
+
+// FindingSearchResult is an image whose latest scan has findings matching a full-text search
+type FindingSearchResult struct {
+	RegistryID int64          `json:"registry_id"`
+	Repository string         `json:"repository"`
+	Tag        string         `json:"tag"`
+	Digest     string         `json:"digest,omitempty"`
+	ScannedAt  time.Time      `json:"scanned_at"`
+	Findings   []FindingMatch `json:"findings"`
+}
+
+// FindingMatch is one matching finding of an image
+type FindingMatch struct {
+	VulnID       string `json:"vuln_id"`
+	Package      string `json:"package"`
+	Version      string `json:"version"`
+	FixedVersion string `json:"fixed_version,omitempty"`
+	Severity     string `json:"severity"`
+	Snippet      string `json:"snippet,omitempty"` // Matching part of the description, terms in [brackets]
 }
 
 // Tag represents a Docker image tag
 type Tag struct {
-	Name   string `json:"name"`
-	Digest string `json:"digest,omitempty"`
+	Name   string          `json:"name"`
+	Digest string          `json:"digest,omitempty"`
+	Harbor *HarborArtifact `json:"harbor,omitempty"` // Artifact metadata, on Harbor registries
+	TagUsage
+}
+
+// TagUsage counts the pulls and pushes of a tag seen in registry events
+type TagUsage struct {
+	PullCount    int64      `json:"pull_count"`
+	PushCount    int64      `json:"push_count"`
+	LastPulledAt *time.Time `json:"last_pulled_at,omitempty"`
+	LastPushedAt *time.Time `json:"last_pushed_at,omitempty"`
 }
 
 // ImageManifest represents manifest details
@@ -111,6 +710,15 @@ type ImageManifest struct {
 	Layers        []ManifestLayer `json:"layers,omitempty"`
 	Config        *ManifestConfig `json:"config,omitempty"`
 	Platform      *Platform       `json:"platform,omitempty"`
+	Manifests     []ManifestEntry `json:"manifests,omitempty"` // Set for manifest lists / OCI indexes
+}
+
+// ManifestEntry is a per-platform child of a manifest list or OCI index
+type ManifestEntry struct {
+	MediaType string    `json:"mediaType"`
+	Size      int64     `json:"size"`
+	Digest    string    `json:"digest"`
+	Platform  *Platform `json:"platform,omitempty"`
 }
 
 // ManifestLayer represents a layer in the manifest
@@ -131,6 +739,219 @@ type ManifestConfig struct {
 type Platform struct {
 	Architecture string `json:"architecture,omitempty"`
 	OS           string `json:"os,omitempty"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// String formats the platform as os/arch[/variant]
+func (p *Platform) String() string {
+	if p == nil {
+		return ""
+	}
+	s := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
+
+// ImageConfig is the runtime configuration and build history stored in an image's config blob
+type ImageConfig struct {
+	Digest       string            `json:"digest"`
+	Created      time.Time         `json:"created"`
+	Author       string            `json:"author,omitempty"`
+	Architecture string            `json:"architecture,omitempty"`
+	OS           string            `json:"os,omitempty"`
+	User         string            `json:"user,omitempty"`
+	ExposedPorts []string          `json:"exposed_ports"`
+	Env          []string          `json:"env"`
+	Entrypoint   []string          `json:"entrypoint"`
+	Cmd          []string          `json:"cmd"`
+	WorkingDir   string            `json:"working_dir,omitempty"`
+	Volumes      []string          `json:"volumes"`
+	StopSignal   string            `json:"stop_signal,omitempty"`
+	Labels       map[string]string `json:"labels"`
+	History      []ImageHistory    `json:"history"`
+}
+
+// ImageHistory is one build step of an image
+type ImageHistory struct {
+	Created    time.Time `json:"created"`
+	CreatedBy  string    `json:"created_by"`
+	Comment    string    `json:"comment,omitempty"`
+	EmptyLayer bool      `json:"empty_layer"`
+}
+
+// LayerFile is one entry of a layer tarball
+type LayerFile struct {
+	Path       string    `json:"path"`
+	Type       string    `json:"type"` // file, dir, symlink, hardlink, other
+	Size       int64     `json:"size"`
+	Mode       string    `json:"mode"`
+	LinkTarget string    `json:"link_target,omitempty"`
+	Whiteout   bool      `json:"whiteout,omitempty"` // Marks a file deleted from lower layers
+	ModTime    time.Time `json:"mod_time"`
+}
+
+// LayerListing is the (possibly truncated) file index of a layer
+type LayerListing struct {
+	Digest    string      `json:"digest"`
+	Files     []LayerFile `json:"files"`
+	Truncated bool        `json:"truncated"`
+}
+
+// SeedImage is a public image mirrored into a local registry on a schedule
+type SeedImage struct {
+	ID               int64     `json:"id"`
+	Image            string    `json:"image"` // Source reference, e.g. alpine:3.20 or ghcr.io/org/base:1
+	TargetRegistryID int64     `json:"target_registry_id"`
+	TargetRepository string    `json:"target_repository"` // Defaults to the source repository
+	IntervalHours    int       `json:"interval_hours"`
+	Enabled          bool      `json:"enabled"`
+	LastDigest       string    `json:"last_digest,omitempty"` // Digest last mirrored
+	LastRunAt        time.Time `json:"last_run_at,omitempty"`
+	LastError        string    `json:"last_error,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// ReplicationRule keeps repositories of a target registry in sync with a source registry: the
+// matching tags are copied whenever their source digest differs from the target's
+type ReplicationRule struct {
+	ID               int64     `json:"id"`
+	Name             string    `json:"name"`
+	SourceRegistryID int64     `json:"source_registry_id"`
+	TargetRegistryID int64     `json:"target_registry_id"`
+	FilterRepos      string    `json:"filter_repos"`      // Regex to select repositories (empty=all)
+	FilterTags       string    `json:"filter_tags"`       // Regex to select tags (empty=all)
+	TargetPrefix     string    `json:"target_prefix"`     // Prepended to repository names in the target, e.g. "mirror/"
+	Conflict         string    `json:"conflict"`          // overwrite, skip: a target tag pointing at another digest
+	PropagateDeletes bool      `json:"propagate_deletes"` // Delete target images whose matching tags left the source
+	IntervalMinutes  int       `json:"interval_minutes"`  // 0 replicates on demand only
+	Enabled          bool      `json:"enabled"`
+	LastRunAt        time.Time `json:"last_run_at,omitempty"`
+	LastStatus       string    `json:"last_status,omitempty"` // completed, partial, failed
+	LastError        string    `json:"last_error,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// ReplicationRun is the progress of one run of a replication rule
+type ReplicationRun struct {
+	ID           int64     `json:"id,omitempty"` // Set once the finished run is in the history
+	RuleID       int64     `json:"rule_id"`
+	Trigger      string    `json:"trigger"` // schedule, manual
+	Status       string    `json:"status"`  // running, completed, partial, failed
+	Repositories int       `json:"repositories"`
+	Tags         int       `json:"tags"`       // Matching tags found in the source
+	Copied       int       `json:"copied"`     // Tags copied to the target
+	UpToDate     int       `json:"up_to_date"` // Tags already at the source digest
+	Conflicts    int       `json:"conflicts"`  // Target tags at another digest, left alone
+	Failed       int       `json:"failed"`
+	Deleted      int       `json:"deleted"` // Target images deleted as their tags left the source
+	CopiedBlobs  int       `json:"copied_blobs"`
+	SkippedBlobs int       `json:"skipped_blobs"` // Already in the target, so not transferred
+	CopiedBytes  int64     `json:"copied_bytes"`
+	Current      string    `json:"current,omitempty"` // repo:tag being copied
+	Errors       []string  `json:"errors,omitempty"`  // The first failures
+	StartedAt    time.Time `json:"started_at"`
+	FinishedAt   time.Time `json:"finished_at,omitempty"`
+}
+
+// CopyJob tracks copying an image (all platforms of a multi-arch tag) between registries
+type CopyJob struct {
+	ID               string    `json:"id"`
+	SourceRegistryID int64     `json:"source_registry_id"`
+	SourceRepository string    `json:"source_repository"`
+	SourceTag        string    `json:"source_tag"`
+	TargetRegistryID int64     `json:"target_registry_id"`
+	TargetRepository string    `json:"target_repository"`
+	TargetTag        string    `json:"target_tag"`
+	Status           string    `json:"status"` // running, completed, failed
+	Error            string    `json:"error,omitempty"`
+	Digest           string    `json:"digest,omitempty"` // Digest of the copied top-level manifest
+	Manifests        int       `json:"manifests"`
+	TotalBlobs       int       `json:"total_blobs"`
+	CopiedBlobs      int       `json:"copied_blobs"`
+	SkippedBlobs     int       `json:"skipped_blobs"` // Already present or mounted in the target
+	TotalBytes       int64     `json:"total_bytes"`
+	CopiedBytes      int64     `json:"copied_bytes"`
+	StartedAt        time.Time `json:"started_at"`
+	FinishedAt       time.Time `json:"finished_at,omitempty"`
+}
+
+// RenameJob tracks moving a repository to a new name within a registry: every tag is copied,
+// the copies are verified against the source digests, then the source is deleted
+type RenameJob struct {
+	ID               string      `json:"id"`
+	RegistryID       int64       `json:"registry_id"`
+	Repository       string      `json:"repository"`
+	NewRepository    string      `json:"new_repository"`
+	KeepSource       bool        `json:"keep_source"`
+	Status           string      `json:"status"` // running, completed, failed
+	Phase            string      `json:"phase"`  // copying, verifying, deleting, done
+	Error            string      `json:"error,omitempty"`
+	Tags             []RenameTag `json:"tags"`
+	CopiedTags       int         `json:"copied_tags"`
+	VerifiedTags     int         `json:"verified_tags"`
+	DeletedManifests int         `json:"deleted_manifests"`
+	StartedAt        time.Time   `json:"started_at"`
+	FinishedAt       time.Time   `json:"finished_at,omitempty"`
+}
+
+// RenameTag is the progress of one tag of a repository rename
+type RenameTag struct {
+	Tag    string `json:"tag"`
+	Digest string `json:"digest,omitempty"` // Digest of the source manifest
+	Status string `json:"status"`           // pending, copied, verified, deleted, failed
+	Error  string `json:"error,omitempty"`
+}
+
+// ExportJob tracks writing tags of a registry's repositories to an OCI image layout archive
+type ExportJob struct {
+	ID             string    `json:"id"`
+	RegistryID     int64     `json:"registry_id"`
+	Repositories   []string  `json:"repositories"`
+	Destination    string    `json:"destination"`        // download or s3
+	Location       string    `json:"location,omitempty"` // s3:// URL of the archive
+	Status         string    `json:"status"`             // running, completed, failed
+	Error          string    `json:"error,omitempty"`
+	Images         int       `json:"images"` // Tags selected for the archive
+	ExportedImages int       `json:"exported_images"`
+	Manifests      int       `json:"manifests"`
+	Blobs          int       `json:"blobs"`
+	Bytes          int64     `json:"bytes"` // Manifest and blob content written so far
+	StartedAt      time.Time `json:"started_at"`
+	FinishedAt     time.Time `json:"finished_at,omitempty"`
+}
+
+// ImageImport is the outcome of importing an image tarball (docker save or OCI image layout)
+type ImageImport struct {
+	Images       []ImportedImage `json:"images"`
+	Blobs        int             `json:"blobs"`         // Uploaded
+	SkippedBlobs int             `json:"skipped_blobs"` // Already in the repository
+	Bytes        int64           `json:"bytes"`         // Blob content uploaded
+}
+
+// ImportedImage is an image pushed from an image tarball
+type ImportedImage struct {
+	Repository string `json:"repository"`
+	Tag        string `json:"tag"`
+	Digest     string `json:"digest"`
+}
+
+// HealthReport is the result of a liveness or readiness probe
+type HealthReport struct {
+	Status        string            `json:"status"` // ok, degraded (a non-critical component failed) or unavailable
+	UptimeSeconds int64             `json:"uptime_seconds"`
+	Components    []ComponentHealth `json:"components,omitempty"`
+}
+
+// ComponentHealth is the state of one component checked by the readiness probe
+type ComponentHealth struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`   // ok or fail
+	Critical   bool   `json:"critical"` // A failing critical component makes the dashboard unready
+	Detail     string `json:"detail,omitempty"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
 }
 
 // DashboardStats for the overview page
@@ -141,21 +962,526 @@ type DashboardStats struct {
 	StorageType      string                 `json:"storage_type"`
 	Registries       []RegistryStats        `json:"registries"`
 	EmbeddedRegistry map[string]interface{} `json:"embedded_registry,omitempty"`
+	Security         SecurityPosture        `json:"security"`
+	RefreshedAt      time.Time              `json:"refreshed_at"`
+	Refreshing       bool                   `json:"refreshing"`
+}
+
+// SecurityPosture summarizes scan coverage and open findings across all registries
+type SecurityPosture struct {
+	TotalCritical       int     `json:"total_critical"`
+	TotalHigh           int     `json:"total_high"`
+	ScannedImages       int     `json:"scanned_images"`
+	UnscannedImages     int     `json:"unscanned_images"`
+	ScannedLast7DaysPct float64 `json:"scanned_last_7_days_pct"`
 }
 
 // RegistryStats per-registry statistics
 type RegistryStats struct {
-	ID         int64  `json:"id"`
-	Name       string `json:"name"`
-	URL        string `json:"url"`
-	ImageCount int    `json:"image_count"`
-	Status     string `json:"status"` // online, offline, error
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	ImageCount  int    `json:"image_count"`
+	Status      string `json:"status"`                // online, offline, error
+	Circuit     string `json:"circuit"`               // closed, open, half-open
+	Certificate string `json:"certificate,omitempty"` // Status of the latest certificate check
+
+	PullRateLimit *PullRateLimit `json:"pull_rate_limit,omitempty"` // Docker Hub only
+}
+
+// RegistrySize is the storage consumed by a registry, with shared layers counted once
+type RegistrySize struct {
+	RegistryID   int64            `json:"registry_id"`
+	TotalSize    int64            `json:"total_size"`
+	BlobCount    int              `json:"blob_count"`
+	Repositories []RepositorySize `json:"repositories"`
+	CalculatedAt time.Time        `json:"calculated_at"`
+	Cached       bool             `json:"cached"` // Served from the database instead of walking the registry
+}
+
+// RepositorySize is the storage referenced by the tags of one repository
+type RepositorySize struct {
+	Name      string `json:"name"`
+	Size      int64  `json:"size"` // Unique blobs of the repository; layers shared with other repositories are included
+	BlobCount int    `json:"blob_count"`
+	TagCount  int    `json:"tag_count"`
+}
+
+// RegistryStorageUsage is what the embedded registry's storage backend holds, read from its layout
+type RegistryStorageUsage struct {
+	StorageType  string                   `json:"storage_type"`
+	TotalSize    int64                    `json:"total_size"` // All blobs, including ones no tag references until garbage collection
+	BlobCount    int                      `json:"blob_count"`
+	UploadCount  int                      `json:"upload_count"` // Uploads in progress, or abandoned ones until upload purging removes them
+	UploadSize   int64                    `json:"upload_size"`
+	Repositories []RepositoryStorageUsage `json:"repositories"`
+	CalculatedAt time.Time                `json:"calculated_at"`
+}
+
+// RepositoryStorageUsage is the storage linked into one repository of the embedded registry
+type RepositoryStorageUsage struct {
+	Name        string `json:"name"`
+	Size        int64  `json:"size"` // Layers and manifests linked to the repository; shared blobs count in each
+	BlobCount   int    `json:"blob_count"`
+	UploadCount int    `json:"upload_count"`
+	UploadSize  int64  `json:"upload_size"`
+}
+
+// DefectDojoMapping sends the scan findings of a registry's repositories to a DefectDojo engagement
+type DefectDojoMapping struct {
+	ID            int64     `json:"id"`
+	RegistryID    int64     `json:"registry_id"`
+	RepoPattern   string    `json:"repo_pattern"` // Regex; empty matches every repository
+	EngagementID  int64     `json:"engagement_id"`
+	Trigger       string    `json:"trigger"`        // "scan" (on scan completion) or "schedule"
+	IntervalHours int       `json:"interval_hours"` // Export interval for trigger "schedule"
+	Enabled       bool      `json:"enabled"`
+	LastExportAt  time.Time `json:"last_export_at,omitempty"`
+	LastError     string    `json:"last_error,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Certificate check statuses, from most to least severe
+const (
+	CertUnreachable = "unreachable" // The registry could not be connected to
+	CertChanged     = "changed"     // The certificate chain differs from the accepted one
+	CertExpiring    = "expiring"    // A certificate of the chain expires soon or has expired
+	CertInvalid     = "invalid"     // The chain does not verify against the system roots
+	CertOK          = "ok"
+	CertPlainHTTP   = "plain_http" // The registry is reached without TLS
+)
+
+// CertificateCheck is the latest scheduled connectivity and TLS certificate check of a registry
+type CertificateCheck struct {
+	RegistryID          int64             `json:"registry_id"`
+	Status              string            `json:"status"`
+	Reachable           bool              `json:"reachable"`
+	Error               string            `json:"error,omitempty"`
+	VerifyError         string            `json:"verify_error,omitempty"`
+	Chain               []CertificateInfo `json:"chain"` // Leaf first
+	Fingerprint         string            `json:"fingerprint,omitempty"`
+	ExpiresAt           *time.Time        `json:"expires_at,omitempty"` // Earliest expiry in the chain
+	DaysLeft            int               `json:"days_left"`
+	ChainChanged        bool              `json:"chain_changed"` // Set until the new chain is accepted
+	PreviousFingerprint string            `json:"previous_fingerprint,omitempty"`
+	CheckedAt           time.Time         `json:"checked_at"`
+}
+
+// CertificateInfo describes one certificate of a registry's TLS chain
+type CertificateInfo struct {
+	Subject     string    `json:"subject"`
+	Issuer      string    `json:"issuer"`
+	DNSNames    []string  `json:"dns_names,omitempty"`
+	NotBefore   time.Time `json:"not_before"`
+	NotAfter    time.Time `json:"not_after"`
+	Fingerprint string    `json:"fingerprint"` // SHA-256 of the DER certificate
+}
+
+// CircuitStatus is the circuit breaker state of a registry
+type CircuitStatus struct {
+	State               string    `json:"state"` // closed, open, half-open
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastError           string    `json:"last_error,omitempty"`
+	OpenedAt            time.Time `json:"opened_at,omitempty"`
+	RetryAt             time.Time `json:"retry_at,omitempty"`
+}
+
+// PullRateLimit is the Docker Hub pull rate limit last reported to a registry's credentials
+type PullRateLimit struct {
+	Limit         int       `json:"limit"`
+	Remaining     int       `json:"remaining"`
+	WindowSeconds int       `json:"window_seconds"`   // Period the limit applies to, e.g. 21600 (6h)
+	Source        string    `json:"source,omitempty"` // IP address or account the limit is counted for
+	Unlimited     bool      `json:"unlimited"`        // Docker Hub reports no limit for the credentials
+	Reserve       int       `json:"reserve"`          // Pulls the dashboard leaves unused
+	Throttled     bool      `json:"throttled"`        // Dashboard pulls are held back: Remaining <= Reserve
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// User is a local dashboard account
+type User struct {
+	ID                int64     `json:"id"`
+	Username          string    `json:"username"`
+	Role              string    `json:"role"` // admin or user
+	PasswordHash      string    `json:"-"`
+	PasswordChangedAt time.Time `json:"password_changed_at"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// APIToken is a long-lived credential for scripts and CI; the secret is only shown when issued
+type APIToken struct {
+	ID         int64     `json:"id"`
+	UserID     int64     `json:"user_id"`
+	Name       string    `json:"name"`
+	Prefix     string    `json:"prefix"` // First characters of the token, to recognize it
+	Token      string    `json:"token,omitempty"`
+	ExpiresAt  time.Time `json:"expires_at,omitempty"`
+	LastUsedAt time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AuditEntry records a security-relevant action
+type AuditEntry struct {
+	ID         int64     `json:"id"`
+	Actor      string    `json:"actor"`  // Username of the caller ("" for anonymous)
+	Action     string    `json:"action"` // e.g. password.change, token.rotate
+	Target     string    `json:"target"`
+	Detail     string    `json:"detail,omitempty"`
+	RemoteAddr string    `json:"remote_addr"`
+	CreatedAt  time.Time `json:"created_at"`
 }
 
 // APIResponse standard API response wrapper
 type APIResponse struct {
-	Success bool        `json:"success"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
-	Message string      `json:"message,omitempty"`
+	Success    bool        `json:"success"`
+	Data       interface{} `json:"data,omitempty"`
+	Error      string      `json:"error,omitempty"`
+	Message    string      `json:"message,omitempty"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// Pagination describes the page of a listing returned in Data
+type Pagination struct {
+	Page       int `json:"page"`
+	PageSize   int `json:"page_size"`
+	Total      int `json:"total"` // Items matching the filter across all pages
+	TotalPages int `json:"total_pages"`
+}
+
+// Capabilities describes what this dashboard instance supports, so clients can adapt to it
+type Capabilities struct {
+	APIVersions []int            `json:"api_versions"` // Served under /api/vN/
+	Auth        AuthCapabilities `json:"auth"`
+	Features    map[string]bool  `json:"features"`
+}
+
+// AuthCapabilities lists the active authentication mechanisms
+type AuthCapabilities struct {
+	Required   bool     `json:"required"`
+	Mechanisms []string `json:"mechanisms"` // In the order they are tried
+	Login      []string `json:"login"`      // Mechanisms that accept a username and password at login
+}
+
+// ScanReport summarizes the latest completed scans of an image, a repository or a registry for auditors
+type ScanReport struct {
+	RegistryID         int64                 `json:"registry_id"`
+	RegistryName       string                `json:"registry_name"`
+	Repository         string                `json:"repository,omitempty"` // "" for the whole registry
+	Tag                string                `json:"tag,omitempty"`        // "" for every tag of the repository
+	GeneratedAt        time.Time             `json:"generated_at"`
+	Severities         map[string]int        `json:"severities"` // Findings by upper-cased severity
+	Total              int                   `json:"total"`
+	Fixable            int                   `json:"fixable"` // Findings with a fixed version
+	Images             []ReportImage         `json:"images"`
+	TopVulnerabilities []ReportVulnerability `json:"top_vulnerabilities"`
+	FixableItems       []ReportFix           `json:"fixable_items"`
+}
+
+// ReportImage is a scanned image in a scan report
+type ReportImage struct {
+	Repository string         `json:"repository"`
+	Tag        string         `json:"tag"`
+	Digest     string         `json:"digest"`
+	ScannedAt  time.Time      `json:"scanned_at"`
+	Severities map[string]int `json:"severities"`
+	Total      int            `json:"total"`
+	Fixable    int            `json:"fixable"`
+}
+
+// ReportVulnerability is a vulnerability in a scan report with the number of images it affects
+type ReportVulnerability struct {
+	ID           string `json:"id"`
+	Severity     string `json:"severity"`
+	Packages     string `json:"packages"` // Comma-separated affected packages
+	FixedVersion string `json:"fixed_version"`
+	Description  string `json:"description"`
+	Images       int    `json:"images"`
+}
+
+// ReportFix is a package upgrade that fixes vulnerabilities in a scan report
+type ReportFix struct {
+	Package         string `json:"package"`
+	Version         string `json:"version"`
+	FixedVersion    string `json:"fixed_version"`
+	Severity        string `json:"severity"` // Highest severity fixed
+	Vulnerabilities int    `json:"vulnerabilities"`
+	Images          int    `json:"images"`
+}
+
+// RegistryProfile records which parts of the OCI distribution spec a registry implements, as found
+// by a conformance probe; clients of the registry use it to pick working code paths
+type RegistryProfile struct {
+	RegistryID        int64              `json:"registry_id"`
+	APIVersion        string             `json:"api_version"`        // Docker-Distribution-API-Version header of /v2/
+	Catalog           bool               `json:"catalog"`            // GET /v2/_catalog is served
+	CatalogPagination bool               `json:"catalog_pagination"` // The catalog honors n and links the next page
+	TagPagination     bool               `json:"tag_pagination"`     // Tag lists honor n and link the next page
+	HeadDigest        bool               `json:"head_digest"`        // HEAD on a manifest returns Docker-Content-Digest
+	DigestMatches     bool               `json:"digest_matches"`     // The returned digest is the SHA-256 of the manifest
+	DeleteEnabled     bool               `json:"delete_enabled"`     // Manifests can be deleted by digest
+	TagDelete         bool               `json:"tag_delete"`         // Tags can be deleted by name
+	Referrers         bool               `json:"referrers"`          // The OCI referrers API is served
+	Harbor            *HarborInfo        `json:"harbor,omitempty"`   // Set when the registry is Harbor
+	Checks            []ConformanceCheck `json:"checks"`
+	ProbedAt          time.Time          `json:"probed_at"`
+}
+
+// HarborInfo is the system information of a Harbor registry
+type HarborInfo struct {
+	Version     string `json:"version,omitempty"` // Only given to signed-in users
+	AuthMode    string `json:"auth_mode,omitempty"`
+	ExternalURL string `json:"external_url,omitempty"`
+}
+
+// HarborProject is a Harbor project with its storage quota
+type HarborProject struct {
+	ID        int64        `json:"id"`
+	Name      string       `json:"name"`
+	Public    bool         `json:"public"`
+	RepoCount int          `json:"repo_count"`
+	CreatedAt time.Time    `json:"created_at"`
+	Quota     *HarborQuota `json:"quota,omitempty"` // nil when the credentials may not read it
+}
+
+// HarborQuota is the storage quota of a Harbor project
+type HarborQuota struct {
+	HardBytes int64 `json:"hard_bytes"` // -1: unlimited
+	UsedBytes int64 `json:"used_bytes"`
+}
+
+// HarborLabel is a label Harbor attaches to artifacts
+type HarborLabel struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Color       string `json:"color,omitempty"`
+	Description string `json:"description,omitempty"`
+	Scope       string `json:"scope"` // g: global, p: project
+}
+
+// HarborArtifact is what Harbor's artifact API knows about an image beyond the V2 API
+type HarborArtifact struct {
+	Digest    string        `json:"digest"`
+	Type      string        `json:"type,omitempty"` // IMAGE, CHART, CNAB...
+	MediaType string        `json:"media_type,omitempty"`
+	Size      int64         `json:"size"`
+	PushedAt  *time.Time    `json:"pushed_at,omitempty"`
+	PulledAt  *time.Time    `json:"pulled_at,omitempty"`
+	Tags      []string      `json:"tags"`
+	Labels    []HarborLabel `json:"labels"`
+}
+
+// ConformanceCheck is the outcome of one check of a conformance probe
+type ConformanceCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // pass, fail or skip
+	Detail string `json:"detail,omitempty"`
+}
+
+// ScanStorageUsage is the space taken by stored full scan reports, against the soft quota
+type ScanStorageUsage struct {
+	QuotaBytes     int64      `json:"quota_bytes"` // 0: no quota
+	UsedBytes      int64      `json:"used_bytes"`
+	Reports        int        `json:"reports"`
+	EvictedReports int        `json:"evicted_reports"`
+	LastEvictionAt *time.Time `json:"last_eviction_at,omitempty"`
+
+	Retention ScanRetention    `json:"retention"`
+	LastPrune *ScanPruneResult `json:"last_prune,omitempty"`
+}
+
+// ScanRetention is how long the scan history is kept; zero values keep everything
+type ScanRetention struct {
+	KeepScans        int `json:"keep_scans"`          // Newest history scans kept per image
+	ReportMaxAgeDays int `json:"report_max_age_days"` // Older history reports are removed, their summaries kept
+}
+
+// ScanPruneResult is what a scan history pruning run removed
+type ScanPruneResult struct {
+	DeletedScans   int       `json:"deleted_scans"`
+	EvictedReports int       `json:"evicted_reports"`
+	FreedBytes     int64     `json:"freed_bytes"`
+	PrunedAt       time.Time `json:"pruned_at"`
+}
+
+// ContentTrust summarizes how many images of a registry are scanned, signed and pass the gate
+type ContentTrust struct {
+	RegistryID   int64     `json:"registry_id"`
+	RegistryName string    `json:"registry_name"`
+	Gate         TrustGate `json:"gate"`
+	TrustCounts
+	Namespaces  []NamespaceTrust `json:"namespaces"`
+	Unchecked   int              `json:"unchecked"` // Images whose digest or signature could not be looked up
+	GeneratedAt time.Time        `json:"generated_at"`
+}
+
+// TrustGate is the limits an image must meet to pass, as with the gate command
+type TrustGate struct {
+	MaxCritical      int  `json:"max_critical"` // -1: no limit
+	MaxHigh          int  `json:"max_high"`     // -1: no limit
+	RequireSignature bool `json:"require_signature"`
+}
+
+// TrustCounts counts the images (tags) of a registry or namespace and their coverage in percent
+type TrustCounts struct {
+	Images         int     `json:"images"`
+	Scanned        int     `json:"scanned"` // Completed scan of the current digest
+	Signed         int     `json:"signed"`
+	PassingGate    int     `json:"passing_gate"`
+	ScannedPct     float64 `json:"scanned_pct"`
+	SignedPct      float64 `json:"signed_pct"`
+	PassingGatePct float64 `json:"passing_gate_pct"`
+	Score          float64 `json:"score"` // Mean of the three percentages
+}
+
+// NamespaceTrust is the content trust of the repositories under one namespace ("" for repositories without one)
+type NamespaceTrust struct {
+	Namespace string `json:"namespace"`
+	TrustCounts
+}
+
+// NotationTrustPolicyDocument is a Notation trust policy (trustpolicy.json, version 1.0); the JSON
+// names are Notation's so existing documents can be used as they are
+type NotationTrustPolicyDocument struct {
+	Version       string                `json:"version"`
+	TrustPolicies []NotationTrustPolicy `json:"trustPolicies"`
+}
+
+// NotationTrustPolicy says how the signatures of the repositories in its scopes are verified
+type NotationTrustPolicy struct {
+	Name                  string                        `json:"name"`
+	RegistryScopes        []string                      `json:"registryScopes"` // host/repository, or "*" for any
+	SignatureVerification NotationSignatureVerification `json:"signatureVerification"`
+	TrustStores           []string                      `json:"trustStores,omitempty"`       // type:name, e.g. "ca:acme"
+	TrustedIdentities     []string                      `json:"trustedIdentities,omitempty"` // "x509.subject: <DN>" or "*"
+}
+
+// NotationSignatureVerification is the verification level of a trust policy and its overrides
+type NotationSignatureVerification struct {
+	Level    string            `json:"level"`              // strict, permissive, audit or skip
+	Override map[string]string `json:"override,omitempty"` // Validation -> enforce, log or skip
+}
+
+// NotationTrustStore is a named set of trusted certificates, like a Notation trust store directory
+type NotationTrustStore struct {
+	ID           int64     `json:"id"`
+	Type         string    `json:"type"` // ca, signingAuthority or tsa
+	Name         string    `json:"name"`
+	Certificates string    `json:"certificates"`       // PEM
+	Subjects     []string  `json:"subjects,omitempty"` // Of the certificates, when listed
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Notation verification statuses of an image
+const (
+	NotationVerified = "verified" // A signature passed every enforced validation
+	NotationFailed   = "failed"   // Signed, but no signature passed
+	NotationUnsigned = "unsigned" // No Notation signature
+	NotationSkipped  = "skipped"  // The trust policy skips verification
+	NotationNoPolicy = "no_policy"
+	NotationError    = "error" // The signatures could not be looked up
+)
+
+// NotationVerification is the outcome of verifying the Notation signatures of a tag
+type NotationVerification struct {
+	Tag        string              `json:"tag"`
+	Digest     string              `json:"digest,omitempty"`
+	Status     string              `json:"status"`
+	Policy     string              `json:"policy,omitempty"`
+	Level      string              `json:"level,omitempty"`
+	Signatures []NotationSignature `json:"signatures"`
+	Error      string              `json:"error,omitempty"`
+}
+
+// NotationSignature is one verified signature: Failures are enforced validations that failed,
+// Warnings the failures the verification level only logs
+type NotationSignature struct {
+	Digest        string     `json:"digest"` // Of the signature manifest
+	EnvelopeType  string     `json:"envelope_type"`
+	SigningScheme string     `json:"signing_scheme,omitempty"`
+	Signer        string     `json:"signer,omitempty"` // Subject of the signing certificate
+	SigningTime   *time.Time `json:"signing_time,omitempty"`
+	Expiry        *time.Time `json:"expiry,omitempty"`
+	Verified      bool       `json:"verified"`
+	Failures      []string   `json:"failures,omitempty"`
+	Warnings      []string   `json:"warnings,omitempty"`
+}
+
+// Attestation verification statuses
+const (
+	AttestationVerified = "verified" // Signed by a trusted key or certificate
+	AttestationSigned   = "signed"   // Signed, but not by a trusted key or by a certificate chaining to a trusted CA
+	AttestationUnsigned = "unsigned" // Intact, but nothing signs it (e.g. BuildKit provenance)
+	AttestationInvalid  = "invalid"  // Tampered with, for another image, or a signature that does not match
+)
+
+// ImageAttestations are the in-toto attestations found for a tag
+type ImageAttestations struct {
+	Repository   string        `json:"repository"`
+	Tag          string        `json:"tag"`
+	Digest       string        `json:"digest"`
+	Attestations []Attestation `json:"attestations"`
+}
+
+// Attestation is one in-toto statement attached to an image, with its verification outcome
+type Attestation struct {
+	Source        string      `json:"source"`             // cosign (.att tag), referrer or buildkit (attestation manifest of an index)
+	Digest        string      `json:"digest"`             // Of the envelope or statement blob
+	Platform      string      `json:"platform,omitempty"` // Image of a multi-platform index the statement is about
+	PredicateType string      `json:"predicate_type"`
+	Status        string      `json:"status"`
+	Signer        string      `json:"signer,omitempty"` // Certificate subject or identity, or key fingerprint
+	Errors        []string    `json:"errors,omitempty"`
+	Provenance    *Provenance `json:"provenance,omitempty"` // For SLSA provenance predicates
+}
+
+// Provenance is what a SLSA provenance predicate says about how an image was built
+type Provenance struct {
+	BuilderID  string               `json:"builder_id,omitempty"`
+	BuildType  string               `json:"build_type,omitempty"`
+	SourceRepo string               `json:"source_repo,omitempty"`
+	SourceRef  string               `json:"source_ref,omitempty"`
+	Commit     string               `json:"commit,omitempty"`
+	Entrypoint string               `json:"entrypoint,omitempty"` // Workflow or Dockerfile
+	StartedOn  *time.Time           `json:"started_on,omitempty"`
+	FinishedOn *time.Time           `json:"finished_on,omitempty"`
+	Materials  []ProvenanceMaterial `json:"materials,omitempty"`
+}
+
+// ProvenanceMaterial is an input of a build: source repository, base image, dependency
+type ProvenanceMaterial struct {
+	URI    string            `json:"uri"`
+	Digest map[string]string `json:"digest,omitempty"`
+}
+
+// SigningKey is a cosign key pair images are signed with from the dashboard. The private key is
+// stored encrypted under the dashboard's signing key secret and never returned.
+type SigningKey struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	PublicKey   string    `json:"public_key"` // PEM, as cosign.pub
+	Fingerprint string    `json:"fingerprint"`
+	PrivateKey  string    `json:"-"`        // Encrypted cosign private key
+	Projects    []int64   `json:"projects"` // Projects whose maintainers may sign their repositories with it; admins may use any key
+	CreatedBy   string    `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ImageSignature is a signature pushed by the dashboard
+type ImageSignature struct {
+	RegistryID      int64  `json:"registry_id"`
+	Repository      string `json:"repository"`
+	Digest          string `json:"digest"`
+	KeyID           int64  `json:"key_id"`
+	Fingerprint     string `json:"fingerprint"`
+	SignatureTag    string `json:"signature_tag"`
+	SignatureDigest string `json:"signature_digest"` // Of the signature manifest under the tag
+}
+
+// ConfigReload is the outcome of rereading the configuration file
+type ConfigReload struct {
+	File            string    `json:"file"`
+	Changed         []string  `json:"changed"`          // Settings applied without a restart
+	RestartRequired []string  `json:"restart_required"` // Changed settings that only apply on the next start
+	Ignored         []string  `json:"ignored"`          // Settings the command line overrides
+	ReloadedAt      time.Time `json:"reloaded_at"`
 }
@@ -0,0 +1,592 @@
+package registry
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// Media types attestations are stored as
+const (
+	dsseMediaType           = "application/vnd.dsse.envelope.v1+json"
+	inTotoMediaType         = "application/vnd.in-toto+json"
+	sigstoreBundleMediaType = "application/vnd.dev.sigstore.bundle"
+)
+
+// Annotations cosign and BuildKit describe attestations with
+const (
+	cosignCertificateAnnotation = "dev.sigstore.cosign/certificate"
+	cosignChainAnnotation       = "dev.sigstore.cosign/chain"
+	cosignPredicateAnnotation   = "predicateType"
+	inTotoPredicateAnnotation   = "in-toto.io/predicate-type"
+	buildkitReferenceType       = "vnd.docker.reference.type"
+	buildkitReferenceDigest     = "vnd.docker.reference.digest"
+)
+
+// SLSA provenance predicate types
+const (
+	slsaProvenanceV02 = "https://slsa.dev/provenance/v0.2"
+	slsaProvenanceV1  = "https://slsa.dev/provenance/v1"
+)
+
+// Certificate extensions Fulcio records the OIDC issuer of a keyless signer in: the current one
+// holds a DER UTF8String, the deprecated one the raw string
+var (
+	fulcioIssuerV2 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+	fulcioIssuerV1 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+)
+
+// errNotAttestation marks a sigstore bundle that holds a plain signature rather than an attestation
+var errNotAttestation = errors.New("not an attestation")
+
+// AttestationTrust holds what attestation signatures are verified against: the public keys of
+// cosign key pairs, and the CA certificates (e.g. Fulcio's) signing certificates chain to with the
+// identity and OIDC issuer they must be issued for
+type AttestationTrust struct {
+	keys     []crypto.PublicKey
+	roots    *x509.CertPool
+	identity *regexp.Regexp
+	issuer   string
+}
+
+// ParseAttestationTrust reads the PUBLIC KEY and CERTIFICATE blocks of a PEM bundle
+func ParseAttestationTrust(bundle []byte) (*AttestationTrust, error) {
+	t := &AttestationTrust{}
+	rest := bundle
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		switch block.Type {
+		case "PUBLIC KEY":
+			key, err := x509.ParsePKIXPublicKey(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("invalid public key: %w", err)
+			}
+			t.keys = append(t.keys, key)
+		case "CERTIFICATE":
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("invalid certificate: %w", err)
+			}
+			if t.roots == nil {
+				t.roots = x509.NewCertPool()
+			}
+			t.roots.AddCert(cert)
+		}
+	}
+	if len(t.keys) == 0 && t.roots == nil {
+		return nil, errors.New("no PEM public key or certificate found")
+	}
+	return t, nil
+}
+
+// RequireIdentity sets whom certificates chaining to a trusted CA must be issued to: identity is a
+// regular expression the whole email or URI of the certificate must match, and issuer, unless
+// empty, the OIDC issuer it was issued through, e.g. https://token.actions.githubusercontent.com.
+// Without an identity, anyone the CA issues a certificate to could sign, so such signatures are
+// only reported as signed.
+func (t *AttestationTrust) RequireIdentity(identity, issuer string) error {
+	if identity == "" {
+		return errors.New("an identity pattern is required")
+	}
+	if t.roots == nil {
+		return errors.New("no CA certificate to check identities of")
+	}
+	re, err := regexp.Compile("^(?:" + identity + ")$")
+	if err != nil {
+		return fmt.Errorf("invalid identity pattern: %w", err)
+	}
+	t.identity, t.issuer = re, issuer
+	return nil
+}
+
+// trustsCertificate reports whether a signing certificate chains to a trusted CA and was issued
+// to an allowed identity through the allowed issuer
+func (t *AttestationTrust) trustsCertificate(chain []*x509.Certificate) bool {
+	if t == nil || t.identity == nil || !t.chains(chain) || !t.identity.MatchString(certificateIdentity(chain[0])) {
+		return false
+	}
+	return t.issuer == "" || certificateIssuer(chain[0]) == t.issuer
+}
+
+// chains reports whether a signing certificate chains to a trusted CA. Keyless signing
+// certificates live for minutes, so the chain is checked when the certificate was issued; the
+// transparency log entry that proves the signature was made then is not checked.
+func (t *AttestationTrust) chains(chain []*x509.Certificate) bool {
+	if t.roots == nil {
+		return false
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         t.roots,
+		Intermediates: intermediates,
+		CurrentTime:   chain[0].NotBefore,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	return err == nil
+}
+
+// dsseEnvelope is a DSSE envelope around an in-toto statement
+type dsseEnvelope struct {
+	PayloadType string `json:"payloadType"`
+	Payload     string `json:"payload"`
+	Signatures  []struct {
+		KeyID string `json:"keyid"`
+		Sig   string `json:"sig"`
+	} `json:"signatures"`
+}
+
+// verifyDSSE checks the signatures of a DSSE envelope and returns the statement it carries. A
+// signature by a trusted key, or by a certificate chaining to a trusted CA and issued to an
+// allowed identity, verifies it; one that only matches another certificate, or no known key at
+// all, leaves it signed.
+func (t *AttestationTrust) verifyDSSE(att *models.Attestation, env *dsseEnvelope, certs []*x509.Certificate) ([]byte, error) {
+	if env.PayloadType != inTotoMediaType {
+		return nil, fmt.Errorf("unexpected DSSE payload type %q", env.PayloadType)
+	}
+	payload, err := base64.StdEncoding.DecodeString(env.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DSSE payload: %w", err)
+	}
+	if len(env.Signatures) == 0 {
+		att.Status = models.AttestationUnsigned
+		return payload, nil
+	}
+
+	// Signatures cover the pre-authentication encoding of the payload, not the payload itself
+	pae := []byte(fmt.Sprintf("DSSEv1 %d %s %d %s", len(env.PayloadType), env.PayloadType, len(payload), payload))
+	for _, s := range env.Signatures {
+		sig, err := base64.StdEncoding.DecodeString(s.Sig)
+		if err != nil {
+			continue
+		}
+		if t != nil {
+			for _, key := range t.keys {
+				if signedBy(key, pae, sig) {
+					att.Status, att.Signer = models.AttestationVerified, "key "+KeyFingerprint(key)
+					return payload, nil
+				}
+			}
+		}
+		if len(certs) > 0 && signedBy(certs[0].PublicKey, pae, sig) {
+			att.Status, att.Signer = models.AttestationSigned, certificateSigner(certs[0])
+			if t.trustsCertificate(certs) {
+				att.Status = models.AttestationVerified
+			}
+			return payload, nil
+		}
+	}
+	if len(certs) > 0 {
+		return nil, errors.New("no signature matches the signing certificate")
+	}
+	att.Status = models.AttestationSigned
+	return payload, nil
+}
+
+// signedBy reports whether sig is a signature of msg by key. ECDSA keys sign a digest matching
+// their curve size, RSA keys SHA-256 with PKCS #1 v1.5 or PSS, as cosign does.
+func signedBy(key crypto.PublicKey, msg, sig []byte) bool {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		h := ecdsaHash(k).New()
+		h.Write(msg)
+		return ecdsa.VerifyASN1(k, h.Sum(nil), sig)
+	case *rsa.PublicKey:
+		sum := sha256.Sum256(msg)
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, sum[:], sig) == nil || rsa.VerifyPSS(k, crypto.SHA256, sum[:], sig, nil) == nil
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, msg, sig)
+	}
+	return false
+}
+
+// ecdsaHash is the digest an ECDSA key signs, matching its curve size
+func ecdsaHash(key *ecdsa.PublicKey) crypto.Hash {
+	switch key.Curve.Params().BitSize {
+	case 384:
+		return crypto.SHA384
+	case 521:
+		return crypto.SHA512
+	}
+	return crypto.SHA256
+}
+
+// KeyFingerprint names a public key by the SHA-256 of its encoding
+func KeyFingerprint(key crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(der)
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// certificateIdentity names the signer of a certificate: the email or URI keyless signing
+// certificates carry, or its subject
+func certificateIdentity(cert *x509.Certificate) string {
+	if len(cert.EmailAddresses) > 0 {
+		return cert.EmailAddresses[0]
+	}
+	if len(cert.URIs) > 0 {
+		return cert.URIs[0].String()
+	}
+	return cert.Subject.String()
+}
+
+// certificateIssuer returns the OIDC issuer Fulcio recorded in a keyless signing certificate, ""
+// for other certificates
+func certificateIssuer(cert *x509.Certificate) string {
+	for _, ext := range cert.Extensions {
+		switch {
+		case ext.Id.Equal(fulcioIssuerV2):
+			var issuer string
+			if _, err := asn1.UnmarshalWithParams(ext.Value, &issuer, "utf8"); err == nil {
+				return issuer
+			}
+		case ext.Id.Equal(fulcioIssuerV1):
+			return string(ext.Value)
+		}
+	}
+	return ""
+}
+
+// certificateSigner describes the signer of a certificate: its identity, and the OIDC issuer
+// when it has one
+func certificateSigner(cert *x509.Certificate) string {
+	if issuer := certificateIssuer(cert); issuer != "" {
+		return certificateIdentity(cert) + " (" + issuer + ")"
+	}
+	return certificateIdentity(cert)
+}
+
+// parseSigstoreBundle reads the DSSE envelope and signing certificates of a sigstore bundle
+func parseSigstoreBundle(blob []byte) (*dsseEnvelope, []*x509.Certificate, error) {
+	var bundle struct {
+		VerificationMaterial struct {
+			Certificate *struct {
+				RawBytes []byte `json:"rawBytes"`
+			} `json:"certificate"`
+			X509CertificateChain *struct {
+				Certificates []struct {
+					RawBytes []byte `json:"rawBytes"`
+				} `json:"certificates"`
+			} `json:"x509CertificateChain"`
+		} `json:"verificationMaterial"`
+		DSSEEnvelope *dsseEnvelope `json:"dsseEnvelope"`
+	}
+	if err := json.Unmarshal(blob, &bundle); err != nil {
+		return nil, nil, fmt.Errorf("invalid sigstore bundle: %w", err)
+	}
+	if bundle.DSSEEnvelope == nil {
+		return nil, nil, errNotAttestation
+	}
+	var raw [][]byte
+	if c := bundle.VerificationMaterial.Certificate; c != nil {
+		raw = append(raw, c.RawBytes)
+	}
+	if chain := bundle.VerificationMaterial.X509CertificateChain; chain != nil {
+		for _, c := range chain.Certificates {
+			raw = append(raw, c.RawBytes)
+		}
+	}
+	var certs []*x509.Certificate
+	for _, der := range raw {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid certificate in sigstore bundle: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	return bundle.DSSEEnvelope, certs, nil
+}
+
+// ListAttestations finds the in-toto attestations of a tag: the BuildKit attestation manifests of
+// an image index, the cosign ".att" tag and OCI referrers. Each is checked to be intact, to be about
+// the image and, when signed, against trust (which may be nil); SLSA provenance is read out of them.
+func (c *Client) ListAttestations(ctx context.Context, trust *AttestationTrust, repoName, tag string) (*models.ImageAttestations, error) {
+	body, mediaType, digest, err := c.GetRawManifest(ctx, repoName, tag)
+	if err != nil {
+		return nil, err
+	}
+	result := &models.ImageAttestations{Repository: repoName, Tag: tag, Digest: digest, Attestations: []models.Attestation{}}
+
+	if IsIndexMediaType(mediaType) {
+		var index struct {
+			Manifests []struct {
+				Referrer
+				Platform *models.Platform `json:"platform,omitempty"`
+			} `json:"manifests"`
+		}
+		if err := json.Unmarshal(body, &index); err != nil {
+			return nil, fmt.Errorf("invalid index: %w", err)
+		}
+		platforms := map[string]string{}
+		for _, m := range index.Manifests {
+			platforms[m.Digest] = m.Platform.String()
+		}
+		for _, m := range index.Manifests {
+			if m.Annotations[buildkitReferenceType] != "attestation-manifest" {
+				continue
+			}
+			subject := m.Annotations[buildkitReferenceDigest]
+			atts := c.manifestAttestations(ctx, trust, repoName, "buildkit", m.Digest, subject)
+			for i := range atts {
+				atts[i].Platform = platforms[subject]
+			}
+			result.Attestations = append(result.Attestations, atts...)
+		}
+	}
+
+	attTag := strings.Replace(digest, ":", "-", 1) + ".att"
+	if _, err := c.GetDigestForTag(ctx, repoName, attTag); err == nil {
+		result.Attestations = append(result.Attestations, c.manifestAttestations(ctx, trust, repoName, "cosign", attTag, digest)...)
+	}
+
+	referrers, err := c.ListReferrers(ctx, repoName, digest)
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range referrers {
+		if ref.ArtifactType == dsseMediaType || ref.ArtifactType == inTotoMediaType || strings.HasPrefix(ref.ArtifactType, sigstoreBundleMediaType) {
+			result.Attestations = append(result.Attestations, c.manifestAttestations(ctx, trust, repoName, "referrer", ref.Digest, digest)...)
+		}
+	}
+	return result, nil
+}
+
+// manifestAttestations reads the attestations in the layers of a manifest, which are about the
+// image with the subject digest
+func (c *Client) manifestAttestations(ctx context.Context, trust *AttestationTrust, repoName, source, reference, subject string) []models.Attestation {
+	body, _, digest, err := c.GetRawManifest(ctx, repoName, reference)
+	if err != nil {
+		return []models.Attestation{{Source: source, Status: models.AttestationInvalid, Errors: []string{err.Error()}}}
+	}
+	var manifest struct {
+		Layers []Referrer `json:"layers"`
+	}
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return []models.Attestation{{Source: source, Digest: digest, Status: models.AttestationInvalid, Errors: []string{"invalid attestation manifest: " + err.Error()}}}
+	}
+
+	var atts []models.Attestation
+	for _, layer := range manifest.Layers {
+		att := models.Attestation{Source: source, Digest: layer.Digest, PredicateType: layer.Annotations[inTotoPredicateAnnotation]}
+		if att.PredicateType == "" {
+			att.PredicateType = layer.Annotations[cosignPredicateAnnotation]
+		}
+		err := c.readAttestation(ctx, trust, repoName, layer, subject, &att)
+		if errors.Is(err, errNotAttestation) {
+			continue
+		}
+		if err != nil {
+			att.Status = models.AttestationInvalid
+			att.Errors = append(att.Errors, err.Error())
+		}
+		atts = append(atts, att)
+	}
+	return atts
+}
+
+// readAttestation fetches the statement of an attestation layer, verifies it and reads its predicate
+func (c *Client) readAttestation(ctx context.Context, trust *AttestationTrust, repoName string, layer Referrer, subject string, att *models.Attestation) error {
+	var statement []byte
+	switch {
+	case layer.MediaType == inTotoMediaType:
+		blob, err := c.getVerifiedBlob(ctx, repoName, layer.Digest)
+		if err != nil {
+			return err
+		}
+		statement, att.Status = blob, models.AttestationUnsigned
+	case layer.MediaType == dsseMediaType:
+		blob, err := c.getVerifiedBlob(ctx, repoName, layer.Digest)
+		if err != nil {
+			return err
+		}
+		var env dsseEnvelope
+		if err := json.Unmarshal(blob, &env); err != nil {
+			return fmt.Errorf("invalid DSSE envelope: %w", err)
+		}
+		var certs []*x509.Certificate
+		if pemCerts := layer.Annotations[cosignCertificateAnnotation]; pemCerts != "" {
+			if certs, err = ParseCertificates(pemCerts + "\n" + layer.Annotations[cosignChainAnnotation]); err != nil {
+				return err
+			}
+		}
+		if statement, err = trust.verifyDSSE(att, &env, certs); err != nil {
+			return err
+		}
+	case strings.HasPrefix(layer.MediaType, sigstoreBundleMediaType):
+		blob, err := c.getVerifiedBlob(ctx, repoName, layer.Digest)
+		if err != nil {
+			return err
+		}
+		env, certs, err := parseSigstoreBundle(blob)
+		if err != nil {
+			return err
+		}
+		if statement, err = trust.verifyDSSE(att, env, certs); err != nil {
+			return err
+		}
+	default:
+		return errNotAttestation
+	}
+	return readStatement(att, statement, subject)
+}
+
+// inTotoSubject is an artifact an in-toto statement is about
+type inTotoSubject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// readStatement checks an in-toto statement is about the subject image and reads its provenance
+func readStatement(att *models.Attestation, statement []byte, subject string) error {
+	var st struct {
+		Type          string          `json:"_type"`
+		Subject       []inTotoSubject `json:"subject"`
+		PredicateType string          `json:"predicateType"`
+		Predicate     json.RawMessage `json:"predicate"`
+	}
+	if err := json.Unmarshal(statement, &st); err != nil {
+		return fmt.Errorf("invalid in-toto statement: %w", err)
+	}
+	if !strings.HasPrefix(st.Type, "https://in-toto.io/Statement/") {
+		return fmt.Errorf("unexpected statement type %q", st.Type)
+	}
+	att.PredicateType = st.PredicateType
+	algorithm, hash, _ := strings.Cut(subject, ":")
+	if !slices.ContainsFunc(st.Subject, func(s inTotoSubject) bool { return s.Digest[algorithm] == hash }) {
+		return fmt.Errorf("the statement is not about %s", subject)
+	}
+	att.Provenance = parseProvenance(st.PredicateType, st.Predicate)
+	return nil
+}
+
+// slsaConfigSource is where the build definition of a BuildKit build came from
+type slsaConfigSource struct {
+	URI        string            `json:"uri"`
+	Digest     map[string]string `json:"digest"`
+	EntryPoint string            `json:"entryPoint"` // SLSA v0.2
+	Path       string            `json:"path"`       // SLSA v1
+}
+
+// buildkitMetadata is the provenance metadata BuildKit adds under its own key, with the Git source
+// of builds from a checkout
+type buildkitMetadata struct {
+	VCS struct {
+		Source   string `json:"source"`
+		Revision string `json:"revision"`
+	} `json:"vcs"`
+}
+
+// parseProvenance reads a SLSA v0.2 or v1 provenance predicate, nil for other predicates and
+// predicates that cannot be read
+func parseProvenance(predicateType string, predicate json.RawMessage) *models.Provenance {
+	var p models.Provenance
+	var source slsaConfigSource
+	var vcs buildkitMetadata
+	switch predicateType {
+	case slsaProvenanceV02:
+		var v struct {
+			Builder struct {
+				ID string `json:"id"`
+			} `json:"builder"`
+			BuildType  string `json:"buildType"`
+			Invocation struct {
+				ConfigSource slsaConfigSource `json:"configSource"`
+			} `json:"invocation"`
+			Metadata struct {
+				BuildStartedOn  *time.Time       `json:"buildStartedOn"`
+				BuildFinishedOn *time.Time       `json:"buildFinishedOn"`
+				BuildKit        buildkitMetadata `json:"https://mobyproject.org/buildkit@v1#metadata"`
+			} `json:"metadata"`
+			Materials []models.ProvenanceMaterial `json:"materials"`
+		}
+		if err := json.Unmarshal(predicate, &v); err != nil {
+			return nil
+		}
+		p = models.Provenance{BuilderID: v.Builder.ID, BuildType: v.BuildType, StartedOn: v.Metadata.BuildStartedOn, FinishedOn: v.Metadata.BuildFinishedOn, Materials: v.Materials}
+		source, vcs = v.Invocation.ConfigSource, v.Metadata.BuildKit
+	case slsaProvenanceV1:
+		var v struct {
+			BuildDefinition struct {
+				BuildType          string `json:"buildType"`
+				ExternalParameters struct {
+					Workflow struct {
+						Repository string `json:"repository"`
+						Ref        string `json:"ref"`
+						Path       string `json:"path"`
+					} `json:"workflow"`
+					ConfigSource slsaConfigSource `json:"configSource"`
+				} `json:"externalParameters"`
+				ResolvedDependencies []models.ProvenanceMaterial `json:"resolvedDependencies"`
+			} `json:"buildDefinition"`
+			RunDetails struct {
+				Builder struct {
+					ID string `json:"id"`
+				} `json:"builder"`
+				Metadata struct {
+					StartedOn  *time.Time       `json:"startedOn"`
+					FinishedOn *time.Time       `json:"finishedOn"`
+					BuildKit   buildkitMetadata `json:"https://mobyproject.org/buildkit@v1#metadata"`
+				} `json:"metadata"`
+			} `json:"runDetails"`
+		}
+		if err := json.Unmarshal(predicate, &v); err != nil {
+			return nil
+		}
+		def, run := v.BuildDefinition, v.RunDetails
+		p = models.Provenance{BuilderID: run.Builder.ID, BuildType: def.BuildType, StartedOn: run.Metadata.StartedOn, FinishedOn: run.Metadata.FinishedOn, Materials: def.ResolvedDependencies}
+		workflow := def.ExternalParameters.Workflow
+		p.SourceRepo, p.SourceRef, p.Entrypoint = workflow.Repository, workflow.Ref, workflow.Path
+		source, vcs = def.ExternalParameters.ConfigSource, run.Metadata.BuildKit
+	default:
+		return nil
+	}
+
+	// BuildKit names the build context as <repo>#<ref>, or only in its metadata for local checkouts
+	if p.SourceRepo == "" && source.URI != "" {
+		p.SourceRepo, p.SourceRef, _ = strings.Cut(source.URI, "#")
+		p.Commit = source.Digest["sha1"]
+	}
+	if p.Entrypoint == "" {
+		p.Entrypoint = source.EntryPoint + source.Path
+	}
+	if p.SourceRepo == "" {
+		p.SourceRepo = vcs.VCS.Source
+	}
+	if p.Commit == "" {
+		p.Commit = vcs.VCS.Revision
+	}
+	// Otherwise the commit is the one of the source among the materials
+	for i := 0; p.Commit == "" && i < len(p.Materials); i++ {
+		m := p.Materials[i]
+		if strings.Contains(m.URI, strings.TrimPrefix(p.SourceRepo, "https://")) {
+			p.Commit = m.Digest["gitCommit"] + m.Digest["sha1"]
+		}
+	}
+	return &p
+}
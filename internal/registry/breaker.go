@@ -0,0 +1,222 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+// ErrCircuitOpen is returned without contacting the registry while its circuit is open
+var ErrCircuitOpen = errors.New("registry unavailable (circuit open)")
+
+const (
+	maxBreakerCooldown = 5 * time.Minute
+	retryAttempts      = 2
+	retryBackoff       = 250 * time.Millisecond
+)
+
+var (
+	breakersMu sync.Mutex
+	breakers   = map[string]*circuitBreaker{}
+
+	// breakerThreshold consecutive failures open a circuit; breakerCooldown is the first wait before a probe
+	breakerThreshold = 5
+	breakerCooldown  = 30 * time.Second
+)
+
+// SetBreakerSettings configures the circuit breakers of all registries; threshold 0 disables them
+func SetBreakerSettings(threshold int, cooldown time.Duration) {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	breakerThreshold = threshold
+	if cooldown > 0 {
+		breakerCooldown = cooldown
+	}
+}
+
+// circuitBreaker tracks the health of one registry endpoint, shared by all clients of it
+type circuitBreaker struct {
+	mu        sync.Mutex
+	url       string
+	state     string
+	failures  int
+	openedAt  time.Time
+	cooldown  time.Duration
+	probing   bool
+	lastError string
+}
+
+func breakerKey(url string) string {
+	return strings.TrimRight(url, "/")
+}
+
+// breakerFor returns the shared breaker of a registry URL
+func breakerFor(url string) *circuitBreaker {
+	breakersMu.Lock()
+	defer breakersMu.Unlock()
+	key := breakerKey(url)
+	b, ok := breakers[key]
+	if !ok {
+		b = &circuitBreaker{url: key, state: CircuitClosed}
+		breakers[key] = b
+	}
+	return b
+}
+
+// allow reports whether a request may be sent; once the cooldown has passed a single probe is let through
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case CircuitOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = CircuitHalfOpen
+		b.probing = true
+		log.Printf("🔌 Probing registry %s after %s", b.url, b.cooldown)
+		return true
+	case CircuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	}
+	return true
+}
+
+// record updates the breaker with the outcome of a request
+func (b *circuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	breakersMu.Lock()
+	threshold, cooldown := breakerThreshold, breakerCooldown
+	breakersMu.Unlock()
+
+	if err == nil {
+		if b.state != CircuitClosed {
+			log.Printf("✅ Registry %s is reachable again, circuit closed", b.url)
+		}
+		b.state, b.failures, b.probing, b.lastError = CircuitClosed, 0, false, ""
+		b.cooldown = 0
+		return
+	}
+
+	b.failures++
+	b.lastError = err.Error()
+	switch {
+	case b.state == CircuitHalfOpen:
+		// Failed probe: back off further before the next one
+		b.cooldown = min(b.cooldown*2, maxBreakerCooldown)
+		b.state, b.openedAt, b.probing = CircuitOpen, time.Now(), false
+	case threshold > 0 && b.failures >= threshold:
+		b.cooldown = cooldown
+		b.state, b.openedAt = CircuitOpen, time.Now()
+		log.Printf("⛔ Registry %s failed %d times in a row, circuit open for %s: %v", b.url, b.failures, b.cooldown, err)
+	}
+}
+
+// status returns a snapshot of the breaker
+func (b *circuitBreaker) status() models.CircuitStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	s := models.CircuitStatus{
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		LastError:           b.lastError,
+	}
+	if b.state != CircuitClosed {
+		s.OpenedAt = b.openedAt
+		s.RetryAt = b.openedAt.Add(b.cooldown)
+	}
+	return s
+}
+
+// CircuitStatusFor returns the circuit breaker state of a registry URL
+func CircuitStatusFor(url string) models.CircuitStatus {
+	return breakerFor(url).status()
+}
+
+// ResetCircuit closes the circuit of a registry URL, e.g. before an explicit connection test
+func ResetCircuit(url string) {
+	b := breakerFor(url)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state, b.failures, b.probing, b.lastError, b.cooldown = CircuitClosed, 0, false, "", 0
+}
+
+// breakerTransport guards a registry's transport with its circuit breaker and retries
+// idempotent requests once on transient failures
+type breakerTransport struct {
+	breaker *circuitBreaker
+	next    http.RoundTripper
+}
+
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.breaker.allow() {
+		return nil, fmt.Errorf("%w: %s", ErrCircuitOpen, t.breaker.url)
+	}
+
+	idempotent := (req.Method == http.MethodGet || req.Method == http.MethodHead) && req.Body == nil
+	attempts := 1
+	if idempotent {
+		attempts = retryAttempts
+	}
+
+	var resp *http.Response
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		resp, err = t.next.RoundTrip(req)
+		if errors.Is(req.Context().Err(), context.Canceled) {
+			// Abandoned by the caller: the attempt tells nothing about the registry
+			t.breaker.release()
+			break
+		}
+		failure := transientFailure(resp, err)
+		if failure == nil || attempt == attempts || req.Context().Err() != nil {
+			t.breaker.record(failure)
+			break
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		time.Sleep(retryBackoff)
+	}
+	return resp, err
+}
+
+// release frees the probe slot of a half-open breaker without recording an outcome
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitHalfOpen {
+		b.state, b.probing = CircuitOpen, false
+	}
+}
+
+// transientFailure classifies a round trip: connection errors and 5xx responses count against the registry
+func transientFailure(resp *http.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	switch resp.StatusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return fmt.Errorf("unexpected status: %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package registry
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// certDialTimeout bounds the connection made to inspect a registry's certificates
+const certDialTimeout = 10 * time.Second
+
+// CertificateChain is the TLS certificate chain presented by a registry
+type CertificateChain struct {
+	Certificates []models.CertificateInfo // Leaf first
+	VerifyError  error                    // Why the chain does not verify against the system roots; nil if it does
+}
+
+// CertPool parses a PEM bundle of CA certificates into a pool that also holds the system roots
+func CertPool(caPEM string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	rest := []byte(caPEM)
+	found := 0
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CA certificate: %w", err)
+		}
+		pool.AddCert(cert)
+		found++
+	}
+	if found == 0 {
+		return nil, fmt.Errorf("invalid CA certificate: no PEM certificate found")
+	}
+	return pool, nil
+}
+
+// InspectCertificates connects to a registry, through its proxy if it has one, and returns its
+// TLS certificate chain, verified against the system roots and the registry's CA bundle. Plain
+// HTTP registries are only connected to and return a nil chain. The connection bypasses the
+// circuit breaker.
+func InspectCertificates(reg *models.Registry) (*CertificateChain, error) {
+	u, err := url.Parse(reg.URL)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid registry URL %q", reg.URL)
+	}
+	host := u.Hostname()
+	addr := u.Host
+	if u.Port() == "" {
+		if u.Scheme == "http" {
+			addr = net.JoinHostPort(host, "80")
+		} else {
+			addr = net.JoinHostPort(host, "443")
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: certDialTimeout}
+	proxy, err := registryProxy(reg, u)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "http" {
+		// Plain HTTP goes to the proxy as requests, not through a tunnel
+		target := addr
+		if proxy != nil {
+			target = proxyAddr(proxy)
+		}
+		conn, err := dialer.Dial("tcp", target)
+		if err != nil {
+			return nil, err
+		}
+		conn.Close()
+		return nil, nil
+	}
+
+	raw, err := dialThrough(dialer, proxy, addr)
+	if err != nil {
+		return nil, err
+	}
+	// Verification happens below, so chains that would fail it can still be inspected
+	conn := tls.Client(raw, &tls.Config{ServerName: host, InsecureSkipVerify: true})
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(certDialTimeout))
+	if err := conn.Handshake(); err != nil {
+		return nil, err
+	}
+
+	peers := conn.ConnectionState().PeerCertificates
+	if len(peers) == 0 {
+		return nil, fmt.Errorf("registry presented no certificate")
+	}
+
+	chain := make([]models.CertificateInfo, len(peers))
+	intermediates := x509.NewCertPool()
+	for i, cert := range peers {
+		sum := sha256.Sum256(cert.Raw)
+		chain[i] = models.CertificateInfo{
+			Subject:     cert.Subject.String(),
+			Issuer:      cert.Issuer.String(),
+			DNSNames:    cert.DNSNames,
+			NotBefore:   cert.NotBefore,
+			NotAfter:    cert.NotAfter,
+			Fingerprint: hex.EncodeToString(sum[:]),
+		}
+		if i > 0 {
+			intermediates.AddCert(cert)
+		}
+	}
+	opts := x509.VerifyOptions{DNSName: host, Intermediates: intermediates}
+	if reg.CACert != "" {
+		if opts.Roots, err = CertPool(reg.CACert); err != nil {
+			return nil, err
+		}
+	}
+	_, verifyErr := peers[0].Verify(opts)
+	return &CertificateChain{Certificates: chain, VerifyError: verifyErr}, nil
+}
+
+// registryProxy returns the proxy connections to a registry go through, nil for none
+func registryProxy(reg *models.Registry, u *url.URL) (*url.URL, error) {
+	if reg.Proxy != "" {
+		return ParseProxy(reg.Proxy)
+	}
+	return http.ProxyFromEnvironment(&http.Request{URL: u})
+}
+
+// proxyAddr is the host:port of a proxy
+func proxyAddr(proxy *url.URL) string {
+	if proxy.Port() != "" {
+		return proxy.Host
+	}
+	port := map[string]string{"http": "80", "https": "443", "socks5": "1080"}[proxy.Scheme]
+	return net.JoinHostPort(proxy.Hostname(), port)
+}
+
+// dialThrough connects to addr, directly or through an HTTP CONNECT tunnel of the proxy
+func dialThrough(dialer *net.Dialer, proxy *url.URL, addr string) (net.Conn, error) {
+	if proxy == nil {
+		return dialer.Dial("tcp", addr)
+	}
+	if proxy.Scheme == "socks5" {
+		return nil, fmt.Errorf("certificate checks through a SOCKS proxy are not supported")
+	}
+	conn, err := dialer.Dial("tcp", proxyAddr(proxy))
+	if err != nil {
+		return nil, fmt.Errorf("proxy %s: %w", proxy.Redacted(), err)
+	}
+	if proxy.Scheme == "https" {
+		conn = tls.Client(conn, &tls.Config{ServerName: proxy.Hostname()})
+	}
+	conn.SetDeadline(time.Now().Add(certDialTimeout))
+
+	req := &http.Request{Method: http.MethodConnect, URL: &url.URL{Opaque: addr}, Host: addr, Header: http.Header{}}
+	if proxy.User != nil {
+		password, _ := proxy.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxy.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s: %w", proxy.Redacted(), err)
+	}
+	// The server speaks only after the TLS client hello, so nothing past the response is buffered
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s: %w", proxy.Redacted(), err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s refused the tunnel to %s: %s", proxy.Redacted(), addr, resp.Status)
+	}
+	conn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// ChainFingerprint identifies a certificate chain by the fingerprints of its certificates
+func ChainFingerprint(chain []models.CertificateInfo) string {
+	if len(chain) == 0 {
+		return ""
+	}
+	parts := make([]string, len(chain))
+	for i, c := range chain {
+		parts[i] = c.Fingerprint
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, ":")))
+	return hex.EncodeToString(sum[:])
+}
@@ -1,70 +1,225 @@
 package registry
 
 import (
+	"context"
+	"crypto/sha256"
 	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	neturl "net/url"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"docker-registry-dashboard/internal/azure"
+	"docker-registry-dashboard/internal/ecr"
+	"docker-registry-dashboard/internal/gcp"
 	"docker-registry-dashboard/internal/models"
 )
 
+// Manifest media types understood by the client
+const (
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+
+	// DefaultPlatform is used when a manifest list is resolved without an explicit platform
+	DefaultPlatform = "linux/amd64"
+)
+
+// Timeouts of registry calls. A request and the reading of its response are bounded by the
+// timeout of its method; streamed blob transfers have none and last as long as their context.
+const (
+	headTimeout    = 10 * time.Second
+	requestTimeout = 30 * time.Second
+	loginTimeout   = 30 * time.Second
+
+	// responseHeaderTimeout catches registries that accept a connection but never answer,
+	// streamed transfers included
+	responseHeaderTimeout = 30 * time.Second
+)
+
+// callTimeout is the timeout of a bodyless or small request
+func callTimeout(method string) time.Duration {
+	if method == http.MethodHead {
+		return headTimeout
+	}
+	return requestTimeout
+}
+
+// manifestAccept is sent on manifest requests so registries return lists/indexes as-is
+var manifestAccept = strings.Join([]string{
+	MediaTypeDockerManifest,
+	MediaTypeDockerManifestList,
+	MediaTypeOCIManifest,
+	MediaTypeOCIIndex,
+}, ", ")
+
+// IsIndexMediaType reports whether the media type is a manifest list or OCI index
+func IsIndexMediaType(mediaType string) bool {
+	return mediaType == MediaTypeDockerManifestList || mediaType == MediaTypeOCIIndex
+}
+
 // Client communicates with Docker Registry V2 API
 type Client struct {
-	baseURL    string
-	username   string
-	password   string
-	httpClient *http.Client
+	baseURL     string
+	username    string
+	password    string
+	httpClient  *http.Client
+	tokenClient *http.Client                                      // Fetches bearer tokens, see do
+	login       func(ctx context.Context) (string, string, error) // Exchanges the credentials for registry ones, e.g. for ECR
+	quay        *quayAPI                                          // Set for Quay registries, listed through the Quay API
+	ghcr        *ghcrAPI                                          // Set for GHCR, listed through the GitHub Packages API
+
+	tokenMu sync.Mutex
+	tokens  map[string]string // Bearer tokens by repository, see do
 }
 
-// NewClient creates a new Registry V2 API client
+// NewClient creates a new Registry V2 API client. Connections go through the proxy that
+// HTTPS_PROXY, HTTP_PROXY and NO_PROXY select.
 func NewClient(url, username, password string, insecure bool) *Client {
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: insecure,
-		},
+	return newClient(url, username, password, &tls.Config{InsecureSkipVerify: insecure}, nil)
+}
+
+// newClient creates a client whose connections verify certificates with tlsConfig and go through
+// proxy, or the proxy of the environment when it is nil
+func newClient(url, username, password string, tlsConfig *tls.Config, proxy *neturl.URL) *Client {
+	transport := &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment, ResponseHeaderTimeout: responseHeaderTimeout}
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
 	}
 
-	return &Client{
+	c := &Client{
 		baseURL:  url,
 		username: username,
 		password: password,
 		httpClient: &http.Client{
-			Timeout:   15 * time.Second,
-			Transport: transport,
+			Transport: &breakerTransport{breaker: breakerFor(url), next: transport},
 		},
+		tokenClient: tokenClient,
 	}
+	if tlsConfig.InsecureSkipVerify || tlsConfig.RootCAs != nil || proxy != nil {
+		// Token services often live on the registry host, behind the same certificate and proxy
+		c.tokenClient = &http.Client{Timeout: tokenClient.Timeout, Transport: transport}
+	}
+	return c
 }
 
-// NewClientFromRegistry creates a client from a Registry model
+// NewClientFromRegistry creates a client from a Registry model. The registry's certificate is
+// verified against its CA bundle as well as the system roots, and its proxy replaces the
+// environment's. The credentials of an ECR, GCP or
+// ACR registry are exchanged for registry ones when a request needs them, and again before they
+// expire. Quay and GHCR registries are listed through their own APIs with the token in Password.
 func NewClientFromRegistry(r *models.Registry) *Client {
-	return NewClient(r.URL, r.Username, r.Password, r.Insecure)
+	tlsConfig := &tls.Config{InsecureSkipVerify: r.Insecure}
+	if r.CACert != "" {
+		pool, err := CertPool(r.CACert)
+		if err != nil {
+			log.Printf("⚠️  Ignoring the CA certificate of registry %s: %v", r.Name, err)
+		} else {
+			tlsConfig.RootCAs = pool
+		}
+	}
+	var proxy *neturl.URL
+	if r.Proxy != "" {
+		var err error
+		if proxy, err = ParseProxy(r.Proxy); err != nil {
+			log.Printf("⚠️  Ignoring the proxy of registry %s: %v", r.Name, err)
+		}
+	}
+	c := newClient(r.URL, r.Username, r.Password, tlsConfig, proxy)
+	switch r.Type {
+	case models.RegistryTypeECR:
+		region := r.Region
+		if region == "" {
+			region = ecr.RegionFromURL(r.URL)
+		}
+		accessKey, secretKey := r.Username, r.Password
+		c.login = func(ctx context.Context) (string, string, error) {
+			ctx, cancel := context.WithTimeout(ctx, loginTimeout)
+			defer cancel()
+			username, password, err := ecr.Login(ctx, region, accessKey, secretKey)
+			if err != nil {
+				return "", "", fmt.Errorf("ECR login failed: %w", err)
+			}
+			return username, password, nil
+		}
+	case models.RegistryTypeGCP:
+		keyJSON := r.Password
+		c.login = func(ctx context.Context) (string, string, error) {
+			ctx, cancel := context.WithTimeout(ctx, loginTimeout)
+			defer cancel()
+			username, password, err := gcp.Login(ctx, keyJSON)
+			if err != nil {
+				return "", "", fmt.Errorf("GCP login failed: %w", err)
+			}
+			return username, password, nil
+		}
+	case models.RegistryTypeACR:
+		registryURL, tenant, clientID, clientSecret := r.URL, r.Tenant, r.Username, r.Password
+		c.login = func(ctx context.Context) (string, string, error) {
+			ctx, cancel := context.WithTimeout(ctx, loginTimeout)
+			defer cancel()
+			username, password, err := azure.Login(ctx, registryURL, tenant, clientID, clientSecret)
+			if err != nil {
+				return "", "", fmt.Errorf("ACR login failed: %w", err)
+			}
+			return username, password, nil
+		}
+	case models.RegistryTypeQuay:
+		c.quay = &quayAPI{token: r.Password, namespaces: splitNamespaces(r.Namespaces)}
+		if c.username == "" && c.password != "" {
+			c.username = QuayTokenUser
+		}
+	case models.RegistryTypeGHCR:
+		c.ghcr = &ghcrAPI{token: r.Password, owners: splitNamespaces(r.Namespaces)}
+	}
+	return c
 }
 
-func (c *Client) doRequest(method, path string, headers map[string]string) (*http.Response, error) {
-	url := fmt.Sprintf("%s%s", c.baseURL, path)
-	req, err := http.NewRequest(method, url, nil)
+// ParseProxy parses the proxy URL of a registry: http://, https:// or socks5://, with optional
+// user:password@
+func ParseProxy(proxy string) (*neturl.URL, error) {
+	u, err := neturl.Parse(proxy)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
 	}
-
-	if c.username != "" {
-		req.SetBasicAuth(c.username, c.password)
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxy URL %q: the scheme must be http, https or socks5", u.Redacted())
 	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: no host", u.Redacted())
+	}
+	return u, nil
+}
 
-	for k, v := range headers {
-		req.Header.Set(k, v)
+// splitNamespaces splits a comma-separated list of namespaces
+func splitNamespaces(list string) []string {
+	var namespaces []string
+	for _, ns := range strings.Split(list, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			namespaces = append(namespaces, ns)
+		}
 	}
+	return namespaces
+}
 
-	return c.httpClient.Do(req)
+// doRequest sends a bodyless request to a path of the registry, bounded by the timeout of its method
+func (c *Client) doRequest(ctx context.Context, method, path string, headers map[string]string) (*http.Response, error) {
+	return c.send(ctx, method, c.baseURL+path, headers, nil, 0, false)
 }
 
 // Ping checks if the registry is accessible (GET /v2/)
-func (c *Client) Ping() error {
-	resp, err := c.doRequest("GET", "/v2/", nil)
+func (c *Client) Ping(ctx context.Context) error {
+	resp, err := c.doRequest(ctx, "GET", "/v2/", nil)
 	if err != nil {
 		return fmt.Errorf("connection failed: %w", err)
 	}
@@ -85,7 +240,13 @@ type catalogResponse struct {
 }
 
 // ListRepositories returns all repositories in the registry
-func (c *Client) ListRepositories() ([]models.Repository, error) {
+func (c *Client) ListRepositories(ctx context.Context) ([]models.Repository, error) {
+	if c.quay != nil {
+		return c.listQuayRepositories(ctx)
+	}
+	if c.ghcr != nil {
+		return c.listGHCRRepositories(ctx)
+	}
 	var allRepos []models.Repository
 	nextURL := "/v2/_catalog?n=100"
 
@@ -95,7 +256,7 @@ func (c *Client) ListRepositories() ([]models.Repository, error) {
 			nextURL = strings.TrimPrefix(nextURL, c.baseURL)
 		}
 
-		resp, err := c.doRequest("GET", nextURL, nil)
+		resp, err := c.doRequest(ctx, "GET", nextURL, nil)
 		if err != nil {
 			return nil, fmt.Errorf("failed to list repositories: %w", err)
 		}
@@ -140,53 +301,52 @@ type tagsResponse struct {
 	Tags []string `json:"tags"`
 }
 
-// ListTags returns all tags for a repository
-func (c *Client) ListTags(repoName string) ([]models.Tag, error) {
+// tagsPageSize is the page size requested from registries known to paginate tag lists
+const tagsPageSize = 1000
+
+// ListTags returns all tags for a repository, following Link headers across pages
+func (c *Client) ListTags(ctx context.Context, repoName string) ([]models.Tag, error) {
+	if c.quay != nil {
+		return c.listQuayTags(ctx, repoName)
+	}
 	path := fmt.Sprintf("/v2/%s/tags/list", repoName)
-	resp, err := c.doRequest("GET", path, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list tags: %w", err)
+	if p := profileFor(c.baseURL); p != nil && p.TagPagination {
+		path += fmt.Sprintf("?n=%d", tagsPageSize)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("registry returned status %d: %s", resp.StatusCode, string(body))
-	}
+	tags := []models.Tag{}
+	for path != "" {
+		resp, err := c.doRequest(ctx, "GET", path, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list tags: %w", err)
+		}
 
-	var tagsResp tagsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&tagsResp); err != nil {
-		return nil, fmt.Errorf("failed to decode tags: %w", err)
-	}
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return nil, fmt.Errorf("registry returned status %d: %s", resp.StatusCode, string(body))
+		}
 
-	tags := make([]models.Tag, len(tagsResp.Tags))
-	for i, name := range tagsResp.Tags {
-		tags[i] = models.Tag{Name: name}
+		var tagsResp tagsResponse
+		err = json.NewDecoder(resp.Body).Decode(&tagsResp)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode tags: %w", err)
+		}
+		for _, name := range tagsResp.Tags {
+			tags = append(tags, models.Tag{Name: name})
+		}
+		path = strings.TrimPrefix(nextLink(resp.Header.Get("Link")), c.baseURL)
 	}
 	return tags, nil
 }
 
-// GetManifest returns the manifest for a specific tag
-func (c *Client) GetManifest(repoName, tag string) (*models.ImageManifest, error) {
-	path := fmt.Sprintf("/v2/%s/manifests/%s", repoName, tag)
-	headers := map[string]string{
-		"Accept": "application/vnd.docker.distribution.manifest.v2+json, application/vnd.oci.image.manifest.v1+json",
-	}
-
-	resp, err := c.doRequest("GET", path, headers)
+// GetManifest returns the manifest for a specific tag or digest.
+// Manifest lists and OCI indexes are returned with their platform entries in Manifests.
+func (c *Client) GetManifest(ctx context.Context, repoName, tag string) (*models.ImageManifest, error) {
+	body, mediaType, digest, err := c.GetRawManifest(ctx, repoName, tag)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get manifest: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("registry returned status %d: %s", resp.StatusCode, string(body))
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read manifest body: %w", err)
+		return nil, err
 	}
 
 	// Parse manifest
@@ -203,16 +363,27 @@ func (c *Client) GetManifest(repoName, tag string) (*models.ImageManifest, error
 			Size      int64  `json:"size"`
 			Digest    string `json:"digest"`
 		} `json:"layers"`
+		Manifests []models.ManifestEntry `json:"manifests"`
 	}
 
 	if err := json.Unmarshal(body, &rawManifest); err != nil {
 		return nil, fmt.Errorf("failed to decode manifest: %w", err)
 	}
 
+	// OCI manifests may omit mediaType in the body; fall back to the response header
+	if rawManifest.MediaType != "" {
+		mediaType = rawManifest.MediaType
+	}
+
 	manifest := &models.ImageManifest{
 		SchemaVersion: rawManifest.SchemaVersion,
-		MediaType:     rawManifest.MediaType,
-		Digest:        resp.Header.Get("Docker-Content-Digest"),
+		MediaType:     mediaType,
+		Digest:        digest,
+	}
+
+	if IsIndexMediaType(mediaType) || len(rawManifest.Manifests) > 0 {
+		manifest.Manifests = rawManifest.Manifests
+		return manifest, nil
 	}
 
 	if rawManifest.Config.Digest != "" {
@@ -237,10 +408,69 @@ func (c *Client) GetManifest(repoName, tag string) (*models.ImageManifest, error
 	return manifest, nil
 }
 
+// ResolvePlatformManifest returns the image manifest for a tag, following manifest lists
+// and OCI indexes to the child matching platform (os/arch[/variant], default linux/amd64)
+func (c *Client) ResolvePlatformManifest(ctx context.Context, repoName, tag, platform string) (*models.ImageManifest, error) {
+	manifest, err := c.GetManifest(ctx, repoName, tag)
+	if err != nil {
+		return nil, err
+	}
+	if len(manifest.Manifests) == 0 {
+		return manifest, nil
+	}
+
+	entry := selectPlatform(manifest.Manifests, platform)
+	if entry == nil {
+		return nil, fmt.Errorf("no manifest for platform %s in %s:%s", platform, repoName, tag)
+	}
+
+	child, err := c.GetManifest(ctx, repoName, entry.Digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s manifest: %w", entry.Platform.String(), err)
+	}
+	if len(child.Manifests) > 0 {
+		return nil, fmt.Errorf("nested manifest lists are not supported")
+	}
+	child.Platform = entry.Platform
+	return child, nil
+}
+
+// selectPlatform picks the manifest entry for a platform string.
+// With no platform it prefers linux/amd64, then the first real (non-attestation) entry.
+func selectPlatform(entries []models.ManifestEntry, platform string) *models.ManifestEntry {
+	want := platform
+	if want == "" {
+		want = DefaultPlatform
+	}
+
+	for i := range entries {
+		p := entries[i].Platform
+		if p == nil {
+			continue
+		}
+		if p.String() == want || (p.Variant != "" && p.OS+"/"+p.Architecture == want) {
+			return &entries[i]
+		}
+	}
+
+	if platform != "" {
+		return nil
+	}
+	for i := range entries {
+		if p := entries[i].Platform; p != nil && p.OS != "unknown" {
+			return &entries[i]
+		}
+	}
+	return nil
+}
+
 // DeleteManifest deletes a manifest by digest
-func (c *Client) DeleteManifest(repoName, digest string) error {
+func (c *Client) DeleteManifest(ctx context.Context, repoName, digest string) error {
+	if c.DeletesDisabled() {
+		return ErrDeleteDisabled
+	}
 	path := fmt.Sprintf("/v2/%s/manifests/%s", repoName, digest)
-	resp, err := c.doRequest("DELETE", path, nil)
+	resp, err := c.doRequest(ctx, "DELETE", path, nil)
 	if err != nil {
 		return fmt.Errorf("failed to delete manifest: %w", err)
 	}
@@ -250,17 +480,34 @@ func (c *Client) DeleteManifest(repoName, digest string) error {
 		body, _ := io.ReadAll(resp.Body)
 		return fmt.Errorf("registry returned status %d: %s", resp.StatusCode, string(body))
 	}
+	c.forgetManifest(repoName, digest)
 	return nil
 }
 
-// GetDigestForTag returns the digest for a specific tag
-func (c *Client) GetDigestForTag(repoName, tag string) (string, error) {
+// GetDigestForTag returns the digest for a specific tag. Registries whose profile says HEAD
+// requests lack a digest header are asked for the manifest instead, and its digest is computed.
+func (c *Client) GetDigestForTag(ctx context.Context, repoName, tag string) (string, error) {
+	if c.profileLacks(func(p *models.RegistryProfile) bool { return p.HeadDigest && p.DigestMatches }) {
+		return c.computeDigest(ctx, repoName, tag)
+	}
+	digest, err := c.headDigest(ctx, repoName, tag)
+	if err != nil {
+		return "", err
+	}
+	if _, _, ok := c.cachedManifest(repoName, digest); ok {
+		c.storeTagDigest(repoName, tag, digest)
+	}
+	return digest, nil
+}
+
+// headDigest asks the registry for the digest of a tag with a HEAD request
+func (c *Client) headDigest(ctx context.Context, repoName, tag string) (string, error) {
 	path := fmt.Sprintf("/v2/%s/manifests/%s", repoName, tag)
 	headers := map[string]string{
-		"Accept": "application/vnd.docker.distribution.manifest.v2+json, application/vnd.oci.image.manifest.v1+json",
+		"Accept": manifestAccept,
 	}
 
-	resp, err := c.doRequest("HEAD", path, headers)
+	resp, err := c.doRequest(ctx, "HEAD", path, headers)
 	if err != nil {
 		return "", fmt.Errorf("failed to get digest: %w", err)
 	}
@@ -273,36 +520,106 @@ func (c *Client) GetDigestForTag(repoName, tag string) (string, error) {
 	return digest, nil
 }
 
+// computeDigest downloads a manifest and returns its sha256 digest
+func (c *Client) computeDigest(ctx context.Context, repoName, reference string) (string, error) {
+	body, _, _, err := c.GetRawManifest(ctx, repoName, reference)
+	if err != nil {
+		return "", fmt.Errorf("failed to get digest: %w", err)
+	}
+	sum := sha256.Sum256(body)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
 // GetImageCreated returns the creation time of an image tag
-func (c *Client) GetImageCreated(repoName, tag string) (time.Time, error) {
-	manifest, err := c.GetManifest(repoName, tag)
+// (for multi-arch images, of the default platform's image)
+func (c *Client) GetImageCreated(ctx context.Context, repoName, tag string) (time.Time, error) {
+	config, err := c.GetImageConfig(ctx, repoName, tag, "")
 	if err != nil {
 		return time.Time{}, err
 	}
+	return config.Created, nil
+}
 
-	if manifest.Config == nil || manifest.Config.Digest == "" {
-		return time.Time{}, fmt.Errorf("manifest config digest missing")
-	}
+// rawImageConfig mirrors the parts of the OCI/Docker image config blob we expose
+type rawImageConfig struct {
+	Created      time.Time `json:"created"`
+	Author       string    `json:"author"`
+	Architecture string    `json:"architecture"`
+	OS           string    `json:"os"`
+	Config       struct {
+		User         string              `json:"User"`
+		ExposedPorts map[string]struct{} `json:"ExposedPorts"`
+		Env          []string            `json:"Env"`
+		Entrypoint   []string            `json:"Entrypoint"`
+		Cmd          []string            `json:"Cmd"`
+		WorkingDir   string              `json:"WorkingDir"`
+		Volumes      map[string]struct{} `json:"Volumes"`
+		Labels       map[string]string   `json:"Labels"`
+		StopSignal   string              `json:"StopSignal"`
+	} `json:"config"`
+	History []struct {
+		Created    time.Time `json:"created"`
+		CreatedBy  string    `json:"created_by"`
+		Comment    string    `json:"comment"`
+		EmptyLayer bool      `json:"empty_layer"`
+	} `json:"history"`
+}
 
-	// Fetch config blob
-	path := fmt.Sprintf("/v2/%s/blobs/%s", repoName, manifest.Config.Digest)
-	resp, err := c.doRequest("GET", path, nil)
+// GetImageConfig fetches and decodes the config blob of an image
+// (platform selects the image of a multi-arch tag, default linux/amd64)
+func (c *Client) GetImageConfig(ctx context.Context, repoName, tag, platform string) (*models.ImageConfig, error) {
+	manifest, err := c.ResolvePlatformManifest(ctx, repoName, tag, platform)
 	if err != nil {
-		return time.Time{}, fmt.Errorf("failed to fetch config blob: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return time.Time{}, fmt.Errorf("blob fetch failed with status %d", resp.StatusCode)
+	if manifest.Config == nil || manifest.Config.Digest == "" {
+		return nil, fmt.Errorf("manifest config digest missing")
 	}
 
-	var config struct {
-		Created time.Time `json:"created"`
+	// Fetch config blob
+	data, err := c.getBlob(ctx, repoName, manifest.Config.Digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch config blob: %w", err)
+	}
+
+	var raw rawImageConfig
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode image config: %w", err)
+	}
+
+	config := &models.ImageConfig{
+		Digest:       manifest.Config.Digest,
+		Created:      raw.Created,
+		Author:       raw.Author,
+		Architecture: raw.Architecture,
+		OS:           raw.OS,
+		User:         raw.Config.User,
+		ExposedPorts: sortedKeys(raw.Config.ExposedPorts),
+		Env:          raw.Config.Env,
+		Entrypoint:   raw.Config.Entrypoint,
+		Cmd:          raw.Config.Cmd,
+		WorkingDir:   raw.Config.WorkingDir,
+		Volumes:      sortedKeys(raw.Config.Volumes),
+		StopSignal:   raw.Config.StopSignal,
+		Labels:       raw.Config.Labels,
+	}
+	for _, h := range raw.History {
+		config.History = append(config.History, models.ImageHistory{
+			Created:    h.Created,
+			CreatedBy:  h.CreatedBy,
+			Comment:    h.Comment,
+			EmptyLayer: h.EmptyLayer,
+		})
 	}
+	return config, nil
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(&config); err != nil {
-		return time.Time{}, fmt.Errorf("failed to decode image config: %w", err)
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
 	}
-
-	return config.Created, nil
+	sort.Strings(keys)
+	return keys
 }
@@ -0,0 +1,444 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"docker-registry-dashboard/internal/docker"
+	"docker-registry-dashboard/internal/gcp"
+	"docker-registry-dashboard/internal/models"
+)
+
+// validateWait is how long a throwaway registry must stay up for its config to count as loadable
+const validateWait = 3 * time.Second
+
+// secretConfigKeys are config.yml keys whose values are masked in previews
+var secretConfigKeys = map[string]bool{
+	"accesskey":  true,
+	"secretkey":  true,
+	"password":   true,
+	"secret":     true,
+	"privatekey": true,
+	"token":      true,
+}
+
+var configFuncs = template.FuncMap{
+	// quote emits a double-quoted YAML scalar, escaping quotes and backslashes in user input
+	"quote": strconv.Quote,
+	// redisAddr is the Redis of the blob-descriptor cache, the managed container without an address
+	"redisAddr": func(addr string) string {
+		if addr == "" {
+			return managedRedisAddr
+		}
+		return addr
+	},
+	// distribution3 tells the config of a distribution 3 image from registry:2's
+	"distribution3": func(image string) bool {
+		return image != "" && isDistribution3(image)
+	},
+	"scheme": func(ssl bool) string {
+		if ssl {
+			return "s"
+		}
+		return ""
+	},
+}
+
+// configData is what the config template renders
+type configData struct {
+	*models.StorageConfig
+	RegistryAuth
+}
+
+// RenderConfig renders the registry config.yml for the given storage settings and authentication
+func RenderConfig(config *models.StorageConfig, auth RegistryAuth) ([]byte, error) {
+	if config == nil {
+		config = &models.StorageConfig{Type: "local"}
+	}
+	if config.Type == "" {
+		config.Type = "local"
+	}
+
+	tmpl, err := template.New("registry-config").Funcs(configFuncs).Parse(registryConfigTmpl)
+	if err != nil {
+		return nil, fmt.Errorf("template parse error: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, configData{StorageConfig: config, RegistryAuth: auth}); err != nil {
+		return nil, fmt.Errorf("template exec error: %w", err)
+	}
+	if strings.TrimSpace(config.ConfigOverrides) == "" {
+		return buf.Bytes(), nil
+	}
+	return mergeConfigOverrides(buf.Bytes(), config.ConfigOverrides)
+}
+
+// ErrInvalidOverrides is returned by RenderConfig when the config overrides are not a YAML mapping
+var ErrInvalidOverrides = errors.New("invalid config overrides")
+
+// managedConfigKeys are config.yml settings the dashboard relies on, which overrides must not change
+var managedConfigKeys = [][]string{
+	{"version"},
+	{"http", "addr"},
+	{"http", "tls"},
+	{"auth"},
+	{"notifications"},
+}
+
+// parseConfigOverrides parses the overrides, which must be a YAML mapping
+func parseConfigOverrides(overrides string) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(overrides), &doc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOverrides, err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%w: expected a mapping such as \"validation:\"", ErrInvalidOverrides)
+	}
+	return doc.Content[0], nil
+}
+
+// mergeConfigOverrides deep-merges the overrides into the rendered config: mappings are merged key
+// by key, anything else in the overrides replaces the generated value
+func mergeConfigOverrides(rendered []byte, overrides string) ([]byte, error) {
+	override, err := parseConfigOverrides(overrides)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(rendered, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse the generated config: %w", err)
+	}
+	mergeMapping(doc.Content[0], override)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to write the merged config: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// mergeMapping merges the entries of the mapping node src into dst
+func mergeMapping(dst, src *yaml.Node) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		blockStyle(value)
+		existing := mappingValue(dst, key.Value)
+		switch {
+		case existing == nil:
+			dst.Content = append(dst.Content, key, value)
+		case existing.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			mergeMapping(existing, value)
+		default:
+			*existing = *value
+		}
+	}
+}
+
+// mappingValue returns the value of a key of a mapping node, nil when it has none
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// blockStyle writes mappings in block style, which LintConfig understands, even when the
+// overrides use flow style
+func blockStyle(n *yaml.Node) {
+	if n.Kind == yaml.MappingNode {
+		n.Style &^= yaml.FlowStyle
+	}
+	for _, child := range n.Content {
+		blockStyle(child)
+	}
+}
+
+// overrideProblems reports overrides that do not parse or change settings the dashboard manages
+func overrideProblems(overrides string) []string {
+	override, err := parseConfigOverrides(overrides)
+	if err != nil {
+		return []string{"config overrides: " + strings.TrimPrefix(err.Error(), ErrInvalidOverrides.Error()+": ")}
+	}
+	problems := []string{}
+	for _, path := range managedConfigKeys {
+		node := override
+		for _, key := range path {
+			if node = mappingValue(node, key); node == nil {
+				break
+			}
+		}
+		if node != nil {
+			problems = append(problems, fmt.Sprintf("config overrides may not change %s, which the dashboard manages", strings.Join(path, ".")))
+		}
+	}
+	return problems
+}
+
+// MaskConfigSecrets replaces the values of secret keys in a rendered config with asterisks
+func MaskConfigSecrets(data []byte) string {
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(value) == "" {
+			continue
+		}
+		if secretConfigKeys[strings.ToLower(strings.TrimSpace(key))] {
+			lines[i] = key + `: "********"`
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// SettingsProblems checks storage settings the registry would only reject at runtime
+func SettingsProblems(config *models.StorageConfig) []string {
+	problems := []string{}
+	if config == nil {
+		return problems
+	}
+	if config.ProxyRemoteURL != "" {
+		u, err := url.Parse(config.ProxyRemoteURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problems = append(problems, "proxy remote URL must be an http(s) URL such as https://registry-1.docker.io")
+		}
+	}
+	if config.ProxyPassword != "" && config.ProxyUsername == "" {
+		problems = append(problems, "proxy password is set without a username")
+	}
+	if config.Type == "gcs" {
+		if config.GCSBucket == "" {
+			problems = append(problems, "GCS bucket is required")
+		}
+		if config.GCSCredentials != "" {
+			if _, err := gcp.ParseKey(config.GCSCredentials); err != nil {
+				problems = append(problems, "GCS credentials: "+err.Error())
+			}
+		}
+	}
+	if config.RedisCache {
+		if config.RedisAddr != "" {
+			if _, port, err := net.SplitHostPort(config.RedisAddr); err != nil || port == "" {
+				problems = append(problems, "Redis address must be host:port, such as redis.internal:6379")
+			}
+		}
+		if config.RedisDB < 0 || config.RedisDB > 15 {
+			problems = append(problems, "Redis database must be between 0 and 15")
+		}
+	}
+	if config.RegistryImage != "" {
+		if _, _, _, err := ParseImageReference(config.RegistryImage); err != nil {
+			problems = append(problems, "registry image: "+err.Error())
+		} else if config.Type == "swift" && isDistribution3(config.RegistryImage) {
+			problems = append(problems, "distribution 3 has no Swift storage driver; use a registry:2 image")
+		}
+	}
+	if strings.TrimSpace(config.ConfigOverrides) != "" {
+		problems = append(problems, overrideProblems(config.ConfigOverrides)...)
+	}
+	if config.Type == "swift" {
+		if u, err := url.Parse(config.SwiftAuthURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problems = append(problems, "Swift auth URL must be the http(s) URL of Keystone, such as https://keystone.example.com/v3")
+		}
+		if config.SwiftUsername == "" || config.SwiftPassword == "" {
+			problems = append(problems, "Swift username and password are required")
+		}
+		if config.SwiftContainer == "" {
+			problems = append(problems, "Swift container is required")
+		}
+	}
+	return problems
+}
+
+// LintConfig parses the block-style YAML subset used by the config template and returns
+// the problems found (tab indentation, bad nesting, malformed entries, unterminated quotes)
+func LintConfig(data []byte) []string {
+	problems := []string{}
+	indents := []int{0}
+	expectChild := false
+
+	for n, line := range strings.Split(string(data), "\n") {
+		lineNo := n + 1
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		body := strings.TrimLeft(line, " ")
+		indent := len(line) - len(body)
+		if strings.HasPrefix(body, "\t") {
+			problems = append(problems, fmt.Sprintf("line %d: tabs are not allowed for indentation", lineNo))
+			continue
+		}
+
+		top := indents[len(indents)-1]
+		switch {
+		case expectChild && indent > top:
+			indents = append(indents, indent)
+		case expectChild && !(indent == top && strings.HasPrefix(body, "- ")):
+			problems = append(problems, fmt.Sprintf("line %d: expected an indented value for the previous key", lineNo))
+		case !expectChild && indent > top:
+			problems = append(problems, fmt.Sprintf("line %d: unexpected indentation", lineNo))
+			continue
+		}
+		for len(indents) > 1 && indent < indents[len(indents)-1] {
+			indents = indents[:len(indents)-1]
+		}
+		if indent != indents[len(indents)-1] {
+			problems = append(problems, fmt.Sprintf("line %d: indentation does not match any parent level", lineNo))
+		}
+
+		entry := body
+		isItem := strings.HasPrefix(entry, "- ")
+		if isItem {
+			entry = strings.TrimSpace(entry[2:])
+		}
+		key, value, hasColon := strings.Cut(entry, ":")
+		if !hasColon || strings.HasPrefix(entry, `"`) || strings.HasPrefix(entry, "'") {
+			expectChild = false
+			if !isItem {
+				problems = append(problems, fmt.Sprintf("line %d: expected \"key: value\"", lineNo))
+				continue
+			}
+			value = entry
+		} else if strings.TrimSpace(key) == "" {
+			problems = append(problems, fmt.Sprintf("line %d: missing key", lineNo))
+			continue
+		} else {
+			expectChild = strings.TrimSpace(value) == ""
+		}
+
+		if msg := lintScalar(strings.TrimSpace(value)); msg != "" {
+			problems = append(problems, fmt.Sprintf("line %d: %s", lineNo, msg))
+		}
+	}
+	if expectChild {
+		problems = append(problems, "last key has no value")
+	}
+	return problems
+}
+
+// lintScalar checks quoting and flow-sequence brackets of a single value
+func lintScalar(value string) string {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		if _, err := strconv.Unquote(value); err != nil {
+			return "malformed double-quoted value"
+		}
+	case strings.HasPrefix(value, "'"):
+		inner := value[1:]
+		if !strings.HasSuffix(inner, "'") || strings.Count(strings.ReplaceAll(inner[:len(inner)-1], "''", ""), "'") > 0 {
+			return "malformed single-quoted value"
+		}
+	case strings.HasPrefix(value, "["):
+		if !strings.HasSuffix(value, "]") {
+			return "unterminated flow sequence"
+		}
+	}
+	return ""
+}
+
+// ValidateConfig renders and lints the config for the given storage settings and, when Docker
+// is available, checks that a throwaway registry:2 container can start with it. An in-process
+// registry instead checks that its storage driver can be created.
+func (r *EmbeddedRegistry) ValidateConfig(config *models.StorageConfig) (*models.RegistryConfigCheck, error) {
+	rendered, err := RenderConfig(config, r.Auth())
+	if errors.Is(err, ErrInvalidOverrides) {
+		return &models.RegistryConfigCheck{Problems: SettingsProblems(config)}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	check := &models.RegistryConfigCheck{
+		Config:   MaskConfigSecrets(rendered),
+		Problems: append(SettingsProblems(config), LintConfig(rendered)...),
+	}
+	if len(check.Problems) == 0 && r.inProcess {
+		if problem := r.validateInProcess(rendered, config); problem != "" {
+			check.Problems = append(check.Problems, problem)
+		}
+	} else if len(check.Problems) == 0 && r.IsDockerAvailable() {
+		problem, err := r.validateInContainer(rendered, config)
+		if err != nil {
+			return nil, err
+		}
+		check.ContainerChecked = true
+		if problem != "" {
+			check.Problems = append(check.Problems, problem)
+		}
+	}
+	check.Valid = len(check.Problems) == 0
+	return check, nil
+}
+
+// validateInContainer starts a throwaway registry container (no published ports, no data volume)
+// with the rendered config and returns its logs as a problem if it exits during startup
+func (r *EmbeddedRegistry) validateInContainer(rendered []byte, config *models.StorageConfig) (string, error) {
+	// The registry needs the htpasswd file, the certificate of the token service and the GCS key as well
+	files, err := r.configFiles(rendered, config)
+	if err != nil {
+		return "", err
+	}
+
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	name := r.Container() + "-validate-" + hex.EncodeToString(suffix)
+	defer r.docker.RemoveContainer(context.Background(), name)
+
+	spec := docker.ContainerSpec{Image: registryImageOf(config), Cmd: registryCmd}
+	create := func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), dockerTimeout)
+		defer cancel()
+		_, err := r.docker.CreateContainer(ctx, name, spec)
+		return err
+	}
+	err = create()
+	if errors.Is(err, docker.ErrNotFound) {
+		// The image was not pulled yet
+		pullCtx, cancelPull := context.WithTimeout(context.Background(), 10*time.Minute)
+		err = r.docker.PullImage(pullCtx, spec.Image)
+		cancelPull()
+		if err == nil {
+			err = create()
+		}
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to create validation container: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dockerTimeout+validateWait)
+	defer cancel()
+	if err := r.docker.CopyToContainer(ctx, name, "/etc/docker/registry", files); err != nil {
+		return "", fmt.Errorf("failed to copy the config into the validation container: %w", err)
+	}
+	if err := r.docker.StartContainer(ctx, name); err != nil {
+		return "", fmt.Errorf("failed to start validation container: %w", err)
+	}
+
+	deadline := time.Now().Add(validateWait)
+	for time.Now().Before(deadline) {
+		time.Sleep(500 * time.Millisecond)
+		state, err := r.docker.InspectContainer(ctx, name)
+		if err == nil && state.Running {
+			continue
+		}
+		logOut, _ := r.docker.Logs(ctx, name, 20)
+		return "registry rejected the config: " + strings.TrimSpace(logOut), nil
+	}
+	return "", nil
+}
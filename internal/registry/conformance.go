@@ -0,0 +1,284 @@
+package registry
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// Conformance check statuses
+const (
+	CheckPass = "pass"
+	CheckFail = "fail"
+	CheckSkip = "skip"
+)
+
+// ErrDeleteDisabled is returned without contacting a registry whose profile says it rejects deletes
+var ErrDeleteDisabled = errors.New("registry does not allow deletes (enable REGISTRY_STORAGE_DELETE_ENABLED)")
+
+// maxProbeRepositories bounds how many repositories the probe looks at for one with tags
+const maxProbeRepositories = 20
+
+// absentDigest is a well-formed digest no registry stores, used to test deletes without removing anything
+var absentDigest = "sha256:" + strings.Repeat("0", 64)
+
+var (
+	profilesMu sync.RWMutex
+	profiles   = map[string]*models.RegistryProfile{}
+)
+
+// SetProfile records the capability profile of a registry URL for all its clients; nil forgets it
+func SetProfile(url string, p *models.RegistryProfile) {
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	if p == nil {
+		delete(profiles, breakerKey(url))
+		return
+	}
+	profiles[breakerKey(url)] = p
+}
+
+// profileFor returns the capability profile of a registry URL, nil until it has been probed
+func profileFor(url string) *models.RegistryProfile {
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+	return profiles[breakerKey(url)]
+}
+
+// profileLacks reports whether a probed registry is known not to support a feature;
+// unprobed registries are assumed to support everything
+func (c *Client) profileLacks(supported func(p *models.RegistryProfile) bool) bool {
+	p := profileFor(c.baseURL)
+	return p != nil && !supported(p)
+}
+
+// DeletesDisabled reports whether the registry's profile says it rejects deletes
+func (c *Client) DeletesDisabled() bool {
+	return c.profileLacks(func(p *models.RegistryProfile) bool { return p.DeleteEnabled })
+}
+
+// ProbeConformance runs a set of OCI distribution spec checks against the registry and returns
+// its capability profile. The probe only reads, except for DELETE requests on a digest and a tag
+// that do not exist, so it is safe to run against production registries.
+func (c *Client) ProbeConformance(ctx context.Context) (*models.RegistryProfile, error) {
+	p := &models.RegistryProfile{Checks: []models.ConformanceCheck{}, ProbedAt: time.Now()}
+	check := func(name, status, detail string) bool {
+		p.Checks = append(p.Checks, models.ConformanceCheck{Name: name, Status: status, Detail: detail})
+		return status == CheckPass
+	}
+
+	// Base endpoint
+	resp, err := c.doRequest(ctx, "GET", "/v2/", nil)
+	if err != nil {
+		return nil, fmt.Errorf("connection failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("authentication failed (401)")
+	}
+	p.APIVersion = resp.Header.Get("Docker-Distribution-API-Version")
+	if resp.StatusCode == http.StatusOK {
+		check("base", CheckPass, "GET /v2/ returned 200")
+	} else {
+		check("base", CheckFail, fmt.Sprintf("GET /v2/ returned %d", resp.StatusCode))
+	}
+	if p.APIVersion != "" {
+		check("api_version", CheckPass, p.APIVersion)
+	} else {
+		check("api_version", CheckFail, "no Docker-Distribution-API-Version header")
+	}
+	if info, err := c.harborSystemInfo(ctx); err == nil {
+		p.Harbor = info
+	}
+
+	// Catalog and its pagination
+	repos, err := c.ListRepositories(ctx)
+	if err != nil {
+		check("catalog", CheckFail, err.Error())
+	} else {
+		p.Catalog = check("catalog", CheckPass, fmt.Sprintf("%d repositories", len(repos)))
+	}
+	if len(repos) < 2 {
+		check("catalog_pagination", CheckSkip, "needs at least two repositories")
+	} else {
+		p.CatalogPagination = c.checkPagination(ctx, "/v2/_catalog?n=1", "repositories", check, "catalog_pagination")
+	}
+
+	// Sample image: the first repository with tags, preferring one with several tags
+	var repo, tag string
+	var tagCount int
+	for i, r := range repos {
+		if i == maxProbeRepositories {
+			break
+		}
+		tags, err := c.ListTags(ctx, r.Name)
+		if err != nil || len(tags) == 0 {
+			continue
+		}
+		if repo == "" || (tagCount < 2 && len(tags) >= 2) {
+			repo, tag, tagCount = r.Name, tags[0].Name, len(tags)
+		}
+		if tagCount >= 2 {
+			break
+		}
+	}
+	if repo == "" {
+		for _, name := range []string{"tag_pagination", "head_digest", "digest_matches", "delete_enabled", "tag_delete", "referrers"} {
+			check(name, CheckSkip, "no tagged repository to test against")
+		}
+		return p, nil
+	}
+
+	if tagCount < 2 {
+		check("tag_pagination", CheckSkip, "needs a repository with at least two tags")
+	} else {
+		p.TagPagination = c.checkPagination(ctx, fmt.Sprintf("/v2/%s/tags/list?n=1", repo), "tags", check, "tag_pagination")
+	}
+
+	// Digests: HEAD must carry Docker-Content-Digest and it must be the sha256 of the manifest
+	manifestPath := fmt.Sprintf("/v2/%s/manifests/%s", repo, tag)
+	var headDigest string
+	if resp, err := c.doRequest(ctx, "HEAD", manifestPath, map[string]string{"Accept": manifestAccept}); err != nil {
+		check("head_digest", CheckFail, err.Error())
+	} else {
+		resp.Body.Close()
+		headDigest = resp.Header.Get("Docker-Content-Digest")
+		switch {
+		case resp.StatusCode != http.StatusOK:
+			check("head_digest", CheckFail, fmt.Sprintf("HEAD %s:%s returned %d", repo, tag, resp.StatusCode))
+		case headDigest == "":
+			check("head_digest", CheckFail, "no Docker-Content-Digest header on HEAD")
+		default:
+			p.HeadDigest = check("head_digest", CheckPass, fmt.Sprintf("%s:%s → %s", repo, tag, headDigest))
+		}
+	}
+	body, _, getDigest, _, err := c.fetchManifest(ctx, repo, tag, "")
+	if err != nil {
+		check("digest_matches", CheckFail, err.Error())
+	} else {
+		sum := sha256.Sum256(body)
+		computed := "sha256:" + hex.EncodeToString(sum[:])
+		reported := headDigest
+		if reported == "" {
+			reported = getDigest
+		}
+		if reported == computed {
+			p.DigestMatches = check("digest_matches", CheckPass, "reported digest is the sha256 of the manifest")
+		} else {
+			check("digest_matches", CheckFail, fmt.Sprintf("reported %q, manifest hashes to %s", reported, computed))
+		}
+	}
+
+	// Deletes, on a digest and a tag that do not exist
+	status, err := c.probeStatus(ctx, "DELETE", fmt.Sprintf("/v2/%s/manifests/%s", repo, absentDigest))
+	switch {
+	case err != nil:
+		check("delete_enabled", CheckFail, err.Error())
+	case status == http.StatusNotFound || status == http.StatusAccepted:
+		p.DeleteEnabled = check("delete_enabled", CheckPass, fmt.Sprintf("DELETE of an unknown digest returned %d", status))
+	case status == http.StatusMethodNotAllowed:
+		check("delete_enabled", CheckFail, "deletes are disabled (405)")
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		check("delete_enabled", CheckFail, fmt.Sprintf("deletes are not permitted for these credentials (%d)", status))
+	default:
+		check("delete_enabled", CheckFail, fmt.Sprintf("DELETE of an unknown digest returned %d", status))
+	}
+	if !p.DeleteEnabled {
+		check("tag_delete", CheckSkip, "deletes are not available")
+	} else {
+		status, err := c.probeStatus(ctx, "DELETE", fmt.Sprintf("/v2/%s/manifests/%s", repo, probeTag()))
+		switch {
+		case err != nil:
+			check("tag_delete", CheckFail, err.Error())
+		case status == http.StatusNotFound || status == http.StatusAccepted:
+			p.TagDelete = check("tag_delete", CheckPass, fmt.Sprintf("DELETE of an unknown tag returned %d", status))
+		default:
+			check("tag_delete", CheckFail, fmt.Sprintf("DELETE by tag returned %d; tags can only be removed by digest", status))
+		}
+	}
+
+	// Referrers API
+	digest := headDigest
+	if digest == "" {
+		digest = getDigest
+	}
+	if digest == "" {
+		check("referrers", CheckSkip, "no manifest digest to query")
+	} else {
+		status, err := c.probeStatus(ctx, "GET", fmt.Sprintf("/v2/%s/referrers/%s", repo, digest))
+		switch {
+		case err != nil:
+			check("referrers", CheckFail, err.Error())
+		case status == http.StatusOK:
+			p.Referrers = check("referrers", CheckPass, "GET /referrers returned 200")
+		default:
+			check("referrers", CheckFail, fmt.Sprintf("GET /referrers returned %d", status))
+		}
+	}
+	return p, nil
+}
+
+// checkPagination requests one item of a paginated listing and checks that the registry honors n=1
+// and links to the next page
+func (c *Client) checkPagination(ctx context.Context, path, field string, check func(name, status, detail string) bool, name string) bool {
+	resp, err := c.doRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return check(name, CheckFail, err.Error())
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return check(name, CheckFail, fmt.Sprintf("GET %s returned %d", path, resp.StatusCode))
+	}
+
+	var page map[string]json.RawMessage
+	var items []string
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return check(name, CheckFail, fmt.Sprintf("failed to decode response: %v", err))
+	}
+	json.Unmarshal(page[field], &items)
+	switch {
+	case len(items) > 1:
+		return check(name, CheckFail, fmt.Sprintf("n=1 returned %d %s", len(items), field))
+	case nextLink(resp.Header.Get("Link")) == "":
+		return check(name, CheckFail, "no Link header to the next page")
+	}
+	return check(name, CheckPass, "n=1 honored with a Link to the next page")
+}
+
+// probeStatus sends a body-less request and returns its status code
+func (c *Client) probeStatus(ctx context.Context, method, path string) (int, error) {
+	resp, err := c.doRequest(ctx, method, path, map[string]string{"Accept": manifestAccept + ", " + MediaTypeOCIIndex})
+	if err != nil {
+		return 0, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// nextLink returns the target of a Link header's rel="next", or ""
+func nextLink(link string) string {
+	parts := strings.Split(link, ";")
+	if len(parts) >= 2 && strings.Contains(parts[1], `rel="next"`) {
+		return strings.Trim(parts[0], " <>")
+	}
+	return ""
+}
+
+// probeTag returns a random tag name that does not exist
+func probeTag() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return "conformance-probe-" + hex.EncodeToString(b)
+}
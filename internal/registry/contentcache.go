@@ -0,0 +1,223 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"docker-registry-dashboard/internal/cache"
+	"docker-registry-dashboard/internal/models"
+)
+
+// contentCacheTTL is how long manifests and config blobs are kept. Content addressed by digest
+// never changes, so the TTL only bounds the memory they take.
+const contentCacheTTL = time.Hour
+
+var (
+	contentCacheMu sync.RWMutex
+	contentCache   cache.Cache // nil: manifests and config blobs are always fetched
+)
+
+// SetContentCache sets where manifests and config blobs are cached by digest, with the last
+// digest seen for each tag; nil disables the cache
+func SetContentCache(c cache.Cache) {
+	contentCacheMu.Lock()
+	defer contentCacheMu.Unlock()
+	contentCache = c
+}
+
+func getContentCache() cache.Cache {
+	contentCacheMu.RLock()
+	defer contentCacheMu.RUnlock()
+	return contentCache
+}
+
+// contentKey names a cached entry of the client's registry and credentials
+func (c *Client) contentKey(kind, repoName, reference string) string {
+	return kind + ":" + breakerKey(c.baseURL) + "\x00" + c.username + "\x00" + repoName + "\x00" + reference
+}
+
+func isDigest(reference string) bool {
+	return strings.Contains(reference, ":")
+}
+
+// cachedManifest returns a cached manifest and its media type by digest
+func (c *Client) cachedManifest(repoName, digest string) ([]byte, string, bool) {
+	store := getContentCache()
+	if store == nil || digest == "" {
+		return nil, "", false
+	}
+	data, ok := store.Get(c.contentKey("manifest", repoName, digest))
+	if !ok {
+		return nil, "", false
+	}
+	mediaType, body, ok := bytes.Cut(data, []byte("\n"))
+	if !ok {
+		return nil, "", false
+	}
+	return body, string(mediaType), true
+}
+
+// storeManifest caches a manifest by digest and, when fetched by tag, the tag's digest
+func (c *Client) storeManifest(repoName, reference, digest, mediaType string, body []byte) {
+	store := getContentCache()
+	if store == nil || digest == "" {
+		return
+	}
+	data := make([]byte, 0, len(mediaType)+1+len(body))
+	data = append(append(append(data, mediaType...), '\n'), body...)
+	store.Set(c.contentKey("manifest", repoName, digest), data, contentCacheTTL)
+	if !isDigest(reference) {
+		c.storeTagDigest(repoName, reference, digest)
+	}
+}
+
+// storeTagDigest remembers the digest a tag last pointed at
+func (c *Client) storeTagDigest(repoName, tag, digest string) {
+	if store := getContentCache(); store != nil && digest != "" {
+		store.Set(c.contentKey("tag", repoName, tag), []byte(digest), contentCacheTTL)
+	}
+}
+
+// lastTagDigest returns the digest a tag last pointed at, if its manifest is still cached
+func (c *Client) lastTagDigest(repoName, tag string) string {
+	store := getContentCache()
+	if store == nil {
+		return ""
+	}
+	digest, ok := store.Get(c.contentKey("tag", repoName, tag))
+	if !ok {
+		return ""
+	}
+	if _, _, ok := c.cachedManifest(repoName, string(digest)); !ok {
+		return ""
+	}
+	return string(digest)
+}
+
+// forgetManifest drops a deleted manifest from the cache
+func (c *Client) forgetManifest(repoName, digest string) {
+	if store := getContentCache(); store != nil {
+		store.Delete(c.contentKey("manifest", repoName, digest))
+	}
+}
+
+// GetRawManifest returns a manifest exactly as stored, with its media type and digest. Manifests
+// are cached by digest. A tag whose digest was seen before is checked with a HEAD request, or a
+// conditional GET on registries whose HEAD answers lack a usable digest, before it is downloaded again.
+func (c *Client) GetRawManifest(ctx context.Context, repoName, reference string) ([]byte, string, string, error) {
+	if isDigest(reference) {
+		if body, mediaType, ok := c.cachedManifest(repoName, reference); ok {
+			return body, mediaType, reference, nil
+		}
+	}
+
+	known := ""
+	if !isDigest(reference) {
+		known = c.lastTagDigest(repoName, reference)
+	}
+	if known != "" && !c.profileLacks(func(p *models.RegistryProfile) bool { return p.HeadDigest && p.DigestMatches }) {
+		if digest, err := c.headDigest(ctx, repoName, reference); err == nil {
+			if body, mediaType, ok := c.cachedManifest(repoName, digest); ok {
+				c.storeTagDigest(repoName, reference, digest)
+				return body, mediaType, digest, nil
+			}
+			known = ""
+		}
+	}
+
+	body, mediaType, digest, notModified, err := c.fetchManifest(ctx, repoName, reference, known)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if notModified {
+		if cached, cachedType, ok := c.cachedManifest(repoName, known); ok {
+			return cached, cachedType, known, nil
+		}
+		if body, mediaType, digest, _, err = c.fetchManifest(ctx, repoName, reference, ""); err != nil {
+			return nil, "", "", err
+		}
+	}
+	if digest == "" {
+		sum := sha256.Sum256(body)
+		digest = "sha256:" + hex.EncodeToString(sum[:])
+	}
+	c.storeManifest(repoName, reference, digest, mediaType, body)
+	return body, mediaType, digest, nil
+}
+
+// fetchManifest downloads a manifest from the registry, bypassing the cache. With a known digest
+// the GET is conditional and notModified reports a 304 answer.
+func (c *Client) fetchManifest(ctx context.Context, repoName, reference, known string) (body []byte, mediaType, digest string, notModified bool, err error) {
+	headers := map[string]string{"Accept": manifestAccept}
+	if known != "" {
+		headers["If-None-Match"] = `"` + known + `"`
+	}
+	resp, err := c.doRequest(ctx, "GET", fmt.Sprintf("/v2/%s/manifests/%s", repoName, reference), headers)
+	if err != nil {
+		return nil, "", "", false, fmt.Errorf("failed to get manifest: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotModified && known != "" {
+		return nil, "", "", true, nil
+	}
+
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", "", false, fmt.Errorf("failed to read manifest body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", "", false, fmt.Errorf("registry returned status %d: %s", resp.StatusCode, string(body))
+	}
+	mediaType = strings.TrimSpace(strings.Split(resp.Header.Get("Content-Type"), ";")[0])
+	return body, mediaType, resp.Header.Get("Docker-Content-Digest"), false, nil
+}
+
+// getBlob returns a small blob such as an image config, cached by digest
+func (c *Client) getBlob(ctx context.Context, repoName, digest string) ([]byte, error) {
+	store := getContentCache()
+	key := c.contentKey("blob", repoName, digest)
+	if store != nil {
+		if data, ok := store.Get(key); ok {
+			return data, nil
+		}
+	}
+
+	resp, err := c.doRequest(ctx, "GET", fmt.Sprintf("/v2/%s/blobs/%s", repoName, digest), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("blob fetch failed with status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	// Only content that matches its digest is kept
+	if sum := sha256.Sum256(data); store != nil && digest == "sha256:"+hex.EncodeToString(sum[:]) {
+		store.Set(key, data, contentCacheTTL)
+	}
+	return data, nil
+}
+
+// getVerifiedBlob is getBlob for content that has to match its digest, such as signature envelopes
+// and attestations
+func (c *Client) getVerifiedBlob(ctx context.Context, repoName, digest string) ([]byte, error) {
+	data, err := c.getBlob(ctx, repoName, digest)
+	if err != nil {
+		return nil, err
+	}
+	if sum := sha256.Sum256(data); digest != "sha256:"+hex.EncodeToString(sum[:]) {
+		return nil, fmt.Errorf("blob %s does not match its digest", digest)
+	}
+	return data, nil
+}
@@ -0,0 +1,176 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CopyStats is the progress of an image copy
+type CopyStats struct {
+	Manifests    int
+	TotalBlobs   int
+	CopiedBlobs  int
+	SkippedBlobs int
+	TotalBytes   int64
+	CopiedBytes  int64
+}
+
+// progressInterval is how many streamed bytes pass between progress callbacks
+const progressInterval = 1 << 20
+
+type descriptor struct {
+	MediaType string   `json:"mediaType"`
+	Size      int64    `json:"size"`
+	Digest    string   `json:"digest"`
+	URLs      []string `json:"urls,omitempty"`
+}
+
+// imageCopier copies manifests and blobs from one repository to another
+type imageCopier struct {
+	ctx        context.Context
+	src, dst   *Client
+	srcRepo    string
+	dstRepo    string
+	stats      CopyStats
+	onProgress func(CopyStats)
+	seenBlobs  map[string]bool
+}
+
+// CopyImage copies srcRepo:srcRef with all its platforms, layers and config blobs to dstRepo:dstTag
+// and returns the digest of the copied manifest. onProgress (optional) receives progress snapshots.
+func CopyImage(ctx context.Context, src *Client, srcRepo, srcRef string, dst *Client, dstRepo, dstTag string, onProgress func(CopyStats)) (string, error) {
+	c := &imageCopier{
+		ctx:        ctx,
+		src:        src,
+		dst:        dst,
+		srcRepo:    srcRepo,
+		dstRepo:    dstRepo,
+		onProgress: onProgress,
+		seenBlobs:  make(map[string]bool),
+	}
+	return c.copyManifest(srcRef, dstTag)
+}
+
+func (c *imageCopier) report() {
+	if c.onProgress != nil {
+		c.onProgress(c.stats)
+	}
+}
+
+// copyManifest copies a manifest (recursively for lists/indexes) and stores it under dstRef
+func (c *imageCopier) copyManifest(srcRef, dstRef string) (string, error) {
+	if err := c.ctx.Err(); err != nil {
+		return "", err
+	}
+
+	body, mediaType, _, err := c.src.GetRawManifest(c.ctx, c.srcRepo, srcRef)
+	if err != nil {
+		return "", err
+	}
+
+	var parsed struct {
+		MediaType string       `json:"mediaType"`
+		Config    *descriptor  `json:"config"`
+		Layers    []descriptor `json:"layers"`
+		Manifests []descriptor `json:"manifests"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return "", fmt.Errorf("failed to decode manifest %s: %w", srcRef, err)
+	}
+	if mediaType == "" {
+		mediaType = parsed.MediaType
+	}
+
+	if IsIndexMediaType(mediaType) || len(parsed.Manifests) > 0 {
+		// Children are pushed by digest before the index that references them
+		for _, child := range parsed.Manifests {
+			if _, err := c.copyManifest(child.Digest, child.Digest); err != nil {
+				return "", fmt.Errorf("failed to copy child manifest %s: %w", child.Digest, err)
+			}
+		}
+	} else {
+		blobs := parsed.Layers
+		if parsed.Config != nil {
+			blobs = append([]descriptor{*parsed.Config}, blobs...)
+		}
+		for _, blob := range blobs {
+			if err := c.copyBlob(blob); err != nil {
+				return "", fmt.Errorf("failed to copy blob %s: %w", blob.Digest, err)
+			}
+		}
+	}
+
+	digest, err := c.dst.PutManifest(c.ctx, c.dstRepo, dstRef, mediaType, body)
+	if err != nil {
+		return "", err
+	}
+	c.stats.Manifests++
+	c.report()
+	return digest, nil
+}
+
+func (c *imageCopier) copyBlob(blob descriptor) error {
+	if c.seenBlobs[blob.Digest] {
+		return nil
+	}
+	c.seenBlobs[blob.Digest] = true
+
+	// Foreign (non-distributable) layers are fetched from their URLs by clients, never pushed
+	if len(blob.URLs) > 0 || strings.Contains(blob.MediaType, "foreign") || strings.Contains(blob.MediaType, "nondistributable") {
+		return nil
+	}
+
+	c.stats.TotalBlobs++
+	c.stats.TotalBytes += blob.Size
+	c.report()
+
+	exists, err := c.dst.BlobExists(c.ctx, c.dstRepo, blob.Digest)
+	if err != nil {
+		return err
+	}
+	if !exists && c.src.SameRegistry(c.dst) && c.srcRepo != c.dstRepo {
+		if exists, err = c.dst.MountBlob(c.ctx, c.dstRepo, blob.Digest, c.srcRepo); err != nil {
+			return err
+		}
+	}
+	if exists {
+		c.stats.SkippedBlobs++
+		c.report()
+		return nil
+	}
+
+	content, err := c.src.OpenBlob(c.ctx, c.srcRepo, blob.Digest)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	counted := &progressReader{r: content, copier: c}
+	if err := c.dst.PushBlob(c.ctx, c.dstRepo, blob.Digest, blob.Size, counted); err != nil {
+		return err
+	}
+	c.stats.CopiedBlobs++
+	c.report()
+	return nil
+}
+
+// progressReader adds streamed bytes to the copy stats
+type progressReader struct {
+	r         io.Reader
+	copier    *imageCopier
+	sinceLast int64
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.copier.stats.CopiedBytes += int64(n)
+	p.sinceLast += int64(n)
+	if p.sinceLast >= progressInterval {
+		p.sinceLast = 0
+		p.copier.report()
+	}
+	return n, err
+}
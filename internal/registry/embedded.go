@@ -2,16 +2,21 @@ package registry
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
-	"text/template"
+	"sync/atomic"
 	"time"
 
+	"docker-registry-dashboard/internal/docker"
 	"docker-registry-dashboard/internal/models"
 )
 
@@ -27,18 +32,40 @@ log:
 storage:
 {{- if eq .Type "s3"}}
   s3:
-    accesskey: "{{ .S3AccessKey }}"
-    secretkey: "{{ .S3SecretKey }}"
-    region: "{{ .S3Region }}"
-    bucket: "{{ .S3Bucket }}"
+    accesskey: {{ quote .S3AccessKey }}
+    secretkey: {{ quote .S3SecretKey }}
+    region: {{ quote .S3Region }}
+    bucket: {{ quote .S3Bucket }}
 {{- if .S3Endpoint }}
-    regionendpoint: "http{{ if .S3UseSSL }}s{{ end }}://{{ .S3Endpoint }}"
+    regionendpoint: {{ quote (printf "http%s://%s" (scheme .S3UseSSL) .S3Endpoint) }}
 {{- end }}
     secure: {{ .S3UseSSL }}
     rootdirectory: /
+{{- else if eq .Type "gcs" }}
+  gcs:
+    bucket: {{ quote .GCSBucket }}
+{{- if .GCSCredentials }}
+    keyfile: /etc/docker/registry/gcs-key.json
+{{- end }}
+{{- if .GCSRootDirectory }}
+    rootdirectory: {{ quote .GCSRootDirectory }}
+{{- end }}
+{{- else if eq .Type "swift" }}
+  swift:
+    authurl: {{ quote .SwiftAuthURL }}
+    username: {{ quote .SwiftUsername }}
+    password: {{ quote .SwiftPassword }}
+    container: {{ quote .SwiftContainer }}
+{{- if .SwiftRegion }}
+    region: {{ quote .SwiftRegion }}
+{{- end }}
 {{- else }}
   filesystem:
     rootdirectory: /var/lib/registry
+{{- end }}
+{{- if .RedisCache }}
+  cache:
+    blobdescriptor: redis
 {{- end }}
   delete:
     enabled: true
@@ -50,21 +77,105 @@ storage:
       dryrun: false
 http:
   addr: :5000
+{{- if .ProxyRemoteURL }}
+  debug:
+    addr: localhost:5001
+{{- end }}
   headers:
     X-Content-Type-Options: [nosniff]
     Access-Control-Allow-Origin: ['*']
     Access-Control-Allow-Methods: ['HEAD', 'GET', 'OPTIONS', 'DELETE']
     Access-Control-Allow-Headers: ['Authorization', 'Accept', 'Cache-Control']
     Access-Control-Expose-Headers: ['Docker-Content-Digest']
+{{- if .TokenRealm }}
+auth:
+  token:
+    realm: {{ quote .TokenRealm }}
+    service: docker-registry-dashboard
+    issuer: docker-registry-dashboard
+    rootcertbundle: /etc/docker/registry/token.crt
+{{- else if .Htpasswd }}
+auth:
+  htpasswd:
+    realm: docker-registry-dashboard
+    path: /etc/docker/registry/htpasswd
+{{- end }}
+{{- if .ProxyRemoteURL }}
+proxy:
+  remoteurl: {{ quote .ProxyRemoteURL }}
+{{- if .ProxyUsername }}
+  username: {{ quote .ProxyUsername }}
+  password: {{ quote .ProxyPassword }}
+{{- end }}
+{{- end }}
+{{- if .RedisCache }}
+redis:
+{{- if distribution3 .RegistryImage }}
+  addrs: [{{ quote (redisAddr .RedisAddr) }}]
+{{- else }}
+  addr: {{ quote (redisAddr .RedisAddr) }}
+{{- end }}
+{{- if .RedisPassword }}
+  password: {{ quote .RedisPassword }}
+{{- end }}
+  db: {{ .RedisDB }}
+{{- end }}
 `
 
+// gcsKeyFile is the service account key of the GCS storage driver, copied next to config.yml
+const gcsKeyFile = "gcs-key.json"
+
+// proxyDebugURL serves the expvar counters, including the pull-through cache's, inside the
+// container; the debug server is only enabled in proxy mode
+const proxyDebugURL = "http://localhost:5001/debug/vars"
+
+// registryImage is the image the embedded registry and its config checks run unless the storage
+// config names another
+const registryImage = "registry:2"
+
+// RedisContainerName is the Redis the dashboard runs as the blob-descriptor cache of the primary
+// registry when the storage config enables the cache without giving a Redis address
+const RedisContainerName = ContainerName + "-redis"
+
+// redisImage is the image of the managed Redis container
+const redisImage = "redis:7-alpine"
+
+// managedRedisAddr is where the registry container reaches the managed Redis, through its link
+const managedRedisAddr = "redis:6379"
+
+// dockerTimeout bounds the Docker API calls other than pulls and garbage collection
+const dockerTimeout = 30 * time.Second
+
+// errNoDocker is returned when no Docker daemon is configured
+var errNoDocker = errors.New("Docker is not available. Please install and start Docker Desktop, or set DOCKER_HOST")
+
 // EmbeddedRegistry manages a Docker Registry V2 container
 type EmbeddedRegistry struct {
-	mu        sync.Mutex
-	baseDir   string
-	port      int
-	configDir string
-	dataDir   string
+	mu           sync.Mutex
+	baseDir      string
+	port         atomic.Int64
+	container    atomic.Value // string: name of the registry container
+	configDir    string
+	dataDir      string
+	docker       *docker.Client                        // nil when DOCKER_HOST is unusable
+	state        atomic.Pointer[docker.ContainerState] // Kept current by WatchEvents; nil when there is no container
+	watching     atomic.Bool                           // Whether the event stream is connected, so state can be trusted
+	cancelEvents atomic.Pointer[context.CancelFunc]    // Ends the current event stream
+	proxyURL     atomic.Value                          // string: upstream mirrored by the running container, "" when not a proxy
+	redisCache   atomic.Value                          // string: Redis of the blob-descriptor cache, "" when there is none
+	authEnabled  atomic.Bool                           // Whether an htpasswd file with accounts was written
+	tokens       *TokenIssuer                          // Set when the registry takes tokens of the dashboard instead of htpasswd passwords
+	tokenRealm   string
+	inProcess    bool                            // Serve the registry from this process instead of a container
+	served       atomic.Pointer[inProcessServer] // The in-process registry while it runs
+	pushGuard    atomic.Pointer[pushGuard]       // Tells the in-process registry which tags it may not overwrite
+	wanted       atomic.Bool                     // Started and not stopped since, so the watchdog keeps it up
+	lastConfig   atomic.Pointer[models.StorageConfig]
+	stoppedID    atomic.Value  // string: ID of the container the dashboard stopped itself, whose exit is no incident
+	crashed      chan struct{} // Signals the watchdog that the container died
+	watchdogOn   atomic.Bool
+	incidents    incidentLog
+	version      atomic.Value // string: version of the registry in the running container
 }
 
 // NewEmbeddedRegistry creates a new embedded registry manager
@@ -72,66 +183,179 @@ func NewEmbeddedRegistry(baseDir string, port int) *EmbeddedRegistry {
 	if port == 0 {
 		port = DefaultPort
 	}
-	return &EmbeddedRegistry{
+	client, err := docker.NewClientFromEnv()
+	if err != nil {
+		log.Printf("⚠️  Docker client unavailable: %v", err)
+	}
+	r := &EmbeddedRegistry{
 		baseDir:   baseDir,
-		port:      port,
 		configDir: filepath.Join(baseDir, "registry-config"),
 		dataDir:   filepath.Join(baseDir, "registry-data"),
+		docker:    client,
+		crashed:   make(chan struct{}, 1),
 	}
+	r.port.Store(int64(port))
+	r.container.Store(ContainerName)
+	return r
 }
 
 // Port returns the registry port
 func (r *EmbeddedRegistry) Port() int {
-	return r.port
+	return int(r.port.Load())
+}
+
+// Container returns the name of the registry container
+func (r *EmbeddedRegistry) Container() string {
+	return r.container.Load().(string)
+}
+
+// redisContainer is the name of the managed Redis container of the registry
+func (r *EmbeddedRegistry) redisContainer() string {
+	return r.Container() + "-redis"
 }
 
 // URL returns the registry URL
 func (r *EmbeddedRegistry) URL() string {
-	return fmt.Sprintf("http://localhost:%d", r.port)
+	return fmt.Sprintf("http://localhost:%d", r.Port())
 }
 
-// IsDockerAvailable checks if Docker CLI is available
+// IsDockerAvailable checks if the Docker daemon answers
 func (r *EmbeddedRegistry) IsDockerAvailable() bool {
-	cmd := exec.Command("docker", "info")
-	cmd.Stdout = nil
-	cmd.Stderr = nil
-	return cmd.Run() == nil
+	if r.docker == nil {
+		return false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return r.docker.Ping(ctx) == nil
 }
 
-// IsRunning checks if the registry container is running
+// IsRunning checks if the registry container, or the in-process registry, is running
 func (r *EmbeddedRegistry) IsRunning() bool {
-	out, err := exec.Command("docker", "inspect", "-f", "{{.State.Running}}", ContainerName).Output()
+	if r.inProcess {
+		return r.served.Load() != nil
+	}
+	state, err := r.containerState()
+	return err == nil && state != nil && state.Running
+}
+
+// containerState returns the registry container's state, nil when there is none. While the event
+// stream is connected the cached state is current; otherwise the daemon is asked.
+func (r *EmbeddedRegistry) containerState() (*docker.ContainerState, error) {
+	if r.watching.Load() {
+		return r.state.Load(), nil
+	}
+	return r.inspect()
+}
+
+// inspect asks the daemon for the registry container's state and caches it
+func (r *EmbeddedRegistry) inspect() (*docker.ContainerState, error) {
+	if r.docker == nil {
+		return nil, errNoDocker
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dockerTimeout)
+	defer cancel()
+	state, err := r.docker.InspectContainer(ctx, r.Container())
+	if errors.Is(err, docker.ErrNotFound) {
+		r.state.Store(nil)
+		return nil, nil
+	}
 	if err != nil {
-		return false
+		return nil, err
 	}
-	return strings.TrimSpace(string(out)) == "true"
+	r.state.Store(state)
+	return state, nil
 }
 
-// generateConfig writes the registry config.yml based on storage settings
-func (r *EmbeddedRegistry) generateConfig(config *models.StorageConfig) error {
-	if err := os.MkdirAll(r.configDir, 0755); err != nil {
-		return fmt.Errorf("failed to create config dir: %w", err)
+// WatchEvents follows the registry container's events so the status is current without asking
+// the daemon on every request, reconnecting when the stream breaks, until quit is closed
+func (r *EmbeddedRegistry) WatchEvents(quit <-chan struct{}) {
+	if r.docker == nil || r.inProcess {
+		return
 	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-quit
+		cancel()
+	}()
+
+	backoff := time.Second
+	for {
+		// The stream follows one container name; Relocate cancels it to follow the new one
+		streamCtx, cancelStream := context.WithCancel(ctx)
+		r.cancelEvents.Store(&cancelStream)
+		stream, err := r.docker.Events(streamCtx, r.Container())
+		if err == nil {
+			backoff = time.Second
+			err = r.followEvents(stream)
+			stream.Close()
+		}
+		relocated := streamCtx.Err() != nil
+		cancelStream()
+		r.watching.Store(false)
+		if ctx.Err() != nil {
+			return
+		}
+		if relocated {
+			continue
+		}
 
-	if config == nil {
-		config = &models.StorageConfig{Type: "local"}
+		log.Printf("⚠️  Lost the Docker event stream: %v; reconnecting in %s", err, backoff)
+		select {
+		case <-quit:
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > time.Minute {
+			backoff = time.Minute
+		}
 	}
-	if config.Type == "" {
-		config.Type = "local"
+}
+
+// followEvents refreshes the cached state on connect and on every event of the stream
+func (r *EmbeddedRegistry) followEvents(stream *docker.EventStream) error {
+	// Inspect after subscribing, so no change falls between the two
+	if _, err := r.inspect(); err != nil {
+		return err
 	}
+	r.watching.Store(true)
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			return err
+		}
+		stopped, _ := r.stoppedID.Load().(string)
+		switch {
+		case event.Action == "die" && event.ID == stopped:
+			log.Printf("📦 Registry container exited (code %s)", event.Attributes["exitCode"])
+		case event.Action == "die":
+			r.recordIncident(models.RegistryIncident{Time: event.Time, Kind: "exited", Detail: "exit code " + event.Attributes["exitCode"]})
+			select {
+			case r.crashed <- struct{}{}:
+			default:
+			}
+		case event.Action == "oom":
+			r.recordIncident(models.RegistryIncident{Time: event.Time, Kind: "oom", Detail: "the registry container ran out of memory"})
+		}
+		if _, err := r.inspect(); err != nil {
+			return err
+		}
+	}
+}
 
-	tmpl, err := template.New("registry-config").Parse(registryConfigTmpl)
-	if err != nil {
-		return fmt.Errorf("template parse error: %w", err)
+// generateConfig writes the registry config.yml based on storage settings
+func (r *EmbeddedRegistry) generateConfig(config *models.StorageConfig) error {
+	if err := os.MkdirAll(r.configDir, 0755); err != nil {
+		return fmt.Errorf("failed to create config dir: %w", err)
 	}
 
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, config); err != nil {
-		return fmt.Errorf("template exec error: %w", err)
+	rendered, err := RenderConfig(config, r.Auth())
+	if err != nil {
+		return err
 	}
 
 	configPath := filepath.Join(r.configDir, "config.yml")
-	if err := os.WriteFile(configPath, buf.Bytes(), 0644); err != nil {
+	if err := os.WriteFile(configPath, rendered, 0644); err != nil {
 		return fmt.Errorf("failed to write config: %w", err)
 	}
 
@@ -139,16 +363,95 @@ func (r *EmbeddedRegistry) generateConfig(config *models.StorageConfig) error {
 	return nil
 }
 
-// stopContainer removes the existing container
+// configFiles returns the files the registry reads from /etc/docker/registry: the config, plus the
+// htpasswd file or the token certificate when authentication is on, and the GCS key
+func (r *EmbeddedRegistry) configFiles(rendered []byte, config *models.StorageConfig) (map[string][]byte, error) {
+	files := map[string][]byte{"config.yml": rendered}
+	if config != nil && config.Type == "gcs" && config.GCSCredentials != "" {
+		files[gcsKeyFile] = []byte(config.GCSCredentials)
+	}
+	auth := r.Auth()
+	for _, file := range []string{htpasswdFile, tokenCertFile} {
+		if (file == htpasswdFile && !auth.Htpasswd) || (file == tokenCertFile && auth.TokenRealm == "") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(r.configDir, file))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		files[file] = data
+	}
+	return files, nil
+}
+
+// stopContainer removes the existing container, or stops the in-process registry
 func (r *EmbeddedRegistry) stopContainer() {
-	exec.Command("docker", "stop", ContainerName).Run()
-	exec.Command("docker", "rm", "-f", ContainerName).Run()
+	if r.inProcess {
+		r.stopInProcess()
+		return
+	}
+	if r.docker == nil {
+		return
+	}
+	if state := r.state.Load(); state != nil {
+		r.stoppedID.Store(state.ID)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dockerTimeout)
+	defer cancel()
+	r.docker.StopContainer(ctx, r.Container(), 10*time.Second)
+	r.docker.RemoveContainer(ctx, r.Container())
+}
+
+// startRedis (re)creates the managed Redis container. It only holds a cache, so it is started
+// empty, without persistence, and with the password of the storage config.
+func (r *EmbeddedRegistry) startRedis(config *models.StorageConfig) error {
+	r.removeRedis()
+
+	log.Printf("📦 Ensuring %s image is available...", redisImage)
+	pullCtx, cancelPull := context.WithTimeout(context.Background(), 10*time.Minute)
+	if err := r.docker.PullImage(pullCtx, redisImage); err != nil {
+		log.Printf("⚠️  %v", err) // The image might already exist
+	}
+	cancelPull()
+
+	spec := docker.ContainerSpec{
+		Image:         redisImage,
+		Cmd:           []string{"redis-server", "--save", "", "--appendonly", "no"},
+		RestartPolicy: "unless-stopped",
+	}
+	if config.RedisPassword != "" {
+		spec.Cmd = append(spec.Cmd, "--requirepass", config.RedisPassword)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dockerTimeout)
+	defer cancel()
+	if _, err := r.docker.CreateContainer(ctx, r.redisContainer(), spec); err != nil {
+		return fmt.Errorf("failed to create Redis container: %w", err)
+	}
+	if err := r.docker.StartContainer(ctx, r.redisContainer()); err != nil {
+		return fmt.Errorf("failed to start Redis container: %w", err)
+	}
+	log.Printf("⚡ Redis blob-descriptor cache started in %s", r.redisContainer())
+	return nil
+}
+
+// removeRedis removes the managed Redis container, if any
+func (r *EmbeddedRegistry) removeRedis() {
+	if r.docker == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dockerTimeout)
+	defer cancel()
+	// Killing it loses nothing but cached descriptors
+	r.docker.RemoveContainer(ctx, r.redisContainer())
 }
 
 // startLocked starts the registry (must hold mu)
 func (r *EmbeddedRegistry) startLocked(config *models.StorageConfig) error {
+	if r.inProcess {
+		return r.startInProcessLocked(config)
+	}
 	if !r.IsDockerAvailable() {
-		return fmt.Errorf("Docker is not available. Please install and start Docker Desktop")
+		return errNoDocker
 	}
 
 	// Default to local if nil
@@ -163,6 +466,14 @@ func (r *EmbeddedRegistry) startLocked(config *models.StorageConfig) error {
 	if err := r.generateConfig(config); err != nil {
 		return err
 	}
+	rendered, err := os.ReadFile(filepath.Join(r.configDir, "config.yml"))
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	files, err := r.configFiles(rendered, config)
+	if err != nil {
+		return err
+	}
 
 	// Ensure data dir exists
 	if err := os.MkdirAll(r.dataDir, 0755); err != nil {
@@ -173,22 +484,24 @@ func (r *EmbeddedRegistry) startLocked(config *models.StorageConfig) error {
 	r.stopContainer()
 
 	// Pull image if not present
-	log.Println("📦 Ensuring registry:2 image is available...")
-	pullCmd := exec.Command("docker", "pull", "registry:2")
-	pullCmd.Stdout = os.Stdout
-	pullCmd.Stderr = os.Stderr
-	pullCmd.Run() // Ignore error, image might already exist
+	image := registryImageOf(config)
+	log.Printf("📦 Ensuring %s image is available...", image)
+	pullCtx, cancelPull := context.WithTimeout(context.Background(), 10*time.Minute)
+	if err := r.docker.PullImage(pullCtx, image); err != nil {
+		log.Printf("⚠️  %v", err) // The image might already exist
+	}
+	cancelPull()
 
 	// Build absolute paths for volume mounts
-	configAbs, _ := filepath.Abs(r.configDir)
 	dataAbs, _ := filepath.Abs(r.dataDir)
 
-	// Build docker run arguments
-	args := []string{
-		"run", "-d",
-		"--name", ContainerName,
-		"-p", fmt.Sprintf("%d:5000", r.port),
-		"-v", fmt.Sprintf("%s:/etc/docker/registry", configAbs),
+	// The config files are copied into the container rather than mounted, so a remote daemon works too;
+	// data paths are on the daemon's host
+	spec := docker.ContainerSpec{
+		Image:         image,
+		Cmd:           registryCmd,
+		Ports:         map[string]string{"5000/tcp": strconv.Itoa(r.Port())},
+		RestartPolicy: "unless-stopped",
 	}
 
 	switch config.Type {
@@ -202,47 +515,80 @@ func (r *EmbeddedRegistry) startLocked(config *models.StorageConfig) error {
 			}
 			os.MkdirAll(localPath, 0755)
 		}
-		args = append(args, "-v", fmt.Sprintf("%s:/var/lib/registry", localPath))
+		spec.Binds = append(spec.Binds, localPath+":/var/lib/registry")
 
 	case "s3":
 		// S3 does not need volume mount, config handles it
 		log.Println("☁️  Using S3/Object Storage backend")
 
+	case "gcs":
+		log.Printf("☁️  Using Google Cloud Storage bucket %s", config.GCSBucket)
+
+	case "swift":
+		log.Printf("☁️  Using OpenStack Swift container %s", config.SwiftContainer)
+
 	case "sftp":
 		// For SFTP, we mount the data dir and note that sshfs should be configured on host
-		args = append(args, "-v", fmt.Sprintf("%s:/var/lib/registry", dataAbs))
+		spec.Binds = append(spec.Binds, dataAbs+":/var/lib/registry")
 		log.Println("🔐 SFTP storage: mount your SFTP server to:", dataAbs)
 		log.Println("   Example: sshfs user@host:/path", dataAbs)
 	}
 
-	args = append(args, "--restart", "unless-stopped", "registry:2")
+	r.redisCache.Store("")
+	switch {
+	case config.RedisCache && config.RedisAddr == "":
+		if err := r.startRedis(config); err != nil {
+			return err
+		}
+		spec.Links = []string{r.redisContainer() + ":redis"}
+		r.redisCache.Store(r.redisContainer())
+	case config.RedisCache:
+		log.Printf("⚡ Using the Redis at %s as the blob-descriptor cache", config.RedisAddr)
+		r.redisCache.Store(config.RedisAddr)
+		r.removeRedis()
+	default:
+		r.removeRedis()
+	}
+
+	r.proxyURL.Store(config.ProxyRemoteURL)
+	if config.ProxyRemoteURL != "" {
+		log.Printf("🪞 Registry runs as a pull-through cache of %s; pushes are rejected", config.ProxyRemoteURL)
+	}
 
-	log.Printf("🐳 Starting Docker Registry V2 container...")
-	cmd := exec.Command("docker", args...)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to start registry container: %w\nOutput: %s", err, string(output))
+	log.Printf("🐳 Starting Docker Registry V2 container on %s...", r.docker.Host())
+	ctx, cancel := context.WithTimeout(context.Background(), dockerTimeout)
+	defer cancel()
+	if _, err := r.docker.CreateContainer(ctx, r.Container(), spec); err != nil {
+		return fmt.Errorf("failed to create registry container: %w", err)
+	}
+	if err := r.docker.CopyToContainer(ctx, r.Container(), "/etc/docker/registry", files); err != nil {
+		return fmt.Errorf("failed to copy the registry config: %w", err)
+	}
+	if err := r.docker.StartContainer(ctx, r.Container()); err != nil {
+		return fmt.Errorf("failed to start registry container: %w", err)
 	}
 
 	// Wait for container to become running
 	for i := 0; i < 20; i++ {
 		time.Sleep(500 * time.Millisecond)
-		if r.IsRunning() {
-			log.Printf("✅ Docker Registry V2 running at http://localhost:%d", r.port)
+		if state, err := r.inspect(); err == nil && state != nil && state.Running {
+			r.version.Store(r.containerVersion())
+			log.Printf("✅ Docker Registry V2 %s running at http://localhost:%d", r.Version(), r.Port())
 			return nil
 		}
 	}
 
 	// If still not running, check logs
-	logCmd := exec.Command("docker", "logs", "--tail", "20", ContainerName)
-	logOut, _ := logCmd.CombinedOutput()
-	return fmt.Errorf("registry container did not become healthy.\nLogs:\n%s", string(logOut))
+	logOut, _ := r.docker.Logs(ctx, r.Container(), 20)
+	return fmt.Errorf("registry container did not become healthy.\nLogs:\n%s", logOut)
 }
 
 // Start starts the registry container with the given storage config
 func (r *EmbeddedRegistry) Start(config *models.StorageConfig) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	r.wanted.Store(true)
+	r.lastConfig.Store(config)
 	return r.startLocked(config)
 }
 
@@ -250,43 +596,127 @@ func (r *EmbeddedRegistry) Start(config *models.StorageConfig) error {
 func (r *EmbeddedRegistry) Stop() error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+	r.wanted.Store(false)
 	r.stopContainer()
+	if !r.inProcess {
+		r.removeRedis()
+	}
 	log.Println("🛑 Docker Registry V2 stopped")
 	return nil
 }
 
-// Restart stops and restarts the registry with new config
+// Restart validates the new config, then stops and restarts the registry with it
 func (r *EmbeddedRegistry) Restart(config *models.StorageConfig) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
+
+	// Validate before touching the running container so a bad config never takes it down
+	check, err := r.ValidateConfig(config)
+	if err != nil {
+		return err
+	}
+	if !check.Valid {
+		return fmt.Errorf("registry config is invalid: %s", strings.Join(check.Problems, "; "))
+	}
+
 	log.Println("🔄 Restarting Docker Registry V2 with new configuration...")
+	r.wanted.Store(true)
+	r.lastConfig.Store(config)
 	return r.startLocked(config)
 }
 
+// Relocate moves the registry to another host port and container name. A registry that should
+// be running is recreated under them, and started again under the previous ones when it does not
+// come up.
+func (r *EmbeddedRegistry) Relocate(port int, container string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	oldPort, oldContainer := r.Port(), r.Container()
+	if port == oldPort && container == oldContainer {
+		return nil
+	}
+
+	running := r.wanted.Load()
+	move := func(port int, container string) error {
+		if running {
+			r.stopContainer()
+			if !r.inProcess {
+				r.removeRedis()
+			}
+		}
+		r.port.Store(int64(port))
+		r.container.Store(container)
+		if cancel := r.cancelEvents.Load(); cancel != nil {
+			(*cancel)()
+		}
+		if !running {
+			return nil
+		}
+		var config *models.StorageConfig
+		if last := r.lastConfig.Load(); last != nil {
+			copied := *last
+			config = &copied
+		}
+		return r.startLocked(config)
+	}
+
+	if running {
+		log.Printf("🚚 Moving the registry from %s on port %d to %s on port %d...", oldContainer, oldPort, container, port)
+	}
+	if err := move(port, container); err != nil {
+		log.Printf("⚠️  Registry did not come up on port %d: %v; moving it back", port, err)
+		if rollbackErr := move(oldPort, oldContainer); rollbackErr != nil {
+			return fmt.Errorf("registry did not come up on port %d (%v), nor again on port %d: %w", port, err, oldPort, rollbackErr)
+		}
+		return fmt.Errorf("registry did not come up on port %d and was moved back to port %d: %w", port, oldPort, err)
+	}
+	return nil
+}
+
 // Status returns the current registry status
 func (r *EmbeddedRegistry) Status() map[string]interface{} {
-	running := r.IsRunning()
+	if r.inProcess {
+		return r.inProcessStatus()
+	}
+	state, _ := r.containerState()
+	running := state != nil && state.Running
 	status := map[string]interface{}{
 		"running":          running,
-		"container_name":   ContainerName,
-		"port":             r.port,
+		"container_name":   r.Container(),
+		"port":             r.Port(),
 		"url":              r.URL(),
 		"docker_available": r.IsDockerAvailable(),
+		"auth_enabled":     r.AuthEnabled(),
+		"events_connected": r.watching.Load(),
+		"mode":             "container",
+		"registry_image":   registryImageOf(r.lastConfig.Load()),
+	}
+	if r.docker != nil {
+		status["docker_host"] = r.docker.Host()
+	}
+	if cache := r.RedisCache(); cache != "" {
+		status["redis_cache"] = cache
+	}
+	r.watchdogStatus(status)
+	if r.tokens != nil {
+		status["token_realm"] = r.tokenRealm
 	}
 
+	if state != nil {
+		status["state"] = state.Status
+	}
 	if running {
-		out, err := exec.Command("docker", "inspect", "-f",
-			"{{.State.Status}}|{{.State.StartedAt}}|{{.Image}}", ContainerName).Output()
-		if err == nil {
-			parts := strings.SplitN(strings.TrimSpace(string(out)), "|", 3)
-			if len(parts) >= 1 {
-				status["state"] = parts[0]
-			}
-			if len(parts) >= 2 {
-				status["started_at"] = parts[1]
-			}
-			if len(parts) >= 3 {
-				status["image"] = parts[2][:12] // Truncate image hash
+		status["version"] = r.Version()
+		status["started_at"] = state.StartedAt
+		image := strings.TrimPrefix(state.Image, "sha256:")
+		if len(image) > 12 {
+			image = image[:12] // Truncate image hash
+		}
+		status["image"] = image
+		if remote := r.ProxyRemoteURL(); remote != "" {
+			status["proxy_remote_url"] = remote
+			if stats, err := r.ProxyStats(); err == nil {
+				status["proxy_cache"] = stats
 			}
 		}
 	}
@@ -294,15 +724,154 @@ func (r *EmbeddedRegistry) Status() map[string]interface{} {
 	return status
 }
 
+// inProcessStatus is Status for the registry served by this process
+func (r *EmbeddedRegistry) inProcessStatus() map[string]interface{} {
+	srv := r.served.Load()
+	status := map[string]interface{}{
+		"running":          srv != nil,
+		"mode":             "in-process",
+		"port":             r.Port(),
+		"url":              r.URL(),
+		"docker_available": r.IsDockerAvailable(),
+		"auth_enabled":     r.AuthEnabled(),
+	}
+	if r.tokens != nil {
+		status["token_realm"] = r.tokenRealm
+	}
+	if cache := r.RedisCache(); cache != "" {
+		status["redis_cache"] = cache
+	}
+	r.watchdogStatus(status)
+	if srv != nil {
+		status["state"] = "running"
+		status["version"] = r.Version()
+		status["started_at"] = srv.startedAt.Format(time.RFC3339Nano)
+		if remote := r.ProxyRemoteURL(); remote != "" {
+			status["proxy_remote_url"] = remote
+			if stats, err := r.ProxyStats(); err == nil {
+				status["proxy_cache"] = stats
+			}
+		}
+	}
+	return status
+}
+
+// RedisCache returns the Redis the running registry caches blob descriptors in: the managed
+// container's name or the configured address, "" without a cache
+func (r *EmbeddedRegistry) RedisCache() string {
+	cache, _ := r.redisCache.Load().(string)
+	return cache
+}
+
+// ProxyRemoteURL returns the upstream registry the running container mirrors, or "" when it is
+// not a pull-through cache
+func (r *EmbeddedRegistry) ProxyRemoteURL() string {
+	remote, _ := r.proxyURL.Load().(string)
+	return remote
+}
+
+// ProxyStats reads the pull-through cache counters from the registry's debug server. The counters
+// start from zero whenever the container starts.
+func (r *EmbeddedRegistry) ProxyStats() (*models.ProxyCacheStats, error) {
+	remote := r.ProxyRemoteURL()
+	if remote == "" {
+		return nil, fmt.Errorf("registry is not a pull-through cache")
+	}
+	data, err := r.proxyVars()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry metrics: %w", err)
+	}
+
+	// The expvar counters use the Go field names
+	type counters struct {
+		Requests, Hits, Misses, BytesPulled, BytesPushed uint64
+	}
+	var vars struct {
+		Registry struct {
+			Proxy struct {
+				Blobs     counters `json:"blobs"`
+				Manifests counters `json:"manifests"`
+			} `json:"proxy"`
+		} `json:"registry"`
+	}
+	if err := json.Unmarshal(data, &vars); err != nil {
+		return nil, fmt.Errorf("failed to parse registry metrics: %w", err)
+	}
+
+	stats := &models.ProxyCacheStats{
+		RemoteURL: remote,
+		Blobs:     models.ProxyCacheCounters(vars.Registry.Proxy.Blobs),
+		Manifests: models.ProxyCacheCounters(vars.Registry.Proxy.Manifests),
+	}
+	if requests := stats.Blobs.Requests + stats.Manifests.Requests; requests > 0 {
+		stats.HitRate = float64(stats.Blobs.Hits+stats.Manifests.Hits) * 100 / float64(requests)
+	}
+	return stats, nil
+}
+
+// proxyVars returns the expvar document holding the pull-through cache counters
+func (r *EmbeddedRegistry) proxyVars() ([]byte, error) {
+	if r.inProcess {
+		return inProcessProxyVars()
+	}
+	if r.docker == nil {
+		return nil, errNoDocker
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dockerTimeout)
+	defer cancel()
+	var out bytes.Buffer
+	code, err := r.docker.Exec(ctx, r.Container(), []string{"wget", "-qO-", proxyDebugURL}, &out)
+	if err == nil && code != 0 {
+		err = fmt.Errorf("wget exited with code %d", code)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
 // GetContainerLogs returns the last N lines of container logs
 func (r *EmbeddedRegistry) GetContainerLogs(lines int) (string, error) {
 	if lines <= 0 {
 		lines = 50
 	}
-	cmd := exec.Command("docker", "logs", "--tail", fmt.Sprintf("%d", lines), ContainerName)
-	out, err := cmd.CombinedOutput()
+	if r.inProcess {
+		return "", fmt.Errorf("the in-process registry writes its warnings to the dashboard's log")
+	}
+	if r.docker == nil {
+		return "", errNoDocker
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), dockerTimeout)
+	defer cancel()
+	out, err := r.docker.Logs(ctx, r.Container(), lines)
 	if err != nil {
 		return "", fmt.Errorf("failed to get logs: %w", err)
 	}
-	return string(out), nil
+	return out, nil
+}
+
+// GarbageCollect removes the blobs no manifest references from the registry's storage, writing the
+// collector's output to out. Pushes running meanwhile can lose blobs, so it is best run while the
+// registry takes no pushes.
+func (r *EmbeddedRegistry) GarbageCollect(dryRun, deleteUntagged bool, out io.Writer) error {
+	if !r.IsRunning() {
+		return fmt.Errorf("container %s is not running", r.Container())
+	}
+	cmd := []string{"registry", "garbage-collect"}
+	if dryRun {
+		cmd = append(cmd, "--dry-run")
+	}
+	if deleteUntagged {
+		cmd = append(cmd, "--delete-untagged")
+	}
+	cmd = append(cmd, "/etc/docker/registry/config.yml")
+
+	code, err := r.docker.Exec(context.Background(), r.Container(), cmd, out)
+	if err == nil && code != 0 {
+		err = fmt.Errorf("exit code %d", code)
+	}
+	if err != nil {
+		return fmt.Errorf("garbage collection failed: %w", err)
+	}
+	return nil
 }
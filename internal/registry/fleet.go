@@ -0,0 +1,156 @@
+package registry
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// ManagedContainerName is the name of the container of an additional embedded registry
+func ManagedContainerName(name string) string {
+	return ContainerName + "-" + name
+}
+
+// NewManagedRegistry creates the manager of an additional embedded registry. Its container is
+// named after it and its config and data live under registries/<name> of the base directory, so
+// it shares nothing with the primary registry or the other ones.
+func NewManagedRegistry(baseDir, name string, port int) *EmbeddedRegistry {
+	r := NewEmbeddedRegistry(baseDir, port)
+	dir := filepath.Join(baseDir, "registries", name)
+	r.container.Store(ManagedContainerName(name))
+	r.configDir = filepath.Join(dir, "config")
+	r.dataDir = filepath.Join(dir, "data")
+	return r
+}
+
+// Fleet runs the additional embedded registries, each following its container's events and kept
+// up by its own watchdog while it runs
+type Fleet struct {
+	mu          sync.Mutex
+	baseDir     string
+	inProcess   bool
+	watchdog    bool
+	guard       PushGuard
+	credentials RegistryCredentials
+	members     map[int64]*fleetMember
+}
+
+type fleetMember struct {
+	reg  *EmbeddedRegistry
+	quit chan struct{}
+}
+
+// NewFleet creates the manager of the additional embedded registries, served in-process or in
+// containers like the primary one
+func NewFleet(baseDir string, inProcess, watchdog bool) *Fleet {
+	return &Fleet{baseDir: baseDir, inProcess: inProcess, watchdog: watchdog, members: make(map[int64]*fleetMember)}
+}
+
+// GuardPushes gives the registries a push guard, see EmbeddedRegistry.GuardPushes. It must be
+// called before they start.
+func (f *Fleet) GuardPushes(guard PushGuard, credentials RegistryCredentials) {
+	f.guard, f.credentials = guard, credentials
+}
+
+// prepare creates the manager of a registry with its account written to its htpasswd file
+func (f *Fleet) prepare(m *models.ManagedRegistry) (*EmbeddedRegistry, error) {
+	reg := NewManagedRegistry(f.baseDir, m.Name, m.Port)
+	if f.inProcess {
+		reg.ServeInProcess()
+	}
+	if f.guard != nil {
+		reg.GuardPushes(f.guard, f.credentials)
+	}
+	var users []models.RegistryUser
+	if m.Username != "" {
+		users = []models.RegistryUser{{Username: m.Username, PasswordHash: m.PasswordHash}}
+	}
+	if err := reg.SetUsers(users); err != nil {
+		return nil, err
+	}
+	return reg, nil
+}
+
+// Validate checks the storage settings of a registry the way the primary registry checks its own
+func (f *Fleet) Validate(m *models.ManagedRegistry) (*models.RegistryConfigCheck, error) {
+	reg, err := f.prepare(m)
+	if err != nil {
+		return nil, err
+	}
+	return reg.ValidateConfig(&m.Storage)
+}
+
+// Start starts a registry, replacing it when it already runs
+func (f *Fleet) Start(m *models.ManagedRegistry) error {
+	f.Stop(m.ID)
+	reg, err := f.prepare(m)
+	if err != nil {
+		return err
+	}
+	if !reg.InProcess() && !reg.IsDockerAvailable() {
+		return errNoDocker
+	}
+	storage := m.Storage
+	if err := reg.Start(&storage); err != nil {
+		reg.Stop()
+		return fmt.Errorf("failed to start registry %s: %w", m.Name, err)
+	}
+
+	member := &fleetMember{reg: reg, quit: make(chan struct{})}
+	go reg.WatchEvents(member.quit)
+	if f.watchdog {
+		go reg.Watchdog(member.quit)
+	}
+	f.mu.Lock()
+	f.members[m.ID] = member
+	f.mu.Unlock()
+	log.Printf("🐳 Registry %s running at %s", m.Name, reg.URL())
+	return nil
+}
+
+// Stop stops a registry, if it runs
+func (f *Fleet) Stop(id int64) {
+	f.mu.Lock()
+	member := f.members[id]
+	delete(f.members, id)
+	f.mu.Unlock()
+	if member == nil {
+		return
+	}
+	close(member.quit)
+	member.reg.Stop()
+}
+
+// StopAll stops every registry of the fleet
+func (f *Fleet) StopAll() {
+	f.mu.Lock()
+	ids := make([]int64, 0, len(f.members))
+	for id := range f.members {
+		ids = append(ids, id)
+	}
+	f.mu.Unlock()
+	for _, id := range ids {
+		f.Stop(id)
+	}
+}
+
+// Remove stops a registry and deletes its config directory. Its data directory is kept, so
+// removing a registry by mistake loses no images.
+func (f *Fleet) Remove(m *models.ManagedRegistry) error {
+	f.Stop(m.ID)
+	return os.RemoveAll(filepath.Join(f.baseDir, "registries", m.Name, "config"))
+}
+
+// Registry returns the manager of a running registry, nil when it does not run
+func (f *Fleet) Registry(id int64) *EmbeddedRegistry {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if member := f.members[id]; member != nil {
+		return member.reg
+	}
+	return nil
+}
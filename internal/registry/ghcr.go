@@ -0,0 +1,117 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// githubClient calls the GitHub REST API, which is not the registry host
+var githubClient = &http.Client{Timeout: 15 * time.Second}
+
+// githubPageSize is the page size of GitHub API listings (its maximum)
+const githubPageSize = 100
+
+// githubMaxPages bounds the pages read from one GitHub API listing
+const githubMaxPages = 100
+
+// ghcrAPI is how a client of GitHub Container Registry lists repositories: through the GitHub
+// Packages API, since GHCR disables /v2/_catalog
+type ghcrAPI struct {
+	token  string   // Personal access token with read:packages
+	owners []string // Users or organizations to list; empty: the token's user
+}
+
+// githubAPIURL is the GitHub REST API; GITHUB_API_URL overrides it, as in GitHub Actions
+func githubAPIURL() string {
+	if u := os.Getenv("GITHUB_API_URL"); u != "" {
+		return strings.TrimRight(u, "/")
+	}
+	return "https://api.github.com"
+}
+
+// githubGet sends a GET to the GitHub API, returning the status with the decoded body when it is 200
+func (g *ghcrAPI) githubGet(ctx context.Context, path string, out interface{}) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", githubAPIURL()+path, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	req.Header.Set("Authorization", "Bearer "+g.token)
+	resp, err := githubClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		if json.Unmarshal(body, &apiErr) == nil && apiErr.Message != "" {
+			return resp.StatusCode, fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, apiErr.Message)
+		}
+		return resp.StatusCode, fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to decode GitHub response: %w", err)
+	}
+	return resp.StatusCode, nil
+}
+
+// listPackages lists the container packages under a packages path, e.g. /orgs/acme/packages
+func (g *ghcrAPI) listPackages(ctx context.Context, path string) ([]models.Repository, int, error) {
+	repos := []models.Repository{}
+	for page := 1; page <= githubMaxPages; page++ {
+		var packages []struct {
+			Name  string `json:"name"`
+			Owner struct {
+				Login string `json:"login"`
+			} `json:"owner"`
+		}
+		status, err := g.githubGet(ctx, fmt.Sprintf("%s?package_type=container&per_page=%d&page=%d", path, githubPageSize, page), &packages)
+		if err != nil {
+			return nil, status, err
+		}
+		for _, p := range packages {
+			repos = append(repos, models.Repository{Name: strings.ToLower(p.Owner.Login + "/" + p.Name)})
+		}
+		if len(packages) < githubPageSize {
+			break
+		}
+	}
+	return repos, http.StatusOK, nil
+}
+
+// listGHCRRepositories lists the container packages of the client's owners with the GitHub API
+func (c *Client) listGHCRRepositories(ctx context.Context) ([]models.Repository, error) {
+	if len(c.ghcr.owners) == 0 {
+		repos, _, err := c.ghcr.listPackages(ctx, "/user/packages")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list repositories: %w", err)
+		}
+		return repos, nil
+	}
+	repos := []models.Repository{}
+	for _, owner := range c.ghcr.owners {
+		// The packages of organizations and users live under different paths
+		list, status, err := c.ghcr.listPackages(ctx, "/orgs/"+url.PathEscape(owner)+"/packages")
+		if status == http.StatusNotFound {
+			list, _, err = c.ghcr.listPackages(ctx, "/users/"+url.PathEscape(owner)+"/packages")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list repositories of %s: %w", owner, err)
+		}
+		repos = append(repos, list...)
+	}
+	return repos, nil
+}
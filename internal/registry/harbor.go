@@ -0,0 +1,323 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// ErrNotHarbor is returned by the Harbor API methods for registries that are not Harbor
+var ErrNotHarbor = errors.New("not a Harbor registry")
+
+// errHarborResponse is a Harbor API answer that is not the JSON expected
+var errHarborResponse = errors.New("failed to decode Harbor response")
+
+// harborPageSize is the page size of Harbor API listings (its maximum)
+const harborPageSize = 100
+
+// harborMaxPages bounds the pages read from one Harbor listing
+const harborMaxPages = 100
+
+// harborDetectionTTL is how long the outcome of Harbor detection is reused
+const harborDetectionTTL = time.Hour
+
+type harborDetection struct {
+	info      *models.HarborInfo // nil: not Harbor
+	checkedAt time.Time
+}
+
+var (
+	harborMu       sync.Mutex
+	harborDetected = map[string]harborDetection{}
+)
+
+// Harbor returns the system information of a Harbor registry, or ErrNotHarbor. Harbor is detected
+// by GET /api/v2.0/systeminfo; the outcome is remembered per registry URL for an hour.
+func (c *Client) Harbor(ctx context.Context) (*models.HarborInfo, error) {
+	key := breakerKey(c.baseURL)
+	harborMu.Lock()
+	d, ok := harborDetected[key]
+	harborMu.Unlock()
+	if ok && time.Since(d.checkedAt) < harborDetectionTTL {
+		if d.info == nil {
+			return nil, ErrNotHarbor
+		}
+		return d.info, nil
+	}
+
+	info, err := c.harborSystemInfo(ctx)
+	if err != nil && err != ErrNotHarbor {
+		return nil, err
+	}
+	harborMu.Lock()
+	harborDetected[key] = harborDetection{info: info, checkedAt: time.Now()}
+	harborMu.Unlock()
+	return info, err
+}
+
+func (c *Client) harborSystemInfo(ctx context.Context) (*models.HarborInfo, error) {
+	var body struct {
+		HarborVersion string  `json:"harbor_version"`
+		AuthMode      *string `json:"auth_mode"`
+		ExternalURL   string  `json:"external_url"`
+	}
+	if err := c.harborGet(ctx, "/api/v2.0/systeminfo", &body); err != nil {
+		var status harborStatusError
+		if errors.As(err, &status) || errors.Is(err, errHarborResponse) {
+			return nil, ErrNotHarbor
+		}
+		return nil, err
+	}
+	if body.AuthMode == nil && body.HarborVersion == "" {
+		return nil, ErrNotHarbor
+	}
+	info := &models.HarborInfo{Version: body.HarborVersion, ExternalURL: body.ExternalURL}
+	if body.AuthMode != nil {
+		info.AuthMode = *body.AuthMode
+	}
+	return info, nil
+}
+
+// harborStatusError is an unexpected status from the Harbor API
+type harborStatusError struct {
+	status int
+	body   string
+}
+
+func (e harborStatusError) Error() string {
+	return fmt.Sprintf("Harbor API returned status %d: %s", e.status, e.body)
+}
+
+// harborGet sends a GET to the Harbor API with the client's credentials and decodes the JSON answer
+func (c *Client) harborGet(ctx context.Context, path string, out interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	username, password, err := c.credentials(ctx)
+	if err != nil {
+		return err
+	}
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return harborStatusError{status: resp.StatusCode, body: strings.TrimSpace(string(body))}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%w: %v", errHarborResponse, err)
+	}
+	return nil
+}
+
+// harborList reads every page of a Harbor listing; fn decodes a page and returns its length
+func (c *Client) harborList(ctx context.Context, path string, fn func(page json.RawMessage) (int, error)) error {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	for page := 1; page <= harborMaxPages; page++ {
+		var raw json.RawMessage
+		if err := c.harborGet(ctx, fmt.Sprintf("%s%spage=%d&page_size=%d", path, sep, page, harborPageSize), &raw); err != nil {
+			return err
+		}
+		n, err := fn(raw)
+		if err != nil {
+			return fmt.Errorf("%w: %v", errHarborResponse, err)
+		}
+		if n < harborPageSize {
+			return nil
+		}
+	}
+	return nil
+}
+
+// ListHarborProjects returns the projects visible to the client's credentials with their quotas
+func (c *Client) ListHarborProjects(ctx context.Context) ([]models.HarborProject, error) {
+	if _, err := c.Harbor(ctx); err != nil {
+		return nil, err
+	}
+	projects := []models.HarborProject{}
+	err := c.harborList(ctx, "/api/v2.0/projects", func(raw json.RawMessage) (int, error) {
+		var page []struct {
+			ProjectID    int64             `json:"project_id"`
+			Name         string            `json:"name"`
+			Metadata     map[string]string `json:"metadata"`
+			RepoCount    int               `json:"repo_count"`
+			CreationTime time.Time         `json:"creation_time"`
+		}
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return 0, err
+		}
+		for _, p := range page {
+			projects = append(projects, models.HarborProject{
+				ID:        p.ProjectID,
+				Name:      p.Name,
+				Public:    p.Metadata["public"] == "true",
+				RepoCount: p.RepoCount,
+				CreatedAt: p.CreationTime,
+			})
+		}
+		return len(page), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	quotas, err := c.harborQuotas(ctx)
+	for i := range projects {
+		p := &projects[i]
+		if err == nil {
+			p.Quota = quotas[p.ID]
+			continue
+		}
+		// Reading all quotas takes a system admin; members can read their project's summary
+		var summary struct {
+			Quota *struct {
+				Hard map[string]int64 `json:"hard"`
+				Used map[string]int64 `json:"used"`
+			} `json:"quota"`
+		}
+		if serr := c.harborGet(ctx, fmt.Sprintf("/api/v2.0/projects/%d/summary", p.ID), &summary); serr == nil && summary.Quota != nil {
+			p.Quota = &models.HarborQuota{HardBytes: summary.Quota.Hard["storage"], UsedBytes: summary.Quota.Used["storage"]}
+		}
+	}
+	return projects, nil
+}
+
+// harborQuotas returns the storage quotas of all projects by project ID
+func (c *Client) harborQuotas(ctx context.Context) (map[int64]*models.HarborQuota, error) {
+	quotas := make(map[int64]*models.HarborQuota)
+	err := c.harborList(ctx, "/api/v2.0/quotas?reference=project", func(raw json.RawMessage) (int, error) {
+		var page []struct {
+			Ref struct {
+				ID int64 `json:"id"`
+			} `json:"ref"`
+			Hard map[string]int64 `json:"hard"`
+			Used map[string]int64 `json:"used"`
+		}
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return 0, err
+		}
+		for _, q := range page {
+			quotas[q.Ref.ID] = &models.HarborQuota{HardBytes: q.Hard["storage"], UsedBytes: q.Used["storage"]}
+		}
+		return len(page), nil
+	})
+	return quotas, err
+}
+
+// ListHarborLabels returns the global labels, and those of a project when one is named
+func (c *Client) ListHarborLabels(ctx context.Context, project string) ([]models.HarborLabel, error) {
+	if _, err := c.Harbor(ctx); err != nil {
+		return nil, err
+	}
+	labels := []models.HarborLabel{}
+	collect := func(raw json.RawMessage) (int, error) {
+		var page []models.HarborLabel
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return 0, err
+		}
+		labels = append(labels, page...)
+		return len(page), nil
+	}
+	if err := c.harborList(ctx, "/api/v2.0/labels?scope=g", collect); err != nil {
+		return nil, err
+	}
+	if project == "" {
+		return labels, nil
+	}
+	var p struct {
+		ProjectID int64 `json:"project_id"`
+	}
+	if err := c.harborGet(ctx, "/api/v2.0/projects/"+url.PathEscape(project), &p); err != nil {
+		return nil, err
+	}
+	if err := c.harborList(ctx, fmt.Sprintf("/api/v2.0/labels?scope=p&project_id=%d", p.ProjectID), collect); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// ListHarborArtifacts returns the artifacts of a repository with their tags and labels. Harbor
+// repository names start with the project; the rest is escaped twice in the API path.
+func (c *Client) ListHarborArtifacts(ctx context.Context, repoName string) ([]models.HarborArtifact, error) {
+	if _, err := c.Harbor(ctx); err != nil {
+		return nil, err
+	}
+	project, repo, ok := strings.Cut(repoName, "/")
+	if !ok {
+		return nil, fmt.Errorf("Harbor repository %q has no project", repoName)
+	}
+	path := fmt.Sprintf("/api/v2.0/projects/%s/repositories/%s/artifacts?with_tag=true&with_label=true",
+		url.PathEscape(project), url.PathEscape(url.PathEscape(repo)))
+
+	artifacts := []models.HarborArtifact{}
+	err := c.harborList(ctx, path, func(raw json.RawMessage) (int, error) {
+		var page []struct {
+			Digest    string    `json:"digest"`
+			Type      string    `json:"type"`
+			MediaType string    `json:"media_type"`
+			Size      int64     `json:"size"`
+			PushTime  time.Time `json:"push_time"`
+			PullTime  time.Time `json:"pull_time"`
+			Tags      []struct {
+				Name string `json:"name"`
+			} `json:"tags"`
+			Labels []models.HarborLabel `json:"labels"`
+		}
+		if err := json.Unmarshal(raw, &page); err != nil {
+			return 0, err
+		}
+		for _, a := range page {
+			artifact := models.HarborArtifact{
+				Digest:    a.Digest,
+				Type:      a.Type,
+				MediaType: a.MediaType,
+				Size:      a.Size,
+				PushedAt:  harborTime(a.PushTime),
+				PulledAt:  harborTime(a.PullTime),
+				Tags:      []string{},
+				Labels:    a.Labels,
+			}
+			for _, t := range a.Tags {
+				artifact.Tags = append(artifact.Tags, t.Name)
+			}
+			if artifact.Labels == nil {
+				artifact.Labels = []models.HarborLabel{}
+			}
+			artifacts = append(artifacts, artifact)
+		}
+		return len(page), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return artifacts, nil
+}
+
+// harborTime turns Harbor's zero timestamps (never pulled) into nil
+func harborTime(t time.Time) *time.Time {
+	if t.IsZero() || t.Year() <= 1 {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,95 @@
+package registry
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// htpasswdFile is the registry's htpasswd file, copied with the config into /etc/docker/registry
+const htpasswdFile = "htpasswd"
+
+// HashRegistryPassword hashes a password with bcrypt, the only scheme the registry's htpasswd accepts
+func HashRegistryPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckRegistryPassword reports whether password matches a hash from HashRegistryPassword
+func CheckRegistryPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// SetUsers writes the htpasswd file of the given accounts, which the registry reads when it
+// (re)starts. With no accounts the file is removed and the registry takes anonymous requests.
+func (r *EmbeddedRegistry) SetUsers(users []models.RegistryUser) error {
+	path := filepath.Join(r.configDir, htpasswdFile)
+	if len(users) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove htpasswd file: %w", err)
+		}
+		r.authEnabled.Store(false)
+		return nil
+	}
+
+	if err := os.MkdirAll(r.configDir, 0755); err != nil {
+		return fmt.Errorf("failed to create config dir: %w", err)
+	}
+	var b strings.Builder
+	for _, u := range users {
+		fmt.Fprintf(&b, "%s:%s\n", u.Username, u.PasswordHash)
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write htpasswd file: %w", err)
+	}
+	r.authEnabled.Store(true)
+	return nil
+}
+
+// AuthEnabled reports whether the registry requires authentication, which it does once it has accounts
+func (r *EmbeddedRegistry) AuthEnabled() bool {
+	return r.authEnabled.Load()
+}
+
+// RegistryAuth is how the embedded registry authenticates clients
+type RegistryAuth struct {
+	Htpasswd   bool   // Require the accounts of the htpasswd file
+	TokenRealm string // Require tokens of the dashboard's token service at this URL instead
+}
+
+// Auth returns how the registry config authenticates clients
+func (r *EmbeddedRegistry) Auth() RegistryAuth {
+	switch {
+	case !r.AuthEnabled():
+		return RegistryAuth{}
+	case r.tokens != nil:
+		return RegistryAuth{TokenRealm: r.tokenRealm}
+	default:
+		return RegistryAuth{Htpasswd: true}
+	}
+}
+
+// EnableTokenAuth makes the registry accept tokens of the dashboard's token service at realm instead
+// of htpasswd passwords, so the permissions of each account are enforced. It must be called before
+// the registry starts; the signing key and certificate are kept in the config directory.
+func (r *EmbeddedRegistry) EnableTokenAuth(realm string) error {
+	issuer, err := loadTokenIssuer(r.configDir)
+	if err != nil {
+		return err
+	}
+	r.tokens, r.tokenRealm = issuer, realm
+	return nil
+}
+
+// Tokens returns the issuer of registry tokens, or nil when the registry uses htpasswd
+func (r *EmbeddedRegistry) Tokens() *TokenIssuer {
+	return r.tokens
+}
@@ -0,0 +1,191 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// ErrPullRateLimited is returned without contacting Docker Hub while the pulls left to the
+// registry's credentials are at or below the reserve
+var ErrPullRateLimited = errors.New("Docker Hub pull rate limit nearly exhausted")
+
+// hubProbeRepository is the image Docker offers for reading the rate limit: a HEAD of its manifest
+// returns the limit headers without counting as a pull
+const hubProbeRepository = "ratelimitpreview/test"
+
+// hubRecheck is how often a throttled client asks Docker Hub whether pulls have been freed
+const hubRecheck = time.Minute
+
+var (
+	hubLimitsMu sync.Mutex
+	hubLimits   = map[string]*models.PullRateLimit{} // By registry URL and username
+
+	// hubPullReserve pulls are left unused by the dashboard, for the people and machines sharing the limit
+	hubPullReserve = 10
+)
+
+// SetPullReserve sets how many Docker Hub pulls the dashboard leaves unused
+func SetPullReserve(reserve int) {
+	hubLimitsMu.Lock()
+	defer hubLimitsMu.Unlock()
+	if reserve < 0 {
+		reserve = 0
+	}
+	hubPullReserve = reserve
+}
+
+// IsDockerHub reports whether a registry URL is Docker Hub
+func IsDockerHub(registryURL string) bool {
+	u, err := url.Parse(registryURL)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Hostname()) {
+	case "registry-1.docker.io", "index.docker.io", "docker.io", "registry.hub.docker.com":
+		return true
+	}
+	return false
+}
+
+// hubLimitKey identifies the limit a client's pulls count against; anonymous pulls are limited
+// per IP address, authenticated ones per account
+func (c *Client) hubLimitKey() string {
+	return breakerKey(c.baseURL) + "\x00" + c.username
+}
+
+// isPull reports whether a request counts as a Docker Hub pull: a GET of a manifest
+func isPull(req *http.Request) bool {
+	return req.Method == http.MethodGet && strings.Contains(req.URL.Path, "/manifests/")
+}
+
+// checkPullLimit refuses a pull from Docker Hub while the pulls left are at or below the reserve.
+// A throttled client asks again at most every hubRecheck whether pulls have been freed.
+func (c *Client) checkPullLimit(req *http.Request) error {
+	if !IsDockerHub(c.baseURL) || !isPull(req) {
+		return nil
+	}
+	limit := c.PullRateLimit()
+	if limit == nil || !limit.Throttled {
+		return nil
+	}
+	if time.Since(limit.UpdatedAt) >= hubRecheck {
+		if refreshed, err := c.RefreshPullRateLimit(req.Context()); err == nil && (refreshed == nil || !refreshed.Throttled) {
+			return nil
+		} else if refreshed != nil {
+			limit = refreshed
+		}
+	}
+	return fmt.Errorf("%w: %d of %d pulls left, %d kept in reserve", ErrPullRateLimited, limit.Remaining, limit.Limit, limit.Reserve)
+}
+
+// recordPullLimit remembers the rate limit headers of a Docker Hub response. They look like
+// "RateLimit-Remaining: 76;w=21600"; accounts without a limit get none. A 429 leaves no pulls.
+func (c *Client) recordPullLimit(resp *http.Response) {
+	if !IsDockerHub(c.baseURL) {
+		return
+	}
+	limit, limitWindow, okLimit := parseRateLimitHeader(resp.Header.Get("RateLimit-Limit"))
+	remaining, _, okRemaining := parseRateLimitHeader(resp.Header.Get("RateLimit-Remaining"))
+	if resp.StatusCode == http.StatusTooManyRequests {
+		remaining, okRemaining = 0, true
+	}
+	if !okLimit && !okRemaining {
+		return
+	}
+
+	key := c.hubLimitKey()
+	hubLimitsMu.Lock()
+	defer hubLimitsMu.Unlock()
+	state := hubLimits[key]
+	if state == nil {
+		state = &models.PullRateLimit{}
+		hubLimits[key] = state
+	}
+	if okLimit {
+		state.Limit, state.WindowSeconds = limit, limitWindow
+	}
+	if okRemaining {
+		state.Remaining = remaining
+	}
+	if source := resp.Header.Get("Docker-RateLimit-Source"); source != "" {
+		state.Source = source
+	}
+	state.Unlimited = false
+	state.UpdatedAt = time.Now()
+	throttled := state.Remaining <= hubPullReserve
+	if throttled && !state.Throttled {
+		log.Printf("⚠️  Docker Hub pulls of %s nearly exhausted (%d of %d left), holding back dashboard pulls", breakerKey(c.baseURL), state.Remaining, state.Limit)
+	}
+	state.Throttled = throttled
+}
+
+// parseRateLimitHeader parses a rate limit header value such as "100;w=21600"
+func parseRateLimitHeader(value string) (int, int, bool) {
+	if value == "" {
+		return 0, 0, false
+	}
+	count, params, _ := strings.Cut(value, ";")
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil {
+		return 0, 0, false
+	}
+	window := 0
+	for _, param := range strings.Split(params, ";") {
+		if k, v, ok := strings.Cut(strings.TrimSpace(param), "="); ok && k == "w" {
+			window, _ = strconv.Atoi(v)
+		}
+	}
+	return n, window, true
+}
+
+// PullRateLimit returns the Docker Hub pull rate limit last reported to the client's credentials,
+// or nil when none has been seen (or the registry is not Docker Hub). It sends no request.
+func (c *Client) PullRateLimit() *models.PullRateLimit {
+	if !IsDockerHub(c.baseURL) {
+		return nil
+	}
+	hubLimitsMu.Lock()
+	defer hubLimitsMu.Unlock()
+	state := hubLimits[c.hubLimitKey()]
+	if state == nil {
+		return nil
+	}
+	limit := *state
+	limit.Reserve = hubPullReserve
+	limit.Throttled = !limit.Unlimited && limit.Remaining <= hubPullReserve
+	return &limit
+}
+
+// RefreshPullRateLimit asks Docker Hub for the current pull rate limit of the client's
+// credentials, with a HEAD request that does not count as a pull. The result has Unlimited set
+// when Docker Hub reports no limit.
+func (c *Client) RefreshPullRateLimit(ctx context.Context) (*models.PullRateLimit, error) {
+	if !IsDockerHub(c.baseURL) {
+		return nil, fmt.Errorf("%s is not Docker Hub", c.baseURL)
+	}
+	resp, err := c.doRequest(ctx, "HEAD", "/v2/"+hubProbeRepository+"/manifests/latest", map[string]string{"Accept": manifestAccept})
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusTooManyRequests {
+		return nil, fmt.Errorf("rate limit check returned status %d", resp.StatusCode)
+	}
+	if resp.Header.Get("RateLimit-Limit") == "" && resp.StatusCode == http.StatusOK {
+		key := c.hubLimitKey()
+		hubLimitsMu.Lock()
+		hubLimits[key] = &models.PullRateLimit{Unlimited: true, Source: resp.Header.Get("Docker-RateLimit-Source"), UpdatedAt: time.Now()}
+		hubLimitsMu.Unlock()
+	}
+	return c.PullRateLimit(), nil
+}
@@ -0,0 +1,358 @@
+package registry
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// ErrArchiveTooLarge is returned when an image archive exceeds the size it may be spooled up to
+var ErrArchiveTooLarge = errors.New("image archive is too large")
+
+// maxArchiveManifestBytes caps the manifests and JSON files read from an archive into memory
+const maxArchiveManifestBytes = 4 << 20
+
+// ImportStats is the progress of an image archive import
+type ImportStats struct {
+	Blobs        int   // Uploaded
+	SkippedBlobs int   // Already in the repository
+	Bytes        int64 // Content bytes uploaded
+}
+
+// ArchiveImage is an image of an archive with the repository and tag it was saved under, empty
+// when it has no name
+type ArchiveImage struct {
+	Repository string
+	Tag        string
+
+	digest    string          // Of its manifest, in an OCI image layout
+	mediaType string          // Of its manifest, in an OCI image layout
+	legacy    *legacyManifest // Its entry of a docker save manifest.json otherwise
+}
+
+// legacyManifest is an image entry of the manifest.json of docker save archives
+type legacyManifest struct {
+	Config   string   `json:"Config"`
+	RepoTags []string `json:"RepoTags"`
+	Layers   []string `json:"Layers"`
+}
+
+// archiveFile is a regular file of a spooled archive
+type archiveFile struct {
+	offset int64
+	size   int64
+	digest string
+}
+
+// ImageArchive is an image tarball, from docker save or an OCI image layout, spooled to a file
+type ImageArchive struct {
+	Images []ArchiveImage
+
+	spool io.ReaderAt
+	files map[string]archiveFile
+}
+
+// archiveCounter counts the bytes of an archive read so far and fails beyond a limit
+type archiveCounter struct {
+	r     io.Reader
+	n     int64
+	limit int64
+}
+
+func (c *archiveCounter) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	if c.n > c.limit {
+		return n, ErrArchiveTooLarge
+	}
+	return n, err
+}
+
+// ReadImageArchive copies a tar archive, optionally gzipped, into spool while indexing its files by
+// offset and digest, then finds its images. OCI image layouts (index.json), which docker save
+// writes since Docker 25, are preferred over the manifest.json of older docker save archives.
+// Archives larger than maxBytes once decompressed fail with ErrArchiveTooLarge.
+func ReadImageArchive(r io.Reader, spool *os.File, maxBytes int64) (*ImageArchive, error) {
+	br := bufio.NewReader(r)
+	var src io.Reader = br
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip stream: %w", err)
+		}
+		defer gz.Close()
+		src = gz
+	}
+
+	// The tar reader reads headers and contents exactly, so the bytes read so far are the
+	// offset of a file's content in the spool
+	counter := &archiveCounter{r: io.TeeReader(src, spool), limit: maxBytes}
+	tr := tar.NewReader(counter)
+	a := &ImageArchive{spool: spool, files: make(map[string]archiveFile)}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if errors.Is(err, ErrArchiveTooLarge) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("invalid tar archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		offset := counter.n
+		h := sha256.New()
+		n, err := io.Copy(h, tr)
+		if err != nil {
+			return nil, err
+		}
+		a.files[path.Clean(hdr.Name)] = archiveFile{offset: offset, size: n, digest: "sha256:" + hex.EncodeToString(h.Sum(nil))}
+	}
+
+	if err := a.findImages(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// findImages lists the images of the archive with their names
+func (a *ImageArchive) findImages() error {
+	if _, ok := a.files["index.json"]; ok {
+		var index struct {
+			Manifests []struct {
+				MediaType   string            `json:"mediaType"`
+				Digest      string            `json:"digest"`
+				Annotations map[string]string `json:"annotations"`
+			} `json:"manifests"`
+		}
+		if err := a.readJSON("index.json", &index); err != nil {
+			return err
+		}
+		for _, m := range index.Manifests {
+			img := ArchiveImage{digest: m.Digest, mediaType: m.MediaType}
+			// The ref name is a full reference, or only a tag
+			if name := m.Annotations["io.containerd.image.name"]; name != "" {
+				img.Repository, img.Tag = archiveImageName(name)
+			} else if name := m.Annotations["org.opencontainers.image.ref.name"]; strings.ContainsAny(name, ":/") {
+				img.Repository, img.Tag = archiveImageName(name)
+			} else {
+				img.Tag = name
+			}
+			a.Images = append(a.Images, img)
+		}
+		return nil
+	}
+
+	if _, ok := a.files["manifest.json"]; ok {
+		var entries []legacyManifest
+		if err := a.readJSON("manifest.json", &entries); err != nil {
+			return err
+		}
+		for i := range entries {
+			if len(entries[i].RepoTags) == 0 {
+				a.Images = append(a.Images, ArchiveImage{legacy: &entries[i]})
+			}
+			for _, name := range entries[i].RepoTags {
+				repo, tag := archiveImageName(name)
+				a.Images = append(a.Images, ArchiveImage{Repository: repo, Tag: tag, legacy: &entries[i]})
+			}
+		}
+		return nil
+	}
+	return errors.New("neither an OCI image layout (index.json) nor a docker save archive (manifest.json)")
+}
+
+// archiveImageName splits the name an image was saved under into repository and tag. The registry
+// host is dropped, and so is the library/ namespace of Docker Hub images, so alpine:3.20 and
+// docker.io/library/alpine:3.20 both import as alpine:3.20.
+func archiveImageName(ref string) (string, string) {
+	ref, _, _ = strings.Cut(ref, "@")
+	name, tag := ref, "latest"
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		name, tag = ref[:i], ref[i+1:]
+	}
+	if host, rest, ok := strings.Cut(name, "/"); ok && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		name = rest
+		if host == "docker.io" || host == "index.docker.io" {
+			name = strings.TrimPrefix(name, "library/")
+		}
+	}
+	return name, tag
+}
+
+// readFile reads a small file of the archive
+func (a *ImageArchive) readFile(f archiveFile) ([]byte, error) {
+	if f.size > maxArchiveManifestBytes {
+		return nil, fmt.Errorf("%d bytes is too large for a manifest or config", f.size)
+	}
+	data := make([]byte, f.size)
+	if _, err := a.spool.ReadAt(data, f.offset); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func (a *ImageArchive) readJSON(name string, v interface{}) error {
+	data, err := a.readFile(a.files[name])
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return nil
+}
+
+// layoutBlob finds a blob of the OCI image layout, checked against its digest
+func (a *ImageArchive) layoutBlob(digest string) (archiveFile, error) {
+	f, ok := a.files[blobPath(digest)]
+	if !ok {
+		return f, fmt.Errorf("blob %s is not in the archive", digest)
+	}
+	if f.digest != digest {
+		return f, fmt.Errorf("blob %s does not match its digest", digest)
+	}
+	return f, nil
+}
+
+// PushImage pushes an image of the archive to repo:tag and returns its manifest digest. Blobs the
+// repository already has are not uploaded again.
+func (a *ImageArchive) PushImage(ctx context.Context, c *Client, img ArchiveImage, repo, tag string, stats *ImportStats) (string, error) {
+	if img.legacy != nil {
+		return a.pushLegacyImage(ctx, c, img.legacy, repo, tag, stats)
+	}
+	return a.pushManifest(ctx, c, repo, tag, img.digest, img.mediaType, stats)
+}
+
+// pushManifest pushes a manifest of the OCI image layout under ref, after its blobs or, for an
+// index, its child manifests
+func (a *ImageArchive) pushManifest(ctx context.Context, c *Client, repo, ref, digest, mediaType string, stats *ImportStats) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	f, err := a.layoutBlob(digest)
+	if err != nil {
+		return "", err
+	}
+	body, err := a.readFile(f)
+	if err != nil {
+		return "", fmt.Errorf("manifest %s: %w", digest, err)
+	}
+	var parsed struct {
+		MediaType string       `json:"mediaType"`
+		Config    *descriptor  `json:"config"`
+		Layers    []descriptor `json:"layers"`
+		Manifests []descriptor `json:"manifests"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return "", fmt.Errorf("failed to decode manifest %s: %w", digest, err)
+	}
+	if mediaType == "" {
+		mediaType = parsed.MediaType
+	}
+
+	if IsIndexMediaType(mediaType) || len(parsed.Manifests) > 0 {
+		for _, child := range parsed.Manifests {
+			if _, err := a.pushManifest(ctx, c, repo, child.Digest, child.Digest, child.MediaType, stats); err != nil {
+				return "", err
+			}
+		}
+	} else {
+		blobs := parsed.Layers
+		if parsed.Config != nil {
+			blobs = append([]descriptor{*parsed.Config}, blobs...)
+		}
+		for _, blob := range blobs {
+			// Foreign (non-distributable) layers are fetched from their URLs by clients, never stored
+			if len(blob.URLs) > 0 || strings.Contains(blob.MediaType, "foreign") || strings.Contains(blob.MediaType, "nondistributable") {
+				continue
+			}
+			f, err := a.layoutBlob(blob.Digest)
+			if err != nil {
+				return "", err
+			}
+			if err := a.pushFile(ctx, c, repo, f, stats); err != nil {
+				return "", fmt.Errorf("failed to push blob %s: %w", blob.Digest, err)
+			}
+		}
+	}
+
+	if _, err := c.PutManifest(ctx, repo, ref, mediaType, body); err != nil {
+		return "", err
+	}
+	return digest, nil
+}
+
+// pushLegacyImage pushes an image of an older docker save archive, whose config and uncompressed
+// layers are files named in manifest.json, under a Docker image manifest made for it
+func (a *ImageArchive) pushLegacyImage(ctx context.Context, c *Client, m *legacyManifest, repo, tag string, stats *ImportStats) (string, error) {
+	config, ok := a.files[path.Clean(m.Config)]
+	if !ok {
+		return "", fmt.Errorf("config %s is not in the archive", m.Config)
+	}
+	if err := a.pushFile(ctx, c, repo, config, stats); err != nil {
+		return "", fmt.Errorf("failed to push config: %w", err)
+	}
+	manifest := struct {
+		SchemaVersion int          `json:"schemaVersion"`
+		MediaType     string       `json:"mediaType"`
+		Config        descriptor   `json:"config"`
+		Layers        []descriptor `json:"layers"`
+	}{
+		SchemaVersion: 2,
+		MediaType:     MediaTypeDockerManifest,
+		Config:        descriptor{MediaType: "application/vnd.docker.container.image.v1+json", Size: config.size, Digest: config.digest},
+		Layers:        []descriptor{},
+	}
+	for _, name := range m.Layers {
+		layer, ok := a.files[path.Clean(name)]
+		if !ok {
+			return "", fmt.Errorf("layer %s is not in the archive", name)
+		}
+		mediaType := "application/vnd.docker.image.rootfs.diff.tar"
+		magic := make([]byte, 2)
+		if _, err := a.spool.ReadAt(magic, layer.offset); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+			mediaType += ".gzip"
+		}
+		if err := a.pushFile(ctx, c, repo, layer, stats); err != nil {
+			return "", fmt.Errorf("failed to push layer %s: %w", name, err)
+		}
+		manifest.Layers = append(manifest.Layers, descriptor{MediaType: mediaType, Size: layer.size, Digest: layer.digest})
+	}
+
+	body, err := json.Marshal(manifest)
+	if err != nil {
+		return "", err
+	}
+	if _, err := c.PutManifest(ctx, repo, tag, MediaTypeDockerManifest, body); err != nil {
+		return "", err
+	}
+	return sha256Digest(body), nil
+}
+
+// pushFile uploads a file of the archive as a blob unless the repository already has it
+func (a *ImageArchive) pushFile(ctx context.Context, c *Client, repo string, f archiveFile, stats *ImportStats) error {
+	if exists, err := c.BlobExists(ctx, repo, f.digest); err == nil && exists {
+		stats.SkippedBlobs++
+		return nil
+	}
+	if err := c.PushBlob(ctx, repo, f.digest, f.size, io.NewSectionReader(a.spool, f.offset, f.size)); err != nil {
+		return err
+	}
+	stats.Blobs++
+	stats.Bytes += f.size
+	return nil
+}
@@ -0,0 +1,135 @@
+package registry
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// tarArchive builds a tar archive of the named files, in order
+func tarArchive(t *testing.T, files ...[2]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: f[0], Mode: 0644, Size: int64(len(f[1])), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(f[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// ociLayout returns the files of an OCI image layout holding one image tagged ref, whose manifest
+// is stored under manifestDigest
+func ociLayout(t *testing.T, ref, manifest, manifestDigest string) [][2]string {
+	t.Helper()
+	index, err := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"manifests": []map[string]interface{}{{
+			"mediaType":   "application/vnd.oci.image.manifest.v1+json",
+			"digest":      manifestDigest,
+			"size":        len(manifest),
+			"annotations": map[string]string{"io.containerd.image.name": ref},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return [][2]string{
+		{"oci-layout", `{"imageLayoutVersion":"1.0.0"}`},
+		{"index.json", string(index)},
+		{blobPath(manifestDigest), manifest},
+	}
+}
+
+func readArchive(t *testing.T, data []byte, maxBytes int64) (*ImageArchive, error) {
+	t.Helper()
+	spool, err := os.CreateTemp(t.TempDir(), "archive-*.tar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { spool.Close() })
+	return ReadImageArchive(bytes.NewReader(data), spool, maxBytes)
+}
+
+func TestReadImageArchiveFindsOCIImages(t *testing.T) {
+	manifest := `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","layers":[]}`
+	data := tarArchive(t, ociLayout(t, "docker.io/library/alpine:3.20", manifest, sha256Digest([]byte(manifest)))...)
+
+	a, err := readArchive(t, data, 1<<20)
+	if err != nil {
+		t.Fatalf("ReadImageArchive: %v", err)
+	}
+	if len(a.Images) != 1 || a.Images[0].Repository != "alpine" || a.Images[0].Tag != "3.20" {
+		t.Fatalf("images = %+v, want alpine:3.20", a.Images)
+	}
+}
+
+func TestReadImageArchiveRejectsOversizedArchives(t *testing.T) {
+	data := tarArchive(t, [2]string{"layer.tar", strings.Repeat("x", 64<<10)})
+
+	if _, err := readArchive(t, data, 16<<10); !errors.Is(err, ErrArchiveTooLarge) {
+		t.Fatalf("err = %v, want ErrArchiveTooLarge", err)
+	}
+}
+
+func TestReadImageArchiveLimitsDecompressedSize(t *testing.T) {
+	// Compresses to a few hundred bytes but expands far beyond the limit
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(tarArchive(t, [2]string{"layer.tar", strings.Repeat("\x00", 1<<20)}))
+	zw.Close()
+	if gz.Len() >= 16<<10 {
+		t.Fatalf("compressed archive is %d bytes, expected it under the limit", gz.Len())
+	}
+
+	if _, err := readArchive(t, gz.Bytes(), 16<<10); !errors.Is(err, ErrArchiveTooLarge) {
+		t.Fatalf("err = %v, want ErrArchiveTooLarge", err)
+	}
+}
+
+func TestPushImageRejectsBlobsNotMatchingTheirDigest(t *testing.T) {
+	manifest := `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","layers":[]}`
+	// The manifest is stored under the digest of different content
+	data := tarArchive(t, ociLayout(t, "team/api:v1", manifest, sha256Digest([]byte("something else")))...)
+
+	a, err := readArchive(t, data, 1<<20)
+	if err != nil {
+		t.Fatalf("ReadImageArchive: %v", err)
+	}
+	// The digest is checked before the registry is contacted, so no client is needed
+	_, err = a.PushImage(context.Background(), nil, a.Images[0], "team/api", "v1", &ImportStats{})
+	if err == nil || !strings.Contains(err.Error(), "does not match its digest") {
+		t.Fatalf("err = %v, want a digest mismatch", err)
+	}
+}
+
+func TestArchiveImageName(t *testing.T) {
+	tests := []struct {
+		ref, repo, tag string
+	}{
+		{"alpine:3.20", "alpine", "3.20"},
+		{"docker.io/library/alpine:3.20", "alpine", "3.20"},
+		{"registry.example.com:5000/team/api:v1", "team/api", "v1"},
+		{"localhost/team/api", "team/api", "latest"},
+		{"team/api@sha256:abc", "team/api", "latest"},
+	}
+	for _, tt := range tests {
+		repo, tag := archiveImageName(tt.ref)
+		if repo != tt.repo || tag != tt.tag {
+			t.Errorf("archiveImageName(%q) = %q, %q; want %q, %q", tt.ref, repo, tag, tt.repo, tt.tag)
+		}
+	}
+}
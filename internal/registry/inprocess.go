@@ -0,0 +1,327 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/distribution/distribution/v3/configuration"
+	_ "github.com/distribution/distribution/v3/registry/auth/htpasswd"
+	_ "github.com/distribution/distribution/v3/registry/auth/token"
+	"github.com/distribution/distribution/v3/registry/handlers"
+	"github.com/distribution/distribution/v3/registry/storage/driver/factory"
+	_ "github.com/distribution/distribution/v3/registry/storage/driver/filesystem"
+	"github.com/sirupsen/logrus"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// inProcessServer is the registry served by the dashboard process itself
+type inProcessServer struct {
+	app       *handlers.App
+	server    *http.Server
+	startedAt time.Time
+}
+
+// ServeInProcess makes the registry run inside the dashboard process, with the distribution
+// library, instead of in a registry:2 container, so Docker is not needed. It must be called
+// before Start.
+func (r *EmbeddedRegistry) ServeInProcess() {
+	r.inProcess = true
+	// The library logs every request, and every login challenge as a warning; the dashboard only
+	// wants its errors
+	logrus.SetLevel(logrus.ErrorLevel)
+}
+
+// InProcess reports whether the registry runs inside the dashboard process
+func (r *EmbeddedRegistry) InProcess() bool {
+	return r.inProcess
+}
+
+// inProcessConfig parses the rendered config.yml and points the paths meant for the container at
+// the host: the listen port, the data directory and the files of the config directory
+func (r *EmbeddedRegistry) inProcessConfig(rendered []byte, config *models.StorageConfig) (*configuration.Configuration, error) {
+	parsed, err := configuration.Parse(bytes.NewReader(rendered))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse registry config: %w", err)
+	}
+	parsed.HTTP.Addr = fmt.Sprintf(":%d", r.Port())
+	parsed.HTTP.Debug.Addr = ""
+	// Upload state is signed with the secret; one process needs no shared one
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	parsed.HTTP.Secret = hex.EncodeToString(secret)
+
+	if params, ok := parsed.Storage["filesystem"]; ok {
+		dataPath, _ := filepath.Abs(r.dataDir)
+		if config != nil && config.Type == "local" && config.LocalPath != "" && config.LocalPath != "/var/lib/registry" {
+			if absPath, err := filepath.Abs(config.LocalPath); err == nil {
+				dataPath = absPath
+			}
+		}
+		if err := os.MkdirAll(dataPath, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create data dir: %w", err)
+		}
+		params["rootdirectory"] = dataPath
+	}
+	// The library's Redis settings take a list of addresses
+	if config != nil && config.RedisCache {
+		parsed.Redis.Options.Addrs = []string{config.RedisAddr}
+	}
+	if params, ok := parsed.Auth["htpasswd"]; ok {
+		params["path"] = filepath.Join(r.configDir, htpasswdFile)
+	}
+	if params, ok := parsed.Auth["token"]; ok {
+		params["rootcertbundle"] = filepath.Join(r.configDir, tokenCertFile)
+	}
+	return parsed, nil
+}
+
+// startInProcessLocked serves the registry on its port from this process (must hold mu)
+func (r *EmbeddedRegistry) startInProcessLocked(config *models.StorageConfig) error {
+	if config == nil {
+		config = &models.StorageConfig{Type: "local", LocalPath: "/var/lib/registry"}
+	}
+	if config.Type == "" {
+		config.Type = "local"
+	}
+	if err := r.generateConfig(config); err != nil {
+		return err
+	}
+	rendered, err := os.ReadFile(filepath.Join(r.configDir, "config.yml"))
+	if err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	parsed, err := r.inProcessConfig(rendered, config)
+	if err != nil {
+		return err
+	}
+
+	r.stopInProcess()
+	app, err := newRegistryApp(parsed)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", parsed.HTTP.Addr)
+	if err != nil {
+		app.Shutdown()
+		return fmt.Errorf("failed to listen on %s: %w", parsed.HTTP.Addr, err)
+	}
+
+	r.redisCache.Store("")
+	if config.RedisCache {
+		r.redisCache.Store(config.RedisAddr)
+	}
+	r.proxyURL.Store(config.ProxyRemoteURL)
+	if config.ProxyRemoteURL != "" {
+		log.Printf("🪞 Registry runs as a pull-through cache of %s; pushes are rejected", config.ProxyRemoteURL)
+	}
+
+	srv := &inProcessServer{
+		app:       app,
+		server:    &http.Server{Handler: r.guardPushes(app), ReadHeaderTimeout: 30 * time.Second},
+		startedAt: time.Now(),
+	}
+	go func() {
+		if err := srv.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("⚠️  In-process registry stopped: %v", err)
+		}
+	}()
+	r.served.Store(srv)
+	log.Printf("✅ Docker Registry V2 served in-process at http://localhost:%d", r.Port())
+	return nil
+}
+
+// PushGuard reports whether a tag of the registry at a URL is immutable
+type PushGuard func(registryURL, repo, tag string) bool
+
+// RegistryCredentials returns the account the dashboard uses for the registry at a URL
+type RegistryCredentials func(registryURL string) (username, password string, err error)
+
+// pushGuard is what the in-process registry needs to check manifest pushes
+type pushGuard struct {
+	immutable   PushGuard
+	credentials RegistryCredentials
+}
+
+// maxManifestSize is the largest manifest the registry accepts
+const maxManifestSize = 4 << 20
+
+// GuardPushes makes the in-process registry reject manifest pushes that would point an existing
+// immutable tag at another image. The registry is asked for the current image with the dashboard's
+// own account, which credentials returns; with token authentication the dashboard signs itself a
+// token instead. A registry:2 container takes pushes directly, so it is not guarded.
+func (r *EmbeddedRegistry) GuardPushes(guard PushGuard, credentials RegistryCredentials) {
+	r.pushGuard.Store(&pushGuard{immutable: guard, credentials: credentials})
+}
+
+// pushGuardSubject is the subject of the tokens the push guard signs itself
+const pushGuardSubject = "dashboard"
+
+// guardPushes lets the push guard check manifest pushes by tag before the registry takes them.
+// The tag may be pushed again with the image it already points at. A push is refused whenever the
+// current image cannot be told, so a failing check never lets an immutable tag move.
+func (r *EmbeddedRegistry) guardPushes(app http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		guard := r.pushGuard.Load()
+		if guard == nil || req.Method != http.MethodPut {
+			app.ServeHTTP(w, req)
+			return
+		}
+		name, tag, ok := strings.Cut(strings.TrimPrefix(req.URL.Path, "/v2/"), "/manifests/")
+		if !ok || strings.Contains(tag, ":") || !guard.immutable(r.URL(), name, tag) {
+			app.ServeHTTP(w, req)
+			return
+		}
+
+		// Ask the registry itself for the current digest, with the dashboard's credentials
+		head, err := http.NewRequestWithContext(req.Context(), http.MethodHead, req.URL.String(), nil)
+		if err == nil {
+			err = r.authorizeGuard(head, guard, name)
+		}
+		if err != nil {
+			log.Printf("🔒 Rejected a push of the immutable tag %s:%s that could not be checked: %v", name, tag, err)
+			denyPush(w, fmt.Sprintf("tag %s of %s is immutable and could not be checked", tag, name))
+			return
+		}
+		head.Header.Set("Accept", manifestAccept)
+		current := &headResponse{header: http.Header{}, status: http.StatusOK}
+		app.ServeHTTP(current, head)
+		if current.status == http.StatusNotFound {
+			app.ServeHTTP(w, req)
+			return
+		}
+		digest := current.header.Get("Docker-Content-Digest")
+		if current.status != http.StatusOK || digest == "" {
+			log.Printf("🔒 Rejected a push of the immutable tag %s:%s: the registry answered its check with %d", name, tag, current.status)
+			denyPush(w, fmt.Sprintf("tag %s of %s is immutable and could not be checked", tag, name))
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(req.Body, maxManifestSize+1))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if sum := sha256.Sum256(body); "sha256:"+hex.EncodeToString(sum[:]) == digest {
+			req.Body = io.NopCloser(bytes.NewReader(body))
+			app.ServeHTTP(w, req)
+			return
+		}
+		log.Printf("🔒 Rejected a push overwriting the immutable tag %s:%s", name, tag)
+		denyPush(w, fmt.Sprintf("tag %s of %s is immutable", tag, name))
+	})
+}
+
+// authorizeGuard lets the push guard's request read a repository as the dashboard
+func (r *EmbeddedRegistry) authorizeGuard(head *http.Request, guard *pushGuard, repo string) error {
+	switch auth := r.Auth(); {
+	case auth.TokenRealm != "":
+		token, _, err := r.tokens.Issue(pushGuardSubject, []TokenAccess{{Type: "repository", Name: repo, Actions: []string{"pull"}}})
+		if err != nil {
+			return err
+		}
+		head.Header.Set("Authorization", "Bearer "+token)
+	case auth.Htpasswd:
+		if guard.credentials == nil {
+			return fmt.Errorf("the dashboard has no account on the registry")
+		}
+		username, password, err := guard.credentials(r.URL())
+		if err != nil {
+			return err
+		}
+		if username == "" {
+			return fmt.Errorf("the dashboard has no account on the registry")
+		}
+		head.SetBasicAuth(username, password)
+	}
+	return nil
+}
+
+// denyPush refuses a manifest push with the registry's DENIED error
+func denyPush(w http.ResponseWriter, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"errors": []map[string]string{{"code": "DENIED", "message": message}},
+	})
+}
+
+// headResponse keeps the status and headers of a HEAD request served in-process
+type headResponse struct {
+	header http.Header
+	status int
+}
+
+func (h *headResponse) Header() http.Header    { return h.header }
+func (h *headResponse) WriteHeader(status int) { h.status = status }
+
+func (h *headResponse) Write(b []byte) (int, error) {
+	if h.status == 0 {
+		h.status = http.StatusOK
+	}
+	return len(b), nil
+}
+
+// newRegistryApp creates the registry application, which panics on configs it cannot use
+func newRegistryApp(config *configuration.Configuration) (app *handlers.App, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("registry rejected the config: %v", p)
+		}
+	}()
+	return handlers.NewApp(context.Background(), config), nil
+}
+
+// stopInProcess shuts the in-process registry down, letting running requests finish
+func (r *EmbeddedRegistry) stopInProcess() {
+	srv := r.served.Swap(nil)
+	if srv == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	srv.server.Shutdown(ctx)
+	srv.app.Shutdown()
+}
+
+// validateInProcess checks that the config parses and its storage driver can be created
+func (r *EmbeddedRegistry) validateInProcess(rendered []byte, config *models.StorageConfig) string {
+	if config != nil && (config.Type == "gcs" || config.Type == "swift") {
+		return fmt.Sprintf("%s storage needs the registry container; start the dashboard without --no-docker", config.Type)
+	}
+	if config != nil && config.RedisCache && config.RedisAddr == "" {
+		return "the Redis cache needs a Redis address when the registry runs without Docker"
+	}
+	parsed, err := r.inProcessConfig(rendered, config)
+	if err != nil {
+		return err.Error()
+	}
+	if _, err := factory.Create(context.Background(), parsed.Storage.Type(), parsed.Storage.Parameters()); err != nil {
+		return fmt.Sprintf("registry rejected the storage settings: %v", err)
+	}
+	return ""
+}
+
+// inProcessProxyVars returns the expvar counters of the in-process pull-through cache, the same
+// document the container's debug server serves
+func inProcessProxyVars() ([]byte, error) {
+	v := expvar.Get("registry")
+	if v == nil {
+		return nil, fmt.Errorf("the pull-through cache has no metrics yet")
+	}
+	return []byte(`{"registry":` + v.String() + `}`), nil
+}
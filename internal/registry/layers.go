@@ -0,0 +1,130 @@
+package registry
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// MaxLayerScanBytes caps how much uncompressed tar data is read when listing a layer
+const MaxLayerScanBytes = 4 << 30
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// OpenBlob streams a blob. Unlike other calls it has no overall timeout, so cancel it through ctx.
+func (c *Client) OpenBlob(ctx context.Context, repoName, digest string) (io.ReadCloser, error) {
+	resp, err := c.send(ctx, "GET", c.baseURL+fmt.Sprintf("/v2/%s/blobs/%s", repoName, digest), nil, nil, 0, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch blob: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("blob fetch failed with status %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// ListLayerFiles streams a layer blob and returns its tar index without buffering file contents.
+// Only entries under prefix are returned, at most maxFiles of them.
+func (c *Client) ListLayerFiles(ctx context.Context, repoName, digest, prefix string, maxFiles int) (*models.LayerListing, error) {
+	blob, err := c.OpenBlob(ctx, repoName, digest)
+	if err != nil {
+		return nil, err
+	}
+	defer blob.Close()
+
+	listing, err := readLayerIndex(blob, prefix, maxFiles)
+	if err != nil {
+		return nil, err
+	}
+	listing.Digest = digest
+	return listing, nil
+}
+
+func readLayerIndex(r io.Reader, prefix string, maxFiles int) (*models.LayerListing, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(4)
+
+	var stream io.Reader = br
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gzip layer: %w", err)
+		}
+		defer gz.Close()
+		stream = gz
+	case bytes.HasPrefix(magic, zstdMagic):
+		return nil, fmt.Errorf("zstd compressed layers are not supported")
+	}
+
+	limited := &io.LimitedReader{R: stream, N: MaxLayerScanBytes}
+	tr := tar.NewReader(limited)
+	prefix = strings.Trim(prefix, "/")
+
+	listing := &models.LayerListing{Files: []models.LayerFile{}}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if limited.N <= 0 {
+				// Hit the scan cap in the middle of an entry
+				listing.Truncated = true
+				break
+			}
+			return nil, fmt.Errorf("failed to read layer tar: %w", err)
+		}
+
+		name := strings.TrimPrefix(path.Clean("/"+hdr.Name), "/")
+		if prefix != "" && name != prefix && !strings.HasPrefix(name, prefix+"/") {
+			continue
+		}
+		if len(listing.Files) >= maxFiles {
+			listing.Truncated = true
+			break
+		}
+
+		base := path.Base(name)
+		file := models.LayerFile{
+			Path:     name,
+			Type:     layerEntryType(hdr.Typeflag),
+			Size:     hdr.Size,
+			Mode:     hdr.FileInfo().Mode().String(),
+			Whiteout: strings.HasPrefix(base, ".wh."),
+			ModTime:  hdr.ModTime,
+		}
+		if hdr.Typeflag == tar.TypeSymlink || hdr.Typeflag == tar.TypeLink {
+			file.LinkTarget = hdr.Linkname
+		}
+		listing.Files = append(listing.Files, file)
+	}
+	return listing, nil
+}
+
+func layerEntryType(flag byte) string {
+	switch flag {
+	case tar.TypeReg:
+		return "file"
+	case tar.TypeDir:
+		return "dir"
+	case tar.TypeSymlink:
+		return "symlink"
+	case tar.TypeLink:
+		return "hardlink"
+	}
+	return "other"
+}
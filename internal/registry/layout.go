@@ -0,0 +1,212 @@
+package registry
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// LayoutImage is one tag to include in an OCI image layout
+type LayoutImage struct {
+	Repository string
+	Tag        string
+}
+
+// LayoutStats is the progress of an OCI layout export
+type LayoutStats struct {
+	Images    int
+	Manifests int
+	Blobs     int
+	Bytes     int64 // Content bytes of the manifests and blobs written
+}
+
+// ociIndexEntry is a descriptor in the layout's index.json
+type ociIndexEntry struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// layoutWriter writes manifests and blobs of a registry into an OCI image layout tar
+type layoutWriter struct {
+	ctx        context.Context
+	c          *Client
+	tw         *tar.Writer
+	written    map[string]bool
+	stats      LayoutStats
+	onProgress func(LayoutStats)
+	modTime    time.Time
+}
+
+// WriteOCILayout streams a tar archive of an OCI image layout (oci-layout, blobs/sha256/...,
+// index.json) holding the images with all their platforms, layers and configs. Blobs shared between
+// images are written once. index.json names each image "repository:tag" in the
+// org.opencontainers.image.ref.name annotation and with the registry host in io.containerd.image.name.
+// Every blob is checked against its digest while it is streamed. onProgress (optional) receives
+// progress snapshots.
+func WriteOCILayout(ctx context.Context, c *Client, images []LayoutImage, w io.Writer, onProgress func(LayoutStats)) (LayoutStats, error) {
+	lw := &layoutWriter{
+		ctx:        ctx,
+		c:          c,
+		tw:         tar.NewWriter(w),
+		written:    make(map[string]bool),
+		onProgress: onProgress,
+		modTime:    time.Now(),
+	}
+	if err := lw.writeFile("oci-layout", []byte(`{"imageLayoutVersion":"1.0.0"}`)); err != nil {
+		return lw.stats, err
+	}
+
+	host := c.baseURL
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	index := struct {
+		SchemaVersion int             `json:"schemaVersion"`
+		MediaType     string          `json:"mediaType"`
+		Manifests     []ociIndexEntry `json:"manifests"`
+	}{SchemaVersion: 2, MediaType: MediaTypeOCIIndex, Manifests: []ociIndexEntry{}}
+
+	for _, img := range images {
+		entry, err := lw.writeManifest(img.Repository, img.Tag)
+		if err != nil {
+			return lw.stats, fmt.Errorf("%s:%s: %w", img.Repository, img.Tag, err)
+		}
+		entry.Annotations = map[string]string{
+			"org.opencontainers.image.ref.name": img.Repository + ":" + img.Tag,
+			"io.containerd.image.name":          host + "/" + img.Repository + ":" + img.Tag,
+		}
+		index.Manifests = append(index.Manifests, *entry)
+		lw.stats.Images++
+		lw.report()
+	}
+
+	body, _ := json.MarshalIndent(index, "", "  ")
+	if err := lw.writeFile("index.json", body); err != nil {
+		return lw.stats, err
+	}
+	return lw.stats, lw.tw.Close()
+}
+
+func (lw *layoutWriter) report() {
+	if lw.onProgress != nil {
+		lw.onProgress(lw.stats)
+	}
+}
+
+// writeManifest writes a manifest (recursively for lists/indexes) with its blobs and returns its descriptor
+func (lw *layoutWriter) writeManifest(repo, ref string) (*ociIndexEntry, error) {
+	if err := lw.ctx.Err(); err != nil {
+		return nil, err
+	}
+	body, mediaType, _, err := lw.c.GetRawManifest(lw.ctx, repo, ref)
+	if err != nil {
+		return nil, err
+	}
+	var parsed struct {
+		MediaType string       `json:"mediaType"`
+		Config    *descriptor  `json:"config"`
+		Layers    []descriptor `json:"layers"`
+		Manifests []descriptor `json:"manifests"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest %s: %w", ref, err)
+	}
+	if mediaType == "" {
+		mediaType = parsed.MediaType
+	}
+	sum := sha256.Sum256(body)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	if strings.HasPrefix(ref, "sha256:") && ref != digest {
+		return nil, fmt.Errorf("manifest %s does not match its digest", ref)
+	}
+
+	if IsIndexMediaType(mediaType) || len(parsed.Manifests) > 0 {
+		for _, child := range parsed.Manifests {
+			if _, err := lw.writeManifest(repo, child.Digest); err != nil {
+				return nil, fmt.Errorf("failed to export child manifest %s: %w", child.Digest, err)
+			}
+		}
+	} else {
+		blobs := parsed.Layers
+		if parsed.Config != nil {
+			blobs = append([]descriptor{*parsed.Config}, blobs...)
+		}
+		for _, blob := range blobs {
+			if err := lw.writeBlob(repo, blob); err != nil {
+				return nil, fmt.Errorf("failed to export blob %s: %w", blob.Digest, err)
+			}
+		}
+	}
+
+	if !lw.written[digest] {
+		lw.written[digest] = true
+		if err := lw.writeFile(blobPath(digest), body); err != nil {
+			return nil, err
+		}
+		lw.stats.Manifests++
+		lw.stats.Bytes += int64(len(body))
+		lw.report()
+	}
+	return &ociIndexEntry{MediaType: mediaType, Digest: digest, Size: int64(len(body))}, nil
+}
+
+// writeBlob streams a blob into the archive, verifying its size and digest
+func (lw *layoutWriter) writeBlob(repo string, blob descriptor) error {
+	if lw.written[blob.Digest] {
+		return nil
+	}
+	// Foreign (non-distributable) layers are fetched from their URLs by clients, never stored
+	if len(blob.URLs) > 0 || strings.Contains(blob.MediaType, "foreign") || strings.Contains(blob.MediaType, "nondistributable") {
+		return nil
+	}
+	if !strings.HasPrefix(blob.Digest, "sha256:") {
+		return fmt.Errorf("unsupported digest algorithm")
+	}
+
+	content, err := lw.c.OpenBlob(lw.ctx, repo, blob.Digest)
+	if err != nil {
+		return err
+	}
+	defer content.Close()
+
+	if err := lw.tw.WriteHeader(&tar.Header{Name: blobPath(blob.Digest), Mode: 0644, Size: blob.Size, ModTime: lw.modTime, Typeflag: tar.TypeReg}); err != nil {
+		return err
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(lw.tw, h), io.LimitReader(content, blob.Size))
+	if err != nil {
+		return err
+	}
+	if n != blob.Size {
+		return fmt.Errorf("blob is %d bytes, its descriptor says %d", n, blob.Size)
+	}
+	if got := "sha256:" + hex.EncodeToString(h.Sum(nil)); got != blob.Digest {
+		return fmt.Errorf("blob content hashes to %s", got)
+	}
+	lw.written[blob.Digest] = true
+	lw.stats.Blobs++
+	lw.stats.Bytes += n
+	lw.report()
+	return nil
+}
+
+func (lw *layoutWriter) writeFile(name string, content []byte) error {
+	if err := lw.tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), ModTime: lw.modTime, Typeflag: tar.TypeReg}); err != nil {
+		return err
+	}
+	_, err := lw.tw.Write(content)
+	return err
+}
+
+// blobPath is the location of a blob in an OCI image layout
+func blobPath(digest string) string {
+	return "blobs/" + strings.Replace(digest, ":", "/", 1)
+}
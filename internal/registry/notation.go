@@ -0,0 +1,429 @@
+package registry
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// notationArtifactType is the artifact type of Notation signature manifests
+const notationArtifactType = "application/vnd.cncf.notary.signature"
+
+// Validations of a Notation trust policy, in the order they run
+const (
+	validationIntegrity          = "integrity"
+	validationAuthenticity       = "authenticity"
+	validationAuthenticTimestamp = "authenticTimestamp"
+	validationExpiry             = "expiry"
+	validationRevocation         = "revocation"
+)
+
+// Actions a verification level takes on a failed validation
+const (
+	actionEnforce = "enforce"
+	actionLog     = "log"
+	actionSkip    = "skip"
+)
+
+// notationLevels are the actions of each verification level, by validation
+var notationLevels = map[string]map[string]string{
+	"strict": {
+		validationIntegrity: actionEnforce, validationAuthenticity: actionEnforce, validationAuthenticTimestamp: actionEnforce,
+		validationExpiry: actionEnforce, validationRevocation: actionEnforce,
+	},
+	"permissive": {
+		validationIntegrity: actionEnforce, validationAuthenticity: actionEnforce, validationAuthenticTimestamp: actionLog,
+		validationExpiry: actionLog, validationRevocation: actionLog,
+	},
+	"audit": {
+		validationIntegrity: actionEnforce, validationAuthenticity: actionLog, validationAuthenticTimestamp: actionLog,
+		validationExpiry: actionLog, validationRevocation: actionLog,
+	},
+	"skip": {},
+}
+
+// NotationTrustStoreTypes are the types of Notation trust stores
+var NotationTrustStoreTypes = []string{"ca", "signingAuthority", "tsa"}
+
+// ParseCertificates parses the certificates of a PEM bundle
+func ParseCertificates(bundle string) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	rest := []byte(bundle)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no PEM certificate found")
+	}
+	return certs, nil
+}
+
+// ValidateNotationTrustPolicy checks a trust policy the way Notation does before using it
+func ValidateNotationTrustPolicy(doc *models.NotationTrustPolicyDocument) error {
+	if doc.Version != "1.0" {
+		return fmt.Errorf("unsupported trust policy version %q, expected \"1.0\"", doc.Version)
+	}
+	names := map[string]bool{}
+	scopes := map[string]string{}
+	for _, p := range doc.TrustPolicies {
+		if p.Name == "" {
+			return errors.New("every trust policy needs a name")
+		}
+		if names[p.Name] {
+			return fmt.Errorf("trust policy %q is defined twice", p.Name)
+		}
+		names[p.Name] = true
+
+		if len(p.RegistryScopes) == 0 {
+			return fmt.Errorf("trust policy %q has no registry scopes", p.Name)
+		}
+		for _, scope := range p.RegistryScopes {
+			if scope == "*" && len(p.RegistryScopes) > 1 {
+				return fmt.Errorf("trust policy %q: the wildcard scope \"*\" cannot be combined with other scopes", p.Name)
+			}
+			if scope != "*" && (strings.Contains(scope, "*") || !strings.Contains(scope, "/")) {
+				return fmt.Errorf("trust policy %q: scope %q must be host/repository or \"*\"", p.Name, scope)
+			}
+			if other, ok := scopes[scope]; ok {
+				return fmt.Errorf("scope %q is in trust policies %q and %q", scope, other, p.Name)
+			}
+			scopes[scope] = p.Name
+		}
+
+		actions, ok := notationLevels[p.SignatureVerification.Level]
+		if !ok {
+			return fmt.Errorf("trust policy %q: level must be strict, permissive, audit or skip", p.Name)
+		}
+		for validation, action := range p.SignatureVerification.Override {
+			if _, ok := notationLevels["strict"][validation]; !ok || validation == validationIntegrity {
+				return fmt.Errorf("trust policy %q: validation %q cannot be overridden", p.Name, validation)
+			}
+			if action != actionEnforce && action != actionLog && action != actionSkip {
+				return fmt.Errorf("trust policy %q: override action must be enforce, log or skip", p.Name)
+			}
+		}
+		if len(actions) == 0 {
+			if len(p.TrustStores) > 0 || len(p.TrustedIdentities) > 0 {
+				return fmt.Errorf("trust policy %q: a skip policy takes no trust stores or trusted identities", p.Name)
+			}
+			continue
+		}
+		if len(p.TrustStores) == 0 || len(p.TrustedIdentities) == 0 {
+			return fmt.Errorf("trust policy %q needs trust stores and trusted identities", p.Name)
+		}
+		for _, store := range p.TrustStores {
+			typ, name, ok := strings.Cut(store, ":")
+			if !ok || name == "" || !slices.Contains(NotationTrustStoreTypes, typ) {
+				return fmt.Errorf("trust policy %q: trust store %q must be ca:<name>, signingAuthority:<name> or tsa:<name>", p.Name, store)
+			}
+		}
+		for _, identity := range p.TrustedIdentities {
+			if identity == "*" {
+				if len(p.TrustedIdentities) > 1 {
+					return fmt.Errorf("trust policy %q: the wildcard identity \"*\" cannot be combined with other identities", p.Name)
+				}
+				continue
+			}
+			dn, ok := strings.CutPrefix(identity, "x509.subject:")
+			if !ok || len(parseDN(dn)) == 0 {
+				return fmt.Errorf("trust policy %q: trusted identity %q must be \"x509.subject: <DN>\" or \"*\"", p.Name, identity)
+			}
+		}
+	}
+	return nil
+}
+
+// NotationTrust is a Notation trust policy with the certificates of the trust stores
+type NotationTrust struct {
+	policy *models.NotationTrustPolicyDocument
+	stores map[string][]*x509.Certificate // By type:name
+}
+
+// NewNotationTrust prepares a trust policy and the trust stores for verifying signatures
+func NewNotationTrust(policy *models.NotationTrustPolicyDocument, stores []models.NotationTrustStore) (*NotationTrust, error) {
+	t := &NotationTrust{policy: policy, stores: make(map[string][]*x509.Certificate, len(stores))}
+	for _, s := range stores {
+		certs, err := ParseCertificates(s.Certificates)
+		if err != nil {
+			return nil, fmt.Errorf("trust store %s:%s: %w", s.Type, s.Name, err)
+		}
+		t.stores[s.Type+":"+s.Name] = certs
+	}
+	return t, nil
+}
+
+// policyFor returns the trust policy of a host/repository scope: the one naming it, else the
+// wildcard one, nil when there is neither
+func (t *NotationTrust) policyFor(scope string) *models.NotationTrustPolicy {
+	var wildcard *models.NotationTrustPolicy
+	for i, p := range t.policy.TrustPolicies {
+		if slices.Contains(p.RegistryScopes, scope) {
+			return &t.policy.TrustPolicies[i]
+		}
+		if slices.Contains(p.RegistryScopes, "*") {
+			wildcard = &t.policy.TrustPolicies[i]
+		}
+	}
+	return wildcard
+}
+
+// authenticate checks that the certificate chain of an envelope leads to a certificate of the
+// policy's trust stores for its signing scheme, and that the signer is a trusted identity
+func (t *NotationTrust) authenticate(p *models.NotationTrustPolicy, env *notationEnvelope) error {
+	storeType := "ca"
+	if env.signingScheme == notationSchemeSigningAuthority {
+		storeType = "signingAuthority"
+	}
+	roots := x509.NewCertPool()
+	found := false
+	for _, store := range p.TrustStores {
+		if !strings.HasPrefix(store, storeType+":") {
+			continue
+		}
+		certs, ok := t.stores[store]
+		if !ok {
+			return fmt.Errorf("trust store %s does not exist", store)
+		}
+		for _, cert := range certs {
+			roots.AddCert(cert)
+		}
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("trust policy %q has no %s trust store for signing scheme %s", p.Name, storeType, env.signingScheme)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range env.certificates[1:] {
+		intermediates.AddCert(cert)
+	}
+	leaf := env.certificates[0]
+	_, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   env.signingTime,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil {
+		return fmt.Errorf("the certificate chain is not trusted: %v", err)
+	}
+
+	subject := parseDN(leaf.Subject.String())
+	for _, identity := range p.TrustedIdentities {
+		if identity == "*" {
+			return nil
+		}
+		dn, _ := strings.CutPrefix(identity, "x509.subject:")
+		matches := true
+		for attr, value := range parseDN(dn) {
+			if subject[attr] != value {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return nil
+		}
+	}
+	return fmt.Errorf("signer %q is not a trusted identity", leaf.Subject.String())
+}
+
+// parseDN splits a distinguished name such as "C=US, O=Acme, CN=Builder" into its attributes
+func parseDN(dn string) map[string]string {
+	attrs := map[string]string{}
+	var part strings.Builder
+	flush := func() {
+		if attr, value, ok := strings.Cut(part.String(), "="); ok && strings.TrimSpace(attr) != "" {
+			attrs[strings.ToUpper(strings.TrimSpace(attr))] = strings.TrimSpace(value)
+		}
+		part.Reset()
+	}
+	escaped := false
+	for _, r := range dn {
+		switch {
+		case escaped:
+			part.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == ',' || r == '+':
+			flush()
+		default:
+			part.WriteRune(r)
+		}
+	}
+	flush()
+	return attrs
+}
+
+// repositoryReference is the host/repository name of a repository of the registry, as trust policy
+// scopes and signature identities name it
+func (c *Client) repositoryReference(repoName string) string {
+	host := c.baseURL
+	if u, err := url.Parse(c.baseURL); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	return host + "/" + repoName
+}
+
+// VerifyNotation verifies the Notation signatures of a manifest against the trust policy that
+// covers the repository. The image is verified when one signature passes every validation the
+// policy enforces; revocation is not checked.
+func (c *Client) VerifyNotation(ctx context.Context, trust *NotationTrust, repoName, digest string) *models.NotationVerification {
+	v := &models.NotationVerification{Digest: digest, Signatures: []models.NotationSignature{}}
+	policy := trust.policyFor(c.repositoryReference(repoName))
+	if policy == nil {
+		v.Status = models.NotationNoPolicy
+		return v
+	}
+	v.Policy, v.Level = policy.Name, policy.SignatureVerification.Level
+	actions := map[string]string{}
+	for validation, action := range notationLevels[policy.SignatureVerification.Level] {
+		actions[validation] = action
+	}
+	if len(actions) == 0 {
+		v.Status = models.NotationSkipped
+		return v
+	}
+	for validation, action := range policy.SignatureVerification.Override {
+		if validation != validationIntegrity {
+			actions[validation] = action
+		}
+	}
+
+	referrers, err := c.ListReferrers(ctx, repoName, digest)
+	if err != nil {
+		v.Status, v.Error = models.NotationError, err.Error()
+		return v
+	}
+	target, _, _, err := c.GetRawManifest(ctx, repoName, digest)
+	if err != nil {
+		v.Status, v.Error = models.NotationError, err.Error()
+		return v
+	}
+	for _, ref := range referrers {
+		if ref.ArtifactType != notationArtifactType {
+			continue
+		}
+		sig := c.verifyNotationSignature(ctx, trust, policy, actions, repoName, ref.Digest, digest, int64(len(target)))
+		if sig.Verified {
+			v.Status = models.NotationVerified
+		}
+		v.Signatures = append(v.Signatures, sig)
+	}
+	switch {
+	case v.Status == models.NotationVerified:
+	case len(v.Signatures) == 0:
+		v.Status = models.NotationUnsigned
+	default:
+		v.Status = models.NotationFailed
+	}
+	return v
+}
+
+// verifyNotationSignature runs the validations of a trust policy on one signature of a manifest
+func (c *Client) verifyNotationSignature(ctx context.Context, trust *NotationTrust, policy *models.NotationTrustPolicy, actions map[string]string, repoName, sigDigest, digest string, size int64) models.NotationSignature {
+	sig := models.NotationSignature{Digest: sigDigest}
+	fail := func(validation string, err error) {
+		switch actions[validation] {
+		case actionEnforce:
+			sig.Failures = append(sig.Failures, validation+": "+err.Error())
+		case actionLog:
+			sig.Warnings = append(sig.Warnings, validation+": "+err.Error())
+		}
+	}
+
+	env, err := c.fetchNotationEnvelope(ctx, repoName, sigDigest, &sig)
+	if err == nil {
+		var payload struct {
+			TargetArtifact struct {
+				Digest string `json:"digest"`
+				Size   int64  `json:"size"`
+			} `json:"targetArtifact"`
+		}
+		if err = json.Unmarshal(env.payload, &payload); err != nil {
+			err = fmt.Errorf("invalid payload: %w", err)
+		} else if payload.TargetArtifact.Digest != digest || payload.TargetArtifact.Size != size {
+			err = fmt.Errorf("the signature is for %s, not this image", payload.TargetArtifact.Digest)
+		}
+	}
+	if err != nil {
+		fail(validationIntegrity, err)
+		return sig
+	}
+	sig.SigningScheme = env.signingScheme
+	sig.Signer = env.certificates[0].Subject.String()
+	sig.SigningTime = &env.signingTime
+	if !env.expiry.IsZero() {
+		sig.Expiry = &env.expiry
+	}
+
+	if actions[validationAuthenticity] != actionSkip {
+		if err := trust.authenticate(policy, env); err != nil {
+			fail(validationAuthenticity, err)
+		}
+	}
+	// Timestamp countersignatures are not verified, so a notary.x509 signing time is only a claim:
+	// the chain must be valid now instead
+	if env.signingScheme == notationSchemeX509 {
+		now := time.Now()
+		for _, cert := range env.certificates {
+			if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+				fail(validationAuthenticTimestamp, fmt.Errorf("certificate %q is not valid now", cert.Subject.String()))
+				break
+			}
+		}
+	}
+	if !env.expiry.IsZero() && time.Now().After(env.expiry) {
+		fail(validationExpiry, fmt.Errorf("the signature expired at %s", env.expiry.Format(time.RFC3339)))
+	}
+	sig.Verified = len(sig.Failures) == 0
+	return sig
+}
+
+// fetchNotationEnvelope downloads the envelope of a signature manifest, checks its digest and
+// parses it
+func (c *Client) fetchNotationEnvelope(ctx context.Context, repoName, sigDigest string, sig *models.NotationSignature) (*notationEnvelope, error) {
+	body, _, _, err := c.GetRawManifest(ctx, repoName, sigDigest)
+	if err != nil {
+		return nil, err
+	}
+	var manifest struct {
+		Layers []Referrer `json:"layers"`
+		Blobs  []Referrer `json:"blobs"` // OCI artifact manifests of early Notation releases
+	}
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid signature manifest: %w", err)
+	}
+	blobs := append(manifest.Layers, manifest.Blobs...)
+	if len(blobs) != 1 {
+		return nil, fmt.Errorf("the signature manifest has %d blobs, expected one envelope", len(blobs))
+	}
+	sig.EnvelopeType = blobs[0].MediaType
+	blob, err := c.getVerifiedBlob(ctx, repoName, blobs[0].Digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signature envelope: %w", err)
+	}
+	return parseNotationEnvelope(blobs[0].MediaType, blob)
+}
@@ -0,0 +1,462 @@
+package registry
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Media types of Notation signature envelopes and their payload
+const (
+	notationJWSMediaType     = "application/jose+json"
+	notationCOSEMediaType    = "application/cose"
+	notationPayloadMediaType = "application/vnd.cncf.notary.payload.v1+json"
+)
+
+// Notation signing schemes
+const (
+	notationSchemeX509             = "notary.x509"
+	notationSchemeSigningAuthority = "notary.x509.signingAuthority"
+)
+
+// Notation header parameters an envelope may mark critical
+const (
+	notationHeaderSigningScheme        = "io.cncf.notary.signingScheme"
+	notationHeaderSigningTime          = "io.cncf.notary.signingTime"
+	notationHeaderAuthenticSigningTime = "io.cncf.notary.authenticSigningTime"
+	notationHeaderExpiry               = "io.cncf.notary.expiry"
+)
+
+// notationEnvelope is a signature envelope whose signature matches the key of its signing
+// certificate; whether the certificate is trusted is up to the trust policy
+type notationEnvelope struct {
+	payload       []byte
+	certificates  []*x509.Certificate // Signing certificate first
+	signingScheme string
+	signingTime   time.Time
+	expiry        time.Time // Zero: the signature does not expire
+}
+
+// parseNotationEnvelope decodes a JWS or COSE envelope and checks its signature
+func parseNotationEnvelope(mediaType string, blob []byte) (*notationEnvelope, error) {
+	switch mediaType {
+	case notationJWSMediaType:
+		return parseNotationJWS(blob)
+	case notationCOSEMediaType:
+		return parseNotationCOSE(blob)
+	}
+	return nil, fmt.Errorf("unsupported signature envelope type %q", mediaType)
+}
+
+// checkCritical checks that the critical header parameters of an envelope are ones Notation
+// defines and this verifier understands, and that they are present
+func checkCritical(crit []string, present func(name string) bool) error {
+	if len(crit) == 0 {
+		return errors.New("the envelope has no critical headers")
+	}
+	for _, name := range crit {
+		switch name {
+		case notationHeaderSigningScheme, notationHeaderAuthenticSigningTime, notationHeaderExpiry:
+		default:
+			return fmt.Errorf("unsupported critical header %q", name)
+		}
+		if !present(name) {
+			return fmt.Errorf("critical header %q is missing", name)
+		}
+	}
+	return nil
+}
+
+// parseNotationJWS decodes a JWS envelope in JSON serialization
+func parseNotationJWS(blob []byte) (*notationEnvelope, error) {
+	var jws struct {
+		Payload   string `json:"payload"`
+		Protected string `json:"protected"`
+		Header    struct {
+			X5c []string `json:"x5c"`
+		} `json:"header"`
+		Signature string `json:"signature"`
+	}
+	if err := json.Unmarshal(blob, &jws); err != nil {
+		return nil, fmt.Errorf("invalid JWS envelope: %w", err)
+	}
+	protected, err := base64.RawURLEncoding.DecodeString(jws.Protected)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWS protected header: %w", err)
+	}
+	var fields map[string]json.RawMessage
+	var header struct {
+		Alg                  string     `json:"alg"`
+		Crit                 []string   `json:"crit"`
+		Cty                  string     `json:"cty"`
+		SigningScheme        string     `json:"io.cncf.notary.signingScheme"`
+		SigningTime          *time.Time `json:"io.cncf.notary.signingTime"`
+		AuthenticSigningTime *time.Time `json:"io.cncf.notary.authenticSigningTime"`
+		Expiry               *time.Time `json:"io.cncf.notary.expiry"`
+	}
+	if err := json.Unmarshal(protected, &fields); err != nil {
+		return nil, fmt.Errorf("invalid JWS protected header: %w", err)
+	}
+	if err := json.Unmarshal(protected, &header); err != nil {
+		return nil, fmt.Errorf("invalid JWS protected header: %w", err)
+	}
+	if err := checkCritical(header.Crit, func(name string) bool { _, ok := fields[name]; return ok }); err != nil {
+		return nil, err
+	}
+	if header.Cty != notationPayloadMediaType {
+		return nil, fmt.Errorf("unsupported payload content type %q", header.Cty)
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(jws.Payload)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWS payload: %w", err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(jws.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWS signature: %w", err)
+	}
+
+	env := &notationEnvelope{payload: payload, signingScheme: header.SigningScheme}
+	for _, der := range jws.Header.X5c {
+		raw, err := base64.StdEncoding.DecodeString(der)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate in x5c: %w", err)
+		}
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate in x5c: %w", err)
+		}
+		env.certificates = append(env.certificates, cert)
+	}
+	if header.SigningTime != nil {
+		env.signingTime = *header.SigningTime
+	}
+	if header.AuthenticSigningTime != nil {
+		env.signingTime = *header.AuthenticSigningTime
+	}
+	if header.Expiry != nil {
+		env.expiry = *header.Expiry
+	}
+	if err := env.check(); err != nil {
+		return nil, err
+	}
+	if err := verifySignature(header.Alg, env.certificates[0], []byte(jws.Protected+"."+jws.Payload), signature); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// COSE algorithm identifiers of the algorithms Notation signs with
+var coseAlgorithms = map[int64]string{
+	-37: "PS256", -38: "PS384", -39: "PS512",
+	-7: "ES256", -35: "ES384", -36: "ES512",
+}
+
+// COSE header labels
+const (
+	coseHeaderAlg         = 1
+	coseHeaderCrit        = 2
+	coseHeaderContentType = 3
+	coseHeaderX5Chain     = 33
+)
+
+// parseNotationCOSE decodes a COSE_Sign1 envelope
+func parseNotationCOSE(blob []byte) (*notationEnvelope, error) {
+	item, rest, err := decodeCBOR(blob)
+	if err != nil {
+		return nil, fmt.Errorf("invalid COSE envelope: %w", err)
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("invalid COSE envelope: trailing data")
+	}
+	if tag, ok := item.(cborTag); ok && tag.number == 18 {
+		item = tag.content
+	}
+	parts, ok := item.([]any)
+	if !ok || len(parts) != 4 {
+		return nil, errors.New("invalid COSE envelope: not a COSE_Sign1 message")
+	}
+	protected, ok1 := parts[0].([]byte)
+	unprotected, ok2 := parts[1].(map[any]any)
+	payload, ok3 := parts[2].([]byte)
+	signature, ok4 := parts[3].([]byte)
+	if !ok1 || !ok2 || !ok3 || !ok4 {
+		return nil, errors.New("invalid COSE envelope: not a COSE_Sign1 message")
+	}
+	item, _, err = decodeCBOR(protected)
+	if err != nil {
+		return nil, fmt.Errorf("invalid COSE protected header: %w", err)
+	}
+	header, ok := item.(map[any]any)
+	if !ok {
+		return nil, errors.New("invalid COSE protected header")
+	}
+
+	alg, _ := header[int64(coseHeaderAlg)].(int64)
+	algorithm, ok := coseAlgorithms[alg]
+	if !ok {
+		return nil, fmt.Errorf("unsupported COSE algorithm %d", alg)
+	}
+	var crit []string
+	critItems, _ := header[int64(coseHeaderCrit)].([]any)
+	for _, c := range critItems {
+		name, ok := c.(string)
+		if !ok {
+			return nil, fmt.Errorf("unsupported critical header %v", c)
+		}
+		crit = append(crit, name)
+	}
+	if err := checkCritical(crit, func(name string) bool { _, ok := header[name]; return ok }); err != nil {
+		return nil, err
+	}
+	if cty, _ := header[int64(coseHeaderContentType)].(string); cty != notationPayloadMediaType {
+		return nil, fmt.Errorf("unsupported payload content type %q", cty)
+	}
+
+	env := &notationEnvelope{payload: payload}
+	env.signingScheme, _ = header[notationHeaderSigningScheme].(string)
+	for name, t := range map[string]*time.Time{
+		notationHeaderSigningTime:          &env.signingTime,
+		notationHeaderAuthenticSigningTime: &env.signingTime,
+		notationHeaderExpiry:               &env.expiry,
+	} {
+		if v, ok := header[name]; ok {
+			tag, ok := v.(cborTag)
+			seconds, isInt := tag.content.(int64)
+			if !ok || tag.number != 1 || !isInt {
+				return nil, fmt.Errorf("invalid COSE header %q", name)
+			}
+			if name != notationHeaderSigningTime || t.IsZero() {
+				*t = time.Unix(seconds, 0)
+			}
+		}
+	}
+	chain := unprotected[int64(coseHeaderX5Chain)]
+	if der, ok := chain.([]byte); ok {
+		chain = []any{der}
+	}
+	chainItems, _ := chain.([]any)
+	for _, c := range chainItems {
+		der, ok := c.([]byte)
+		if !ok {
+			return nil, errors.New("invalid certificate in x5chain")
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("invalid certificate in x5chain: %w", err)
+		}
+		env.certificates = append(env.certificates, cert)
+	}
+	if err := env.check(); err != nil {
+		return nil, err
+	}
+	if err := verifySignature(algorithm, env.certificates[0], coseSignature1(protected, payload), signature); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// check checks the headers both envelope formats must have
+func (e *notationEnvelope) check() error {
+	if len(e.certificates) == 0 {
+		return errors.New("the envelope has no certificate chain")
+	}
+	if e.signingScheme != notationSchemeX509 && e.signingScheme != notationSchemeSigningAuthority {
+		return fmt.Errorf("unsupported signing scheme %q", e.signingScheme)
+	}
+	if e.signingTime.IsZero() {
+		return errors.New("the envelope has no signing time")
+	}
+	return nil
+}
+
+// verifySignature checks a PS256/384/512 or ES256/384/512 signature with the key of a certificate
+func verifySignature(alg string, cert *x509.Certificate, signed, signature []byte) error {
+	var hash crypto.Hash
+	var curve elliptic.Curve
+	switch alg {
+	case "PS256", "ES256":
+		hash, curve = crypto.SHA256, elliptic.P256()
+	case "PS384", "ES384":
+		hash, curve = crypto.SHA384, elliptic.P384()
+	case "PS512", "ES512":
+		hash, curve = crypto.SHA512, elliptic.P521()
+	default:
+		return fmt.Errorf("unsupported signature algorithm %q", alg)
+	}
+	h := hash.New()
+	h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		if alg[0] != 'P' {
+			return fmt.Errorf("algorithm %s does not match the RSA key of the signing certificate", alg)
+		}
+		if err := rsa.VerifyPSS(key, hash, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}); err != nil {
+			return errors.New("the signature does not match the signing certificate")
+		}
+	case *ecdsa.PublicKey:
+		if alg[0] != 'E' || key.Curve != curve {
+			return fmt.Errorf("algorithm %s does not match the EC key of the signing certificate", alg)
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("the signature does not match the signing certificate")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(key, digest, r, s) {
+			return errors.New("the signature does not match the signing certificate")
+		}
+	default:
+		return errors.New("unsupported key type of the signing certificate")
+	}
+	return nil
+}
+
+// coseSignature1 encodes the Sig_structure a COSE_Sign1 signature is computed over:
+// ["Signature1", protected, external_aad (empty), payload]
+func coseSignature1(protected, payload []byte) []byte {
+	out := []byte{0x84}
+	out = appendCBORHead(out, 3, uint64(len("Signature1")))
+	out = append(out, "Signature1"...)
+	out = appendCBORHead(out, 2, uint64(len(protected)))
+	out = append(out, protected...)
+	out = appendCBORHead(out, 2, 0)
+	out = appendCBORHead(out, 2, uint64(len(payload)))
+	return append(out, payload...)
+}
+
+// appendCBORHead appends the head of a CBOR data item with a major type and argument
+func appendCBORHead(out []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(out, major<<5|byte(n))
+	case n <= 0xff:
+		return append(out, major<<5|24, byte(n))
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(out, major<<5|25), uint16(n))
+	case n <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(out, major<<5|26), uint32(n))
+	}
+	return binary.BigEndian.AppendUint64(append(out, major<<5|27), n)
+}
+
+// cborTag is a tagged CBOR data item
+type cborTag struct {
+	number  uint64
+	content any
+}
+
+// decodeCBOR decodes the definite-length CBOR data item at the start of data into int64, []byte,
+// string, []any, map[any]any, cborTag, bool or nil, and returns what follows it. That is all
+// COSE envelopes use; floats and indefinite lengths are rejected.
+func decodeCBOR(data []byte) (any, []byte, error) {
+	return decodeCBORItem(data, 0)
+}
+
+func decodeCBORItem(data []byte, depth int) (any, []byte, error) {
+	if depth > 16 {
+		return nil, nil, errors.New("CBOR nesting too deep")
+	}
+	if len(data) == 0 {
+		return nil, nil, errors.New("unexpected end of CBOR data")
+	}
+	major, info := data[0]>>5, data[0]&0x1f
+	data = data[1:]
+
+	var n uint64
+	switch {
+	case info < 24:
+		n = uint64(info)
+	case info <= 27:
+		size := 1 << (info - 24)
+		if len(data) < size {
+			return nil, nil, errors.New("unexpected end of CBOR data")
+		}
+		for _, b := range data[:size] {
+			n = n<<8 | uint64(b)
+		}
+		data = data[size:]
+	default:
+		return nil, nil, errors.New("unsupported CBOR encoding")
+	}
+
+	switch major {
+	case 0, 1:
+		if n > 1<<63-1 {
+			return nil, nil, errors.New("CBOR integer out of range")
+		}
+		if major == 1 {
+			return -1 - int64(n), data, nil
+		}
+		return int64(n), data, nil
+	case 2, 3:
+		if uint64(len(data)) < n {
+			return nil, nil, errors.New("unexpected end of CBOR data")
+		}
+		if major == 2 {
+			return data[:n], data[n:], nil
+		}
+		return string(data[:n]), data[n:], nil
+	case 4:
+		if n > uint64(len(data)) {
+			return nil, nil, errors.New("unexpected end of CBOR data")
+		}
+		items := make([]any, 0, n)
+		for i := uint64(0); i < n; i++ {
+			item, rest, err := decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			items, data = append(items, item), rest
+		}
+		return items, data, nil
+	case 5:
+		if n > uint64(len(data)) {
+			return nil, nil, errors.New("unexpected end of CBOR data")
+		}
+		m := make(map[any]any, n)
+		for i := uint64(0); i < n; i++ {
+			key, rest, err := decodeCBORItem(data, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, nil, errors.New("unsupported CBOR map key")
+			}
+			value, rest, err := decodeCBORItem(rest, depth+1)
+			if err != nil {
+				return nil, nil, err
+			}
+			m[key], data = value, rest
+		}
+		return m, data, nil
+	case 6:
+		content, rest, err := decodeCBORItem(data, depth+1)
+		if err != nil {
+			return nil, nil, err
+		}
+		return cborTag{number: n, content: content}, rest, nil
+	}
+	switch n {
+	case 20:
+		return false, data, nil
+	case 21:
+		return true, data, nil
+	case 22, 23:
+		return nil, data, nil
+	}
+	return nil, nil, errors.New("unsupported CBOR simple value")
+}
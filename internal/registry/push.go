@@ -0,0 +1,235 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// defaultUploadChunkSize is the upload chunk size unless -upload-chunk-mb says otherwise
+const defaultUploadChunkSize = 64 << 20
+
+// uploadChunkSize is the largest blob uploaded in one request. Larger blobs are uploaded in chunks
+// of this size, which gets them past proxies that cap request bodies; 0 uploads every blob at once.
+var uploadChunkSize atomic.Int64
+
+func init() {
+	uploadChunkSize.Store(defaultUploadChunkSize)
+}
+
+// SetUploadChunkSize sets the size of the chunks large blobs are uploaded in (0 disables chunked uploads)
+func SetUploadChunkSize(size int64) {
+	if size < 0 {
+		size = 0
+	}
+	uploadChunkSize.Store(size)
+}
+
+// PutManifest uploads a manifest under a tag or digest and returns its digest
+func (c *Client) PutManifest(ctx context.Context, repoName, reference, mediaType string, manifest []byte) (string, error) {
+	resp, err := c.send(ctx, "PUT", c.baseURL+fmt.Sprintf("/v2/%s/manifests/%s", repoName, reference),
+		map[string]string{"Content-Type": mediaType}, bytes.NewReader(manifest), int64(len(manifest)), false)
+	if err != nil {
+		return "", fmt.Errorf("failed to put manifest: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("registry returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return resp.Header.Get("Docker-Content-Digest"), nil
+}
+
+// Retag points targetTag at the manifest of sourceTag in the same repository (no blobs are transferred)
+func (c *Client) Retag(ctx context.Context, repoName, sourceTag, targetTag string) (string, error) {
+	manifest, mediaType, _, err := c.GetRawManifest(ctx, repoName, sourceTag)
+	if err != nil {
+		return "", err
+	}
+	return c.PutManifest(ctx, repoName, targetTag, mediaType, manifest)
+}
+
+// BlobExists checks whether a repository already has a blob
+func (c *Client) BlobExists(ctx context.Context, repoName, digest string) (bool, error) {
+	resp, err := c.doRequest(ctx, "HEAD", fmt.Sprintf("/v2/%s/blobs/%s", repoName, digest), nil)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, fmt.Errorf("blob check returned status %d", resp.StatusCode)
+}
+
+// MountBlob links a blob from another repository of the same registry without uploading it.
+// It returns false when the registry started a regular upload instead.
+func (c *Client) MountBlob(ctx context.Context, repoName, digest, fromRepo string) (bool, error) {
+	path := fmt.Sprintf("/v2/%s/blobs/uploads/?mount=%s&from=%s", repoName, url.QueryEscape(digest), url.QueryEscape(fromRepo))
+	resp, err := c.doRequest(ctx, "POST", path, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to mount blob: %w", err)
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		return true, nil
+	case http.StatusAccepted:
+		// Mount not possible; the opened upload session is left to expire
+		return false, nil
+	}
+	return false, fmt.Errorf("blob mount returned status %d", resp.StatusCode)
+}
+
+// PushBlob uploads a blob of known size: in a single request (POST + PUT), or when it is larger
+// than the upload chunk size in chunks (POST, a PATCH per chunk, PUT)
+func (c *Client) PushBlob(ctx context.Context, repoName, digest string, size int64, content io.Reader) error {
+	location, minChunk, err := c.startUpload(ctx, repoName)
+	if err != nil {
+		return err
+	}
+	chunk := uploadChunkSize.Load()
+	if chunk > 0 && chunk < minChunk {
+		chunk = minChunk
+	}
+	if chunk > 0 && size > chunk {
+		if location, err = c.uploadChunks(ctx, location, content, size, chunk); err != nil {
+			return err
+		}
+		content, size = nil, 0
+	}
+
+	q := location.Query()
+	q.Set("digest", digest)
+	location.RawQuery = q.Encode()
+	resp, err := c.send(ctx, "PUT", location.String(), map[string]string{"Content-Type": "application/octet-stream"}, content, size, true)
+	if err != nil {
+		return fmt.Errorf("failed to upload blob: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("blob upload returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
+
+// startUpload opens an upload session and returns its location with the smallest chunk the
+// registry accepts (OCI-Chunk-Min-Length), 0 when it names none
+func (c *Client) startUpload(ctx context.Context, repoName string) (*url.URL, int64, error) {
+	resp, err := c.send(ctx, "POST", c.baseURL+fmt.Sprintf("/v2/%s/blobs/uploads/", repoName), nil, nil, 0, false)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to start upload: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return nil, 0, fmt.Errorf("upload start returned status %d", resp.StatusCode)
+	}
+
+	location, err := c.resolveLocation(resp.Header.Get("Location"))
+	if err != nil {
+		return nil, 0, err
+	}
+	minChunk, _ := strconv.ParseInt(resp.Header.Get("OCI-Chunk-Min-Length"), 10, 64)
+	return location, minChunk, nil
+}
+
+// uploadChunks sends size bytes of content in chunks, each to the location the registry returned
+// for the previous one, and returns the location the upload is completed at
+func (c *Client) uploadChunks(ctx context.Context, location *url.URL, content io.Reader, size, chunk int64) (*url.URL, error) {
+	for offset := int64(0); offset < size; {
+		n := min(chunk, size-offset)
+		headers := map[string]string{
+			"Content-Type":  "application/octet-stream",
+			"Content-Range": fmt.Sprintf("%d-%d", offset, offset+n-1),
+		}
+		resp, err := c.send(ctx, "PATCH", location.String(), headers, io.LimitReader(content, n), n, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upload chunk at offset %d: %w", offset, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusAccepted {
+			return nil, fmt.Errorf("chunk upload at offset %d returned status %d: %s", offset, resp.StatusCode, string(body))
+		}
+		if location, err = c.resolveLocation(resp.Header.Get("Location")); err != nil {
+			return nil, err
+		}
+		offset += n
+	}
+	return location, nil
+}
+
+// resolveLocation turns an upload Location header (absolute or relative) into a URL
+func (c *Client) resolveLocation(location string) (*url.URL, error) {
+	if location == "" {
+		return nil, fmt.Errorf("registry did not return an upload location")
+	}
+	base, err := url.Parse(c.baseURL)
+	if err != nil {
+		return nil, err
+	}
+	ref, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upload location %q: %w", location, err)
+	}
+	return base.ResolveReference(ref), nil
+}
+
+// send performs a request with an optional body. Requests other than streaming ones are bounded
+// by the timeout of their method, which keeps running until the response body is closed.
+func (c *Client) send(ctx context.Context, method, rawURL string, headers map[string]string, body io.Reader, size int64, stream bool) (*http.Response, error) {
+	cancel := context.CancelFunc(func() {})
+	if !stream {
+		ctx, cancel = context.WithTimeout(ctx, callTimeout(method))
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, body)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases the timeout of a request when its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// SameRegistry reports whether two clients talk to the same registry
+func (c *Client) SameRegistry(other *Client) bool {
+	return strings.TrimRight(c.baseURL, "/") == strings.TrimRight(other.baseURL, "/")
+}
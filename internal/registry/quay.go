@@ -0,0 +1,138 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// QuayTokenUser is the registry username that goes with a Quay OAuth token
+const QuayTokenUser = "$oauthtoken"
+
+// quayMaxPages bounds the pages read from one Quay API listing
+const quayMaxPages = 200
+
+// quayAPI is how a client of a Quay registry lists repositories and tags: through the Quay API,
+// since Quay restricts /v2/_catalog
+type quayAPI struct {
+	token      string   // OAuth application token, sent as a bearer token
+	namespaces []string // Namespaces to list; empty: the token's user and its organizations
+}
+
+// quayGet sends a GET to the Quay API and decodes the JSON answer
+func (c *Client) quayGet(ctx context.Context, path string, out interface{}) error {
+	ctx, cancel := context.WithTimeout(ctx, requestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.quay.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.quay.token)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("Quay API returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode Quay response: %w", err)
+	}
+	return nil
+}
+
+// quayNamespaces returns the configured namespaces, or the token's user and its organizations
+func (c *Client) quayNamespaces(ctx context.Context) ([]string, error) {
+	if len(c.quay.namespaces) > 0 {
+		return c.quay.namespaces, nil
+	}
+	var user struct {
+		Username      string `json:"username"`
+		Organizations []struct {
+			Name string `json:"name"`
+		} `json:"organizations"`
+	}
+	if err := c.quayGet(ctx, "/api/v1/user/", &user); err != nil {
+		return nil, fmt.Errorf("no namespaces configured and the token's user cannot be read (it needs the user:read scope): %w", err)
+	}
+	namespaces := []string{user.Username}
+	for _, org := range user.Organizations {
+		namespaces = append(namespaces, org.Name)
+	}
+	return namespaces, nil
+}
+
+// listQuayRepositories lists the repositories of the client's namespaces with the Quay API
+func (c *Client) listQuayRepositories(ctx context.Context) ([]models.Repository, error) {
+	namespaces, err := c.quayNamespaces(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories: %w", err)
+	}
+	repos := []models.Repository{}
+	for _, ns := range namespaces {
+		next := ""
+		for page := 0; page < quayMaxPages; page++ {
+			q := url.Values{"namespace": {ns}}
+			if next != "" {
+				q.Set("next_page", next)
+			}
+			var body struct {
+				Repositories []struct {
+					Namespace string `json:"namespace"`
+					Name      string `json:"name"`
+				} `json:"repositories"`
+				NextPage string `json:"next_page"`
+			}
+			if err := c.quayGet(ctx, "/api/v1/repository?"+q.Encode(), &body); err != nil {
+				return nil, fmt.Errorf("failed to list repositories of %s: %w", ns, err)
+			}
+			for _, r := range body.Repositories {
+				repos = append(repos, models.Repository{Name: r.Namespace + "/" + r.Name})
+			}
+			if next = body.NextPage; next == "" {
+				break
+			}
+		}
+	}
+	return repos, nil
+}
+
+// listQuayTags lists the active tags of a repository with their digests through the Quay API
+func (c *Client) listQuayTags(ctx context.Context, repoName string) ([]models.Tag, error) {
+	ns, name, ok := strings.Cut(repoName, "/")
+	if !ok {
+		return nil, fmt.Errorf("Quay repository %q has no namespace", repoName)
+	}
+	tags := []models.Tag{}
+	for page := 1; page <= quayMaxPages; page++ {
+		var body struct {
+			Tags []struct {
+				Name           string `json:"name"`
+				ManifestDigest string `json:"manifest_digest"`
+			} `json:"tags"`
+			HasAdditional bool `json:"has_additional"`
+		}
+		path := fmt.Sprintf("/api/v1/repository/%s/%s/tag/?onlyActiveTags=true&limit=100&page=%d", url.PathEscape(ns), url.PathEscape(name), page)
+		if err := c.quayGet(ctx, path, &body); err != nil {
+			return nil, fmt.Errorf("failed to list tags: %w", err)
+		}
+		for _, t := range body.Tags {
+			tags = append(tags, models.Tag{Name: t.Name, Digest: t.ManifestDigest})
+		}
+		if !body.HasAdditional {
+			break
+		}
+	}
+	return tags, nil
+}
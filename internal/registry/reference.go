@@ -0,0 +1,53 @@
+package registry
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// dockerHubRegistry is the V2 endpoint of Docker Hub images written without a registry host
+const dockerHubRegistry = "https://registry-1.docker.io"
+
+// repositoryName is the repository name grammar of the OCI distribution spec
+var repositoryName = regexp.MustCompile(`^[a-z0-9]+((\.|_|__|-+)[a-z0-9]+)*(/[a-z0-9]+((\.|_|__|-+)[a-z0-9]+)*)*$`)
+
+// ValidRepositoryName reports whether name is a repository name registries accept
+func ValidRepositoryName(name string) bool {
+	return len(name) <= 255 && repositoryName.MatchString(name)
+}
+
+// ParseImageReference splits an image reference such as alpine:3.20, ghcr.io/org/app:v1 or
+// localhost:5000/team/app into the registry base URL, repository and tag ("latest" if omitted).
+// Docker Hub names get their library/ namespace; an http:// prefix selects plain HTTP.
+func ParseImageReference(ref string) (string, string, string, error) {
+	scheme := "https://"
+	if rest, ok := strings.CutPrefix(ref, "http://"); ok {
+		scheme, ref = "http://", rest
+	} else {
+		ref = strings.TrimPrefix(ref, "https://")
+	}
+	if strings.Contains(ref, "@") {
+		return "", "", "", fmt.Errorf("image %q: digest references are not supported, use a tag", ref)
+	}
+
+	name, tag := ref, "latest"
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		name, tag = ref[:i], ref[i+1:]
+	}
+	if name == "" || tag == "" {
+		return "", "", "", fmt.Errorf("invalid image reference %q", ref)
+	}
+
+	baseURL := dockerHubRegistry
+	if host, repo, ok := strings.Cut(name, "/"); ok && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		name = repo
+		if host != "docker.io" && host != "index.docker.io" {
+			baseURL = scheme + host
+		}
+	}
+	if baseURL == dockerHubRegistry && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	return baseURL, name, tag, nil
+}
@@ -1,6 +1,7 @@
 package registry
 
 import (
+	"context"
 	"docker-registry-dashboard/internal/models"
 	"fmt"
 	"log"
@@ -10,10 +11,19 @@ import (
 	"time"
 )
 
-// RunRetention executes the retention policy for a registry
-func RunRetention(reg *models.Registry, policy *models.RetentionPolicy) ([]models.RetentionLog, error) {
+// RunRetention executes the retention policy for a registry.
+// Repositories with an assigned template (keyed by repository name) use the template's limits instead.
+// Cancelling ctx stops the run before its next delete; the logs of what was done are returned with the error.
+func RunRetention(ctx context.Context, reg *models.Registry, policy *models.RetentionPolicy, templates map[string]models.RetentionTemplate) ([]models.RetentionLog, error) {
+	return RunRetentionWhere(ctx, reg, policy, templates, nil, nil)
+}
+
+// RunRetentionWhere is RunRetention limited to the repositories allow accepts; a nil allow
+// accepts all of them. Repositories it skips are left alone, templates included. The tags
+// immutable reports are always kept, whatever the policy says.
+func RunRetentionWhere(ctx context.Context, reg *models.Registry, policy *models.RetentionPolicy, templates map[string]models.RetentionTemplate, allow func(repo string) bool, immutable func(repo, tag string) bool) ([]models.RetentionLog, error) {
 	client := NewClientFromRegistry(reg)
-	repos, err := client.ListRepositories()
+	repos, err := client.ListRepositories(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list repositories: %w", err)
 	}
@@ -38,15 +48,36 @@ func RunRetention(reg *models.Registry, policy *models.RetentionPolicy) ([]model
 
 	// Process each repository
 	for _, repo := range repos {
-		// Repo Filtering
-		if filterRepoRe != nil && !filterRepoRe.MatchString(repo.Name) {
-			continue // Skip not matching
+		if err := ctx.Err(); err != nil {
+			return logs, fmt.Errorf("retention run interrupted: %w", err)
 		}
-		if excludeRepoRe != nil && excludeRepoRe.MatchString(repo.Name) {
-			continue // Skip excluded
+		if allow != nil && !allow(repo.Name) {
+			continue
+		}
+		repoPolicy := policy
+		if t, ok := templates[repo.Name]; ok {
+			// An explicitly assigned template applies regardless of the registry's repo filters
+			repoPolicy = &models.RetentionPolicy{
+				RegistryID:    policy.RegistryID,
+				KeepLastCount: t.KeepLastCount,
+				KeepDays:      t.KeepDays,
+				DryRun:        policy.DryRun,
+				ExcludeTags:   t.ExcludeTags,
+			}
+		} else {
+			// Repo Filtering
+			if filterRepoRe != nil && !filterRepoRe.MatchString(repo.Name) {
+				continue // Skip not matching
+			}
+			if excludeRepoRe != nil && excludeRepoRe.MatchString(repo.Name) {
+				continue // Skip excluded
+			}
 		}
 
-		repoLogs, err := processRepository(client, repo.Name, policy)
+		repoLogs, err := processRepository(ctx, client, repo.Name, repoPolicy, immutable)
+		if ctx.Err() != nil {
+			return append(logs, repoLogs...), fmt.Errorf("retention run interrupted: %w", ctx.Err())
+		}
 		if err != nil {
 			log.Printf("⚠️ Error processing repo %s: %v", repo.Name, err)
 			continue
@@ -64,10 +95,19 @@ type imageInfo struct {
 	Digest    string
 	Created   time.Time
 	Protected bool
+	Immutable bool             // Matches an immutable tag rule, so no policy may delete it
+	Blobs     map[string]int64 // Blobs referenced by the image; only fetched for size budgets
+}
+
+type tagDecision struct {
+	img        imageInfo
+	keep       bool
+	reason     string
+	overBudget bool
 }
 
-func processRepository(client *Client, repoName string, policy *models.RetentionPolicy) ([]models.RetentionLog, error) {
-	tags, err := client.ListTags(repoName)
+func processRepository(ctx context.Context, client *Client, repoName string, policy *models.RetentionPolicy, immutable func(repo, tag string) bool) ([]models.RetentionLog, error) {
+	tags, err := client.ListTags(ctx, repoName)
 	if err != nil {
 		return nil, err
 	}
@@ -105,11 +145,12 @@ func processRepository(client *Client, repoName string, policy *models.Retention
 			if excludeTagRe != nil && excludeTagRe.MatchString(t) {
 				isProtected = true
 			}
+			isImmutable := immutable != nil && immutable(repoName, t)
 
 			// If protected, do we still fetch created time?
 			// Yes, for correct sorting (KeepLastCount logic).
 
-			created, err := client.GetImageCreated(repoName, t)
+			created, err := client.GetImageCreated(ctx, repoName, t)
 			if err != nil {
 				// Fallback: try to guess or just skip?
 				// Logging error and skipping is safer than deleting wrongly.
@@ -117,13 +158,20 @@ func processRepository(client *Client, repoName string, policy *models.Retention
 				return
 			}
 
-			digest, err := client.GetDigestForTag(repoName, t)
+			digest, err := client.GetDigestForTag(ctx, repoName, t)
 			if err != nil {
 				return
 			}
 
+			var blobs map[string]int64
+			if policy.MaxRepoSizeGB > 0 {
+				if blobs, err = imageBlobs(ctx, client, repoName, digest); err != nil {
+					log.Printf("⚠️ Failed to get size of %s:%s: %v", repoName, t, err)
+				}
+			}
+
 			mu.Lock()
-			images = append(images, imageInfo{Tag: t, Digest: digest, Created: created, Protected: isProtected})
+			images = append(images, imageInfo{Tag: t, Digest: digest, Created: created, Protected: isProtected || isImmutable, Immutable: isImmutable, Blobs: blobs})
 			mu.Unlock()
 		}(tag.Name)
 	}
@@ -140,42 +188,54 @@ func processRepository(client *Client, repoName string, policy *models.Retention
 	// Track kept digests to prevent deleting shared manifests
 	keptDigests := make(map[string]bool)
 
-	type tagDecision struct {
-		img    imageInfo
-		keep   bool
-		reason string
-	}
 	decisions := make([]tagDecision, 0, len(images))
 
+	// Tags are ranked within the rule that governs them; -1 is the policy-wide keep count
+	rules := compileTagRules(policy.Rules)
+	ranks := make(map[int]int)
+
 	// Pass 1: Evaluate Rules
-	for i, img := range images {
+	for _, img := range images {
 		shouldKeep := false
 		reason := "default keep"
 
+		ruleIdx := matchTagRule(rules, img.Tag)
+		rank := ranks[ruleIdx]
+		ranks[ruleIdx]++
+
+		keepLast, keepDays, scope := policy.KeepLastCount, policy.KeepDays, "images"
+		if ruleIdx >= 0 {
+			keepLast, keepDays = rules[ruleIdx].KeepCount, rules[ruleIdx].KeepDays
+			scope = fmt.Sprintf("tags matching %s", rules[ruleIdx].TagPattern)
+		}
+
 		// Rule 1: Keep Last Count
-		if policy.KeepLastCount > 0 {
-			if i < policy.KeepLastCount {
+		if keepLast > 0 {
+			if rank < keepLast {
 				shouldKeep = true
-				reason = fmt.Sprintf("within last %d images", policy.KeepLastCount)
+				reason = fmt.Sprintf("within last %d %s", keepLast, scope)
 			}
 		}
 
 		// Rule 2: Keep Days
-		if policy.KeepDays > 0 {
+		if keepDays > 0 {
 			age := now.Sub(img.Created)
 			days := int(age.Hours() / 24)
-			if days < policy.KeepDays {
+			if days < keepDays {
 				if shouldKeep {
-					reason += fmt.Sprintf(" AND newer than %d days", policy.KeepDays)
+					reason += fmt.Sprintf(" AND newer than %d days", keepDays)
 				} else {
 					shouldKeep = true
-					reason = fmt.Sprintf("newer than %d days", policy.KeepDays)
+					reason = fmt.Sprintf("newer than %d days", keepDays)
 				}
 			}
 		}
 
 		// Rule 3: Whitelist (Override)
-		if img.Protected {
+		if img.Immutable {
+			shouldKeep = true
+			reason = "immutable tag"
+		} else if img.Protected {
 			shouldKeep = true
 			if reason == "default keep" { // Don't overwrite if already kept by other rules
 				reason = "matches whitelist tag"
@@ -184,16 +244,23 @@ func processRepository(client *Client, repoName string, policy *models.Retention
 			}
 		}
 
-		// Safety: if no policy set, keep everything
-		if policy.KeepLastCount <= 0 && policy.KeepDays <= 0 {
+		// Safety: if no policy set, keep everything (a tag rule with keep 0 deletes on purpose)
+		if ruleIdx < 0 && keepLast <= 0 && keepDays <= 0 {
 			shouldKeep = true
 			reason = "no policy set"
 		}
 
-		if shouldKeep {
-			keptDigests[img.Digest] = true
+		decisions = append(decisions, tagDecision{img: img, keep: shouldKeep, reason: reason})
+	}
+
+	// Pass 1b: Trim the oldest remaining images until the repository fits its size budget
+	if policy.MaxRepoSizeGB > 0 {
+		applySizeBudget(decisions, policy.MaxRepoSizeGB)
+	}
+	for _, d := range decisions {
+		if d.keep {
+			keptDigests[d.img.Digest] = true
 		}
-		decisions = append(decisions, tagDecision{img, shouldKeep, reason})
 	}
 
 	// Pass 2: Execute actions
@@ -202,7 +269,9 @@ func processRepository(client *Client, repoName string, policy *models.Retention
 		reason := d.reason
 
 		if !d.keep {
-			reason = "exceeds retention limits"
+			if !d.overBudget {
+				reason = "exceeds retention limits"
+			}
 
 			// Critical Safety: Check if digest is used by another KEPT tag
 			if keptDigests[d.img.Digest] {
@@ -211,8 +280,15 @@ func processRepository(client *Client, repoName string, policy *models.Retention
 			} else {
 				if policy.DryRun {
 					action = "would_delete"
+				} else if ctx.Err() != nil {
+					// Interrupted: the remaining images are left for the next run
+					break
 				} else {
-					if err := client.DeleteManifest(repoName, d.img.Digest); err != nil {
+					// Sized before deleting so the deleted-image ledger can record it
+					if d.img.Blobs == nil {
+						d.img.Blobs, _ = imageBlobs(ctx, client, repoName, d.img.Digest)
+					}
+					if err := client.DeleteManifest(ctx, repoName, d.img.Digest); err != nil {
 						action = "error_delete"
 						reason = fmt.Sprintf("failed to delete: %v", err)
 					} else {
@@ -227,6 +303,7 @@ func processRepository(client *Client, repoName string, policy *models.Retention
 			Tag:        d.img.Tag,
 			Digest:     d.img.Digest,
 			Created:    d.img.Created,
+			Size:       imageSize(d.img.Blobs),
 			Action:     action,
 			Reason:     reason,
 		})
@@ -234,3 +311,130 @@ func processRepository(client *Client, repoName string, policy *models.Retention
 
 	return logs, nil
 }
+
+// bytesPerGB converts size budgets (decimal gigabytes) to bytes
+const bytesPerGB = 1e9
+
+// applySizeBudget marks kept images for deletion, oldest first, until the blobs still referenced
+// fit into maxGB. Blobs shared between images only count once, so deleting an image frees
+// only what no remaining image references. Whitelisted images and images of unknown size stay.
+func applySizeBudget(decisions []tagDecision, maxGB float64) {
+	budget := int64(maxGB * bytesPerGB)
+
+	// Group kept tags by image; decisions are sorted newest first, so an image's first tag is its newest
+	var digests []string
+	tagsOf := make(map[string][]int)
+	for i, d := range decisions {
+		if !d.keep {
+			continue
+		}
+		if _, seen := tagsOf[d.img.Digest]; !seen {
+			digests = append(digests, d.img.Digest)
+		}
+		tagsOf[d.img.Digest] = append(tagsOf[d.img.Digest], i)
+	}
+
+	refs := make(map[string]int)
+	sizes := make(map[string]int64)
+	var total int64
+	for _, digest := range digests {
+		for blob, size := range decisions[tagsOf[digest][0]].img.Blobs {
+			if refs[blob] == 0 {
+				total += size
+			}
+			refs[blob]++
+			sizes[blob] = size
+		}
+	}
+
+	for i := len(digests) - 1; i >= 0 && total > budget; i-- {
+		tags := tagsOf[digests[i]]
+		img := decisions[tags[0]].img
+		if img.Blobs == nil {
+			continue
+		}
+		protected := false
+		for _, j := range tags {
+			protected = protected || decisions[j].img.Protected
+		}
+		if protected {
+			continue
+		}
+
+		for blob := range img.Blobs {
+			if refs[blob]--; refs[blob] == 0 {
+				total -= sizes[blob]
+			}
+		}
+		for _, j := range tags {
+			decisions[j].keep = false
+			decisions[j].overBudget = true
+			decisions[j].reason = fmt.Sprintf("repository over size budget of %g GB", maxGB)
+		}
+	}
+}
+
+// imageBlobs returns the config and layer blobs (with sizes) of an image, across all platforms of an index
+func imageBlobs(ctx context.Context, client *Client, repoName, digest string) (map[string]int64, error) {
+	manifest, err := client.GetManifest(ctx, repoName, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	manifests := []*models.ImageManifest{manifest}
+	for _, child := range manifest.Manifests {
+		m, err := client.GetManifest(ctx, repoName, child.Digest)
+		if err != nil {
+			return nil, err
+		}
+		manifests = append(manifests, m)
+	}
+
+	blobs := make(map[string]int64)
+	for _, m := range manifests {
+		if m.Config != nil {
+			blobs[m.Config.Digest] = m.Config.Size
+		}
+		for _, l := range m.Layers {
+			blobs[l.Digest] = l.Size
+		}
+	}
+	return blobs, nil
+}
+
+func imageSize(blobs map[string]int64) int64 {
+	var size int64
+	for _, s := range blobs {
+		size += s
+	}
+	return size
+}
+
+type tagRule struct {
+	models.TagRetentionRule
+	re *regexp.Regexp
+}
+
+// compileTagRules compiles the tag patterns of a policy; invalid patterns are skipped
+func compileTagRules(rules []models.TagRetentionRule) []tagRule {
+	compiled := make([]tagRule, 0, len(rules))
+	for _, r := range rules {
+		re, err := regexp.Compile(r.TagPattern)
+		if err != nil {
+			log.Printf("⚠️ Invalid tag rule regex %q: %v", r.TagPattern, err)
+			continue
+		}
+		compiled = append(compiled, tagRule{TagRetentionRule: r, re: re})
+	}
+	return compiled
+}
+
+// matchTagRule returns the index of the first rule matching tag, or -1
+func matchTagRule(rules []tagRule, tag string) int {
+	for i, r := range rules {
+		if r.re.MatchString(tag) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,364 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	storagedriver "github.com/distribution/distribution/v3/registry/storage/driver"
+	"github.com/distribution/distribution/v3/registry/storage/driver/base"
+	"github.com/distribution/distribution/v3/registry/storage/driver/factory"
+
+	"docker-registry-dashboard/internal/s3"
+)
+
+// s3DriverName registers the in-process registry's S3 driver under the name the storage section of
+// config.yml uses, so the same config serves the container and the in-process registry
+const s3DriverName = "s3"
+
+func init() {
+	factory.Register(s3DriverName, s3DriverFactory{})
+}
+
+type s3DriverFactory struct{}
+
+// Create builds the driver from the s3 parameters RenderConfig writes
+func (s3DriverFactory) Create(ctx context.Context, parameters map[string]interface{}) (storagedriver.StorageDriver, error) {
+	param := func(name string) string {
+		if v, ok := parameters[name]; ok && v != nil {
+			return fmt.Sprint(v)
+		}
+		return ""
+	}
+	cfg := s3.Config{
+		Endpoint:  param("regionendpoint"),
+		Region:    param("region"),
+		Bucket:    param("bucket"),
+		AccessKey: param("accesskey"),
+		SecretKey: param("secretkey"),
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("the s3 storage driver needs a bucket")
+	}
+	if cfg.Endpoint == "" {
+		region := cfg.Region
+		if region == "" {
+			region = "us-east-1"
+		}
+		cfg.Endpoint = "s3." + region + ".amazonaws.com"
+	}
+	if secure := param("secure"); secure != "" {
+		b, err := strconv.ParseBool(secure)
+		if err != nil {
+			return nil, fmt.Errorf("the secure parameter should be a boolean")
+		}
+		cfg.PlainHTTP = !b
+	}
+
+	d := &s3Driver{client: s3.NewClient(cfg), root: strings.Trim(param("rootdirectory"), "/")}
+	return &s3StorageDriver{baseEmbed{base.Base{StorageDriver: d}}}, nil
+}
+
+// baseEmbed hides the Base the driver embeds for its path checks
+type baseEmbed struct {
+	base.Base
+}
+
+// s3StorageDriver is the S3 driver behind distribution's path and error checks
+type s3StorageDriver struct {
+	baseEmbed
+}
+
+// s3Driver stores the registry's files as objects of a bucket using the dashboard's own S3 client
+type s3Driver struct {
+	client *s3.Client
+	root   string // Key prefix of all paths, without slashes
+}
+
+func (d *s3Driver) Name() string {
+	return s3DriverName
+}
+
+// key returns the object key of a driver path such as /docker/registry/v2/blobs
+func (d *s3Driver) key(p string) string {
+	return strings.TrimPrefix(path.Join("/", d.root, p), "/")
+}
+
+// dirPrefix returns the key prefix of the objects under a driver path
+func (d *s3Driver) dirPrefix(p string) string {
+	if key := d.key(p); key != "" {
+		return key + "/"
+	}
+	return ""
+}
+
+// driverPath turns an object key or common prefix back into a driver path
+func (d *s3Driver) driverPath(key string) string {
+	key = strings.TrimSuffix(key, "/")
+	if d.root != "" {
+		key = strings.TrimPrefix(key, d.root+"/")
+	}
+	return "/" + key
+}
+
+func (d *s3Driver) notFound(p string, err error) error {
+	if s3.IsNotFound(err) {
+		return storagedriver.PathNotFoundError{Path: p, DriverName: s3DriverName}
+	}
+	return err
+}
+
+func (d *s3Driver) GetContent(ctx context.Context, p string) ([]byte, error) {
+	rc, err := d.Reader(ctx, p, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func (d *s3Driver) PutContent(ctx context.Context, p string, content []byte) error {
+	return d.client.Put(ctx, d.key(p), content)
+}
+
+func (d *s3Driver) Reader(ctx context.Context, p string, offset int64) (io.ReadCloser, error) {
+	rc, err := d.client.Get(ctx, d.key(p), offset)
+	if err != nil {
+		return nil, d.notFound(p, err)
+	}
+	return rc, nil
+}
+
+func (d *s3Driver) Stat(ctx context.Context, p string) (storagedriver.FileInfo, error) {
+	info, err := d.client.Head(ctx, d.key(p))
+	if err == nil {
+		return storagedriver.FileInfoInternal{FileInfoFields: storagedriver.FileInfoFields{
+			Path: p, Size: info.Size, ModTime: info.LastModified,
+		}}, nil
+	}
+	if !s3.IsNotFound(err) {
+		return nil, err
+	}
+
+	// Directories only exist as the common prefix of their objects
+	objects, prefixes, err := d.client.List(ctx, d.dirPrefix(p), "/")
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 && len(prefixes) == 0 {
+		return nil, storagedriver.PathNotFoundError{Path: p, DriverName: s3DriverName}
+	}
+	return storagedriver.FileInfoInternal{FileInfoFields: storagedriver.FileInfoFields{Path: p, IsDir: true}}, nil
+}
+
+func (d *s3Driver) List(ctx context.Context, p string) ([]string, error) {
+	objects, prefixes, err := d.client.List(ctx, d.dirPrefix(p), "/")
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 && len(prefixes) == 0 && p != "/" {
+		return nil, storagedriver.PathNotFoundError{Path: p, DriverName: s3DriverName}
+	}
+
+	children := make([]string, 0, len(objects)+len(prefixes))
+	for _, o := range objects {
+		children = append(children, d.driverPath(o.Key))
+	}
+	for _, prefix := range prefixes {
+		children = append(children, d.driverPath(prefix))
+	}
+	return children, nil
+}
+
+func (d *s3Driver) Move(ctx context.Context, sourcePath, destPath string) error {
+	info, err := d.client.Head(ctx, d.key(sourcePath))
+	if err != nil {
+		return d.notFound(sourcePath, err)
+	}
+	if err := d.client.Copy(ctx, d.key(sourcePath), d.key(destPath), info.Size); err != nil {
+		return err
+	}
+	return d.client.Delete(ctx, d.key(sourcePath))
+}
+
+func (d *s3Driver) Delete(ctx context.Context, p string) error {
+	objects, _, err := d.client.List(ctx, d.dirPrefix(p), "")
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(objects)+1)
+	if _, err := d.client.Head(ctx, d.key(p)); err == nil {
+		keys = append(keys, d.key(p))
+	}
+	for _, o := range objects {
+		keys = append(keys, o.Key)
+	}
+	if len(keys) == 0 {
+		return storagedriver.PathNotFoundError{Path: p, DriverName: s3DriverName}
+	}
+	for _, key := range keys {
+		if err := d.client.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RedirectURL never redirects; blobs are served through the registry
+func (d *s3Driver) RedirectURL(*http.Request, string) (string, error) {
+	return "", nil
+}
+
+func (d *s3Driver) Walk(ctx context.Context, p string, f storagedriver.WalkFn, options ...func(*storagedriver.WalkOptions)) error {
+	return storagedriver.WalkFallback(ctx, d, p, f, options...)
+}
+
+// Writer returns a writer of the object at p. Closing it stores what was written as the object, so
+// an upload spanning several requests resumes by appending: the stored object becomes the first
+// parts of a new multipart upload, copied within S3, or is read back when it is too small for a part.
+func (d *s3Driver) Writer(ctx context.Context, p string, append bool) (storagedriver.FileWriter, error) {
+	w := &s3Writer{client: d.client, key: d.key(p)}
+	if !append {
+		return w, nil
+	}
+
+	info, err := d.client.Head(ctx, w.key)
+	if s3.IsNotFound(err) {
+		return w, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	w.size = info.Size
+	if info.Size < s3.MinPartSize {
+		rc, err := d.client.Get(ctx, w.key, 0)
+		if err != nil {
+			return nil, err
+		}
+		_, err = io.Copy(&w.buf, rc)
+		rc.Close()
+		return w, err
+	}
+	if w.upload, err = d.client.NewMultipartUpload(ctx, w.key); err != nil {
+		return nil, err
+	}
+	if err := w.upload.CopyFrom(ctx, w.key, info.Size); err != nil {
+		w.upload.Abort()
+		return nil, err
+	}
+	return w, nil
+}
+
+// s3Writer buffers writes into parts of a multipart upload, started once a part is full
+type s3Writer struct {
+	client    *s3.Client
+	key       string
+	upload    *s3.MultipartUpload
+	buf       bytes.Buffer
+	size      int64
+	closed    bool
+	committed bool
+	cancelled bool
+}
+
+func (w *s3Writer) Write(p []byte) (int, error) {
+	switch {
+	case w.closed:
+		return 0, errors.New("already closed")
+	case w.committed:
+		return 0, errors.New("already committed")
+	case w.cancelled:
+		return 0, errors.New("already cancelled")
+	}
+	w.buf.Write(p)
+	w.size += int64(len(p))
+	for w.buf.Len() >= s3.PartSize {
+		if err := w.flushPart(context.Background(), s3.PartSize); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// flushPart uploads the first n buffered bytes as the next part
+func (w *s3Writer) flushPart(ctx context.Context, n int) error {
+	if w.upload == nil {
+		upload, err := w.client.NewMultipartUpload(ctx, w.key)
+		if err != nil {
+			return err
+		}
+		w.upload = upload
+	}
+	if err := w.upload.WritePart(ctx, w.buf.Bytes()[:n]); err != nil {
+		return err
+	}
+	w.buf.Next(n)
+	return nil
+}
+
+// store writes the content so far as the object
+func (w *s3Writer) store(ctx context.Context) error {
+	if w.upload == nil {
+		return w.client.Put(ctx, w.key, w.buf.Bytes())
+	}
+	if w.buf.Len() > 0 {
+		if err := w.flushPart(ctx, w.buf.Len()); err != nil {
+			return err
+		}
+	}
+	if err := w.upload.Complete(ctx); err != nil {
+		w.upload.Abort()
+		return err
+	}
+	return nil
+}
+
+func (w *s3Writer) Size() int64 {
+	return w.size
+}
+
+func (w *s3Writer) Close() error {
+	if w.closed {
+		return errors.New("already closed")
+	}
+	w.closed = true
+	if w.committed || w.cancelled {
+		return nil
+	}
+	return w.store(context.Background())
+}
+
+func (w *s3Writer) Cancel(ctx context.Context) error {
+	if w.closed {
+		return errors.New("already closed")
+	} else if w.committed {
+		return errors.New("already committed")
+	}
+	w.cancelled = true
+	if w.upload != nil {
+		w.upload.Abort()
+	}
+	return w.client.Delete(ctx, w.key)
+}
+
+func (w *s3Writer) Commit(ctx context.Context) error {
+	switch {
+	case w.closed:
+		return errors.New("already closed")
+	case w.committed:
+		return errors.New("already committed")
+	case w.cancelled:
+		return errors.New("already cancelled")
+	}
+	if err := w.store(ctx); err != nil {
+		return err
+	}
+	w.committed = true
+	return nil
+}
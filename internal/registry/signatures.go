@@ -0,0 +1,134 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// Artifact types of signatures attached through the OCI referrers API
+var signatureArtifactTypes = []string{
+	"application/vnd.dev.cosign.artifact.sig.v1+json",
+	"application/vnd.dev.sigstore.bundle.v0.3+json",
+	"application/vnd.cncf.notary.signature",
+}
+
+// cosignArtifactTag matches the tags cosign stores signatures, attestations and SBOMs under
+var cosignArtifactTag = regexp.MustCompile(`^sha256-[0-9a-f]{64}\.(sig|att|sbom)$`)
+
+// IsCosignArtifactTag reports whether a tag holds a cosign signature, attestation or SBOM
+// rather than an image
+func IsCosignArtifactTag(tag string) bool {
+	return cosignArtifactTag.MatchString(tag)
+}
+
+// referrersTag matches the tags registries without the referrers API list the referrers of a
+// manifest under (the OCI referrers tag schema)
+var referrersTag = regexp.MustCompile(`^sha256-[0-9a-f]{64}$`)
+
+// IsReferrersTag reports whether a tag holds the referrers index of a manifest rather than an image
+func IsReferrersTag(tag string) bool {
+	return referrersTag.MatchString(tag)
+}
+
+// CosignSignatureTag returns the tag cosign stores the signature of a manifest digest under
+func CosignSignatureTag(digest string) string {
+	return strings.Replace(digest, ":", "-", 1) + ".sig"
+}
+
+// HasSignature reports whether a signature exists for a manifest digest,
+// either as a cosign ".sig" tag or as an OCI referrer with a signature artifact type
+func (c *Client) HasSignature(ctx context.Context, repoName, digest string) (bool, error) {
+	if _, err := c.GetDigestForTag(ctx, repoName, CosignSignatureTag(digest)); err == nil {
+		return true, nil
+	}
+	return c.HasReferrerSignature(ctx, repoName, digest)
+}
+
+// HasReferrerSignature reports whether an OCI referrer with a signature artifact type exists for a
+// manifest digest, for callers that already know the repository has no cosign ".sig" tag for it
+func (c *Client) HasReferrerSignature(ctx context.Context, repoName, digest string) (bool, error) {
+	referrers, err := c.ListReferrers(ctx, repoName, digest)
+	if err != nil {
+		return false, err
+	}
+	for _, ref := range referrers {
+		for _, t := range signatureArtifactTypes {
+			if ref.ArtifactType == t {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// Referrer is a manifest that refers to another one (signature, SBOM, attestation)
+type Referrer struct {
+	MediaType    string            `json:"mediaType"`
+	ArtifactType string            `json:"artifactType"`
+	Digest       string            `json:"digest"`
+	Size         int64             `json:"size"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+// ListReferrers queries the OCI referrers API. Registries without it, and those whose profile says
+// they lack it, are read through the referrers tag schema instead, as clients such as Notation
+// push to them.
+func (c *Client) ListReferrers(ctx context.Context, repoName, digest string) ([]Referrer, error) {
+	if c.profileLacks(func(p *models.RegistryProfile) bool { return p.Referrers }) {
+		return c.listReferrersTag(ctx, repoName, digest)
+	}
+	path := fmt.Sprintf("/v2/%s/referrers/%s", repoName, digest)
+	resp, err := c.doRequest(ctx, "GET", path, map[string]string{"Accept": MediaTypeOCIIndex})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list referrers: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return c.listReferrersTag(ctx, repoName, digest)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("referrers request returned status %d", resp.StatusCode)
+	}
+	return decodeReferrers(resp)
+}
+
+// listReferrersTag reads the index tagged sha256-<hex> that lists the referrers of a manifest in
+// the referrers tag schema; there are none without the tag
+func (c *Client) listReferrersTag(ctx context.Context, repoName, digest string) ([]Referrer, error) {
+	tag := strings.Replace(digest, ":", "-", 1)
+	if !IsReferrersTag(tag) {
+		return nil, nil
+	}
+	path := fmt.Sprintf("/v2/%s/manifests/%s", repoName, tag)
+	resp, err := c.doRequest(ctx, "GET", path, map[string]string{"Accept": MediaTypeOCIIndex})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list referrers: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("referrers tag request returned status %d", resp.StatusCode)
+	}
+	return decodeReferrers(resp)
+}
+
+// decodeReferrers decodes the index of referrers in a response
+func decodeReferrers(resp *http.Response) ([]Referrer, error) {
+	var index struct {
+		Manifests []Referrer `json:"manifests"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&index); err != nil {
+		return nil, fmt.Errorf("failed to decode referrers: %w", err)
+	}
+	return index.Manifests, nil
+}
@@ -0,0 +1,292 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/nacl/secretbox"
+	"golang.org/x/crypto/scrypt"
+)
+
+// PEM types of cosign's encrypted private keys; releases before cosign 2.0 wrote the second
+const (
+	sigstorePrivateKeyType = "ENCRYPTED SIGSTORE PRIVATE KEY"
+	cosignPrivateKeyType   = "ENCRYPTED COSIGN PRIVATE KEY"
+)
+
+// The scrypt parameters cosign encrypts new private keys with
+const (
+	scryptN = 65536
+	scryptR = 8
+	scryptP = 1
+)
+
+// Media type and annotation of the layers of a cosign signature manifest
+const (
+	cosignSimpleSigningMediaType = "application/vnd.dev.cosign.simplesigning.v1+json"
+	cosignSignatureAnnotation    = "dev.cosignproject.cosign/signature"
+)
+
+// encryptedKey is a cosign private key: PKCS #8 sealed with NaCl secretbox under a key the
+// password is stretched into with scrypt
+type encryptedKey struct {
+	KDF struct {
+		Name   string `json:"name"`
+		Params struct {
+			N int `json:"N"`
+			R int `json:"r"`
+			P int `json:"p"`
+		} `json:"params"`
+		Salt []byte `json:"salt"`
+	} `json:"kdf"`
+	Cipher struct {
+		Name  string `json:"name"`
+		Nonce []byte `json:"nonce"`
+	} `json:"cipher"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// GenerateSigningKey creates an ECDSA P-256 key pair, as cosign generate-key-pair does
+func GenerateSigningKey() (crypto.Signer, error) {
+	return ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+}
+
+// EncryptSigningKey encrypts a private key with a password in cosign's key format
+func EncryptSigningKey(key crypto.Signer, password []byte) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	var k encryptedKey
+	k.KDF.Name = "scrypt"
+	k.KDF.Params.N, k.KDF.Params.R, k.KDF.Params.P = scryptN, scryptR, scryptP
+	k.KDF.Salt = make([]byte, 32)
+	if _, err := rand.Read(k.KDF.Salt); err != nil {
+		return nil, err
+	}
+	var nonce [24]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	secret, err := scrypt.Key(password, k.KDF.Salt, scryptN, scryptR, scryptP, 32)
+	if err != nil {
+		return nil, err
+	}
+	k.Cipher.Name, k.Cipher.Nonce = "nacl/secretbox", nonce[:]
+	k.Ciphertext = secretbox.Seal(nil, der, &nonce, (*[32]byte)(secret))
+
+	body, err := json.Marshal(k)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: sigstorePrivateKeyType, Bytes: body}), nil
+}
+
+// DecryptSigningKey decrypts a private key in cosign's key format (cosign.key)
+func DecryptSigningKey(keyPEM, password []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil || (block.Type != sigstorePrivateKeyType && block.Type != cosignPrivateKeyType) {
+		return nil, errors.New("not an encrypted cosign private key")
+	}
+	var k encryptedKey
+	if err := json.Unmarshal(block.Bytes, &k); err != nil {
+		return nil, fmt.Errorf("invalid cosign private key: %w", err)
+	}
+	if k.KDF.Name != "scrypt" || k.Cipher.Name != "nacl/secretbox" || len(k.Cipher.Nonce) != 24 {
+		return nil, fmt.Errorf("unsupported key encryption %s/%s", k.KDF.Name, k.Cipher.Name)
+	}
+	secret, err := scrypt.Key(password, k.KDF.Salt, k.KDF.Params.N, k.KDF.Params.R, k.KDF.Params.P, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key derivation parameters: %w", err)
+	}
+	der, ok := secretbox.Open(nil, k.Ciphertext, (*[24]byte)(k.Cipher.Nonce), (*[32]byte)(secret))
+	if !ok {
+		return nil, errors.New("wrong password for the private key")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	return signer, nil
+}
+
+// PublicKeyPEM encodes the public key of a key pair as cosign.pub does
+func PublicKeyPEM(key crypto.Signer) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return "", err
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
+
+// PublicKeyFingerprint is the KeyFingerprint of a PEM public key, empty when it cannot be read
+func PublicKeyFingerprint(publicKeyPEM string) string {
+	block, _ := pem.Decode([]byte(publicKeyPEM))
+	if block == nil {
+		return ""
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return ""
+	}
+	return KeyFingerprint(key)
+}
+
+// signPayload signs msg the way signedBy verifies signatures
+func signPayload(key crypto.Signer, msg []byte) ([]byte, error) {
+	switch k := key.Public().(type) {
+	case *ecdsa.PublicKey:
+		h := ecdsaHash(k)
+		digest := h.New()
+		digest.Write(msg)
+		return key.Sign(rand.Reader, digest.Sum(nil), h)
+	case *rsa.PublicKey:
+		sum := sha256.Sum256(msg)
+		return key.Sign(rand.Reader, sum[:], crypto.SHA256)
+	case ed25519.PublicKey:
+		return key.Sign(rand.Reader, msg, crypto.Hash(0))
+	}
+	return nil, fmt.Errorf("unsupported key type %T", key.Public())
+}
+
+// simpleSigning is the payload cosign signs: the image digest and the repository it was signed in
+type simpleSigning struct {
+	Critical struct {
+		Identity struct {
+			DockerReference string `json:"docker-reference"`
+		} `json:"identity"`
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+		Type string `json:"type"`
+	} `json:"critical"`
+	Optional map[string]string `json:"optional"`
+}
+
+// signatureLayer is a layer of a cosign signature manifest: a signed payload with its signature
+type signatureLayer struct {
+	MediaType   string            `json:"mediaType"`
+	Size        int64             `json:"size"`
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// SignImage signs a manifest digest the way cosign sign does and pushes the signature to the
+// digest's ".sig" tag, next to the signatures already there. The optional annotations are signed
+// along. It returns the digest of the signature manifest.
+func (c *Client) SignImage(ctx context.Context, key crypto.Signer, repoName, digest string, annotations map[string]string) (string, error) {
+	var payload simpleSigning
+	payload.Critical.Identity.DockerReference = c.repositoryReference(repoName)
+	payload.Critical.Image.DockerManifestDigest = digest
+	payload.Critical.Type = "cosign container image signature"
+	if len(annotations) > 0 {
+		payload.Optional = annotations
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	sig, err := signPayload(key, body)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign: %w", err)
+	}
+	layer := signatureLayer{
+		MediaType:   cosignSimpleSigningMediaType,
+		Size:        int64(len(body)),
+		Digest:      sha256Digest(body),
+		Annotations: map[string]string{cosignSignatureAnnotation: base64.StdEncoding.EncodeToString(sig)},
+	}
+	if err := c.pushBlobBytes(ctx, repoName, body); err != nil {
+		return "", err
+	}
+
+	// Earlier signatures of the digest are kept as they are
+	tag := CosignSignatureTag(digest)
+	var existing struct {
+		Layers []json.RawMessage `json:"layers"`
+	}
+	if _, err := c.GetDigestForTag(ctx, repoName, tag); err == nil {
+		raw, _, _, err := c.GetRawManifest(ctx, repoName, tag)
+		if err != nil {
+			return "", fmt.Errorf("failed to read existing signatures: %w", err)
+		}
+		if err := json.Unmarshal(raw, &existing); err != nil {
+			return "", fmt.Errorf("invalid signature manifest %s: %w", tag, err)
+		}
+	}
+	newLayer, err := json.Marshal(layer)
+	if err != nil {
+		return "", err
+	}
+	layers := append(existing.Layers, newLayer)
+
+	// The config lists the layers as an image config does, as cosign writes it
+	diffIDs := make([]string, 0, len(layers))
+	for _, l := range layers {
+		var d Referrer
+		if err := json.Unmarshal(l, &d); err != nil {
+			return "", fmt.Errorf("invalid signature layer: %w", err)
+		}
+		diffIDs = append(diffIDs, d.Digest)
+	}
+	config, err := json.Marshal(map[string]any{
+		"architecture": "",
+		"os":           "",
+		"created":      "0001-01-01T00:00:00Z",
+		"history":      []any{map[string]string{"created": "0001-01-01T00:00:00Z"}},
+		"rootfs":       map[string]any{"type": "layers", "diff_ids": diffIDs},
+		"config":       map[string]any{},
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := c.pushBlobBytes(ctx, repoName, config); err != nil {
+		return "", err
+	}
+	manifest, err := json.Marshal(map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     MediaTypeOCIManifest,
+		"config":        descriptor{MediaType: "application/vnd.oci.image.config.v1+json", Size: int64(len(config)), Digest: sha256Digest(config)},
+		"layers":        layers,
+	})
+	if err != nil {
+		return "", err
+	}
+	if _, err := c.PutManifest(ctx, repoName, tag, MediaTypeOCIManifest, manifest); err != nil {
+		return "", err
+	}
+	return sha256Digest(manifest), nil
+}
+
+// pushBlobBytes uploads a small blob unless the repository already has it
+func (c *Client) pushBlobBytes(ctx context.Context, repoName string, data []byte) error {
+	digest := sha256Digest(data)
+	if exists, err := c.BlobExists(ctx, repoName, digest); err == nil && exists {
+		return nil
+	}
+	return c.PushBlob(ctx, repoName, digest, int64(len(data)), bytes.NewReader(data))
+}
+
+// sha256Digest is the digest of content
+func sha256Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
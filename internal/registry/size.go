@@ -0,0 +1,98 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// CalculateStorageSize sums the unique blob sizes of every repository and of the whole registry.
+// Layers shared between tags (or, for the total, between repositories) are counted once.
+func CalculateStorageSize(ctx context.Context, client *Client) (*models.RegistrySize, error) {
+	repos, err := client.ListRepositories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories: %w", err)
+	}
+
+	result := &models.RegistrySize{Repositories: []models.RepositorySize{}}
+	allBlobs := make(map[string]int64)
+	for _, repo := range repos {
+		repoSize, blobs, err := repositorySize(ctx, client, repo.Name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", repo.Name, err)
+		}
+		for digest, size := range blobs {
+			allBlobs[digest] = size
+		}
+		result.Repositories = append(result.Repositories, *repoSize)
+	}
+
+	sort.Slice(result.Repositories, func(i, j int) bool {
+		return result.Repositories[i].Size > result.Repositories[j].Size
+	})
+	result.TotalSize = imageSize(allBlobs)
+	result.BlobCount = len(allBlobs)
+	result.CalculatedAt = time.Now()
+	return result, nil
+}
+
+// RepositoriesSize sums the unique blobs referenced by the tags of some repositories of a
+// registry; layers they share are counted once
+func RepositoriesSize(ctx context.Context, client *Client, repos []string) (int64, error) {
+	allBlobs := make(map[string]int64)
+	for _, repo := range repos {
+		_, blobs, err := repositorySize(ctx, client, repo)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", repo, err)
+		}
+		for digest, size := range blobs {
+			allBlobs[digest] = size
+		}
+	}
+	return imageSize(allBlobs), nil
+}
+
+// repositorySize collects the unique blobs referenced by the tags of a repository
+func repositorySize(ctx context.Context, client *Client, repoName string) (*models.RepositorySize, map[string]int64, error) {
+	tags, err := client.ListTags(ctx, repoName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	blobs := make(map[string]int64)
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		digest, err := client.GetDigestForTag(ctx, repoName, tag.Name)
+		if err != nil || seen[digest] {
+			continue
+		}
+		seen[digest] = true
+
+		imgBlobs, err := imageBlobs(ctx, client, repoName, digest)
+		if err != nil {
+			continue
+		}
+		for d, size := range imgBlobs {
+			blobs[d] = size
+		}
+	}
+
+	return &models.RepositorySize{
+		Name:      repoName,
+		Size:      imageSize(blobs),
+		BlobCount: len(blobs),
+		TagCount:  len(tags),
+	}, blobs, nil
+}
+
+// ImageSize returns the bytes referenced by an image (config and layers, across all platforms of an index)
+func (c *Client) ImageSize(ctx context.Context, repoName, digest string) (int64, error) {
+	blobs, err := imageBlobs(ctx, c, repoName, digest)
+	if err != nil {
+		return 0, err
+	}
+	return imageSize(blobs), nil
+}
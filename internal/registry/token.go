@@ -0,0 +1,182 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// tokenClient fetches bearer tokens from registry auth services, which often live on another host
+var tokenClient = &http.Client{Timeout: 15 * time.Second}
+
+// credentials returns the username and password requests authenticate with
+func (c *Client) credentials(ctx context.Context) (string, string, error) {
+	if c.login != nil {
+		return c.login(ctx)
+	}
+	return c.username, c.password, nil
+}
+
+// authorize sets the bearer token cached for the request's repository, or the basic credentials
+func (c *Client) authorize(req *http.Request) error {
+	if token := c.cachedToken(tokenScopeKey(req.URL.Path)); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+	username, password, err := c.credentials(req.Context())
+	if err != nil {
+		return err
+	}
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+	return nil
+}
+
+// do sends a request. When the registry answers with a bearer token challenge (Docker Hub, GHCR,
+// token-auth registries) a token is fetched with the client's credentials and the request is
+// sent again if its body can be replayed; the token is reused for later requests to the repository.
+// Pulls from Docker Hub are held back while its pull rate limit is nearly exhausted.
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if err := c.checkPullLimit(req); err != nil {
+		return nil, err
+	}
+	resp, err := c.sendAuthorized(req)
+	if err == nil {
+		c.recordPullLimit(resp)
+	}
+	return resp, err
+}
+
+// sendAuthorized sends a request with the client's credentials, answering a bearer token challenge
+func (c *Client) sendAuthorized(req *http.Request) (*http.Response, error) {
+	if err := c.authorize(req); err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	challenge, ok := parseBearerChallenge(resp.Header.Get("WWW-Authenticate"))
+	if !ok || (req.Body != nil && req.GetBody == nil) {
+		return resp, nil
+	}
+	token, err := c.fetchToken(req.Context(), challenge)
+	if err != nil {
+		// Surface the registry's 401, it says more than the token service failure
+		return resp, nil
+	}
+	resp.Body.Close()
+	c.storeToken(tokenScopeKey(req.URL.Path), token)
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	retry.Header.Set("Authorization", "Bearer "+token)
+	return c.httpClient.Do(retry)
+}
+
+// parseBearerChallenge parses a WWW-Authenticate header of the form
+// Bearer realm="...",service="...",scope="..."
+func parseBearerChallenge(header string) (map[string]string, bool) {
+	scheme, params, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return nil, false
+	}
+	challenge := make(map[string]string)
+	for params != "" {
+		var key, value string
+		key, params, _ = strings.Cut(strings.TrimLeft(params, " ,"), "=")
+		if strings.HasPrefix(params, `"`) {
+			value, params, _ = strings.Cut(params[1:], `"`)
+		} else {
+			value, params, _ = strings.Cut(params, ",")
+		}
+		challenge[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+	return challenge, challenge["realm"] != ""
+}
+
+// fetchToken requests a bearer token from the realm of a challenge
+func (c *Client) fetchToken(ctx context.Context, challenge map[string]string) (string, error) {
+	u, err := url.Parse(challenge["realm"])
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	for _, key := range []string{"service", "scope"} {
+		if v := challenge[key]; v != "" {
+			q.Set(key, v)
+		}
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	username, password, err := c.credentials(ctx)
+	if err != nil {
+		return "", err
+	}
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+	resp, err := c.tokenClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token service returned status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode token: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+	return "", fmt.Errorf("token service returned no token")
+}
+
+// tokenScopeKey returns the repository a /v2/ path addresses ("" for registry-wide paths),
+// so a token granted for a repository is reused for all its manifests and blobs
+func tokenScopeKey(path string) string {
+	name := strings.TrimPrefix(path, "/v2/")
+	for _, part := range []string{"/manifests/", "/blobs/", "/tags/", "/referrers/"} {
+		if i := strings.LastIndex(name, part); i > 0 {
+			return name[:i]
+		}
+	}
+	return ""
+}
+
+func (c *Client) cachedToken(key string) string {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	return c.tokens[key]
+}
+
+func (c *Client) storeToken(key, token string) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	if c.tokens == nil {
+		c.tokens = make(map[string]string)
+	}
+	c.tokens[key] = token
+}
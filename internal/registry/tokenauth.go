@@ -0,0 +1,171 @@
+package registry
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// TokenService names the embedded registry in token requests and the tokens' audience
+	TokenService = "docker-registry-dashboard"
+	tokenIssuer  = "docker-registry-dashboard"
+
+	// tokenTTL is how long an issued registry token is valid
+	tokenTTL = 5 * time.Minute
+
+	tokenKeyFile  = "token.key"
+	tokenCertFile = "token.crt" // Trusted by the registry as rootcertbundle
+)
+
+// TokenAccess is one resource of a token request or grant, e.g. repository "team/app" with pull and push
+type TokenAccess struct {
+	Type    string   `json:"type"`
+	Name    string   `json:"name"`
+	Actions []string `json:"actions"`
+}
+
+// ParseTokenScope parses a scope of a token request, "repository:team/app:pull,push". Names may
+// contain a registry port, so the actions follow the last colon.
+func ParseTokenScope(scope string) (TokenAccess, bool) {
+	typ, rest, ok := strings.Cut(scope, ":")
+	if !ok {
+		return TokenAccess{}, false
+	}
+	i := strings.LastIndex(rest, ":")
+	if i <= 0 {
+		return TokenAccess{}, false
+	}
+	access := TokenAccess{Type: typ, Name: rest[:i], Actions: []string{}}
+	for _, action := range strings.Split(rest[i+1:], ",") {
+		if action = strings.TrimSpace(action); action != "" {
+			access.Actions = append(access.Actions, action)
+		}
+	}
+	return access, true
+}
+
+// TokenIssuer signs the JWTs of the distribution token authentication scheme with an ECDSA key
+// whose self-signed certificate the embedded registry trusts
+type TokenIssuer struct {
+	key  *ecdsa.PrivateKey
+	cert []byte // DER
+}
+
+// loadTokenIssuer reads the signing key and certificate from dir, creating them on first use
+func loadTokenIssuer(dir string) (*TokenIssuer, error) {
+	keyPath, certPath := filepath.Join(dir, tokenKeyFile), filepath.Join(dir, tokenCertFile)
+	keyPEM, keyErr := os.ReadFile(keyPath)
+	certPEM, certErr := os.ReadFile(certPath)
+	if keyErr == nil && certErr == nil {
+		keyBlock, _ := pem.Decode(keyPEM)
+		certBlock, _ := pem.Decode(certPEM)
+		if keyBlock != nil && certBlock != nil {
+			if key, err := x509.ParseECPrivateKey(keyBlock.Bytes); err == nil {
+				return &TokenIssuer{key: key, cert: certBlock.Bytes}, nil
+			}
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: tokenIssuer},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(20, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token certificate: %w", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create config dir: %w", err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write token key: %w", err)
+	}
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write token certificate: %w", err)
+	}
+	return &TokenIssuer{key: key, cert: cert}, nil
+}
+
+// Issue signs a token for subject ("" when anonymous) granting the given access. The certificate
+// travels in the x5c header, so the registry checks it against its rootcertbundle.
+func (t *TokenIssuer) Issue(subject string, access []TokenAccess) (string, time.Time, error) {
+	now := time.Now()
+	jti := make([]byte, 16)
+	if _, err := rand.Read(jti); err != nil {
+		return "", now, err
+	}
+
+	header, err := json.Marshal(map[string]interface{}{
+		"typ": "JWT",
+		"alg": "ES256",
+		"x5c": []string{base64.StdEncoding.EncodeToString(t.cert)},
+	})
+	if err != nil {
+		return "", now, err
+	}
+	if access == nil {
+		access = []TokenAccess{}
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iss":    tokenIssuer,
+		"sub":    subject,
+		"aud":    TokenService,
+		"exp":    now.Add(tokenTTL).Unix(),
+		"nbf":    now.Add(-time.Minute).Unix(), // Allows for clock skew with the registry
+		"iat":    now.Unix(),
+		"jti":    hex.EncodeToString(jti),
+		"access": access,
+	})
+	if err != nil {
+		return "", now, err
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, t.key, digest[:])
+	if err != nil {
+		return "", now, err
+	}
+	// ES256 signatures are r and s as 32-byte big-endian integers
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), now, nil
+}
+
+// TokenTTL returns how long issued tokens are valid
+func (t *TokenIssuer) TokenTTL() time.Duration {
+	return tokenTTL
+}
@@ -0,0 +1,116 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/distribution/distribution/v3/version"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// registryCmd serves the config the dashboard copies into the container. The entrypoints of the
+// registry:2 and registry:3 images and the registry binary of distribution's own image all take it.
+var registryCmd = []string{"serve", "/etc/docker/registry/config.yml"}
+
+// registryImageOf returns the image the registry container runs with a storage config
+func registryImageOf(config *models.StorageConfig) string {
+	if config != nil && config.RegistryImage != "" {
+		return config.RegistryImage
+	}
+	return registryImage
+}
+
+// isDistribution3 reports whether an image runs distribution 3, whose config differs in places.
+// Numbered tags such as 2.8.3 or v3.0.0 tell by their major version; other tags such as latest
+// have been distribution 3 since its release.
+func isDistribution3(image string) bool {
+	tag := "latest"
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		tag = image[i+1:]
+	}
+	major, _, _ := strings.Cut(strings.TrimPrefix(tag, "v"), ".")
+	if n, err := strconv.Atoi(major); err == nil {
+		return n >= 3
+	}
+	return true
+}
+
+// Version returns the version of the running registry, "" when it is unknown
+func (r *EmbeddedRegistry) Version() string {
+	if r.inProcess {
+		return strings.TrimPrefix(strings.TrimSuffix(version.Version(), "+unknown"), "v")
+	}
+	v, _ := r.version.Load().(string)
+	return v
+}
+
+// containerVersion asks the registry binary in the container for its version, "" when it cannot
+// tell. It prints e.g. "registry github.com/docker/distribution v2.8.3".
+func (r *EmbeddedRegistry) containerVersion() string {
+	ctx, cancel := context.WithTimeout(context.Background(), dockerTimeout)
+	defer cancel()
+	var out bytes.Buffer
+	code, err := r.docker.Exec(ctx, r.Container(), []string{"registry", "--version"}, &out)
+	fields := strings.Fields(out.String())
+	if err != nil || code != 0 || len(fields) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(fields[len(fields)-1], "v")
+}
+
+// Upgrade moves the registry container to the image of config, or to the newest build of the same
+// tag. The image is pulled and the config checked with it before the running container is
+// touched, and when no container comes up on the new image, the previous one is started again.
+func (r *EmbeddedRegistry) Upgrade(config *models.StorageConfig) (*models.RegistryUpgrade, error) {
+	if r.inProcess {
+		return nil, errors.New("the in-process registry is part of the dashboard; upgrade the dashboard instead")
+	}
+	if r.docker == nil {
+		return nil, errNoDocker
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous := r.lastConfig.Load()
+	image := registryImageOf(config)
+	result := &models.RegistryUpgrade{
+		PreviousImage:   registryImageOf(previous),
+		PreviousVersion: r.Version(),
+		Image:           image,
+	}
+
+	log.Printf("📦 Pulling %s...", image)
+	pullCtx, cancelPull := context.WithTimeout(context.Background(), 10*time.Minute)
+	err := r.docker.PullImage(pullCtx, image)
+	cancelPull()
+	if err != nil {
+		return nil, err
+	}
+	check, err := r.ValidateConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	if !check.Valid {
+		return nil, fmt.Errorf("registry config is invalid with %s: %s", image, strings.Join(check.Problems, "; "))
+	}
+
+	log.Printf("⬆️  Moving the registry from %s to %s...", result.PreviousImage, image)
+	r.wanted.Store(true)
+	if err := r.startLocked(config); err != nil {
+		log.Printf("⚠️  Registry did not come up on %s: %v; rolling back to %s", image, err, result.PreviousImage)
+		if rollbackErr := r.startLocked(previous); rollbackErr != nil {
+			return nil, fmt.Errorf("registry did not come up on %s (%v), nor again on %s: %w", image, err, result.PreviousImage, rollbackErr)
+		}
+		return nil, fmt.Errorf("registry did not come up on %s and was rolled back to %s: %w", image, result.PreviousImage, err)
+	}
+	r.lastConfig.Store(config)
+	result.Version = r.Version()
+	return result, nil
+}
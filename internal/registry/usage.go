@@ -0,0 +1,146 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	storagedriver "github.com/distribution/distribution/v3/registry/storage/driver"
+	"github.com/distribution/distribution/v3/registry/storage/driver/factory"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// Paths of distribution's storage layout
+const (
+	layoutBlobsDir = "/docker/registry/v2/blobs"
+	layoutReposDir = "/docker/registry/v2/repositories"
+)
+
+// StorageUsage reads the embedded registry's storage backend directly and reports the bytes and
+// blobs it holds, per repository, and the uploads in progress. It works for the storage the
+// dashboard can open itself: the filesystem layout (local or SFTP mount) and S3.
+func (r *EmbeddedRegistry) StorageUsage(ctx context.Context, config *models.StorageConfig) (*models.RegistryStorageUsage, error) {
+	if config == nil {
+		config = &models.StorageConfig{Type: "local", LocalPath: "/var/lib/registry"}
+	}
+	if config.Type == "" {
+		config.Type = "local"
+	}
+	if config.Type == "gcs" || config.Type == "swift" {
+		return nil, fmt.Errorf("storage usage is not available for %s storage", config.Type)
+	}
+
+	driver, err := r.storageDriver(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &models.RegistryStorageUsage{StorageType: config.Type, Repositories: []models.RepositoryStorageUsage{}}
+	blobSizes := make(map[string]int64)
+	err = walkLayout(ctx, driver, layoutBlobsDir, func(fi storagedriver.FileInfo) error {
+		// blobs/sha256/ab/<hex>/data
+		if fi.IsDir() || path.Base(fi.Path()) != "data" {
+			return nil
+		}
+		dir := path.Dir(fi.Path())
+		digest := path.Base(path.Dir(path.Dir(dir))) + ":" + path.Base(dir)
+		blobSizes[digest] = fi.Size()
+		usage.BlobCount++
+		usage.TotalSize += fi.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk blobs: %w", err)
+	}
+
+	repos := make(map[string]*models.RepositoryStorageUsage)
+	uploads := make(map[string]bool)
+	err = walkLayout(ctx, driver, layoutReposDir, func(fi storagedriver.FileInfo) error {
+		if fi.IsDir() {
+			return nil
+		}
+		// repositories/<name>/_layers/sha256/<hex>/link, _manifests/revisions/sha256/<hex>/link,
+		// _uploads/<id>/data; repository names have no components starting with "_"
+		parts := strings.Split(strings.TrimPrefix(fi.Path(), layoutReposDir+"/"), "/")
+		i := 0
+		for i < len(parts) && !strings.HasPrefix(parts[i], "_") {
+			i++
+		}
+		if i == 0 || i == len(parts) {
+			return nil
+		}
+		name := strings.Join(parts[:i], "/")
+		repo := repos[name]
+		if repo == nil {
+			repo = &models.RepositoryStorageUsage{Name: name}
+			repos[name] = repo
+		}
+
+		rest := parts[i:]
+		switch {
+		case rest[0] == "_uploads" && len(rest) >= 3:
+			id := name + "/" + rest[1]
+			if !uploads[id] {
+				uploads[id] = true
+				repo.UploadCount++
+				usage.UploadCount++
+			}
+			if rest[len(rest)-1] == "data" && len(rest) == 3 {
+				repo.UploadSize += fi.Size()
+				usage.UploadSize += fi.Size()
+			}
+		case rest[len(rest)-1] == "link" && len(rest) >= 4 && (rest[0] == "_layers" || (rest[0] == "_manifests" && rest[1] == "revisions")):
+			digest := rest[len(rest)-3] + ":" + rest[len(rest)-2]
+			if size, ok := blobSizes[digest]; ok {
+				repo.Size += size
+				repo.BlobCount++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk repositories: %w", err)
+	}
+
+	for _, repo := range repos {
+		usage.Repositories = append(usage.Repositories, *repo)
+	}
+	sort.Slice(usage.Repositories, func(i, j int) bool {
+		return usage.Repositories[i].Size > usage.Repositories[j].Size
+	})
+	usage.CalculatedAt = time.Now()
+	return usage, nil
+}
+
+// storageDriver opens the storage the config points the registry at, with the host paths the
+// in-process registry would use
+func (r *EmbeddedRegistry) storageDriver(ctx context.Context, config *models.StorageConfig) (storagedriver.StorageDriver, error) {
+	rendered, err := RenderConfig(config, r.Auth())
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := r.inProcessConfig(rendered, config)
+	if err != nil {
+		return nil, err
+	}
+	driver, err := factory.Create(ctx, parsed.Storage.Type(), parsed.Storage.Parameters())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the registry storage: %w", err)
+	}
+	return driver, nil
+}
+
+// walkLayout walks a directory of the layout; a directory that does not exist yet is empty
+func walkLayout(ctx context.Context, driver storagedriver.StorageDriver, dir string, f storagedriver.WalkFn) error {
+	err := driver.Walk(ctx, dir, f)
+	var notFound storagedriver.PathNotFoundError
+	if errors.As(err, &notFound) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,201 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+const (
+	// watchdogInterval is how often the watchdog checks the registry
+	watchdogInterval = 15 * time.Second
+	// watchdogFailures is how many checks in a row a running registry may fail to answer /v2/
+	// before it is restarted
+	watchdogFailures = 3
+	// watchdogGrace lets Docker's restart policy bring a crashed container back before the
+	// watchdog does
+	watchdogGrace = 5 * time.Second
+	// watchdogStable is how long a restarted registry must stay healthy for the backoff to reset
+	watchdogStable = 10 * time.Minute
+	// maxIncidents is how many incidents are kept for the status
+	maxIncidents = 50
+)
+
+// incidentLog holds the latest incidents of the embedded registry, newest last
+type incidentLog struct {
+	mu        sync.Mutex
+	incidents []models.RegistryIncident
+	restarts  int
+}
+
+func (l *incidentLog) add(incident models.RegistryIncident) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if incident.Restarted {
+		l.restarts++
+	}
+	l.incidents = append(l.incidents, incident)
+	if len(l.incidents) > maxIncidents {
+		l.incidents = l.incidents[len(l.incidents)-maxIncidents:]
+	}
+}
+
+// Incidents returns the recorded incidents of the embedded registry, newest first
+func (r *EmbeddedRegistry) Incidents() []models.RegistryIncident {
+	r.incidents.mu.Lock()
+	defer r.incidents.mu.Unlock()
+	incidents := make([]models.RegistryIncident, len(r.incidents.incidents))
+	for i, incident := range r.incidents.incidents {
+		incidents[len(incidents)-1-i] = incident
+	}
+	return incidents
+}
+
+// recordIncident logs an incident and keeps it for the status
+func (r *EmbeddedRegistry) recordIncident(incident models.RegistryIncident) {
+	switch {
+	case incident.Restarted:
+		log.Printf("🩺 Registry %s (%s); restarted it", incident.Kind, incident.Detail)
+	case incident.RestartError != "":
+		log.Printf("🩺 Registry %s (%s); restart failed: %s", incident.Kind, incident.Detail, incident.RestartError)
+	default:
+		log.Printf("🩺 Registry %s (%s)", incident.Kind, incident.Detail)
+	}
+	r.incidents.add(incident)
+}
+
+// watchdogStatus adds the watchdog's state to a status
+func (r *EmbeddedRegistry) watchdogStatus(status map[string]interface{}) {
+	status["watchdog"] = r.watchdogOn.Load()
+	r.incidents.mu.Lock()
+	status["watchdog_restarts"] = r.incidents.restarts
+	r.incidents.mu.Unlock()
+	status["incidents"] = r.Incidents()
+}
+
+// Watchdog keeps the registry up until quit is closed. It checks every watchdogInterval, and soon
+// after the container dies, that the registry runs and answers /v2/, and restarts it with the
+// config it was last started with when it does not, waiting longer after each restart that did
+// not hold. A registry stopped through Stop is left alone.
+func (r *EmbeddedRegistry) Watchdog(quit <-chan struct{}) {
+	r.watchdogOn.Store(true)
+	defer r.watchdogOn.Store(false)
+	ticker := time.NewTicker(watchdogInterval)
+	defer ticker.Stop()
+
+	failures := 0
+	backoff := 10 * time.Second
+	var lastRestart, nextRestart time.Time
+	for {
+		select {
+		case <-quit:
+			return
+		case <-ticker.C:
+		case <-r.crashed:
+			select {
+			case <-quit:
+				return
+			case <-time.After(watchdogGrace):
+			}
+		}
+		if !r.wanted.Load() {
+			failures = 0
+			continue
+		}
+
+		kind, problem := r.healthProblem()
+		if problem == "" {
+			failures = 0
+			if !lastRestart.IsZero() && time.Since(lastRestart) > watchdogStable {
+				backoff = 10 * time.Second
+				lastRestart = time.Time{}
+			}
+			continue
+		}
+		if kind == "unresponsive" {
+			if failures++; failures < watchdogFailures {
+				continue
+			}
+		}
+		if time.Now().Before(nextRestart) {
+			continue
+		}
+
+		incident := models.RegistryIncident{Time: time.Now(), Kind: kind, Detail: problem}
+		restarted, err := r.restartForWatchdog()
+		if err != nil {
+			incident.RestartError = err.Error()
+		} else if !restarted {
+			continue
+		}
+		incident.Restarted = restarted
+		r.recordIncident(incident)
+		failures = 0
+		lastRestart = time.Now()
+		nextRestart = lastRestart.Add(backoff)
+		if backoff *= 2; backoff > 5*time.Minute {
+			backoff = 5 * time.Minute
+		}
+	}
+}
+
+// healthProblem returns what is wrong with the registry: kind "down" when it does not run,
+// "unresponsive" when it runs but does not answer /v2/, and an empty problem when it is healthy
+func (r *EmbeddedRegistry) healthProblem() (kind, problem string) {
+	if r.inProcess {
+		if r.served.Load() == nil {
+			return "down", "the in-process registry is not serving"
+		}
+	} else {
+		state, err := r.containerState()
+		switch {
+		case err != nil:
+			return "down", err.Error()
+		case state == nil:
+			return "down", "the registry container does not exist"
+		case !state.Running:
+			return "down", fmt.Sprintf("the registry container is %s (exit code %d)", state.Status, state.ExitCode)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", r.URL()+"/v2/", nil)
+	if err != nil {
+		return "unresponsive", err.Error()
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "unresponsive", fmt.Sprintf("/v2/ did not answer: %v", err)
+	}
+	resp.Body.Close()
+	// 401 is the answer of a registry with authentication
+	if resp.StatusCode >= 500 {
+		return "unresponsive", fmt.Sprintf("/v2/ returned status %d", resp.StatusCode)
+	}
+	return "", ""
+}
+
+// restartForWatchdog starts the registry again with the config it was last started with, unless
+// it was stopped meanwhile
+func (r *EmbeddedRegistry) restartForWatchdog() (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.wanted.Load() {
+		return false, nil
+	}
+	var config *models.StorageConfig
+	if last := r.lastConfig.Load(); last != nil {
+		copied := *last
+		config = &copied
+	}
+	if err := r.startLocked(config); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,80 @@
+package report
+
+import (
+	"html/template"
+	"io"
+	"time"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"color":      severityColor,
+	"short":      shortDigest,
+	"severities": func() []string { return severities },
+	"date":       func(t time.Time) string { return t.UTC().Format("2006-01-02 15:04 UTC") },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Vulnerability Scan Report – {{.Scope}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #111827; margin: 32px; font-size: 13px; }
+h1 { font-size: 22px; margin: 0 0 4px; }
+h2 { font-size: 16px; margin: 28px 0 8px; border-bottom: 1px solid #e5e7eb; padding-bottom: 4px; }
+.meta { color: #6b7280; }
+.cards { display: flex; gap: 12px; flex-wrap: wrap; margin-top: 16px; }
+.card { border: 1px solid #e5e7eb; border-radius: 6px; padding: 10px 14px; min-width: 90px; }
+.card .n { font-size: 20px; font-weight: 600; }
+.card .l { color: #6b7280; font-size: 11px; text-transform: uppercase; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 5px 8px; border-bottom: 1px solid #f3f4f6; vertical-align: top; }
+th { background: #f9fafb; font-size: 11px; text-transform: uppercase; color: #374151; }
+td.num, th.num { text-align: right; }
+.sev { color: #fff; border-radius: 4px; padding: 1px 6px; font-size: 11px; font-weight: 600; }
+.mono { font-family: ui-monospace, Menlo, Consolas, monospace; font-size: 12px; }
+.desc { color: #4b5563; max-width: 420px; }
+.empty { color: #6b7280; font-style: italic; }
+@media print { body { margin: 0; } h2 { break-after: avoid; } tr { break-inside: avoid; } }
+</style>
+</head>
+<body>
+{{$r := .Report}}
+<h1>Vulnerability Scan Report</h1>
+<div class="meta">Scope: {{.Scope}} · Generated {{date $r.GeneratedAt}} · {{len $r.Images}} scanned images</div>
+
+<h2>Summary</h2>
+<div class="cards">
+<div class="card"><div class="n">{{$r.Total}}</div><div class="l">Findings</div></div>
+{{range severities}}<div class="card"><div class="n" style="color: {{color .}}">{{index $r.Severities .}}</div><div class="l">{{.}}</div></div>
+{{end}}<div class="card"><div class="n">{{$r.Fixable}}</div><div class="l">Fixable</div></div>
+</div>
+
+<h2>Top Vulnerabilities</h2>
+{{if $r.TopVulnerabilities}}<table>
+<tr><th>Vulnerability</th><th>Severity</th><th>Packages</th><th>Fixed In</th><th class="num">Images</th><th>Description</th></tr>
+{{range $r.TopVulnerabilities}}<tr><td class="mono">{{.ID}}</td><td><span class="sev" style="background: {{color .Severity}}">{{.Severity}}</span></td><td>{{.Packages}}</td><td class="mono">{{if .FixedVersion}}{{.FixedVersion}}{{else}}–{{end}}</td><td class="num">{{.Images}}</td><td class="desc">{{.Description}}</td></tr>
+{{end}}</table>{{else}}<p class="empty">No vulnerabilities found.</p>{{end}}
+
+<h2>Fixable Items</h2>
+{{if $r.FixableItems}}<table>
+<tr><th>Package</th><th>Installed</th><th>Fixed In</th><th>Highest Severity</th><th class="num">Vulnerabilities</th><th class="num">Images</th></tr>
+{{range $r.FixableItems}}<tr><td>{{.Package}}</td><td class="mono">{{.Version}}</td><td class="mono">{{.FixedVersion}}</td><td><span class="sev" style="background: {{color .Severity}}">{{.Severity}}</span></td><td class="num">{{.Vulnerabilities}}</td><td class="num">{{.Images}}</td></tr>
+{{end}}</table>{{else}}<p class="empty">No fixable vulnerabilities.</p>{{end}}
+
+<h2>Scanned Images</h2>
+{{if $r.Images}}<table>
+<tr><th>Image</th><th>Digest</th><th>Scanned</th>{{range severities}}<th class="num">{{.}}</th>{{end}}<th class="num">Fixable</th></tr>
+{{range $img := $r.Images}}<tr><td>{{$img.Repository}}:{{$img.Tag}}</td><td class="mono">{{short $img.Digest}}</td><td>{{date $img.ScannedAt}}</td>{{range severities}}<td class="num">{{index $img.Severities .}}</td>{{end}}<td class="num">{{$img.Fixable}}</td></tr>
+{{end}}</table>{{else}}<p class="empty">No completed scans.</p>{{end}}
+</body>
+</html>
+`))
+
+// HTML writes a scan report as a standalone, printable HTML page
+func HTML(w io.Writer, rep *models.ScanReport) error {
+	return htmlTemplate.Execute(w, struct {
+		Report *models.ScanReport
+		Scope  string
+	}{rep, Scope(rep)})
+}
@@ -0,0 +1,321 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// A4 portrait in points
+const (
+	pageWidth  = 595.28
+	pageHeight = 841.89
+	margin     = 42.0
+)
+
+// Fonts of the document; both use the standard Helvetica metrics every PDF reader has
+const (
+	fontRegular = "F1"
+	fontBold    = "F2"
+)
+
+// PDF writes a scan report as a PDF document
+func PDF(w io.Writer, rep *models.ScanReport) error {
+	d := &pdfDoc{}
+	d.newPage()
+
+	d.line(fontBold, 18, "Vulnerability Scan Report")
+	d.line(fontRegular, 9, fmt.Sprintf("Scope: %s", Scope(rep)))
+	d.line(fontRegular, 9, fmt.Sprintf("Generated %s - %d scanned images", rep.GeneratedAt.UTC().Format("2006-01-02 15:04 UTC"), len(rep.Images)))
+
+	d.heading("Summary")
+	summary := pdfTable{severityColumn: -1, columns: []pdfColumn{{"Findings", 75, true}}}
+	row := []string{strconv.Itoa(rep.Total)}
+	for _, s := range severities {
+		summary.columns = append(summary.columns, pdfColumn{s, 70, true})
+		row = append(row, strconv.Itoa(rep.Severities[s]))
+	}
+	summary.columns = append(summary.columns, pdfColumn{"Fixable", 70, true})
+	summary.rows = [][]string{append(row, strconv.Itoa(rep.Fixable))}
+	d.table(summary)
+
+	d.heading("Top Vulnerabilities")
+	if len(rep.TopVulnerabilities) == 0 {
+		d.line(fontRegular, 9, "No vulnerabilities found.")
+	} else {
+		t := pdfTable{severityColumn: 1, columns: []pdfColumn{
+			{"Vulnerability", 105, false}, {"Severity", 60, false}, {"Packages", 110, false},
+			{"Fixed In", 64, false}, {"Images", 40, true}, {"Description", 132, false},
+		}}
+		for _, v := range rep.TopVulnerabilities {
+			t.rows = append(t.rows, []string{v.ID, v.Severity, v.Packages, orDash(v.FixedVersion), strconv.Itoa(v.Images), v.Description})
+		}
+		d.table(t)
+	}
+
+	d.heading("Fixable Items")
+	if len(rep.FixableItems) == 0 {
+		d.line(fontRegular, 9, "No fixable vulnerabilities.")
+	} else {
+		t := pdfTable{severityColumn: 3, columns: []pdfColumn{
+			{"Package", 130, false}, {"Installed", 100, false}, {"Fixed In", 100, false},
+			{"Severity", 60, false}, {"Vulns", 60, true}, {"Images", 61, true},
+		}}
+		for _, f := range rep.FixableItems {
+			t.rows = append(t.rows, []string{f.Package, f.Version, f.FixedVersion, f.Severity, strconv.Itoa(f.Vulnerabilities), strconv.Itoa(f.Images)})
+		}
+		d.table(t)
+	}
+
+	d.heading("Scanned Images")
+	if len(rep.Images) == 0 {
+		d.line(fontRegular, 9, "No completed scans.")
+	} else {
+		t := pdfTable{severityColumn: -1, columns: []pdfColumn{{"Image", 140, false}, {"Digest", 70, false}, {"Scanned", 62, false}}}
+		for _, s := range severities {
+			t.columns = append(t.columns, pdfColumn{severityAbbreviations[s], 39, true})
+		}
+		t.columns = append(t.columns, pdfColumn{"Fixable", 44, true})
+		for _, img := range rep.Images {
+			row := []string{img.Repository + ":" + img.Tag, shortDigest(img.Digest), img.ScannedAt.UTC().Format("2006-01-02")}
+			for _, s := range severities {
+				row = append(row, strconv.Itoa(img.Severities[s]))
+			}
+			t.rows = append(t.rows, append(row, strconv.Itoa(img.Fixable)))
+		}
+		d.table(t)
+	}
+
+	_, err := w.Write(d.bytes())
+	return err
+}
+
+// severityAbbreviations head the narrow per-image severity columns
+var severityAbbreviations = map[string]string{"CRITICAL": "Crit", "HIGH": "High", "MEDIUM": "Med", "LOW": "Low", "UNKNOWN": "Unk"}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// pdfDoc lays out text top to bottom over as many pages as needed
+type pdfDoc struct {
+	pages []*bytes.Buffer // Content streams
+	page  *bytes.Buffer
+	y     float64 // Baseline of the next line
+}
+
+func (d *pdfDoc) newPage() {
+	d.page = &bytes.Buffer{}
+	d.pages = append(d.pages, d.page)
+	d.y = pageHeight - margin
+}
+
+// ensure starts a new page unless height points fit above the bottom margin
+func (d *pdfDoc) ensure(height float64) bool {
+	if d.y-height >= margin+12 {
+		return false
+	}
+	d.newPage()
+	return true
+}
+
+func (d *pdfDoc) text(x, y float64, font string, size float64, s string) {
+	fmt.Fprintf(d.page, "BT /%s %.1f Tf %.2f %.2f Td (%s) Tj ET\n", font, size, x, y, pdfString(s))
+}
+
+func (d *pdfDoc) fillRect(x, y, w, h float64, color string) {
+	r, g, b := hexColor(color)
+	fmt.Fprintf(d.page, "%.3f %.3f %.3f rg %.2f %.2f %.2f %.2f re f 0 g\n", r, g, b, x, y, w, h)
+}
+
+// line writes a line of text at the left margin
+func (d *pdfDoc) line(font string, size float64, s string) {
+	d.ensure(size * 1.4)
+	d.y -= size
+	d.text(margin, d.y, font, size, fit(s, font, size, pageWidth-2*margin))
+	d.y -= size * 0.4
+}
+
+func (d *pdfDoc) heading(s string) {
+	d.ensure(60) // Keep a heading with the start of its section
+	d.y -= 14
+	d.line(fontBold, 13, s)
+	d.y -= 2
+}
+
+type pdfColumn struct {
+	title string
+	width float64
+	right bool // Right-aligned (numbers)
+}
+
+type pdfTable struct {
+	columns        []pdfColumn
+	rows           [][]string
+	severityColumn int // Index of the column drawn as severity badges, -1 for none
+}
+
+// table draws rows of single-line cells, truncating what does not fit and repeating the header on new pages
+func (d *pdfDoc) table(t pdfTable) {
+	const size, rowHeight = 8.0, 14.0
+	header := func() {
+		d.fillRect(margin, d.y-rowHeight+3, pageWidth-2*margin, rowHeight, "#f3f4f6")
+		d.cells(t.columns, nil, fontBold, size, rowHeight, -1)
+	}
+	d.ensure(rowHeight * 2)
+	header()
+	for _, row := range t.rows {
+		if d.ensure(rowHeight) {
+			header()
+		}
+		d.cells(t.columns, row, fontRegular, size, rowHeight, t.severityColumn)
+	}
+	d.y -= 4
+}
+
+// cells draws one table row; a nil row draws the column titles
+func (d *pdfDoc) cells(columns []pdfColumn, row []string, font string, size, rowHeight float64, badge int) {
+	y := d.y - rowHeight + 7
+	x := margin
+	for i, col := range columns {
+		s := col.title
+		if row != nil {
+			s = row[i]
+		}
+		s = fit(s, font, size, col.width-6)
+		tx := x + 3
+		if col.right {
+			tx = x + col.width - 3 - textWidth(s, font, size)
+		}
+		if i == badge && row != nil {
+			d.fillRect(x+1, y-2.5, textWidth(s, fontBold, size)+5, size+3, severityColor(s))
+			fmt.Fprint(d.page, "1 g\n")
+			d.text(x+3.5, y, fontBold, size, s)
+			fmt.Fprint(d.page, "0 g\n")
+		} else {
+			d.text(tx, y, font, size, s)
+		}
+		x += col.width
+	}
+	d.y -= rowHeight
+}
+
+// bytes assembles the pages into a PDF file with page numbers in the footer
+func (d *pdfDoc) bytes() []byte {
+	var out bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, out.Len())
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	// 1: catalog, 2: page tree, 3-4: fonts, then a page object and its content stream per page
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, len(d.pages))
+	for i := range d.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range d.pages {
+		footer := fmt.Sprintf("Page %d of %d", i+1, len(d.pages))
+		fmt.Fprintf(page, "0.42 g BT /%s 8.0 Tf %.2f %.2f Td (%s) Tj ET 0 g\n", fontRegular,
+			pageWidth-margin-textWidth(footer, fontRegular, 8), margin-14, footer)
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /%s 3 0 R /%s 4 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, fontRegular, fontBold, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", page.Len(), page.String()))
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return out.Bytes()
+}
+
+// winAnsi maps the non-Latin-1 characters of WinAnsiEncoding that reports commonly contain
+var winAnsi = map[rune]byte{'–': 0x96, '—': 0x97, '‘': 0x91, '’': 0x92, '“': 0x93, '”': 0x94, '•': 0x95, '…': 0x85, '€': 0x80}
+
+// pdfString encodes text as a WinAnsi PDF string body; characters the standard fonts lack become '?'
+func pdfString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n' || r == '\t':
+			b.WriteByte(' ')
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&b, "\\%03o", r)
+		case winAnsi[r] != 0:
+			fmt.Fprintf(&b, "\\%03o", winAnsi[r])
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// charWidth approximates Helvetica glyph widths in thousandths of the font size
+func charWidth(r rune, bold bool) float64 {
+	w := 556.0
+	switch {
+	case strings.ContainsRune("iljI.,:;'|!", r):
+		w = 278
+	case strings.ContainsRune("ftr -()[]/", r):
+		w = 333
+	case strings.ContainsRune("mwMW@", r):
+		w = 889
+	case r >= 'A' && r <= 'Z':
+		w = 667
+	}
+	if bold {
+		w *= 1.06
+	}
+	return w
+}
+
+func textWidth(s, font string, size float64) float64 {
+	var w float64
+	for _, r := range s {
+		w += charWidth(r, font == fontBold)
+	}
+	return w * size / 1000
+}
+
+// fit shortens text with "..." until it is at most width points wide
+func fit(s, font string, size, width float64) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if textWidth(s, font, size) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && textWidth(string(runes)+"...", font, size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// hexColor converts "#rrggbb" to PDF color components
+func hexColor(c string) (float64, float64, float64) {
+	v, err := strconv.ParseUint(strings.TrimPrefix(c, "#"), 16, 32)
+	if err != nil {
+		return 0, 0, 0
+	}
+	return float64(v>>16&0xff) / 255, float64(v>>8&0xff) / 255, float64(v&0xff) / 255
+}
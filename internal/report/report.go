@@ -0,0 +1,64 @@
+// Package report renders scan reports for auditors as standalone HTML pages and PDF documents.
+package report
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"docker-registry-dashboard/internal/models"
+)
+
+// severities are the report's severity columns, most severe first
+var severities = []string{"CRITICAL", "HIGH", "MEDIUM", "LOW", "UNKNOWN"}
+
+// severityColors are the colors of severity badges (RGB hex)
+var severityColors = map[string]string{
+	"CRITICAL": "#991b1b",
+	"HIGH":     "#dc2626",
+	"MEDIUM":   "#d97706",
+	"LOW":      "#2563eb",
+	"UNKNOWN":  "#6b7280",
+}
+
+func severityColor(s string) string {
+	if c, ok := severityColors[strings.ToUpper(s)]; ok {
+		return c
+	}
+	return severityColors["UNKNOWN"]
+}
+
+// Scope describes what a report covers, e.g. "registry prod", "repository app" or "image app:1.0"
+func Scope(rep *models.ScanReport) string {
+	switch {
+	case rep.Tag != "":
+		return fmt.Sprintf("image %s:%s in registry %s", rep.Repository, rep.Tag, rep.RegistryName)
+	case rep.Repository != "":
+		return fmt.Sprintf("repository %s in registry %s", rep.Repository, rep.RegistryName)
+	}
+	return "registry " + rep.RegistryName
+}
+
+var unsafeFilename = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Filename returns a download name for a report, e.g. "scan-report-prod-app-1.0-20240102.pdf"
+func Filename(rep *models.ScanReport, ext string) string {
+	parts := []string{"scan-report", rep.RegistryName}
+	if rep.Repository != "" {
+		parts = append(parts, rep.Repository)
+	}
+	if rep.Tag != "" {
+		parts = append(parts, rep.Tag)
+	}
+	parts = append(parts, rep.GeneratedAt.Format("20060102"))
+	name := unsafeFilename.ReplaceAllString(strings.Join(parts, "-"), "_")
+	return name + "." + ext
+}
+
+// shortDigest abbreviates a digest for tables
+func shortDigest(d string) string {
+	if _, hex, ok := strings.Cut(d, ":"); ok && len(hex) > 12 {
+		return hex[:12]
+	}
+	return d
+}
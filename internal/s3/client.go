@@ -0,0 +1,332 @@
+// Package s3 reads and writes objects of S3-compatible storage (AWS S3, MinIO, Ceph) with
+// Signature V4, using path-style URLs and multipart uploads so archives of any size can be streamed.
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PartSize is the size of multipart upload parts; S3 requires at least 5 MiB for all but the last
+const PartSize = 16 << 20
+
+// maxParts is the S3 limit on parts per multipart upload
+const maxParts = 10000
+
+// emptySHA256 is the payload hash of a request without body
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// Config locates a bucket and the credentials to write to it
+type Config struct {
+	Endpoint  string // host[:port], or a URL with scheme
+	Region    string // Defaults to us-east-1
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PlainHTTP bool // Use http:// for an endpoint given without scheme
+}
+
+// Client reads and writes the objects of one bucket
+type Client struct {
+	cfg        Config
+	baseURL    string // scheme://host[:port]
+	httpClient *http.Client
+}
+
+// NewClient creates a client for a bucket
+func NewClient(cfg Config) *Client {
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	base := strings.TrimRight(cfg.Endpoint, "/")
+	if !strings.Contains(base, "://") {
+		if cfg.PlainHTTP {
+			base = "http://" + base
+		} else {
+			base = "https://" + base
+		}
+	}
+	return &Client{cfg: cfg, baseURL: base, httpClient: &http.Client{Timeout: 10 * time.Minute}}
+}
+
+// Location returns the s3:// URL of an object key
+func (c *Client) Location(key string) string {
+	return "s3://" + c.cfg.Bucket + "/" + key
+}
+
+// Upload streams r to the object key and returns the number of bytes written. Content up to one
+// part is sent with a single PUT; larger content uses a multipart upload, aborted on failure.
+func (c *Client) Upload(ctx context.Context, key string, r io.Reader) (int64, error) {
+	buf := make([]byte, PartSize)
+	n, err := io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return int64(n), c.putObject(ctx, key, buf[:n])
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	uploadID, err := c.createMultipartUpload(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	var parts []completedPart
+	var total int64
+	for {
+		if len(parts) == maxParts {
+			err = fmt.Errorf("object exceeds %d parts of %d bytes", maxParts, PartSize)
+			break
+		}
+		var etag string
+		etag, err = c.uploadPart(ctx, key, uploadID, len(parts)+1, buf[:n])
+		if err != nil {
+			break
+		}
+		parts = append(parts, completedPart{PartNumber: len(parts) + 1, ETag: etag})
+		total += int64(n)
+
+		n, err = io.ReadFull(r, buf)
+		if err == io.EOF {
+			err = nil
+			break
+		}
+		if err == io.ErrUnexpectedEOF {
+			err = nil
+		} else if err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = c.completeMultipartUpload(ctx, key, uploadID, parts)
+	}
+	if err != nil {
+		c.abortMultipartUpload(key, uploadID)
+		return total, err
+	}
+	return total, nil
+}
+
+func (c *Client) putObject(ctx context.Context, key string, body []byte) error {
+	resp, err := c.do(ctx, "PUT", key, nil, body)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+type initiateResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+func (c *Client) createMultipartUpload(ctx context.Context, key string) (string, error) {
+	resp, err := c.do(ctx, "POST", key, url.Values{"uploads": {""}}, nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var result initiateResult
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil || result.UploadID == "" {
+		return "", fmt.Errorf("failed to start multipart upload: invalid response")
+	}
+	return result.UploadID, nil
+}
+
+func (c *Client) uploadPart(ctx context.Context, key, uploadID string, number int, body []byte) (string, error) {
+	resp, err := c.do(ctx, "PUT", key, url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}, body)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload part %d: %w", number, err)
+	}
+	resp.Body.Close()
+	return resp.Header.Get("ETag"), nil
+}
+
+type completedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeUpload struct {
+	XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+	Parts   []completedPart `xml:"Part"`
+}
+
+func (c *Client) completeMultipartUpload(ctx context.Context, key, uploadID string, parts []completedPart) error {
+	body, _ := xml.Marshal(completeUpload{Parts: parts})
+	resp, err := c.do(ctx, "POST", key, url.Values{"uploadId": {uploadID}}, body)
+	if err != nil {
+		return fmt.Errorf("failed to complete multipart upload: %w", err)
+	}
+	defer resp.Body.Close()
+	// S3 can report a failure with 200 once it has started answering
+	respBody, _ := io.ReadAll(resp.Body)
+	if bytes.Contains(respBody, []byte("<Error>")) {
+		return fmt.Errorf("failed to complete multipart upload: %s", respBody)
+	}
+	return nil
+}
+
+func (c *Client) abortMultipartUpload(key, uploadID string) {
+	if resp, err := c.do(context.Background(), "DELETE", key, url.Values{"uploadId": {uploadID}}, nil); err == nil {
+		resp.Body.Close()
+	}
+}
+
+// StatusError is returned for a non-2xx answer
+type StatusError struct {
+	Method     string
+	Key        string
+	StatusCode int
+	Message    string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s returned %d: %s", e.Method, e.Key, e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is a 404 answer
+func IsNotFound(err error) bool {
+	var statusErr *StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
+
+// do sends a signed request for an object and fails on non-2xx responses
+func (c *Client) do(ctx context.Context, method, key string, query url.Values, body []byte) (*http.Response, error) {
+	return c.doWithHeaders(ctx, method, key, query, nil, body)
+}
+
+// doWithHeaders is do with extra headers, which are signed too
+func (c *Client) doWithHeaders(ctx context.Context, method, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	u := c.baseURL + "/" + c.cfg.Bucket + "/" + encodePath(key)
+	if len(query) > 0 {
+		u += "?" + canonicalQuery(query)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	payloadHash := emptySHA256
+	if len(body) > 0 {
+		sum := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(sum[:])
+	}
+	c.sign(req, payloadHash, time.Now().UTC())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		return nil, &StatusError{Method: method, Key: key, StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(msg))}
+	}
+	return resp, nil
+}
+
+// sign adds a Signature V4 Authorization header covering the host and every header already set
+func (c *Client) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(v, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + c.cfg.Region + "/s3/aws4_request"
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+
+	key := hmacSHA256([]byte("AWS4"+c.cfg.SecretKey), day)
+	key = hmacSHA256(key, c.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	m := hmac.New(sha256.New, key)
+	m.Write([]byte(data))
+	return m.Sum(nil)
+}
+
+// canonicalQuery sorts and strictly URI-encodes query parameters as Signature V4 requires
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		for _, v := range q[k] {
+			parts = append(parts, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// encodePath URI-encodes every segment of an object key, keeping the slashes
+func encodePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = uriEncode(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// uriEncode encodes everything but unreserved characters (RFC 3986), as AWS does
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ch >= 'A' && ch <= 'Z' || ch >= 'a' && ch <= 'z' || ch >= '0' && ch <= '9' || ch == '-' || ch == '_' || ch == '.' || ch == '~' {
+			b.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,243 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// MinPartSize is the smallest part S3 accepts but for the last one of a multipart upload
+const MinPartSize = 5 << 20
+
+// maxCopySize is the largest object a single CopyObject or UploadPartCopy request copies
+const maxCopySize = 5 << 30
+
+// copyPartSize is the size of the ranges large objects are copied in
+const copyPartSize = 1 << 30
+
+// ObjectInfo describes an object
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	LastModified time.Time
+}
+
+// Get opens an object for reading from offset. An offset at or past the end gives an empty reader.
+func (c *Client) Get(ctx context.Context, key string, offset int64) (io.ReadCloser, error) {
+	var header http.Header
+	if offset > 0 {
+		header = http.Header{"Range": {fmt.Sprintf("bytes=%d-", offset)}}
+	}
+	resp, err := c.doWithHeaders(ctx, "GET", key, nil, header, nil)
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Head returns the size and modification time of an object
+func (c *Client) Head(ctx context.Context, key string) (ObjectInfo, error) {
+	resp, err := c.do(ctx, "HEAD", key, nil, nil)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	resp.Body.Close()
+	info := ObjectInfo{Key: key, Size: resp.ContentLength}
+	info.LastModified, _ = http.ParseTime(resp.Header.Get("Last-Modified"))
+	return info, nil
+}
+
+// Put writes an object with a single request
+func (c *Client) Put(ctx context.Context, key string, data []byte) error {
+	return c.putObject(ctx, key, data)
+}
+
+// Delete removes an object; deleting a missing object succeeds
+func (c *Client) Delete(ctx context.Context, key string) error {
+	resp, err := c.do(ctx, "DELETE", key, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+type listResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	CommonPrefixes []struct {
+		Prefix string `xml:"Prefix"`
+	} `xml:"CommonPrefixes"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List returns the objects whose keys start with prefix. With a delimiter such as "/", keys
+// continuing past the next delimiter are grouped into the returned common prefixes instead.
+func (c *Client) List(ctx context.Context, prefix, delimiter string) ([]ObjectInfo, []string, error) {
+	var objects []ObjectInfo
+	var prefixes []string
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if delimiter != "" {
+			query.Set("delimiter", delimiter)
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := c.do(ctx, "GET", "", query, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+		var page listResult
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse object list: %w", err)
+		}
+
+		for _, o := range page.Contents {
+			objects = append(objects, ObjectInfo{Key: o.Key, Size: o.Size, LastModified: o.LastModified})
+		}
+		for _, p := range page.CommonPrefixes {
+			prefixes = append(prefixes, p.Prefix)
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return objects, prefixes, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// Copy copies the object src of the given size to dst within the bucket, in ranges when it is
+// too large for a single request
+func (c *Client) Copy(ctx context.Context, src, dst string, size int64) error {
+	if size <= maxCopySize {
+		header := http.Header{"X-Amz-Copy-Source": {"/" + c.cfg.Bucket + "/" + encodePath(src)}}
+		resp, err := c.doWithHeaders(ctx, "PUT", dst, nil, header, nil)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		// Like completing a multipart upload, a copy can fail after a 200
+		body, _ := io.ReadAll(resp.Body)
+		if bytes.Contains(body, []byte("<Error>")) {
+			return fmt.Errorf("failed to copy %s: %s", src, body)
+		}
+		return nil
+	}
+
+	upload, err := c.NewMultipartUpload(ctx, dst)
+	if err != nil {
+		return err
+	}
+	if err := upload.CopyFrom(ctx, src, size); err != nil {
+		upload.Abort()
+		return err
+	}
+	if err := upload.Complete(ctx); err != nil {
+		upload.Abort()
+		return err
+	}
+	return nil
+}
+
+// MultipartUpload is an upload of an object in parts, which can be written to or copied from
+// other objects
+type MultipartUpload struct {
+	c        *Client
+	key      string
+	uploadID string
+	parts    []completedPart
+}
+
+// NewMultipartUpload starts a multipart upload of an object
+func (c *Client) NewMultipartUpload(ctx context.Context, key string) (*MultipartUpload, error) {
+	uploadID, err := c.createMultipartUpload(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return &MultipartUpload{c: c, key: key, uploadID: uploadID}, nil
+}
+
+// WritePart uploads the next part; all but the last must be at least MinPartSize
+func (u *MultipartUpload) WritePart(ctx context.Context, data []byte) error {
+	if len(u.parts) == maxParts {
+		return fmt.Errorf("object exceeds %d parts", maxParts)
+	}
+	number := len(u.parts) + 1
+	etag, err := u.c.uploadPart(ctx, u.key, u.uploadID, number, data)
+	if err != nil {
+		return err
+	}
+	u.parts = append(u.parts, completedPart{PartNumber: number, ETag: etag})
+	return nil
+}
+
+type copyPartResult struct {
+	ETag string `xml:"ETag"`
+}
+
+// CopyFrom adds the object src of the given size (at least MinPartSize unless it ends the upload)
+// as the next parts
+func (u *MultipartUpload) CopyFrom(ctx context.Context, src string, size int64) error {
+	for start := int64(0); start < size; {
+		end := start + copyPartSize
+		// A short remainder goes with this range, since only the last part may be small
+		if end >= size || size-end < MinPartSize {
+			end = size
+		}
+		if len(u.parts) == maxParts {
+			return fmt.Errorf("object exceeds %d parts", maxParts)
+		}
+		number := len(u.parts) + 1
+		header := http.Header{
+			"X-Amz-Copy-Source":       {"/" + u.c.cfg.Bucket + "/" + encodePath(src)},
+			"X-Amz-Copy-Source-Range": {fmt.Sprintf("bytes=%d-%d", start, end-1)},
+		}
+		query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {u.uploadID}}
+		resp, err := u.c.doWithHeaders(ctx, "PUT", u.key, query, header, nil)
+		if err != nil {
+			return fmt.Errorf("failed to copy part %d: %w", number, err)
+		}
+		var result copyPartResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil || result.ETag == "" {
+			return fmt.Errorf("failed to copy part %d: invalid response", number)
+		}
+		u.parts = append(u.parts, completedPart{PartNumber: number, ETag: result.ETag})
+		start = end
+	}
+	return nil
+}
+
+// Parts returns how many parts were uploaded
+func (u *MultipartUpload) Parts() int {
+	return len(u.parts)
+}
+
+// Complete assembles the parts into the object
+func (u *MultipartUpload) Complete(ctx context.Context) error {
+	return u.c.completeMultipartUpload(ctx, u.key, u.uploadID, u.parts)
+}
+
+// Abort discards the uploaded parts
+func (u *MultipartUpload) Abort() {
+	u.c.abortMultipartUpload(u.key, u.uploadID)
+}
@@ -0,0 +1,177 @@
+package scanner
+
+import (
+	"encoding/json"
+)
+
+// Finding is a single vulnerability reported by one scanner
+type Finding struct {
+	ID           string `json:"id"`
+	Package      string `json:"package"`
+	Version      string `json:"version"`
+	FixedVersion string `json:"fixed_version"`
+	Severity     string `json:"severity"`
+	Description  string `json:"description"`
+	Scanner      string `json:"scanner"` // "Trivy" or "OSV"
+}
+
+// ParseFindings flattens a report wrapped under scanner keys ({"trivy": ..., "osv": ...})
+func ParseFindings(report string) []Finding {
+	var result []Finding
+
+	var reportWrapper map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(report), &reportWrapper); err != nil {
+		return result
+	}
+
+	if trivyData, ok := reportWrapper["trivy"]; ok {
+		result = append(result, parseTrivyFindings(trivyData)...)
+	}
+	if osvData, ok := reportWrapper["osv"]; ok {
+		result = append(result, parseOSVFindings(osvData)...)
+	}
+	return result
+}
+
+// ParseScannerFindings is ParseFindings limited to the results of one scanner ("trivy" or "osv"),
+// leaving out what other scanners reported, possibly for an older digest
+func ParseScannerFindings(report, key string) []Finding {
+	var reportWrapper map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(report), &reportWrapper); err != nil {
+		return nil
+	}
+	data, ok := reportWrapper[key]
+	if !ok {
+		return nil
+	}
+	switch key {
+	case "trivy":
+		return parseTrivyFindings(data)
+	case "osv":
+		return parseOSVFindings(data)
+	}
+	return nil
+}
+
+func parseTrivyFindings(data json.RawMessage) []Finding {
+	var result []Finding
+
+	var trivyReport TrivyReport
+	if err := json.Unmarshal(data, &trivyReport); err != nil {
+		return result
+	}
+
+	for _, res := range trivyReport.Results {
+		for _, vuln := range res.Vulnerabilities {
+			result = append(result, Finding{
+				ID:           vuln.VulnerabilityID,
+				Package:      vuln.PkgName,
+				Version:      vuln.InstalledVersion,
+				FixedVersion: vuln.FixedVersion,
+				Severity:     vuln.Severity,
+				Description:  vuln.Title,
+				Scanner:      "Trivy",
+			})
+		}
+	}
+	return result
+}
+
+func parseOSVFindings(data json.RawMessage) []Finding {
+	var result []Finding
+
+	var osvOutput OSVOutput
+	if err := json.Unmarshal(data, &osvOutput); err != nil {
+		return result
+	}
+
+	for _, res := range osvOutput.Results {
+		for _, pkg := range res.Packages {
+			for _, vuln := range pkg.Vulnerabilities {
+				severity := "UNKNOWN"
+				if vuln.DatabaseSpecific != nil {
+					if s, ok := vuln.DatabaseSpecific["severity"].(string); ok {
+						severity = s
+					}
+				}
+				if severity == "UNKNOWN" && len(vuln.Severity) > 0 {
+					severity = vuln.Severity[0].Score
+				}
+
+				result = append(result, Finding{
+					ID:          vuln.ID,
+					Package:     pkg.Package.Name,
+					Version:     pkg.Package.Version,
+					Severity:    severity,
+					Description: vuln.Summary,
+					Scanner:     "OSV",
+				})
+			}
+		}
+	}
+	return result
+}
+
+// ReportKeys are the scanner keys a wrapped report or summary may contain
+var ReportKeys = []string{"trivy", "osv"}
+
+// IsWrapped reports whether a report/summary JSON is already wrapped under scanner keys
+func IsWrapped(data string) bool {
+	var parsed map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(data), &parsed); err != nil {
+		return false
+	}
+	for _, key := range ReportKeys {
+		if _, ok := parsed[key]; ok {
+			return true
+		}
+	}
+	return len(parsed) == 0
+}
+
+// MergeReport stores newJSON under key in an already wrapped report/summary
+func MergeReport(wrappedJSON, key, newJSON string) string {
+	data := make(map[string]json.RawMessage)
+	if wrappedJSON != "" {
+		if err := json.Unmarshal([]byte(wrappedJSON), &data); err != nil {
+			data = make(map[string]json.RawMessage)
+		}
+	}
+
+	if newJSON != "" {
+		data[key] = json.RawMessage(newJSON)
+	}
+
+	b, _ := json.Marshal(data)
+	return string(b)
+}
+
+// WrapLegacy converts an unwrapped report/summary (raw Trivy output from older versions) to the wrapped format
+func WrapLegacy(data string) string {
+	if data == "" || IsWrapped(data) {
+		return data
+	}
+	if !json.Valid([]byte(data)) {
+		b, _ := json.Marshal(map[string]string{"error": data})
+		data = string(b)
+	}
+	return MergeReport("", "trivy", data)
+}
+
+// ScannerOutcome reports whether a wrapped report holds a result of the given scanner,
+// and the error message if that scanner failed
+func ScannerOutcome(wrappedJSON, key string) (bool, string) {
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(wrappedJSON), &data); err != nil {
+		return false, ""
+	}
+	raw, ok := data[key]
+	if !ok {
+		return false, ""
+	}
+	var failed struct {
+		Error string `json:"error"`
+	}
+	json.Unmarshal(raw, &failed)
+	return true, failed.Error
+}
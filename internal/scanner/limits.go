@@ -0,0 +1,153 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Limits bound the resources scanner containers may take from the host
+type Limits struct {
+	CPUs          float64       // CPUs of each container (0: unlimited)
+	MemoryMB      int           // Memory of each container, swap included (0: unlimited)
+	PidsLimit     int           // Processes of each container (0: unlimited)
+	Timeout       time.Duration // A container running longer is killed (0: no timeout)
+	MaxContainers int           // Scanner containers running at once, across all scans (0: unlimited)
+}
+
+// WorkerStatus reports the active limits and how many scanner containers run or wait for a slot
+type WorkerStatus struct {
+	CPUs           float64 `json:"cpus"`
+	MemoryMB       int     `json:"memory_mb"`
+	PidsLimit      int     `json:"pids_limit"`
+	TimeoutSeconds int     `json:"timeout_seconds"`
+	MaxContainers  int     `json:"max_containers"`
+	Running        int     `json:"running"`
+	Waiting        int     `json:"waiting"`
+}
+
+var (
+	limitsMu sync.Mutex
+	limits   Limits
+	slots    chan struct{} // nil when the number of containers is not capped
+	running  int
+	waiting  int
+)
+
+// SetLimits applies resource limits to the scanner containers started from now on. When the
+// number of containers changes, running containers keep their slot in the old count.
+func SetLimits(l Limits) {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	if slots != nil && l.MaxContainers == limits.MaxContainers {
+		limits = l
+		return
+	}
+	limits = l
+	slots = nil
+	if l.MaxContainers > 0 {
+		slots = make(chan struct{}, l.MaxContainers)
+	}
+}
+
+// Status returns the active limits and the current container counts
+func Status() WorkerStatus {
+	limitsMu.Lock()
+	defer limitsMu.Unlock()
+	return WorkerStatus{
+		CPUs:           limits.CPUs,
+		MemoryMB:       limits.MemoryMB,
+		PidsLimit:      limits.PidsLimit,
+		TimeoutSeconds: int(limits.Timeout / time.Second),
+		MaxContainers:  limits.MaxContainers,
+		Running:        running,
+		Waiting:        waiting,
+	}
+}
+
+// runContainer runs "docker run --rm <args>" within the resource limits: it waits for a free
+// container slot, caps the container's CPUs, memory and processes, and kills it at the timeout
+// or when ctx is cancelled
+func runContainer(ctx context.Context, args []string, stdout, stderr *bytes.Buffer) error {
+	limitsMu.Lock()
+	l, s := limits, slots
+	waiting++
+	limitsMu.Unlock()
+
+	if s != nil {
+		select {
+		case s <- struct{}{}:
+		default:
+			log.Printf("⏳ All %d scanner slots are busy, waiting", cap(s))
+			select {
+			case s <- struct{}{}:
+			case <-ctx.Done():
+				limitsMu.Lock()
+				waiting--
+				limitsMu.Unlock()
+				return fmt.Errorf("scanner container not started: %w", ctx.Err())
+			}
+		}
+		defer func() { <-s }()
+	}
+	limitsMu.Lock()
+	waiting--
+	running++
+	limitsMu.Unlock()
+	defer func() {
+		limitsMu.Lock()
+		running--
+		limitsMu.Unlock()
+	}()
+
+	// A named container can be killed: stopping the docker client alone leaves it running
+	b := make([]byte, 6)
+	rand.Read(b)
+	name := "registry-dashboard-scan-" + hex.EncodeToString(b)
+	runArgs := []string{"run", "--rm", "--name", name}
+	if l.CPUs > 0 {
+		runArgs = append(runArgs, "--cpus", strconv.FormatFloat(l.CPUs, 'f', -1, 64))
+	}
+	if l.MemoryMB > 0 {
+		mem := fmt.Sprintf("%dm", l.MemoryMB)
+		runArgs = append(runArgs, "--memory", mem, "--memory-swap", mem)
+	}
+	if l.PidsLimit > 0 {
+		runArgs = append(runArgs, "--pids-limit", strconv.Itoa(l.PidsLimit))
+	}
+	runArgs = append(runArgs, args...)
+
+	if l.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.Timeout)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, "docker", runArgs...)
+	cmd.Cancel = func() error {
+		exec.Command("docker", "kill", name).Run()
+		return cmd.Process.Kill()
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("scanner container killed after the %s timeout", l.Timeout)
+	}
+	if ctx.Err() != nil {
+		return fmt.Errorf("scanner container killed: %w", ctx.Err())
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 137 && l.MemoryMB > 0 {
+		return fmt.Errorf("scanner container killed (exit 137), probably for exceeding the %d MB memory limit", l.MemoryMB)
+	}
+	return err
+}
@@ -2,11 +2,11 @@ package scanner
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 )
@@ -41,7 +41,7 @@ type OSVVulnerability struct {
 }
 
 // ScanImageOSV generates an SBOM using Trivy and scans it with OSV-Scanner
-func ScanImageOSV(registryURL, repo, tag string) (string, string, error) {
+func ScanImageOSV(ctx context.Context, registryURL, repo, tag, platform string) (string, string, error) {
 	// 1. Determine Image Ref
 	targetURL := registryURL
 	// Replace localhost with host.docker.internal for Docker-in-Docker networking
@@ -79,8 +79,7 @@ func ScanImageOSV(registryURL, repo, tag string) (string, string, error) {
 
 	// Create Trivy command to generate SBOM
 	// docker run --rm -v "absTempDir":/output -v /var/run/docker.sock:/var/run/docker.sock aquasec/trivy image --format cyclonedx --output /output/sbom.json <image>
-	trivyCmd := exec.Command("docker", "run", "--rm",
-		"-v", fmt.Sprintf("%s:/output", absTempDir),
+	trivyArgs := []string{"-v", fmt.Sprintf("%s:/output", absTempDir),
 		"-v", "/var/run/docker.sock:/var/run/docker.sock", // Mount docker socket so trivy can find the image
 		"aquasec/trivy", "image",
 		"--format", "cyclonedx",
@@ -88,14 +87,13 @@ func ScanImageOSV(registryURL, repo, tag string) (string, string, error) {
 		"--scanners", "vuln", // Trivy still needs to know what to look at, though for SBOM 'image' is key
 		"--insecure",
 		"--no-progress",
-		imageRef,
-	)
-
+	}
+	if platform != "" {
+		trivyArgs = append(trivyArgs, "--platform", platform)
+	}
+	trivyArgs = append(trivyArgs, imageRef)
 	var trivyOut, trivyErr bytes.Buffer
-	trivyCmd.Stdout = &trivyOut
-	trivyCmd.Stderr = &trivyErr
-
-	if err := trivyCmd.Run(); err != nil {
+	if err := runContainer(ctx, trivyArgs, &trivyOut, &trivyErr); err != nil {
 		log.Printf("⚠️ [OSV] Trivy SBOM generation failed. Stderr: %s", trivyErr.String())
 		return "", "", fmt.Errorf("trivy sbom generation failed: %v", err)
 	}
@@ -112,18 +110,13 @@ func ScanImageOSV(registryURL, repo, tag string) (string, string, error) {
 	log.Printf("🔍 [OSV] Scanning SBOM with OSV-Scanner...")
 
 	// docker run --rm -v "absTempDir":/output ghcr.io/google/osv-scanner --sbom /output/sbom.json --json
-	cmd := exec.Command("docker", "run", "--rm",
+	var stdout, stderr bytes.Buffer
+	err = runContainer(ctx, []string{
 		"-v", fmt.Sprintf("%s:/output", absTempDir),
 		"ghcr.io/google/osv-scanner:v1.9.2",
 		"--sbom", containerSbomPath,
 		"--json",
-	)
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err = cmd.Run()
+	}, &stdout, &stderr)
 	log.Printf("🔍 [OSV] OSV-Scanner exit status: err=%v, stdout len=%d, stderr len=%d", err, stdout.Len(), stderr.Len())
 
 	if stderr.Len() > 0 {
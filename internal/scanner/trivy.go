@@ -2,10 +2,10 @@ package scanner
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
-	"os/exec"
 	"strings"
 )
 
@@ -39,8 +39,10 @@ type TrivyReport struct {
 	Results []TrivyResult `json:"Results"`
 }
 
-// ScanImage runs trivy scan against a target image
-func ScanImage(registryURL, repo, tag string) (string, string, error) {
+// ScanImage runs trivy scan against a target image.
+// platform (e.g. "linux/arm64") selects the image of a multi-arch tag; empty uses trivy's default.
+// Cancelling ctx kills the scanner container.
+func ScanImage(ctx context.Context, registryURL, repo, tag, platform string) (string, string, error) {
 	// Prepare target URL
 	// Replace localhost with host.docker.internal for Docker-in-Docker networking on Windows/Mac
 	// Assuming registryURL is like "http://localhost:5000"
@@ -59,20 +61,19 @@ func ScanImage(registryURL, repo, tag string) (string, string, error) {
 	log.Printf("🔍 Scanning image: %s (via trivy)", imageRef)
 
 	// Command: docker run --rm aquasec/trivy image --format json --insecure --scanners vuln <image>
-	cmd := exec.Command("docker", "run", "--rm",
-		"aquasec/trivy", "image",
+	args := []string{"aquasec/trivy", "image",
 		"--format", "json",
 		"--scanners", "vuln",
 		"--insecure", // Allow insecure registry
 		"--no-progress",
-		imageRef,
-	)
+	}
+	if platform != "" {
+		args = append(args, "--platform", platform)
+	}
+	args = append(args, imageRef)
 
 	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
+	if err := runContainer(ctx, args, &stdout, &stderr); err != nil {
 		return "", "", fmt.Errorf("trivy execution failed: %v, stderr: %s", err, stderr.String())
 	}
 
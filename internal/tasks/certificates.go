@@ -0,0 +1,208 @@
+package tasks
+
+import (
+	"database/sql"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"docker-registry-dashboard/internal/database"
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/registry"
+)
+
+// Certificate check settings, see SetCertificateChecks
+var (
+	certMu            sync.Mutex
+	certCheckInterval = 6 * time.Hour
+	certExpiryDays    = 30
+	alertWebhookURL   string
+	certChanged       = make(chan struct{}, 1) // Restarts the wait for the next scheduled check
+)
+
+// SetCertificateChecks sets how often registries are checked (0 disables the schedule), how many
+// days before expiry a certificate is reported, and the webhook alerts are sent to ("" only logs them).
+// When the interval changes, a running scheduler waits the new interval from now on.
+func SetCertificateChecks(interval time.Duration, expiryDays int, webhookURL string) {
+	certMu.Lock()
+	changed := interval != certCheckInterval
+	certCheckInterval = interval
+	certExpiryDays = expiryDays
+	alertWebhookURL = webhookURL
+	certMu.Unlock()
+	if !changed {
+		return
+	}
+	select {
+	case certChanged <- struct{}{}:
+	default:
+	}
+}
+
+// certificateSettings returns the check interval, expiry days and alert webhook URL
+func certificateSettings() (time.Duration, int, string) {
+	certMu.Lock()
+	defer certMu.Unlock()
+	return certCheckInterval, certExpiryDays, alertWebhookURL
+}
+
+func (s *Scheduler) runCertificateChecks() {
+	if interval, _, _ := certificateSettings(); interval > 0 {
+		s.checkAllCertificates()
+	}
+
+	for {
+		var timer *time.Timer
+		var next <-chan time.Time
+		if interval, _, _ := certificateSettings(); interval > 0 {
+			timer = time.NewTimer(interval)
+			next = timer.C
+		}
+		select {
+		case <-next:
+			s.checkAllCertificates()
+		case <-certChanged:
+			if timer != nil {
+				timer.Stop()
+			}
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+func (s *Scheduler) checkAllCertificates() {
+	registries, err := s.db.ListRegistries()
+	if err != nil {
+		log.Println("Certificate check DB Error:", err)
+		return
+	}
+	for i := range registries {
+		if _, err := CheckCertificate(s.db, &registries[i]); err != nil {
+			log.Printf("❌ Certificate check of registry %d failed: %v", registries[i].ID, err)
+		}
+	}
+}
+
+// CheckCertificate connects to a registry, inspects its TLS certificate chain and stores the result.
+// It alerts when the registry becomes unreachable or reachable again, when a certificate starts
+// expiring within the configured days, and when the chain differs from the one last accepted.
+func CheckCertificate(db *database.DB, reg *models.Registry) (*models.CertificateCheck, error) {
+	prev, err := db.GetCertificateCheck(reg.ID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	now := time.Now()
+	check := &models.CertificateCheck{RegistryID: reg.ID, Chain: []models.CertificateInfo{}, CheckedAt: now}
+	chain, err := registry.InspectCertificates(reg)
+	switch {
+	case err != nil:
+		check.Error = err.Error()
+		if prev != nil {
+			// Keep what was last seen so expiry keeps being tracked while the registry is down
+			check.Chain, check.Fingerprint, check.ExpiresAt = prev.Chain, prev.Fingerprint, prev.ExpiresAt
+			check.ChainChanged, check.PreviousFingerprint = prev.ChainChanged, prev.PreviousFingerprint
+		}
+	case chain != nil:
+		check.Reachable = true
+		check.Chain = chain.Certificates
+		check.Fingerprint = registry.ChainFingerprint(chain.Certificates)
+		if chain.VerifyError != nil {
+			check.VerifyError = chain.VerifyError.Error()
+		}
+		for _, c := range chain.Certificates {
+			if check.ExpiresAt == nil || c.NotAfter.Before(*check.ExpiresAt) {
+				expires := c.NotAfter
+				check.ExpiresAt = &expires
+			}
+		}
+		if prev != nil && prev.Fingerprint != "" {
+			if prev.Fingerprint != check.Fingerprint {
+				check.ChainChanged, check.PreviousFingerprint = true, prev.Fingerprint
+			} else {
+				check.ChainChanged, check.PreviousFingerprint = prev.ChainChanged, prev.PreviousFingerprint
+			}
+		}
+	default:
+		check.Reachable = true
+	}
+	if check.ExpiresAt != nil {
+		check.DaysLeft = int(math.Floor(check.ExpiresAt.Sub(now).Hours() / 24))
+	}
+	check.Status = certificateStatus(check)
+
+	if err := db.SaveCertificateCheck(check); err != nil {
+		return nil, err
+	}
+	alertCertificateChanges(reg, prev, check)
+	return check, nil
+}
+
+// AcceptCertificateChain marks a registry's current certificate chain as expected, clearing a change alert
+func AcceptCertificateChain(db *database.DB, registryID int64) (*models.CertificateCheck, error) {
+	check, err := db.GetCertificateCheck(registryID)
+	if err != nil {
+		return nil, err
+	}
+	check.ChainChanged, check.PreviousFingerprint = false, ""
+	check.Status = certificateStatus(check)
+	if err := db.SaveCertificateCheck(check); err != nil {
+		return nil, err
+	}
+	return check, nil
+}
+
+// certificateStatus summarizes a check by its most severe finding
+func certificateStatus(c *models.CertificateCheck) string {
+	_, expiryDays, _ := certificateSettings()
+	switch {
+	case !c.Reachable:
+		return models.CertUnreachable
+	case c.ChainChanged:
+		return models.CertChanged
+	case c.ExpiresAt != nil && c.DaysLeft < expiryDays:
+		return models.CertExpiring
+	case c.VerifyError != "":
+		return models.CertInvalid
+	case c.Fingerprint == "":
+		return models.CertPlainHTTP
+	}
+	return models.CertOK
+}
+
+// alertCertificateChanges sends an alert for every finding that is new since the previous check
+func alertCertificateChanges(reg *models.Registry, prev, check *models.CertificateCheck) {
+	_, expiryDays, webhookURL := certificateSettings()
+	var events []string
+	switch {
+	case !check.Reachable && (prev == nil || prev.Reachable):
+		events = append(events, "registry.unreachable")
+	case check.Reachable && prev != nil && !prev.Reachable:
+		events = append(events, "registry.reachable")
+	}
+	expiring := check.Reachable && check.ExpiresAt != nil && check.DaysLeft < expiryDays
+	if expiring && (prev == nil || prev.DaysLeft >= expiryDays || prev.ExpiresAt == nil) {
+		events = append(events, "certificate.expiring")
+	}
+	if check.ChainChanged && (prev == nil || !prev.ChainChanged || prev.Fingerprint != check.Fingerprint) {
+		events = append(events, "certificate.changed")
+	}
+
+	for _, event := range events {
+		log.Printf("🔐 Registry %s: %s (status %s, %d days left)", reg.Name, event, check.Status, check.DaysLeft)
+		if webhookURL == "" {
+			continue
+		}
+		err := postAlert(webhookURL, map[string]interface{}{
+			"event":         event,
+			"registry_id":   reg.ID,
+			"registry_name": reg.Name,
+			"check":         check,
+		})
+		if err != nil {
+			log.Printf("⚠️ Failed to send %s alert for registry %d: %v", event, reg.ID, err)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package tasks
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sync/atomic"
+	"time"
+
+	"docker-registry-dashboard/internal/database"
+	"docker-registry-dashboard/internal/defectdojo"
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/scanner"
+)
+
+// defectDojoCheckInterval is how often scheduled DefectDojo mappings are checked for being due
+const defectDojoCheckInterval = 5 * time.Minute
+
+// defectDojo is the DefectDojo instance findings are exported to; nil disables exports
+var defectDojo atomic.Pointer[defectdojo.Client]
+
+// SetDefectDojoClient enables exports to a DefectDojo instance; nil disables them. It may be
+// called while exports run, which finish with the client they started with.
+func SetDefectDojoClient(c *defectdojo.Client) {
+	defectDojo.Store(c)
+}
+
+// DefectDojoEnabled reports whether a DefectDojo instance is configured
+func DefectDojoEnabled() bool {
+	return defectDojo.Load() != nil
+}
+
+func (s *Scheduler) runDefectDojo() {
+	ticker := time.NewTicker(defectDojoCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.exportDueMappings()
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// exportDueMappings runs the scheduled mappings whose interval has passed
+func (s *Scheduler) exportDueMappings() {
+	if !DefectDojoEnabled() {
+		return
+	}
+	mappings, err := s.db.ListDefectDojoMappings()
+	if err != nil {
+		log.Println("DefectDojo DB Error:", err)
+		return
+	}
+	now := time.Now()
+	for i := range mappings {
+		m := &mappings[i]
+		if !m.Enabled || m.Trigger != "schedule" {
+			continue
+		}
+		interval := m.IntervalHours
+		if interval < 1 {
+			interval = 24
+		}
+		if !m.LastExportAt.IsZero() && now.Before(m.LastExportAt.Add(time.Duration(interval)*time.Hour)) {
+			continue
+		}
+		if n, err := ExportDefectDojoMapping(s.db, m); err != nil {
+			log.Printf("❌ DefectDojo: mapping %d failed: %v", m.ID, err)
+		} else {
+			log.Printf("🛡️ DefectDojo: mapping %d exported %d images to engagement %d", m.ID, n, m.EngagementID)
+		}
+	}
+}
+
+// ExportScanToDefectDojo exports a just-completed scan through every enabled on-scan mapping matching it
+func ExportScanToDefectDojo(db *database.DB, scan *models.VulnerabilityScan) {
+	if !DefectDojoEnabled() || scan.Status != "completed" {
+		return
+	}
+	mappings, err := db.ListDefectDojoMappings()
+	if err != nil {
+		log.Println("DefectDojo DB Error:", err)
+		return
+	}
+	for i := range mappings {
+		m := &mappings[i]
+		if !m.Enabled || m.Trigger != "scan" || m.RegistryID != scan.RegistryID {
+			continue
+		}
+		repoRe, err := compileRepoPattern(m.RepoPattern)
+		if err != nil || (repoRe != nil && !repoRe.MatchString(scan.Repository)) {
+			continue
+		}
+
+		_, err = exportScan(db, m, scan)
+		errMsg := ""
+		if err != nil {
+			errMsg = err.Error()
+			log.Printf("❌ DefectDojo: export of %s:%s failed: %v", scan.Repository, scan.Tag, err)
+		}
+		db.SetDefectDojoExportResult(m.ID, time.Now(), errMsg)
+	}
+}
+
+// ExportDefectDojoMapping exports every completed scan of a mapping that changed since its last export
+// and returns how many images were uploaded
+func ExportDefectDojoMapping(db *database.DB, m *models.DefectDojoMapping) (int, error) {
+	if !DefectDojoEnabled() {
+		return 0, fmt.Errorf("DefectDojo is not configured")
+	}
+	repoRe, err := compileRepoPattern(m.RepoPattern)
+	if err != nil {
+		return 0, err
+	}
+	scans, err := db.ListScans(m.RegistryID)
+	if err != nil {
+		return 0, err
+	}
+
+	exported := 0
+	var lastErr error
+	for i := range scans {
+		scan := &scans[i]
+		if scan.Status != "completed" || (repoRe != nil && !repoRe.MatchString(scan.Repository)) {
+			continue
+		}
+		uploaded, err := exportScan(db, m, scan)
+		if err != nil {
+			lastErr = fmt.Errorf("%s:%s: %w", scan.Repository, scan.Tag, err)
+			continue
+		}
+		if uploaded {
+			exported++
+		}
+	}
+
+	errMsg := ""
+	if lastErr != nil {
+		errMsg = lastErr.Error()
+	}
+	db.SetDefectDojoExportResult(m.ID, time.Now(), errMsg)
+	return exported, lastErr
+}
+
+// exportScan uploads one image's findings unless that scan was already exported through the mapping
+func exportScan(db *database.DB, m *models.DefectDojoMapping, scan *models.VulnerabilityScan) (bool, error) {
+	testID, exportedScan, err := db.GetDefectDojoTest(m.ID, scan.RegistryID, scan.Repository, scan.Tag)
+	if err == nil && exportedScan.Equal(scan.ScannedAt) {
+		return false, nil
+	}
+
+	client := defectDojo.Load()
+	if client == nil {
+		return false, fmt.Errorf("DefectDojo is not configured")
+	}
+	image := scan.Repository + ":" + scan.Tag
+	testID, err = client.ImportFindings(m.EngagementID, testID, image, scanner.ParseFindings(scan.Report))
+	if err != nil {
+		return false, err
+	}
+	return true, db.SaveDefectDojoTest(m.ID, scan.RegistryID, scan.Repository, scan.Tag, testID, scan.ScannedAt)
+}
+
+func compileRepoPattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile(pattern)
+}
@@ -0,0 +1,66 @@
+package tasks
+
+import (
+	"log"
+	"regexp"
+
+	"docker-registry-dashboard/internal/database"
+	"docker-registry-dashboard/internal/models"
+)
+
+// onboardRepositories applies the first matching onboarding rule to each newly discovered repository
+// and returns how many were set up
+func onboardRepositories(db *database.DB, registryID int64, discovered []string) int {
+	if len(discovered) == 0 {
+		return 0
+	}
+
+	rules, err := db.ListOnboardingRules()
+	if err != nil {
+		log.Printf("⚠️ Sync: failed to load onboarding rules: %v", err)
+		return 0
+	}
+
+	type compiledRule struct {
+		rule models.OnboardingRule
+		re   *regexp.Regexp
+	}
+	var active []compiledRule
+	for _, rule := range rules {
+		if !rule.Enabled || (rule.RegistryID != 0 && rule.RegistryID != registryID) {
+			continue
+		}
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			log.Printf("⚠️ Sync: invalid pattern in onboarding rule %d: %v", rule.ID, err)
+			continue
+		}
+		active = append(active, compiledRule{rule, re})
+	}
+
+	onboarded := 0
+	for _, name := range discovered {
+		for _, c := range active {
+			if !c.re.MatchString(name) {
+				continue
+			}
+			info := &models.RepositoryInfo{
+				RegistryID:          registryID,
+				Name:                name,
+				Owner:               c.rule.Owner,
+				Labels:              c.rule.Labels,
+				ScanMode:            c.rule.ScanMode,
+				RetentionTemplateID: c.rule.RetentionTemplateID,
+				OnboardingRuleID:    c.rule.ID,
+			}
+			if err := db.SaveRepositoryInfo(info); err != nil {
+				log.Printf("⚠️ Sync: failed to onboard %s: %v", name, err)
+				break
+			}
+			log.Printf("🏷️ Onboarded %s with rule %q", name, c.rule.Name)
+			onboarded++
+			break
+		}
+	}
+	return onboarded
+}
@@ -0,0 +1,99 @@
+package tasks
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"docker-registry-dashboard/internal/database"
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/registry"
+)
+
+// checkTagPins compares every pinned tag of a registry with the digest it points at now.
+// listed holds the tags of each repository whose tag list could be read; pins of repositories
+// whose tags could not be listed are skipped. A drift is recorded and alerted once per observed digest.
+func checkTagPins(ctx context.Context, db *database.DB, client *registry.Client, policy *models.ImagePolicy, repos []models.Repository, listed map[string][]models.Tag, now time.Time) (int, error) {
+	pins, err := db.ListTagPins(policy.RegistryID)
+	if err != nil || len(pins) == 0 {
+		return 0, err
+	}
+
+	inCatalog := make(map[string]bool, len(repos))
+	for _, repo := range repos {
+		inCatalog[repo.Name] = true
+	}
+
+	fresh := 0
+	var fallback []models.TagDrift // Drifts of pins without their own webhook
+	for i := range pins {
+		pin := &pins[i]
+		tags, ok := listed[pin.Repository]
+		if !ok && inCatalog[pin.Repository] {
+			continue // Tag list unavailable this round
+		}
+
+		observed := ""
+		if hasTag(tags, pin.Tag) {
+			digest, err := client.GetDigestForTag(ctx, pin.Repository, pin.Tag)
+			if err != nil {
+				log.Printf("⚠️ Sync: failed to check pinned tag %s:%s: %v", pin.Repository, pin.Tag, err)
+				continue
+			}
+			observed = digest
+		}
+
+		drifted := observed != pin.Digest
+		if drifted && !(pin.Drifted && pin.ObservedDigest == observed) {
+			drift := models.TagDrift{
+				PinID:          pin.ID,
+				RegistryID:     pin.RegistryID,
+				Repository:     pin.Repository,
+				Tag:            pin.Tag,
+				PinnedDigest:   pin.Digest,
+				ObservedDigest: observed,
+				DetectedAt:     now,
+			}
+			if err := db.AddTagDrift(&drift); err != nil {
+				return 0, err
+			}
+			log.Printf("🚨 Pinned tag %s:%s drifted from %s to %q", pin.Repository, pin.Tag, pin.Digest, observed)
+			fresh++
+			if pin.AlertWebhookURL == "" {
+				fallback = append(fallback, drift)
+			} else if err := postAlert(pin.AlertWebhookURL, driftPayload(pin.RegistryID, []models.TagDrift{drift})); err != nil {
+				log.Printf("⚠️ Sync: failed to send drift alert for %s:%s: %v", pin.Repository, pin.Tag, err)
+			}
+		}
+		if !drifted {
+			observed = ""
+		}
+		if err := db.SetTagPinCheck(pin.ID, now, drifted, observed); err != nil {
+			return 0, err
+		}
+	}
+
+	if policy.AlertWebhookURL != "" && len(fallback) > 0 {
+		if err := postAlert(policy.AlertWebhookURL, driftPayload(policy.RegistryID, fallback)); err != nil {
+			log.Printf("⚠️ Sync: failed to send drift alert for registry %d: %v", policy.RegistryID, err)
+		}
+	}
+	return fresh, nil
+}
+
+func driftPayload(registryID int64, drifts []models.TagDrift) map[string]interface{} {
+	return map[string]interface{}{
+		"event":       "tag.drift",
+		"registry_id": registryID,
+		"drifts":      drifts,
+	}
+}
+
+func hasTag(tags []models.Tag, name string) bool {
+	for _, t := range tags {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package tasks
+
+import (
+	"log"
+	"time"
+)
+
+// scanPruneInterval is how often the scan history retention settings are applied
+const scanPruneInterval = time.Hour
+
+func (s *Scheduler) runScanPruning() {
+	s.pruneScanHistory()
+
+	ticker := time.NewTicker(scanPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.pruneScanHistory()
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// pruneScanHistory deletes the history scans and reports the retention settings no longer keep
+func (s *Scheduler) pruneScanHistory() {
+	if r := s.db.ScanRetention(); r.KeepScans <= 0 && r.ReportMaxAgeDays <= 0 {
+		return
+	}
+	result, err := s.db.PruneScanHistory()
+	if err != nil {
+		log.Printf("❌ Scan history pruning failed: %v", err)
+		return
+	}
+	if result.DeletedScans > 0 || result.EvictedReports > 0 {
+		log.Printf("🧹 Pruned the scan history: %d old scans deleted, %d old reports removed, %d bytes freed",
+			result.DeletedScans, result.EvictedReports, result.FreedBytes)
+	}
+}
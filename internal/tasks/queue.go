@@ -0,0 +1,123 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrQueueFull is returned when a job cannot be queued in time
+var ErrQueueFull = errors.New("job queue full")
+
+// JobQueue distributes scan jobs to workers
+type JobQueue interface {
+	// Push queues a job, giving up after timeout
+	Push(job ScanJob, timeout time.Duration) error
+	// Pop blocks until a job is available; ok is false once the queue is closed
+	Pop() (job ScanJob, ok bool)
+	// Close stops handing out jobs
+	Close()
+}
+
+// MemoryQueue is the default in-process queue backed by a buffered channel
+type MemoryQueue struct {
+	jobs chan ScanJob
+	once sync.Once
+}
+
+// NewMemoryQueue creates an in-process queue holding up to size jobs
+func NewMemoryQueue(size int) *MemoryQueue {
+	return &MemoryQueue{jobs: make(chan ScanJob, size)}
+}
+
+func (q *MemoryQueue) Push(job ScanJob, timeout time.Duration) error {
+	select {
+	case q.jobs <- job:
+		return nil
+	case <-time.After(timeout):
+		return ErrQueueFull
+	}
+}
+
+func (q *MemoryQueue) Pop() (ScanJob, bool) {
+	job, ok := <-q.jobs
+	return job, ok
+}
+
+func (q *MemoryQueue) Close() {
+	q.once.Do(func() { close(q.jobs) })
+}
+
+// RedisQueue shares jobs between dashboard replicas through a Redis list
+type RedisQueue struct {
+	client *redis.Client
+	key    string
+	quit   chan struct{}
+	once   sync.Once
+}
+
+// NewRedisQueue creates a queue stored in the Redis list key
+func NewRedisQueue(client *redis.Client, key string) *RedisQueue {
+	return &RedisQueue{client: client, key: key, quit: make(chan struct{})}
+}
+
+func (q *RedisQueue) Push(job ScanJob, timeout time.Duration) error {
+	b, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := q.client.LPush(ctx, q.key, b).Err(); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return ErrQueueFull
+		}
+		return err
+	}
+	return nil
+}
+
+func (q *RedisQueue) Pop() (ScanJob, bool) {
+	for {
+		select {
+		case <-q.quit:
+			return ScanJob{}, false
+		default:
+		}
+
+		// Short blocking pops so Close is noticed promptly
+		reply, err := q.client.BRPop(context.Background(), time.Second, q.key).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			log.Printf("⚠️  Redis queue pop failed: %v", err)
+			select {
+			case <-q.quit:
+				return ScanJob{}, false
+			case <-time.After(2 * time.Second):
+			}
+			continue
+		}
+
+		// BRPOP replies with the list key followed by the value
+		if len(reply) != 2 {
+			continue
+		}
+		var job ScanJob
+		if err := json.Unmarshal([]byte(reply[1]), &job); err != nil {
+			log.Printf("⚠️  Dropping malformed queued job: %v", err)
+			continue
+		}
+		return job, true
+	}
+}
+
+func (q *RedisQueue) Close() {
+	q.once.Do(func() { close(q.quit) })
+}
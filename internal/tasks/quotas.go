@@ -0,0 +1,151 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"docker-registry-dashboard/internal/database"
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/registry"
+)
+
+// quotaCheckInterval is how often the usage of every repository quota is measured
+const quotaCheckInterval = time.Hour
+
+// quotaCheckTimeout bounds the measurement of one quota
+const quotaCheckTimeout = 10 * time.Minute
+
+// Quota states, from best to worst
+const (
+	QuotaOK       = "ok"
+	QuotaWarning  = "warning"
+	QuotaExceeded = "exceeded"
+)
+
+var quotaSeverity = map[string]int{"": 0, QuotaOK: 0, QuotaWarning: 1, QuotaExceeded: 2}
+
+func (s *Scheduler) runQuotaChecks() {
+	ticker := time.NewTicker(quotaCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.checkAllQuotas()
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+func (s *Scheduler) checkAllQuotas() {
+	quotas, err := s.db.ListRepositoryQuotas()
+	if err != nil {
+		log.Println("Quota check DB Error:", err)
+		return
+	}
+	for i := range quotas {
+		ctx, cancel := context.WithTimeout(WorkContext(), quotaCheckTimeout)
+		err := CheckQuota(ctx, s.db, &quotas[i])
+		cancel()
+		if err != nil {
+			log.Printf("❌ Quota %d check failed: %v", quotas[i].ID, err)
+		}
+	}
+}
+
+// CheckQuota measures the storage of the repositories a quota covers from their manifests and
+// stores it with the quota's state. Entering the warning or exceeded state is alerted to the
+// quota's webhook, or else to the alert webhook. A failed measurement keeps the last usage.
+func CheckQuota(ctx context.Context, db *database.DB, q *models.RepositoryQuota) error {
+	previous := q.State
+	now := time.Now()
+	used, err := quotaUsage(ctx, db, q)
+	q.CheckedAt = &now
+	if err != nil {
+		q.CheckError = err.Error()
+		db.SetRepositoryQuotaUsage(q.ID, q.UsedBytes, q.State, q.CheckError, now)
+		return err
+	}
+
+	q.UsedBytes, q.CheckError = used, ""
+	warnPercent := q.WarnPercent
+	if warnPercent <= 0 {
+		warnPercent = 80
+	}
+	switch {
+	case used > q.LimitBytes:
+		q.State = QuotaExceeded
+	case used*100 >= q.LimitBytes*int64(warnPercent):
+		q.State = QuotaWarning
+	default:
+		q.State = QuotaOK
+	}
+	if err := db.SetRepositoryQuotaUsage(q.ID, q.UsedBytes, q.State, "", now); err != nil {
+		return err
+	}
+
+	if quotaSeverity[q.State] > quotaSeverity[previous] {
+		log.Printf("📦 Quota %d is %s: %d of %d bytes used", q.ID, q.State, q.UsedBytes, q.LimitBytes)
+		url := q.AlertWebhookURL
+		if url == "" {
+			_, _, url = certificateSettings()
+		}
+		if url != "" {
+			payload := map[string]interface{}{"event": "quota." + q.State, "quota": q}
+			if err := postAlert(url, payload); err != nil {
+				log.Printf("⚠️ Failed to send quota alert for quota %d: %v", q.ID, err)
+			}
+		}
+	}
+	return nil
+}
+
+// quotaUsage sums the storage of the repositories a quota covers, registry by registry
+func quotaUsage(ctx context.Context, db *database.DB, q *models.RepositoryQuota) (int64, error) {
+	patterns := map[int64][]string{}
+	if q.ProjectID != 0 {
+		p, err := db.GetProject(q.ProjectID)
+		if err != nil {
+			return 0, fmt.Errorf("project %d not found", q.ProjectID)
+		}
+		for _, id := range p.Registries {
+			patterns[id] = append(patterns[id], "*")
+		}
+		for _, pr := range p.Repositories {
+			patterns[pr.RegistryID] = append(patterns[pr.RegistryID], pr.Repository)
+		}
+	} else {
+		patterns[q.RegistryID] = []string{q.Repository}
+	}
+
+	var total int64
+	for registryID, covered := range patterns {
+		reg, err := db.GetRegistry(registryID)
+		if err != nil {
+			return 0, fmt.Errorf("registry %d not found", registryID)
+		}
+		client := registry.NewClientFromRegistry(reg)
+		repos, err := client.ListRepositories(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to list repositories of %s: %w", reg.Name, err)
+		}
+		var names []string
+		for _, repo := range repos {
+			for _, pattern := range covered {
+				if models.MatchRepository(pattern, repo.Name) {
+					names = append(names, repo.Name)
+					break
+				}
+			}
+		}
+		size, err := registry.RepositoriesSize(ctx, client, names)
+		if err != nil {
+			return 0, err
+		}
+		total += size
+	}
+	return total, nil
+}
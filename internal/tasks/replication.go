@@ -0,0 +1,387 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"docker-registry-dashboard/internal/database"
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/registry"
+)
+
+// Conflict strategies of replication rules: what happens to a target tag at another digest
+const (
+	ConflictOverwrite = "overwrite" // The source image replaces it
+	ConflictSkip      = "skip"      // It is left alone and counted as a conflict
+)
+
+// replicationCheckInterval is how often replication rules are checked for being due
+const replicationCheckInterval = time.Minute
+
+// replicationTimeout bounds one scheduled run of a replication rule
+const replicationTimeout = 6 * time.Hour
+
+// replicationMaxErrors bounds the failures kept in the progress of a run
+const replicationMaxErrors = 20
+
+var (
+	replicationMu   sync.Mutex
+	replicationRuns = map[int64]*models.ReplicationRun{} // Latest run of each rule since the start
+)
+
+func (s *Scheduler) runReplication() {
+	ticker := time.NewTicker(replicationCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.replicateDueRules()
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// replicateDueRules runs the enabled, scheduled replication rules whose interval has passed,
+// unless maintenance mode is on
+func (s *Scheduler) replicateDueRules() {
+	if s.db.InMaintenance() {
+		return // Due rules run once maintenance mode is off
+	}
+	rules, err := s.db.ListReplicationRules()
+	if err != nil {
+		log.Println("Replication DB Error:", err)
+		return
+	}
+	now := time.Now()
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.Enabled || rule.IntervalMinutes <= 0 {
+			continue
+		}
+		if !rule.LastRunAt.IsZero() && now.Before(rule.LastRunAt.Add(time.Duration(rule.IntervalMinutes)*time.Minute)) {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(WorkContext(), replicationTimeout)
+		_, err := RunReplication(ctx, s.db, rule, "schedule")
+		cancel()
+		if err != nil {
+			log.Printf("❌ Replication: %s failed: %v", rule.Name, err)
+		}
+	}
+}
+
+// ReplicationStatus returns the progress of the latest run of a rule since the dashboard started
+func ReplicationStatus(ruleID int64) (models.ReplicationRun, bool) {
+	replicationMu.Lock()
+	defer replicationMu.Unlock()
+	run, ok := replicationRuns[ruleID]
+	if !ok {
+		return models.ReplicationRun{}, false
+	}
+	return snapshotRun(run), true
+}
+
+func snapshotRun(run *models.ReplicationRun) models.ReplicationRun {
+	snapshot := *run
+	snapshot.Errors = append([]string(nil), run.Errors...)
+	return snapshot
+}
+
+// beginReplication registers a new run of a rule, unless one is still running
+func beginReplication(ruleID int64, trigger string) (*models.ReplicationRun, error) {
+	replicationMu.Lock()
+	defer replicationMu.Unlock()
+	if run, ok := replicationRuns[ruleID]; ok && run.Status == "running" {
+		return nil, fmt.Errorf("replication rule %d is already running", ruleID)
+	}
+	run := &models.ReplicationRun{RuleID: ruleID, Trigger: trigger, Status: "running", StartedAt: time.Now()}
+	replicationRuns[ruleID] = run
+	return run, nil
+}
+
+func updateRun(run *models.ReplicationRun, apply func(run *models.ReplicationRun)) {
+	replicationMu.Lock()
+	defer replicationMu.Unlock()
+	apply(run)
+}
+
+// StartReplication starts a run of a rule in the background and returns its initial progress;
+// done (optional) receives the finished run
+func StartReplication(db *database.DB, rule *models.ReplicationRule, trigger string, done func(models.ReplicationRun)) (models.ReplicationRun, error) {
+	run, err := beginReplication(rule.ID, trigger)
+	if err != nil {
+		return models.ReplicationRun{}, err
+	}
+	snapshot := snapshotRun(run)
+	go func() {
+		defer BeginWork()()
+		ctx, cancel := context.WithTimeout(WorkContext(), replicationTimeout)
+		defer cancel()
+		finished, _ := finishReplication(ctx, db, rule, run)
+		if done != nil {
+			done(finished)
+		}
+	}()
+	return snapshot, nil
+}
+
+// RunReplication runs a rule and returns the finished run. The error is set when the run failed
+// as a whole; tags that failed to copy are counted in the run.
+func RunReplication(ctx context.Context, db *database.DB, rule *models.ReplicationRule, trigger string) (models.ReplicationRun, error) {
+	run, err := beginReplication(rule.ID, trigger)
+	if err != nil {
+		return models.ReplicationRun{}, err
+	}
+	return finishReplication(ctx, db, rule, run)
+}
+
+func finishReplication(ctx context.Context, db *database.DB, rule *models.ReplicationRule, run *models.ReplicationRun) (models.ReplicationRun, error) {
+	err := replicate(ctx, db, rule, run)
+	errMsg := ""
+	updateRun(run, func(run *models.ReplicationRun) {
+		run.Current = ""
+		run.FinishedAt = time.Now()
+		switch {
+		case err != nil:
+			run.Status = "failed"
+			errMsg = err.Error()
+			if len(run.Errors) < replicationMaxErrors {
+				run.Errors = append(run.Errors, errMsg)
+			}
+		case run.Failed > 0:
+			run.Status = "partial"
+			errMsg = fmt.Sprintf("%d of %d tags failed", run.Failed, run.Tags)
+		default:
+			run.Status = "completed"
+		}
+	})
+	finished, _ := ReplicationStatus(rule.ID)
+	if dbErr := db.AddReplicationRun(&finished); dbErr != nil {
+		log.Printf("⚠️ Replication: failed to record run of %s: %v", rule.Name, dbErr)
+	}
+	updateRun(run, func(run *models.ReplicationRun) { run.ID = finished.ID })
+	if dbErr := db.SetReplicationResult(rule.ID, finished.FinishedAt, finished.Status, errMsg); dbErr != nil {
+		log.Printf("⚠️ Replication: failed to record result of %s: %v", rule.Name, dbErr)
+	}
+	if err == nil {
+		log.Printf("🔁 Replicated %s: %d copied, %d up to date, %d conflicts, %d deleted, %d failed",
+			rule.Name, finished.Copied, finished.UpToDate, finished.Conflicts, finished.Deleted, finished.Failed)
+	}
+	return finished, err
+}
+
+// replicate copies every matching tag of the source whose digest differs from the target's
+func replicate(ctx context.Context, db *database.DB, rule *models.ReplicationRule, run *models.ReplicationRun) error {
+	srcReg, err := db.GetRegistry(rule.SourceRegistryID)
+	if err != nil {
+		return fmt.Errorf("source registry %d not found", rule.SourceRegistryID)
+	}
+	dstReg, err := db.GetRegistry(rule.TargetRegistryID)
+	if err != nil {
+		return fmt.Errorf("target registry %d not found", rule.TargetRegistryID)
+	}
+	repoRe, tagRe, err := CompileReplicationFilters(rule)
+	if err != nil {
+		return err
+	}
+	// Immutable tags of the target are neither overwritten nor deleted
+	immutableRules, err := db.ListImmutableTagRules(dstReg.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load the target's immutable tag rules: %w", err)
+	}
+	immutable := models.ImmutableTags(immutableRules)
+	// A target repository over a blocking quota takes no more copies
+	overQuota := func(repo string) bool {
+		q, err := db.BlockingQuota(dstReg.ID, repo)
+		return err == nil && q != nil
+	}
+
+	src := registry.NewClientFromRegistry(srcReg)
+	dst := registry.NewClientFromRegistry(dstReg)
+	repos, err := src.ListRepositories(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list source repositories: %w", err)
+	}
+
+	fail := func(ref string, err error) {
+		updateRun(run, func(run *models.ReplicationRun) {
+			run.Failed++
+			if len(run.Errors) < replicationMaxErrors {
+				run.Errors = append(run.Errors, fmt.Sprintf("%s: %v", ref, err))
+			}
+		})
+	}
+	sourceRepos := make(map[string]bool)
+	for _, repo := range repos {
+		if repoRe != nil && !repoRe.MatchString(repo.Name) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		sourceRepos[repo.Name] = true
+		updateRun(run, func(run *models.ReplicationRun) { run.Repositories++ })
+		tags, err := src.ListTags(ctx, repo.Name)
+		if err != nil {
+			fail(repo.Name, err)
+			continue
+		}
+		targetRepo := rule.TargetPrefix + repo.Name
+		sourceTags := make(map[string]bool, len(tags))
+		for _, tag := range tags {
+			sourceTags[tag.Name] = true
+			if tagRe != nil && !tagRe.MatchString(tag.Name) {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			ref := repo.Name + ":" + tag.Name
+			updateRun(run, func(run *models.ReplicationRun) { run.Tags++ })
+			if err := replicateTag(ctx, src, dst, rule, run, repo.Name, tag.Name, targetRepo, immutable, overQuota); err != nil {
+				fail(ref, err)
+			}
+		}
+		if rule.PropagateDeletes {
+			if err := propagateDeletes(ctx, dst, run, targetRepo, sourceTags, tagRe, immutable); err != nil {
+				fail(targetRepo, err)
+			}
+		}
+	}
+
+	if !rule.PropagateDeletes {
+		return nil
+	}
+	// Repositories gone from the source lose their matching tags in the target too
+	targetRepos, err := dst.ListRepositories(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list target repositories: %w", err)
+	}
+	for _, repo := range targetRepos {
+		name, ok := strings.CutPrefix(repo.Name, rule.TargetPrefix)
+		if !ok || sourceRepos[name] || (repoRe != nil && !repoRe.MatchString(name)) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := propagateDeletes(ctx, dst, run, repo.Name, nil, tagRe, immutable); err != nil {
+			fail(repo.Name, err)
+		}
+	}
+	return nil
+}
+
+// propagateDeletes deletes the target images of matching tags the source no longer has. Registries
+// delete images, not tags, so an image another remaining tag points at is kept, and so is the
+// image of an immutable tag.
+func propagateDeletes(ctx context.Context, dst *registry.Client, run *models.ReplicationRun, targetRepo string, sourceTags map[string]bool, tagRe *regexp.Regexp, immutable func(repo, tag string) bool) error {
+	tags, err := dst.ListTags(ctx, targetRepo)
+	if err != nil {
+		// A repository never replicated has nothing to delete
+		return nil
+	}
+	var removed, kept []models.Tag
+	for _, tag := range tags {
+		if !sourceTags[tag.Name] && (tagRe == nil || tagRe.MatchString(tag.Name)) && (immutable == nil || !immutable(targetRepo, tag.Name)) {
+			removed = append(removed, tag)
+		} else {
+			kept = append(kept, tag)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	keptDigests := make(map[string]bool, len(kept))
+	for _, tag := range kept {
+		digest, err := dst.GetDigestForTag(ctx, targetRepo, tag.Name)
+		if err != nil {
+			return err
+		}
+		keptDigests[digest] = true
+	}
+	deleted := make(map[string]bool)
+	for _, tag := range removed {
+		digest, err := dst.GetDigestForTag(ctx, targetRepo, tag.Name)
+		if err != nil {
+			return err
+		}
+		if keptDigests[digest] || deleted[digest] {
+			continue
+		}
+		if err := dst.DeleteManifest(ctx, targetRepo, digest); err != nil {
+			return fmt.Errorf("failed to delete %s:%s: %w", targetRepo, tag.Name, err)
+		}
+		deleted[digest] = true
+		updateRun(run, func(run *models.ReplicationRun) { run.Deleted++ })
+	}
+	return nil
+}
+
+// replicateTag copies one tag unless the target already has its digest, or has another one and
+// the rule skips conflicts or the target tag is immutable. A target repository overQuota fails the copy.
+func replicateTag(ctx context.Context, src, dst *registry.Client, rule *models.ReplicationRule, run *models.ReplicationRun, repo, tag, targetRepo string, immutable func(repo, tag string) bool, overQuota func(repo string) bool) error {
+	digest, err := src.GetDigestForTag(ctx, repo, tag)
+	if err != nil {
+		return err
+	}
+	if current, err := dst.GetDigestForTag(ctx, targetRepo, tag); err == nil {
+		if current == digest {
+			updateRun(run, func(run *models.ReplicationRun) { run.UpToDate++ })
+			return nil
+		}
+		if rule.Conflict == ConflictSkip || (immutable != nil && immutable(targetRepo, tag)) {
+			updateRun(run, func(run *models.ReplicationRun) { run.Conflicts++ })
+			return nil
+		}
+	}
+	if overQuota(targetRepo) {
+		return fmt.Errorf("%s is over its storage quota", targetRepo)
+	}
+
+	var baseBlobs, baseSkipped int
+	var baseBytes int64
+	updateRun(run, func(run *models.ReplicationRun) {
+		run.Current = repo + ":" + tag
+		baseBlobs, baseSkipped, baseBytes = run.CopiedBlobs, run.SkippedBlobs, run.CopiedBytes
+	})
+	// The digest compared is the one copied, even if the tag moves meanwhile. Blobs already in the
+	// target are skipped, so only the layers that changed are transferred.
+	_, err = registry.CopyImage(ctx, src, repo, digest, dst, targetRepo, tag, func(stats registry.CopyStats) {
+		updateRun(run, func(run *models.ReplicationRun) {
+			run.CopiedBlobs = baseBlobs + stats.CopiedBlobs
+			run.SkippedBlobs = baseSkipped + stats.SkippedBlobs
+			run.CopiedBytes = baseBytes + stats.CopiedBytes
+		})
+	})
+	if err != nil {
+		return err
+	}
+	updateRun(run, func(run *models.ReplicationRun) { run.Copied++ })
+	return nil
+}
+
+// CompileReplicationFilters compiles the repository and tag filters of a rule; an empty filter is nil
+func CompileReplicationFilters(rule *models.ReplicationRule) (*regexp.Regexp, *regexp.Regexp, error) {
+	var repoRe, tagRe *regexp.Regexp
+	var err error
+	if rule.FilterRepos != "" {
+		if repoRe, err = regexp.Compile(rule.FilterRepos); err != nil {
+			return nil, nil, fmt.Errorf("invalid repository filter: %w", err)
+		}
+	}
+	if rule.FilterTags != "" {
+		if tagRe, err = regexp.Compile(rule.FilterTags); err != nil {
+			return nil, nil, fmt.Errorf("invalid tag filter: %w", err)
+		}
+	}
+	return repoRe, tagRe, nil
+}
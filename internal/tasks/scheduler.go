@@ -1,10 +1,13 @@
 package tasks
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"regexp"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"docker-registry-dashboard/internal/database"
@@ -18,24 +21,42 @@ type ScanJob struct {
 	RegistryID  int64
 	Repo        string
 	Tag         string
+	Scanner     string // "trivy" (default) or "osv"
+	Platform    string // e.g. "linux/arm64" for multi-arch images (optional)
 }
 
 type Scheduler struct {
-	db      *database.DB
-	jobChan chan ScanJob
-	quit    chan struct{}
-	wg      sync.WaitGroup
+	db    *database.DB
+	queue JobQueue
+	quit  chan struct{}
+	wg    sync.WaitGroup
+
+	// lastTick is the Unix time the schedule ticker last ran (or the scheduler started); 0 when stopped
+	lastTick atomic.Int64
 }
 
-func NewScheduler(db *database.DB) *Scheduler {
+// schedulerStallAfter is how long the schedule ticker may go without running before the
+// scheduler is reported as stalled
+const schedulerStallAfter = 5 * time.Minute
+
+// policyListTimeout bounds the registry walk that queues the scans of a policy
+const policyListTimeout = 10 * time.Minute
+
+// NewScheduler creates a scheduler; a nil queue uses the in-process queue
+func NewScheduler(db *database.DB, queue JobQueue) *Scheduler {
+	if queue == nil {
+		queue = NewMemoryQueue(100) // Buffer 100 jobs
+	}
 	return &Scheduler{
-		db:      db,
-		jobChan: make(chan ScanJob, 100), // Buffer 100 jobs
-		quit:    make(chan struct{}),
+		db:    db,
+		queue: queue,
+		quit:  make(chan struct{}),
 	}
 }
 
 func (s *Scheduler) Start() {
+	s.lastTick.Store(time.Now().Unix())
+
 	// Start 2 Workers
 	for i := 0; i < 2; i++ {
 		s.wg.Add(1)
@@ -44,11 +65,38 @@ func (s *Scheduler) Start() {
 
 	// Start Ticker
 	go s.runTicker()
+
+	// Periodic catalog sync (image policy evaluation)
+	go s.runSync()
+
+	// Scheduled DefectDojo exports
+	go s.runDefectDojo()
+
+	// Public images mirrored into local registries
+	go s.runSeeds()
+
+	// Scheduled replication between registries
+	go s.runReplication()
+
+	// Connectivity and TLS certificate checks
+	go s.runCertificateChecks()
+
+	// Scan history retention
+	go s.runScanPruning()
+
+	// Repository storage quotas
+	go s.runQuotaChecks()
+
+	// Scans the last shutdown interrupted
+	go s.requeueInterrupted()
 }
 
+// Stop stops handing out queued jobs and waits for the workers to finish their current scan; jobs
+// popped while stopping are recorded as interrupted. Pair it with Drain to bound the wait.
 func (s *Scheduler) Stop() {
+	s.lastTick.Store(0)
 	close(s.quit)
-	close(s.jobChan)
+	s.queue.Close()
 	s.wg.Wait()
 }
 
@@ -59,13 +107,28 @@ func (s *Scheduler) runTicker() {
 	for {
 		select {
 		case <-ticker.C:
+			s.lastTick.Store(time.Now().Unix())
 			s.checkSchedules()
+			s.completeCredentialRotations()
 		case <-s.quit:
 			return
 		}
 	}
 }
 
+// Health reports whether the scheduler runs and its schedule ticker has not stalled
+func (s *Scheduler) Health() (string, error) {
+	last := s.lastTick.Load()
+	if last == 0 {
+		return "", fmt.Errorf("scheduler is not running")
+	}
+	since := time.Since(time.Unix(last, 0)).Round(time.Second)
+	if since > schedulerStallAfter {
+		return "", fmt.Errorf("schedule ticker last ran %s ago", since)
+	}
+	return fmt.Sprintf("last tick %s ago", since), nil
+}
+
 // checkSchedules checks DB for due policies
 func (s *Scheduler) checkSchedules() {
 	policies, err := s.db.ListEnabledScanPolicies()
@@ -93,6 +156,18 @@ func (s *Scheduler) checkSchedules() {
 	}
 }
 
+// completeCredentialRotations discards old registry credentials whose rollback window has passed
+func (s *Scheduler) completeCredentialRotations() {
+	n, err := s.db.CompleteExpiredRotations(time.Now())
+	if err != nil {
+		log.Println("Scheduler DB Error:", err)
+		return
+	}
+	if n > 0 {
+		log.Printf("🔑 Discarded old credentials of %d registries after their rollback window", n)
+	}
+}
+
 func (s *Scheduler) triggerPolicy(p models.ScanPolicy) {
 	reg, err := s.db.GetRegistry(p.RegistryID)
 	if err != nil {
@@ -100,8 +175,10 @@ func (s *Scheduler) triggerPolicy(p models.ScanPolicy) {
 		return
 	}
 
-	client := registry.NewClient(reg.URL, reg.Username, reg.Password, reg.Insecure)
-	repos, err := client.ListRepositories()
+	ctx, cancel := context.WithTimeout(WorkContext(), policyListTimeout)
+	defer cancel()
+	client := registry.NewClientFromRegistry(reg)
+	repos, err := client.ListRepositories(ctx)
 	if err != nil {
 		log.Printf("❌ Scheduler: Failed to list repos for registry %d: %v", p.RegistryID, err)
 		return
@@ -124,14 +201,26 @@ func (s *Scheduler) triggerPolicy(p models.ScanPolicy) {
 		}
 	}
 
+	// Per-repository scan modes set by onboarding rules or users override the repo filter
+	infos, err := s.db.ListRepositoryInfo(reg.ID)
+	if err != nil {
+		log.Printf("⚠️ Scheduler: Failed to load repository metadata for registry %d: %v", reg.ID, err)
+	}
+
 	count := 0
 	for _, repo := range repos {
 		repoName := repo.Name
-		if filterRe != nil && !filterRe.MatchString(repoName) {
+		switch infos[repoName].ScanMode {
+		case "never":
 			continue
+		case "always":
+		default:
+			if filterRe != nil && !filterRe.MatchString(repoName) {
+				continue
+			}
 		}
 
-		tags, err := client.ListTags(repoName)
+		tags, err := client.ListTags(ctx, repoName)
 		if err != nil {
 			continue
 		}
@@ -141,17 +230,17 @@ func (s *Scheduler) triggerPolicy(p models.ScanPolicy) {
 				continue
 			}
 			// Queue Job
-			select {
-			case s.jobChan <- ScanJob{
+			err := s.queue.Push(ScanJob{
 				RegistryURL: reg.URL,
 				RegistryID:  reg.ID,
 				Repo:        repoName,
 				Tag:         tag.Name,
-			}:
-				count++
-			case <-time.After(2 * time.Second):
-				log.Printf("⚠️ Scheduler job queue full, skipping %s:%s", repoName, tag.Name)
+			}, 2*time.Second)
+			if err != nil {
+				log.Printf("⚠️ Scheduler could not queue %s:%s: %v", repoName, tag.Name, err)
+				continue
 			}
+			count++
 		}
 	}
 	log.Printf("✅ Scheduler queued %d images for registry %d", count, p.RegistryID)
@@ -160,40 +249,87 @@ func (s *Scheduler) triggerPolicy(p models.ScanPolicy) {
 func (s *Scheduler) worker(id int) {
 	defer s.wg.Done()
 	log.Printf("👷 Scan Worker %d started", id)
-	for job := range s.jobChan {
-		// Create DB record (status: scanning)
-		scan := &models.VulnerabilityScan{
-			RegistryID: job.RegistryID,
-			Repository: job.Repo,
-			Tag:        job.Tag,
-			Status:     "scanning",
-			ScannedAt:  time.Now(),
+	for {
+		job, ok := s.queue.Pop()
+		if !ok {
+			return
 		}
+		s.runScanJob(job)
+	}
+}
 
-		if err := s.db.SaveScan(scan); err != nil {
-			log.Printf("Worker DB Error: %v", err)
-			continue
-		}
+// runScanJob scans one queued image and stores the result
+func (s *Scheduler) runScanJob(job ScanJob) {
+	done := BeginWork()
+	defer done()
+	scannerType := job.Scanner
+	if scannerType == "" {
+		scannerType = "trivy"
+	}
 
-		// Run Scan
-		// Pass credentials if needed (currently not supported by scanner func, assumes no auth/public)
-		// But in scheduler we have registry object access in triggerPolicy.
-		// job struct only has URL.
-		// Future improvement: Pass auth.
+	// Create DB record (status: scanning)
+	scan := &models.VulnerabilityScan{
+		RegistryID: job.RegistryID,
+		Repository: job.Repo,
+		Tag:        job.Tag,
+		Status:     "scanning",
+		ScannedAt:  time.Now(),
+	}
 
-		report, summary, err := scanner.ScanImage(job.RegistryURL, job.Repo, job.Tag)
-		if err != nil {
-			scan.Status = "failed"
-			scan.Report = fmt.Sprintf(`{"error": "%s"}`, err.Error())
-		} else {
-			scan.Status = "completed"
-			scan.Report = report
-			scan.Summary = summary
-		}
-		scan.ScannedAt = time.Now()
+	// Keep other scanners' results while this one runs
+	if existing, errGet := s.db.GetScan(job.RegistryID, job.Repo, job.Tag); errGet == nil {
+		scan.Report = existing.Report
+		scan.Summary = existing.Summary
+	}
 
-		if err := s.db.SaveScan(scan); err != nil {
-			log.Printf("Worker DB Error saving result: %v", err)
-		}
+	// A job popped while stopping (e.g. still buffered in the in-process queue) runs on the next start
+	select {
+	case <-s.quit:
+		markScanInterrupted(s.db, scan, job)
+		return
+	default:
+	}
+
+	if err := s.db.SaveScan(scan); err != nil {
+		log.Printf("Worker DB Error: %v", err)
+		return
+	}
+
+	// Run Scan
+	// Pass credentials if needed (currently not supported by scanner func, assumes no auth/public)
+	// But in scheduler we have registry object access in triggerPolicy.
+	// job struct only has URL.
+	// Future improvement: Pass auth.
+
+	var report, summary string
+	var err error
+	if scannerType == "osv" {
+		report, summary, err = scanner.ScanImageOSV(WorkContext(), job.RegistryURL, job.Repo, job.Tag, job.Platform)
+	} else {
+		report, summary, err = scanner.ScanImage(WorkContext(), job.RegistryURL, job.Repo, job.Tag, job.Platform)
+	}
+	if err != nil && Interrupted() {
+		markScanInterrupted(s.db, scan, job)
+		return
+	}
+
+	// Results are stored wrapped under the scanner key, merged with other scanners' data
+	existingReport, existingSummary := scan.Report, scan.Summary
+	if err != nil {
+		errorJSON, _ := json.Marshal(map[string]string{"error": err.Error()})
+		scan.Status = "failed"
+		scan.Report = scanner.MergeReport(existingReport, scannerType, string(errorJSON))
+		scan.Summary = scanner.MergeReport(existingSummary, scannerType, `{"Unknown":0}`)
+	} else {
+		scan.Status = "completed"
+		scan.Report = scanner.MergeReport(existingReport, scannerType, report)
+		scan.Summary = scanner.MergeReport(existingSummary, scannerType, summary)
+	}
+	scan.ScannedAt = time.Now()
+
+	if err := s.db.SaveScan(scan); err != nil {
+		log.Printf("Worker DB Error saving result: %v", err)
+		return
 	}
+	go ExportScanToDefectDojo(s.db, scan)
 }
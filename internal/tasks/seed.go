@@ -0,0 +1,126 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"docker-registry-dashboard/internal/database"
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/registry"
+)
+
+// seedCheckInterval is how often seed images are checked for being due
+const seedCheckInterval = 5 * time.Minute
+
+// seedTimeout bounds one scheduled mirror of a seed image
+const seedTimeout = time.Hour
+
+// seedRuns keeps a seed image from being mirrored twice at the same time
+var seedRuns sync.Map
+
+func (s *Scheduler) runSeeds() {
+	ticker := time.NewTicker(seedCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.seedDueImages()
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// seedDueImages mirrors the enabled seed images whose interval has passed, unless maintenance
+// mode is on
+func (s *Scheduler) seedDueImages() {
+	if s.db.InMaintenance() {
+		return // Due seeds are mirrored once maintenance mode is off
+	}
+	seeds, err := s.db.ListSeedImages()
+	if err != nil {
+		log.Println("Seed DB Error:", err)
+		return
+	}
+	now := time.Now()
+	for i := range seeds {
+		seed := &seeds[i]
+		if !seed.Enabled {
+			continue
+		}
+		interval := seed.IntervalHours
+		if interval < 1 {
+			interval = 24
+		}
+		if !seed.LastRunAt.IsZero() && now.Before(seed.LastRunAt.Add(time.Duration(interval)*time.Hour)) {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(WorkContext(), seedTimeout)
+		_, err := RunSeed(ctx, s.db, seed)
+		cancel()
+		if err != nil {
+			log.Printf("❌ Seed: %s failed: %v", seed.Image, err)
+		}
+	}
+}
+
+// RunSeed mirrors a seed image into its target registry through the copy machinery. It returns
+// true when something was copied; an image whose digest is already in the target is left alone.
+func RunSeed(ctx context.Context, db *database.DB, seed *models.SeedImage) (bool, error) {
+	if _, running := seedRuns.LoadOrStore(seed.ID, true); running {
+		return false, fmt.Errorf("seed %d is already running", seed.ID)
+	}
+	defer seedRuns.Delete(seed.ID)
+
+	copied, digest, err := mirrorSeed(ctx, db, seed)
+	errMsg := ""
+	if err != nil {
+		errMsg = err.Error()
+	}
+	if err := db.SetSeedResult(seed.ID, time.Now(), digest, errMsg); err != nil {
+		log.Printf("⚠️ Seed: failed to record result of %s: %v", seed.Image, err)
+	}
+	if copied {
+		log.Printf("🌱 Seeded %s (%s) into registry %d", seed.Image, digest, seed.TargetRegistryID)
+	}
+	return copied, err
+}
+
+func mirrorSeed(ctx context.Context, db *database.DB, seed *models.SeedImage) (bool, string, error) {
+	baseURL, repo, tag, err := registry.ParseImageReference(seed.Image)
+	if err != nil {
+		return false, "", err
+	}
+	target, err := db.GetRegistry(seed.TargetRegistryID)
+	if err != nil {
+		return false, "", fmt.Errorf("target registry %d not found", seed.TargetRegistryID)
+	}
+	targetRepo := seed.TargetRepository
+	if targetRepo == "" {
+		targetRepo = repo
+	}
+
+	src := registry.NewClient(baseURL, "", "", false)
+	dst := registry.NewClientFromRegistry(target)
+	digest, err := src.GetDigestForTag(ctx, repo, tag)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to resolve %s: %w", seed.Image, err)
+	}
+	if current, err := dst.GetDigestForTag(ctx, targetRepo, tag); err == nil && current == digest {
+		return false, digest, nil
+	}
+	if q, err := db.BlockingQuota(target.ID, targetRepo); err != nil {
+		return false, "", err
+	} else if q != nil {
+		return false, "", fmt.Errorf("%s is over its storage quota", targetRepo)
+	}
+
+	if _, err := registry.CopyImage(ctx, src, repo, tag, dst, targetRepo, tag, nil); err != nil {
+		return false, "", fmt.Errorf("failed to copy %s: %w", seed.Image, err)
+	}
+	return true, digest, nil
+}
@@ -0,0 +1,118 @@
+package tasks
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"docker-registry-dashboard/internal/database"
+	"docker-registry-dashboard/internal/models"
+)
+
+// Background work that outlives a request (scans, retention runs) registers here, so a shutdown
+// can wait for it and, once the grace period is over, cancel it at a safe point
+var (
+	workCtx, cancelWork = context.WithCancel(context.Background())
+	work                sync.WaitGroup
+)
+
+// interruptWait is how long cancelled work gets to record its interruption after the grace period
+const interruptWait = 10 * time.Second
+
+// WorkContext is cancelled when the grace period of a shutdown runs out
+func WorkContext() context.Context {
+	return workCtx
+}
+
+// BeginWork registers background work a shutdown waits for; call the returned function when it ends
+func BeginWork() func() {
+	work.Add(1)
+	return work.Done
+}
+
+// Interrupted reports whether a shutdown cancelled the background work
+func Interrupted() bool {
+	return workCtx.Err() != nil
+}
+
+// Drain waits for the registered work until ctx is done, then cancels WorkContext and gives the
+// work interruptWait to wind down. It reports whether all work finished within the grace period.
+func Drain(ctx context.Context) bool {
+	done := make(chan struct{})
+	go func() {
+		work.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+	}
+
+	log.Println("⏱️  Shutdown grace period is over, interrupting background work")
+	cancelWork()
+	select {
+	case <-done:
+	case <-time.After(interruptWait):
+		log.Println("⚠️  Background work did not stop in time")
+	}
+	return false
+}
+
+// markScanInterrupted records a scan cut short by a shutdown; the next start queues it again
+func markScanInterrupted(db *database.DB, scan *models.VulnerabilityScan, job ScanJob) {
+	scan.Status = "interrupted"
+	scan.ScannedAt = time.Now()
+	if err := db.SaveScan(scan); err != nil {
+		log.Printf("⚠️  Failed to mark scan of %s:%s interrupted: %v", job.Repo, job.Tag, err)
+	}
+	if err := db.AddInterruptedScan(job.RegistryID, job.Repo, job.Tag, job.Scanner, job.Platform); err != nil {
+		log.Printf("⚠️  Failed to record %s:%s for requeue: %v", job.Repo, job.Tag, err)
+	}
+}
+
+// MarkScanInterrupted records a scan started outside the scheduler queue as interrupted
+func MarkScanInterrupted(db *database.DB, scan *models.VulnerabilityScan, scannerType, platform string) {
+	markScanInterrupted(db, scan, ScanJob{
+		RegistryID: scan.RegistryID,
+		Repo:       scan.Repository,
+		Tag:        scan.Tag,
+		Scanner:    scannerType,
+		Platform:   platform,
+	})
+}
+
+// requeueInterrupted queues the scans an earlier shutdown interrupted. Without a shared queue no
+// other process can be running a scan, so scans left "scanning" by a crash are queued as well.
+func (s *Scheduler) requeueInterrupted() {
+	_, shared := s.queue.(*RedisQueue)
+	jobs, err := s.db.TakeInterruptedScans(!shared)
+	if err != nil {
+		log.Printf("⚠️  Failed to load interrupted scans: %v", err)
+		return
+	}
+	count := 0
+	for _, j := range jobs {
+		reg, err := s.db.GetRegistry(j.RegistryID)
+		if err != nil {
+			continue
+		}
+		err = s.queue.Push(ScanJob{
+			RegistryURL: reg.URL,
+			RegistryID:  reg.ID,
+			Repo:        j.Repository,
+			Tag:         j.Tag,
+			Scanner:     j.Scanner,
+			Platform:    j.Platform,
+		}, 2*time.Second)
+		if err != nil {
+			log.Printf("⚠️  Could not requeue interrupted scan of %s:%s: %v", j.Repository, j.Tag, err)
+			continue
+		}
+		count++
+	}
+	if count > 0 {
+		log.Printf("🔁 Requeued %d scans interrupted by the last shutdown", count)
+	}
+}
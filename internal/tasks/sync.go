@@ -0,0 +1,245 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"docker-registry-dashboard/internal/compliance"
+	"docker-registry-dashboard/internal/database"
+	"docker-registry-dashboard/internal/models"
+	"docker-registry-dashboard/internal/registry"
+)
+
+// syncInterval is how often every registry's catalog is walked
+const syncInterval = 15 * time.Minute
+
+// syncTimeout bounds the scheduled walk of one registry, so a hung registry cannot hold up the others
+const syncTimeout = 10 * time.Minute
+
+func (s *Scheduler) runSync() {
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.syncAll()
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+func (s *Scheduler) syncAll() {
+	registries, err := s.db.ListRegistries()
+	if err != nil {
+		log.Println("Sync DB Error:", err)
+		return
+	}
+	for i := range registries {
+		ctx, cancel := context.WithTimeout(WorkContext(), syncTimeout)
+		result, err := SyncRegistry(ctx, s.db, &registries[i])
+		cancel()
+		if err != nil {
+			log.Printf("❌ Sync: registry %d failed: %v", registries[i].ID, err)
+			continue
+		}
+		log.Printf("🔄 Synced registry %d: %d repositories (%d new, %d onboarded), %d tags (%d new), %d violations, %d drifts",
+			result.RegistryID, result.Repositories, len(result.Discovered), result.Onboarded, result.Tags, result.Pushes, result.Violations, result.Drifts)
+	}
+}
+
+// SyncRegistry walks a registry's catalog, onboards new repositories, evaluates the image policy,
+// refreshes the search index and checks pinned tags for drift
+func SyncRegistry(ctx context.Context, db *database.DB, reg *models.Registry) (*models.SyncResult, error) {
+	result := &models.SyncResult{RegistryID: reg.ID, StartedAt: time.Now()}
+
+	policy, err := db.GetImagePolicy(reg.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	client := registry.NewClientFromRegistry(reg)
+	repos, err := client.ListRepositories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories: %w", err)
+	}
+	result.Repositories = len(repos)
+
+	names := make([]string, len(repos))
+	for i, repo := range repos {
+		names[i] = repo.Name
+	}
+	discovered, err := db.TrackRepositories(reg.ID, names, result.StartedAt)
+	if err != nil {
+		return nil, err
+	}
+	result.Discovered = discovered
+	result.Onboarded = onboardRepositories(db, reg.ID, discovered)
+
+	var violations []models.ComplianceViolation
+	listed := make(map[string][]models.Tag, len(repos))
+	for _, repo := range repos {
+		tags, err := client.ListTags(ctx, repo.Name)
+		if err != nil {
+			log.Printf("⚠️ Sync: failed to list tags of %s: %v", repo.Name, err)
+			continue
+		}
+		result.Tags += len(tags)
+		listed[repo.Name] = tags
+
+		if policy.Enabled {
+			violations = append(violations, evaluateImagePolicy(ctx, client, policy, repo.Name, tags)...)
+		}
+	}
+
+	if policy.Enabled {
+		if err := recordViolations(db, policy, violations, result.StartedAt); err != nil {
+			return nil, err
+		}
+		result.Violations = len(violations)
+	}
+
+	added, err := db.IndexRegistry(reg.ID, names, listed, result.StartedAt)
+	if err != nil {
+		log.Printf("⚠️ Sync: failed to update search index of registry %d: %v", reg.ID, err)
+	}
+	result.Pushes = recordDetectedPushes(ctx, db, client, reg.ID, added, result.StartedAt)
+
+	drifts, err := checkTagPins(ctx, db, client, policy, repos, listed, result.StartedAt)
+	if err != nil {
+		return nil, err
+	}
+	result.Drifts = drifts
+
+	result.FinishedAt = time.Now()
+	return result, nil
+}
+
+// recordDetectedPushes stores a push event for every tag that appeared since the previous sync,
+// so registries without webhooks still feed the activity and push feeds. It returns the number stored.
+func recordDetectedPushes(ctx context.Context, db *database.DB, client *registry.Client, registryID int64, added map[string][]string, now time.Time) int {
+	stored := 0
+	for repo, tags := range added {
+		for _, tag := range tags {
+			e := &models.RegistryEvent{RegistryID: registryID, Source: "sync", Action: "push", Repository: repo, Tag: tag, Timestamp: now}
+			if digest, err := client.GetDigestForTag(ctx, repo, tag); err == nil {
+				e.Digest = digest
+			}
+			if err := db.AddRegistryEvent(e); err != nil {
+				log.Printf("⚠️ Sync: failed to record push of %s:%s: %v", repo, tag, err)
+				continue
+			}
+			stored++
+		}
+	}
+	return stored
+}
+
+// evaluateImagePolicy checks every tag of a repository against the policy
+func evaluateImagePolicy(ctx context.Context, client *registry.Client, policy *models.ImagePolicy, repo string, tags []models.Tag) []models.ComplianceViolation {
+	var violations []models.ComplianceViolation
+	checkBase := compliance.ChecksBase(policy)
+
+	for _, tag := range tags {
+		add := func(rule, detail string) {
+			violations = append(violations, models.ComplianceViolation{
+				RegistryID: policy.RegistryID,
+				Repository: repo,
+				Tag:        tag.Name,
+				Digest:     tag.Digest,
+				Rule:       rule,
+				Detail:     detail,
+			})
+		}
+
+		if rule, detail, bad := compliance.CheckRepository(policy, repo); bad {
+			add(rule, detail)
+		}
+		if !checkBase {
+			continue
+		}
+
+		config, err := client.GetImageConfig(ctx, repo, tag.Name, "")
+		if err != nil {
+			log.Printf("⚠️ Sync: failed to read config of %s:%s: %v", repo, tag.Name, err)
+			continue
+		}
+		if rule, detail, bad := compliance.CheckBase(policy, config.Labels[compliance.BaseImageLabel]); bad {
+			add(rule, detail)
+		}
+	}
+	return violations
+}
+
+// recordViolations replaces the stored violations, keeping detection times of known ones,
+// and alerts on new violations when the policy asks for it
+func recordViolations(db *database.DB, policy *models.ImagePolicy, violations []models.ComplianceViolation, now time.Time) error {
+	previous, err := db.ListViolations(policy.RegistryID)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]time.Time, len(previous))
+	for _, v := range previous {
+		known[violationKey(v)] = v.DetectedAt
+	}
+
+	var fresh []models.ComplianceViolation
+	for i := range violations {
+		if detectedAt, ok := known[violationKey(violations[i])]; ok {
+			violations[i].DetectedAt = detectedAt
+			continue
+		}
+		violations[i].DetectedAt = now
+		fresh = append(fresh, violations[i])
+	}
+
+	if err := db.ReplaceViolations(policy.RegistryID, violations, now); err != nil {
+		return err
+	}
+
+	if policy.Action == "alert" && policy.AlertWebhookURL != "" && len(fresh) > 0 {
+		if err := sendViolationAlert(policy.AlertWebhookURL, policy.RegistryID, fresh); err != nil {
+			log.Printf("⚠️ Sync: failed to send compliance alert for registry %d: %v", policy.RegistryID, err)
+		}
+	}
+	return nil
+}
+
+func violationKey(v models.ComplianceViolation) string {
+	return v.Repository + ":" + v.Tag + "|" + v.Rule
+}
+
+// sendViolationAlert POSTs new violations as JSON to the policy's webhook
+func sendViolationAlert(url string, registryID int64, violations []models.ComplianceViolation) error {
+	return postAlert(url, map[string]interface{}{
+		"event":       "compliance.violations",
+		"registry_id": registryID,
+		"violations":  violations,
+	})
+}
+
+// postAlert POSTs an alert payload as JSON to a webhook
+func postAlert(url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -9,39 +9,77 @@
             const opts = { method, headers: { 'Content-Type': 'application/json' } };
             if (body) opts.body = JSON.stringify(body);
             const res = await fetch(url, opts);
+            if (res.status === 401 && url !== '/api/v1/auth/login' && await API.login()) return API.request(method, url, body);
             const data = await res.json();
             if (!data.success) throw new Error(data.error || 'Unknown error');
             return data;
         },
-        getDashboardStats: () => API.request('GET', '/api/dashboard/stats'),
-        getRegistries: () => API.request('GET', '/api/registries'),
-        createRegistry: (d) => API.request('POST', '/api/registries', d),
-        updateRegistry: (id, d) => API.request('PUT', `/api/registries/${id}`, d),
-        deleteRegistry: (id) => API.request('DELETE', `/api/registries/${id}`),
-        testRegistry: (id) => API.request('POST', `/api/registries/${id}/test`),
-        getRepositories: (id) => API.request('GET', `/api/registries/${id}/repositories`),
-        getTags: (id, repo) => API.request('GET', `/api/registries/${id}/tags?repo=${encodeURIComponent(repo)}`),
-        getManifest: (id, repo, tag) => API.request('GET', `/api/registries/${id}/manifest?repo=${encodeURIComponent(repo)}&tag=${encodeURIComponent(tag)}`),
-        deleteTag: (id, repo, tag) => API.request('DELETE', `/api/registries/${id}/tag?repo=${encodeURIComponent(repo)}&tag=${encodeURIComponent(tag)}`),
-        getStorageConfig: () => API.request('GET', '/api/storage'),
-        saveStorageConfig: (d) => API.request('POST', '/api/storage', d),
-        testStorageConnection: (d) => API.request('POST', '/api/storage/test', d),
-        getRegistryStatus: () => API.request('GET', '/api/registry/status'),
-        restartRegistry: () => API.request('POST', '/api/registry/restart'),
-        stopRegistry: () => API.request('POST', '/api/registry/stop'),
-        startRegistry: () => API.request('POST', '/api/registry/start'),
-        getRegistryLogs: () => API.request('GET', '/api/registry/logs'),
-        getRetention: (id) => API.request('GET', `/api/registries/${id}/retention`),
-        saveRetention: (id, d) => API.request('POST', `/api/registries/${id}/retention`, d),
-        runRetention: (id, dry) => API.request('POST', `/api/registries/${id}/retention/run?dry_run=${dry}`),
+        // Asks for credentials when the server requires authentication (started with -auth)
+        async login() {
+            const username = prompt('Username');
+            if (!username) return false;
+            const password = prompt('Password');
+            if (password === null) return false;
+            try { await API.request('POST', '/api/v1/auth/login', { username, password }); return true; }
+            catch (e) { alert(e.message); return false; }
+        },
+        getDashboardStats: () => API.request('GET', '/api/v1/dashboard/stats'),
+        refreshDashboardStats: () => API.request('POST', '/api/v1/dashboard/stats/refresh'),
+        search: (q) => API.request('GET', '/api/v1/search?' + new URLSearchParams({ q })),
+        getRegistries: () => API.request('GET', '/api/v1/registries'),
+        createRegistry: (d) => API.request('POST', '/api/v1/registries', d),
+        updateRegistry: (id, d) => API.request('PUT', `/api/v1/registries/${id}`, d),
+        deleteRegistry: (id) => API.request('DELETE', `/api/v1/registries/${id}`),
+        testRegistry: (id) => API.request('POST', `/api/v1/registries/${id}/test`),
+        getRegistryProfile: (id) => API.request('GET', `/api/v1/registries/${id}/conformance`),
+        probeConformance: (id) => API.request('POST', `/api/v1/registries/${id}/conformance`),
+        harborProjects: (id) => API.request('GET', `/api/v1/registries/${id}/harbor/projects`),
+        getRepositories: (id, params) => API.request('GET', `/api/v1/registries/${id}/repositories` + (params ? '?' + new URLSearchParams(params) : '')),
+        getTags: (id, repo) => API.request('GET', `/api/v1/registries/${id}/tags?repo=${encodeURIComponent(repo)}`),
+        verifyNotation: (id, repo) => API.request('GET', `/api/v1/registries/${id}/notation-verification?repo=${encodeURIComponent(repo)}`),
+        getAttestations: (id, repo, tag) => API.request('GET', `/api/v1/registries/${id}/attestations?repo=${encodeURIComponent(repo)}&tag=${encodeURIComponent(tag)}`),
+        getManifest: (id, repo, tag) => API.request('GET', `/api/v1/registries/${id}/manifest?repo=${encodeURIComponent(repo)}&tag=${encodeURIComponent(tag)}`),
+        deleteTag: (id, repo, tag) => API.request('DELETE', `/api/v1/registries/${id}/tag?repo=${encodeURIComponent(repo)}&tag=${encodeURIComponent(tag)}`),
+        pinTag: (id, repo, tag) => API.request('POST', `/api/v1/registries/${id}/pins`, { repository: repo, tag }),
+        getSigningKeys: () => API.request('GET', '/api/v1/signing-keys'),
+        signImage: (id, repo, tag, keyId) => API.request('POST', '/api/v1/images/sign', { registry_id: id, repository: repo, tag, key_id: keyId }),
+        renameRepository: (id, repo, newRepo) => API.request('POST', `/api/v1/registries/${id}/repository/rename`, { repository: repo, new_repository: newRepo }),
+        getRenameJob: (id, job) => API.request('GET', `/api/v1/registries/${id}/repository/rename/${job}`),
+        getStorageConfig: () => API.request('GET', '/api/v1/storage'),
+        saveStorageConfig: (d) => API.request('POST', '/api/v1/storage', d),
+        testStorageConnection: (d) => API.request('POST', '/api/v1/storage/test', d),
+        getRegistryStatus: () => API.request('GET', '/api/v1/registry/status'),
+        getRegistryUsers: () => API.request('GET', '/api/v1/registry/users'),
+        createRegistryUser: (d) => API.request('POST', '/api/v1/registry/users', d),
+        deleteRegistryUser: (id) => API.request('DELETE', `/api/v1/registry/users/${id}`),
+        updateRegistryPermissions: (id, d) => API.request('PUT', `/api/v1/registry/users/${id}/permissions`, d),
+        getRegistryInstances: () => API.request('GET', '/api/v1/registry/instances'),
+        createRegistryInstance: (d) => API.request('POST', '/api/v1/registry/instances', d),
+        deleteRegistryInstance: (id) => API.request('DELETE', `/api/v1/registry/instances/${id}`),
+        registryInstanceAction: (id, action) => API.request('POST', `/api/v1/registry/instances/${id}/${action}`),
+        restartRegistry: () => API.request('POST', '/api/v1/registry/restart'),
+        stopRegistry: () => API.request('POST', '/api/v1/registry/stop'),
+        startRegistry: () => API.request('POST', '/api/v1/registry/start'),
+        upgradeRegistry: (d) => API.request('POST', '/api/v1/registry/upgrade', d),
+        getRegistrySettings: () => API.request('GET', '/api/v1/registry/settings'),
+        updateRegistrySettings: (d) => API.request('PUT', '/api/v1/registry/settings', d),
+        getRegistryLogs: () => API.request('GET', '/api/v1/registry/logs'),
+        getMaintenance: () => API.request('GET', '/api/v1/maintenance'),
+        setMaintenance: (d) => API.request('PUT', '/api/v1/maintenance', d),
+        getQuotas: () => API.request('GET', '/api/v1/quotas'),
+        checkQuota: (id) => API.request('POST', `/api/v1/quotas/${id}/check`),
+        previewRegistryConfig: (d) => API.request('POST', '/api/v1/registry/config/preview', d),
+        getRetention: (id) => API.request('GET', `/api/v1/registries/${id}/retention`),
+        saveRetention: (id, d) => API.request('POST', `/api/v1/registries/${id}/retention`, d),
+        runRetention: (id, dry) => API.request('POST', `/api/v1/registries/${id}/retention/run?dry_run=${dry}`),
 
         // Vulnerability Scan
-        triggerScan: (data) => API.request('POST', '/api/scan/trigger', data),
-        getScanResult: (regId, repo, tag) => API.request('GET', `/api/scan/result?registry_id=${regId}&repository=${repo}&tag=${tag}`),
-        listScans: (id) => fetch(`/api/scan/list?registry_id=${id}`).then(r => r.json()),
-        listVulnerabilities: (id) => API.request('GET', `/api/vulnerabilities/list?registry_id=${id}`),
-        getScanPolicy: (id) => fetch(`/api/registries/${id}/scan-policy`).then(r => r.json()),
-        saveScanPolicy: (id, data) => fetch(`/api/registries/${id}/scan-policy`, { method: 'POST', body: JSON.stringify(data) }).then(r => r.json()),
+        triggerScan: (data) => API.request('POST', '/api/v1/scan/trigger', data),
+        getScanResult: (regId, repo, tag) => API.request('GET', `/api/v1/scan/result?registry_id=${regId}&repository=${repo}&tag=${tag}`),
+        listScans: (id) => fetch(`/api/v1/scan/list?registry_id=${id}`).then(r => r.json()),
+        listVulnerabilities: (id) => API.request('GET', `/api/v1/vulnerabilities/list?registry_id=${id}`),
+        getScanPolicy: (id) => fetch(`/api/v1/registries/${id}/scan-policy`).then(r => r.json()),
+        saveScanPolicy: (id, data) => fetch(`/api/v1/registries/${id}/scan-policy`, { method: 'POST', body: JSON.stringify(data) }).then(r => r.json()),
     };
 
     // Toast Notifications
@@ -118,6 +156,7 @@
             const res = await API.getDashboardStats();
             const s = res.data;
             const er = s.embedded_registry || {};
+            const sec = s.security || {};
 
             let regCardsHtml = '';
             if (s.registries && s.registries.length > 0) {
@@ -126,9 +165,11 @@
                         <div class="registry-card-header">
                             <div class="registry-card-info"><h3>${escapeHtml(r.name)}</h3><div class="registry-card-url">${escapeHtml(r.url)}</div></div>
                             <span class="badge ${r.status === 'online' ? 'badge-success' : 'badge-danger'}"><span class="badge-dot"></span>${r.status}</span>
+                            ${r.certificate && !['ok', 'plain_http'].includes(r.certificate) ? `<span class="badge ${r.certificate === 'invalid' ? 'badge-warning' : 'badge-danger'}" title="TLS certificate check">🔐 ${escapeHtml(r.certificate)}</span>` : ''}
                         </div>
                         <div class="registry-card-stats">
                             <div class="registry-stat"><span class="registry-stat-value">${r.image_count}</span><span class="registry-stat-label">Images</span></div>
+                            ${r.pull_rate_limit ? `<div class="registry-stat" title="Docker Hub pull rate limit${r.pull_rate_limit.source ? ' of ' + escapeHtml(r.pull_rate_limit.source) : ''}${r.pull_rate_limit.throttled ? ' · dashboard pulls held back, ' + r.pull_rate_limit.reserve + ' kept in reserve' : ''}"><span class="registry-stat-value" style="${r.pull_rate_limit.throttled ? 'color:var(--danger)' : ''}">${r.pull_rate_limit.unlimited ? '∞' : r.pull_rate_limit.remaining + ' / ' + r.pull_rate_limit.limit}</span><span class="registry-stat-label">Pulls left${r.pull_rate_limit.window_seconds ? ' (' + Math.round(r.pull_rate_limit.window_seconds / 3600) + 'h)' : ''}</span></div>` : ''}
                         </div>
                     </div>`).join('');
             }
@@ -143,24 +184,41 @@
                             </div>
                             <div>
                                 <div style="font-weight:700;font-size:1.05rem">Embedded Registry V2</div>
-                                <div style="font-size:0.85rem;color:var(--text-muted)">${er.running ? 'Running at <strong style=color:var(--text-accent)>' + escapeHtml(er.url || '') + '</strong>' : 'Not running'}</div>
+                                <div style="font-size:0.85rem;color:var(--text-muted)">${er.running ? 'Running at <strong style=color:var(--text-accent)>' + escapeHtml(er.url || '') + '</strong>' : 'Not running'}${er.registry_image ? ' · ' + escapeHtml(er.registry_image) : ''}${er.version ? ' (v' + escapeHtml(er.version) + ')' : ''}${er.proxy_remote_url ? ' · 🪞 Pull-through cache of ' + escapeHtml(er.proxy_remote_url) : ''}</div>
+                                ${er.proxy_cache ? `<div style="font-size:0.8rem;color:var(--text-muted)" title="Since the registry started">Cache hits: <strong>${er.proxy_cache.hit_rate.toFixed(1)}%</strong> · Manifests ${er.proxy_cache.manifests.hits}/${er.proxy_cache.manifests.requests} · Blobs ${er.proxy_cache.blobs.hits}/${er.proxy_cache.blobs.requests} · ${formatBytes(er.proxy_cache.blobs.bytes_pulled)} pulled from upstream</div>` : ''}
+                                ${er.incidents && er.incidents.length ? `<div style="font-size:0.8rem;color:var(--warning)" title="${escapeHtml(er.incidents.map(i => new Date(i.time).toLocaleString() + ' ' + i.kind + ': ' + i.detail + (i.restarted ? ' (restarted)' : i.restart_error ? ' (restart failed: ' + i.restart_error + ')' : '')).join('\n'))}">🩺 ${er.incidents.length} incident${er.incidents.length === 1 ? '' : 's'} · last ${new Date(er.incidents[0].time).toLocaleString()}: ${escapeHtml(er.incidents[0].kind)} · ${er.watchdog_restarts || 0} watchdog restart${er.watchdog_restarts === 1 ? '' : 's'}</div>` : ''}
                             </div>
                         </div>
                         <div style="display:flex;gap:8px">
                             ${er.running
-                    ? '<button class="btn btn-sm btn-ghost" onclick="window.app.embeddedAction(\'restart\')">🔄 Restart</button><button class="btn btn-sm btn-danger" onclick="window.app.embeddedAction(\'stop\')">Stop</button>'
+                    ? (er.mode === 'container' ? '<button class="btn btn-sm btn-ghost" onclick="window.app.upgradeEmbeddedRegistry()">⬆️ Upgrade</button>' : '') + '<button class="btn btn-sm btn-ghost" onclick="window.app.embeddedAction(\'restart\')">🔄 Restart</button><button class="btn btn-sm btn-danger" onclick="window.app.embeddedAction(\'stop\')">Stop</button>'
                     : '<button class="btn btn-sm btn-success" onclick="window.app.embeddedAction(\'start\')">▶ Start</button>'
                 }
                         </div>
                     </div>
                 </div>
 
+                <div style="display:flex;align-items:center;justify-content:flex-end;gap:10px;margin-bottom:12px;font-size:0.85rem;color:var(--text-muted)">
+                    <input class="form-input" id="global-search" placeholder="Search repositories and tags (repo:tag)..." style="max-width:340px;margin-right:auto" onkeydown="if(event.key==='Enter')window.app.globalSearch(this.value)">
+                    <span>${s.refreshing ? 'Refreshing...' : 'Updated ' + (s.refreshed_at ? new Date(s.refreshed_at).toLocaleString() : 'never')}</span>
+                    <button class="btn btn-sm btn-ghost" onclick="window.app.refreshDashboardStats()">🔄 Refresh</button>
+                </div>
+                <div id="global-search-results"></div>
                 <div class="stats-grid">
                     <div class="stat-card stat-registries"><div class="stat-icon"><svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><ellipse cx="12" cy="5" rx="9" ry="3"/><path d="M21 12c0 1.66-4 3-9 3s-9-1.34-9-3"/><path d="M3 5v14c0 1.66 4 3 9 3s9-1.34 9-3V5"/></svg></div><div class="stat-value">${s.total_registries}</div><div class="stat-label">Registries</div></div>
                     <div class="stat-card stat-images"><div class="stat-icon"><svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M22 19a2 2 0 0 1-2 2H4a2 2 0 0 1-2-2V5a2 2 0 0 1 2-2h5l2 3h9a2 2 0 0 1 2 2z"/></svg></div><div class="stat-value">${s.total_images}</div><div class="stat-label">Images</div></div>
                     <div class="stat-card stat-tags"><div class="stat-icon"><svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M20.59 13.41l-7.17 7.17a2 2 0 0 1-2.83 0L2 12V2h10l8.59 8.59a2 2 0 0 1 0 2.82z"/><line x1="7" y1="7" x2="7.01" y2="7"/></svg></div><div class="stat-value">${s.total_tags}</div><div class="stat-label">Tags</div></div>
                     <div class="stat-card stat-storage"><div class="stat-icon"><svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M4 7V4a2 2 0 0 1 2-2h8.5L20 7.5V20a2 2 0 0 1-2 2H6a2 2 0 0 1-2-2v-3"/><polyline points="14 2 14 8 20 8"/></svg></div><div class="stat-value" style="text-transform:uppercase;font-size:1.3rem">${s.storage_type || 'N/A'}</div><div class="stat-label">Storage Type</div></div>
                 </div>
+                <div class="section-header"><h2>Security Posture</h2></div>
+                <div class="card" style="margin-bottom:24px">
+                    <div class="registry-card-stats">
+                        <div class="registry-stat"><span class="registry-stat-value" style="color:#dc2626">${sec.total_critical || 0}</span><span class="registry-stat-label">Critical</span></div>
+                        <div class="registry-stat"><span class="registry-stat-value" style="color:#ea580c">${sec.total_high || 0}</span><span class="registry-stat-label">High</span></div>
+                        <div class="registry-stat"><span class="registry-stat-value">${sec.unscanned_images || 0}</span><span class="registry-stat-label">Unscanned Images</span></div>
+                        <div class="registry-stat"><span class="registry-stat-value">${sec.scanned_last_7_days_pct || 0}%</span><span class="registry-stat-label">Scanned (7 days)</span></div>
+                    </div>
+                </div>
                 ${regCardsHtml ? '<div class="section-header"><h2>Connected Registries</h2></div><div class="registry-grid">' + regCardsHtml + '</div>' : ''}
             </div>`;
         } catch (err) {
@@ -181,6 +239,7 @@
                         <div class="registry-card-info"><h3>${escapeHtml(r.name)}</h3><div class="registry-card-url">${escapeHtml(r.url)}</div></div>
                         <div class="registry-card-actions">
                             <button class="btn btn-icon btn-ghost" onclick="event.stopPropagation();window.app.testRegistry(${r.id})" title="Test"><svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M22 11.08V12a10 10 0 1 1-5.93-9.14"/><polyline points="22 4 12 14.01 9 11.01"/></svg></button>
+                            <button class="btn btn-icon btn-ghost" onclick="event.stopPropagation();window.app.showConformance(${r.id})" title="Conformance"><svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M9 11l3 3L22 4"/><path d="M21 12v7a2 2 0 0 1-2 2H5a2 2 0 0 1-2-2V5a2 2 0 0 1 2-2h11"/></svg></button>
                             <button class="btn btn-icon btn-ghost" onclick="event.stopPropagation();window.app.showEditRegistry(${r.id})" title="Edit"><svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M11 4H4a2 2 0 0 0-2 2v14a2 2 0 0 0 2 2h14a2 2 0 0 0 2-2v-7"/><path d="M18.5 2.5a2.121 2.121 0 0 1 3 3L12 15l-4 1 1-4 9.5-9.5z"/></svg></button>
                             <button class="btn btn-icon btn-ghost" onclick="event.stopPropagation();window.app.deleteRegistry(${r.id},'${escapeHtml(r.name)}')" title="Delete" style="color:var(--danger)"><svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><polyline points="3 6 5 6 21 6"/><path d="M19 6v14a2 2 0 0 1-2 2H7a2 2 0 0 1-2-2V6m3 0V4a2 2 0 0 1 2-2h4a2 2 0 0 1 2 2v2"/></svg></button>
                         </div>
@@ -204,7 +263,7 @@
             if (!regs.length) { c.innerHTML = '<div class="page-enter">' + showEmpty('<svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M22 19a2 2 0 0 1-2 2H4a2 2 0 0 1-2-2V5a2 2 0 0 1 2-2h5l2 3h9a2 2 0 0 1 2 2z"/></svg>', 'No registries available', 'Please add a registry first.', '<button class="btn btn-primary" onclick="window.app.navigate(\'registries\')">Go to Registries</button>') + '</div>'; return; }
             const opts = regs.map(r => `<option value="${r.id}">${escapeHtml(r.name)} (${escapeHtml(r.url)})</option>`).join('');
             c.innerHTML = `<div class="page-enter"><div class="registry-selector"><label for="registry-select">Select Registry:</label><select id="registry-select" class="form-select" onchange="window.app.loadImages(this.value)"><option value="">-- Choose a registry --</option>${opts}</select></div><div id="images-content"></div></div>`;
-            if (window.app._selectedRegistry) { document.getElementById('registry-select').value = window.app._selectedRegistry; window.app.loadImages(window.app._selectedRegistry); }
+            if (window.app._selectedRegistry) { document.getElementById('registry-select').value = window.app._selectedRegistry; await window.app.loadImages(window.app._selectedRegistry); }
         } catch (err) { Toast.error('Failed: ' + err.message); }
     }
 
@@ -212,26 +271,58 @@
         const c = document.getElementById('page-container');
         c.innerHTML = '<div class="page-enter">' + showLoading() + '</div>';
         try {
-            const [storageRes, statusRes] = await Promise.all([API.getStorageConfig(), API.getRegistryStatus()]);
+            const [storageRes, statusRes, usersRes, instancesRes, maintenanceRes, quotasRes] = await Promise.all([API.getStorageConfig(), API.getRegistryStatus(), API.getRegistryUsers(), API.getRegistryInstances(), API.getMaintenance(), API.getQuotas()]);
             const cfg = storageRes.data || { type: 'local' };
             const regStatus = statusRes.data || {};
+            const regUsers = usersRes.data || [];
+            const instances = instancesRes.data || [];
+            const maintenance = maintenanceRes.data || {};
+            const quotas = quotasRes.data || [];
+            const quotaBadge = { ok: 'badge-success', warning: 'badge-warning', exceeded: 'badge-danger' };
 
             c.innerHTML = `<div class="page-enter">
                 <div class="section-header"><h2>Storage Configuration</h2></div>
+                <!-- Maintenance Mode Card -->
+                <div class="card" style="margin-bottom:24px;border-left:3px solid ${maintenance.enabled ? 'var(--warning)' : 'var(--border)'}">
+                    <div style="display:flex;align-items:center;justify-content:space-between;flex-wrap:wrap;gap:12px">
+                        <div><div style="font-weight:700">🚧 Maintenance Mode</div><div style="font-size:0.85rem;color:var(--text-muted)">${maintenance.enabled ? 'On since ' + new Date(maintenance.started_at).toLocaleString() + (maintenance.started_by ? ' by ' + escapeHtml(maintenance.started_by) : '') + (maintenance.reason ? ' · ' + escapeHtml(maintenance.reason) : '') + '. Changes are refused; browsing still works.' : 'Off. Turn it on to refuse deletes, retention runs and storage changes, e.g. during garbage collection.'}</div></div>
+                        ${maintenance.enabled ? '<button class="btn btn-sm btn-success" onclick="window.app.setMaintenance(false)">End Maintenance</button>' : '<button class="btn btn-sm btn-ghost" onclick="window.app.setMaintenance(true)">Start Maintenance</button>'}
+                    </div>
+                </div>
+                ${quotas.length ? `<!-- Storage Quotas Card -->
+                <div class="card" style="margin-bottom:24px;border-left:3px solid ${quotas.some(q => q.state === 'exceeded') ? 'var(--danger)' : quotas.some(q => q.state === 'warning') ? 'var(--warning)' : 'var(--border)'}">
+                    <div style="font-weight:700;margin-bottom:12px">📦 Storage Quotas</div>
+                    <div class="image-list">${quotas.map(q => `<div class="image-item"><div class="image-item-info"><div><div class="image-item-name">${q.project_id ? 'Project ' + q.project_id : 'Registry ' + q.registry_id + ' · ' + escapeHtml(q.repository)} <span class="badge ${quotaBadge[q.state] || 'badge-info'}">${escapeHtml(q.state || 'unchecked')}</span>${q.block ? ' <span class="badge badge-info" title="Copies into these repositories are refused while the quota is exceeded">blocks copies</span>' : ''}</div><div class="image-item-meta">${formatBytes(q.used_bytes)} of ${formatBytes(q.limit_bytes)} (${Math.round(q.used_bytes * 100 / q.limit_bytes)}%)${q.checked_at ? ' · checked ' + new Date(q.checked_at).toLocaleString() : ''}${q.check_error ? ' · ' + escapeHtml(q.check_error) : ''}</div></div></div><button class="btn btn-sm btn-ghost" onclick="window.app.checkQuota(${q.id})">Check Now</button></div>`).join('')}</div>
+                </div>` : ''}
                 <!-- Registry Status Card -->
                 <div class="card" style="margin-bottom:24px;border-left:3px solid ${regStatus.running ? 'var(--success)' : 'var(--danger)'}">
                     <div style="display:flex;align-items:center;justify-content:space-between;flex-wrap:wrap;gap:12px">
                         <div><div style="font-weight:700">🐳 Embedded Registry</div><div style="font-size:0.85rem;color:var(--text-muted)">${regStatus.running ? 'Running at ' + escapeHtml(regStatus.url || '') : 'Stopped'}${regStatus.started_at ? ' · Started: ' + new Date(regStatus.started_at).toLocaleString() : ''}</div></div>
                         <div style="display:flex;gap:8px">
                             ${regStatus.running ? '<button class="btn btn-sm btn-ghost" onclick="window.app.embeddedAction(\'restart\')">🔄 Restart</button><button class="btn btn-sm btn-danger" onclick="window.app.embeddedAction(\'stop\')">Stop</button>' : '<button class="btn btn-sm btn-success" onclick="window.app.embeddedAction(\'start\')">▶ Start</button>'}
+                            <button class="btn btn-sm btn-ghost" onclick="window.app.editRegistrySettings()">🚚 Port & Name</button>
                             <button class="btn btn-sm btn-ghost" onclick="window.app.showRegistryLogs()">📋 Logs</button>
                         </div>
                     </div>
                 </div>
+                <div class="card" style="max-width:700px;margin-bottom:24px">
+                    <div style="font-weight:700;margin-bottom:4px">🔑 Registry Users</div>
+                    <div style="font-size:0.85rem;color:var(--text-muted);margin-bottom:12px">${regUsers.length ? (regStatus.token_realm ? 'Pushes and pulls need one of these accounts; each may only use the repositories its permissions allow (token auth).' : 'Pushes and pulls need one of these accounts (htpasswd).') : 'No users: the registry accepts anonymous pushes and pulls. Adding a user turns authentication on.'}</div>
+                    ${regUsers.length ? `<div class="image-list" style="margin-bottom:12px">${regUsers.map(u => `<div class="image-item"><div class="image-item-info"><div><div class="image-item-name">${escapeHtml(u.username)}</div><div class="image-item-meta">${u.managed ? 'Used by the dashboard for the Local Registry entry' : 'Created ' + new Date(u.created_at).toLocaleString()}${regStatus.token_realm && !u.managed ? ' · ' + (u.permissions.length ? u.permissions.map(p => escapeHtml(p.repository) + ': ' + escapeHtml(p.actions)).join('; ') : 'no access') : ''}</div></div></div><div class="image-item-right">${u.managed ? '<span class="badge badge-info">dashboard</span>' : `${regStatus.token_realm ? `<button class="btn btn-sm btn-ghost" onclick="window.app.editRegistryPermissions(${u.id})">Permissions</button>` : ''}<button class="btn btn-sm btn-danger" onclick="window.app.deleteRegistryUser(${u.id},'${escapeHtml(u.username)}')">Remove</button>`}</div></div>`).join('')}</div>` : ''}
+                    <form class="form-row" onsubmit="event.preventDefault();window.app.addRegistryUser()"><div class="form-group"><input type="text" id="registry-user-name" class="form-input" placeholder="Username" autocomplete="off"></div><div class="form-group"><input type="password" id="registry-user-password" class="form-input" placeholder="Password (min. 8 characters)" autocomplete="new-password"></div><div class="form-group"><button type="submit" class="btn btn-primary">Add User</button></div></form>
+                </div>
+                <div class="card" style="max-width:700px;margin-bottom:24px">
+                    <div style="font-weight:700;margin-bottom:4px">🗂️ Additional Registries</div>
+                    <div style="font-size:0.85rem;color:var(--text-muted);margin-bottom:12px">More embedded registries on their own ports, storage and account, e.g. a scratch registry for development next to one for promoted images. Each is added to the registry list.</div>
+                    ${instances.length ? `<div class="image-list" style="margin-bottom:12px">${instances.map(i => `<div class="image-item"><div class="image-item-info"><div><div class="image-item-name">${escapeHtml(i.name)}</div><div class="image-item-meta">Port ${i.port} · ${escapeHtml(i.storage.type)} storage · ${i.username ? 'account ' + escapeHtml(i.username) : 'anonymous'}${i.status && i.status.running ? ' · Running at ' + escapeHtml(i.status.url) : ' · Stopped'}</div></div></div><div class="image-item-right">${i.status && i.status.running ? `<button class="btn btn-sm btn-ghost" onclick="window.app.registryInstanceAction(${i.id},'stop')">Stop</button>` : `<button class="btn btn-sm btn-success" onclick="window.app.registryInstanceAction(${i.id},'start')">▶ Start</button>`}<button class="btn btn-sm btn-danger" onclick="window.app.deleteRegistryInstance(${i.id},'${escapeHtml(i.name)}')">Remove</button></div></div>`).join('')}</div>` : ''}
+                    <form onsubmit="event.preventDefault();window.app.addRegistryInstance()"><div class="form-row"><div class="form-group"><input type="text" id="instance-name" class="form-input" placeholder="Name, e.g. dev" autocomplete="off"></div><div class="form-group"><input type="number" id="instance-port" class="form-input" placeholder="Port, e.g. 5001" min="1" max="65535"></div><div class="form-group"><input type="text" id="instance-path" class="form-input" placeholder="Storage path (optional)"></div></div><div class="form-row"><div class="form-group"><input type="text" id="instance-username" class="form-input" placeholder="Username (optional)" autocomplete="off"></div><div class="form-group"><input type="password" id="instance-password" class="form-input" placeholder="Password (min. 8 characters)" autocomplete="new-password"></div><div class="form-group"><button type="submit" class="btn btn-primary">Add Registry</button></div></div></form>
+                </div>
                 <div class="card" style="max-width:700px">
                     <div class="storage-tabs">
                         <button class="storage-tab ${cfg.type === 'local' ? 'active' : ''}" data-tab="local" onclick="window.app.switchStorageTab('local')">📁 Local</button>
                         <button class="storage-tab ${cfg.type === 's3' ? 'active' : ''}" data-tab="s3" onclick="window.app.switchStorageTab('s3')">☁️ Object Storage</button>
+                        <button class="storage-tab ${cfg.type === 'gcs' ? 'active' : ''}" data-tab="gcs" onclick="window.app.switchStorageTab('gcs')">🪣 Google Cloud Storage</button>
+                        <button class="storage-tab ${cfg.type === 'swift' ? 'active' : ''}" data-tab="swift" onclick="window.app.switchStorageTab('swift')">🗄️ Swift</button>
                         <button class="storage-tab ${cfg.type === 'sftp' ? 'active' : ''}" data-tab="sftp" onclick="window.app.switchStorageTab('sftp')">🔐 SFTP</button>
                     </div>
                     <form id="storage-form" onsubmit="event.preventDefault();window.app.saveStorage()">
@@ -244,15 +335,37 @@
                             <div class="form-row"><div class="form-group"><label class="form-label">Access Key</label><input type="text" id="s3-access-key" class="form-input" value="${escapeHtml(cfg.s3_access_key || '')}"></div><div class="form-group"><label class="form-label">Secret Key</label><input type="password" id="s3-secret-key" class="form-input" value="${escapeHtml(cfg.s3_secret_key || '')}"></div></div>
                             <div class="form-group"><label class="form-check"><input type="checkbox" id="s3-use-ssl" ${cfg.s3_use_ssl ? 'checked' : ''}><span class="form-check-label">Use SSL/TLS</span></label></div>
                         </div>
+                        <div id="storage-gcs" class="storage-form-section ${cfg.type === 'gcs' ? 'active' : ''}">
+                            <div class="form-row"><div class="form-group"><label class="form-label">Bucket</label><input type="text" id="gcs-bucket" class="form-input" value="${escapeHtml(cfg.gcs_bucket || '')}" placeholder="my-registry-bucket"></div><div class="form-group"><label class="form-label">Root Directory (optional)</label><input type="text" id="gcs-root-directory" class="form-input" value="${escapeHtml(cfg.gcs_root_directory || '')}" placeholder="/registry"></div></div>
+                            <div class="form-group"><label class="form-label">Service Account Key (optional)</label><textarea id="gcs-credentials" class="form-textarea" placeholder='{"type": "service_account", ...}'>${escapeHtml(cfg.gcs_credentials || '')}</textarea><div class="form-hint">Without a key the registry uses the credentials of the machine it runs on</div></div>
+                        </div>
+                        <div id="storage-swift" class="storage-form-section ${cfg.type === 'swift' ? 'active' : ''}">
+                            <div class="form-row"><div class="form-group"><label class="form-label">Auth URL</label><input type="text" id="swift-auth-url" class="form-input" value="${escapeHtml(cfg.swift_auth_url || '')}" placeholder="https://keystone.example.com/v3"></div><div class="form-group"><label class="form-label">Region</label><input type="text" id="swift-region" class="form-input" value="${escapeHtml(cfg.swift_region || '')}" placeholder="RegionOne"></div></div>
+                            <div class="form-group"><label class="form-label">Container</label><input type="text" id="swift-container" class="form-input" value="${escapeHtml(cfg.swift_container || '')}" placeholder="registry"></div>
+                            <div class="form-row"><div class="form-group"><label class="form-label">Username</label><input type="text" id="swift-username" class="form-input" value="${escapeHtml(cfg.swift_username || '')}"></div><div class="form-group"><label class="form-label">Password</label><input type="password" id="swift-password" class="form-input" value="${escapeHtml(cfg.swift_password || '')}"></div></div>
+                        </div>
                         <div id="storage-sftp" class="storage-form-section ${cfg.type === 'sftp' ? 'active' : ''}">
                             <div class="form-row"><div class="form-group"><label class="form-label">Host</label><input type="text" id="sftp-host" class="form-input" value="${escapeHtml(cfg.sftp_host || '')}" placeholder="sftp.example.com"></div><div class="form-group"><label class="form-label">Port</label><input type="number" id="sftp-port" class="form-input" value="${cfg.sftp_port || 22}"></div></div>
                             <div class="form-row"><div class="form-group"><label class="form-label">Username</label><input type="text" id="sftp-user" class="form-input" value="${escapeHtml(cfg.sftp_user || '')}"></div><div class="form-group"><label class="form-label">Password</label><input type="password" id="sftp-password" class="form-input" value="${escapeHtml(cfg.sftp_password || '')}"></div></div>
                             <div class="form-group"><label class="form-label">Remote Path</label><input type="text" id="sftp-path" class="form-input" value="${escapeHtml(cfg.sftp_path || '')}" placeholder="/data/registry"></div>
                             <div class="form-group"><label class="form-label">Private Key (optional)</label><textarea id="sftp-key" class="form-textarea" placeholder="SSH private key...">${escapeHtml(cfg.sftp_private_key || '')}</textarea></div>
                         </div>
+                        <div style="border-top:1px solid var(--border-color);margin:16px 0;padding-top:16px">
+                            <div class="form-group"><label class="form-label">Pull-through Cache Upstream (optional)</label><input type="text" id="proxy-remote-url" class="form-input" value="${escapeHtml(cfg.proxy_remote_url || '')}" placeholder="https://registry-1.docker.io"><div class="form-hint">Mirror this registry; pulls are cached locally and pushes are rejected</div></div>
+                            <div class="form-row"><div class="form-group"><label class="form-label">Upstream Username</label><input type="text" id="proxy-username" class="form-input" value="${escapeHtml(cfg.proxy_username || '')}"></div><div class="form-group"><label class="form-label">Upstream Password</label><input type="password" id="proxy-password" class="form-input" value="${escapeHtml(cfg.proxy_password || '')}"></div></div>
+                        </div>
+                        <div style="border-top:1px solid var(--border-color);margin:16px 0;padding-top:16px">
+                            <div class="form-group"><label class="form-check"><input type="checkbox" id="redis-cache" ${cfg.redis_cache ? 'checked' : ''}><span class="form-check-label">Redis blob-descriptor cache</span></label><div class="form-hint">Speeds up busy registries; without an address a Redis container is started next to the registry</div></div>
+                            <div class="form-row"><div class="form-group"><label class="form-label">Redis Address (optional)</label><input type="text" id="redis-addr" class="form-input" value="${escapeHtml(cfg.redis_addr || '')}" placeholder="redis.internal:6379"></div><div class="form-group"><label class="form-label">Redis Password</label><input type="password" id="redis-password" class="form-input" value="${escapeHtml(cfg.redis_password || '')}"></div><div class="form-group"><label class="form-label">Redis DB</label><input type="number" id="redis-db" class="form-input" value="${cfg.redis_db || 0}" min="0" max="15"></div></div>
+                        </div>
+                        <div style="border-top:1px solid var(--border-color);margin:16px 0;padding-top:16px">
+                            <div class="form-group"><label class="form-label">Registry Image</label><input type="text" id="registry-image" class="form-input" value="${escapeHtml(cfg.registry_image || '')}" placeholder="registry:2"><div class="form-hint">Image of the registry container, e.g. registry:2.8.3 or ghcr.io/distribution/distribution:3</div></div>
+                            <div class="form-group"><label class="form-label">Advanced: config.yml Overrides (optional)</label><textarea id="config-overrides" class="form-textarea" rows="5" spellcheck="false" style="font-family:monospace" placeholder="validation:\n  manifests:\n    urls:\n      allow:\n        - ^https?://([^/]+\\.)*example\\.com/">${escapeHtml(cfg.config_overrides || '')}</textarea><div class="form-hint">YAML deep-merged into the generated config.yml, e.g. validation rules or middleware; check the result with Preview</div></div>
+                        </div>
                         <div style="display:flex;gap:12px;margin-top:8px">
                             <button type="submit" class="btn btn-primary"><svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M19 21H5a2 2 0 0 1-2-2V5a2 2 0 0 1 2-2h11l5 5v11a2 2 0 0 1-2 2z"/><polyline points="17 21 17 13 7 13 7 21"/><polyline points="7 3 7 8 15 8"/></svg> Save & Apply</button>
                             <button type="button" class="btn btn-ghost" onclick="window.app.testStorage()"><svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M22 11.08V12a10 10 0 1 1-5.93-9.14"/><polyline points="22 4 12 14.01 9 11.01"/></svg> Test</button>
+                            <button type="button" class="btn btn-ghost" onclick="window.app.previewStorageConfig()"><svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M1 12s4-8 11-8 11 8 11 8-4 8-11 8-11-8-11-8z"/><circle cx="12" cy="12" r="3"/></svg> Preview</button>
                         </div>
                     </form>
                 </div></div>`;
@@ -349,7 +462,7 @@
 
             c.innerHTML = `
                 <div class="page-enter">
-                    <div class="section-header"><h2>📋 Vulnerability Report</h2></div>
+                    <div class="section-header"><h2>📋 Vulnerability Report</h2><div style="display:flex;gap:8px"><button class="btn btn-sm btn-ghost" title="Download a report of the selected registry for auditors" onclick="window.app.exportScanReport('html')">📄 HTML</button><button class="btn btn-sm btn-ghost" title="Download a report of the selected registry for auditors" onclick="window.app.exportScanReport('pdf')">📄 PDF</button></div></div>
                     <div class="card" style="margin-bottom:24px;padding:20px">
                         <div style="display:flex;gap:16px;align-items:flex-end;flex-wrap:wrap">
                             <div style="flex:1;min-width:200px">
@@ -438,6 +551,17 @@
                                 <div class="form-hint">Keep images pushed within the last N days (0 to disable)</div>
                             </div>
                             
+                            <div class="form-group">
+                                <label class="form-label">Max Repository Size (GB)</label>
+                                <input type="number" id="max-repo-size" class="form-input" value="${p.max_repo_size_gb || 0}" min="0" step="0.1">
+                                <div class="form-hint">Delete the oldest images until each repository is under this size (0 to disable)</div>
+                            </div>
+                            <div class="form-group">
+                                <label class="form-label">Tag Pattern Rules</label>
+                                <textarea id="tag-rules" class="form-input" rows="3" placeholder="^release-.* = 10&#10;^pr-\\d+$ = 3&#10;^nightly- = 0">${escapeHtml((p.rules || []).map(r => `${r.tag_pattern} = ${r.keep_count}${r.keep_days ? `, ${r.keep_days}d` : ''}`).join('\n'))}</textarea>
+                                <div class="form-hint">One <code>regex = keep</code> (or <code>regex = keep, 30d</code>) per line, checked in order per repository. Tags matching no rule use the limits above.</div>
+                            </div>
+
                             <hr style="border:0;border-top:1px solid var(--border);margin:20px 0;">
                             <h4>Filters & Whitelists (Regex)</h4>
                             
@@ -480,10 +604,17 @@
             const data = {
                 keep_last_count: parseInt(document.getElementById('keep-last').value) || 0,
                 keep_days: parseInt(document.getElementById('keep-days').value) || 0,
+                max_repo_size_gb: parseFloat(document.getElementById('max-repo-size').value) || 0,
                 dry_run: document.getElementById('retention-dry-run').checked,
                 filter_repos: document.getElementById('filter-repos').value,
                 exclude_repos: document.getElementById('exclude-repos').value,
-                exclude_tags: document.getElementById('exclude-tags').value
+                exclude_tags: document.getElementById('exclude-tags').value,
+                rules: document.getElementById('tag-rules').value.split('\n').map(l => l.trim()).filter(Boolean).map(l => {
+                    const i = l.lastIndexOf('=');
+                    if (i < 0) return { tag_pattern: l, keep_count: 0 };
+                    const [keep, days] = l.slice(i + 1).split(',');
+                    return { tag_pattern: l.slice(0, i).trim(), keep_count: parseInt(keep) || 0, keep_days: parseInt(days) || 0 };
+                })
             };
             try { await API.saveRetention(id, data); Toast.success('Policy saved!'); } catch (e) { Toast.error(e.message); }
         },
@@ -548,7 +679,7 @@
             document.querySelectorAll('.nav-item').forEach(i => i.classList.toggle('active', i.dataset.page === page));
             const titles = { dashboard: 'Dashboard', registries: 'Registries', images: 'Images', retention: 'Image Retention', scan: 'Vulnerability Scanner', 'vuln-report': 'Vulnerability Report', storage: 'Storage Settings' };
             document.getElementById('page-title').textContent = titles[page] || 'Dashboard';
-            ({
+            return ({
                 dashboard: renderDashboard,
                 registries: renderRegistries,
                 images: renderImages,
@@ -697,7 +828,7 @@
                                 </div>`;
 
                             if (s) {
-                                if (s.status === 'scanning' || s.status === 'pending') {
+                                if (s.status === 'scanning' || s.status === 'pending' || s.status === 'interrupted') {
                                     statusHtml = '<span class="badge badge-warning" style="padding:6px 10px;font-size:0.8rem">Scanning...</span>';
                                     actionHtml = `<button class="btn btn-sm btn-ghost" disabled style="opacity:0.7">Please Wait...</button>`;
                                     setTimeout(() => window.app.refreshScanStatus(regId, repo, t.name), 4000);
@@ -1239,37 +1370,57 @@
 
         // Registry CRUD
         showAddRegistry() {
-            Modal.open('Add Registry', `<form id="add-registry-form" onsubmit="event.preventDefault();window.app.addRegistry()"><div class="form-group"><label class="form-label">Name</label><input type="text" id="reg-name" class="form-input" placeholder="My Registry" required></div><div class="form-group"><label class="form-label">URL</label><input type="text" id="reg-url" class="form-input" placeholder="https://registry.example.com" required><div class="form-hint">Full URL with protocol</div></div><div class="form-row"><div class="form-group"><label class="form-label">Username</label><input type="text" id="reg-username" class="form-input"></div><div class="form-group"><label class="form-label">Password</label><input type="password" id="reg-password" class="form-input"></div></div><div class="form-group"><label class="form-check"><input type="checkbox" id="reg-insecure"><span class="form-check-label">Allow insecure connection</span></label></div><div style="display:flex;gap:12px;justify-content:flex-end"><button type="button" class="btn btn-ghost" onclick="Modal.close()">Cancel</button><button type="submit" class="btn btn-primary">Add Registry</button></div></form>`);
+            Modal.open('Add Registry', `<form id="add-registry-form" onsubmit="event.preventDefault();window.app.addRegistry()"><div class="form-group"><label class="form-label">Name</label><input type="text" id="reg-name" class="form-input" placeholder="My Registry" required></div><div class="form-group"><label class="form-label">URL</label><input type="text" id="reg-url" class="form-input" placeholder="https://registry.example.com" required><div class="form-hint">Full URL with protocol</div></div><div class="form-row"><div class="form-group"><label class="form-label">Type</label><select id="reg-type" class="form-input"><option value="">Docker Registry</option><option value="ecr">AWS ECR</option><option value="gcp">Google Artifact Registry / GCR</option><option value="acr">Azure Container Registry</option><option value="quay">Quay</option><option value="ghcr">GitHub Container Registry</option></select></div><div class="form-group"><label class="form-label">AWS Region</label><input type="text" id="reg-region" class="form-input" placeholder="From the ECR URL"></div><div class="form-group"><label class="form-label">Azure Tenant</label><input type="text" id="reg-tenant" class="form-input" placeholder="Tenant ID"></div><div class="form-group"><label class="form-label">Namespaces (Quay / GHCR)</label><input type="text" id="reg-namespaces" class="form-input" placeholder="org1, org2"></div></div><div class="form-hint">For ECR, Username and Password are an IAM access key ID and secret; for Google, Password is a service account JSON key; for Azure, they are a service principal's client ID and secret; for Quay, Password is an OAuth token, and for GHCR a personal access token. Leave them empty to use the dashboard's own cloud identity</div><div class="form-row"><div class="form-group"><label class="form-label">Username</label><input type="text" id="reg-username" class="form-input"></div><div class="form-group"><label class="form-label">Password</label><input type="password" id="reg-password" class="form-input"></div></div><div class="form-group"><label class="form-label">Proxy</label><input type="text" id="reg-proxy" class="form-input" value="" placeholder="http://proxy.corp:3128"><div class="form-hint">Empty: HTTPS_PROXY / HTTP_PROXY / NO_PROXY of the dashboard</div></div><div class="form-group"><label class="form-label">CA Certificate (PEM)</label><textarea id="reg-ca-cert" class="form-textarea" rows="3" placeholder="-----BEGIN CERTIFICATE-----"></textarea><input type="file" accept=".pem,.crt,.cer" class="form-input" onchange="window.app.loadCACert(this, 'reg-ca-cert')"><div class="form-hint">Internal CA the registry's certificate is issued by, trusted besides the system CAs</div></div><div class="form-group"><label class="form-check"><input type="checkbox" id="reg-insecure"><span class="form-check-label">Allow insecure connection</span></label></div><div style="display:flex;gap:12px;justify-content:flex-end"><button type="button" class="btn btn-ghost" onclick="Modal.close()">Cancel</button><button type="submit" class="btn btn-primary">Add Registry</button></div></form>`);
         },
         async addRegistry() {
-            try { await API.createRegistry({ name: document.getElementById('reg-name').value, url: document.getElementById('reg-url').value, username: document.getElementById('reg-username').value, password: document.getElementById('reg-password').value, insecure: document.getElementById('reg-insecure').checked }); Modal.close(); Toast.success('Registry added!'); this.navigate(this.currentPage); } catch (e) { Toast.error(e.message); }
+            try { await API.createRegistry({ name: document.getElementById('reg-name').value, url: document.getElementById('reg-url').value, username: document.getElementById('reg-username').value, password: document.getElementById('reg-password').value, type: document.getElementById('reg-type').value, region: document.getElementById('reg-region').value.trim(), tenant: document.getElementById('reg-tenant').value.trim(), namespaces: document.getElementById('reg-namespaces').value.trim(), ca_cert: document.getElementById('reg-ca-cert').value.trim(), proxy: document.getElementById('reg-proxy').value.trim(), insecure: document.getElementById('reg-insecure').checked }); Modal.close(); Toast.success('Registry added!'); this.navigate(this.currentPage); } catch (e) { Toast.error(e.message); }
         },
         async showEditRegistry(id) {
             try {
                 const res = await API.getRegistries(); const r = (res.data || []).find(x => x.id === id); if (!r) return Toast.error('Not found');
-                Modal.open('Edit Registry', `<form onsubmit="event.preventDefault();window.app.updateRegistry(${id})"><div class="form-group"><label class="form-label">Name</label><input type="text" id="edit-reg-name" class="form-input" value="${escapeHtml(r.name)}" required></div><div class="form-group"><label class="form-label">URL</label><input type="text" id="edit-reg-url" class="form-input" value="${escapeHtml(r.url)}" required></div><div class="form-row"><div class="form-group"><label class="form-label">Username</label><input type="text" id="edit-reg-username" class="form-input" value="${escapeHtml(r.username || '')}"></div><div class="form-group"><label class="form-label">Password</label><input type="password" id="edit-reg-password" class="form-input" value="${escapeHtml(r.password || '')}"></div></div><div class="form-group"><label class="form-check"><input type="checkbox" id="edit-reg-insecure" ${r.insecure ? 'checked' : ''}><span class="form-check-label">Allow insecure</span></label></div><div style="display:flex;gap:12px;justify-content:flex-end"><button type="button" class="btn btn-ghost" onclick="Modal.close()">Cancel</button><button type="submit" class="btn btn-primary">Save</button></div></form>`);
+                Modal.open('Edit Registry', `<form onsubmit="event.preventDefault();window.app.updateRegistry(${id})"><div class="form-group"><label class="form-label">Name</label><input type="text" id="edit-reg-name" class="form-input" value="${escapeHtml(r.name)}" required></div><div class="form-group"><label class="form-label">URL</label><input type="text" id="edit-reg-url" class="form-input" value="${escapeHtml(r.url)}" required></div><div class="form-row"><div class="form-group"><label class="form-label">Type</label><select id="edit-reg-type" class="form-input"><option value="">Docker Registry</option><option value="ecr" ${r.type === 'ecr' ? 'selected' : ''}>AWS ECR</option><option value="gcp" ${r.type === 'gcp' ? 'selected' : ''}>Google Artifact Registry / GCR</option><option value="acr" ${r.type === 'acr' ? 'selected' : ''}>Azure Container Registry</option><option value="quay" ${r.type === 'quay' ? 'selected' : ''}>Quay</option><option value="ghcr" ${r.type === 'ghcr' ? 'selected' : ''}>GitHub Container Registry</option></select></div><div class="form-group"><label class="form-label">AWS Region</label><input type="text" id="edit-reg-region" class="form-input" value="${escapeHtml(r.region || '')}" placeholder="From the ECR URL"></div><div class="form-group"><label class="form-label">Azure Tenant</label><input type="text" id="edit-reg-tenant" class="form-input" value="${escapeHtml(r.tenant || '')}" placeholder="Tenant ID"></div><div class="form-group"><label class="form-label">Namespaces (Quay / GHCR)</label><input type="text" id="edit-reg-namespaces" class="form-input" value="${escapeHtml(r.namespaces || '')}" placeholder="org1, org2"></div></div><div class="form-row"><div class="form-group"><label class="form-label">Username</label><input type="text" id="edit-reg-username" class="form-input" value="${escapeHtml(r.username || '')}"></div><div class="form-group"><label class="form-label">Password</label><input type="password" id="edit-reg-password" class="form-input" value="${escapeHtml(r.password || '')}"></div></div><div class="form-group"><label class="form-label">Proxy</label><input type="text" id="edit-reg-proxy" class="form-input" value="${escapeHtml(r.proxy || '')}" placeholder="http://proxy.corp:3128"><div class="form-hint">Empty: HTTPS_PROXY / HTTP_PROXY / NO_PROXY of the dashboard</div></div><div class="form-group"><label class="form-label">CA Certificate (PEM)</label><textarea id="edit-reg-ca-cert" class="form-textarea" rows="3" placeholder="-----BEGIN CERTIFICATE-----">${escapeHtml(r.ca_cert || '')}</textarea><input type="file" accept=".pem,.crt,.cer" class="form-input" onchange="window.app.loadCACert(this, 'edit-reg-ca-cert')"><div class="form-hint">Internal CA the registry's certificate is issued by, trusted besides the system CAs</div></div><div class="form-group"><label class="form-check"><input type="checkbox" id="edit-reg-insecure" ${r.insecure ? 'checked' : ''}><span class="form-check-label">Allow insecure</span></label></div><div style="display:flex;gap:12px;justify-content:flex-end"><button type="button" class="btn btn-ghost" onclick="Modal.close()">Cancel</button><button type="submit" class="btn btn-primary">Save</button></div></form>`);
             } catch (e) { Toast.error(e.message); }
         },
-        async updateRegistry(id) { try { await API.updateRegistry(id, { name: document.getElementById('edit-reg-name').value, url: document.getElementById('edit-reg-url').value, username: document.getElementById('edit-reg-username').value, password: document.getElementById('edit-reg-password').value, insecure: document.getElementById('edit-reg-insecure').checked }); Modal.close(); Toast.success('Updated!'); renderRegistries(); } catch (e) { Toast.error(e.message); } },
+        loadCACert(input, targetId) { const file = input.files[0]; if (!file) return; const reader = new FileReader(); reader.onload = () => { document.getElementById(targetId).value = reader.result; }; reader.readAsText(file); },
+        async updateRegistry(id) { try { await API.updateRegistry(id, { name: document.getElementById('edit-reg-name').value, url: document.getElementById('edit-reg-url').value, username: document.getElementById('edit-reg-username').value, password: document.getElementById('edit-reg-password').value, type: document.getElementById('edit-reg-type').value, region: document.getElementById('edit-reg-region').value.trim(), tenant: document.getElementById('edit-reg-tenant').value.trim(), namespaces: document.getElementById('edit-reg-namespaces').value.trim(), ca_cert: document.getElementById('edit-reg-ca-cert').value.trim(), proxy: document.getElementById('edit-reg-proxy').value.trim(), insecure: document.getElementById('edit-reg-insecure').checked }); Modal.close(); Toast.success('Updated!'); renderRegistries(); } catch (e) { Toast.error(e.message); } },
         async deleteRegistry(id, name) { if (!(await Confirm.show('Delete', 'Delete "' + name + '"?'))) return; try { await API.deleteRegistry(id); Toast.success('Deleted!'); renderRegistries(); } catch (e) { Toast.error(e.message); } },
         async testRegistry(id) { Toast.info('Testing...'); try { const r = await API.testRegistry(id); Toast.success('Connected! ' + r.data.latency_ms + 'ms'); } catch (e) { Toast.error(e.message); } },
+        async showConformance(id, probe = false) {
+            let p = null;
+            try {
+                if (probe) { Toast.info('Probing...'); p = (await API.probeConformance(id)).data; } else { p = (await API.getRegistryProfile(id)).data; }
+            } catch (e) { if (probe) return Toast.error(e.message); }
+            const badge = { pass: 'badge-success', fail: 'badge-danger', skip: 'badge-warning' };
+            const body = p ? `<div style="font-size:0.85rem;color:var(--text-muted);margin-bottom:12px">API ${escapeHtml(p.api_version || 'unknown')}${p.harbor ? ' · Harbor ' + escapeHtml(p.harbor.version || '') + ` <a href="#" onclick="event.preventDefault();window.app.showHarborProjects(${id})">projects &amp; quotas</a>` : ''} · probed ${new Date(p.probed_at).toLocaleString()}</div><table class="data-table"><thead><tr><th>Check</th><th>Result</th><th>Detail</th></tr></thead><tbody>${p.checks.map(c => `<tr><td>${escapeHtml(c.name)}</td><td><span class="badge ${badge[c.status] || ''}">${escapeHtml(c.status)}</span></td><td style="font-size:0.85rem">${escapeHtml(c.detail || '')}</td></tr>`).join('')}</tbody></table>` : showEmpty('🧪', 'Not probed yet', 'Run the conformance probe to learn which OCI distribution features this registry supports.');
+            Modal.open('Registry Conformance', `${body}<div style="display:flex;gap:12px;justify-content:flex-end;margin-top:16px"><button type="button" class="btn btn-ghost" onclick="Modal.close()">Close</button><button type="button" class="btn btn-primary" onclick="window.app.showConformance(${id}, true)">Run Probe</button></div>`);
+        },
+
+        async showHarborProjects(id) {
+            try {
+                const projects = (await API.harborProjects(id)).data || [];
+                const quota = q => !q ? '—' : formatBytes(q.used_bytes) + ' / ' + (q.hard_bytes < 0 ? '∞' : formatBytes(q.hard_bytes));
+                Modal.open('Harbor Projects', `<table class="data-table"><thead><tr><th>Project</th><th>Access</th><th>Repositories</th><th>Storage used / quota</th></tr></thead><tbody>${projects.map(p => `<tr><td>${escapeHtml(p.name)}</td><td>${p.public ? 'public' : 'private'}</td><td>${p.repo_count}</td><td>${quota(p.quota)}</td></tr>`).join('')}</tbody></table><div style="display:flex;justify-content:flex-end;margin-top:16px"><button type="button" class="btn btn-ghost" onclick="window.app.showConformance(${id})">Back</button></div>`);
+            } catch (e) { Toast.error(e.message); }
+        },
 
         // Image/Tag browsing
         viewRegistryImages(id) { this._selectedRegistry = id; this.navigate('images'); },
-        async loadImages(regId) {
+        async loadImages(regId, page = 1, q = '') {
             if (!regId) return; this._selectedRegistry = regId;
             const d = document.getElementById('images-content'); if (!d) return; d.innerHTML = showLoading();
             try {
-                const res = await API.getRepositories(regId); const repos = res.data || [];
+                const res = await API.getRepositories(regId, { page, page_size: 50, q }); const repos = res.data || []; const pg = res.pagination || { page: 1, total_pages: 1, total: repos.length };
+                const search = `<div style="display:flex;gap:8px;align-items:center;margin-bottom:12px"><input class="form-input" id="repo-search" placeholder="Filter repositories..." value="${escapeHtml(q)}" onkeydown="if(event.key==='Enter')window.app.loadImages(${regId},1,this.value)"><button class="btn btn-sm btn-ghost" ${pg.page <= 1 ? 'disabled' : ''} onclick="window.app.loadImages(${regId},${pg.page - 1},document.getElementById('repo-search').value)">‹ Prev</button><span style="white-space:nowrap;font-size:0.85rem;color:var(--text-muted)">Page ${pg.page} / ${Math.max(pg.total_pages, 1)}</span><button class="btn btn-sm btn-ghost" ${pg.page >= pg.total_pages ? 'disabled' : ''} onclick="window.app.loadImages(${regId},${pg.page + 1},document.getElementById('repo-search').value)">Next ›</button></div>`;
+                if (!repos.length && q) { d.innerHTML = search + showEmpty('🔍', 'No matches', 'No repository matches this filter.'); return; }
                 if (!repos.length) { d.innerHTML = showEmpty('<svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M22 19a2 2 0 0 1-2 2H4a2 2 0 0 1-2-2V5a2 2 0 0 1 2-2h5l2 3h9a2 2 0 0 1 2 2z"/></svg>', 'No images', 'This registry has no images yet.'); return; }
-                d.innerHTML = `<div class="section-header"><h2>Repositories (${repos.length})</h2></div><div class="image-list">${repos.map((r, i) => `<div class="image-item" style="animation-delay:${i * 0.04}s" onclick="window.app.viewTags(${regId},'${escapeHtml(r.name)}')"><div class="image-item-info"><div class="image-item-icon"><svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M22 19a2 2 0 0 1-2 2H4a2 2 0 0 1-2-2V5a2 2 0 0 1 2-2h5l2 3h9a2 2 0 0 1 2 2z"/></svg></div><div><div class="image-item-name">${escapeHtml(r.name)}</div><div class="image-item-meta">${r.tag_count || 0} tags</div></div></div><div class="image-item-right"><span class="badge badge-info">${r.tag_count || 0} tags</span><svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><polyline points="9 18 15 12 9 6"/></svg></div></div>`).join('')}</div>`;
+                d.innerHTML = `<div class="section-header"><h2>Repositories (${pg.total})</h2></div>${search}<div class="image-list">${repos.map((r, i) => `<div class="image-item" style="animation-delay:${i * 0.04}s" onclick="window.app.viewTags(${regId},'${escapeHtml(r.name)}')"><div class="image-item-info"><div class="image-item-icon"><svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M22 19a2 2 0 0 1-2 2H4a2 2 0 0 1-2-2V5a2 2 0 0 1 2-2h5l2 3h9a2 2 0 0 1 2 2z"/></svg></div><div><div class="image-item-name">${escapeHtml(r.name)}</div><div class="image-item-meta">${r.tag_count || 0} tags${r.description ? ' · ' + escapeHtml(r.description) : ''}</div></div></div><div class="image-item-right"><span class="badge badge-info">${r.tag_count || 0} tags</span><svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><polyline points="9 18 15 12 9 6"/></svg></div></div>`).join('')}</div>`;
             } catch (e) { d.innerHTML = showEmpty('<svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><circle cx="12" cy="12" r="10"/></svg>', 'Error', e.message); }
         },
         async viewTags(regId, repo) {
             const d = document.getElementById('images-content'); if (!d) return; d.innerHTML = showLoading();
             try {
                 const res = await API.getTags(regId, repo); const tags = res.data || [];
-                d.innerHTML = `<div class="tags-header"><button class="back-btn" onclick="window.app.loadImages(${regId})"><svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><line x1="19" y1="12" x2="5" y2="12"/><polyline points="12 19 5 12 12 5"/></svg> Back</button></div><div class="section-header"><h2><span style="color:var(--text-muted)">Tags for</span> ${escapeHtml(repo)} <span class="badge badge-info" style="margin-left:8px;font-size:0.7rem">${tags.length}</span></h2></div><div id="tags-list">${tags.map((t, i) => `<div class="tag-item" style="animation-delay:${i * 0.04}s"><div class="tag-item-info"><div class="tag-icon"><svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M20.59 13.41l-7.17 7.17a2 2 0 0 1-2.83 0L2 12V2h10l8.59 8.59a2 2 0 0 1 0 2.82z"/><line x1="7" y1="7" x2="7.01" y2="7"/></svg></div><div><div class="tag-name">${escapeHtml(t.name)}</div>${t.digest ? '<div class="tag-digest">' + truncateDigest(t.digest) + '</div>' : ''}</div></div><div class="tag-actions"><button class="btn btn-sm btn-ghost" onclick="window.app.viewManifest(${regId},'${escapeHtml(repo)}','${escapeHtml(t.name)}')">🔍 Inspect</button><button class="btn btn-sm btn-danger" onclick="window.app.deleteImageTag(${regId},'${escapeHtml(repo)}','${escapeHtml(t.name)}')">Delete</button></div></div>`).join('')}</div>`;
+                d.innerHTML = `<div class="tags-header"><button class="back-btn" onclick="window.app.loadImages(${regId})"><svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><line x1="19" y1="12" x2="5" y2="12"/><polyline points="12 19 5 12 12 5"/></svg> Back</button></div><div class="section-header"><h2><span style="color:var(--text-muted)">Tags for</span> ${escapeHtml(repo)} <span class="badge badge-info" style="margin-left:8px;font-size:0.7rem">${tags.length}</span></h2><a class="btn btn-sm btn-ghost" href="/api/v1/registries/${regId}/feed?repo=${encodeURIComponent(repo)}" target="_blank" title="Atom feed of new pushes (feed readers can append &token= with an API token)">📡 Feed</a><button class="btn btn-sm btn-ghost" title="Move every tag to a new repository name" onclick="window.app.renameRepository(${regId},'${escapeHtml(repo)}')">✏️ Rename</button><button class="btn btn-sm btn-ghost" title="Verify the Notation signatures of every tag against the trust policy" onclick="window.app.verifyTagSignatures(${regId},'${escapeHtml(repo)}')">✍️ Verify Signatures</button></div><div id="tags-list">${tags.map((t, i) => `<div class="tag-item" data-tag="${escapeHtml(t.name)}" style="animation-delay:${i * 0.04}s"><div class="tag-item-info"><div class="tag-icon"><svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><path d="M20.59 13.41l-7.17 7.17a2 2 0 0 1-2.83 0L2 12V2h10l8.59 8.59a2 2 0 0 1 0 2.82z"/><line x1="7" y1="7" x2="7.01" y2="7"/></svg></div><div><div class="tag-name">${escapeHtml(t.name)} <span class="notation-status"></span></div>${t.digest ? '<div class="tag-digest">' + truncateDigest(t.digest) + '</div>' : ''}<div class="tag-digest" title="${t.last_pulled_at ? 'Last pulled ' + new Date(t.last_pulled_at).toLocaleString() : 'Never pulled (since notifications were enabled)'}">⬇ ${t.pull_count || 0} pulls · ⬆ ${t.push_count || 0} pushes</div>${t.harbor ? '<div class="tag-digest">' + formatBytes(t.harbor.size) + (t.harbor.pushed_at ? ' · pushed ' + new Date(t.harbor.pushed_at).toLocaleString() : '') + ' ' + t.harbor.labels.map(l => '<span class="badge" style="background:' + escapeHtml(l.color || '#4a5568') + ';color:#fff" title="' + escapeHtml(l.description || '') + '">' + escapeHtml(l.name) + '</span>').join(' ') + '</div>' : ''}</div></div><div class="tag-actions"><button class="btn btn-sm btn-ghost" onclick="window.app.viewManifest(${regId},'${escapeHtml(repo)}','${escapeHtml(t.name)}')">🔍 Inspect</button><button class="btn btn-sm btn-ghost" title="Attestations and SLSA provenance: where and from which source the image was built" onclick="window.app.viewAttestations(${regId},'${escapeHtml(repo)}','${escapeHtml(t.name)}')">📜 Provenance</button><button class="btn btn-sm btn-ghost" title="Alert when this tag is moved to another digest" onclick="window.app.pinImageTag(${regId},'${escapeHtml(repo)}','${escapeHtml(t.name)}')">📌 Pin</button><button class="btn btn-sm btn-ghost" title="Sign the image with a cosign signing key" onclick="window.app.signImageTag(${regId},'${escapeHtml(repo)}','${escapeHtml(t.name)}')">🔏 Sign</button><button class="btn btn-sm btn-danger" onclick="window.app.deleteImageTag(${regId},'${escapeHtml(repo)}','${escapeHtml(t.name)}')">Delete</button></div></div>`).join('')}</div>`;
             } catch (e) { d.innerHTML = showEmpty('<svg viewBox="0 0 24 24" fill="none" stroke="currentColor" stroke-width="2"><circle cx="12" cy="12" r="10"/></svg>', 'Error', e.message); }
         },
         async viewManifest(regId, repo, tag) {
@@ -1278,6 +1429,46 @@
                 Modal.open(`${repo}:${tag}`, `<div class="manifest-viewer"><div class="manifest-section"><div class="manifest-section-title">General</div><div class="manifest-detail"><span class="manifest-detail-label">Schema</span><span class="manifest-detail-value">${m.schemaVersion}</span></div><div class="manifest-detail"><span class="manifest-detail-label">Media Type</span><span class="manifest-detail-value">${escapeHtml(m.mediaType || 'N/A')}</span></div><div class="manifest-detail"><span class="manifest-detail-label">Digest</span><span class="manifest-detail-value" title="${escapeHtml(m.digest || '')}">${truncateDigest(m.digest || '', 24)}</span></div><div class="manifest-detail"><span class="manifest-detail-label">Total Size</span><span class="manifest-detail-value">${formatBytes(m.totalSize)}</span></div></div>${m.config ? '<div class="manifest-section"><div class="manifest-section-title">Config</div><div class="manifest-detail"><span class="manifest-detail-label">Type</span><span class="manifest-detail-value">' + escapeHtml(m.config.mediaType) + '</span></div><div class="manifest-detail"><span class="manifest-detail-label">Size</span><span class="manifest-detail-value">' + formatBytes(m.config.size) + '</span></div></div>' : ''}${m.layers && m.layers.length ? '<div class="manifest-section"><div class="manifest-section-title">Layers (' + m.layers.length + ')</div>' + m.layers.map(l => '<div class="layer-item"><span class="layer-digest">' + truncateDigest(l.digest, 20) + '</span><span class="layer-size">' + formatBytes(l.size) + '</span></div>').join('') + '</div>' : ''}</div>`);
             } catch (e) { Toast.error(e.message); }
         },
+        async viewAttestations(regId, repo, tag) {
+            Toast.info('Loading attestations...'); try {
+                const a = (await API.getAttestations(regId, repo, tag)).data;
+                const badges = { verified: 'badge-success', signed: 'badge-info', unsigned: 'badge-warning', invalid: 'badge-danger' };
+                const row = (label, value) => value ? `<div class="manifest-detail"><span class="manifest-detail-label">${label}</span><span class="manifest-detail-value">${escapeHtml(value)}</span></div>` : '';
+                const body = a.attestations.length ? a.attestations.map(at => { const p = at.provenance || {}; return `<div class="manifest-section"><div class="manifest-section-title">${escapeHtml(at.predicate_type || 'unknown predicate')} <span class="badge ${badges[at.status] || 'badge-info'}">${escapeHtml(at.status)}</span></div>${row('Source', at.source + (at.platform ? ' (' + at.platform + ')' : ''))}${row('Signer', at.signer)}${(at.errors || []).map(e => '<div style="color:var(--danger)">' + escapeHtml(e) + '</div>').join('')}${row('Builder', p.builder_id)}${row('Build Type', p.build_type)}${row('Source Repo', p.source_repo)}${row('Ref', p.source_ref)}${row('Commit', p.commit)}${row('Entrypoint', p.entrypoint)}${row('Built', p.finished_on ? new Date(p.finished_on).toLocaleString() : '')}${(p.materials || []).map(m => row('Material', m.uri)).join('')}</div>`; }).join('') : showEmpty('📜', 'No Attestations', 'No in-toto attestations are attached to this image');
+                Modal.open(`Provenance of ${repo}:${tag}`, `<div class="manifest-viewer">${row('Digest', a.digest)}${body}</div>`);
+            } catch (e) { Toast.error(e.message); }
+        },
+        async renameRepository(regId, repo) {
+            const newRepo = prompt('New name for ' + repo, repo); if (!newRepo || newRepo === repo) return;
+            try {
+                let job = (await API.renameRepository(regId, repo, newRepo)).data;
+                Toast.info('Renaming ' + repo + '...');
+                while (job.status === 'running') { await new Promise(r => setTimeout(r, 1000)); job = (await API.getRenameJob(regId, job.id)).data; }
+                if (job.status === 'failed') { Toast.error(job.error); this.viewTags(regId, repo); return; }
+                Toast.success('Renamed to ' + newRepo); this.viewTags(regId, newRepo);
+            } catch (e) { Toast.error(e.message); }
+        },
+        async verifyTagSignatures(regId, repo) {
+            Toast.info('Verifying signatures...');
+            try {
+                const res = await API.verifyNotation(regId, repo);
+                const badges = { verified: 'badge-success', failed: 'badge-danger', unsigned: 'badge-warning', error: 'badge-danger' };
+                (res.data || []).forEach(v => {
+                    const item = [...document.querySelectorAll('#tags-list .tag-item')].find(el => el.dataset.tag === v.tag); if (!item) return;
+                    const details = v.error || v.signatures.map(s => (s.signer || s.digest) + ': ' + [...(s.failures || []), ...(s.warnings || [])].join('; ')).join('\n');
+                    item.querySelector('.notation-status').innerHTML = `<span class="badge ${badges[v.status] || 'badge-info'}" title="${escapeHtml((v.policy ? 'Policy ' + v.policy + ' (' + v.level + ')\n' : '') + details)}">✍️ ${escapeHtml(v.status.replace('_', ' '))}</span>`;
+                });
+            } catch (e) { Toast.error(e.message); }
+        },
+        async signImageTag(regId, repo, tag) {
+            try {
+                const keys = (await API.getSigningKeys()).data || []; if (!keys.length) { Toast.error('No signing keys; an admin can add one with POST /api/v1/signing-keys'); return; }
+                let key = keys[0];
+                if (keys.length > 1) { const name = prompt('Sign ' + repo + ':' + tag + ' with which key? (' + keys.map(k => k.name).join(', ') + ')', keys[0].name); if (!name) return; key = keys.find(k => k.name === name.trim()); if (!key) { Toast.error('Unknown key ' + name); return; } }
+                const r = await API.signImage(regId, repo, tag, key.id); Toast.success('Signed ' + truncateDigest(r.data.digest) + ' with ' + key.name);
+            } catch (e) { Toast.error(e.message); }
+        },
+        async pinImageTag(regId, repo, tag) { try { const r = await API.pinTag(regId, repo, tag); Toast.success('Pinned to ' + truncateDigest(r.data.digest)); } catch (e) { Toast.error(e.message); } },
         async deleteImageTag(regId, repo, tag) { if (!(await Confirm.show('Delete Tag', 'Delete ' + repo + ':' + tag + '?'))) return; try { await API.deleteTag(regId, repo, tag); Toast.success('Deleted!'); this.viewTags(regId, repo); } catch (e) { Toast.error(e.message); } },
 
         // Storage
@@ -1287,22 +1478,100 @@
             const t = typeof type === 'object' ? type.tab : type; const d = { type: t };
             if (t === 'local') d.local_path = document.getElementById('local-path').value;
             else if (t === 's3') { d.s3_endpoint = document.getElementById('s3-endpoint').value; d.s3_region = document.getElementById('s3-region').value; d.s3_bucket = document.getElementById('s3-bucket').value; d.s3_access_key = document.getElementById('s3-access-key').value; d.s3_secret_key = document.getElementById('s3-secret-key').value; d.s3_use_ssl = document.getElementById('s3-use-ssl').checked; }
+            else if (t === 'gcs') { d.gcs_bucket = document.getElementById('gcs-bucket').value.trim(); d.gcs_root_directory = document.getElementById('gcs-root-directory').value.trim(); d.gcs_credentials = document.getElementById('gcs-credentials').value.trim(); }
+            else if (t === 'swift') { d.swift_auth_url = document.getElementById('swift-auth-url').value.trim(); d.swift_region = document.getElementById('swift-region').value.trim(); d.swift_container = document.getElementById('swift-container').value.trim(); d.swift_username = document.getElementById('swift-username').value; d.swift_password = document.getElementById('swift-password').value; }
             else if (t === 'sftp') { d.sftp_host = document.getElementById('sftp-host').value; d.sftp_port = parseInt(document.getElementById('sftp-port').value) || 22; d.sftp_user = document.getElementById('sftp-user').value; d.sftp_password = document.getElementById('sftp-password').value; d.sftp_path = document.getElementById('sftp-path').value; d.sftp_private_key = document.getElementById('sftp-key').value; }
+            d.proxy_remote_url = document.getElementById('proxy-remote-url').value.trim(); d.proxy_username = document.getElementById('proxy-username').value; d.proxy_password = document.getElementById('proxy-password').value;
+            d.redis_cache = document.getElementById('redis-cache').checked; d.redis_addr = document.getElementById('redis-addr').value.trim(); d.redis_password = document.getElementById('redis-password').value; d.redis_db = parseInt(document.getElementById('redis-db').value) || 0;
+            d.config_overrides = document.getElementById('config-overrides').value; d.registry_image = document.getElementById('registry-image').value.trim();
             return d;
         },
+        async addRegistryUser() {
+            try { const r = await API.createRegistryUser({ username: document.getElementById('registry-user-name').value.trim(), password: document.getElementById('registry-user-password').value }); Toast.success(r.message || 'User added'); this.navigate('storage'); } catch (e) { Toast.error(e.message); }
+        },
+        async editRegistryPermissions(id) {
+            try {
+                const u = ((await API.getRegistryUsers()).data || []).find(x => x.id === id); if (!u) return;
+                const lines = u.permissions.map(p => p.repository + ' ' + p.actions).join('\n');
+                Modal.open('Permissions of ' + u.username, `<form onsubmit="event.preventDefault();window.app.saveRegistryPermissions(${id})"><div class="form-group"><label class="form-label">One repository per line with its actions</label><textarea id="registry-permissions" class="form-textarea" rows="8" placeholder="team/* pull,push&#10;base/alpine pull&#10;* pull">${escapeHtml(lines)}</textarea><div class="form-hint">A name, a prefix ending in *, or * for all repositories. Actions: pull, push, delete.</div></div><button type="submit" class="btn btn-primary">Save</button></form>`);
+            } catch (e) { Toast.error(e.message); }
+        },
+        async saveRegistryPermissions(id) {
+            const perms = document.getElementById('registry-permissions').value.split('\n').map(l => l.trim()).filter(Boolean).map(l => { const m = l.match(/^(\S+)\s*(.*)$/); return { repository: m[1], actions: m[2].replace(/\s+/g, '') }; });
+            try { await API.updateRegistryPermissions(id, perms); Modal.close(); Toast.success('Permissions saved'); this.navigate('storage'); } catch (e) { Toast.error(e.message); }
+        },
+        async addRegistryInstance() {
+            const path = document.getElementById('instance-path').value.trim();
+            const d = { name: document.getElementById('instance-name').value.trim(), port: parseInt(document.getElementById('instance-port').value) || 0, username: document.getElementById('instance-username').value.trim(), password: document.getElementById('instance-password').value, storage: { type: 'local', local_path: path } };
+            try { const r = await API.createRegistryInstance(d); Toast.success(r.message || 'Registry added'); this.navigate('storage'); } catch (e) { Toast.error(e.message); }
+        },
+        async registryInstanceAction(id, action) { try { const r = await API.registryInstanceAction(id, action); Toast.success(r.message || 'Done'); this.navigate('storage'); } catch (e) { Toast.error(e.message); } },
+        async deleteRegistryInstance(id, name) { if (!(await Confirm.show('Remove Registry', 'Remove the registry ' + name + ' and its registry entry? Its data directory is kept.'))) return; try { const r = await API.deleteRegistryInstance(id); Toast.success(r.message || 'Removed'); this.navigate('storage'); } catch (e) { Toast.error(e.message); } },
+        async deleteRegistryUser(id, username) { if (!(await Confirm.show('Remove Registry User', 'Remove ' + username + '? Clients using this account can no longer push or pull.'))) return; try { const r = await API.deleteRegistryUser(id); Toast.success(r.message || 'Removed'); this.navigate('storage'); } catch (e) { Toast.error(e.message); } },
         async saveStorage() { try { const res = await API.saveStorageConfig(this._getStorageData()); Toast.success(res.message || 'Saved!'); } catch (e) { Toast.error(e.message); } },
+        async previewStorageConfig() {
+            try { const r = await API.previewRegistryConfig(this._getStorageData()); const problems = (r.data.problems || []).map(p => '<div style="color:var(--danger)">' + escapeHtml(p) + '</div>').join(''); Modal.open('Registry config.yml', problems + '<pre style="background:var(--bg-primary);padding:16px;border-radius:var(--radius-md);font-size:0.8rem;color:var(--text-secondary);max-height:500px;overflow:auto;white-space:pre-wrap">' + escapeHtml(r.data.config) + '</pre>'); } catch (e) { Toast.error(e.message); }
+        },
         async testStorage() { Toast.info('Testing...'); try { const r = await API.testStorageConnection(this._getStorageData()); r.data.status === 'warning' ? Toast.warning(r.data.message) : Toast.success(r.data.message); } catch (e) { Toast.error(e.message); } },
 
+        async globalSearch(q) {
+            const d = document.getElementById('global-search-results'); if (!d) return;
+            if (!q.trim()) { d.innerHTML = ''; return; }
+            try {
+                const hits = (await API.search(q)).data || [];
+                d.innerHTML = `<div class="card" style="margin-bottom:24px">${hits.length ? `<div class="image-list">${hits.map(h => `<div class="image-item" onclick="window.app.openSearchHit(${h.registry_id},'${escapeHtml(h.repository)}')"><div class="image-item-info"><div><div class="image-item-name">${escapeHtml(h.repository)}${h.tag ? ':' + escapeHtml(h.tag) : ''}</div><div class="image-item-meta">${escapeHtml(h.registry_name)}</div></div></div><div class="image-item-right"><span class="badge badge-info">${h.match}</span></div></div>`).join('')}</div>` : '<p style="color:var(--text-muted)">No matches in the search index.</p>'}</div>`;
+            } catch (e) { Toast.error(e.message); }
+        },
+        async openSearchHit(regId, repo) { this._selectedRegistry = regId; await this.navigate('images'); this.viewTags(regId, repo); },
+
+        async refreshDashboardStats() {
+            Toast.info('Refreshing statistics...');
+            try { await API.refreshDashboardStats(); if (this.currentPage === 'dashboard') this.navigate('dashboard'); } catch (e) { Toast.error(e.message); }
+        },
+
         // Embedded registry control
         async embeddedAction(action) {
             Toast.info(action === 'restart' ? 'Restarting...' : action === 'stop' ? 'Stopping...' : 'Starting...');
             try { const fn = { restart: API.restartRegistry, stop: API.stopRegistry, start: API.startRegistry }[action]; const r = await fn(); Toast.success(r.message || 'Done!'); setTimeout(() => this.navigate(this.currentPage), 1500); } catch (e) { Toast.error(e.message); }
         },
+        async upgradeEmbeddedRegistry() {
+            const image = prompt('Registry image to move to (empty: the newest build of the current image), e.g. registry:2.8.3 or ghcr.io/distribution/distribution:3', '');
+            if (image === null) return;
+            Toast.info('Pulling and upgrading...');
+            try { const r = await API.upgradeRegistry({ image: image.trim() }); Toast.success(`Registry now runs ${r.data.image}${r.data.version ? ' v' + r.data.version : ''}`); setTimeout(() => this.navigate(this.currentPage), 1500); } catch (e) { Toast.error(e.message); }
+        },
+        async editRegistrySettings() {
+            try {
+                const cur = (await API.getRegistrySettings()).data;
+                Modal.open('Registry Port & Container Name', `<form onsubmit="event.preventDefault();window.app.saveRegistrySettings()"><div class="form-row"><div class="form-group"><label class="form-label">Host Port</label><input type="number" id="registry-settings-port" class="form-input" value="${cur.port}" min="1" max="65535"></div><div class="form-group"><label class="form-label">Container Name</label><input type="text" id="registry-settings-name" class="form-input" value="${escapeHtml(cur.container_name)}"></div></div><div class="form-hint" style="margin-bottom:12px">A running registry is recreated under them; registry entries at its old URL follow it</div><button type="submit" class="btn btn-primary">Move Registry</button></form>`);
+            } catch (e) { Toast.error(e.message); }
+        },
+        async saveRegistrySettings() {
+            const d = { port: parseInt(document.getElementById('registry-settings-port').value) || 0, container_name: document.getElementById('registry-settings-name').value.trim() };
+            Toast.info('Moving the registry...');
+            try { const r = await API.updateRegistrySettings(d); Modal.close(); Toast.success(r.message || 'Moved'); setTimeout(() => this.navigate(this.currentPage), 1500); } catch (e) { Toast.error(e.message); }
+        },
+        async setMaintenance(enabled) {
+            let reason = '';
+            if (enabled) {
+                reason = prompt('Reason shown to users (optional), e.g. Garbage collection', '');
+                if (reason === null) return;
+            }
+            try { const r = await API.setMaintenance({ enabled, reason: reason.trim() }); Toast.success(r.message || 'Saved'); this.navigate(this.currentPage); } catch (e) { Toast.error(e.message); }
+        },
+        async checkQuota(id) {
+            Toast.info('Measuring usage...');
+            try { await API.checkQuota(id); this.navigate(this.currentPage); } catch (e) { Toast.error(e.message); }
+        },
         async showRegistryLogs() {
             try { const r = await API.getRegistryLogs(); Modal.open('Registry Logs', '<pre style="background:var(--bg-primary);padding:16px;border-radius:var(--radius-md);font-size:0.8rem;color:var(--text-secondary);max-height:500px;overflow:auto;white-space:pre-wrap;word-break:break-all">' + escapeHtml(r.data.logs || 'No logs') + '</pre>'); } catch (e) { Toast.error(e.message); }
         },
 
         // Vulnerability Report Methods
+        exportScanReport(format) {
+            const regId = document.getElementById('vuln-reg-select')?.value || this._selectedRegistry;
+            if (regId) window.open(`/api/v1/reports/scan?registry_id=${regId}&format=${format}`, '_blank');
+        },
         async loadVulnerabilities(regId) {
             this._selectedRegistry = regId;
             this._allVulns = [];